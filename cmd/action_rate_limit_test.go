@@ -0,0 +1,105 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimitedActionConfig is a configuration file with an action running once per hour, named [name]
+// since the runs are shared by every evaluation of the project
+func rateLimitedActionConfig(name string) string {
+	return strings.ReplaceAll(`
+actions:
+  - name: NAME
+    if: 'true'
+    rate_limit:
+      key: title
+      window: 1h
+    then:
+      - action: comment
+        message: hello
+`, "NAME", name)
+}
+
+func TestProcessMR_ActionRateLimit(t *testing.T) {
+	t.Parallel()
+
+	// evaluate runs [evaluations] evaluations of the Merge Request with [applyStep] and returns how often it was called
+	evaluate := func(t *testing.T, name string, dryRun bool, evaluations int, applyStep func(attempt int32) error) int32 {
+		t.Helper()
+
+		api := &testGitLab{
+			config:  func(string) string { return rateLimitedActionConfig(name) },
+			headSHA: func() string { return "abc123" },
+		}
+
+		ctx, gitlabClient := newTestGitLab(t, api)
+		ctx = state.WithDryRun(ctx, dryRun)
+
+		var attempts atomic.Int32
+
+		client := &testClient{
+			Client:      gitlabClient,
+			evalContext: &testEvalContext{Title: "alice"},
+			applyStep: func(context.Context, scm.ActionStep) error {
+				return applyStep(attempts.Add(1))
+			},
+		}
+
+		for range evaluations {
+			_ = cmd.ProcessMR(ctx, client, nil, nil)
+		}
+
+		return attempts.Load()
+	}
+
+	t.Run("applied actions use up the rate limit", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, int32(1), evaluate(t, "rate limit applied", false, 3, func(int32) error { return nil }))
+	})
+
+	t.Run("failing actions don't use up the rate limit", func(t *testing.T) {
+		t.Parallel()
+
+		attempts := evaluate(t, "rate limit failing", false, 3, func(attempt int32) error {
+			if attempt == 1 {
+				return errors.New("the webhook timed out")
+			}
+
+			return nil
+		})
+
+		// The failed attempt, and the attempt that was applied
+		require.Equal(t, int32(2), attempts)
+	})
+
+	t.Run("skipped actions don't use up the rate limit", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, int32(3), evaluate(t, "rate limit skipped", false, 3, func(int32) error { return scm.ErrSkipAction }))
+	})
+
+	t.Run("deferred actions don't use up the rate limit", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, int32(3), evaluate(t, "rate limit deferred", false, 3, func(int32) error {
+			return &scm.DeferredError{Reason: "the head pipeline has not finished yet", Delay: time.Hour, MaxDeferrals: 5}
+		}))
+	})
+
+	t.Run("dry runs don't use up the rate limit", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, int32(3), evaluate(t, "rate limit dry run", true, 3, func(int32) error { return nil }))
+	})
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type auditLogKey struct{}
+
+// AuditLog appends an [AuditRecord] for every evaluation of a Merge Request to a JSONL file (one JSON record per line),
+// building an auditable history of what scm-engine decided. Combined with '--dry-run', the history shows what
+// would have happened, so the rules can be reviewed before enforcing them.
+//
+// Records are appended, so the file keeps the history of earlier runs, and written before the evaluation
+// returns, so they're never lost when the process exits
+type AuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenAuditLog opens (or creates) the audit log file at [path] for appending
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the audit log: %w", err)
+	}
+
+	return &AuditLog{file: file}, nil
+}
+
+func withAuditLog(ctx context.Context, log *AuditLog) context.Context {
+	return context.WithValue(ctx, auditLogKey{}, log)
+}
+
+// auditLogFromContext returns the audit log, or nil if none is configured
+func auditLogFromContext(ctx context.Context) *AuditLog {
+	log, _ := ctx.Value(auditLogKey{}).(*AuditLog)
+
+	return log
+}
+
+// Write appends [record] as a single line; concurrent evaluations never interleave their records
+func (l *AuditLog) Write(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not encode audit record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("could not write the audit log: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the audit log file
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
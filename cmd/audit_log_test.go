@@ -0,0 +1,116 @@
+package cmd_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditLog(t *testing.T, path string) []cmd.AuditRecord {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	var records []cmd.AuditRecord
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record cmd.AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+
+		records = append(records, record)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	return records
+}
+
+func TestAuditLog_Write(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	startedAt := time.Date(2024, 1, 2, 3, 4, 3, 0, time.UTC)
+
+	// Every run appends its records, keeping the records of earlier runs
+	for run, outcome := range []string{cmd.AuditOutcomeSuccess, cmd.AuditOutcomeFailed} {
+		log, err := cmd.OpenAuditLog(path)
+		require.NoError(t, err)
+
+		require.NoError(t, log.Write(cmd.AuditRecord{
+			RequestID: fmt.Sprintf("run-%d", run),
+			StartedAt: startedAt,
+			Timestamp: startedAt.Add(2 * time.Second),
+			Outcome:   outcome,
+			MergeRequestReport: cmd.MergeRequestReport{
+				Project:        "group/project",
+				MergeRequestID: "1",
+				DryRun:         true,
+				Labels:         cmd.LabelReport{Add: []string{"bug"}, Remove: []string{}, Current: []string{"bug"}},
+				Actions:        []cmd.ActionReport{{Name: "close", Outcome: "applied"}},
+			},
+		}))
+
+		require.NoError(t, log.Close())
+	}
+
+	records := readAuditLog(t, path)
+	require.Len(t, records, 2)
+
+	require.Equal(t, "run-0", records[0].RequestID)
+	require.Equal(t, cmd.AuditOutcomeSuccess, records[0].Outcome)
+	require.Equal(t, startedAt, records[0].StartedAt)
+	require.Equal(t, startedAt.Add(2*time.Second), records[0].Timestamp)
+	require.True(t, records[0].DryRun)
+	require.Equal(t, []string{"bug"}, records[0].Labels.Add)
+	require.Equal(t, []cmd.ActionReport{{Name: "close", Outcome: "applied"}}, records[0].Actions)
+
+	require.Equal(t, "run-1", records[1].RequestID)
+	require.Equal(t, cmd.AuditOutcomeFailed, records[1].Outcome)
+}
+
+func TestAuditLog_ConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	log, err := cmd.OpenAuditLog(path)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	// Concurrent evaluations (for example in the server) each write a whole record per line
+	for i := range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			require.NoError(t, log.Write(cmd.AuditRecord{RequestID: fmt.Sprintf("request-%d", i), Outcome: cmd.AuditOutcomeSuccess}))
+		}()
+	}
+
+	wg.Wait()
+	require.NoError(t, log.Close())
+
+	records := readAuditLog(t, path)
+	require.Len(t, records, 20)
+}
+
+func TestOpenAuditLog_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := cmd.OpenAuditLog(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+	require.ErrorContains(t, err, "could not open the audit log")
+}
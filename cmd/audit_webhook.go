@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// Number of audit records that could not be delivered, after all retries
+var auditWebhookFailures = expvar.NewInt("audit_webhook_failures")
+
+// AuditSignatureHeader carries the HMAC-SHA256 signature of the audit record (example: 'sha256=<hex>'), if a secret is configured
+const AuditSignatureHeader = "X-Scm-Engine-Signature"
+
+// Outcomes of an evaluation in [AuditRecord]
+const (
+	AuditOutcomeSuccess  = "success"
+	AuditOutcomeDeferred = "deferred"
+	AuditOutcomeFailed   = "failed"
+)
+
+// AuditRecord is the JSON payload POSTed to the audit webhook after each evaluation of a Merge Request
+type AuditRecord struct {
+	// The ID of the webhook request that triggered the evaluation, empty for periodic evaluations
+	RequestID string `json:"request_id,omitempty"`
+
+	// When the evaluation started
+	StartedAt time.Time `json:"started_at"`
+
+	// When the evaluation finished
+	Timestamp time.Time `json:"timestamp"`
+
+	// One of 'success', 'deferred' or 'failed'
+	Outcome string `json:"outcome"`
+
+	MergeRequestReport
+}
+
+type auditWebhookKey struct{}
+
+// AuditWebhook delivers an [AuditRecord] for every evaluation to an external HTTP endpoint (e.g. a SIEM).
+//
+// Records are delivered in the background and retried with backoff, so a slow or failing endpoint never blocks evaluations.
+type AuditWebhook struct {
+	// The URL records are POSTed to
+	URL string
+
+	// Extra headers sent with every request (e.g. 'Authorization')
+	Headers http.Header
+
+	// (Optional) Secret for signing records, see [AuditSignatureHeader]
+	Secret string
+
+	// Number of delivery attempts before giving up on a record
+	MaxAttempts int
+
+	// Delay before the first retry, doubled for every following retry
+	Backoff time.Duration
+
+	client  *http.Client
+	pending sync.WaitGroup
+}
+
+// NewAuditWebhook creates an [AuditWebhook] for [url], with [headers] in the format 'Name: value'
+func NewAuditWebhook(url string, headers []string, secret string) (*AuditWebhook, error) {
+	webhook := &AuditWebhook{
+		URL:         url,
+		Headers:     http.Header{},
+		Secret:      secret,
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || len(strings.TrimSpace(name)) == 0 {
+			return nil, fmt.Errorf("invalid audit webhook header %q, must be in the format 'Name: value'", header)
+		}
+
+		webhook.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return webhook, nil
+}
+
+func withAuditWebhook(ctx context.Context, webhook *AuditWebhook) context.Context {
+	return context.WithValue(ctx, auditWebhookKey{}, webhook)
+}
+
+// auditWebhookFromContext returns the audit webhook, or nil if none is configured
+func auditWebhookFromContext(ctx context.Context) *AuditWebhook {
+	webhook, _ := ctx.Value(auditWebhookKey{}).(*AuditWebhook)
+
+	return webhook
+}
+
+// newAuditRecord creates the audit record for an evaluation of the Merge Request in [report], that started at [startedAt] and ended with [err]
+func newAuditRecord(ctx context.Context, report *MergeRequestReport, startedAt time.Time, err error) AuditRecord {
+	record := AuditRecord{
+		RequestID:          state.RequestID(ctx),
+		StartedAt:          startedAt,
+		Timestamp:          time.Now().UTC(),
+		Outcome:            AuditOutcomeSuccess,
+		MergeRequestReport: *report,
+	}
+
+	var deferred *scm.DeferredError
+
+	switch {
+	case errors.As(err, &deferred):
+		record.Outcome = AuditOutcomeDeferred
+
+	case err != nil:
+		record.Outcome = AuditOutcomeFailed
+	}
+
+	return record
+}
+
+// Send delivers [record] in the background, see [AuditWebhook.Wait]
+func (w *AuditWebhook) Send(ctx context.Context, record AuditRecord) {
+	body, err := json.Marshal(record)
+	if err != nil {
+		slogctx.Error(ctx, "Could not encode audit record", slog.Any("error", err))
+
+		return
+	}
+
+	// The delivery must outlive the (webhook request) context of the evaluation
+	ctx = context.WithoutCancel(ctx)
+
+	w.pending.Add(1)
+
+	go func() {
+		defer w.pending.Done()
+
+		if err := w.deliver(ctx, body); err != nil {
+			auditWebhookFailures.Add(1)
+
+			slogctx.Error(ctx, "Could not deliver audit record", slog.Any("error", err))
+		}
+	}()
+}
+
+// Wait blocks until all records have been delivered (or given up on)
+func (w *AuditWebhook) Wait() {
+	w.pending.Wait()
+}
+
+func (w *AuditWebhook) deliver(ctx context.Context, body []byte) error {
+	backoff := w.Backoff
+
+	var err error
+
+	for attempt := 1; attempt <= w.MaxAttempts; attempt++ {
+		if err = w.post(ctx, body); err == nil {
+			return nil
+		}
+
+		if attempt == w.MaxAttempts {
+			break
+		}
+
+		slogctx.Warn(ctx, "Audit record delivery failed, retrying", slog.Any("error", err), slog.Int("attempt", attempt), slog.Duration("backoff", backoff))
+
+		time.Sleep(backoff)
+
+		backoff = min(backoff*2, 30*time.Second)
+	}
+
+	return fmt.Errorf("gave up after %d attempts: %w", w.MaxAttempts, err)
+}
+
+func (w *AuditWebhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header = w.Headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(w.Secret) > 0 {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+
+		req.Header.Set(AuditSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
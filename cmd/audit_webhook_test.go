@@ -0,0 +1,92 @@
+package cmd_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditWebhook_Send(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		attempts int
+		bodies   []string
+		headers  []http.Header
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+
+		attempts++
+		bodies = append(bodies, string(body))
+		headers = append(headers, r.Header.Clone())
+
+		// Fail the first delivery, so it's retried
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	webhook, err := cmd.NewAuditWebhook(server.URL, []string{"Authorization: Bearer secret-token"}, "hmac-secret")
+	require.NoError(t, err)
+
+	webhook.Backoff = time.Millisecond
+
+	webhook.Send(context.Background(), cmd.AuditRecord{
+		RequestID: "abc",
+		StartedAt: time.Date(2024, 1, 2, 3, 4, 3, 0, time.UTC),
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Outcome:   cmd.AuditOutcomeSuccess,
+		MergeRequestReport: cmd.MergeRequestReport{
+			Project:        "group/project",
+			MergeRequestID: "1",
+			CommitSHA:      "abc123",
+			Labels:         cmd.LabelReport{Add: []string{"bug"}, Remove: []string{}, Current: []string{"bug"}},
+			Actions: []cmd.ActionReport{
+				{Name: "Close stale MR", Outcome: cmd.ActionOutcomeSkipped, Reason: "action skipped: the head pipeline failed"},
+			},
+		},
+	})
+	webhook.Wait()
+
+	want := `{"request_id":"abc","started_at":"2024-01-02T03:04:03Z","timestamp":"2024-01-02T03:04:05Z","outcome":"success","project":"group/project","merge_request_id":"1","commit_sha":"abc123","dry_run":false,"labels":{"add":["bug"],"remove":[],"current":["bug"]},"actions":[{"name":"Close stale MR","outcome":"skipped","reason":"action skipped: the head pipeline failed"}]}`
+
+	mac := hmac.New(sha256.New, []byte("hmac-secret"))
+	mac.Write([]byte(want))
+
+	require.Equal(t, 2, attempts)
+	require.Equal(t, []string{want, want}, bodies)
+
+	for _, header := range headers {
+		require.Equal(t, "application/json", header.Get("Content-Type"))
+		require.Equal(t, "Bearer secret-token", header.Get("Authorization"))
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), header.Get(cmd.AuditSignatureHeader))
+	}
+}
+
+func TestNewAuditWebhook_InvalidHeader(t *testing.T) {
+	t.Parallel()
+
+	_, err := cmd.NewAuditWebhook("https://siem.example.com/", []string{"Authorization"}, "")
+	require.EqualError(t, err, `invalid audit webhook header "Authorization", must be in the format 'Name: value'`)
+}
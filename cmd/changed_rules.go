@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type previousConfigKey struct{}
+
+// withPreviousConfig sets the previous configuration file from [FlagChangedRulesSince]
+func withPreviousConfig(ctx context.Context, previous *config.Config) context.Context {
+	return context.WithValue(ctx, previousConfigKey{}, previous)
+}
+
+// previousConfigFromContext returns the previous configuration file, or nil if all rules should be evaluated
+func previousConfigFromContext(ctx context.Context) *config.Config {
+	previous, _ := ctx.Value(previousConfigKey{}).(*config.Config)
+
+	return previous
+}
+
+// withChangedRules limits the evaluation to the rules in [cfg] that differ from the [previous] configuration file.
+//
+// The [previous] configuration file is resolved the same way as [cfg] first, so only changes to the rules
+// themselves are evaluated. If that fails, all rules are evaluated
+func withChangedRules(ctx context.Context, previous, cfg *config.Config) context.Context {
+	previous = previous.WithGlobalConfig(ctx, globalConfigFromContext(ctx))
+
+	previous, err := previous.WithProfile(ctx, state.Profile(ctx))
+	if err != nil {
+		slogctx.Warn(ctx, "Could not resolve the previous configuration file, evaluating all rules", slog.Any("error", err))
+
+		return ctx
+	}
+
+	diff := config.DiffConfigs(previous, cfg)
+
+	slogctx.Info(ctx, "Only evaluating the rules that changed since the previous configuration file",
+		slog.Any("labels", append(diff.Labels.Added, diff.Labels.Modified...)),
+		slog.Any("actions", append(diff.Actions.Added, diff.Actions.Modified...)),
+	)
+
+	return config.WithChangedRules(ctx, diff)
+}
@@ -1,22 +1,96 @@
 package cmd
 
 const (
+	FlagAllOpen                                         = "all-open"
+	FlagAllOpenAuthor                                   = "all-open-author"
+	FlagAllOpenLabel                                    = "all-open-label"
+	FlagAllOpenMilestone                                = "all-open-milestone"
+	FlagAllOpenSearch                                   = "all-open-search"
+	FlagAllowPartialData                                = "allow-partial-data"
+	FlagAPIHeader                                       = "api-header"
+	FlagAPIPageSize                                     = "api-page-size"
 	FlagAPIToken                                        = "api-token"
+	FlagAPITokenMapping                                 = "api-token-mapping"
+	FlagAuditLog                                        = "audit-log"
+	FlagAuditWebhookHeader                              = "audit-webhook-header"
+	FlagAuditWebhookSecret                              = "audit-webhook-secret"
+	FlagAuditWebhookURL                                 = "audit-webhook-url"
+	FlagCABundle                                        = "ca-bundle"
+	FlagCacheDir                                        = "cache-dir"
+	FlagCacheTTL                                        = "cache-ttl"
+	FlagChangedRulesSince                               = "changed-rules-since"
+	FlagCommentRateLimit                                = "comment-rate-limit"
+	FlagCommentRateLimitWindow                          = "comment-rate-limit-window"
 	FlagCommitSHA                                       = "commit"
+	FlagConcurrency                                     = "concurrency"
+	FlagConfigChangePolicy                              = "config-change-policy"
 	FlagConfigFile                                      = "config"
+	FlagDebounce                                        = "debounce"
+	FlagDrainTimeout                                    = "drain-timeout"
 	FlagDryRun                                          = "dry-run"
+	FlagErrorCommentTemplate                            = "error-comment-template"
+	FlagEvaluateArchivedProjects                        = "evaluate-archived-projects"
+	FlagFailOn                                          = "fail-on"
+	FlagForce                                           = "force"
+	FlagGitHubAPIToken                                  = "github-api-token"
+	FlagGitHubWebhookPath                               = "github-webhook-path"
+	FlagGitHubWebhookSecret                             = "github-webhook-secret"
+	FlagGlobalConfigFile                                = "global-config-file"
+	FlagGlobalConfigProject                             = "global-config-project"
+	FlagGlobalConfigRef                                 = "global-config-ref"
+	FlagJSON                                            = "json"
+	FlagLabelExpirySweepInterval                        = "label-expiry-sweep-interval"
+	FlagLabelExpirySweepLabels                          = "label-expiry-sweep-labels"
 	FlagMergeRequestID                                  = "id"
+	FlagMaxActionSteps                                  = "max-action-steps"
+	FlagMergeRequestURL                                 = "mr"
+	FlagMissingCommitSHABehavior                        = "missing-commit-sha-behavior"
+	FlagMissingConfigBehavior                           = "missing-config-behavior"
+	FlagNoCache                                         = "no-cache"
+	FlagOnlyTags                                        = "only-tags"
+	FlagOutput                                          = "output"
+	FlagProfile                                         = "profile"
+	FlagProfileCPU                                      = "profile-cpu"
+	FlagProfileMem                                      = "profile-mem"
+	FlagPostEvaluationHook                              = "post-evaluation-hook"
+	FlagPostEvaluationHookTimeout                       = "post-evaluation-hook-timeout"
+	FlagPprofListen                                     = "pprof-listen"
+	FlagProvider                                        = "provider"
+	FlagProxyURL                                        = "proxy-url"
+	FlagQuiet                                           = "quiet"
+	FlagRateLimit                                       = "rate-limit"
+	FlagReadOnly                                        = "read-only"
+	FlagReconcileReport                                 = "reconcile-report"
+	FlagReplayFailed                                    = "replay-failed"
+	FlagRuleMetrics                                     = "rule-metrics"
+	FlagRuleMetricsMaxRules                             = "rule-metrics-max-rules"
 	FlagSCMBaseURL                                      = "base-url"
+	FlagSCMGroup                                        = "group"
 	FlagSCMProject                                      = "project"
+	FlagSchedule                                        = "schedule"
+	FlagScheduleProjects                                = "schedule-projects"
+	FlagSkipSelfTest                                    = "skip-selftest"
+	FlagSkipTags                                        = "skip-tags"
 	FlagServerListenHost                                = "listen-host"
 	FlagServerListenPort                                = "listen-port"
 	FlagServerTimeout                                   = "timeout"
+	FlagStatusFailureTemplate                           = "status-failure-template"
+	FlagStatusNameTemplate                              = "status-name-template"
+	FlagStatusRunningTemplate                           = "status-running-template"
+	FlagStatusSuccessTemplate                           = "status-success-template"
+	FlagSystemHookInstallConfig                         = "system-hook-install-config"
+	FlagSystemHookPath                                  = "system-hook-path"
+	FlagSystemHookSecret                                = "system-hook-secret"
+	FlagTriggerOnChanges                                = "trigger-on-changes"
 	FlagUpdatePipeline                                  = "update-pipeline"
 	FlagUpdatePipelineURL                               = "update-pipeline-url"
+	FlagUserAgent                                       = "user-agent"
 	FlagPeriodicEvaluationInterval                      = "periodic-evaluation-interval"
 	FlagPeriodicEvaluationIgnoreMergeRequestsWithLabel  = "periodic-evaluation-ignore-mr-labels"
 	FlagPeriodicEvaluationRequireMergeRequestsWithLabel = "periodic-evaluation-require-mr-labels"
 	FlagPeriodicEvaluationOnlyProjectsWithTopics        = "periodic-evaluation-project-topics"
 	FlagPeriodicEvaluationOnlyProjectsWithMembership    = "periodic-evaluation-only-project-membership"
+	FlagWebhookLogNewFields                             = "webhook-log-new-fields"
+	FlagWebhookPath                                     = "webhook-path"
 	FlagWebhookSecret                                   = "webhook-secret"
 )
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type debouncerKey struct{}
+
+// debouncer delays the evaluation of webhook events, so rapid successive events for the same Merge Request
+// (like several pushes in a row) coalesce into a single evaluation of the latest event
+type debouncer struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*debouncedEvaluation
+	running sync.WaitGroup
+	flushed bool
+}
+
+// debouncedEvaluation is an evaluation waiting for its delay to pass
+type debouncedEvaluation struct {
+	ctx      context.Context
+	timer    *time.Timer
+	evaluate func(ctx context.Context) error
+}
+
+// WithDebounce delays the evaluation of webhook events by [delay], restarting the delay when another event
+// for the same Merge Request arrives in the meantime. A zero [delay] evaluates webhook events right away
+func WithDebounce(ctx context.Context, delay time.Duration) context.Context {
+	if delay <= 0 {
+		return ctx
+	}
+
+	ctx = slogctx.With(ctx, slog.Duration("debounce", delay))
+
+	return context.WithValue(ctx, debouncerKey{}, &debouncer{delay: delay, pending: map[string]*debouncedEvaluation{}})
+}
+
+func debouncerFromContext(ctx context.Context) *debouncer {
+	d, _ := ctx.Value(debouncerKey{}).(*debouncer)
+
+	return d
+}
+
+func debounceKey(ctx context.Context) string {
+	return state.Provider(ctx) + "/" + state.ProjectID(ctx) + "/" + state.MergeRequestID(ctx)
+}
+
+// schedule runs [evaluate] once the delay passed without another event for the Merge Request,
+// replacing the evaluation scheduled by an earlier event (if any)
+func (d *debouncer) schedule(ctx context.Context, evaluate func(ctx context.Context) error) {
+	key := debounceKey(ctx)
+
+	// The original context (e.g. the webhook request) is done by the time we run
+	ctx = context.WithoutCancel(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Shutting down, so there is no time to wait for more events
+	if d.flushed {
+		d.start(&debouncedEvaluation{ctx: ctx, evaluate: evaluate})
+
+		return
+	}
+
+	if previous, ok := d.pending[key]; ok && previous.timer.Stop() {
+		slogctx.Info(ctx, "Replacing the debounced evaluation of an earlier event")
+	}
+
+	evaluation := &debouncedEvaluation{ctx: ctx, evaluate: evaluate}
+
+	evaluation.timer = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		// Replaced by a later event, or already started by [debouncer.flush]
+		if d.pending[key] != evaluation {
+			return
+		}
+
+		delete(d.pending, key)
+		d.start(evaluation)
+	})
+
+	d.pending[key] = evaluation
+}
+
+// start runs the [evaluation] in the background, tracked by [debouncer.flush]. [d.mu] must be held
+func (d *debouncer) start(evaluation *debouncedEvaluation) {
+	d.running.Add(1)
+
+	go func() {
+		defer d.running.Done()
+		defer recoverEvaluation(evaluation.ctx)
+
+		if err := evaluation.evaluate(evaluation.ctx); err != nil {
+			slogctx.Error(evaluation.ctx, "Debounced Merge Request evaluation failed", slog.Any("error", err))
+		}
+	}()
+}
+
+// flush starts the pending evaluations right away rather than waiting for their delay, and waits for all
+// running evaluations to complete, so no event is lost when the server is stopped
+func (d *debouncer) flush(ctx context.Context) {
+	d.mu.Lock()
+
+	d.flushed = true
+
+	if len(d.pending) > 0 {
+		slogctx.Info(ctx, "Evaluating debounced events before shutting down", slog.Int("pending", len(d.pending)))
+	}
+
+	for key, evaluation := range d.pending {
+		evaluation.timer.Stop()
+		delete(d.pending, key)
+
+		d.start(evaluation)
+	}
+
+	d.mu.Unlock()
+
+	d.running.Wait()
+}
+
+// DrainScheduledEvaluations is called when the server is stopped: it evaluates the debounced events right away
+// (see [WithDebounce]), drops the re-queued evaluations (see [WithRequeuer]), and waits for the running ones
+func DrainScheduledEvaluations(ctx context.Context) {
+	if d := debouncerFromContext(ctx); d != nil {
+		d.flush(ctx)
+	}
+
+	if r := requeuerFromContext(ctx); r != nil {
+		r.stop()
+	}
+}
+
+// evaluateWebhookEvent runs [evaluate] for the webhook event and writes the response, or (with [WithDebounce])
+// schedules it and responds right away, since the outcome isn't known yet
+func evaluateWebhookEvent(ctx context.Context, w http.ResponseWriter, evaluate func(ctx context.Context) error) {
+	if d := debouncerFromContext(ctx); d != nil {
+		d.schedule(ctx, evaluate)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK - scheduled"))
+
+		return
+	}
+
+	if err := evaluate(ctx); err != nil {
+		errHandler(ctx, w, http.StatusOK, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
@@ -0,0 +1,130 @@
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_Debounce(t *testing.T) {
+	t.Parallel()
+
+	const delay = 100 * time.Millisecond
+
+	type fixture struct {
+		ctx         context.Context
+		send        func(mergeRequest int, sha string) *httptest.ResponseRecorder
+		evaluations func() []string
+	}
+
+	setup := func(t *testing.T, delay time.Duration) fixture {
+		t.Helper()
+
+		var (
+			mu          sync.Mutex
+			evaluations []string
+		)
+
+		// Every evaluation starts by reading the configuration file, pinned to the commit of the event
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, "/repository/files/") {
+				mu.Lock()
+				evaluations = append(evaluations, r.URL.Query().Get("ref"))
+				mu.Unlock()
+			}
+
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Not Found"}`))
+		}))
+		t.Cleanup(server.Close)
+
+		ctx := context.Background()
+		ctx = state.WithProvider(ctx, "gitlab")
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+		ctx = cmd.WithDebounce(ctx, delay)
+
+		handler, err := cmd.GitLabWebhookHandler(ctx, "")
+		require.NoError(t, err)
+
+		return fixture{
+			ctx: ctx,
+			send: func(mergeRequest int, sha string) *httptest.ResponseRecorder {
+				body := fmt.Sprintf(`{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/scm-engine", "archived": false}, "object_attributes": {"iid": %d, "action": "update", "last_commit": {"id": %q}}}`, mergeRequest, sha)
+
+				req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+				req.Header.Set("Content-Type", "application/json")
+
+				recorder := httptest.NewRecorder()
+				handler(recorder, req)
+
+				return recorder
+			},
+			evaluations: func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+
+				return append([]string(nil), evaluations...)
+			},
+		}
+	}
+
+	t.Run("rapid events for the same Merge Request coalesce into one evaluation of the latest event", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t, delay)
+
+		for _, sha := range []string{"aaa", "bbb", "ccc"} {
+			recorder := f.send(1, sha)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, "OK - scheduled", recorder.Body.String())
+		}
+
+		// Nothing is evaluated until the delay passed
+		require.Empty(t, f.evaluations())
+
+		require.Eventually(t, func() bool { return len(f.evaluations()) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+		// Wait past another delay, to be sure the earlier events were dropped rather than delayed
+		time.Sleep(2 * delay)
+
+		require.Equal(t, []string{"ccc"}, f.evaluations())
+	})
+
+	t.Run("events for different Merge Requests are evaluated separately", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t, delay)
+
+		f.send(1, "aaa")
+		f.send(2, "bbb")
+
+		require.Eventually(t, func() bool { return len(f.evaluations()) == 2 }, 5*time.Second, 10*time.Millisecond)
+		require.ElementsMatch(t, []string{"aaa", "bbb"}, f.evaluations())
+	})
+	t.Run("pending evaluations run right away when the server is stopped", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t, time.Hour)
+
+		f.send(1, "aaa")
+		f.send(2, "bbb")
+
+		require.Empty(t, f.evaluations())
+
+		// Waits for the flushed evaluations to complete
+		cmd.DrainScheduledEvaluations(f.ctx)
+
+		require.ElementsMatch(t, []string{"aaa", "bbb"}, f.evaluations())
+	})
+}
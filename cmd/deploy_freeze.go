@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+)
+
+// deployFreezeLister is implemented by clients that can read the deploy freeze periods of the project
+type deployFreezeLister interface {
+	DeployFreezes(ctx context.Context) (stdlib.DeployFreezes, error)
+}
+
+// resolveDeployFreezes returns the configured deploy freeze periods, and the periods of the project
+// if 'deploy_freeze.gitlab' is on, see [config.DeployFreeze]
+func resolveDeployFreezes(ctx context.Context, client scm.Client, settings *config.DeployFreeze) (stdlib.DeployFreezes, error) {
+	freezes, err := settings.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.UseGitLab() {
+		return freezes, nil
+	}
+
+	lister, ok := client.(deployFreezeLister)
+	if !ok {
+		return nil, errors.New("deploy_freeze: 'gitlab' is only supported on GitLab")
+	}
+
+	projectFreezes, err := lister.DeployFreezes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(freezes, projectFreezes...), nil
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// Outcomes of an action in [ActionReport]
+const (
+	ActionOutcomeApplied  = "applied"
+	ActionOutcomeDeferred = "deferred"
+	ActionOutcomeSkipped  = "skipped"
+	ActionOutcomeFailed   = "failed"
+)
+
+// EvaluationReport is the machine-readable outcome of an evaluation, printed by 'evaluate --output json'.
+//
+// The JSON shape is stable: fields are only ever added, never renamed or removed.
+type EvaluationReport struct {
+	// The evaluated Merge Requests, sorted by project and Merge Request ID
+	MergeRequests []*MergeRequestReport `json:"merge_requests"`
+
+	mu sync.Mutex
+}
+
+// MergeRequestReport is the outcome of evaluating a single Merge Request
+type MergeRequestReport struct {
+	// Full path of the project (example: 'gitlab-org/gitlab')
+	Project string `json:"project"`
+
+	// The Merge Request ID (IID) within the project
+	MergeRequestID string `json:"merge_request_id"`
+
+	// The commit the evaluation was pinned to
+	CommitSHA string `json:"commit_sha"`
+
+	// Wether the changes were only logged, and not applied (see --dry-run)
+	DryRun bool `json:"dry_run"`
+
+	// The label changes compared to the labels already on the Merge Request
+	Labels LabelReport `json:"labels"`
+
+	// The actions that evaluated positively, in the order they were applied
+	Actions []ActionReport `json:"actions"`
+
+	// The evaluation error, empty if the evaluation succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// LabelReport is the label changes made to a Merge Request
+type LabelReport struct {
+	// Labels added to the Merge Request
+	Add []string `json:"add"`
+
+	// Labels removed from the Merge Request
+	Remove []string `json:"remove"`
+
+	// Labels on the Merge Request after the evaluation
+	Current []string `json:"current"`
+}
+
+// ActionReport is the outcome of a single action
+type ActionReport struct {
+	// The name of the action
+	Name string `json:"name" expr:"name"`
+
+	// One of 'applied', 'deferred', 'skipped' or 'failed'
+	Outcome string `json:"outcome" expr:"outcome"`
+
+	// Why the action was deferred, skipped or failed
+	Reason string `json:"reason,omitempty" expr:"reason"`
+}
+
+type reportContextKey uint
+
+const (
+	evaluationReportKey reportContextKey = iota
+	mergeRequestReportKey
+)
+
+// withEvaluationReport returns a context that records the outcome of every evaluated Merge Request into the returned [EvaluationReport]
+func withEvaluationReport(ctx context.Context) (context.Context, *EvaluationReport) {
+	report := &EvaluationReport{MergeRequests: []*MergeRequestReport{}}
+
+	return context.WithValue(ctx, evaluationReportKey, report), report
+}
+
+// withMergeRequestReport adds a report for the current Merge Request, used by the [EvaluationReport] (if the context has one),
+// the audit webhook (see [AuditWebhook]) and the report comment (see [config.ReportComment])
+func withMergeRequestReport(ctx context.Context) (context.Context, *MergeRequestReport) {
+	report, ok := ctx.Value(evaluationReportKey).(*EvaluationReport)
+
+	mergeRequest := &MergeRequestReport{
+		Project:        state.ProjectID(ctx),
+		MergeRequestID: state.MergeRequestID(ctx),
+	}
+
+	if ok {
+		report.mu.Lock()
+		report.MergeRequests = append(report.MergeRequests, mergeRequest)
+		report.mu.Unlock()
+	}
+
+	return context.WithValue(ctx, mergeRequestReportKey, mergeRequest), mergeRequest
+}
+
+// mergeRequestReportFromContext returns the report for the current Merge Request, or nil if there is none
+func mergeRequestReportFromContext(ctx context.Context) *MergeRequestReport {
+	report, _ := ctx.Value(mergeRequestReportKey).(*MergeRequestReport)
+
+	return report
+}
+
+// recordAction records the outcome of an action, if the context has a [MergeRequestReport]
+func recordAction(ctx context.Context, name, outcome string, reason error) {
+	report := mergeRequestReportFromContext(ctx)
+	if report == nil {
+		return
+	}
+
+	entry := ActionReport{Name: name, Outcome: outcome}
+	if reason != nil {
+		entry.Reason = reason.Error()
+	}
+
+	report.Actions = append(report.Actions, entry)
+}
+
+// reset clears the outcome of a previous evaluation attempt, and pins the report to the current commit
+func (r *MergeRequestReport) reset(ctx context.Context) {
+	if r == nil {
+		return
+	}
+
+	r.CommitSHA = state.CommitSHA(ctx)
+	r.DryRun = false
+	r.Labels = LabelReport{Add: []string{}, Remove: []string{}, Current: []string{}}
+	r.Actions = []ActionReport{}
+	r.Error = ""
+}
+
+func (r *MergeRequestReport) setError(err error) {
+	if r == nil || err == nil {
+		return
+	}
+
+	r.Error = err.Error()
+}
+
+// setLabels records the label changes in [update], compared to the [current] labels on the Merge Request
+func (r *MergeRequestReport) setLabels(ctx context.Context, update *scm.UpdateMergeRequestOptions, current []string) {
+	if r == nil {
+		return
+	}
+
+	// The configuration file 'dry_run' setting is only known at this point
+	r.DryRun = state.IsDryRun(ctx)
+
+	if update.AddLabels != nil {
+		r.Labels.Add = slices.Sorted(slices.Values(*update.AddLabels))
+	}
+
+	if update.RemoveLabels != nil {
+		r.Labels.Remove = slices.Sorted(slices.Values(*update.RemoveLabels))
+	}
+
+	labels := slices.DeleteFunc(slices.Clone(current), func(label string) bool {
+		return slices.Contains(r.Labels.Remove, label)
+	})
+
+	r.Labels.Current = slices.Compact(slices.Sorted(slices.Values(append(labels, r.Labels.Add...))))
+}
+
+// Write prints the report as indented JSON
+func (r *EvaluationReport) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slices.SortStableFunc(r.MergeRequests, func(a, b *MergeRequestReport) int {
+		return cmp.Or(cmp.Compare(a.Project, b.Project), cmp.Compare(a.MergeRequestID, b.MergeRequestID))
+	})
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(out))
+
+	return err
+}
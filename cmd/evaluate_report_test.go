@@ -0,0 +1,65 @@
+package cmd_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluationReportWrite(t *testing.T) {
+	t.Parallel()
+
+	report := &cmd.EvaluationReport{
+		MergeRequests: []*cmd.MergeRequestReport{
+			{
+				Project:        "group/b",
+				MergeRequestID: "1",
+				CommitSHA:      "def",
+				Labels:         cmd.LabelReport{Add: []string{}, Remove: []string{}, Current: []string{}},
+				Actions:        []cmd.ActionReport{},
+				Error:          "boom",
+			},
+			{
+				Project:        "group/a",
+				MergeRequestID: "2",
+				CommitSHA:      "abc",
+				DryRun:         true,
+				Labels:         cmd.LabelReport{Add: []string{"bug"}, Remove: []string{"needs-review"}, Current: []string{"bug"}},
+				Actions: []cmd.ActionReport{
+					{Name: "close stale", Outcome: cmd.ActionOutcomeApplied},
+					{Name: "merge", Outcome: cmd.ActionOutcomeSkipped, Reason: "the head pipeline failed"},
+				},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+
+	require.JSONEq(t, `{
+		"merge_requests": [
+			{
+				"project": "group/a",
+				"merge_request_id": "2",
+				"commit_sha": "abc",
+				"dry_run": true,
+				"labels": {"add": ["bug"], "remove": ["needs-review"], "current": ["bug"]},
+				"actions": [
+					{"name": "close stale", "outcome": "applied"},
+					{"name": "merge", "outcome": "skipped", "reason": "the head pipeline failed"}
+				]
+			},
+			{
+				"project": "group/b",
+				"merge_request_id": "1",
+				"commit_sha": "def",
+				"dry_run": false,
+				"labels": {"add": [], "remove": [], "current": []},
+				"actions": [],
+				"error": "boom"
+			}
+		]
+	}`, out.String())
+}
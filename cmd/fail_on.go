@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+)
+
+// FailOnGate fails the evaluation when its predicate (see --fail-on) is true for the outcome of any evaluated Merge Request
+type FailOnGate struct {
+	predicate string
+	program   *vm.Program
+}
+
+// failOnEnv is the environment the --fail-on predicate is evaluated in
+type failOnEnv struct {
+	Project        string         `expr:"project"`
+	MergeRequestID string         `expr:"merge_request_id"`
+	DryRun         bool           `expr:"dry_run"`
+	Labels         []string       `expr:"labels"`
+	AddedLabels    []string       `expr:"added_labels"`
+	RemovedLabels  []string       `expr:"removed_labels"`
+	Actions        []ActionReport `expr:"actions"`
+
+	// Whether the label is on the Merge Request after the evaluation
+	HasLabel func(name string) bool `expr:"has_label"`
+
+	// The outcome of the action, or an empty string if it didn't evaluate positively
+	ActionOutcome func(name string) string `expr:"action_outcome"`
+}
+
+// NewFailOnGate compiles the [predicate] of a --fail-on gate, which must return a boolean
+func NewFailOnGate(predicate string) (*FailOnGate, error) {
+	opts := []expr.Option{}
+	opts = append(opts, expr.Env(failOnEnv{}))
+	opts = append(opts, expr.AsBool())
+	opts = append(opts, stdlib.Functions...)
+
+	program, err := expr.Compile(predicate, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile --%s into valid expr-lang syntax: %w", FlagFailOn, err)
+	}
+
+	return &FailOnGate{predicate: predicate, program: program}, nil
+}
+
+// Matches returns whether the predicate is true for the outcome of the Merge Request
+func (g *FailOnGate) Matches(report *MergeRequestReport) (bool, error) {
+	env := failOnEnv{
+		Project:        report.Project,
+		MergeRequestID: report.MergeRequestID,
+		DryRun:         report.DryRun,
+		Labels:         report.Labels.Current,
+		AddedLabels:    report.Labels.Add,
+		RemovedLabels:  report.Labels.Remove,
+		Actions:        report.Actions,
+		HasLabel: func(name string) bool {
+			return slices.Contains(report.Labels.Current, name)
+		},
+		ActionOutcome: func(name string) string {
+			for _, action := range report.Actions {
+				if action.Name == name {
+					return action.Outcome
+				}
+			}
+
+			return ""
+		},
+	}
+
+	output, err := expr.Run(g.program, env)
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate --%s for %s!%s: %w", FlagFailOn, report.Project, report.MergeRequestID, err)
+	}
+
+	return output.(bool), nil //nolint:forcetypeassert
+}
+
+// Check returns an error listing the Merge Requests in the [report] that the predicate is true for
+func (g *FailOnGate) Check(report *EvaluationReport) error {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	var matched []string
+
+	for _, mergeRequest := range report.MergeRequests {
+		ok, err := g.Matches(mergeRequest)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			matched = append(matched, mergeRequest.Project+"!"+mergeRequest.MergeRequestID)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+
+	slices.Sort(matched)
+
+	return fmt.Errorf("--%s %q matched %s", FlagFailOn, g.predicate, strings.Join(matched, ", "))
+}
@@ -0,0 +1,88 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailOnGate(t *testing.T) {
+	t.Parallel()
+
+	report := &cmd.EvaluationReport{
+		MergeRequests: []*cmd.MergeRequestReport{
+			{
+				Project:        "group/b",
+				MergeRequestID: "2",
+				Labels:         cmd.LabelReport{Add: []string{"do-not-merge"}, Remove: []string{}, Current: []string{"do-not-merge"}},
+				Actions:        []cmd.ActionReport{{Name: "merge", Outcome: cmd.ActionOutcomeFailed, Reason: "boom"}},
+			},
+			{
+				Project:        "group/a",
+				MergeRequestID: "1",
+				Labels:         cmd.LabelReport{Add: []string{}, Remove: []string{"do-not-merge"}, Current: []string{"bug"}},
+				Actions:        []cmd.ActionReport{{Name: "merge", Outcome: cmd.ActionOutcomeApplied}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		predicate string
+		wantErr   string
+	}{
+		{
+			name:      "passing gate",
+			predicate: `has_label("needs-review") || "security" in added_labels`,
+		},
+		{
+			name:      "failing gate on a label",
+			predicate: `has_label("do-not-merge")`,
+			wantErr:   `--fail-on "has_label(\"do-not-merge\")" matched group/b!2`,
+		},
+		{
+			name:      "failing gate on action outcomes, listing every matched Merge Request",
+			predicate: `action_outcome("merge") != "" && any(actions, .outcome != "skipped")`,
+			wantErr:   `--fail-on "action_outcome(\"merge\") != \"\" && any(actions, .outcome != \"skipped\")" matched group/a!1, group/b!2`,
+		},
+		{
+			name:      "failing gate on label changes",
+			predicate: `"do-not-merge" in removed_labels && project == "group/a"`,
+			wantErr:   "matched group/a!1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gate, err := cmd.NewFailOnGate(tt.predicate)
+			require.NoError(t, err)
+
+			err = gate.Check(report)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewFailOnGate_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := cmd.NewFailOnGate(`has_label(`)
+	require.ErrorContains(t, err, "could not compile --fail-on into valid expr-lang syntax")
+
+	// The predicate must return a boolean
+	_, err = cmd.NewFailOnGate(`labels`)
+	require.ErrorContains(t, err, "expected bool")
+
+	// Only the evaluation outcome is available, not the Merge Request
+	_, err = cmd.NewFailOnGate(`merge_request.draft`)
+	require.ErrorContains(t, err, "unknown name merge_request")
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/urfave/cli/v2"
 )
@@ -31,6 +33,53 @@ var GitHub = &cli.Command{
 		},
 	},
 	Subcommands: []*cli.Command{
+		{
+			Name:   "server",
+			Usage:  "Start HTTP server for webhook event driven usage",
+			Action: GitHubServer,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  FlagWebhookSecret,
+					Usage: "Used to validate received payloads. GitHub signs the payload with it in the X-Hub-Signature-256 HTTP header",
+					EnvVars: []string{
+						"SCM_ENGINE_WEBHOOK_SECRET",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagWebhookPath,
+					Usage: "The path to serve the GitHub webhook on",
+					Value: "/github",
+					EnvVars: []string{
+						"SCM_ENGINE_WEBHOOK_PATH",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagServerListenHost,
+					Usage: "IP that the HTTP server should listen on",
+					Value: "0.0.0.0",
+					EnvVars: []string{
+						"SCM_ENGINE_LISTEN_ADDR",
+					},
+				},
+				&cli.IntFlag{
+					Name:  FlagServerListenPort,
+					Usage: "Port that the HTTP server should listen on",
+					Value: 3000,
+					EnvVars: []string{
+						"SCM_ENGINE_LISTEN_PORT",
+						"PORT",
+					},
+				},
+				&cli.DurationFlag{
+					Name:  FlagServerTimeout,
+					Usage: "Timeout for webhook requests",
+					Value: 5 * time.Second,
+					EnvVars: []string{
+						"SCM_ENGINE_TIMEOUT",
+					},
+				},
+			},
+		},
 		{
 			Name:      "evaluate",
 			Usage:     "Evaluate a Pull Request",
@@ -60,6 +109,28 @@ var GitHub = &cli.Command{
 						"GITHUB_SHA", // GitHub Actions
 					},
 				},
+				&cli.StringFlag{
+					Name:  FlagFailOn,
+					Usage: "Exit non-zero if this expr-lang predicate is true for the outcome of any evaluated Pull Request (example: 'has_label(\"do-not-merge\")')",
+					EnvVars: []string{
+						"SCM_ENGINE_FAIL_ON",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagReconcileReport,
+					Usage: "(Optional) Write the label changes of every evaluated Pull Request to this file, as CSV or JSON depending on the file extension (example: 'reconcile.csv'). With --dry-run it's the plan, otherwise the result",
+					EnvVars: []string{
+						"SCM_ENGINE_RECONCILE_REPORT",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagOutput,
+					Usage: "Output format, either 'text' (human-readable logs) or 'json' (a machine-readable report of the label changes, action outcomes and errors, printed to stdout)",
+					Value: OutputText,
+					EnvVars: []string{
+						"SCM_ENGINE_OUTPUT",
+					},
+				},
 			},
 		},
 	},
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/redact"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/urfave/cli/v2"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+func GitHubServer(cCtx *cli.Context) error {
+	// Setup context configuration
+	ctx := cCtx.Context
+	ctx = state.WithConfigFilePath(ctx, cCtx.String(FlagConfigFile))
+	ctx = withGitHubState(ctx, cCtx.String(FlagAPIToken))
+
+	redact.Register(state.Token(ctx), cCtx.String(FlagWebhookSecret))
+
+	// Add logging context key/value pairs
+	ctx = slogctx.With(ctx, slog.Duration("server_timeout", cCtx.Duration(FlagServerTimeout)))
+
+	listenAddr := net.JoinHostPort(cCtx.String(FlagServerListenHost), cCtx.String(FlagServerListenPort))
+	slogctx.Info(ctx, "Starting HTTP server", slog.String("listen_address", listenAddr))
+
+	mux, err := NewWebhookRouter(WebhookRoute{
+		Flag:    FlagWebhookPath,
+		Path:    cCtx.String(FlagWebhookPath),
+		Handler: GitHubWebhookHandler(ctx, cCtx.String(FlagWebhookSecret)),
+	})
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:         listenAddr,
+		Handler:      mux,
+		ReadTimeout:  cCtx.Duration(FlagServerTimeout),
+		WriteTimeout: cCtx.Duration(FlagServerTimeout),
+		BaseContext: func(l net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			slogctx.Error(ctx, "HTTP server error", slog.Any("error", err))
+
+			os.Exit(1)
+		}
+
+		slogctx.Info(ctx, "Stopped serving new connections.")
+	}()
+
+	//
+	// Wait for shutdown signals
+	//
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	slogctx.Info(ctx, "Got SIGINT/SIGTERM, starting graceful shutdown.")
+
+	// NOTE: do not use the existing "ctx" since its already cancelled in developer mode if CTRL+C-ing
+	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownRelease()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slogctx.Error(ctx, "HTTP shutdown error", slog.Any("error", err))
+	}
+
+	slogctx.Info(ctx, "Graceful shutdown complete")
+
+	return nil
+}
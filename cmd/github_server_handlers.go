@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm/github"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+func GitHubWebhookHandler(ctx context.Context, webhookSecret string) http.HandlerFunc {
+	client := github.NewClient(ctx)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// Read the POST body of the request, it's needed to verify the signature
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			errHandler(ctx, w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		// Check if the webhook secret is set (and if the signature is matching)
+		if len(webhookSecret) > 0 && !validGitHubSignature(webhookSecret, r.Header.Get("X-Hub-Signature-256"), body) {
+			errHandler(ctx, w, http.StatusUnauthorized, errors.New("Missing or invalid X-Hub-Signature-256 header"))
+
+			return
+		}
+
+		event := r.Header.Get("X-GitHub-Event")
+
+		// GitHub sends a "ping" event when the webhook is created
+		if event == "ping" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("pong - the scm-engine webhook is configured correctly"))
+
+			return
+		}
+
+		if !githubEventHeaders[event] {
+			errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("unsupported X-GitHub-Event header: %q", event))
+
+			return
+		}
+
+		// Validate content type
+		if !isJSONContentType(r.Header.Get("Content-Type")) {
+			errHandler(ctx, w, http.StatusNotAcceptable, errors.New("The request is not using Content-Type: application/json"))
+
+			return
+		}
+
+		// Decode request payload
+		var payload GithubWebhookPayload
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&payload); err != nil {
+			errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("could not decode POST body into Payload struct: %w", err))
+
+			return
+		}
+
+		ctx = state.WithProjectID(ctx, payload.Repository.FullName)
+		ctx = slogctx.With(ctx, slog.String("event_type", event))
+
+		var (
+			id     int
+			gitSha string
+		)
+
+		switch event {
+		case "pull_request", "pull_request_review":
+			if payload.PullRequest == nil {
+				errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("%s event is missing the 'pull_request' payload", event))
+
+				return
+			}
+
+			id = payload.PullRequest.Number
+			gitSha = payload.PullRequest.Head.SHA
+
+			switch {
+			case event == "pull_request_review":
+				ctx = state.WithTriggerEvent(ctx, state.TriggerEventNote)
+
+			case payload.Action == "opened":
+				ctx = state.WithTriggerEvent(ctx, state.TriggerEventOpen)
+
+			case payload.Action == "reopened":
+				ctx = state.WithTriggerEvent(ctx, state.TriggerEventReopen)
+
+			case payload.Action == "closed" && payload.PullRequest.Merged:
+				ctx = state.WithTriggerEvent(ctx, state.TriggerEventMerge)
+
+			default:
+				ctx = state.WithTriggerEvent(ctx, state.TriggerEventUpdate)
+			}
+
+		case "issue_comment":
+			if payload.Issue == nil {
+				errHandler(ctx, w, http.StatusBadRequest, errors.New("issue_comment event is missing the 'issue' payload"))
+
+				return
+			}
+
+			// Comments on regular issues are fine, but there is nothing for us to evaluate
+			if payload.Issue.PullRequest == nil {
+				slogctx.Info(ctx, "Ignoring comment on issue")
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK - ignored issue comment"))
+
+				return
+			}
+
+			id = payload.Issue.Number
+
+			ctx = state.WithTriggerEvent(ctx, state.TriggerEventNote)
+
+			// The comment payload doesn't include the head commit
+			ctx = state.WithMergeRequestID(ctx, strconv.Itoa(id))
+
+			gitSha, err = client.MergeRequests().HeadSHA(ctx)
+			if err != nil {
+				errHandler(ctx, w, http.StatusInternalServerError, err)
+
+				return
+			}
+		}
+
+		ctx = state.WithMergeRequestID(ctx, strconv.Itoa(id))
+		ctx = state.WithCommitSHA(ctx, gitSha)
+		ctx = withTagFiltersFromQuery(ctx, r)
+
+		// Fail fast if the payload didn't provide everything we need
+		if err := state.RequireMergeRequestContext(ctx); err != nil {
+			errHandler(ctx, w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		slogctx.Info(ctx, "POST /github webhook")
+
+		// Decode request payload into 'any' so we have all the details
+		var fullEventPayload any
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&fullEventPayload); err != nil {
+			errHandler(ctx, w, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		evaluateWebhookEvent(ctx, w, func(ctx context.Context) error {
+			return ProcessMR(ctx, client, nil, fullEventPayload)
+		})
+	}
+}
+
+// validGitHubSignature checks the "sha256=<hex>" HMAC signature GitHub sends in the X-Hub-Signature-256 header
+//
+// See: https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+func validGitHubSignature(secret, header string, body []byte) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), actual)
+}
@@ -0,0 +1,98 @@
+package cmd_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubWebhookHandler(t *testing.T) {
+	t.Parallel()
+
+	const secret = "very-secret"
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "github")
+	ctx = state.WithToken(ctx, "token")
+
+	handler := cmd.GitHubWebhookHandler(ctx, secret)
+
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name      string
+		event     string
+		body      string
+		signature string
+		wantCode  int
+	}{
+		{
+			name:      "missing signature",
+			event:     "pull_request",
+			body:      `{"action": "opened"}`,
+			signature: "",
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:      "signature mismatch",
+			event:     "pull_request",
+			body:      `{"action": "opened"}`,
+			signature: sign(`{"action": "closed"}`),
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:      "ping",
+			event:     "ping",
+			body:      `{"zen": "Keep it logically awesome."}`,
+			signature: sign(`{"zen": "Keep it logically awesome."}`),
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "unsupported event",
+			event:     "push",
+			body:      `{"ref": "refs/heads/main"}`,
+			signature: sign(`{"ref": "refs/heads/main"}`),
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "comment on issue is ignored",
+			event:     "issue_comment",
+			body:      `{"action": "created", "repository": {"full_name": "jippi/scm-engine"}, "issue": {"number": 1}}`,
+			signature: sign(`{"action": "created", "repository": {"full_name": "jippi/scm-engine"}, "issue": {"number": 1}}`),
+			wantCode:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/github", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-GitHub-Event", tt.event)
+
+			if len(tt.signature) > 0 {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, tt.wantCode, recorder.Code)
+		})
+	}
+}
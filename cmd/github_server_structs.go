@@ -0,0 +1,38 @@
+package cmd
+
+// githubEventHeaders are the supported "X-GitHub-Event" header values
+//
+// See: https://docs.github.com/en/webhooks/webhook-events-and-payloads
+var githubEventHeaders = map[string]bool{
+	"issue_comment":       true,
+	"pull_request":        true,
+	"pull_request_review": true,
+}
+
+type GithubWebhookPayload struct {
+	Action      string                           `json:"action"`
+	Repository  GithubWebhookPayloadRepository   `json:"repository"`
+	PullRequest *GithubWebhookPayloadPullRequest `json:"pull_request,omitempty"` // "pull_request" is sent on "pull_request" and "pull_request_review" events
+	Issue       *GithubWebhookPayloadIssue       `json:"issue,omitempty"`        // "issue" is sent on "issue_comment" events
+}
+
+type GithubWebhookPayloadRepository struct {
+	FullName string `json:"full_name"`
+}
+
+type GithubWebhookPayloadPullRequest struct {
+	Number int                        `json:"number"`
+	Head   GithubWebhookPayloadCommit `json:"head"`
+	Merged bool                       `json:"merged,omitempty"` // "merged" is true when a "closed" Pull Request was merged
+}
+
+type GithubWebhookPayloadCommit struct {
+	SHA string `json:"sha"`
+}
+
+type GithubWebhookPayloadIssue struct {
+	Number int `json:"number"`
+
+	// PullRequest is only set when the issue is a Pull Request
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"os"
 	"time"
 
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/redact"
 	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/urfave/cli/v2"
 )
@@ -15,23 +18,79 @@ var GitLab = &cli.Command{
 		cCtx.Context = state.WithProvider(cCtx.Context, "gitlab")
 		cCtx.Context = state.WithToken(cCtx.Context, cCtx.String(FlagAPIToken))
 
+		// Fall back to the GitLab CI predefined variables when running in a GitLab CI job
+		cCtx.Context = WithGitLabCIDefaults(cCtx.Context, cCtx.IsSet(FlagSCMBaseURL), os.LookupEnv)
+
+		// Keep the token (or CI job token) out of logs and error responses
+		redact.Register(state.Token(cCtx.Context))
+
+		// Post-evaluation exec hooks are opt-in, since they run an arbitrary command
+		if command := cCtx.String(FlagPostEvaluationHook); len(command) > 0 {
+			hook, err := NewExecHook(command, cCtx.Duration(FlagPostEvaluationHookTimeout))
+			if err != nil {
+				return err
+			}
+
+			cCtx.Context = withExecHook(cCtx.Context, hook)
+		}
+
+		// Record every evaluation in the audit log (if configured)
+		if path := cCtx.String(FlagAuditLog); len(path) > 0 {
+			log, err := OpenAuditLog(path)
+			if err != nil {
+				return err
+			}
+
+			cCtx.Context = withAuditLog(cCtx.Context, log)
+		}
+
+		return nil
+	},
+	After: func(cCtx *cli.Context) error {
+		WaitForPostEvaluationHooks()
+
+		if log := auditLogFromContext(cCtx.Context); log != nil {
+			return log.Close()
+		}
+
 		return nil
 	},
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  FlagAPIToken,
-			Usage: "GitLab API token",
+			Usage: "GitLab API token. Defaults to the job token (CI_JOB_TOKEN) when running in GitLab CI",
 			EnvVars: []string{
 				"SCM_ENGINE_TOKEN", // SCM Engine Native
 			},
 		},
 		&cli.StringFlag{
 			Name:  FlagSCMBaseURL,
-			Usage: "Base URL for the SCM instance",
+			Usage: "Base URL for the SCM instance. Defaults to the GitLab instance (CI_API_V4_URL) when running in GitLab CI",
 			Value: "https://gitlab.com/",
 			EnvVars: []string{
 				"SCM_ENGINE_BASE_URL", // SCM Engine Native
-				"CI_SERVER_URL",       // GitLab CI
+			},
+		},
+		&cli.StringFlag{
+			Name:  FlagAuditLog,
+			Usage: "(Optional) Append a JSON record of every evaluation (the decisions, and whether they were applied) to this JSONL file. Combine with --dry-run to record what would happen, without acting",
+			EnvVars: []string{
+				"SCM_ENGINE_AUDIT_LOG",
+			},
+		},
+		&cli.StringFlag{
+			Name:  FlagPostEvaluationHook,
+			Usage: "(Optional) Command to run after every evaluation, with the outcome as JSON on stdin (example: '/usr/local/bin/notify --channel reviews'). Run directly, not through a shell. Disabled by default",
+			EnvVars: []string{
+				"SCM_ENGINE_POST_EVALUATION_HOOK",
+			},
+		},
+		&cli.DurationFlag{
+			Name:  FlagPostEvaluationHookTimeout,
+			Usage: "How long the --post-evaluation-hook command may run before it's stopped",
+			Value: DefaultPostEvaluationHookTimeout,
+			EnvVars: []string{
+				"SCM_ENGINE_POST_EVALUATION_HOOK_TIMEOUT",
 			},
 		},
 	},
@@ -49,6 +108,90 @@ var GitLab = &cli.Command{
 				},
 			},
 		},
+		{
+			Name:  "config",
+			Usage: "Configuration file related commands",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "diff",
+					Usage:     "Show the label and action changes between two configuration files (with 'include' settings resolved). Exits non-zero when there are changes",
+					Args:      true,
+					ArgsUsage: " old.yml new.yml",
+					Action:    ConfigDiff,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  FlagQuiet,
+							Usage: "Don't print the changes, only use the exit code",
+						},
+					},
+				},
+				{
+					Name:   "show",
+					Usage:  "Show the effective configuration for a Merge Request, with 'include' settings resolved exactly like an evaluation would",
+					Args:   false,
+					Action: ConfigShow,
+					Flags: append([]cli.Flag{
+						&cli.StringFlag{
+							Name:  FlagMergeRequestURL,
+							Usage: "URL of the Merge Request (example: 'https://gitlab.com/gitlab-org/gitlab/-/merge_requests/1')",
+						},
+						&cli.BoolFlag{
+							Name:  FlagJSON,
+							Usage: "Print the configuration as JSON instead of YAML",
+						},
+					}, globalConfigFlags()...),
+				},
+			},
+		},
+		{
+			Name:  "labels",
+			Usage: "Merge Request label related commands",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "restore",
+					Usage:  "Restore the labels of a Merge Request from the snapshot taken by the 'snapshot_labels' action",
+					Args:   false,
+					Action: LabelsRestore,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  FlagMergeRequestURL,
+							Usage: "URL of the Merge Request (example: 'https://gitlab.com/gitlab-org/gitlab/-/merge_requests/1')",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "validate-remote",
+			Usage:  "Parse and lint the configuration file on the default branch of every project in a group",
+			Args:   false,
+			Action: ValidateRemote,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  FlagSCMGroup,
+					Usage: "GitLab group to validate projects in, including subgroups (example: 'gitlab-org')",
+					EnvVars: []string{
+						"GITLAB_GROUP",
+					},
+				},
+				&cli.IntFlag{
+					Name:  FlagConcurrency,
+					Usage: "How many projects to validate at the same time",
+					Value: 4,
+					EnvVars: []string{
+						"SCM_ENGINE_CONCURRENCY",
+					},
+				},
+				&cli.Float64Flag{
+					Name:  FlagRateLimit,
+					Usage: "Maximum number of projects to validate per second",
+					Value: 10,
+					EnvVars: []string{
+						"SCM_ENGINE_RATE_LIMIT",
+					},
+				},
+			},
+		},
 		{
 			Name:      "evaluate",
 			Usage:     "Evaluate a Merge Request",
@@ -56,6 +199,26 @@ var GitLab = &cli.Command{
 			ArgsUsage: " [mr_id, mr_id, ...]",
 			Action:    Evaluate,
 			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  FlagAllOpen,
+					Usage: "Evaluate all open Merge Requests in the project (same as the 'all' argument), for example for one-shot migrations",
+				},
+				&cli.StringSliceFlag{
+					Name:  FlagAllOpenLabel,
+					Usage: "(Optional) Only evaluate the open Merge Requests with this label, with --all-open. Can be repeated to require all of the labels",
+				},
+				&cli.StringFlag{
+					Name:  FlagAllOpenAuthor,
+					Usage: "(Optional) Only evaluate the open Merge Requests opened by this username, with --all-open",
+				},
+				&cli.StringFlag{
+					Name:  FlagAllOpenMilestone,
+					Usage: "(Optional) Only evaluate the open Merge Requests in this milestone (by title), with --all-open",
+				},
+				&cli.StringFlag{
+					Name:  FlagAllOpenSearch,
+					Usage: "(Optional) Only evaluate the open Merge Requests with this text in their title or description, with --all-open",
+				},
 				&cli.BoolFlag{
 					Name:  FlagUpdatePipeline,
 					Usage: "Update the CI pipeline status with progress",
@@ -93,6 +256,80 @@ var GitLab = &cli.Command{
 						"CI_COMMIT_SHA", // GitLab CI
 					},
 				},
+				&cli.IntFlag{
+					Name:  FlagConcurrency,
+					Usage: "How many Merge Requests to evaluate at the same time when evaluating 'all'",
+					Value: 1,
+					EnvVars: []string{
+						"SCM_ENGINE_CONCURRENCY",
+					},
+				},
+				&cli.DurationFlag{
+					Name:  FlagDrainTimeout,
+					Usage: "How long to wait for in-flight Merge Request evaluations to finish after receiving SIGINT/SIGTERM when evaluating 'all'",
+					Value: 30 * time.Second,
+					EnvVars: []string{
+						"SCM_ENGINE_DRAIN_TIMEOUT",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagFailOn,
+					Usage: "Exit non-zero if this expr-lang predicate is true for the outcome of any evaluated Merge Request (example: 'has_label(\"do-not-merge\")')",
+					EnvVars: []string{
+						"SCM_ENGINE_FAIL_ON",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagReconcileReport,
+					Usage: "(Optional) Write the label changes of every evaluated Merge Request to this file, as CSV or JSON depending on the file extension (example: 'reconcile.csv'). With --dry-run it's the plan, otherwise the result",
+					EnvVars: []string{
+						"SCM_ENGINE_RECONCILE_REPORT",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagOutput,
+					Usage: "Output format, either 'text' (human-readable logs) or 'json' (a machine-readable report of the label changes, action outcomes and errors, printed to stdout)",
+					Value: OutputText,
+					EnvVars: []string{
+						"SCM_ENGINE_OUTPUT",
+					},
+				},
+				&cli.BoolFlag{
+					Name:  FlagReplayFailed,
+					Usage: "Only apply the actions that failed during the previous evaluation of the same commit, so actions that were already applied don't run again",
+					EnvVars: []string{
+						"SCM_ENGINE_REPLAY_FAILED",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagChangedRulesSince,
+					Usage: "(Optional) Path to the previous configuration file (for example, from 'git show HEAD~1:.scm-engine.yml'). Only the labels and actions added or modified since then are evaluated, the rest are left as-is. All rules are evaluated if the file can't be loaded",
+					EnvVars: []string{
+						"SCM_ENGINE_CHANGED_RULES_SINCE",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagCacheDir,
+					Usage: "(Optional) Directory to cache the (compressed) GitLab API read responses in, keyed by request and commit SHA, so reruns of a batch evaluation (for example, after a crash) don't fetch the same data again",
+					EnvVars: []string{
+						"SCM_ENGINE_CACHE_DIR",
+					},
+				},
+				&cli.DurationFlag{
+					Name:  FlagCacheTTL,
+					Usage: "How long the cached GitLab API responses in --" + FlagCacheDir + " are used for",
+					Value: state.DefaultResponseCacheTTL,
+					EnvVars: []string{
+						"SCM_ENGINE_CACHE_TTL",
+					},
+				},
+				&cli.BoolFlag{
+					Name:  FlagNoCache,
+					Usage: "Don't use the GitLab API response cache, even if --" + FlagCacheDir + " is set",
+					EnvVars: []string{
+						"SCM_ENGINE_NO_CACHE",
+					},
+				},
 			},
 		},
 		{
@@ -100,7 +337,7 @@ var GitLab = &cli.Command{
 			Usage:  "Start HTTP server for webhook event driven usage",
 			Hidden: true, // DEPRECATED
 			Action: Server,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				&cli.StringFlag{
 					Name:  FlagWebhookSecret,
 					Usage: "Used to validate received payloads. Sent with the request in the X-Gitlab-Token HTTP header",
@@ -108,6 +345,108 @@ var GitLab = &cli.Command{
 						"SCM_ENGINE_WEBHOOK_SECRET",
 					},
 				},
+				&cli.BoolFlag{
+					Name:  FlagWebhookLogNewFields,
+					Usage: "Log a warning when a webhook payload has fields not seen in earlier payloads of the same event type, to notice changes to the GitLab webhook events",
+					Value: false,
+					EnvVars: []string{
+						"SCM_ENGINE_WEBHOOK_LOG_NEW_FIELDS",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagPprofListen,
+					Usage: "(Optional) Loopback address to serve the pprof profiling endpoints on, under '/debug/pprof/' (example: 'localhost:6060')",
+					EnvVars: []string{
+						"SCM_ENGINE_PPROF_LISTEN",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagWebhookPath,
+					Usage: "The path to serve the GitLab webhook on",
+					Value: "/gitlab",
+					EnvVars: []string{
+						"SCM_ENGINE_WEBHOOK_PATH",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagSystemHookPath,
+					Usage: "The path to serve the GitLab system hook on",
+					Value: "/gitlab/system",
+					EnvVars: []string{
+						"SCM_ENGINE_SYSTEM_HOOK_PATH",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagGitHubWebhookPath,
+					Usage: "(Optional) Also serve GitHub webhooks, on this path (example: '/github'). Requires --github-api-token",
+					EnvVars: []string{
+						"SCM_ENGINE_GITHUB_WEBHOOK_PATH",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagGitHubWebhookSecret,
+					Usage: "Used to validate received GitHub webhook payloads. GitHub signs the payload with it in the X-Hub-Signature-256 HTTP header",
+					EnvVars: []string{
+						"SCM_ENGINE_GITHUB_WEBHOOK_SECRET",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagGitHubAPIToken,
+					Usage: "GitHub API token, used for the GitHub webhooks served with --github-webhook-path",
+					EnvVars: []string{
+						"SCM_ENGINE_GITHUB_TOKEN",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagSystemHookSecret,
+					Usage: "Used to validate received system hook payloads on 'POST /gitlab/system'. Sent with the request in the X-Gitlab-Token HTTP header",
+					EnvVars: []string{
+						"SCM_ENGINE_SYSTEM_HOOK_SECRET",
+					},
+				},
+				&cli.BoolFlag{
+					Name:  FlagSystemHookInstallConfig,
+					Usage: "(Optional) Commit the bundled default configuration file to new projects when receiving a 'project_create' system hook",
+					Value: false,
+					EnvVars: []string{
+						"SCM_ENGINE_SYSTEM_HOOK_INSTALL_CONFIG",
+					},
+				},
+				&cli.StringSliceFlag{
+					Name:  FlagAPITokenMapping,
+					Usage: "(Optional) Use a different API token for projects matching a glob, in the format 'project-glob=token' (example: 'my-group/**=glpat-xxx'). The first matching mapping is used, falling back to --api-token",
+					EnvVars: []string{
+						"SCM_ENGINE_TOKEN_MAPPING",
+					},
+				},
+				&cli.StringSliceFlag{
+					Name:  FlagTriggerOnChanges,
+					Usage: "(Optional) Only evaluate 'merge_request' update events that changed at least one of these fields ('title', 'labels', 'description' or 'commits')",
+					EnvVars: []string{
+						"SCM_ENGINE_TRIGGER_ON_CHANGES",
+					},
+				},
+				&cli.DurationFlag{
+					Name:  FlagDebounce,
+					Usage: "(Optional) Delay evaluating webhook events by this long (example: '10s'), restarting the delay when another event for the same Merge Request arrives, so rapid successive events are evaluated once",
+					EnvVars: []string{
+						"SCM_ENGINE_DEBOUNCE",
+					},
+				},
+				&cli.BoolFlag{
+					Name:  FlagSkipSelfTest,
+					Usage: "Start the server even if the startup self-test (global config, GitLab connectivity and API token scopes) fails",
+					EnvVars: []string{
+						"SCM_ENGINE_SKIP_SELFTEST",
+					},
+				},
+				&cli.BoolFlag{
+					Name:  FlagEvaluateArchivedProjects,
+					Usage: "Evaluate webhook events for archived (read-only) projects, rather than skipping them",
+					EnvVars: []string{
+						"SCM_ENGINE_EVALUATE_ARCHIVED_PROJECTS",
+					},
+				},
 				&cli.StringFlag{
 					Name:  FlagServerListenHost,
 					Usage: "IP that the HTTP server should listen on",
@@ -148,6 +487,36 @@ var GitLab = &cli.Command{
 						"SCM_ENGINE_UPDATE_PIPELINE_URL",
 					},
 				},
+				&cli.StringFlag{
+					Name:  FlagSchedule,
+					Usage: "(Optional) Cron expression (example: '0 * * * *' or '@hourly') for evaluating all open Merge Requests in the --schedule-projects projects",
+					EnvVars: []string{
+						"SCM_ENGINE_SCHEDULE",
+					},
+				},
+				&cli.StringSliceFlag{
+					Name:  FlagScheduleProjects,
+					Usage: "(Optional) Projects to evaluate on the --schedule cron (example: 'gitlab-org/gitlab')",
+					EnvVars: []string{
+						"SCM_ENGINE_SCHEDULE_PROJECTS",
+					},
+				},
+				&cli.IntFlag{
+					Name:  FlagConcurrency,
+					Usage: "How many Merge Requests of a project to evaluate at the same time during scheduled evaluations",
+					Value: 1,
+					EnvVars: []string{
+						"SCM_ENGINE_CONCURRENCY",
+					},
+				},
+				&cli.DurationFlag{
+					Name:  FlagDrainTimeout,
+					Usage: "How long to wait for in-flight scheduled evaluations to finish after receiving SIGINT/SIGTERM",
+					Value: 30 * time.Second,
+					EnvVars: []string{
+						"SCM_ENGINE_DRAIN_TIMEOUT",
+					},
+				},
 				&cli.DurationFlag{
 					Name:  FlagPeriodicEvaluationInterval,
 					Usage: "(Optional) Frequency of which to evaluate all Merge Requests regardless of user activity",
@@ -176,6 +545,72 @@ var GitLab = &cli.Command{
 						"SCM_ENGINE_PERIODIC_EVALUATION_REQUIRE_PROJECT_TOPICS",
 					},
 				},
+				&cli.DurationFlag{
+					Name:  FlagLabelExpirySweepInterval,
+					Usage: "(Optional) Frequency of which to remove expired labels (see --label-expiry-sweep-labels) from Merge Requests regardless of user activity, without evaluating any other rules",
+					EnvVars: []string{
+						"SCM_ENGINE_LABEL_EXPIRY_SWEEP_INTERVAL",
+					},
+				},
+				&cli.StringSliceFlag{
+					Name:  FlagLabelExpirySweepLabels,
+					Usage: "(Optional) Labels with an 'expires_at' setting, Merge Requests with any of them will be checked by the label expiry sweep",
+					EnvVars: []string{
+						"SCM_ENGINE_LABEL_EXPIRY_SWEEP_LABELS",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagAuditWebhookURL,
+					Usage: "(Optional) URL to POST a JSON audit record to after every evaluation (example: a SIEM endpoint). Deliveries are retried with backoff in the background",
+					EnvVars: []string{
+						"SCM_ENGINE_AUDIT_WEBHOOK_URL",
+					},
+				},
+				&cli.StringSliceFlag{
+					Name:  FlagAuditWebhookHeader,
+					Usage: "(Optional) Extra HTTP header to send to the --audit-webhook-url, in the format 'Name: value' (example: 'Authorization: Bearer xxx')",
+					EnvVars: []string{
+						"SCM_ENGINE_AUDIT_WEBHOOK_HEADER",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagAuditWebhookSecret,
+					Usage: "(Optional) Secret used to sign audit records with HMAC-SHA256, sent in the X-Scm-Engine-Signature HTTP header",
+					EnvVars: []string{
+						"SCM_ENGINE_AUDIT_WEBHOOK_SECRET",
+					},
+				},
+				&cli.BoolFlag{
+					Name:  FlagRuleMetrics,
+					Usage: "(Optional) Count evaluations, matches, errors and action executions by rule name, in the 'rule_metrics' metric of the /_metrics endpoint",
+					EnvVars: []string{
+						"SCM_ENGINE_RULE_METRICS",
+					},
+				},
+				&cli.IntFlag{
+					Name:  FlagRuleMetricsMaxRules,
+					Usage: "Number of distinct rules with their own --rule-metrics counters, the counters of any other rules are added up under '_other'",
+					Value: config.DefaultRuleMetricsMaxRules,
+					EnvVars: []string{
+						"SCM_ENGINE_RULE_METRICS_MAX_RULES",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagMissingConfigBehavior,
+					Usage: "What to do when a project has no scm-engine configuration file. One of 'error' (surface the error), 'ignore' (log and skip) or 'use_default' (use the bundled default configuration)",
+					Value: string(config.MissingConfigError),
+					EnvVars: []string{
+						"SCM_ENGINE_MISSING_CONFIG_BEHAVIOR",
+					},
+				},
+				&cli.StringFlag{
+					Name:  FlagMissingCommitSHABehavior,
+					Usage: "What to do when a webhook event has no commit SHA (example: a note on a Merge Request without commits). One of 'lookup' (read the head commit of the Merge Request from the API, and skip if it has none), 'skip' (log and skip) or 'error' (surface the error)",
+					Value: MissingCommitSHALookup,
+					EnvVars: []string{
+						"SCM_ENGINE_MISSING_COMMIT_SHA_BEHAVIOR",
+					},
+				},
 				&cli.BoolFlag{
 					Name:  FlagPeriodicEvaluationOnlyProjectsWithMembership,
 					Usage: "(Optional) Only evaluate projects with membership",
@@ -184,7 +619,36 @@ var GitLab = &cli.Command{
 						"SCM_ENGINE_PERIODIC_EVALUATION_ONLY_PROJECTS_WITH_MEMBERSHIP",
 					},
 				},
-			},
+			}, globalConfigFlags()...),
 		},
 	},
 }
+
+// globalConfigFlags returns the flags for the global configuration file (see [loadGlobalConfig]),
+// shared by the 'server' and 'config show' commands
+func globalConfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  FlagGlobalConfigProject,
+			Usage: "(Optional) GitLab project hosting a global configuration file, merged into the configuration of every project like an 'include' (example: 'platform/scm-engine-policy'). Read once at startup",
+			EnvVars: []string{
+				"SCM_ENGINE_GLOBAL_CONFIG_PROJECT",
+			},
+		},
+		&cli.StringFlag{
+			Name:  FlagGlobalConfigFile,
+			Usage: "Path to the global configuration file in the --global-config-project project",
+			Value: ".scm-engine.yml",
+			EnvVars: []string{
+				"SCM_ENGINE_GLOBAL_CONFIG_FILE",
+			},
+		},
+		&cli.StringFlag{
+			Name:  FlagGlobalConfigRef,
+			Usage: "(Optional) Git reference to read the global configuration file from. Defaults to HEAD of the --global-config-project project",
+			EnvVars: []string{
+				"SCM_ENGINE_GLOBAL_CONFIG_REF",
+			},
+		},
+	}
+}
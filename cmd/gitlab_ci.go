@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// WithGitLabCIDefaults fills in the GitLab base URL and API token from the GitLab CI predefined variables,
+// when running inside a GitLab CI job and they were not configured explicitly.
+//
+// The base URL is taken from 'CI_API_V4_URL' (falling back to 'CI_SERVER_URL'), and the token from 'CI_JOB_TOKEN'.
+func WithGitLabCIDefaults(ctx context.Context, baseURLIsSet bool, lookupEnv func(string) (string, bool)) context.Context {
+	if value, _ := lookupEnv("GITLAB_CI"); value != "true" {
+		return ctx
+	}
+
+	if !baseURLIsSet {
+		if baseURL := gitlabCIBaseURL(lookupEnv); len(baseURL) > 0 {
+			slogctx.Debug(ctx, "Using the GitLab CI base URL", slog.String("base_url", baseURL))
+
+			ctx = state.WithBaseURL(ctx, baseURL)
+		}
+	}
+
+	if len(state.Token(ctx)) == 0 {
+		if jobToken, _ := lookupEnv("CI_JOB_TOKEN"); len(jobToken) > 0 {
+			slogctx.Info(ctx, "No API token configured, using the GitLab CI job token (CI_JOB_TOKEN), which only has limited API permissions")
+
+			ctx = state.WithToken(ctx, jobToken)
+			ctx = state.WithJobToken(ctx, true)
+		}
+	}
+
+	return ctx
+}
+
+func gitlabCIBaseURL(lookupEnv func(string) (string, bool)) string {
+	if apiURL, _ := lookupEnv("CI_API_V4_URL"); len(apiURL) > 0 {
+		return strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api/v4") + "/"
+	}
+
+	if serverURL, _ := lookupEnv("CI_SERVER_URL"); len(serverURL) > 0 {
+		return strings.TrimSuffix(serverURL, "/") + "/"
+	}
+
+	return ""
+}
@@ -0,0 +1,128 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestWithGitLabCIDefaults(t *testing.T) {
+	t.Parallel()
+
+	gitlabCI := map[string]string{
+		"GITLAB_CI":     "true",
+		"CI_API_V4_URL": "https://gitlab.example.com/api/v4",
+		"CI_SERVER_URL": "https://gitlab.example.com",
+		"CI_JOB_TOKEN":  "job-token",
+	}
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		baseURLIsSet bool
+		token        string
+		wantBaseURL  string
+		wantToken    string
+		wantJobToken bool
+	}{
+		{
+			name:        "not running in GitLab CI",
+			env:         map[string]string{"CI_JOB_TOKEN": "job-token", "CI_API_V4_URL": "https://gitlab.example.com/api/v4"},
+			wantBaseURL: "https://gitlab.com/",
+		},
+		{
+			name:         "GitLab CI without explicit configuration",
+			env:          gitlabCI,
+			wantBaseURL:  "https://gitlab.example.com/",
+			wantToken:    "job-token",
+			wantJobToken: true,
+		},
+		{
+			name:         "explicit configuration takes precedence",
+			env:          gitlabCI,
+			baseURLIsSet: true,
+			token:        "glpat-xxx",
+			wantBaseURL:  "https://gitlab.com/",
+			wantToken:    "glpat-xxx",
+		},
+		{
+			name:         "falls back to CI_SERVER_URL",
+			env:          map[string]string{"GITLAB_CI": "true", "CI_SERVER_URL": "https://gitlab.example.com/", "CI_JOB_TOKEN": "job-token"},
+			wantBaseURL:  "https://gitlab.example.com/",
+			wantToken:    "job-token",
+			wantJobToken: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, "https://gitlab.com/")
+			ctx = state.WithToken(ctx, tt.token)
+
+			ctx = cmd.WithGitLabCIDefaults(ctx, tt.baseURLIsSet, func(key string) (string, bool) {
+				value, ok := tt.env[key]
+
+				return value, ok
+			})
+
+			require.Equal(t, tt.wantBaseURL, state.BaseURL(ctx))
+			require.Equal(t, tt.wantToken, state.Token(ctx))
+			require.Equal(t, tt.wantJobToken, state.IsJobToken(ctx))
+		})
+	}
+}
+
+//nolint:paralleltest // The GitLab CI predefined variables are read from the environment
+func TestEvaluate_GitLabCIJobToken(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		headers = append(headers, r.Header.Get("JOB-TOKEN"))
+
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_API_V4_URL", server.URL+"/api/v4")
+	t.Setenv("CI_JOB_TOKEN", "job-token")
+	t.Setenv("SCM_ENGINE_TOKEN", "")
+
+	configPath := filepath.Join(t.TempDir(), ".scm-engine.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("label: []\n"), 0o600))
+
+	app := &cli.App{
+		Flags:    []cli.Flag{&cli.StringFlag{Name: cmd.FlagConfigFile}},
+		Commands: []*cli.Command{cmd.GitLab},
+	}
+
+	// The evaluation fails on the 401 responses, only the authentication matters here
+	_ = app.Run([]string{"scm-engine", "--" + cmd.FlagConfigFile, configPath, "gitlab", "evaluate", "--" + cmd.FlagSCMProject, "jippi/scm-engine", "--" + cmd.FlagMergeRequestID, "1", "--" + cmd.FlagUpdatePipeline + "=false", "--" + cmd.FlagNoCache})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.NotEmpty(t, headers)
+
+	for _, header := range headers {
+		require.Equal(t, "job-token", header)
+	}
+}
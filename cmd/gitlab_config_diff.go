@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/urfave/cli/v2"
+)
+
+func ConfigDiff(cCtx *cli.Context) error {
+	ctx := cCtx.Context
+
+	if cCtx.Args().Len() != 2 {
+		return errors.New("Expected exactly two arguments: the old and the new configuration file")
+	}
+
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous, err := loadResolvedConfig(cCtx, client, cCtx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	next, err := loadResolvedConfig(cCtx, client, cCtx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	diff := config.DiffConfigs(previous, next)
+
+	if !cCtx.Bool(FlagQuiet) {
+		diff.Write(cCtx.App.Writer)
+	}
+
+	// Exit non-zero so the command can be used for gating changes
+	if diff.HasChanges() {
+		return cli.Exit("", 1)
+	}
+
+	return nil
+}
+
+// loadResolvedConfig loads the configuration file at [path] with all of its 'include' settings resolved
+func loadResolvedConfig(cCtx *cli.Context, client scm.Client, path string) (*config.Config, error) {
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config file [%s]: %w", path, err)
+	}
+
+	if err := cfg.LoadIncludes(cCtx.Context, client); err != nil {
+		return nil, fmt.Errorf("failed to load 'include' settings for config file [%s]: %w", path, err)
+	}
+
+	return cfg, nil
+}
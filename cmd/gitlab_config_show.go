@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func ConfigShow(cCtx *cli.Context) error {
+	ctx := cCtx.Context
+
+	baseURL, project, id, err := parseMergeRequestURL(cCtx.String(FlagMergeRequestURL))
+	if err != nil {
+		return err
+	}
+
+	ctx = state.WithBaseURL(ctx, baseURL)
+	ctx = state.WithProjectID(ctx, project)
+	ctx = state.WithMergeRequestID(ctx, id)
+	ctx = state.WithConfigFilePath(ctx, cCtx.String(FlagConfigFile))
+
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Pin to the head commit, exactly like an evaluation would
+	sha, err := client.MergeRequests().HeadSHA(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = state.WithCommitSHA(ctx, sha)
+
+	// Merge the global configuration file (if any) into the configuration, exactly like the server would
+	ctx, err = loadGlobalConfig(ctx, cCtx, client)
+	if err != nil {
+		return err
+	}
+
+	evalContext, err := client.EvalContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if evalContext == nil || !evalContext.IsValid() {
+		return fmt.Errorf("merge request !%s in project [%s] could not be found", id, project)
+	}
+
+	_, cfg, err := resolveConfig(ctx, client, evalContext, nil)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(cCtx.App.Writer, cfg, cCtx.Bool(FlagJSON))
+}
+
+// parseMergeRequestURL splits a Merge Request URL (example: 'https://gitlab.com/gitlab-org/gitlab/-/merge_requests/1')
+// into the GitLab base URL, the project path and the Merge Request ID
+func parseMergeRequestURL(in string) (string, string, string, error) {
+	if len(in) == 0 {
+		return "", "", "", errors.New("Missing required flag: --" + FlagMergeRequestURL)
+	}
+
+	u, err := url.Parse(in)
+	if err != nil {
+		return "", "", "", fmt.Errorf("could not parse merge request URL: %w", err)
+	}
+
+	project, rest, ok := strings.Cut(strings.Trim(u.Path, "/"), "/-/merge_requests/")
+	if !ok || len(project) == 0 || len(u.Host) == 0 {
+		return "", "", "", fmt.Errorf("%q is not a merge request URL (example: 'https://gitlab.com/gitlab-org/gitlab/-/merge_requests/1')", in)
+	}
+
+	// Ignore any trailing path, e.g. '/diffs'
+	id, _, _ := strings.Cut(rest, "/")
+
+	return u.Scheme + "://" + u.Host + "/", project, id, nil
+}
+
+// writeConfig writes the configuration as JSON, or YAML with the same keys as the configuration file
+func writeConfig(w io.Writer, cfg *config.Config, asJSON bool) error {
+	// Use the JSON representation for both, since it's complete (e.g. label priority) and uses the configuration file keys
+	out, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		fmt.Fprintln(w, string(out))
+
+		return nil
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(out, &node); err != nil {
+		return err
+	}
+
+	resetYAMLStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// resetYAMLStyle removes the JSON (flow and quoted) styles from the node tree, so it's rendered as regular YAML
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
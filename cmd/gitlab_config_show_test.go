@@ -0,0 +1,154 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// configShowGitLab is a fake GitLab API serving Merge Request !1 of 'jippi/scm-engine', its configuration file
+// and the global configuration file in 'platform/policy'
+func configShowGitLab(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/v4/projects/jippi/scm-engine/merge_requests/1":
+			w.Write([]byte(`{"iid": 1, "sha": "abc123"}`))
+
+		case r.URL.Path == "/api/v4/projects/jippi/scm-engine/repository/files/.scm-engine.yml/raw":
+			require.Equal(t, "abc123", r.URL.Query().Get("ref"))
+
+			w.Write([]byte("label:\n  - name: project-label\n    script: \"true\"\n"))
+
+		case r.URL.Path == "/api/graphql" && strings.Contains(string(body), "blobs"):
+			require.Contains(t, string(body), `"project":"platform/policy"`)
+
+			w.Write([]byte(`{"data": {"project": {"repository": {"blobs": {"nodes": [{"path": ".scm-engine.yml", "rawBlob": "label:\n  - name: global-label\n    script: \"true\"\n"}]}}}}}`))
+
+		case r.URL.Path == "/api/graphql":
+			w.Write([]byte(`{"data": {"project": {"labels": {"nodes": []}, "mergeRequest": {"iid": "1", "title": "Add feature", "sourceProjectId": 1, "targetProjectId": 1, "labels": {"nodes": []}, "notes": {"nodes": []}, "first_commit": {"nodes": []}, "last_commit": {"nodes": []}}}}}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Not Found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// runConfigShow runs 'gitlab config show' with [args], returning the output
+func runConfigShow(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	app := &cli.App{
+		Writer:   &out,
+		Flags:    []cli.Flag{&cli.StringFlag{Name: cmd.FlagConfigFile, Value: ".scm-engine.yml"}},
+		Commands: []*cli.Command{cmd.GitLab},
+	}
+
+	err := app.Run(append([]string{"scm-engine", "gitlab", "--" + cmd.FlagAPIToken, "config-show-api-token", "config", "show"}, args...))
+
+	return out.String(), err
+}
+
+func TestConfigShow(t *testing.T) {
+	t.Parallel()
+
+	server := configShowGitLab(t)
+	url := server.URL + "/jippi/scm-engine/-/merge_requests/1/diffs"
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := runConfigShow(t, "--"+cmd.FlagMergeRequestURL, url)
+		require.NoError(t, err)
+		require.Contains(t, out, "label:\n  - name: project-label\n")
+		require.Contains(t, out, `script: "true"`)
+		require.NotContains(t, out, "global-label")
+
+		// Rendered as regular YAML, rather than the JSON (flow and quoted) style
+		require.NotContains(t, out, `"name"`)
+		require.NotContains(t, out, "[{")
+	})
+
+	t.Run("with the global configuration file", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := runConfigShow(t, "--"+cmd.FlagMergeRequestURL, url, "--"+cmd.FlagGlobalConfigProject, "platform/policy")
+		require.NoError(t, err)
+		require.Contains(t, out, "- name: project-label\n")
+		require.Contains(t, out, "- name: global-label\n")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := runConfigShow(t, "--"+cmd.FlagMergeRequestURL, url, "--"+cmd.FlagJSON)
+		require.NoError(t, err)
+
+		var cfg map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out), &cfg))
+		require.Equal(t, "project-label", cfg["label"].([]any)[0].(map[string]any)["name"]) //nolint:forcetypeassert
+	})
+}
+
+func TestConfigShow_MergeRequestURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{
+			name:    "missing",
+			wantErr: "Missing required flag: --" + cmd.FlagMergeRequestURL,
+		},
+		{
+			name:    "not a merge request",
+			url:     "https://gitlab.com/gitlab-org/gitlab/-/issues/1",
+			wantErr: `"https://gitlab.com/gitlab-org/gitlab/-/issues/1" is not a merge request URL`,
+		},
+		{
+			name:    "no project",
+			url:     "https://gitlab.com/-/merge_requests/1",
+			wantErr: "is not a merge request URL",
+		},
+		{
+			name:    "no host",
+			url:     "/gitlab-org/gitlab/-/merge_requests/1",
+			wantErr: "is not a merge request URL",
+		},
+		{
+			name:    "unparsable",
+			url:     "https://gitlab.com/%zz",
+			wantErr: "could not parse merge request URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := runConfigShow(t, "--"+cmd.FlagMergeRequestURL, tt.url)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
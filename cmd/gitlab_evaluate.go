@@ -1,21 +1,98 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/jippi/scm-engine/pkg/config"
 	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/urfave/cli/v2"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// Output formats for the 'evaluate' command
+const (
+	OutputText = "text"
+	OutputJSON = "json"
 )
 
 func Evaluate(cCtx *cli.Context) error {
 	ctx := cCtx.Context
+
+	var gate *FailOnGate
+
+	if predicate := cCtx.String(FlagFailOn); len(predicate) > 0 {
+		var err error
+
+		gate, err = NewFailOnGate(predicate)
+		if err != nil {
+			return err
+		}
+	}
+
+	output := cCtx.String(FlagOutput)
+	if output != "" && output != OutputText && output != OutputJSON {
+		return fmt.Errorf("--%s must be either '%s' or '%s', got %q", FlagOutput, OutputText, OutputJSON, output)
+	}
+
+	// Fail before evaluating anything if the reconcile report can't be written
+	reconcilePath := cCtx.String(FlagReconcileReport)
+	if len(reconcilePath) > 0 {
+		if _, err := ReconcileFormat(reconcilePath); err != nil {
+			return err
+		}
+	}
+
+	if output != OutputJSON && gate == nil && len(reconcilePath) == 0 {
+		return evaluate(ctx, cCtx)
+	}
+
+	ctx, report := withEvaluationReport(ctx)
+
+	// Write the reports even if the evaluation failed, the error is included for each Merge Request
+	err := evaluate(ctx, cCtx)
+
+	if output == OutputJSON {
+		if writeErr := report.Write(cCtx.App.Writer); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+	}
+
+	if len(reconcilePath) > 0 {
+		if writeErr := writeReconcileReport(report, reconcilePath); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+	}
+
+	if err != nil || gate == nil {
+		return err
+	}
+
+	return checkFailOnGate(gate, report)
+}
+
+// checkFailOnGate exits non-zero if the --fail-on [gate] matched any of the evaluated Merge Requests in the [report]
+func checkFailOnGate(gate *FailOnGate, report *EvaluationReport) error {
+	if err := gate.Check(report); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	return nil
+}
+
+func evaluate(ctx context.Context, cCtx *cli.Context) error {
 	ctx = state.WithCommitSHA(ctx, cCtx.String(FlagCommitSHA))
 	ctx = state.WithConfigFilePath(ctx, cCtx.String(FlagConfigFile))
 	ctx = state.WithProjectID(ctx, cCtx.String(FlagSCMProject))
-	ctx = state.WithToken(ctx, cCtx.String(FlagAPIToken))
 	ctx = state.WithUpdatePipeline(ctx, cCtx.Bool(FlagUpdatePipeline), cCtx.String(FlagUpdatePipelineURL))
+	ctx = state.WithReplayFailedActions(ctx, cCtx.Bool(FlagReplayFailed))
+
+	if !cCtx.Bool(FlagNoCache) {
+		ctx = state.WithResponseCache(ctx, cCtx.String(FlagCacheDir), cCtx.Duration(FlagCacheTTL))
+	}
 
 	cfg, err := config.LoadFile(state.ConfigFilePath(ctx))
 	if err != nil {
@@ -27,22 +104,29 @@ func Evaluate(cCtx *cli.Context) error {
 		return err
 	}
 
+	// Only evaluate the rules that changed since the previous configuration file, if it can be loaded
+	if path := cCtx.String(FlagChangedRulesSince); len(path) > 0 {
+		previous, err := loadResolvedConfig(cCtx, client, path)
+		if err != nil {
+			slogctx.Warn(ctx, "Could not load the previous configuration file, evaluating all rules", slog.Any("error", err))
+		} else {
+			ctx = withPreviousConfig(ctx, previous)
+		}
+	}
+
 	switch {
-	// If first arg is 'all' we will find all opened MRs and apply the rules to them
-	case cCtx.Args().First() == "all":
-		res, err := client.MergeRequests().List(ctx, &scm.ListMergeRequestsOptions{State: "opened", First: 100})
+	// If first arg is 'all' (or --all-open is set) we will find all opened MRs and apply the rules to them
+	case cCtx.Args().First() == "all" || cCtx.Bool(FlagAllOpen):
+		res, err := client.MergeRequests().List(ctx, allOpenListOptions(cCtx))
 		if err != nil {
 			return err
 		}
 
-		for _, mr := range res {
-			ctx := state.WithMergeRequestID(ctx, mr.ID)
-			ctx = state.WithCommitSHA(ctx, mr.SHA)
+		return EvaluateMergeRequests(ctx, client, cfg, res, cCtx.Int(FlagConcurrency), cCtx.Duration(FlagDrainTimeout))
 
-			if err := ProcessMR(ctx, client, cfg, nil); err != nil {
-				return err
-			}
-		}
+	// The filters only narrow down the open Merge Requests
+	case hasAllOpenFilters(cCtx):
+		return fmt.Errorf("--%s, --%s, --%s and --%s can only be used with --%s", FlagAllOpenLabel, FlagAllOpenAuthor, FlagAllOpenMilestone, FlagAllOpenSearch, FlagAllOpen)
 
 	// If the flag is set, use that for evaluation
 	case cCtx.String(FlagMergeRequestID) != "":
@@ -66,3 +150,20 @@ func Evaluate(cCtx *cli.Context) error {
 
 	return nil
 }
+
+// allOpenListOptions returns the options for listing the open Merge Requests to evaluate with --all-open,
+// narrowed down by the --all-open-* filters (if any), which are passed through to the GitLab API
+func allOpenListOptions(cCtx *cli.Context) *scm.ListMergeRequestsOptions {
+	return &scm.ListMergeRequestsOptions{
+		State:          "opened",
+		First:          100,
+		Labels:         cCtx.StringSlice(FlagAllOpenLabel),
+		AuthorUsername: cCtx.String(FlagAllOpenAuthor),
+		MilestoneTitle: cCtx.String(FlagAllOpenMilestone),
+		Search:         cCtx.String(FlagAllOpenSearch),
+	}
+}
+
+func hasAllOpenFilters(cCtx *cli.Context) bool {
+	return cCtx.IsSet(FlagAllOpenLabel) || cCtx.IsSet(FlagAllOpenAuthor) || cCtx.IsSet(FlagAllOpenMilestone) || cCtx.IsSet(FlagAllOpenSearch)
+}
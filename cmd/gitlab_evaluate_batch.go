@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type evaluationOutcome struct {
+	id  string
+	err error
+}
+
+// EvaluateMergeRequests evaluates [mergeRequests] using a pool of [concurrency] workers.
+//
+// All workers share the same [scm.Client], and thus the same API rate limiter.
+//
+// On SIGINT/SIGTERM no new Merge Requests are started, and in-flight evaluations are given
+// [drainTimeout] to finish before they are cancelled.
+func EvaluateMergeRequests(ctx context.Context, client scm.Client, cfg *config.Config, mergeRequests []scm.ListMergeRequest, concurrency int, drainTimeout time.Duration) error {
+	concurrency = max(concurrency, 1)
+
+	// The context used to signal that no new evaluations should be started
+	stopCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The context used by in-flight evaluations; only cancelled once the drain timeout is exceeded
+	//
+	// NOTE: deferred after "stop" so it's cancelled first when we return, which tells the drain goroutine below to exit
+	workCtx, cancelWork := context.WithCancel(ctx)
+	defer cancelWork()
+
+	go func() {
+		<-stopCtx.Done()
+
+		if workCtx.Err() != nil {
+			return
+		}
+
+		slogctx.Warn(ctx, "Got SIGINT/SIGTERM, waiting for in-flight evaluations to finish", slog.Duration("drain_timeout", drainTimeout))
+
+		select {
+		case <-time.After(drainTimeout):
+			slogctx.Error(ctx, "Drain timeout exceeded, cancelling in-flight evaluations")
+
+			cancelWork()
+
+		case <-workCtx.Done():
+		}
+	}()
+
+	var (
+		queue     = make(chan scm.ListMergeRequest)
+		outcomes  = make(chan evaluationOutcome, len(mergeRequests))
+		processed atomic.Int64
+		total     = len(mergeRequests)
+		wg        sync.WaitGroup
+	)
+
+	slogctx.Info(ctx, "Evaluating Merge Requests", slog.Int("total", total), slog.Int("concurrency", concurrency))
+
+	for range concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for mergeRequest := range queue {
+				ctx := state.WithMergeRequestID(workCtx, mergeRequest.ID)
+				ctx = state.WithCommitSHA(ctx, mergeRequest.SHA)
+
+				err := ProcessMR(ctx, client, cfg, nil)
+				if err != nil {
+					slogctx.Error(ctx, "Failed to evaluate Merge Request", slog.Any("error", err))
+				}
+
+				outcomes <- evaluationOutcome{id: mergeRequest.ID, err: err}
+
+				slogctx.Info(ctx, fmt.Sprintf("Progress: %d/%d Merge Requests processed", processed.Add(1), total))
+			}
+		}()
+	}
+
+dispatch:
+	for _, mergeRequest := range mergeRequests {
+		select {
+		case queue <- mergeRequest:
+
+		case <-stopCtx.Done():
+			break dispatch
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+	close(outcomes)
+
+	// Final report
+	var (
+		result    error
+		succeeded int
+	)
+
+	for outcome := range outcomes {
+		if outcome.err != nil {
+			result = multierror.Append(result, fmt.Errorf("Merge Request %s: %w", outcome.id, outcome.err))
+
+			continue
+		}
+
+		succeeded++
+	}
+
+	failed := int(processed.Load()) - succeeded
+	skipped := total - int(processed.Load())
+
+	slogctx.Info(ctx, "Evaluation report", slog.Int("total", total), slog.Int("succeeded", succeeded), slog.Int("failed", failed), slog.Int("skipped", skipped))
+
+	if skipped > 0 {
+		result = multierror.Append(result, fmt.Errorf("evaluation was interrupted; %d Merge Requests were not processed", skipped))
+	}
+
+	return result
+}
@@ -0,0 +1,132 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+// testMergeRequests returns [n] Merge Requests with the IDs 1 to [n]
+func testMergeRequests(n int) []scm.ListMergeRequest {
+	mergeRequests := make([]scm.ListMergeRequest, 0, n)
+
+	for i := 1; i <= n; i++ {
+		mergeRequests = append(mergeRequests, scm.ListMergeRequest{ID: strconv.Itoa(i)})
+	}
+
+	return mergeRequests
+}
+
+func TestEvaluateMergeRequests(t *testing.T) {
+	t.Parallel()
+
+	// newClient returns a client running a single action per Merge Request with [applyStep]
+	newClient := func(t *testing.T, applyStep func(ctx context.Context) error) (context.Context, *testClient) {
+		t.Helper()
+
+		api := &testGitLab{
+			config: func(string) string {
+				return "actions:\n  - name: comment\n    if: 'true'\n    then:\n      - action: comment\n        message: hello\n"
+			},
+			headSHA: func() string { return "" },
+		}
+
+		ctx, gitlabClient := newTestGitLab(t, api)
+
+		return ctx, &testClient{
+			Client:      gitlabClient,
+			evalContext: &testEvalContext{},
+			applyStep: func(ctx context.Context, _ scm.ActionStep) error {
+				return applyStep(ctx)
+			},
+		}
+	}
+
+	t.Run("no more Merge Requests than the concurrency are evaluated at once", func(t *testing.T) {
+		t.Parallel()
+
+		var inFlight, maxInFlight, evaluated atomic.Int32
+
+		ctx, client := newClient(t, func(context.Context) error {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			evaluated.Add(1)
+
+			return nil
+		})
+
+		require.NoError(t, cmd.EvaluateMergeRequests(ctx, client, nil, testMergeRequests(8), 3, time.Second))
+		require.Equal(t, int32(8), evaluated.Load())
+		require.LessOrEqual(t, maxInFlight.Load(), int32(3))
+		require.Greater(t, maxInFlight.Load(), int32(1))
+	})
+
+	t.Run("the errors of every Merge Request are collected", func(t *testing.T) {
+		t.Parallel()
+
+		var evaluated atomic.Int32
+
+		ctx, client := newClient(t, func(ctx context.Context) error {
+			evaluated.Add(1)
+
+			switch state.MergeRequestID(ctx) {
+			case "2", "4":
+				return errors.New("the webhook timed out")
+			}
+
+			return nil
+		})
+
+		err := cmd.EvaluateMergeRequests(ctx, client, nil, testMergeRequests(5), 2, time.Second)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Merge Request 2:")
+		require.Contains(t, err.Error(), "Merge Request 4:")
+		require.NotContains(t, err.Error(), "Merge Request 1:")
+		require.NotContains(t, err.Error(), "interrupted")
+
+		// One failing Merge Request doesn't stop the others from being evaluated
+		require.Equal(t, int32(5), evaluated.Load())
+	})
+
+	t.Run("cancelling the context stops new evaluations and reports the skipped Merge Requests", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			evaluated atomic.Int32
+			cancel    context.CancelFunc
+		)
+
+		testCtx, client := newClient(t, func(context.Context) error {
+			if evaluated.Add(1) == 1 {
+				cancel()
+			}
+
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(testCtx)
+		defer cancel()
+
+		err := cmd.EvaluateMergeRequests(ctx, client, nil, testMergeRequests(10), 1, time.Second)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "evaluation was interrupted")
+		require.Less(t, evaluated.Load(), int32(10))
+	})
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/urfave/cli/v2"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// LabelsRestore replaces the labels of a Merge Request with the ones recorded by the 'snapshot_labels' action
+func LabelsRestore(cCtx *cli.Context) error {
+	ctx := cCtx.Context
+
+	baseURL, project, id, err := parseMergeRequestURL(cCtx.String(FlagMergeRequestURL))
+	if err != nil {
+		return err
+	}
+
+	ctx = state.WithBaseURL(ctx, baseURL)
+	ctx = state.WithProjectID(ctx, project)
+	ctx = state.WithMergeRequestID(ctx, id)
+
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := client.MergeRequests().FindNote(ctx, scm.LabelSnapshotMarker)
+	if err != nil {
+		return err
+	}
+
+	if len(body) == 0 {
+		return fmt.Errorf("merge request !%s in project [%s]: %w", id, project, scm.ErrNoLabelSnapshot)
+	}
+
+	labels, err := scm.ParseLabelSnapshot(body)
+	if err != nil {
+		return fmt.Errorf("merge request !%s in project [%s]: %w", id, project, err)
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Restoring labels", slog.Any("labels", labels))
+
+		return nil
+	}
+
+	if _, err := client.MergeRequests().Update(ctx, &scm.UpdateMergeRequestOptions{Labels: scm.Ptr(scm.LabelOptions(labels))}); err != nil {
+		return fmt.Errorf("could not restore labels: %w", err)
+	}
+
+	fmt.Fprintf(cCtx.App.Writer, "Restored the labels of merge request !%s in project [%s] to: %s\n", id, project, strings.Join(labels, ", "))
+
+	return nil
+}
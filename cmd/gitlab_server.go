@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"errors"
+	"expvar"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
@@ -12,6 +14,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/cron"
+	"github.com/jippi/scm-engine/pkg/redact"
 	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/urfave/cli/v2"
@@ -21,15 +26,161 @@ import (
 func Server(cCtx *cli.Context) error {
 	var wg sync.WaitGroup
 
+	missingConfigBehavior, err := config.ParseMissingConfigBehavior(cCtx.String(FlagMissingConfigBehavior))
+	if err != nil {
+		return err
+	}
+
+	missingCommitSHABehavior, err := parseMissingCommitSHABehavior(cCtx.String(FlagMissingCommitSHABehavior))
+	if err != nil {
+		return err
+	}
+
 	// Setup context configuration
 	ctx := cCtx.Context
 	ctx = state.WithConfigFilePath(ctx, cCtx.String(FlagConfigFile))
+	ctx = state.WithMissingConfigBehavior(ctx, string(missingConfigBehavior))
+	ctx = state.WithMissingCommitSHABehavior(ctx, missingCommitSHABehavior)
 	ctx = state.WithUpdatePipeline(ctx, cCtx.Bool(FlagUpdatePipeline), cCtx.String(FlagUpdatePipelineURL))
+	ctx = state.WithTokenMappings(ctx, cCtx.StringSlice(FlagAPITokenMapping))
+	ctx = state.WithTriggerOnChanges(ctx, cCtx.StringSlice(FlagTriggerOnChanges))
+	ctx = state.WithEvaluateArchivedProjects(ctx, cCtx.Bool(FlagEvaluateArchivedProjects))
+	ctx = WithRequeuer(ctx)
+	ctx = WithDebounce(ctx, cCtx.Duration(FlagDebounce))
+
+	if cCtx.Bool(FlagWebhookLogNewFields) {
+		ctx = WithPayloadFieldLogging(ctx)
+	}
+
+	// Validate the token mappings before we start serving requests
+	mappings, err := parseTokenMappings(state.TokenMappings(ctx))
+	if err != nil {
+		return err
+	}
+
+	// Keep the tokens and secrets out of logs and error responses
+	redact.Register(cCtx.String(FlagWebhookSecret), cCtx.String(FlagSystemHookSecret), cCtx.String(FlagAuditWebhookSecret))
+
+	for _, mapping := range mappings {
+		redact.Register(mapping.token)
+	}
+
+	// Validate the watched fields before we start serving requests
+	if err := validateTriggerOnChanges(state.TriggerOnChanges(ctx)); err != nil {
+		return err
+	}
+
+	// Validate the evaluation schedule (if any) before we start serving requests
+	var (
+		schedule  *cron.Schedule
+		scheduled *ScheduledEvaluation
+	)
+
+	if spec := cCtx.String(FlagSchedule); len(spec) > 0 {
+		schedule, err = cron.Parse(spec)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %w", FlagSchedule, err)
+		}
+
+		scheduled, err = NewScheduledEvaluation(ctx, cCtx.StringSlice(FlagScheduleProjects), cCtx.Int(FlagConcurrency), cCtx.Duration(FlagDrainTimeout))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Read the global configuration file (if any) before we start serving requests
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, err = loadGlobalConfig(ctx, cCtx, client)
+	if err != nil {
+		return err
+	}
+
+	// Fail fast if the server can't do its job, rather than failing every webhook
+	if cCtx.Bool(FlagSkipSelfTest) {
+		slogctx.Warn(ctx, "Skipping the startup self-test")
+	} else if err := SelfTest(ctx); err != nil {
+		return err
+	}
+
+	// Ship the outcome of every evaluation to the audit webhook (if any)
+	var audit *AuditWebhook
+
+	if url := cCtx.String(FlagAuditWebhookURL); len(url) > 0 {
+		audit, err = NewAuditWebhook(url, cCtx.StringSlice(FlagAuditWebhookHeader), cCtx.String(FlagAuditWebhookSecret))
+		if err != nil {
+			return err
+		}
+
+		ctx = withAuditWebhook(ctx, audit)
+	}
+
+	// Count evaluations by rule, opt-in since every rule adds metrics
+	if cCtx.Bool(FlagRuleMetrics) {
+		maxRules := cCtx.Int(FlagRuleMetricsMaxRules)
+		if maxRules < 1 {
+			return fmt.Errorf("--%s must be at least 1, got %d", FlagRuleMetricsMaxRules, maxRules)
+		}
+
+		metrics := config.NewRuleMetrics(maxRules)
+		expvar.Publish("rule_metrics", metrics)
+
+		ctx = config.WithRuleMetrics(ctx, metrics)
+	}
+
+	// Validate the profiling address (if any) before we start serving requests
+	var pprofServer *http.Server
+
+	if addr := cCtx.String(FlagPprofListen); len(addr) > 0 {
+		pprofServer, err = NewPprofServer(addr)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Add logging context key/value pairs
 	ctx = slogctx.With(ctx, slog.String("gitlab_url", cCtx.String(FlagSCMBaseURL)))
 	ctx = slogctx.With(ctx, slog.Duration("server_timeout", cCtx.Duration(FlagServerTimeout)))
 
+	// Initialize the webhook handler (and its GitLab clients) before we start serving requests
+	webhookHandler, err := GitLabWebhookHandler(ctx, cCtx.String(FlagWebhookSecret))
+	if err != nil {
+		return err
+	}
+
+	systemHookHandler, err := GitLabSystemHookHandler(ctx, cCtx.String(FlagSystemHookSecret), cCtx.Bool(FlagSystemHookInstallConfig))
+	if err != nil {
+		return err
+	}
+
+	routes := []WebhookRoute{
+		{Flag: FlagWebhookPath, Path: cCtx.String(FlagWebhookPath), Handler: webhookHandler},
+		{Flag: FlagSystemHookPath, Path: cCtx.String(FlagSystemHookPath), Handler: systemHookHandler},
+	}
+
+	// Serve GitHub webhooks from the same server (if configured)
+	if path := cCtx.String(FlagGitHubWebhookPath); len(path) > 0 {
+		if len(cCtx.String(FlagGitHubAPIToken)) == 0 {
+			return fmt.Errorf("--%s is required when --%s is set", FlagGitHubAPIToken, FlagGitHubWebhookPath)
+		}
+
+		redact.Register(cCtx.String(FlagGitHubAPIToken), cCtx.String(FlagGitHubWebhookSecret))
+
+		routes = append(routes, WebhookRoute{
+			Flag:    FlagGitHubWebhookPath,
+			Path:    path,
+			Handler: GitHubRouteHandler(ctx, cCtx.String(FlagGitHubAPIToken), cCtx.String(FlagGitHubWebhookSecret)),
+		})
+	}
+
+	mux, err := NewWebhookRouter(routes...)
+	if err != nil {
+		return err
+	}
+
 	//
 	// Setup periodic evaluation logic
 	//
@@ -45,7 +196,34 @@ func Server(cCtx *cli.Context) error {
 	}
 
 	evalCtx, stopPeriodicEvaluation := context.WithCancel(ctx)
-	startPeriodicEvaluation(evalCtx, cCtx.Duration(FlagPeriodicEvaluationInterval), filter, &wg)
+	startPeriodicEvaluation(evalCtx, cCtx.Duration(FlagPeriodicEvaluationInterval), filter, processPeriodicMR, &wg)
+
+	// Evaluate the allowlisted projects on a cron schedule (if configured)
+	if scheduled != nil {
+		scheduled.Start(evalCtx, schedule, &wg)
+	}
+
+	// Consume webhook events from the registered event sources (if any), see [RegisterEventSource]
+	if err := startEventConsumers(evalCtx, &wg); err != nil {
+		stopPeriodicEvaluation()
+
+		return err
+	}
+
+	// Sweep Merge Requests with expiring labels, so they are removed even without any user activity.
+	// The sweep only removes the expired labels, rather than evaluating all the rules (see [SweepExpiredLabels])
+	if interval := cCtx.Duration(FlagLabelExpirySweepInterval); interval > 0 {
+		for _, label := range cCtx.StringSlice(FlagLabelExpirySweepLabels) {
+			sweepFilter := filter
+			sweepFilter.OnlyMergeRequestsWithLabels = []string{label}
+
+			sweep := func(ctx context.Context, client scm.Client, cfg *config.Config) error {
+				return SweepExpiredLabels(ctx, client, cfg, []string{label})
+			}
+
+			startPeriodicEvaluation(slogctx.With(evalCtx, slog.String("label_expiry_sweep", label)), interval, sweepFilter, sweep, &wg)
+		}
+	}
 
 	//
 	// Setup HTTP server
@@ -54,9 +232,7 @@ func Server(cCtx *cli.Context) error {
 	listenAddr := net.JoinHostPort(cCtx.String(FlagServerListenHost), cCtx.String(FlagServerListenPort))
 	slogctx.Info(ctx, "Starting HTTP server", slog.String("listen_address", listenAddr))
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /_status", GitLabStatusHandler)
-	mux.HandleFunc("POST /gitlab", GitLabWebhookHandler(ctx, cCtx.String(FlagWebhookSecret)))
+	mux.Handle("GET /_metrics", MetricsHandler())
 
 	server := &http.Server{
 		Addr:         listenAddr,
@@ -84,6 +260,10 @@ func Server(cCtx *cli.Context) error {
 		slogctx.Info(ctx, "Stopped serving new connections.")
 	}()
 
+	if pprofServer != nil {
+		startPprofServer(ctx, pprofServer)
+	}
+
 	//
 	// Wait for shutdown signals
 	//
@@ -108,12 +288,26 @@ func Server(cCtx *cli.Context) error {
 		slogctx.Error(ctx, "HTTP shutdown error", slog.Any("error", err))
 	}
 
+	if pprofServer != nil {
+		if err := pprofServer.Shutdown(shutdownCtx); err != nil {
+			slogctx.Error(ctx, "pprof HTTP shutdown error", slog.Any("error", err))
+		}
+	}
+
 	wg.Done() // -1: HTTP Server - shutdown complete
 
 	slogctx.Info(ctx, "Graceful HTTP shutdown complete")
 
+	DrainScheduledEvaluations(ctx) // Evaluate the debounced events, and drop the re-queued evaluations
+
 	wg.Wait() // Wait for PeriodicEvaluation to complete
 
+	if audit != nil {
+		audit.Wait() // Wait for pending audit records to be delivered
+	}
+
+	WaitForPostEvaluationHooks()
+
 	slogctx.Info(ctx, "Graceful shutdown complete")
 
 	return nil
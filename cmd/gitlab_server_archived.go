@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// archivedProjectChecker is implemented by clients that can check if a project is archived
+type archivedProjectChecker interface {
+	IsProjectArchived(ctx context.Context, project string) (bool, error)
+}
+
+// isArchivedProject returns whether the [project] of the webhook event is archived (and thus read-only), using the
+// payload if it has the information, and otherwise looking up the project.
+//
+// Projects that can't be looked up are assumed not to be archived, so the evaluation surfaces the actual error
+func isArchivedProject(ctx context.Context, client scm.Client, project GitlabWebhookPayloadProject) bool {
+	if project.Archived != nil {
+		return *project.Archived
+	}
+
+	checker, ok := client.(archivedProjectChecker)
+	if !ok {
+		return false
+	}
+
+	archived, err := checker.IsProjectArchived(ctx, project.PathWithNamespace)
+	if err != nil {
+		slogctx.Warn(ctx, "Could not check if the project is archived, evaluating it anyway", slog.Any("error", err))
+
+		return false
+	}
+
+	return archived
+}
@@ -0,0 +1,133 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_ArchivedProject(t *testing.T) {
+	t.Parallel()
+
+	type fixture struct {
+		handler  http.HandlerFunc
+		requests func() []string
+	}
+
+	setup := func(t *testing.T, evaluateArchived bool) fixture {
+		t.Helper()
+
+		var (
+			mu       sync.Mutex
+			requests []string
+		)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests = append(requests, r.URL.EscapedPath())
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+
+			switch r.URL.EscapedPath() {
+			case "/api/v4/projects/jippi%2Farchived":
+				w.Write([]byte(`{"id": 1, "archived": true}`))
+
+			case "/api/v4/projects/jippi%2Factive":
+				w.Write([]byte(`{"id": 2, "archived": false}`))
+
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "404 Not Found"}`))
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		ctx := context.Background()
+		ctx = state.WithProvider(ctx, "gitlab")
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+		ctx = state.WithEvaluateArchivedProjects(ctx, evaluateArchived)
+
+		handler, err := cmd.GitLabWebhookHandler(ctx, "")
+		require.NoError(t, err)
+
+		return fixture{
+			handler: func(w http.ResponseWriter, r *http.Request) { handler(w, r.WithContext(ctx)) },
+			requests: func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+
+				return append([]string(nil), requests...)
+			},
+		}
+	}
+
+	send := func(t *testing.T, f fixture, project string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body := `{"event_type": "merge_request", "project": ` + project + `, "object_attributes": {"iid": 1, "action": "update", "last_commit": {"id": "abc123"}}}`
+
+		req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		f.handler(recorder, req)
+
+		return recorder
+	}
+
+	configFetched := func(requests []string) bool {
+		for _, path := range requests {
+			if strings.Contains(path, "/repository/files/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	t.Run("archived payloads are skipped without any API requests", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t, false)
+
+		recorder := send(t, f, `{"path_with_namespace": "jippi/active", "archived": true}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "OK - skipped, the project is archived", recorder.Body.String())
+		require.Empty(t, f.requests())
+	})
+
+	t.Run("projects are looked up when the payload doesn't say", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t, false)
+
+		recorder := send(t, f, `{"path_with_namespace": "jippi/archived"}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "OK - skipped, the project is archived", recorder.Body.String())
+		require.Equal(t, []string{"/api/v4/projects/jippi%2Farchived"}, f.requests())
+
+		// Active projects are evaluated
+		send(t, f, `{"path_with_namespace": "jippi/active"}`)
+		require.True(t, configFetched(f.requests()))
+	})
+
+	t.Run("archived projects are evaluated when configured", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t, true)
+
+		recorder := send(t, f, `{"path_with_namespace": "jippi/archived", "archived": true}`)
+		require.NotEqual(t, "OK - skipped, the project is archived", recorder.Body.String())
+		require.True(t, configFetched(f.requests()))
+	})
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// triggerOnChangesFields are the Merge Request fields that can be watched with the '--trigger-on-changes' flag
+var triggerOnChangesFields = []string{"title", "labels", "description", "commits"}
+
+func validateTriggerOnChanges(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(triggerOnChangesFields, field) {
+			return fmt.Errorf("invalid --%s field %q, must be one of: %s", FlagTriggerOnChanges, field, strings.Join(triggerOnChangesFields, ", "))
+		}
+	}
+
+	return nil
+}
+
+// changedFields returns the watched fields changed by a "merge_request" event
+//
+// GitLab lists changed attributes in the "changes" payload, while new commits are signaled by "oldrev"
+func changedFields(payload GitlabWebhookPayload, watched []string) []string {
+	var changed []string
+
+	for _, field := range watched {
+		switch field {
+		case "commits":
+			if payload.ObjectAttributes != nil && len(payload.ObjectAttributes.OldRev) > 0 {
+				changed = append(changed, field)
+			}
+
+		default:
+			if _, ok := payload.Changes[field]; ok {
+				changed = append(changed, field)
+			}
+		}
+	}
+
+	return changed
+}
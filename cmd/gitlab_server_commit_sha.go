@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// Behaviors for webhook events without a commit SHA, see [FlagMissingCommitSHABehavior]
+const (
+	// MissingCommitSHALookup reads the head commit of the Merge Request from the API, and skips the event if it has none
+	MissingCommitSHALookup = "lookup"
+	// MissingCommitSHASkip logs and skips the event
+	MissingCommitSHASkip = "skip"
+	// MissingCommitSHAError surfaces the missing commit SHA as an error
+	MissingCommitSHAError = "error"
+)
+
+// parseMissingCommitSHABehavior validates the --missing-commit-sha-behavior value
+func parseMissingCommitSHABehavior(in string) (string, error) {
+	switch in {
+	case MissingCommitSHALookup, MissingCommitSHASkip, MissingCommitSHAError:
+		return in, nil
+
+	default:
+		return "", fmt.Errorf("invalid --%s %q, must be one of '%s', '%s' or '%s'", FlagMissingCommitSHABehavior, in, MissingCommitSHALookup, MissingCommitSHASkip, MissingCommitSHAError)
+	}
+}
+
+// resolveMissingCommitSHA returns the commit to evaluate for a webhook event without a commit SHA,
+// or an empty string if the event should be skipped, see [FlagMissingCommitSHABehavior].
+//
+// Without a commit SHA the configuration file would be read from an empty ref, which fails with a confusing error
+func resolveMissingCommitSHA(ctx context.Context, client scm.Client) (string, error) {
+	switch state.MissingCommitSHABehavior(ctx) {
+	case MissingCommitSHASkip:
+		return "", nil
+
+	case MissingCommitSHAError:
+		return "", fmt.Errorf("the webhook event has no commit SHA; see --%s", FlagMissingCommitSHABehavior)
+
+	default:
+		sha, err := client.MergeRequests().HeadSHA(ctx)
+		if err != nil {
+			return "", fmt.Errorf("the webhook event has no commit SHA, and the Merge Request head commit could not be read: %w", err)
+		}
+
+		if len(sha) == 0 {
+			slogctx.Info(ctx, "The Merge Request has no commits yet")
+
+			return "", nil
+		}
+
+		slogctx.Debug(ctx, "Using the Merge Request head commit, since the webhook event has no commit SHA", slog.String("git_commit_sha", sha))
+
+		return sha, nil
+	}
+}
@@ -0,0 +1,118 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_MissingCommitSHA(t *testing.T) {
+	t.Parallel()
+
+	// A note on a Merge Request without any commits has an empty 'last_commit'
+	const body = `{"event_type": "note", "project": {"path_with_namespace": "jippi/scm-engine", "archived": false}, "object_attributes": {"noteable_type": "MergeRequest"}, "merge_request": {"iid": 1, "last_commit": {"id": ""}}}`
+
+	tests := []struct {
+		name         string
+		behavior     string
+		headSHA      string
+		wantStatus   int
+		wantResponse string
+		wantRequests []string
+	}{
+		{
+			name:         "lookup uses the head commit of the merge request",
+			behavior:     cmd.MissingCommitSHALookup,
+			headSHA:      "abc123",
+			wantStatus:   http.StatusOK,
+			wantResponse: "project has disabled scm-engine",
+			wantRequests: []string{
+				"GET /api/v4/projects/jippi%2Fscm-engine/merge_requests/1",
+				"GET /api/v4/projects/jippi%2Fscm-engine/repository/files/%2Escm-engine%2Eyml/raw?ref=abc123",
+			},
+		},
+		{
+			name:         "lookup skips merge requests without commits",
+			behavior:     cmd.MissingCommitSHALookup,
+			wantStatus:   http.StatusOK,
+			wantResponse: "OK - skipped, the event has no commit SHA",
+			wantRequests: []string{"GET /api/v4/projects/jippi%2Fscm-engine/merge_requests/1"},
+		},
+		{
+			name:         "skip",
+			behavior:     cmd.MissingCommitSHASkip,
+			wantStatus:   http.StatusOK,
+			wantResponse: "OK - skipped, the event has no commit SHA",
+		},
+		{
+			name:         "error",
+			behavior:     cmd.MissingCommitSHAError,
+			wantStatus:   http.StatusBadRequest,
+			wantResponse: "the webhook event has no commit SHA; see --missing-commit-sha-behavior",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, strings.TrimSuffix(r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery, "?"))
+				mu.Unlock()
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch {
+				case r.URL.EscapedPath() == "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1":
+					w.Write([]byte(`{"iid": 1, "sha": "` + tt.headSHA + `"}`))
+
+				case strings.HasPrefix(r.URL.EscapedPath(), "/api/v4/projects/jippi%2Fscm-engine/repository/files/"):
+					// Stop the evaluation right after reading the configuration file
+					w.Write([]byte("enabled: false\n"))
+
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message": "404 Not Found"}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProvider(ctx, "gitlab")
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+			ctx = state.WithMissingCommitSHABehavior(ctx, tt.behavior)
+
+			handler, err := cmd.GitLabWebhookHandler(ctx, "")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, tt.wantStatus, recorder.Code)
+			require.Contains(t, recorder.Body.String(), tt.wantResponse)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_ProjectDisabled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		mu.Unlock()
+
+		if strings.HasPrefix(r.URL.EscapedPath(), "/api/v4/projects/jippi%2Fopted-out/repository/files/") {
+			w.Write([]byte("enabled: false\n\nlabel:\n  - name: bug\n    script: \"true\"\n"))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 Not Found"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	body := `{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/opted-out", "archived": false}, "object_attributes": {"iid": 1, "action": "update", "last_commit": {"id": "abc123"}}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "project has disabled scm-engine in its configuration file")
+
+	// Only the configuration file is read; no pipeline status, labels or evaluation
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, requests, 1)
+	require.Contains(t, requests[0], "/repository/files/")
+}
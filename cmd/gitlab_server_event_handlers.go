@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+)
+
+// EventHandler handles a GitLab webhook event type that scm-engine doesn't handle itself, see [RegisterEventHandler].
+//
+// The context has the project, API token and logger set up like for the built-in events, and [client] is
+// authenticated for the project. [payload] is the raw JSON request body.
+//
+// Returning an error answers the webhook request with the error message, like a failed evaluation
+type EventHandler func(ctx context.Context, client scm.Client, payload []byte) error
+
+// eventHandlers are the custom event handlers, keyed by the payload 'event_type' (or 'object_kind')
+var eventHandlers = struct {
+	sync.RWMutex
+
+	handlers map[string]EventHandler
+}{handlers: map[string]EventHandler{}}
+
+// RegisterEventHandler makes the GitLab webhook handler call [fn] for webhook events of [eventType],
+// for programs using scm-engine as a library to handle events scm-engine doesn't handle itself.
+//
+// [eventType] is matched against the 'event_type' of the payload, or its 'object_kind' for events without
+// one (example: 'push' or 'pipeline'). It's called from 'init()' functions, like [database/sql.Register],
+// and panics if [fn] is nil, or [eventType] is empty, built-in or already registered
+func RegisterEventHandler(eventType string, fn EventHandler) {
+	if len(eventType) == 0 {
+		panic("cmd: RegisterEventHandler event type is empty")
+	}
+
+	if fn == nil {
+		panic("cmd: RegisterEventHandler handler is nil for event type " + eventType)
+	}
+
+	for _, builtin := range gitlabEventHeaders {
+		if builtin == eventType {
+			panic(fmt.Sprintf("cmd: RegisterEventHandler can't override the built-in %q event type", eventType))
+		}
+	}
+
+	eventHandlers.Lock()
+	defer eventHandlers.Unlock()
+
+	if _, ok := eventHandlers.handlers[eventType]; ok {
+		panic(fmt.Sprintf("cmd: RegisterEventHandler called twice for event type %q", eventType))
+	}
+
+	eventHandlers.handlers[eventType] = fn
+}
+
+// eventHandler returns the custom event handler registered for [eventType], if any
+func eventHandler(eventType string) (EventHandler, bool) {
+	eventHandlers.RLock()
+	defer eventHandlers.RUnlock()
+
+	fn, ok := eventHandlers.handlers[eventType]
+
+	return fn, ok
+}
+
+// isCustomEventHeader reports whether the "X-Gitlab-Event" [header] belongs to an event type with a custom
+// event handler, using the GitLab naming convention (example: 'Pipeline Hook' for 'pipeline' events)
+func isCustomEventHeader(header string) bool {
+	eventType := strings.ToLower(strings.ReplaceAll(strings.TrimSuffix(header, " Hook"), " ", "_"))
+
+	_, ok := eventHandler(eventType)
+
+	return ok
+}
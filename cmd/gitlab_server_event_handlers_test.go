@@ -0,0 +1,108 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	customEventsMu sync.Mutex
+	customEvents   []string
+)
+
+func init() {
+	record := func(ctx context.Context, client scm.Client, payload []byte) error {
+		customEventsMu.Lock()
+		defer customEventsMu.Unlock()
+
+		if client == nil {
+			return errors.New("missing client")
+		}
+
+		customEvents = append(customEvents, state.ProjectID(ctx)+" "+string(payload))
+
+		return nil
+	}
+
+	cmd.RegisterEventHandler("custom_scan", record)
+	cmd.RegisterEventHandler("custom_deploy", record)
+	cmd.RegisterEventHandler("custom_failure", func(ctx context.Context, client scm.Client, payload []byte) error {
+		return errors.New("custom handler failed")
+	})
+}
+
+func TestGitLabWebhookHandler_CustomEventHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	serve := func(header, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/gitlab", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		if len(header) > 0 {
+			req.Header.Set("X-Gitlab-Event", header)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+
+		return recorder
+	}
+
+	// Routed on the payload 'event_type', with the event header following the GitLab naming convention
+	scan := `{"event_type": "custom_scan", "project": {"path_with_namespace": "jippi/scm-engine"}}`
+
+	recorder := serve("Custom Scan Hook", scan)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "OK", recorder.Body.String())
+
+	// Routed on the payload 'object_kind' for events without an 'event_type'
+	deploy := `{"object_kind": "custom_deploy", "project": {"path_with_namespace": "jippi/other"}}`
+
+	recorder = serve("", deploy)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	customEventsMu.Lock()
+	require.Equal(t, []string{"jippi/scm-engine " + scan, "jippi/other " + deploy}, customEvents)
+	customEventsMu.Unlock()
+
+	// Handler errors are reported back like failed evaluations
+	recorder = serve("Custom Failure Hook", `{"event_type": "custom_failure", "project": {"path_with_namespace": "jippi/scm-engine"}}`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "custom handler failed", recorder.Body.String())
+
+	// Event types without a handler are still refused
+	recorder = serve("", `{"event_type": "custom_unknown", "project": {"path_with_namespace": "jippi/scm-engine"}}`)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	recorder = serve("Custom Unknown Hook", `{"event_type": "custom_unknown"}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestRegisterEventHandler_Panics(t *testing.T) {
+	t.Parallel()
+
+	noop := func(ctx context.Context, client scm.Client, payload []byte) error { return nil }
+
+	require.PanicsWithValue(t, "cmd: RegisterEventHandler event type is empty", func() { cmd.RegisterEventHandler("", noop) })
+	require.PanicsWithValue(t, "cmd: RegisterEventHandler handler is nil for event type custom_nil", func() { cmd.RegisterEventHandler("custom_nil", nil) })
+	require.PanicsWithValue(t, `cmd: RegisterEventHandler can't override the built-in "merge_request" event type`, func() { cmd.RegisterEventHandler("merge_request", noop) })
+	require.PanicsWithValue(t, `cmd: RegisterEventHandler called twice for event type "custom_scan"`, func() { cmd.RegisterEventHandler("custom_scan", noop) })
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/urfave/cli/v2"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type globalConfigKey struct{}
+
+// withGlobalConfig merges the global configuration file into the configuration of every evaluated Merge Request
+func withGlobalConfig(ctx context.Context, global *config.GlobalConfig) context.Context {
+	return context.WithValue(ctx, globalConfigKey{}, global)
+}
+
+// globalConfigFromContext returns the global configuration file, or nil if none is configured
+func globalConfigFromContext(ctx context.Context) *config.GlobalConfig {
+	global, _ := ctx.Value(globalConfigKey{}).(*config.GlobalConfig)
+
+	return global
+}
+
+// loadGlobalConfig reads the global configuration file configured by [FlagGlobalConfigProject] once at startup,
+// so a missing or broken file is caught before serving any requests
+func loadGlobalConfig(ctx context.Context, cCtx *cli.Context, client scm.Client) (context.Context, error) {
+	project := cCtx.String(FlagGlobalConfigProject)
+	if len(project) == 0 {
+		return ctx, nil
+	}
+
+	var ref *string
+	if cCtx.IsSet(FlagGlobalConfigRef) {
+		ref = scm.Ptr(cCtx.String(FlagGlobalConfigRef))
+	}
+
+	global, err := config.LoadGlobalConfig(ctx, client, project, cCtx.String(FlagGlobalConfigFile), ref)
+	if err != nil {
+		return ctx, err
+	}
+
+	slogctx.Info(ctx, "Loaded global config file", slog.String("global_config_project", global.Project), slog.String("global_config_file", global.File))
+
+	return withGlobalConfig(ctx, global), nil
+}
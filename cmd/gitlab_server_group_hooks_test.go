@@ -0,0 +1,152 @@
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+// groupMergeRequestPayload is a 'merge_request' event as sent by a group webhook of the 'acme' group
+const groupMergeRequestPayload = `{
+	"object_kind": "merge_request",
+	"event_type": "merge_request",
+	"user": {"id": 1, "username": "alice"},
+	"project": {"id": 42, "name": "frontend", "namespace": "web", "path_with_namespace": %q, "archived": false},
+	"object_attributes": {"iid": 7, "action": "update", "source_project_id": 42, "target_project_id": 42, "last_commit": {"id": "abc123"}},
+	"labels": [],
+	"changes": {},
+	"repository": {"name": "frontend"}
+}`
+
+func TestGitLabWebhookHandler_GroupWebhook(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]string{}
+	)
+
+	// Every project has opted out, so only the configuration file is read
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path] = r.Header.Get("Private-Token")
+		mu.Unlock()
+
+		w.Write([]byte("enabled: false\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "default-token")
+	ctx = state.WithTokenMappings(ctx, []string{"acme/web/**=web-token"})
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		project   string
+		token     string
+		wantCode  int
+		wantToken string
+	}{
+		{name: "project in a subgroup", project: "acme/web/frontend", token: "secret", wantCode: http.StatusOK, wantToken: "web-token"},
+		{name: "project in the group", project: "acme/api", token: "secret", wantCode: http.StatusOK, wantToken: "default-token"},
+		{name: "invalid secret", project: "acme/billing", token: "wrong", wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := fmt.Sprintf(groupMergeRequestPayload, tt.project)
+
+			req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+			req.Header.Set("X-Gitlab-Token", tt.token)
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, tt.wantCode, recorder.Code)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			path := "/api/v4/projects/" + tt.project + "/repository/files/.scm-engine.yml/raw"
+
+			token, ok := requests[path]
+			if len(tt.wantToken) == 0 {
+				require.False(t, ok, "the configuration file should not be read")
+
+				return
+			}
+
+			// The configuration file and API token are resolved for the project of the payload
+			require.True(t, ok, "the configuration file of the project should be read")
+			require.Equal(t, tt.wantToken, token)
+		})
+	}
+}
+
+func TestGitLabWebhookHandler_GroupEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+	}{
+		{
+			name:   "subgroup event",
+			header: "Subgroup Hook",
+			body:   `{"event_name": "subgroup_create", "name": "web", "path": "web", "full_path": "acme/web", "parent_full_path": "acme"}`,
+		},
+		{
+			name:   "member event",
+			header: "Member Hook",
+			body:   `{"event_name": "user_add_to_group", "group_path": "acme", "user_username": "alice"}`,
+		},
+		{
+			name: "subgroup event without the event header",
+			body: `{"event_name": "subgroup_destroy", "full_path": "acme/web"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(tt.body)).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitlab-Event", tt.header)
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			// Group events are acknowledged, so GitLab doesn't disable the group webhook for failing
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Contains(t, recorder.Body.String(), "ignored group webhook event")
+		})
+	}
+}
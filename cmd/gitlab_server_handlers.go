@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"bytes"
+	"cmp"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
 
@@ -25,11 +25,11 @@ func GitLabStatusHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("scm-engine status: OK\n\nNOTE: this is a static 'OK', no actual checks are being made"))
 }
 
-func GitLabWebhookHandler(ctx context.Context, webhookSecret string) http.HandlerFunc {
-	// Initialize GitLab client
-	client, err := getClient(ctx)
+func GitLabWebhookHandler(ctx context.Context, webhookSecret string) (http.HandlerFunc, error) {
+	// Initialize GitLab clients
+	clients, err := NewClientPool(ctx)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("could not initialize GitLab clients: %w", err)
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -45,90 +45,196 @@ func GitLabWebhookHandler(ctx context.Context, webhookSecret string) http.Handle
 			}
 		}
 
-		// Validate content type
-		if r.Header.Get("Content-Type") != "application/json" {
-			errHandler(ctx, w, http.StatusNotAcceptable, errors.New("The request is not using Content-Type: application/json"))
+		serveGitLabWebhook(clients, w, r)
+	}, nil
+}
 
-			return
-		}
+// serveGitLabWebhook processes a project or group webhook event (or a system hook event with the same payload).
+//
+// Group webhooks send the same payload as project webhooks for the events of every project in the group,
+// so the project (and with it the API token and configuration file) is always taken from the payload
+func serveGitLabWebhook(clients *ClientPool, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-		// Read the POST body of the request
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			errHandler(ctx, w, http.StatusBadRequest, err)
+	// Validate content type
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		errHandler(ctx, w, http.StatusNotAcceptable, errors.New("The request is not using Content-Type: application/json"))
+
+		return
+	}
+
+	// Use the event header (if provided) for early routing, so unsupported events are rejected before parsing the body
+	headerEvent := r.Header.Get("X-Gitlab-Event")
+
+	expectedEventType, supported := gitlabEventHeaders[headerEvent]
+	if len(headerEvent) > 0 && !supported && !gitlabGroupEventHeaders[headerEvent] && !isCustomEventHeader(headerEvent) {
+		errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("unsupported X-Gitlab-Event header: %q", headerEvent))
+
+		return
+	}
+
+	// Read the POST body of the request
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errHandler(ctx, w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	// Ensure we have content in the POST body
+	if len(body) == 0 {
+		errHandler(ctx, w, http.StatusBadRequest, errors.New("The POST body is empty; expected a JSON payload"))
+
+		return
+	}
+
+	// Decode request payload into both the typed payload and 'any', so we have all the details;
+	// unknown fields are ignored, so changes to the GitLab webhook schema never fail a request
+	payload, fullEventPayload, err := DecodeGitlabWebhookPayload(body)
+	if err != nil {
+		errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("could not decode POST body into Payload struct: %w", err))
+
+		return
+	}
+
+	if tracker := payloadFieldTrackerFromContext(ctx); tracker != nil {
+		tracker.log(ctx, payload.EventType, fullEventPayload)
+	}
+
+	// Group webhooks also send events about the group itself (like a new subgroup), which have no Merge Request to evaluate
+	if gitlabGroupEventHeaders[headerEvent] || (len(payload.EventType) == 0 && len(payload.ObjectKind) == 0 && len(payload.EventName) > 0) {
+		event := cmp.Or(payload.EventName, headerEvent)
+
+		slogctx.Info(ctx, "Ignoring group webhook event", slog.String("group_hook_event", event))
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK - ignored group webhook event " + event))
+
+		return
+	}
+
+	// Ensure the event header and the payload agree on what kind of event this is
+	if supported && payload.EventType != expectedEventType {
+		errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("X-Gitlab-Event header %q does not match payload event_type %q", headerEvent, payload.EventType))
+
+		return
+	}
+
+	// Initialize context
+	ctx = state.WithProjectID(ctx, payload.Project.PathWithNamespace)
+
+	// Select the GitLab client (and token) for the project
+	ctx, client, err := clients.ClientForProject(ctx, payload.Project.PathWithNamespace)
+	if err != nil {
+		errHandler(ctx, w, http.StatusForbidden, err)
+
+		return
+	}
+
+	// Grab event specific information
+	var (
+		id     string
+		gitSha string
+	)
+
+	switch payload.EventType {
+	case "merge_request":
+		if payload.ObjectAttributes == nil {
+			errHandler(ctx, w, http.StatusBadRequest, errors.New("merge request event is missing the 'object_attributes' payload"))
 
 			return
 		}
 
-		// Ensure we have content in the POST body
-		if len(body) == 0 {
-			errHandler(ctx, w, http.StatusBadRequest, errors.New("The POST body is empty; expected a JSON payload"))
-		}
+		id = strconv.Itoa(payload.ObjectAttributes.IID)
+		gitSha = payload.ObjectAttributes.LastCommit.ID
+
+		ctx = slogctx.With(ctx, slog.String("webhook_action", payload.ObjectAttributes.Action), slog.Bool("fork", payload.ObjectAttributes.SourceProjectID != payload.ObjectAttributes.TargetProjectID))
+
+		// Skip updates that didn't change any of the watched fields (if configured)
+		if watched := state.TriggerOnChanges(ctx); payload.ObjectAttributes.Action == "update" && len(watched) > 0 && len(changedFields(payload, watched)) == 0 {
+			slogctx.Info(ctx, "Skipping merge request update without changes to watched fields", slog.Any("watched_fields", watched))
 
-		// Decode request payload
-		var payload GitlabWebhookPayload
-		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&payload); err != nil {
-			errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("could not decode POST body into Payload struct: %w", err))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK - skipped, no watched fields changed"))
 
 			return
 		}
 
-		// Initialize context
-		ctx = state.WithProjectID(ctx, payload.Project.PathWithNamespace)
+		ctx = state.WithTriggerEvent(ctx, GitLabTriggerEvent(payload.ObjectAttributes.Action))
 
-		// Grab event specific information
-		var (
-			id     string
-			gitSha string
-		)
+	case "note":
+		noteableType := ""
+		if payload.ObjectAttributes != nil {
+			noteableType = payload.ObjectAttributes.NoteableType
+		}
+
+		switch noteableType {
+		case "MergeRequest":
+			if payload.MergeRequest == nil {
+				errHandler(ctx, w, http.StatusBadRequest, errors.New("note event on a merge request is missing the 'merge_request' payload"))
 
-		switch payload.EventType {
-		case "merge_request":
-			id = strconv.Itoa(payload.ObjectAttributes.IID)
-			gitSha = payload.ObjectAttributes.LastCommit.ID
+				return
+			}
 
-		case "note":
 			id = strconv.Itoa(payload.MergeRequest.IID)
 			gitSha = payload.MergeRequest.LastCommit.ID
 
+			ctx = state.WithTriggerEvent(ctx, state.TriggerEventNote)
+
+		// scm-engine only evaluates Merge Requests, so notes on other resources are acknowledged but otherwise ignored
+		case "Issue", "Commit", "Snippet":
+			slogctx.Info(ctx, "Ignoring note event", slog.String("noteable_type", noteableType))
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK - ignored note on " + noteableType))
+
+			return
+
 		default:
-			errHandler(ctx, w, http.StatusInternalServerError, fmt.Errorf("unknown event type: %s", payload.EventType))
+			errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("unknown note noteable_type: %q", noteableType))
 
 			return
 		}
 
-		// Build context for rest of the pipeline
-		ctx = state.WithCommitSHA(ctx, gitSha)
-		ctx = state.WithMergeRequestID(ctx, id)
-		ctx = slogctx.With(ctx, slog.String("event_type", payload.EventType))
+	case "issue", "confidential_issue":
+		if payload.ObjectAttributes == nil {
+			errHandler(ctx, w, http.StatusBadRequest, errors.New("issue event is missing the 'object_attributes' payload"))
 
-		slogctx.Info(ctx, "GET /gitlab webhook")
+			return
+		}
 
-		// Decode request payload into 'any' so we have all the details
-		var fullEventPayload any
-		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&fullEventPayload); err != nil {
-			errHandler(ctx, w, http.StatusInternalServerError, err)
+		ctx = slogctx.With(ctx, slog.String("event_type", payload.EventType), slog.String("webhook_action", payload.ObjectAttributes.Action))
+		ctx = state.WithTriggerEvent(ctx, GitLabTriggerEvent(payload.ObjectAttributes.Action))
+
+		slogctx.Info(ctx, "GET /gitlab webhook (issue)")
+
+		if err := ProcessIssue(ctx, client, payload.ObjectAttributes.IID, fullEventPayload); err != nil {
+			errHandler(ctx, w, http.StatusOK, err)
 
 			return
 		}
 
-		// Check if there exists scm-config file in the repo before moving forward
-		file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), state.CommitSHA(ctx))
-		if err != nil {
-			errHandler(ctx, w, http.StatusOK, err)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+
+		return
+
+	default:
+		// Fall back to the event handlers registered by programs using scm-engine as a library
+		eventType := cmp.Or(payload.EventType, payload.ObjectKind)
+
+		handler, ok := eventHandler(eventType)
+		if !ok {
+			errHandler(ctx, w, http.StatusInternalServerError, fmt.Errorf("unknown event type: %s", eventType))
 
 			return
 		}
 
-		// Try to parse the config file
-		//
-		// In case of a parse error cfg remains "nil" and ProcessMR will try to read-and-parse it
-		// (but obviously also fail), but will surface the error within the GitLab External Pipeline (if enabled)
-		// which will surface the issue to the end-user directly
-		cfg, _ := config.ParseFile(file)
+		ctx = slogctx.With(ctx, slog.String("event_type", eventType))
 
-		// Process the MR
-		if err := ProcessMR(ctx, client, cfg, fullEventPayload); err != nil {
+		slogctx.Info(ctx, "GET /gitlab webhook (custom event handler)")
+
+		if err := handler(ctx, client, body); err != nil {
 			errHandler(ctx, w, http.StatusOK, err)
 
 			return
@@ -136,5 +242,110 @@ func GitLabWebhookHandler(ctx context.Context, webhookSecret string) http.Handle
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
+
+		return
+	}
+
+	// Build context for rest of the pipeline
+	ctx = state.WithMergeRequestID(ctx, id)
+
+	// Some events have no commit SHA, for example a note on a Merge Request without any commits
+	if len(gitSha) == 0 {
+		gitSha, err = resolveMissingCommitSHA(ctx, client)
+		if err != nil {
+			errHandler(ctx, w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		if len(gitSha) == 0 {
+			slogctx.Info(ctx, "Skipping event without a commit SHA")
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK - skipped, the event has no commit SHA"))
+
+			return
+		}
+	}
+
+	ctx = state.WithCommitSHA(ctx, gitSha)
+	ctx = slogctx.With(ctx, slog.String("event_type", payload.EventType))
+	ctx = withTagFiltersFromQuery(ctx, r)
+
+	// Fail fast if the payload didn't provide everything we need
+	if err := state.RequireMergeRequestContext(ctx); err != nil {
+		errHandler(ctx, w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	// Archived projects are read-only, so evaluating them would only fail with confusing API errors
+	if !state.EvaluateArchivedProjects(ctx) && isArchivedProject(ctx, client, payload.Project) {
+		slogctx.Info(ctx, "Skipping event for archived project")
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK - skipped, the project is archived"))
+
+		return
+	}
+
+	slogctx.Info(ctx, "GET /gitlab webhook")
+
+	evaluateWebhookEvent(ctx, w, func(ctx context.Context) error {
+		// Check if there exists scm-config file in the repo before moving forward
+		var cfg *config.Config
+
+		file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), state.CommitSHA(ctx))
+		if err != nil {
+			cfg, err = missingConfigFallback(ctx, err)
+			if err != nil {
+				return err
+			}
+		} else {
+			// Try to parse the config file
+			//
+			// In case of a parse error cfg remains "nil" and ProcessMR will try to read-and-parse it
+			// (but obviously also fail), but will surface the error within the GitLab External Pipeline (if enabled)
+			// which will surface the issue to the end-user directly
+			cfg, _ = config.ParseFile(file)
+		}
+
+		// Projects can opt out with 'enabled: false' in their configuration file
+		if err := checkProjectEnabled(ctx, cfg); err != nil {
+			return err
+		}
+
+		// Process the MR
+		return ProcessMR(ctx, client, cfg, fullEventPayload)
+	})
+}
+
+// isJSONContentType reports whether the Content-Type header is a JSON media type,
+// ignoring any parameters (such as "charset=utf-8") proxies might add along the way
+func isJSONContentType(header string) bool {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json"
+}
+
+// GitLabTriggerEvent returns the kind of event (see [state.WithTriggerEvent]) of a 'merge_request' webhook event with [action].
+//
+// Reopening a Merge Request is its own kind of event, so actions with 'run_on: open' (like a welcome comment) don't run again
+func GitLabTriggerEvent(action string) string {
+	switch action {
+	case "open":
+		return state.TriggerEventOpen
+
+	case "reopen":
+		return state.TriggerEventReopen
+
+	case "merge":
+		return state.TriggerEventMerge
+
+	default:
+		return state.TriggerEventUpdate
 	}
 }
@@ -0,0 +1,259 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_EventHeader(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+	}{
+		{
+			name:   "unsupported event header",
+			header: "Push Hook",
+			body:   `{"event_type": "push"}`,
+		},
+		{
+			name:   "header and body mismatch",
+			header: "Note Hook",
+			body:   `{"event_type": "merge_request"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitlab-Event", tt.header)
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
+
+func TestGitLabWebhookHandler_NoteableType(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		noteableType string
+		wantCode     int
+	}{
+		{name: "issue notes are ignored", noteableType: "Issue", wantCode: http.StatusOK},
+		{name: "commit notes are ignored", noteableType: "Commit", wantCode: http.StatusOK},
+		{name: "snippet notes are ignored", noteableType: "Snippet", wantCode: http.StatusOK},
+		{name: "merge request notes require the merge request payload", noteableType: "MergeRequest", wantCode: http.StatusBadRequest},
+		{name: "unknown noteable type", noteableType: "Epic", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := `{"event_type": "note", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"noteable_type": "` + tt.noteableType + `"}}`
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/gitlab", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitlab-Event", "Note Hook")
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, tt.wantCode, recorder.Code)
+		})
+	}
+}
+
+func TestGitLabWebhookHandler_ContentType(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		contentType string
+		wantCode    int
+	}{
+		{name: "json", contentType: "application/json", wantCode: http.StatusOK},
+		{name: "json with charset", contentType: "application/json; charset=utf-8", wantCode: http.StatusOK},
+		{name: "plain text", contentType: "text/plain", wantCode: http.StatusNotAcceptable},
+		{name: "missing", contentType: "", wantCode: http.StatusNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Issue notes are accepted and ignored, so no GitLab API calls are made
+			body := `{"event_type": "note", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"noteable_type": "Issue"}}`
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/gitlab", strings.NewReader(body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, tt.wantCode, recorder.Code)
+		})
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := cmd.RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload *cmd.GitlabWebhookPayload
+
+		w.Write([]byte(payload.EventType))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(`{}`))
+	req.Header.Set("X-Gitlab-Event-UUID", "8e8c8e8c-0000-4000-8000-000000000000")
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.Equal(t, "8e8c8e8c-0000-4000-8000-000000000000", recorder.Header().Get("X-Request-Id"))
+	require.Contains(t, recorder.Body.String(), "request id: 8e8c8e8c-0000-4000-8000-000000000000")
+}
+
+func TestMetricsHandler(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/_metrics", nil)
+
+	recorder := httptest.NewRecorder()
+	cmd.MetricsHandler().ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var metrics map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &metrics))
+
+	require.Contains(t, metrics, "webhook_panics")
+	require.Contains(t, metrics, "rate_limit_remaining")
+	require.NotContains(t, metrics, "cmdline")
+	require.NotContains(t, metrics, "memstats")
+}
+
+func TestGitLabWebhookHandler_TriggerOnChanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		attributes   string
+		changes      string
+		wantEvaluate bool
+	}{
+		{name: "watched field changed", attributes: `"action": "update"`, changes: `{"title": {"previous": "a", "current": "b"}}`, wantEvaluate: true},
+		{name: "new commits pushed", attributes: `"action": "update", "oldrev": "abc123"`, changes: `{}`, wantEvaluate: true},
+		{name: "only unwatched fields changed", attributes: `"action": "update"`, changes: `{"updated_at": {"previous": "a", "current": "b"}, "assignees": {"previous": [], "current": []}}`, wantEvaluate: false},
+		{name: "other actions are always evaluated", attributes: `"action": "open"`, changes: `{}`, wantEvaluate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProvider(ctx, "gitlab")
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+			ctx = state.WithTriggerOnChanges(ctx, []string{"title", "labels", "commits"})
+
+			handler, err := cmd.GitLabWebhookHandler(ctx, "")
+			require.NoError(t, err)
+
+			body := `{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"iid": 1, "last_commit": {"id": "def456"}, ` + tt.attributes + `}, "changes": ` + tt.changes + `}`
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/gitlab", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, tt.wantEvaluate, requests.Load() > 0, "reading the configuration file means the merge request was evaluated")
+
+			if !tt.wantEvaluate {
+				require.Contains(t, recorder.Body.String(), "skipped")
+			}
+		})
+	}
+}
+
+func TestGitLabTriggerEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{action: "open", want: state.TriggerEventOpen},
+		{action: "reopen", want: state.TriggerEventReopen},
+		{action: "merge", want: state.TriggerEventMerge},
+		{action: "update", want: state.TriggerEventUpdate},
+		{action: "approved", want: state.TriggerEventUpdate},
+		{action: "", want: state.TriggerEventUpdate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, cmd.GitLabTriggerEvent(tt.action))
+		})
+	}
+}
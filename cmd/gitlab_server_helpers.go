@@ -2,23 +2,146 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
 	"strings"
 
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/redact"
+	"github.com/jippi/scm-engine/pkg/state"
 	slogctx "github.com/veqryn/slog-context"
 )
 
+// Number of webhook requests that panicked
+var webhookPanics = expvar.NewInt("webhook_panics")
+
+// metricNames are the [expvar] variables served on the '/_metrics' endpoint.
+//
+// The [expvar.Handler] also publishes 'cmdline' and 'memstats', and the command line may include
+// the API token and webhook secret, so only the scm-engine metrics are served
+var metricNames = []string{"audit_webhook_failures", "rate_limit_histories", "rate_limit_remaining", "rule_metrics", "webhook_panics"}
+
+// MetricsHandler serves the scm-engine [expvar] metrics (see [metricNames]) as a JSON object
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		fmt.Fprint(w, "{\n")
+
+		first := true
+
+		for _, name := range metricNames {
+			metric := expvar.Get(name)
+			if metric == nil {
+				continue
+			}
+
+			if !first {
+				fmt.Fprint(w, ",\n")
+			}
+
+			first = false
+
+			fmt.Fprintf(w, "%q: %s", name, metric.String())
+		}
+
+		fmt.Fprint(w, "\n}\n")
+	})
+}
+
+// requestIDHeaders are the HTTP headers that may carry an ID for the request, in order of preference
+var requestIDHeaders = []string{"X-Request-Id", "X-Gitlab-Event-UUID", "X-GitHub-Delivery"}
+
+// RecoverHandler tags the request with a request ID, and recovers from any panic in [next],
+// responding with 500 Internal Server Error instead of taking down the connection
+func RecoverHandler(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := requestID(r)
+
+		ctx := slogctx.With(r.Context(), slog.String("request_id", id))
+		ctx = state.WithRequestID(ctx, id)
+		w.Header().Set("X-Request-Id", id)
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// Let net/http handle aborted requests like it normally would
+			if recovered == http.ErrAbortHandler { //nolint:errorlint,err113
+				panic(recovered)
+			}
+
+			webhookPanics.Add(1)
+
+			slogctx.Error(ctx, "Recovered from panic while handling request", slog.Any("panic", recovered), slog.String("stack", string(debug.Stack())))
+
+			errHandler(ctx, w, http.StatusInternalServerError, fmt.Errorf("internal server error (request id: %s)", id))
+		}()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// recoverEvaluation recovers from a panic in an evaluation running in the background (like a debounced
+// or re-queued one), where there is no request for [RecoverHandler] to fail. It must be deferred directly
+func recoverEvaluation(ctx context.Context) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	webhookPanics.Add(1)
+
+	slogctx.Error(ctx, "Recovered from panic while evaluating in the background", slog.Any("panic", recovered), slog.String("stack", string(debug.Stack())))
+}
+
+func requestID(r *http.Request) string {
+	for _, header := range requestIDHeaders {
+		if id := r.Header.Get(header); len(id) > 0 {
+			return id
+		}
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+// withTagFiltersFromQuery overrides the '--only-tags' and '--skip-tags' filters with the (comma separated)
+// 'only_tags' and 'skip_tags' query parameters of the webhook request, if provided
+func withTagFiltersFromQuery(ctx context.Context, r *http.Request) context.Context {
+	if tags := r.URL.Query().Get("only_tags"); len(tags) > 0 {
+		ctx = state.WithOnlyTags(ctx, strings.Split(tags, ","))
+	}
+
+	if tags := r.URL.Query().Get("skip_tags"); len(tags) > 0 {
+		ctx = state.WithSkipTags(ctx, strings.Split(tags, ","))
+	}
+
+	return ctx
+}
+
 func errHandler(ctx context.Context, w http.ResponseWriter, code int, err error) {
-	// Treat 404 errors as informational instead of actual errors
-	if strings.Contains(err.Error(), "404 Not Found") {
+	// Treat 404 errors, ignored and opted out projects as informational instead of actual errors
+	if strings.Contains(err.Error(), "404 Not Found") || errors.Is(err, config.ErrMissingConfigIgnored) || errors.Is(err, config.ErrProjectDisabled) {
 		slogctx.Info(ctx, "Server response", slog.Int("response_code", code), slog.Any("response_message", err))
 	} else {
 		slogctx.Error(ctx, "Server response", slog.Int("response_code", code), slog.Any("response_message", err))
 	}
 
 	w.WriteHeader(code)
-	w.Write([]byte(err.Error()))
+	w.Write([]byte(redact.String(err.Error())))
 
 	return
 }
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// ProcessIssue evaluates the 'on_issue' actions of the project configuration file for the issue [iid], and applies the ones that match.
+//
+// Issues have no commit to read the configuration file from, so it's read from the default branch
+func ProcessIssue(ctx context.Context, client scm.Client, iid int, event any) error {
+	issues, ok := client.(scm.IssueClient)
+	if !ok {
+		return fmt.Errorf("the %s client can't evaluate issue events", state.Provider(ctx))
+	}
+
+	ctx = state.WithEvaluationID(ctx, sid.MustGenerate())
+	ctx = state.WithActionStepCounter(ctx)
+	ctx = slogctx.With(ctx, slog.Int("issue_iid", iid), slog.String("config_source_branch", "HEAD"))
+
+	cfg, err := loadIssueConfig(ctx, client)
+	if err != nil {
+		// Projects without configuration file are allowed to be ignored, and projects can opt out, so don't report any errors
+		if errors.Is(err, config.ErrMissingConfigIgnored) || errors.Is(err, config.ErrProjectDisabled) {
+			slogctx.Info(ctx, err.Error())
+
+			return nil
+		}
+
+		return err
+	}
+
+	if len(cfg.OnIssue) == 0 {
+		slogctx.Debug(ctx, "No on_issue actions configured, skipping issue event")
+
+		return nil
+	}
+
+	evalContext, err := issues.IssueEvalContext(ctx, iid)
+	if err != nil {
+		return err
+	}
+
+	// Allow changing the 'dry-run' mode via configuration file
+	if cfg.DryRun != nil && *cfg.DryRun != state.IsDryRun(ctx) && !state.IsReadOnly(ctx) {
+		slogctx.Info(ctx, "Configuration file has a 'dry_run' value, using that in favor of server default")
+
+		ctx = state.WithDryRun(ctx, *cfg.DryRun)
+	}
+
+	if err := cfg.LintIssue(evalContext); err != nil {
+		return fmt.Errorf("Configuration failed validation: %w", err)
+	}
+
+	ctx = config.WithConfig(ctx, cfg)
+
+	evalContext.SetWebhookEvent(event)
+	evalContext.SetContext(ctx)
+
+	actions, err := cfg.EvaluateIssue(ctx, evalContext)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		ctx := slogctx.With(ctx, slog.String("action_name", action.Name))
+		slogctx.Info(ctx, "Applying issue action")
+
+		if evalContext.HasExecutedActionGroup(action.Group) {
+			slogctx.Warn(ctx, fmt.Sprintf("Already executed another action within group '%s'; skipping current action", action.Group))
+
+			continue
+		}
+
+		evalContext.TrackActionGroupExecution(action.Group)
+		config.RecordRuleMetric(ctx, "action", action.Name, config.RuleMetricExecutions)
+
+		for _, step := range action.Then {
+			if err := state.CountActionStep(ctx); err != nil {
+				return err
+			}
+
+			if err := issues.ApplyIssueStep(ctx, evalContext, step); err != nil {
+				config.RecordRuleMetric(ctx, "action", action.Name, config.RuleMetricErrors)
+
+				return fmt.Errorf("action: %s; %w", action.Name, err)
+			}
+		}
+
+		action.RecordRun(ctx, time.Now())
+	}
+
+	return nil
+}
+
+// loadIssueConfig reads the configuration file from the default branch, with all 'include' settings
+// and the global configuration file loaded, and the selected profile applied
+func loadIssueConfig(ctx context.Context, client scm.Client) (*config.Config, error) {
+	file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), "HEAD")
+	if err != nil {
+		cfg, err := missingConfigFallback(ctx, err)
+		if err != nil {
+			if errors.Is(err, config.ErrMissingConfigIgnored) {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("could not read remote config file: %w", err)
+		}
+
+		return completeConfig(ctx, client, cfg)
+	}
+
+	cfg, err := config.ParseFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file: %w", err)
+	}
+
+	return completeConfig(ctx, client, cfg)
+}
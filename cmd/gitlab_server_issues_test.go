@@ -0,0 +1,90 @@
+package cmd_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_IssueEvent(t *testing.T) {
+	t.Parallel()
+
+	const issuePath = "/api/v4/projects/jippi%2Fscm-engine/issues/3"
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		request := r.Method + " " + r.URL.EscapedPath()
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			request += " " + strings.TrimSpace(string(body))
+		}
+
+		requests = append(requests, request)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == testConfigFilePath:
+			require.Equal(t, "HEAD", r.URL.Query().Get("ref"))
+
+			w.Write([]byte("on_issue:\n  - name: move support requests\n    if: '\"support\" in issue.labels'\n    then:\n      - action: move_to_project\n        project: '\"jippi/support\"'\n"))
+
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == issuePath:
+			w.Write([]byte(`{"id": 30, "iid": 3, "title": "Help", "labels": ["support"]}`))
+
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == issuePath+"/notes":
+			w.Write([]byte(`[]`))
+
+		case r.URL.EscapedPath() == "/api/v4/projects/jippi%2Fsupport":
+			w.Write([]byte(`{"id": 20}`))
+
+		case r.URL.EscapedPath() == issuePath+"/move":
+			w.Write([]byte(`{"id": 31, "iid": 1, "web_url": "https://gitlab.example.com/jippi/support/-/issues/1"}`))
+
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+	ctx = state.WithDryRun(ctx, false)
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	body := `{"object_kind": "issue", "event_type": "issue", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"iid": 3, "action": "open"}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitlab-Event", "Issue Hook")
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Contains(t, requests, `POST `+issuePath+`/move {"to_project_id":20}`)
+	require.Contains(t, requests, `POST `+issuePath+`/notes {"body":"Moved to https://gitlab.example.com/jippi/support/-/issues/1 by scm-engine."}`)
+}
@@ -0,0 +1,100 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabWebhookHandler_MissingConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		behavior config.MissingConfigBehavior
+		wantBody string
+		// wantEvaluated is true if the Merge Request is evaluated (read through the GraphQL API)
+		wantEvaluated bool
+	}{
+		{
+			behavior: config.MissingConfigError,
+			wantBody: "scm-engine configuration file not found",
+		},
+		{
+			behavior: config.MissingConfigIgnore,
+			wantBody: "project has no scm-engine configuration file; ignoring",
+		},
+		{
+			behavior:      config.MissingConfigUseDefault,
+			wantEvaluated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.behavior), func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			// The project doesn't have a configuration file, and every other API call fails too
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+				mu.Unlock()
+
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "404 File Not Found"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProvider(ctx, "gitlab")
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+			ctx = state.WithUpdatePipeline(ctx, false, "")
+			ctx = state.WithMissingConfigBehavior(ctx, string(tt.behavior))
+
+			handler, err := cmd.GitLabWebhookHandler(ctx, "")
+			require.NoError(t, err)
+
+			body := `{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/no-config", "archived": false}, "object_attributes": {"iid": 1, "action": "update", "last_commit": {"id": "abc123"}}}`
+
+			req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Equal(t, "GET /api/v4/projects/jippi%2Fno-config/repository/files/%2Escm-engine%2Eyml/raw", requests[0])
+
+			if !tt.wantEvaluated {
+				require.Contains(t, recorder.Body.String(), tt.wantBody)
+
+				// Nothing else is read once the configuration file is missing
+				require.Len(t, requests, 1)
+
+				return
+			}
+
+			// The bundled default configuration is used, rather than reporting the missing file
+			require.NotContains(t, recorder.Body.String(), "configuration file not found")
+			require.Contains(t, requests, "POST /api/graphql")
+		})
+	}
+}
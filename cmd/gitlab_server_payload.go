@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"encoding/json"
+)
+
+// DecodeGitlabWebhookPayload decodes the webhook [body] into both the typed [GitlabWebhookPayload]
+// used for routing, and the full payload (as 'any') available to scripts.
+//
+// Unknown fields are ignored, and missing or null fields are left at their zero value
+func DecodeGitlabWebhookPayload(body []byte) (GitlabWebhookPayload, any, error) {
+	var (
+		payload GitlabWebhookPayload
+		full    any
+	)
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return payload, nil, err
+	}
+
+	if err := json.Unmarshal(body, &full); err != nil {
+		return payload, nil, err
+	}
+
+	return payload, full, nil
+}
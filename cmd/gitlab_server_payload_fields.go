@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type payloadFieldTrackerKey struct{}
+
+// PayloadFieldTracker remembers the fields seen in webhook payloads, by event type, so fields GitLab adds
+// to its webhook events are noticed. The typed payload decoding ignores unknown fields, so new fields never fail a request
+type PayloadFieldTracker struct {
+	mu     sync.Mutex
+	fields map[string]map[string]bool
+}
+
+func NewPayloadFieldTracker() *PayloadFieldTracker {
+	return &PayloadFieldTracker{fields: map[string]map[string]bool{}}
+}
+
+// Record returns the (sorted) field paths in the decoded [payload] that weren't seen in earlier payloads of [eventType].
+//
+// The first payload of every event type is the baseline, so nothing is returned for it. Array elements are
+// recorded as 'name[]', and the keys in 'changes' are not recorded, since they depend on what changed
+func (t *PayloadFieldTracker) Record(eventType string, payload any) []string {
+	paths := map[string]bool{}
+	collectPayloadFields(payload, "", paths)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	known, ok := t.fields[eventType]
+	if !ok {
+		t.fields[eventType] = paths
+
+		return nil
+	}
+
+	var unseen []string
+
+	for path := range paths {
+		if !known[path] {
+			known[path] = true
+
+			unseen = append(unseen, path)
+		}
+	}
+
+	slices.Sort(unseen)
+
+	return unseen
+}
+
+// log records the fields in [payload] and logs a warning if any of them are new
+func (t *PayloadFieldTracker) log(ctx context.Context, eventType string, payload any) {
+	if unseen := t.Record(eventType, payload); len(unseen) > 0 {
+		slogctx.Warn(ctx, "Webhook payload has fields not seen in earlier payloads of this event type", slog.String("event_type", eventType), slog.Any("new_fields", unseen))
+	}
+}
+
+func collectPayloadFields(value any, prefix string, paths map[string]bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			path := key
+			if len(prefix) > 0 {
+				path = prefix + "." + key
+			}
+
+			paths[path] = true
+
+			if path == "changes" {
+				continue
+			}
+
+			collectPayloadFields(child, path, paths)
+		}
+
+	case []any:
+		for _, child := range value {
+			collectPayloadFields(child, prefix+"[]", paths)
+		}
+	}
+}
+
+// WithPayloadFieldLogging turns on logging of new webhook payload fields, see [PayloadFieldTracker]
+func WithPayloadFieldLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, payloadFieldTrackerKey{}, NewPayloadFieldTracker())
+}
+
+// payloadFieldTrackerFromContext returns the payload field tracker, or nil if logging new fields is off
+func payloadFieldTrackerFromContext(ctx context.Context) *PayloadFieldTracker {
+	tracker, _ := ctx.Value(payloadFieldTrackerKey{}).(*PayloadFieldTracker)
+
+	return tracker
+}
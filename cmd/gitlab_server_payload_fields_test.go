@@ -0,0 +1,67 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadFieldTracker_Record(t *testing.T) {
+	t.Parallel()
+
+	decode := func(payload string) any {
+		var out any
+		require.NoError(t, json.Unmarshal([]byte(payload), &out))
+
+		return out
+	}
+
+	tracker := cmd.NewPayloadFieldTracker()
+
+	// The first payload is the baseline
+	require.Empty(t, tracker.Record("merge_request", decode(`{"event_type": "merge_request", "object_attributes": {"iid": 1}, "labels": [{"title": "bug"}], "changes": {"title": {}}}`)))
+
+	// Only new fields are reported, once
+	payload := decode(`{"event_type": "merge_request", "brand_new_field": true, "object_attributes": {"iid": 1, "new_attribute": "x"}, "labels": [{"title": "bug", "color": "#fff"}], "changes": {"description": {}}}`)
+
+	require.Equal(t, []string{"brand_new_field", "labels[].color", "object_attributes.new_attribute"}, tracker.Record("merge_request", payload))
+	require.Empty(t, tracker.Record("merge_request", payload))
+
+	// Every event type has its own baseline
+	require.Empty(t, tracker.Record("note", payload))
+}
+
+func TestGitLabWebhookHandler_UnknownFields(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+	ctx = cmd.WithPayloadFieldLogging(ctx)
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	for _, body := range []string{
+		`{"event_type": "note", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"noteable_type": "Issue"}}`,
+		`{"event_type": "note", "project": {"path_with_namespace": "jippi/scm-engine", "brand_new_field": 1}, "object_attributes": {"noteable_type": "Issue", "brand_new_field": [1]}, "brand_new_field": {"nested": true}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+
+		// Unknown fields never fail the request
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		require.Equal(t, "OK - ignored note on Issue", recorder.Body.String())
+	}
+}
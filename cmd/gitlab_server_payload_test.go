@@ -0,0 +1,87 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeGitlabWebhookPayload(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		`{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/scm-engine", "archived": false}, "object_attributes": {"iid": 7, "action": "update", "oldrev": "abc", "last_commit": {"id": "def"}, "source_project_id": 1, "target_project_id": 2}, "changes": {"title": {"previous": "a", "current": "b"}}}`,
+		`{"event_type": "note", "object_kind": "note", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"noteable_type": "MergeRequest"}, "merge_request": {"iid": 3, "last_commit": {"id": "abc"}}}`,
+		`{"object_kind": "push", "project": {"path_with_namespace": "jippi/scm-engine", "archived": true}, "object_attributes": null, "brand_new_field": [1, 2]}`,
+		`{}`,
+	} {
+		// The typed payload must match decoding into the struct directly
+		var want cmd.GitlabWebhookPayload
+		require.NoError(t, json.Unmarshal([]byte(body), &want))
+
+		var wantFull any
+		require.NoError(t, json.Unmarshal([]byte(body), &wantFull))
+
+		payload, full, err := cmd.DecodeGitlabWebhookPayload([]byte(body))
+		require.NoError(t, err, body)
+		require.Equal(t, want, payload, body)
+		require.Equal(t, wantFull, full, body)
+	}
+
+	for body, wantErr := range map[string]string{
+		`[]`:                                               `cannot unmarshal array into Go value`,
+		`{"event_type": 1}`:                                `cannot unmarshal number into Go struct field GitlabWebhookPayload.event_type of type string`,
+		`{"project": "jippi/scm-engine"}`:                  `cannot unmarshal string into Go struct field GitlabWebhookPayload.project of type`,
+		`{"project": {"archived": "yes"}}`:                 `cannot unmarshal string into Go struct field GitlabWebhookPayloadProject.project.archived of type bool`,
+		`{"object_attributes": {"iid": "7"}}`:              `cannot unmarshal string into Go struct field GitlabWebhookPayloadObjectAttributes.object_attributes.iid of type int`,
+		`{"merge_request": {"iid": 1.5}}`:                  `cannot unmarshal number 1.5 into Go struct field GitlabWebhookPayloadMergeRequest.merge_request.iid of type int`,
+		`{"merge_request": {"last_commit": {"id": true}}}`: `cannot unmarshal bool into Go struct field GitlabWebhookPayloadCommit.merge_request.last_commit.id of type string`,
+		`{"event_type": "merge_request"`:                   `unexpected end of JSON input`,
+	} {
+		_, _, err := cmd.DecodeGitlabWebhookPayload([]byte(body))
+		require.ErrorContains(t, err, wantErr, body)
+	}
+}
+
+// largeWebhookPayload returns a Merge Request event with a long description and many changed fields
+func largeWebhookPayload(b *testing.B) []byte {
+	b.Helper()
+
+	labels := make([]any, 0, 50)
+	for i := range 50 {
+		labels = append(labels, map[string]any{"id": i, "title": fmt.Sprintf("label-%d", i), "color": "#ff0000", "description": strings.Repeat("text ", 20)})
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"event_type": "merge_request",
+		"project":    map[string]any{"path_with_namespace": "jippi/scm-engine", "description": strings.Repeat("project ", 200)},
+		"object_attributes": map[string]any{
+			"iid":         7,
+			"action":      "update",
+			"title":       "Add a feature",
+			"description": strings.Repeat("A long Merge Request description. ", 2000),
+			"last_commit": map[string]any{"id": "abc123", "message": strings.Repeat("commit ", 100)},
+		},
+		"labels":  labels,
+		"changes": map[string]any{"labels": map[string]any{"previous": labels[:25], "current": labels}},
+	})
+	require.NoError(b, err)
+
+	return body
+}
+
+func BenchmarkDecodeGitlabWebhookPayload(b *testing.B) {
+	body := largeWebhookPayload(b)
+
+	b.ReportAllocs()
+
+	for range b.N {
+		if _, _, err := cmd.DecodeGitlabWebhookPayload(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -14,7 +14,15 @@ import (
 	slogctx "github.com/veqryn/slog-context"
 )
 
-func startPeriodicEvaluation(ctx context.Context, interval time.Duration, filter scm.MergeRequestListFilters, wg *sync.WaitGroup) {
+// periodicProcessor processes a single Merge Request found by the periodic evaluation, with its configuration file [cfg]
+type periodicProcessor func(ctx context.Context, client scm.Client, cfg *config.Config) error
+
+// processPeriodicMR fully evaluates the Merge Request, see [ProcessMR]
+func processPeriodicMR(ctx context.Context, client scm.Client, cfg *config.Config) error {
+	return ProcessMR(ctx, client, cfg, nil)
+}
+
+func startPeriodicEvaluation(ctx context.Context, interval time.Duration, filter scm.MergeRequestListFilters, process periodicProcessor, wg *sync.WaitGroup) {
 	// Empty interval means disabling
 	if interval == 0 {
 		slogctx.Warn(ctx, "scm-engine will not be doing periodic evaluation since interval is '0'. Set 'SCM_ENGINE_PERIODIC_EVALUATION_INTERVAL' or '--periodic-evaluation-interval'  to a non-zero duration to activate")
@@ -34,8 +42,14 @@ func startPeriodicEvaluation(ctx context.Context, interval time.Duration, filter
 		interval = 15 * time.Minute
 	}
 
-	// Initialize the SCM-Engine client
-	client, err := getClient(ctx)
+	// Initialize the SCM-Engine clients, the default one to find the Merge Requests, and one per token mapping
+	// (see --api-token-mapping) to evaluate them
+	clients, err := NewClientPool(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	client, err := clients.DefaultClient(ctx)
 	if err != nil {
 		panic(err)
 	}
@@ -83,20 +97,35 @@ func startPeriodicEvaluation(ctx context.Context, interval time.Duration, filter
 					ctx = state.WithMergeRequestID(ctx, mergeRequest.MergeRequestID)
 					ctx = state.WithProjectID(ctx, mergeRequest.Project)
 
+					ctx, client, err := clients.ClientForProject(ctx, mergeRequest.Project)
+					if err != nil {
+						slogctx.Error(ctx, "failed to select the API token for the project", slog.Any("error", err))
+
+						continue
+					}
+
 					if !mergeRequest.UpdatePipeline {
 						slogctx.Info(ctx, "Disabling CI pipeline commit status updating since the MR HEAD CI pipeline is in a failed state")
 
 						ctx = state.WithUpdatePipeline(ctx, false, "")
 					}
 
+					var cfg *config.Config
+
 					if len(mergeRequest.ConfigBlob) == 0 {
-						slogctx.Warn(ctx, "Could not find the scm-engine configuration file in the repository, skipping...")
+						// Only projects using the bundled default configuration are evaluated without a configuration file
+						if config.MissingConfigBehavior(state.MissingConfigBehavior(ctx)) != config.MissingConfigUseDefault {
+							slogctx.Warn(ctx, "Could not find the scm-engine configuration file in the repository, skipping...")
 
-						continue
+							continue
+						}
+
+						cfg, err = config.Default()
+					} else {
+						// Parse the file
+						cfg, err = config.ParseFile(strings.NewReader(mergeRequest.ConfigBlob))
 					}
 
-					// Parse the file
-					cfg, err := config.ParseFile(strings.NewReader(mergeRequest.ConfigBlob))
 					if err != nil {
 						slogctx.Error(ctx, "could not parse config file", slog.Any("error", err))
 
@@ -104,7 +133,7 @@ func startPeriodicEvaluation(ctx context.Context, interval time.Duration, filter
 					}
 
 					// Process the Merge Request
-					if err := ProcessMR(ctx, client, cfg, nil); err != nil {
+					if err := process(ctx, client, cfg); err != nil {
 						slogctx.Error(ctx, "failed to process MR", slog.Any("error", err))
 
 						continue
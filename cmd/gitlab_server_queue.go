@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// QueuedEvent is a GitLab webhook event read from a queue, as GitLab sent it to the webhook endpoint
+type QueuedEvent struct {
+	// The HTTP headers of the webhook request (example: 'X-Gitlab-Event'). The 'Content-Type' defaults to 'application/json'
+	Header http.Header
+
+	// The raw JSON request body
+	Body []byte
+}
+
+// EventSource is a queue of GitLab webhook events, such as a message broker, see [RegisterEventSource].
+//
+// Receive blocks until the next event is available, or [ctx] is done, and returns [io.EOF] once the source is closed.
+// Events are processed one at a time, so an event is done processing when Receive is called again
+type EventSource interface {
+	Receive(ctx context.Context) (QueuedEvent, error)
+}
+
+// ChannelEventSource is an [EventSource] reading events from an in-process channel, the source is closed with the channel
+type ChannelEventSource chan QueuedEvent
+
+func (source ChannelEventSource) Receive(ctx context.Context) (QueuedEvent, error) {
+	select {
+	case <-ctx.Done():
+		return QueuedEvent{}, ctx.Err()
+
+	case event, ok := <-source:
+		if !ok {
+			return QueuedEvent{}, io.EOF
+		}
+
+		return event, nil
+	}
+}
+
+// eventSources are the event sources consumed by the server, keyed by name
+var eventSources = struct {
+	sync.RWMutex
+
+	sources map[string]EventSource
+}{sources: map[string]EventSource{}}
+
+// RegisterEventSource makes the GitLab server consume webhook events from [source] alongside the HTTP webhook
+// endpoint, for programs using scm-engine as a library to decouple GitLab from the availability of scm-engine
+// (GitLab → queue → scm-engine).
+//
+// It's called from 'init()' functions, like [database/sql.Register], and panics if [source] is nil,
+// or [name] is empty or already registered
+func RegisterEventSource(name string, source EventSource) {
+	if len(name) == 0 {
+		panic("cmd: RegisterEventSource name is empty")
+	}
+
+	if source == nil {
+		panic("cmd: RegisterEventSource source is nil for " + name)
+	}
+
+	eventSources.Lock()
+	defer eventSources.Unlock()
+
+	if _, ok := eventSources.sources[name]; ok {
+		panic(fmt.Sprintf("cmd: RegisterEventSource called twice for %q", name))
+	}
+
+	eventSources.sources[name] = source
+}
+
+// EventConsumer processes queued webhook events through the same path as [GitLabWebhookHandler]
+type EventConsumer struct {
+	clients *ClientPool
+}
+
+func NewEventConsumer(ctx context.Context) (*EventConsumer, error) {
+	clients, err := NewClientPool(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize GitLab clients: %w", err)
+	}
+
+	return &EventConsumer{clients: clients}, nil
+}
+
+// Consume processes the events of [source] until it's closed (returning nil), or [ctx] is done.
+//
+// The webhook secret isn't checked, since the events come from a trusted queue rather than the network
+func (consumer *EventConsumer) Consume(ctx context.Context, source EventSource) error {
+	for {
+		event, err := source.Receive(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		consumer.process(ctx, event)
+	}
+}
+
+// process feeds [event] to the webhook handler as if GitLab sent it, and logs the response
+func (consumer *EventConsumer) process(ctx context.Context, event QueuedEvent) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(event.Body))
+	if err != nil {
+		slogctx.Error(ctx, "Could not process queued webhook event", slog.Any("error", err))
+
+		return
+	}
+
+	if event.Header != nil {
+		req.Header = event.Header.Clone()
+	}
+
+	if len(req.Header.Get("Content-Type")) == 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	response := &queuedEventResponse{status: http.StatusOK}
+
+	serveGitLabWebhook(consumer.clients, response, req)
+
+	if response.status >= http.StatusBadRequest {
+		slogctx.Error(ctx, "Queued webhook event was rejected", slog.Int("status", response.status), slog.String("response", response.body.String()))
+
+		return
+	}
+
+	slogctx.Info(ctx, "Processed queued webhook event", slog.String("response", response.body.String()))
+}
+
+// startEventConsumers consumes the events of the registered event sources, until [ctx] is done
+func startEventConsumers(ctx context.Context, wg *sync.WaitGroup) error {
+	eventSources.RLock()
+	defer eventSources.RUnlock()
+
+	if len(eventSources.sources) == 0 {
+		return nil
+	}
+
+	consumer, err := NewEventConsumer(ctx)
+	if err != nil {
+		return err
+	}
+
+	for name, source := range eventSources.sources {
+		ctx := slogctx.With(ctx, slog.String("event_source", name))
+
+		slogctx.Info(ctx, "Consuming webhook events from event source")
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := consumer.Consume(ctx, source); err != nil && !errors.Is(err, context.Canceled) {
+				slogctx.Error(ctx, "Event source failed", slog.Any("error", err))
+			}
+		}()
+	}
+
+	return nil
+}
+
+// queuedEventResponse is the [http.ResponseWriter] of a queued event, there is nobody to answer, so it's only logged
+type queuedEventResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *queuedEventResponse) Header() http.Header {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+
+	return r.header
+}
+
+func (r *queuedEventResponse) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *queuedEventResponse) WriteHeader(status int) {
+	r.status = status
+}
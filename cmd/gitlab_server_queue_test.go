@@ -0,0 +1,89 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventConsumer_Consume(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		mu.Unlock()
+
+		if strings.HasPrefix(r.URL.EscapedPath(), "/api/v4/projects/jippi%2Fscm-engine/repository/files/") {
+			w.Write([]byte("label:\n  - name: bug\n    script: \"true\"\n"))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 Not Found"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+	ctx = state.WithUpdatePipeline(ctx, false, "")
+
+	consumer, err := cmd.NewEventConsumer(ctx)
+	require.NoError(t, err)
+
+	source := make(cmd.ChannelEventSource, 3)
+
+	// Processed like a webhook request, without an 'X-Gitlab-Event' header or 'Content-Type'
+	source <- cmd.QueuedEvent{
+		Body: []byte(`{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"iid": 1, "action": "update", "last_commit": {"id": "abc123"}}}`),
+	}
+
+	// Invalid events are rejected (and logged), without stopping the consumer
+	source <- cmd.QueuedEvent{
+		Header: http.Header{"X-Gitlab-Event": []string{"Unknown Hook"}},
+		Body:   []byte(`{}`),
+	}
+
+	source <- cmd.QueuedEvent{Body: []byte(`{"event_type": "merge_request", "project": {"path_with_namespace": "jippi/scm-engine"}}`)}
+
+	close(source)
+
+	// Returns once the source is closed
+	require.NoError(t, consumer.Consume(ctx, source))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The first event reads the configuration file, and evaluates the Merge Request
+	require.Contains(t, requests, "GET /api/v4/projects/jippi%2Fscm-engine/repository/files/%2Escm-engine%2Eyml/raw")
+	require.Contains(t, requests, "POST /api/graphql")
+}
+
+func TestChannelEventSource_Receive(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	source := make(cmd.ChannelEventSource)
+
+	cancel()
+
+	_, err := source.Receive(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/cron"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// ScheduledEvaluation evaluates all open Merge Requests in an allowlist of projects on a cron schedule,
+// so rules like stale detection or label expiry run even without any webhook activity.
+//
+// Merge Requests are locked while being processed, so scheduled evaluations are safe to run alongside webhooks.
+type ScheduledEvaluation struct {
+	clients      *ClientPool
+	projects     []string
+	concurrency  int
+	drainTimeout time.Duration
+}
+
+// NewScheduledEvaluation creates a [ScheduledEvaluation] for [projects], evaluating up to [concurrency]
+// Merge Requests of a project at the same time
+func NewScheduledEvaluation(ctx context.Context, projects []string, concurrency int, drainTimeout time.Duration) (*ScheduledEvaluation, error) {
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("--%s requires at least one project in --%s", FlagSchedule, FlagScheduleProjects)
+	}
+
+	// Initialize GitLab clients
+	clients, err := NewClientPool(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize GitLab clients: %w", err)
+	}
+
+	return &ScheduledEvaluation{
+		clients:      clients,
+		projects:     projects,
+		concurrency:  concurrency,
+		drainTimeout: drainTimeout,
+	}, nil
+}
+
+// Start runs [ScheduledEvaluation.Run] every time [schedule] activates, until [ctx] is cancelled
+func (s *ScheduledEvaluation) Start(ctx context.Context, schedule *cron.Schedule, wg *sync.WaitGroup) {
+	ctx = slogctx.With(ctx, slog.String("event_type", "scheduled_evaluation"), slog.Any("scheduled_evaluation_projects", s.projects))
+
+	wg.Add(1) // +1: Scheduled Evaluation
+
+	go func() {
+		defer wg.Done() // -1: Scheduled Evaluation
+
+		for {
+			next, err := schedule.Next(time.Now())
+			if err != nil {
+				slogctx.Error(ctx, "Stopping scheduled evaluation", slog.Any("error", err))
+
+				return
+			}
+
+			slogctx.Info(ctx, "Waiting for next scheduled evaluation", slog.Time("next_scheduled_evaluation", next))
+
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				slogctx.Info(ctx, "Stopping scheduled evaluation as scm-engine is shutting down")
+
+				return
+
+			case <-timer.C:
+				// Track all log output back to a scheduled evaluation cycle
+				ctx := slogctx.With(ctx, slog.String("scheduled_eval_id", sid.MustGenerate()))
+
+				if err := s.Run(ctx); err != nil {
+					slogctx.Error(ctx, "Scheduled evaluation cycle failed", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+}
+
+// Run evaluates the open Merge Requests of every project once
+func (s *ScheduledEvaluation) Run(ctx context.Context) error {
+	slogctx.Info(ctx, "Starting scheduled evaluation cycle")
+
+	// Don't update the CI pipeline status, to avoid notifying users about pipelines on every cycle
+	ctx = state.WithUpdatePipeline(ctx, false, "")
+
+	var result error
+
+	for _, project := range s.projects {
+		if err := s.runProject(slogctx.With(ctx, slog.String("project", project)), project); err != nil {
+			result = errors.Join(result, fmt.Errorf("project %q: %w", project, err))
+		}
+	}
+
+	slogctx.Info(ctx, "Completed scheduled evaluation cycle")
+
+	return result
+}
+
+func (s *ScheduledEvaluation) runProject(ctx context.Context, project string) error {
+	ctx = state.WithProjectID(ctx, project)
+
+	// Select the GitLab client (and token) for the project
+	ctx, client, err := s.clients.ClientForProject(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	// Read the configuration file from the default branch, to skip projects without (or disabling) scm-engine
+	// before listing their Merge Requests
+	var cfg *config.Config
+
+	file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), "")
+	if err != nil {
+		cfg, err = missingConfigFallback(ctx, err)
+	} else {
+		cfg, err = config.ParseFile(file)
+	}
+
+	if errors.Is(err, config.ErrMissingConfigIgnored) {
+		slogctx.Info(ctx, "Skipping project without a configuration file")
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if checkProjectEnabled(ctx, cfg) != nil {
+		slogctx.Info(ctx, "Skipping project that disabled scm-engine in its configuration file")
+
+		return nil
+	}
+
+	mergeRequests, err := client.MergeRequests().List(ctx, &scm.ListMergeRequestsOptions{State: "opened", First: 100})
+	if err != nil {
+		return fmt.Errorf("could not list Merge Requests: %w", err)
+	}
+
+	// Every Merge Request is evaluated with the configuration file on its own branch, exactly like a webhook event
+	return EvaluateMergeRequests(ctx, client, nil, mergeRequests, s.concurrency, s.drainTimeout)
+}
@@ -0,0 +1,139 @@
+package cmd_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledEvaluation_Run(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		evaluated []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/v4/projects/jippi/scm-engine/repository/files/.scm-engine.yml/raw":
+			w.Write([]byte("actions: []\nlabel: []\n"))
+
+		case r.URL.Path == "/api/graphql" && strings.Contains(string(body), "diffHeadSha"):
+			w.Write([]byte(`{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "1", "diffHeadSha": "abc"}, {"iid": "2", "diffHeadSha": "def"}, {"iid": "3"}]}}}}`))
+
+		case r.URL.Path == "/api/graphql":
+			// Track the evaluation context query of every processed Merge Request
+			for _, id := range []string{"1", "2", "3"} {
+				if strings.Contains(string(body), `"mr_id":"`+id+`"`) {
+					mu.Lock()
+					evaluated = append(evaluated, id)
+					mu.Unlock()
+				}
+			}
+
+			w.Write([]byte(`{"data": {"project": null}}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithDryRun(ctx, true)
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+	scheduled, err := cmd.NewScheduledEvaluation(ctx, []string{"jippi/scm-engine"}, 2, time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, scheduled.Run(ctx))
+
+	// Merge Requests without commits are not evaluated
+	require.ElementsMatch(t, []string{"1", "2"}, evaluated)
+}
+
+func TestNewScheduledEvaluation_RequiresProjects(t *testing.T) {
+	t.Parallel()
+
+	_, err := cmd.NewScheduledEvaluation(context.Background(), nil, 1, time.Second)
+	require.EqualError(t, err, "--schedule requires at least one project in --schedule-projects")
+}
+
+func TestScheduledEvaluation_RunPaginatesAndUsesMergeRequestConfig(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		refs []string
+	)
+
+	shas := map[string]string{"1": "abc", "2": "def"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/v4/projects/jippi/scm-engine/repository/files/.scm-engine.yml/raw":
+			mu.Lock()
+			refs = append(refs, r.URL.Query().Get("ref"))
+			mu.Unlock()
+
+			w.Write([]byte("actions: []\nlabel: []\n"))
+
+		case strings.HasPrefix(r.URL.Path, "/api/v4/projects/jippi/scm-engine/merge_requests/"):
+			id := strings.TrimPrefix(r.URL.Path, "/api/v4/projects/jippi/scm-engine/merge_requests/")
+
+			w.Write([]byte(`{"iid": ` + id + `, "sha": "` + shas[id] + `"}`))
+
+		// The Merge Requests are listed one per page
+		case r.URL.Path == "/api/graphql" && strings.Contains(string(body), "diffHeadSha") && strings.Contains(string(body), `"after":"page-2"`):
+			w.Write([]byte(`{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "2", "diffHeadSha": "def"}], "pageInfo": {"hasNextPage": false}}}}}`))
+
+		case r.URL.Path == "/api/graphql" && strings.Contains(string(body), "diffHeadSha"):
+			w.Write([]byte(`{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "1", "diffHeadSha": "abc"}], "pageInfo": {"hasNextPage": true, "endCursor": "page-2"}}}}}`))
+
+		case r.URL.Path == "/api/graphql":
+			w.Write([]byte(`{"data": {"project": {"labels": {"nodes": []}, "mergeRequest": {"iid": "1", "sourceProjectId": 1, "targetProjectId": 1, "labels": {"nodes": []}, "notes": {"nodes": []}, "first_commit": {"nodes": []}, "last_commit": {"nodes": []}}}}}`))
+
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithDryRun(ctx, true)
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+	scheduled, err := cmd.NewScheduledEvaluation(ctx, []string{"jippi/scm-engine"}, 1, time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, scheduled.Run(ctx))
+
+	// The default branch is read to check the project uses scm-engine, then every Merge Request
+	// (on every page) is evaluated with the configuration file on its own branch
+	require.Equal(t, "", refs[0])
+	require.ElementsMatch(t, []string{"abc", "def"}, refs[1:])
+}
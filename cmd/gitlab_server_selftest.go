@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// selfTestClient is implemented by clients that can check their connectivity and API token
+type selfTestClient interface {
+	Version(ctx context.Context) (string, error)
+	TokenScopes(ctx context.Context) ([]string, error)
+}
+
+// errSelfTestSkipped is returned by self-test checks that don't apply, or can't be decided
+var errSelfTestSkipped = errors.New("skipped")
+
+// selfTestCheck is a single check of [SelfTest], run with [token] as the API token (if set)
+type selfTestCheck struct {
+	name  string
+	token string
+	run   func(ctx context.Context) error
+}
+
+// SelfTest checks that the server can do its job before it starts serving requests: the global configuration
+// file (if any) lints, and GitLab is reachable with the default API token and the token of every token mapping,
+// which have the scopes scm-engine needs.
+//
+// Every check is logged, and the failed checks are returned as an error. Checks that can't be decided
+// (like the scopes of tokens GitLab can't describe) are skipped rather than failed
+func SelfTest(ctx context.Context) error {
+	mappings, err := parseTokenMappings(state.TokenMappings(ctx))
+	if err != nil {
+		return err
+	}
+
+	checks := []selfTestCheck{
+		{name: "global config", run: selfTestGlobalConfig},
+	}
+
+	if token, ok := state.TokenOk(ctx); ok {
+		checks = append(checks, selfTestTokenChecks("default token", token)...)
+	}
+
+	for _, mapping := range mappings {
+		checks = append(checks, selfTestTokenChecks("token mapping "+mapping.pattern, mapping.token)...)
+	}
+
+	var failed []string
+
+	for _, check := range checks {
+		ctx := slogctx.With(ctx, slog.String("self_test_check", check.name))
+		if len(check.token) > 0 {
+			ctx = state.WithToken(ctx, check.token)
+		}
+
+		switch err := check.run(ctx); {
+		case err == nil:
+			slogctx.Info(ctx, "Self-test check passed")
+
+		case errors.Is(err, errSelfTestSkipped):
+			slogctx.Info(ctx, "Self-test check skipped", slog.Any("reason", err))
+
+		default:
+			slogctx.Error(ctx, "Self-test check failed", slog.Any("error", err))
+
+			failed = append(failed, fmt.Sprintf("%s: %s", check.name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("self-test failed (use --%s to start anyway): %s", FlagSkipSelfTest, strings.Join(failed, "; "))
+	}
+
+	slogctx.Info(ctx, "Self-test passed", slog.Int("checks", len(checks)))
+
+	return nil
+}
+
+func selfTestGlobalConfig(ctx context.Context) error {
+	global := globalConfigFromContext(ctx)
+	if global == nil {
+		return fmt.Errorf("%w: no global config file is configured", errSelfTestSkipped)
+	}
+
+	return global.Config.Lint(ctx, &gitlab.Context{})
+}
+
+// selfTestTokenChecks returns the connectivity and scope checks of [token], described as [label] since the token is a secret
+func selfTestTokenChecks(label, token string) []selfTestCheck {
+	// The scopes are only checked once GitLab is reachable with the token, since they would fail for the same reason
+	var client selfTestClient
+
+	connectivity := func(ctx context.Context) error {
+		scmClient, err := getClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		tester, ok := scmClient.(selfTestClient)
+		if !ok {
+			return fmt.Errorf("%w: the client can't check its connectivity", errSelfTestSkipped)
+		}
+
+		version, err := tester.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		client = tester
+
+		slogctx.Debug(ctx, "Connected to GitLab", slog.String("gitlab_version", version))
+
+		return nil
+	}
+
+	scopes := func(ctx context.Context) error {
+		if client == nil {
+			return fmt.Errorf("%w: GitLab is not reachable with the token", errSelfTestSkipped)
+		}
+
+		scopes, err := client.TokenScopes(ctx)
+		if errors.Is(err, gitlab.ErrTokenScopesUnavailable) {
+			// The token may work just fine, GitLab just can't tell
+			return fmt.Errorf("%w: %w", errSelfTestSkipped, err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return checkTokenScopes(scopes, state.IsReadOnly(ctx))
+	}
+
+	return []selfTestCheck{
+		{name: "gitlab connectivity (" + label + ")", token: token, run: connectivity},
+		{name: "token scopes (" + label + ")", token: token, run: scopes},
+	}
+}
+
+// checkTokenScopes returns an error if [scopes] don't allow scm-engine to do its job; read-only mode only reads from the API
+func checkTokenScopes(scopes []string, readOnly bool) error {
+	switch {
+	case slices.Contains(scopes, "api"):
+		return nil
+
+	case readOnly && slices.Contains(scopes, "read_api"):
+		return nil
+
+	case readOnly:
+		return fmt.Errorf("the token needs the 'api' or 'read_api' scope, got %v", scopes)
+
+	default:
+		return fmt.Errorf("the token needs the 'api' scope, got %v", scopes)
+	}
+}
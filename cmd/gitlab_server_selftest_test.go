@@ -0,0 +1,124 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	t.Parallel()
+
+	// The scopes of the API tokens known to the fake GitLab instance
+	tokens := map[string]string{
+		"api-token":      `{"active": true, "scopes": ["api"]}`,
+		"read-api-token": `{"active": true, "scopes": ["read_api"]}`,
+		"expired-token":  `{"active": false, "scopes": ["api"]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token := r.Header.Get("Private-Token")
+
+		scopes, ok := tokens[token]
+		if !ok && token != "job-like-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "401 Unauthorized"}`))
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v4/version":
+			w.Write([]byte(`{"version": "17.5.0"}`))
+
+		case "/api/v4/personal_access_tokens/self":
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "404 Not Found"}`))
+
+				return
+			}
+
+			w.Write([]byte(scopes))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name     string
+		token    string
+		mappings []string
+		readOnly bool
+		wantErr  []string
+	}{
+		{
+			name:     "all tokens have the api scope",
+			token:    "api-token",
+			mappings: []string{"team-a/**=api-token"},
+		},
+		{
+			name:     "read-only mode only needs the read_api scope",
+			token:    "read-api-token",
+			readOnly: true,
+		},
+		{
+			name:  "token scopes GitLab can't describe are skipped",
+			token: "job-like-token",
+		},
+		{
+			name:     "token mapping without the api scope",
+			token:    "api-token",
+			mappings: []string{"team-a/**=api-token", "team-b/**=read-api-token"},
+			wantErr:  []string{"token scopes (token mapping team-b/**): the token needs the 'api' scope, got [read_api]"},
+		},
+		{
+			name:    "expired token",
+			token:   "expired-token",
+			wantErr: []string{"token scopes (default token): the API token is expired or revoked"},
+		},
+		{
+			name:    "invalid token",
+			token:   "invalid-token",
+			wantErr: []string{"gitlab connectivity (default token)", "401"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = state.WithProvider(ctx, "gitlab")
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, tt.token)
+			ctx = state.WithTokenMappings(ctx, tt.mappings)
+			ctx = state.WithReadOnly(ctx, tt.readOnly)
+
+			err := cmd.SelfTest(ctx)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, "self-test failed (use --skip-selftest to start anyway)")
+
+			for _, want := range tt.wantErr {
+				require.ErrorContains(t, err, want)
+			}
+
+			// The token is a secret, so the checks are named after the token mapping instead
+			require.NotContains(t, err.Error(), tt.token)
+		})
+	}
+}
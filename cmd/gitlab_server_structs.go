@@ -1,21 +1,74 @@
 package cmd
 
+// gitlabEventHeaders maps the supported "X-Gitlab-Event" header values to their payload "event_type"
+//
+// See: https://docs.gitlab.com/ee/user/project/integrations/webhook_events.html
+var gitlabEventHeaders = map[string]string{
+	"Confidential Issue Hook": "confidential_issue",
+	"Issue Hook":              "issue",
+	"Merge Request Hook":      "merge_request",
+	"Note Hook":               "note",
+}
+
+// gitlabGroupEventHeaders are the "X-Gitlab-Event" header values of the events only group webhooks send;
+// they are about the group itself rather than a project, so they are acknowledged but otherwise ignored
+//
+// See: https://docs.gitlab.com/ee/user/project/integrations/webhook_events.html#group-member-events
+var gitlabGroupEventHeaders = map[string]bool{
+	"Member Hook":   true,
+	"Project Hook":  true,
+	"Subgroup Hook": true,
+}
+
 type GitlabWebhookPayload struct {
-	EventType        string                            `json:"event_type"`
-	Project          GitlabWebhookPayloadProject       `json:"project"`                     // "project" is sent for all events
-	ObjectAttributes *GitlabWebhookPayloadMergeRequest `json:"object_attributes,omitempty"` // "object_attributes" is sent on "merge_request" events
-	MergeRequest     *GitlabWebhookPayloadMergeRequest `json:"merge_request,omitempty"`     // "merge_request" is sent on "note" activity
+	EventType        string                                `json:"event_type"`
+	ObjectKind       string                                `json:"object_kind,omitempty"`       // "object_kind" is sent for all events, including those without an "event_type" (like "push")
+	EventName        string                                `json:"event_name,omitempty"`        // "event_name" is sent on the group events of group webhooks (like "subgroup_create"), which have no "object_kind"
+	Project          GitlabWebhookPayloadProject           `json:"project"`                     // "project" is sent for all events, and is the target project of merge requests from a fork
+	ObjectAttributes *GitlabWebhookPayloadObjectAttributes `json:"object_attributes,omitempty"` // "object_attributes" is sent on "merge_request" (the merge request), "issue" (the issue) and "note" (the note) events
+	MergeRequest     *GitlabWebhookPayloadMergeRequest     `json:"merge_request,omitempty"`     // "merge_request" is sent on "note" activity
+	Changes          map[string]any                        `json:"changes,omitempty"`           // "changes" is sent on "merge_request" events, keyed by the changed attribute
 }
 
 type GitlabWebhookPayloadProject struct {
 	PathWithNamespace string `json:"path_with_namespace"`
+
+	// Archived is whether the project is archived; GitLab doesn't send it in webhook payloads, but proxies may add it
+	Archived *bool `json:"archived,omitempty"`
+}
+
+type GitlabWebhookPayloadObjectAttributes struct {
+	GitlabWebhookPayloadMergeRequest
+
+	// Action is what happened to the merge request ("open", "update", "approved", "merge", ...) or issue ("open", "update", "close", ...);
+	// only sent on "merge_request" and "issue" events
+	Action string `json:"action,omitempty"`
+
+	// NoteableType is the kind of resource the note was made on ("MergeRequest", "Issue", "Commit" or "Snippet"); only sent on "note" events
+	NoteableType string `json:"noteable_type,omitempty"`
+
+	// OldRev is the previous head commit when new commits were pushed; only sent on "merge_request" "update" events
+	OldRev string `json:"oldrev,omitempty"`
 }
 
 type GitlabWebhookPayloadMergeRequest struct {
 	IID        int                        `json:"iid"`
 	LastCommit GitlabWebhookPayloadCommit `json:"last_commit"`
+
+	// SourceProjectID and TargetProjectID differ when the merge request is from a fork
+	SourceProjectID int `json:"source_project_id,omitempty"`
+	TargetProjectID int `json:"target_project_id,omitempty"`
 }
 
 type GitlabWebhookPayloadCommit struct {
 	ID string `json:"id"`
 }
+
+// GitlabSystemHookPayload holds the fields shared by the system hook events scm-engine cares about
+//
+// See: https://docs.gitlab.com/ee/administration/system_hooks.html
+type GitlabSystemHookPayload struct {
+	ObjectKind        string `json:"object_kind,omitempty"`         // "object_kind" is sent on "merge_request" events
+	EventName         string `json:"event_name,omitempty"`          // "event_name" is sent on project, group and user events
+	PathWithNamespace string `json:"path_with_namespace,omitempty"` // "path_with_namespace" is sent on "project_create" events
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// configInstaller is implemented by clients that can commit a configuration file to a project
+type configInstaller interface {
+	InstallConfig(ctx context.Context, project, filename, content string) error
+}
+
+// GitLabSystemHookHandler handles instance wide GitLab system hooks
+//
+// System hooks are validated with their own secret, since they are configured by instance administrators
+// rather than project maintainers. 'merge_request' events are processed like project webhooks, and
+// 'project_create' events install the bundled default configuration file when [installConfig] is enabled.
+// All other events are acknowledged but otherwise ignored.
+func GitLabSystemHookHandler(ctx context.Context, systemHookSecret string, installConfig bool) (http.HandlerFunc, error) {
+	// Initialize GitLab clients
+	clients, err := NewClientPool(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize GitLab clients: %w", err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// Check if the system hook secret is set (and if its matching)
+		if len(systemHookSecret) > 0 {
+			theirSecret := r.Header.Get("X-Gitlab-Token")
+			if systemHookSecret != theirSecret {
+				errHandler(ctx, w, http.StatusForbidden, errors.New("Missing or invalid X-Gitlab-Token header"))
+
+				return
+			}
+		}
+
+		// Validate content type
+		if !isJSONContentType(r.Header.Get("Content-Type")) {
+			errHandler(ctx, w, http.StatusNotAcceptable, errors.New("The request is not using Content-Type: application/json"))
+
+			return
+		}
+
+		// Read the POST body of the request
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			errHandler(ctx, w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		var payload GitlabSystemHookPayload
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&payload); err != nil {
+			errHandler(ctx, w, http.StatusBadRequest, fmt.Errorf("could not decode POST body into Payload struct: %w", err))
+
+			return
+		}
+
+		switch {
+		// Merge request system hooks carry the same payload as project webhooks, so route them through the normal pipeline
+		case payload.ObjectKind == "merge_request":
+			r = r.Clone(ctx)
+			r.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			serveGitLabWebhook(clients, w, r)
+
+		case payload.EventName == "project_create":
+			ctx = slogctx.With(ctx, slog.String("system_hook_event", payload.EventName), slog.String("project", payload.PathWithNamespace))
+
+			if !installConfig {
+				slogctx.Info(ctx, "Ignoring system hook event; installing configuration files is disabled")
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK - ignored system hook event " + payload.EventName))
+
+				return
+			}
+
+			installConfigFile(ctx, clients, w, payload.PathWithNamespace)
+
+		default:
+			event := payload.EventName
+			if len(event) == 0 {
+				event = payload.ObjectKind
+			}
+
+			slogctx.Info(ctx, "Ignoring system hook event", slog.String("system_hook_event", event))
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK - ignored system hook event " + event))
+		}
+	}, nil
+}
+
+// installConfigFile commits the bundled default configuration file to a newly created [project]
+func installConfigFile(ctx context.Context, clients *ClientPool, w http.ResponseWriter, project string) {
+	if len(project) == 0 {
+		errHandler(ctx, w, http.StatusBadRequest, errors.New("project_create event is missing the 'path_with_namespace' field"))
+
+		return
+	}
+
+	ctx, client, err := clients.ClientForProject(ctx, project)
+	if err != nil {
+		errHandler(ctx, w, http.StatusForbidden, err)
+
+		return
+	}
+
+	installer, ok := client.(configInstaller)
+	if !ok {
+		errHandler(ctx, w, http.StatusInternalServerError, errors.New("the client does not support installing configuration files"))
+
+		return
+	}
+
+	err = installer.InstallConfig(ctx, project, state.ConfigFilePath(ctx), config.DefaultSource())
+
+	switch {
+	case errors.Is(err, gitlab.ErrConfigFileExists):
+		slogctx.Info(ctx, "Project already has a configuration file", slog.Any("reason", err))
+
+	case err != nil:
+		errHandler(ctx, w, http.StatusOK, err)
+
+		return
+
+	default:
+		slogctx.Info(ctx, "Installed configuration file")
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
@@ -0,0 +1,146 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+type systemHookRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+func TestGitLabSystemHookHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		secret        string
+		installConfig bool
+		body          string
+		wantCode      int
+		wantBody      string
+		wantRequests  []string
+	}{
+		{
+			name:     "invalid secret",
+			secret:   "system-secret",
+			body:     `{"event_name": "project_create", "path_with_namespace": "jippi/new-project"}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:         "merge request events are processed",
+			body:         `{"object_kind": "merge_request", "event_type": "merge_request", "project": {"path_with_namespace": "jippi/scm-engine"}, "object_attributes": {"iid": 1, "action": "open", "last_commit": {"id": "def456"}}}`,
+			wantCode:     http.StatusOK,
+			wantRequests: []string{"GET /api/v4/projects/jippi/scm-engine", "GET /api/v4/projects/jippi/scm-engine/repository/files/.scm-engine.yml/raw"},
+		},
+		{
+			name:     "project_create is ignored unless installing configuration files is enabled",
+			body:     `{"event_name": "project_create", "path_with_namespace": "jippi/new-project"}`,
+			wantCode: http.StatusOK,
+			wantBody: "OK - ignored system hook event project_create",
+		},
+		{
+			name:          "project_create installs the default configuration file",
+			installConfig: true,
+			body:          `{"event_name": "project_create", "path_with_namespace": "jippi/new-project"}`,
+			wantCode:      http.StatusOK,
+			wantBody:      "OK",
+			wantRequests: []string{
+				"GET /api/v4/projects/jippi/new-project",
+				"HEAD /api/v4/projects/jippi/new-project/repository/files/.scm-engine.yml",
+				"POST /api/v4/projects/jippi/new-project/repository/files/.scm-engine.yml",
+			},
+		},
+		{
+			name:     "other events are ignored",
+			body:     `{"event_name": "user_create"}`,
+			wantCode: http.StatusOK,
+			wantBody: "OK - ignored system hook event user_create",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []systemHookRequest
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := systemHookRequest{Method: r.Method, Path: r.URL.Path}
+				_ = json.NewDecoder(r.Body).Decode(&request.Body)
+
+				mu.Lock()
+				requests = append(requests, request)
+				mu.Unlock()
+
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/jippi/new-project":
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`{"id": 74, "default_branch": "main"}`))
+
+				case r.Method == http.MethodPost:
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"file_path": ".scm-engine.yml", "branch": "main"}`))
+
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProvider(ctx, "gitlab")
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithDryRun(ctx, false)
+			ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+			handler, err := cmd.GitLabSystemHookHandler(ctx, tt.secret, tt.installConfig)
+			require.NoError(t, err)
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/gitlab/system", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitlab-Event", "System Hook")
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			require.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+
+			if len(tt.wantBody) > 0 {
+				require.Equal(t, tt.wantBody, recorder.Body.String())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			var seen []string
+			for _, request := range requests {
+				seen = append(seen, request.Method+" "+request.Path)
+			}
+
+			require.Equal(t, tt.wantRequests, seen)
+
+			if tt.installConfig {
+				created := requests[len(requests)-1].Body
+				require.Equal(t, "main", created["branch"])
+				require.NotEmpty(t, created["content"])
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// tokenMapping maps projects matching [pattern] to the API [token] that should be used for them
+type tokenMapping struct {
+	pattern string
+	token   string
+}
+
+func (mapping tokenMapping) matches(project string) bool {
+	// "group/**" matches all projects within "group" (and its subgroups)
+	if prefix, ok := strings.CutSuffix(mapping.pattern, "/**"); ok {
+		return strings.HasPrefix(project, prefix+"/")
+	}
+
+	matched, _ := path.Match(mapping.pattern, project)
+
+	return matched
+}
+
+// parseTokenMappings parses a list of "pattern=token" pairs
+func parseTokenMappings(in []string) ([]tokenMapping, error) {
+	mappings := make([]tokenMapping, 0, len(in))
+
+	for _, pair := range in {
+		pattern, token, ok := strings.Cut(pair, "=")
+		if !ok || len(pattern) == 0 || len(token) == 0 {
+			return nil, fmt.Errorf("invalid token mapping %q; must be in the format 'project-glob=token'", redactToken(pair))
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid token mapping pattern %q: %w", pattern, err)
+		}
+
+		mappings = append(mappings, tokenMapping{pattern: pattern, token: token})
+	}
+
+	return mappings, nil
+}
+
+// redactToken removes the token from a "pattern=token" pair so it's safe to include in errors
+func redactToken(pair string) string {
+	pattern, _, _ := strings.Cut(pair, "=")
+
+	return pattern + "=<redacted>"
+}
+
+// ClientPool selects the API token for a project (see --api-token-mapping) and memoizes one client per token
+type ClientPool struct {
+	mappings []tokenMapping
+
+	mu      sync.Mutex
+	clients map[string]scm.Client
+}
+
+// NewClientPool creates a [ClientPool] for the token mappings in [ctx], returning an error if any of them is invalid
+func NewClientPool(ctx context.Context) (*ClientPool, error) {
+	mappings, err := parseTokenMappings(state.TokenMappings(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientPool{mappings: mappings, clients: map[string]scm.Client{}}, nil
+}
+
+// ClientForProject returns the client for [project], along with a context holding the matching token.
+//
+// The first matching mapping wins. The default token (--api-token) is used when no mappings match,
+// and it's an error if no default token is configured either.
+func (pool *ClientPool) ClientForProject(ctx context.Context, project string) (context.Context, scm.Client, error) {
+	token, hasDefault := state.TokenOk(ctx)
+
+	matched := false
+
+	for _, mapping := range pool.mappings {
+		if mapping.matches(project) {
+			token, matched = mapping.token, true
+
+			break
+		}
+	}
+
+	if !matched && !hasDefault {
+		return ctx, nil, fmt.Errorf("no API token is configured for project %q; please check the token mappings", project)
+	}
+
+	ctx = state.WithToken(ctx, token)
+
+	client, err := pool.client(ctx, token)
+
+	return ctx, client, err
+}
+
+// DefaultClient returns the client for the default token (--api-token), for requests that aren't about a single project
+func (pool *ClientPool) DefaultClient(ctx context.Context) (scm.Client, error) {
+	token, ok := state.TokenOk(ctx)
+	if !ok {
+		return nil, errors.New("no default API token is configured")
+	}
+
+	return pool.client(ctx, token)
+}
+
+// client returns the memoized client for [token], creating it with [ctx] (holding the token) on first use
+func (pool *ClientPool) client(ctx context.Context, token string) (scm.Client, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if client, ok := pool.clients[token]; ok {
+		return client, nil
+	}
+
+	client, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.clients[token] = client
+
+	return client, nil
+}
@@ -0,0 +1,134 @@
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenMappingContext(mappings ...string) context.Context {
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "http://gitlab.example.com")
+	ctx = state.WithTokenMappings(ctx, mappings)
+
+	return ctx
+}
+
+func TestNewClientPool_InvalidMappings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mapping string
+		wantErr string
+	}{
+		{mapping: "jippi/**", wantErr: `invalid token mapping "jippi/**=<redacted>"`},
+		{mapping: "=secret", wantErr: `invalid token mapping "=<redacted>"`},
+		{mapping: "jippi/**=", wantErr: `invalid token mapping "jippi/**=<redacted>"`},
+		{mapping: "jippi/[=secret", wantErr: `invalid token mapping pattern "jippi/["`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mapping, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := cmd.NewClientPool(newTokenMappingContext(tt.mapping))
+			require.ErrorContains(t, err, tt.wantErr)
+			require.NotContains(t, err.Error(), "secret")
+		})
+	}
+}
+
+func TestClientPool_ClientForProject(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTokenMappingContext("jippi/scm-engine=exact", "jippi/**=group", "other/*=glob")
+	ctx = state.WithToken(ctx, "default")
+
+	pool, err := cmd.NewClientPool(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		project   string
+		wantToken string
+	}{
+		// The first matching mapping wins, even if a later one matches too
+		{project: "jippi/scm-engine", wantToken: "exact"},
+		{project: "jippi/other", wantToken: "group"},
+		{project: "jippi/subgroup/project", wantToken: "group"},
+		{project: "other/project", wantToken: "glob"},
+		// '*' doesn't match subgroups
+		{project: "other/subgroup/project", wantToken: "default"},
+		{project: "jippi-fork/scm-engine", wantToken: "default"},
+	}
+
+	for _, tt := range tests {
+		projectCtx, _, err := pool.ClientForProject(ctx, tt.project)
+		require.NoError(t, err, tt.project)
+		require.Equal(t, tt.wantToken, state.Token(projectCtx), tt.project)
+	}
+
+	// Clients are memoized per token, and shared by all projects using it
+	_, first, err := pool.ClientForProject(ctx, "jippi/other")
+	require.NoError(t, err)
+
+	_, second, err := pool.ClientForProject(ctx, "jippi/subgroup/project")
+	require.NoError(t, err)
+	require.Same(t, first, second)
+
+	_, exact, err := pool.ClientForProject(ctx, "jippi/scm-engine")
+	require.NoError(t, err)
+	require.NotSame(t, first, exact)
+
+	_, fallback, err := pool.ClientForProject(ctx, "unknown/project")
+	require.NoError(t, err)
+
+	defaultClient, err := pool.DefaultClient(ctx)
+	require.NoError(t, err)
+	require.Same(t, fallback, defaultClient)
+}
+
+func TestClientPool_ClientForProject_NoDefaultToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTokenMappingContext("jippi/**=group")
+
+	pool, err := cmd.NewClientPool(ctx)
+	require.NoError(t, err)
+
+	_, _, err = pool.ClientForProject(ctx, "jippi/scm-engine")
+	require.NoError(t, err)
+
+	_, _, err = pool.ClientForProject(ctx, "other/project")
+	require.ErrorContains(t, err, `no API token is configured for project "other/project"`)
+
+	_, err = pool.DefaultClient(ctx)
+	require.ErrorContains(t, err, "no default API token is configured")
+}
+
+func TestGitLabWebhookHandler_NoTokenForProject(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTokenMappingContext("jippi/**=group")
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+	handler, err := cmd.GitLabWebhookHandler(ctx, "")
+	require.NoError(t, err)
+
+	body := `{"event_type": "merge_request", "project": {"path_with_namespace": "other/project", "archived": false}, "object_attributes": {"iid": 1, "action": "update", "last_commit": {"id": "abc123"}}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `no API token is configured for project "other/project"`)
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/urfave/cli/v2"
+	slogctx "github.com/veqryn/slog-context"
+	"golang.org/x/time/rate"
+)
+
+type remoteValidationResult struct {
+	project string
+	status  string
+	err     error
+}
+
+func ValidateRemote(cCtx *cli.Context) error {
+	ctx := cCtx.Context
+	ctx = state.WithConfigFilePath(ctx, cCtx.String(FlagConfigFile))
+
+	group := cCtx.String(FlagSCMGroup)
+	if len(group) == 0 {
+		return fmt.Errorf("Missing required flag: --%s", FlagSCMGroup)
+	}
+
+	client, err := gitlab.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	projects, err := client.GroupProjects(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	slogctx.Info(ctx, "Validating remote configuration files", slog.String("group", group), slog.Int("number_of_projects", len(projects)))
+
+	var (
+		limiter = rate.NewLimiter(rate.Limit(cCtx.Float64(FlagRateLimit)), 1)
+		queue   = make(chan gitlab.GroupProject)
+		results = make(chan remoteValidationResult, len(projects))
+		wg      sync.WaitGroup
+	)
+
+	for range max(cCtx.Int(FlagConcurrency), 1) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for project := range queue {
+				ctx := state.WithProjectID(ctx, project.PathWithNamespace)
+
+				if err := limiter.Wait(ctx); err != nil {
+					results <- remoteValidationResult{project: project.PathWithNamespace, status: "skipped", err: err}
+
+					continue
+				}
+
+				status, err := validateRemoteConfig(ctx, client, project)
+
+				results <- remoteValidationResult{project: project.PathWithNamespace, status: status, err: err}
+			}
+		}()
+	}
+
+	for _, project := range projects {
+		queue <- project
+	}
+
+	close(queue)
+	wg.Wait()
+	close(results)
+
+	// Report
+	var (
+		report []remoteValidationResult
+		broken int
+	)
+
+	for result := range results {
+		if result.err != nil {
+			broken++
+		}
+
+		report = append(report, result)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].project < report[j].project
+	})
+
+	for _, result := range report {
+		if result.err != nil {
+			fmt.Fprintf(cCtx.App.Writer, "%-10s %s: %s\n", result.status, result.project, result.err)
+
+			continue
+		}
+
+		fmt.Fprintf(cCtx.App.Writer, "%-10s %s\n", result.status, result.project)
+	}
+
+	fmt.Fprintf(cCtx.App.Writer, "\n%d projects checked, %d with broken configuration files\n", len(report), broken)
+
+	if broken > 0 {
+		return cli.Exit("", 1)
+	}
+
+	return nil
+}
+
+// validateRemoteConfig reads, parses and lints the configuration file on the default branch of [project]
+func validateRemoteConfig(ctx context.Context, client *gitlab.Client, project gitlab.GroupProject) (string, error) {
+	file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), project.DefaultBranch)
+	if err != nil {
+		// Projects without configuration file are not broken, they are just not using scm-engine
+		if errors.Is(err, scm.ErrConfigFileNotFound) {
+			return "no-config", nil
+		}
+
+		return "error", err
+	}
+
+	cfg, err := config.ParseFile(file)
+	if err != nil {
+		return "invalid", err
+	}
+
+	if err := cfg.Lint(ctx, &gitlab.Context{}); err != nil {
+		return "invalid", err
+	}
+
+	return "ok", nil
+}
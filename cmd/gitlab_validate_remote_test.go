@@ -0,0 +1,132 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// validateRemoteGitLab is a fake GitLab API serving the group 'jippi' with one project per entry in [configs]:
+// the configuration file on the default branch, or an empty value for projects without one.
+// Projects named 'error' respond with 403 Forbidden
+func validateRemoteGitLab(t *testing.T, configs map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/api/v4/groups/jippi/projects" {
+			projects := []map[string]any{}
+			for name := range configs {
+				projects = append(projects, map[string]any{"path_with_namespace": "jippi/" + name, "default_branch": "main"})
+			}
+
+			require.NoError(t, json.NewEncoder(w).Encode(projects))
+
+			return
+		}
+
+		project, ok := strings.CutPrefix(r.URL.Path, "/api/v4/projects/jippi/")
+		project, _, _ = strings.Cut(project, "/")
+
+		switch {
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+
+		case project == "error":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "403 Forbidden"}`))
+
+		case len(configs[project]) == 0:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 File Not Found"}`))
+
+		default:
+			require.Equal(t, "main", r.URL.Query().Get("ref"))
+
+			w.Write([]byte(configs[project]))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// runValidateRemote runs 'gitlab validate-remote' for the group 'jippi' against [server], returning the output
+func runValidateRemote(t *testing.T, server *httptest.Server) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	app := &cli.App{
+		Writer:         &out,
+		ExitErrHandler: func(*cli.Context, error) {}, // Don't exit the test binary on broken configuration files
+		Flags:          []cli.Flag{&cli.StringFlag{Name: cmd.FlagConfigFile, Value: ".scm-engine.yml"}},
+		Commands:       []*cli.Command{cmd.GitLab},
+	}
+
+	err := app.Run([]string{"scm-engine", "gitlab", "--" + cmd.FlagAPIToken, "validate-remote-api-token", "--" + cmd.FlagSCMBaseURL, server.URL, "validate-remote", "--" + cmd.FlagSCMGroup, "jippi", "--" + cmd.FlagRateLimit, "1000"})
+
+	return out.String(), err
+}
+
+func TestValidateRemote(t *testing.T) {
+	t.Parallel()
+
+	server := validateRemoteGitLab(t, map[string]string{
+		"ok":      "label:\n  - name: bug\n    script: \"true\"\n",
+		"none":    "",
+		"invalid": "label:\n  - name: bug\n    script: \"merge_request.does_not_exist\"\n",
+		"broken":  "label: [",
+		"error":   "",
+	})
+
+	out, err := runValidateRemote(t, server)
+
+	// Broken configuration files exit non-zero
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 1, exitErr.ExitCode())
+
+	// Every project is reported with its status, sorted by project
+	reports := []string{
+		"invalid    jippi/broken: yaml: ",
+		"error      jippi/error: failed to read remote raw file: ",
+		"invalid    jippi/invalid: ",
+		"no-config  jippi/none\n",
+		"ok         jippi/ok\n",
+		"\n5 projects checked, 3 with broken configuration files\n",
+	}
+
+	offset := 0
+
+	for _, report := range reports {
+		index := strings.Index(out[offset:], report)
+		require.GreaterOrEqual(t, index, 0, "%q not found after offset %d in:\n%s", report, offset, out)
+
+		offset += index + len(report)
+	}
+
+	require.Contains(t, out, "403 Forbidden")
+	require.Contains(t, out, `Label "bug" failed validation`)
+}
+
+func TestValidateRemote_AllValid(t *testing.T) {
+	t.Parallel()
+
+	server := validateRemoteGitLab(t, map[string]string{
+		"ok":   "label:\n  - name: bug\n    script: \"true\"\n",
+		"none": "",
+	})
+
+	out, err := runValidateRemote(t, server)
+	require.NoError(t, err)
+	require.Equal(t, "no-config  jippi/none\nok         jippi/ok\n\n2 projects checked, 0 with broken configuration files\n", out)
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/urfave/cli/v2"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+var Init = &cli.Command{
+	Name:   "init",
+	Usage:  "Write a commented starter configuration file (to the --config path) for the project",
+	Action: InitConfig,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  FlagProvider,
+			Usage: "SCM the configuration file is for, either 'gitlab' or 'github'. Detected from the git remote of the current directory if not set, defaulting to 'gitlab'",
+		},
+		&cli.BoolFlag{
+			Name:  FlagForce,
+			Usage: "Overwrite the configuration file if it already exists",
+		},
+	},
+}
+
+func InitConfig(cCtx *cli.Context) error {
+	ctx := cCtx.Context
+
+	provider := cCtx.String(FlagProvider)
+	if len(provider) == 0 {
+		provider = "gitlab"
+
+		if dir, err := os.Getwd(); err == nil {
+			if detected := DetectProvider(dir); len(detected) > 0 {
+				provider = detected
+			}
+		}
+
+		slogctx.Info(ctx, "Writing starter configuration file for "+provider+", use --"+FlagProvider+" to change it")
+	}
+
+	source, err := config.StarterSource(provider)
+	if err != nil {
+		return err
+	}
+
+	path := cCtx.String(FlagConfigFile)
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !cCtx.Bool(FlagForce) {
+		flags |= os.O_EXCL
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("the configuration file %q already exists, use --%s to overwrite it", path, FlagForce)
+		}
+
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(source); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	slogctx.Info(ctx, "Wrote starter configuration file", slog.String("path", path), slog.String("provider", provider))
+
+	return nil
+}
+
+// DetectProvider returns the SCM ('gitlab' or 'github') of the git repository [dir] is in, from its 'origin'
+// remote (or else the first remote), or an empty string if [dir] is not in a git repository with a remote.
+//
+// Remotes on GitHub hosts are 'github', remotes on any other host are assumed to be (self-hosted) GitLab
+func DetectProvider(dir string) string {
+	remote := gitRemoteURL(dir)
+	if len(remote) == 0 {
+		return ""
+	}
+
+	if strings.Contains(strings.ToLower(gitRemoteHost(remote)), "github") {
+		return "github"
+	}
+
+	return "gitlab"
+}
+
+// gitRemoteURL returns the URL of the 'origin' remote (or else the first remote) of the git repository [dir] is in
+func gitRemoteURL(dir string) string {
+	configPath := findGitConfig(dir)
+	if len(configPath) == 0 {
+		return ""
+	}
+
+	file, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var (
+		remote  string
+		section string
+		urls    = map[string]string{}
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			section = line
+
+			continue
+		}
+
+		name, found := strings.CutPrefix(section, `[remote "`)
+		if !found {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.TrimSpace(key) != "url" {
+			continue
+		}
+
+		name = strings.TrimSuffix(name, `"]`)
+		if _, ok := urls[name]; ok {
+			continue
+		}
+
+		urls[name] = strings.TrimSpace(value)
+
+		if len(remote) == 0 {
+			remote = name
+		}
+	}
+
+	if origin, ok := urls["origin"]; ok {
+		return origin
+	}
+
+	return urls[remote]
+}
+
+// findGitConfig returns the path to the git configuration file of the repository [dir] is in
+func findGitConfig(dir string) string {
+	for {
+		gitPath := filepath.Join(dir, ".git")
+
+		info, err := os.Stat(gitPath)
+		if err == nil {
+			// Worktrees and submodules have a '.git' file, pointing at the git directory
+			if !info.IsDir() {
+				content, err := os.ReadFile(gitPath)
+				if err != nil {
+					return ""
+				}
+
+				gitDir, found := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+				if !found {
+					return ""
+				}
+
+				gitPath = strings.TrimSpace(gitDir)
+				if !filepath.IsAbs(gitPath) {
+					gitPath = filepath.Join(dir, gitPath)
+				}
+			}
+
+			return filepath.Join(gitPath, "config")
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
+}
+
+// gitRemoteHost returns the host of the git [remote] URL, both for URLs (example: 'https://github.com/org/repo.git')
+// and scp-like SSH remotes (example: 'git@github.com:org/repo.git')
+func gitRemoteHost(remote string) string {
+	if strings.Contains(remote, "://") {
+		parsed, err := url.Parse(remote)
+		if err != nil {
+			return ""
+		}
+
+		return parsed.Hostname()
+	}
+
+	host, _, _ := strings.Cut(remote, ":")
+	if _, after, found := strings.Cut(host, "@"); found {
+		host = after
+	}
+
+	return host
+}
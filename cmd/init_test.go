@@ -0,0 +1,132 @@
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/github"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestInitConfig(t *testing.T) {
+	t.Parallel()
+
+	run := func(args ...string) error {
+		app := &cli.App{
+			Flags:    []cli.Flag{&cli.StringFlag{Name: cmd.FlagConfigFile}},
+			Commands: []*cli.Command{cmd.Init},
+		}
+
+		return app.Run(append([]string{"scm-engine"}, args...))
+	}
+
+	for provider, evalContext := range map[string]scm.EvalContext{"gitlab": &gitlab.Context{}, "github": &github.Context{}} {
+		t.Run("writes a valid "+provider+" starter configuration file", func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), ".scm-engine.yml")
+
+			require.NoError(t, run("--config", path, "init", "--provider", provider))
+
+			cfg, err := config.LoadFile(path)
+			require.NoError(t, err)
+			require.NotEmpty(t, cfg.Labels)
+			require.NotEmpty(t, cfg.Actions)
+			require.NoError(t, cfg.Lint(context.Background(), evalContext))
+		})
+	}
+
+	t.Run("refuses to overwrite an existing file unless forced", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), ".scm-engine.yml")
+		require.NoError(t, os.WriteFile(path, []byte("label: []\n"), 0o600))
+
+		require.ErrorContains(t, run("--config", path, "init", "--provider", "gitlab"), "already exists, use --force to overwrite it")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "label: []\n", string(content))
+
+		require.NoError(t, run("--config", path, "init", "--provider", "gitlab", "--force"))
+
+		content, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(content), "merge_request.modified_files")
+	})
+
+	t.Run("unsupported provider", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), ".scm-engine.yml")
+
+		require.ErrorContains(t, run("--config", path, "init", "--provider", "bitbucket"), `unsupported provider "bitbucket"`)
+		require.NoFileExists(t, path)
+	})
+}
+
+func TestDetectProvider(t *testing.T) {
+	t.Parallel()
+
+	repository := func(t *testing.T, gitConfig string) string {
+		t.Helper()
+
+		dir := t.TempDir()
+
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(gitConfig), 0o600))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub", "dir"), 0o700))
+
+		return dir
+	}
+
+	tests := []struct {
+		name      string
+		gitConfig string
+		want      string
+	}{
+		{
+			name:      "GitHub SSH remote",
+			gitConfig: "[core]\n\tbare = false\n[remote \"origin\"]\n\turl = git@github.com:jippi/scm-engine.git\n",
+			want:      "github",
+		},
+		{
+			name:      "self-hosted GitLab HTTPS remote",
+			gitConfig: "[remote \"origin\"]\n\turl = https://gitlab.example.com/jippi/scm-engine.git\n",
+			want:      "gitlab",
+		},
+		{
+			name:      "origin is preferred over other remotes",
+			gitConfig: "[remote \"upstream\"]\n\turl = https://github.com/jippi/scm-engine.git\n[remote \"origin\"]\n\turl = ssh://git@gitlab.com/jippi/scm-engine.git\n",
+			want:      "gitlab",
+		},
+		{
+			name:      "first remote without origin",
+			gitConfig: "[remote \"upstream\"]\n\turl = https://github.com/jippi/scm-engine.git\n",
+			want:      "github",
+		},
+		{
+			name:      "no remotes",
+			gitConfig: "[core]\n\tbare = false\n",
+			want:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := repository(t, tt.gitConfig)
+
+			require.Equal(t, tt.want, cmd.DetectProvider(dir))
+			require.Equal(t, tt.want, cmd.DetectProvider(filepath.Join(dir, "sub", "dir")))
+		})
+	}
+}
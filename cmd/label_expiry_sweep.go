@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// SweepExpiredLabels removes the [labels] (see --label-expiry-sweep-labels) that expired on the Merge Request, based on
+// their 'expires_at' setting in [cfg] and the Merge Request label event history.
+//
+// Unlike [ProcessMR], no rules are evaluated and the evaluation context isn't loaded, keeping periodic sweeps cheap.
+// Rules adding the label again later still respect the expiry, like in a full evaluation
+func SweepExpiredLabels(ctx context.Context, client scm.Client, cfg *config.Config, labels []string) error {
+	defer state.LockForProcessing(ctx)()
+
+	cfg, err := completeConfig(ctx, client, cfg)
+	if err != nil {
+		if errors.Is(err, config.ErrProjectDisabled) {
+			slogctx.Info(ctx, err.Error())
+
+			return nil
+		}
+
+		return err
+	}
+
+	// Allow changing the 'dry-run' mode via configuration file
+	if cfg.DryRun != nil && *cfg.DryRun != state.IsDryRun(ctx) && !state.IsReadOnly(ctx) {
+		ctx = state.WithDryRun(ctx, *cfg.DryRun)
+	}
+
+	expiresAfter, err := cfg.Labels.ExpiresAfter()
+	if err != nil {
+		return err
+	}
+
+	var candidates []string
+
+	for _, label := range labels {
+		if _, ok := expiresAfter[label]; ok {
+			candidates = append(candidates, label)
+		}
+	}
+
+	if len(candidates) == 0 {
+		slogctx.Debug(ctx, "None of the swept labels has an 'expires_at' setting in the configuration file", slog.Any("labels", labels))
+
+		return nil
+	}
+
+	events, err := client.MergeRequests().LabelEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		now     = time.Now()
+		expired scm.LabelOptions
+	)
+
+	for _, label := range candidates {
+		if scm.LabelExpired(events, label, expiresAfter[label], now) && !slices.Contains(expired, label) {
+			slogctx.Info(ctx, "Label has expired, removing it", slog.String("label", label), slog.Duration("expires_after", expiresAfter[label]))
+
+			expired = append(expired, label)
+		}
+	}
+
+	if len(expired) == 0 {
+		slogctx.Debug(ctx, "No labels have expired")
+
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Removing expired labels", slog.Any("labels", expired))
+
+		return nil
+	}
+
+	_, err = client.MergeRequests().Update(ctx, &scm.UpdateMergeRequestOptions{RemoveLabels: &expired})
+
+	return err
+}
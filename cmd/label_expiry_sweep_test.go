@@ -0,0 +1,138 @@
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweepExpiredLabels(t *testing.T) {
+	t.Parallel()
+
+	// The scripts don't compile, so the sweep fails if it evaluates any rules
+	const configFile = `
+label:
+  - name: needs-review
+    script: this is not a valid script
+    expires_at: 1h
+
+  - name: hotfix
+    script: this is not a valid script either
+`
+
+	const eventsPath = "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1/resource_label_events"
+
+	addedAgo := func(name string, ago time.Duration) string {
+		return fmt.Sprintf(`[{"id": 1, "action": "add", "label": {"name": %q}, "created_at": %q}]`, name, time.Now().Add(-ago).Format(time.RFC3339))
+	}
+
+	tests := []struct {
+		name         string
+		config       string
+		labels       []string
+		events       string
+		dryRun       bool
+		wantRequests []string
+	}{
+		{
+			name:   "removes expired labels",
+			config: configFile,
+			labels: []string{"needs-review"},
+			events: addedAgo("needs-review", 2*time.Hour),
+			wantRequests: []string{
+				"GET " + eventsPath,
+				`PUT /api/v4/projects/jippi%2Fscm-engine/merge_requests/1 {"remove_labels":["needs-review"]}`,
+			},
+		},
+		{
+			name:         "keeps labels that haven't expired yet",
+			config:       configFile,
+			labels:       []string{"needs-review"},
+			events:       addedAgo("needs-review", 10*time.Minute),
+			wantRequests: []string{"GET " + eventsPath},
+		},
+		{
+			name:         "dry run doesn't remove the labels",
+			config:       configFile,
+			labels:       []string{"needs-review"},
+			events:       addedAgo("needs-review", 2*time.Hour),
+			dryRun:       true,
+			wantRequests: []string{"GET " + eventsPath},
+		},
+		{
+			name:   "labels without 'expires_at' are ignored",
+			config: configFile,
+			labels: []string{"hotfix"},
+			events: addedAgo("hotfix", 24*time.Hour),
+		},
+		{
+			name:   "projects that opted out are skipped",
+			config: "enabled: false\n" + configFile,
+			labels: []string{"needs-review"},
+			events: addedAgo("needs-review", 2*time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.EscapedPath()
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				if r.URL.EscapedPath() == eventsPath {
+					w.Write([]byte(tt.events))
+
+					return
+				}
+
+				w.Write([]byte(`{}`))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProvider(ctx, "gitlab")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithDryRun(ctx, tt.dryRun)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			cfg, err := config.ParseFile(strings.NewReader(tt.config))
+			require.NoError(t, err)
+
+			require.NoError(t, cmd.SweepExpiredLabels(ctx, client, cfg, tt.labels))
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
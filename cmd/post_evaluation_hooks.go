@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// DefaultPostEvaluationHookTimeout is how long a post-evaluation hook may run before it's stopped
+const DefaultPostEvaluationHookTimeout = 30 * time.Second
+
+// maxPostEvaluationHookOutput is how much of the stdout and stderr of an exec hook is kept for the logs
+const maxPostEvaluationHookOutput = 64 * 1024
+
+// PostEvaluationHook is called with the outcome of every evaluation of a Merge Request, see [RegisterPostEvaluationHook].
+//
+// Hooks run after the evaluation, and can't change its outcome: returning an error only logs it
+type PostEvaluationHook func(ctx context.Context, record AuditRecord) error
+
+// postEvaluationHooks are the registered hooks, in registration order
+var postEvaluationHooks = struct {
+	sync.RWMutex
+
+	names []string
+	hooks []PostEvaluationHook
+}{}
+
+// pendingPostEvaluationHooks tracks the hooks started by [ProcessMR] that haven't completed yet
+var pendingPostEvaluationHooks sync.WaitGroup
+
+// RegisterPostEvaluationHook makes [ProcessMR] call [fn] after every evaluation, for programs using scm-engine as a
+// library to integrate with other systems. [fn] gets a context limited to [DefaultPostEvaluationHookTimeout].
+//
+// It's called from 'init()' functions, like [database/sql.Register], and panics if [fn] is nil,
+// or [name] is empty or already registered
+func RegisterPostEvaluationHook(name string, fn PostEvaluationHook) {
+	if len(name) == 0 {
+		panic("cmd: RegisterPostEvaluationHook name is empty")
+	}
+
+	if fn == nil {
+		panic("cmd: RegisterPostEvaluationHook hook is nil for " + name)
+	}
+
+	postEvaluationHooks.Lock()
+	defer postEvaluationHooks.Unlock()
+
+	for _, registered := range postEvaluationHooks.names {
+		if registered == name {
+			panic(fmt.Sprintf("cmd: RegisterPostEvaluationHook called twice for %q", name))
+		}
+	}
+
+	postEvaluationHooks.names = append(postEvaluationHooks.names, name)
+	postEvaluationHooks.hooks = append(postEvaluationHooks.hooks, fn)
+}
+
+type execHookKey struct{}
+
+// ExecHook runs an external command after every evaluation, with the [AuditRecord] as JSON on stdin.
+//
+// The command is run directly (not through a shell), its output is logged, and it's stopped after [Timeout]
+type ExecHook struct {
+	// The command and its arguments
+	Command []string
+
+	// How long the command may run
+	Timeout time.Duration
+}
+
+// NewExecHook creates an [ExecHook] for [command], split into arguments on whitespace
+// (example: '/usr/local/bin/notify --channel reviews')
+func NewExecHook(command string, timeout time.Duration) (*ExecHook, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("the post-evaluation hook command is empty")
+	}
+
+	if timeout <= 0 {
+		return nil, fmt.Errorf("the post-evaluation hook timeout must be positive, got %s", timeout)
+	}
+
+	return &ExecHook{Command: args, Timeout: timeout}, nil
+}
+
+func withExecHook(ctx context.Context, hook *ExecHook) context.Context {
+	return context.WithValue(ctx, execHookKey{}, hook)
+}
+
+// execHookFromContext returns the exec hook, or nil if none is configured
+func execHookFromContext(ctx context.Context) *ExecHook {
+	hook, _ := ctx.Value(execHookKey{}).(*ExecHook)
+
+	return hook
+}
+
+// Run runs the command with [record] as JSON on stdin, returning an error if it fails or times out
+func (h *ExecHook) Run(ctx context.Context, record AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not encode the evaluation record: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	var stdout, stderr cappedBuffer
+
+	command := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...) //nolint:gosec
+	command.Stdin = bytes.NewReader(body)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	command.WaitDelay = time.Second
+
+	started := time.Now()
+	err = command.Run()
+
+	attrs := []any{
+		slog.String("command", h.Command[0]),
+		slog.Duration("duration", time.Since(started)),
+		slog.String("stdout", stdout.String()),
+		slog.String("stderr", stderr.String()),
+	}
+
+	if ctx.Err() != nil {
+		slogctx.Warn(ctx, "Post-evaluation hook timed out", attrs...)
+
+		return fmt.Errorf("post-evaluation hook %q timed out after %s", h.Command[0], h.Timeout)
+	}
+
+	if err != nil {
+		slogctx.Warn(ctx, "Post-evaluation hook failed", attrs...)
+
+		return fmt.Errorf("post-evaluation hook %q failed: %w", h.Command[0], err)
+	}
+
+	slogctx.Info(ctx, "Post-evaluation hook completed", attrs...)
+
+	return nil
+}
+
+// hasPostEvaluationHooks reports whether there are any hooks to run after an evaluation
+func hasPostEvaluationHooks(ctx context.Context) bool {
+	if execHookFromContext(ctx) != nil {
+		return true
+	}
+
+	postEvaluationHooks.RLock()
+	defer postEvaluationHooks.RUnlock()
+
+	return len(postEvaluationHooks.hooks) > 0
+}
+
+// RunPostEvaluationHooks runs the registered hooks (see [RegisterPostEvaluationHook]) and the exec hook
+// (if any) with [record], one after the other. [ProcessMR] runs them in the background after every evaluation,
+// see [WaitForPostEvaluationHooks].
+//
+// Hooks outlive the (webhook request) context of the evaluation, and errors are logged rather than returned,
+// so a failing hook never fails an evaluation
+func RunPostEvaluationHooks(ctx context.Context, record AuditRecord) {
+	ctx = context.WithoutCancel(ctx)
+
+	postEvaluationHooks.RLock()
+	names := postEvaluationHooks.names
+	hooks := postEvaluationHooks.hooks
+	postEvaluationHooks.RUnlock()
+
+	for i, hook := range hooks {
+		hookCtx, cancel := context.WithTimeout(ctx, DefaultPostEvaluationHookTimeout)
+
+		if err := hook(hookCtx, record); err != nil {
+			slogctx.Error(ctx, "Post-evaluation hook failed", slog.String("hook", names[i]), slog.Any("error", err))
+		}
+
+		cancel()
+	}
+
+	if hook := execHookFromContext(ctx); hook != nil {
+		if err := hook.Run(ctx, record); err != nil {
+			slogctx.Error(ctx, "Post-evaluation hook failed", slog.Any("error", err))
+		}
+	}
+}
+
+// runPostEvaluationHooksInBackground runs [RunPostEvaluationHooks] in a goroutine, so slow hooks don't delay
+// the (webhook) response of the evaluation
+func runPostEvaluationHooksInBackground(ctx context.Context, record AuditRecord) {
+	pendingPostEvaluationHooks.Add(1)
+
+	go func() {
+		defer pendingPostEvaluationHooks.Done()
+
+		RunPostEvaluationHooks(ctx, record)
+	}()
+}
+
+// WaitForPostEvaluationHooks blocks until the post-evaluation hooks of all evaluations so far have completed.
+//
+// The server calls it on shutdown, and the 'gitlab' command before exiting, so no hook is cut off
+func WaitForPostEvaluationHooks() {
+	pendingPostEvaluationHooks.Wait()
+}
+
+// cappedBuffer keeps the first [maxPostEvaluationHookOutput] bytes written to it, discarding the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := maxPostEvaluationHookOutput - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+
+		return len(p), nil
+	}
+
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + " [truncated]"
+	}
+
+	return b.buf.String()
+}
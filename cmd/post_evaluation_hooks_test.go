@@ -0,0 +1,200 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func newHookRecord(requestID string) cmd.AuditRecord {
+	return cmd.AuditRecord{
+		RequestID: requestID,
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Outcome:   cmd.AuditOutcomeSuccess,
+		MergeRequestReport: cmd.MergeRequestReport{
+			Project:        "group/project",
+			MergeRequestID: "42",
+		},
+	}
+}
+
+// writeHookScript writes an executable shell script running [script] to a temporary directory
+func writeHookScript(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o700)) //nolint:gosec
+
+	return path
+}
+
+func TestExecHook_Run(t *testing.T) {
+	t.Parallel()
+
+	output := filepath.Join(t.TempDir(), "record.json")
+	script := writeHookScript(t, `cat > "$1"; echo "received"`)
+
+	hook, err := cmd.NewExecHook(script+" "+output, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, hook.Run(context.Background(), newHookRecord("exec-hook")))
+
+	body, err := os.ReadFile(output)
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(body, &record))
+
+	require.Equal(t, "exec-hook", record["request_id"])
+	require.Equal(t, cmd.AuditOutcomeSuccess, record["outcome"])
+	require.Equal(t, "group/project", record["project"])
+	require.Equal(t, "42", record["merge_request_id"])
+}
+
+func TestExecHook_Run_Failure(t *testing.T) {
+	t.Parallel()
+
+	hook, err := cmd.NewExecHook(writeHookScript(t, `echo "boom" >&2; exit 3`), time.Minute)
+	require.NoError(t, err)
+
+	require.ErrorContains(t, hook.Run(context.Background(), newHookRecord("exec-hook-failure")), "exit status 3")
+}
+
+func TestExecHook_Run_Timeout(t *testing.T) {
+	t.Parallel()
+
+	hook, err := cmd.NewExecHook(writeHookScript(t, `sleep 10`), 50*time.Millisecond)
+	require.NoError(t, err)
+
+	started := time.Now()
+
+	require.ErrorContains(t, hook.Run(context.Background(), newHookRecord("exec-hook-timeout")), "timed out after 50ms")
+	require.Less(t, time.Since(started), 5*time.Second)
+}
+
+func TestNewExecHook_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := cmd.NewExecHook("   ", time.Minute)
+	require.ErrorContains(t, err, "command is empty")
+
+	_, err = cmd.NewExecHook("/bin/true", 0)
+	require.ErrorContains(t, err, "timeout must be positive")
+}
+
+func TestRunPostEvaluationHooks(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan cmd.AuditRecord, 1)
+
+	cmd.RegisterPostEvaluationHook("test-run-post-evaluation-hooks", func(ctx context.Context, record cmd.AuditRecord) error {
+		// Other tests may run the registered hooks too
+		if record.RequestID != "registered-hook" {
+			return nil
+		}
+
+		_, hasDeadline := ctx.Deadline()
+		require.True(t, hasDeadline)
+
+		received <- record
+
+		// Errors are logged, and don't stop the other hooks
+		return errors.New("hook failed")
+	})
+
+	// The context of the evaluation being canceled (example: the webhook request is done) doesn't stop hooks
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd.RunPostEvaluationHooks(ctx, newHookRecord("registered-hook"))
+
+	select {
+	case record := <-received:
+		require.Equal(t, newHookRecord("registered-hook"), record)
+
+	default:
+		t.Fatal("the registered hook wasn't called")
+	}
+}
+
+func TestProcessMR_PostEvaluationHooksRunInBackground(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	cmd.RegisterPostEvaluationHook("test-background-post-evaluation-hooks", func(ctx context.Context, record cmd.AuditRecord) error {
+		// Other tests may run the registered hooks too
+		if record.RequestID != "background-hook" {
+			return nil
+		}
+
+		close(started)
+		<-release
+		close(done)
+
+		return nil
+	})
+
+	api := &testGitLab{
+		config:  func(string) string { return "label: []\n" },
+		headSHA: func() string { return "abc123" },
+	}
+
+	ctx, gitlabClient := newTestGitLab(t, api)
+	ctx = state.WithRequestID(ctx, "background-hook")
+
+	client := &testClient{Client: gitlabClient, evalContext: &testEvalContext{}}
+
+	// The evaluation completes while the hook is still running
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+	<-started
+
+	select {
+	case <-done:
+		t.Fatal("the hook completed before it was released")
+
+	default:
+	}
+
+	close(release)
+
+	cmd.WaitForPostEvaluationHooks()
+
+	select {
+	case <-done:
+
+	default:
+		t.Fatal("WaitForPostEvaluationHooks returned before the hook completed")
+	}
+}
+
+func TestRegisterPostEvaluationHook_Panics(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, cmd.AuditRecord) error { return nil }
+
+	require.PanicsWithValue(t, "cmd: RegisterPostEvaluationHook name is empty", func() {
+		cmd.RegisterPostEvaluationHook("", noop)
+	})
+
+	require.PanicsWithValue(t, "cmd: RegisterPostEvaluationHook hook is nil for nil-hook", func() {
+		cmd.RegisterPostEvaluationHook("nil-hook", nil)
+	})
+
+	cmd.RegisterPostEvaluationHook("test-duplicate-hook", noop)
+
+	require.PanicsWithValue(t, `cmd: RegisterPostEvaluationHook called twice for "test-duplicate-hook"`, func() {
+		cmd.RegisterPostEvaluationHook("test-duplicate-hook", noop)
+	})
+}
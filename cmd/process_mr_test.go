@@ -0,0 +1,290 @@
+package cmd_test
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMergeRequestPath = "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1"
+	testConfigFilePath   = "/api/v4/projects/jippi%2Fscm-engine/repository/files/%2Escm-engine%2Eyml/raw"
+)
+
+// testEvalContext is a minimal [scm.EvalContext], exposing the Merge Request title and labels to scripts
+type testEvalContext struct {
+	Title  string         `expr:"title"`
+	Labels []string       `expr:"labels"`
+	Vars   map[string]any `expr:"vars"`
+}
+
+func (testEvalContext) AllowPipelineFailure(context.Context) bool                     { return false }
+func (testEvalContext) CanUseConfigurationFileFromChangeRequest(context.Context) bool { return true }
+func (testEvalContext) GetDescription() string                                        { return "" }
+func (c *testEvalContext) GetLabels() []string                                        { return c.Labels }
+func (testEvalContext) GetTargetBranch() string                                       { return "main" }
+func (c *testEvalContext) GetTitle() string                                           { return c.Title }
+func (testEvalContext) HasExecutedActionGroup(string) bool                            { return false }
+func (testEvalContext) IsDraft() bool                                                 { return false }
+func (testEvalContext) IsValid() bool                                                 { return true }
+func (testEvalContext) SetContext(context.Context)                                    {}
+func (c *testEvalContext) SetVars(vars map[string]any)                                { c.Vars = vars }
+func (testEvalContext) SetWebhookEvent(any)                                           {}
+func (testEvalContext) TrackActionGroupExecution(string)                              {}
+func (testEvalContext) UnavailableData() map[string]string                            { return nil }
+
+// testClient is a GitLab client with a fixed evaluation context, so [cmd.ProcessMR] can be tested without the GraphQL API
+type testClient struct {
+	*gitlab.Client
+
+	evalContext scm.EvalContext
+
+	// applyStep replaces [gitlab.Client.ApplyStep] if set
+	applyStep func(ctx context.Context, step scm.ActionStep) error
+}
+
+func (c *testClient) EvalContext(context.Context) (scm.EvalContext, error) {
+	return c.evalContext, nil
+}
+
+func (c *testClient) ApplyStep(ctx context.Context, evalContext scm.EvalContext, update *scm.UpdateMergeRequestOptions, step scm.ActionStep) error {
+	if c.applyStep != nil {
+		return c.applyStep(ctx, step)
+	}
+
+	return c.Client.ApplyStep(ctx, evalContext, update, step)
+}
+
+// testGitLab is a fake GitLab API serving the configuration file and Merge Request, recording every other request
+type testGitLab struct {
+	mu       sync.Mutex
+	requests []string
+
+	// config returns the configuration file at [ref]
+	config func(ref string) string
+
+	// headSHA returns the head commit of the Merge Request, called for every read of the Merge Request
+	headSHA func() string
+
+	// notes are the notes of the Merge Request as JSON, defaults to none
+	notes string
+}
+
+func newTestGitLab(t *testing.T, api *testGitLab) (context.Context, *gitlab.Client) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		request := r.Method + " " + r.URL.EscapedPath()
+		if len(r.URL.RawQuery) > 0 {
+			request += "?" + r.URL.RawQuery
+		}
+
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			request += " " + strings.TrimSpace(string(body))
+		}
+
+		api.requests = append(api.requests, request)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == testConfigFilePath:
+			w.Write([]byte(api.config(r.URL.Query().Get("ref"))))
+
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == testMergeRequestPath:
+			w.Write([]byte(`{"iid": 1, "sha": "` + api.headSHA() + `"}`))
+
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/labels"):
+			w.Write([]byte(`[]`))
+
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/notes"):
+			w.Write([]byte(cmp.Or(api.notes, `[]`)))
+
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/version":
+			w.Write([]byte(`{"version": "17.0.0"}`))
+
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+	ctx = state.WithDryRun(ctx, false)
+	ctx = state.WithUpdatePipeline(ctx, false, "")
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	return ctx, client
+}
+
+// Requests returns the requests made to the fake GitLab API, with the given method
+func (api *testGitLab) Requests(method string) []string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	var requests []string
+
+	for _, request := range api.requests {
+		if strings.HasPrefix(request, method+" ") {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+func TestProcessMR_HeadChangedDuringEvaluation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the configuration file is read again at the new head commit", func(t *testing.T) {
+		t.Parallel()
+
+		api := &testGitLab{
+			config: func(ref string) string {
+				if ref == "abc123" {
+					return "label:\n  - name: stale\n    script: \"true\"\n"
+				}
+
+				return "label:\n  - name: fresh\n    script: \"true\"\n"
+			},
+			// A new commit was pushed after the evaluation was pinned to 'abc123'
+			headSHA: func() string { return "def456" },
+		}
+
+		ctx, gitlabClient := newTestGitLab(t, api)
+		client := &testClient{Client: gitlabClient, evalContext: &testEvalContext{}}
+
+		require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+		require.Equal(t, []string{
+			"GET " + testConfigFilePath + "?ref=abc123",
+			"GET " + testConfigFilePath + "?ref=def456",
+		}, configRequests(api))
+
+		// Only the outcome of the evaluation of the new head commit is applied
+		updates := mergeRequestUpdates(api)
+		require.Len(t, updates, 1)
+		require.Contains(t, updates[0], `"add_labels":["fresh"]`)
+		require.NotContains(t, updates[0], "stale")
+
+		for _, request := range api.Requests("POST") {
+			require.NotContains(t, request, "stale")
+		}
+	})
+
+	t.Run("the evaluation fails if the head commit keeps changing", func(t *testing.T) {
+		t.Parallel()
+
+		var pushes atomic.Int32
+
+		api := &testGitLab{
+			config:  func(string) string { return "label:\n  - name: stale\n    script: \"true\"\n" },
+			headSHA: func() string { return fmt.Sprintf("sha%d", pushes.Add(1)) },
+		}
+
+		ctx, gitlabClient := newTestGitLab(t, api)
+		client := &testClient{Client: gitlabClient, evalContext: &testEvalContext{}}
+
+		err := cmd.ProcessMR(ctx, client, nil, nil)
+
+		var headChanged *scm.HeadChangedError
+		require.ErrorAs(t, err, &headChanged)
+
+		// The first evaluation and 3 retries, none of which are applied
+		require.Len(t, configRequests(api), 4)
+		require.Empty(t, mergeRequestUpdates(api))
+	})
+}
+
+// configRequests returns the reads of the configuration file made to the fake GitLab API
+func configRequests(api *testGitLab) []string {
+	var requests []string
+
+	for _, request := range api.Requests("GET") {
+		if strings.HasPrefix(request, "GET "+testConfigFilePath) {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+// mergeRequestUpdates returns the updates of the Merge Request made to the fake GitLab API
+func mergeRequestUpdates(api *testGitLab) []string {
+	var requests []string
+
+	for _, request := range api.Requests("PUT") {
+		if strings.HasPrefix(request, "PUT "+testMergeRequestPath+" ") {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+func TestProcessMR_SingleLabelUpdate(t *testing.T) {
+	t.Parallel()
+
+	api := &testGitLab{
+		config: func(string) string {
+			return `
+label:
+  - name: bug
+    script: "true"
+  - name: needs-review
+    script: "true"
+  - name: keep
+    script: "true"
+  - name: stale
+    script: "false"
+  - name: wip
+    script: "false"
+
+actions:
+  - name: triage
+    if: "true"
+    then:
+      - action: add_label
+        name: triaged
+`
+		},
+		headSHA: func() string { return "abc123" },
+	}
+
+	ctx, gitlabClient := newTestGitLab(t, api)
+	client := &testClient{Client: gitlabClient, evalContext: &testEvalContext{Labels: []string{"keep", "stale", "unrelated"}}}
+
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+	// All matching rules and actions are applied in one request, only carrying the changes to the current labels
+	updates := mergeRequestUpdates(api)
+	require.Len(t, updates, 1)
+
+	_, body, _ := strings.Cut(updates[0], " {")
+	require.JSONEq(t, `{"add_labels": ["bug", "needs-review", "triaged"], "remove_labels": ["stale"]}`, "{"+body)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rpprof "runtime/pprof"
+	"time"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// StartProfiling starts writing a CPU profile to [cpuPath] (if not empty), and returns a function
+// that stops it and writes a heap profile to [memPath] (if not empty).
+//
+// Nothing is started when both paths are empty, so profiling has no overhead unless asked for
+func StartProfiling(ctx context.Context, cpuPath, memPath string) (func() error, error) {
+	var cpuFile *os.File
+
+	if len(cpuPath) > 0 {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not create CPU profile: %w", err)
+		}
+
+		if err := rpprof.StartCPUProfile(file); err != nil {
+			file.Close()
+
+			return nil, fmt.Errorf("could not start CPU profile: %w", err)
+		}
+
+		slogctx.Info(ctx, "Writing CPU profile", slog.String("path", cpuPath))
+
+		cpuFile = file
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			rpprof.StopCPUProfile()
+
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("could not write CPU profile: %w", err)
+			}
+		}
+
+		if len(memPath) == 0 {
+			return nil
+		}
+
+		file, err := os.Create(memPath)
+		if err != nil {
+			return fmt.Errorf("could not create heap profile: %w", err)
+		}
+		defer file.Close()
+
+		// Get up-to-date statistics about the allocations
+		runtime.GC()
+
+		if err := rpprof.WriteHeapProfile(file); err != nil {
+			return fmt.Errorf("could not write heap profile: %w", err)
+		}
+
+		slogctx.Info(ctx, "Wrote heap profile", slog.String("path", memPath))
+
+		return nil
+	}, nil
+}
+
+// NewPprofServer returns a HTTP server exposing the pprof endpoints under '/debug/pprof/' on [listenAddr].
+//
+// The profiles show internals of the running server, so only loopback addresses are allowed
+func NewPprofServer(listenAddr string) (*http.Server, error) {
+	host, _, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", FlagPprofListen, err)
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("invalid --%s: %q is not a loopback address (example: 'localhost:6060')", FlagPprofListen, listenAddr)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}, nil
+}
+
+// startPprofServer serves the pprof endpoints in the background until [server] is shut down
+func startPprofServer(ctx context.Context, server *http.Server) {
+	slogctx.Info(ctx, "Starting pprof HTTP server", slog.String("listen_address", server.Addr))
+
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			slogctx.Error(ctx, "pprof HTTP server error", slog.Any("error", err))
+		}
+	}()
+}
@@ -0,0 +1,58 @@
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartProfiling(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cpuPath := filepath.Join(dir, "cpu.pprof")
+	memPath := filepath.Join(dir, "mem.pprof")
+
+	stop, err := cmd.StartProfiling(context.Background(), cpuPath, memPath)
+	require.NoError(t, err)
+	require.NoError(t, stop())
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		require.NoError(t, err, path)
+		require.NotZero(t, info.Size(), path)
+	}
+}
+
+func TestNewPprofServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{addr: "localhost:6060"},
+		{addr: "127.0.0.1:6060"},
+		{addr: "[::1]:6060"},
+		{addr: ":6060", wantErr: true},
+		{addr: "0.0.0.0:6060", wantErr: true},
+		{addr: "10.0.0.1:6060", wantErr: true},
+		{addr: "localhost", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		server, err := cmd.NewPprofServer(tt.addr)
+		if tt.wantErr {
+			require.Error(t, err, tt.addr)
+
+			continue
+		}
+
+		require.NoError(t, err, tt.addr)
+		require.Equal(t, tt.addr, server.Addr)
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Formats of the reconcile report, picked by the file extension of --reconcile-report
+const (
+	ReconcileFormatCSV  = "csv"
+	ReconcileFormatJSON = "json"
+)
+
+// Modes of a [ReconcileRow]
+const (
+	// ReconcileModePlan is the label changes that would have been applied (see --dry-run)
+	ReconcileModePlan = "plan"
+	// ReconcileModeResult is the label changes that were applied
+	ReconcileModeResult = "result"
+)
+
+// ReconcileRow is the label changes of a single Merge Request in the reconcile report
+type ReconcileRow struct {
+	// Full path of the project (example: 'gitlab-org/gitlab')
+	Project string `json:"project"`
+
+	// The Merge Request ID (IID) within the project
+	MergeRequestID string `json:"merge_request_id"`
+
+	// Either 'plan' (dry run) or 'result'
+	Mode string `json:"mode"`
+
+	// Labels added to the Merge Request
+	Added []string `json:"added"`
+
+	// Labels removed from the Merge Request
+	Removed []string `json:"removed"`
+
+	// Labels on the Merge Request after the evaluation
+	Labels []string `json:"labels"`
+
+	// The evaluation error, empty if the evaluation succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// ReconcileFormat returns the format of the reconcile report at [path], based on its file extension
+func ReconcileFormat(path string) (string, error) {
+	switch format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."); format {
+	case ReconcileFormatCSV, ReconcileFormatJSON:
+		return format, nil
+
+	default:
+		return "", fmt.Errorf("--%s must end with either '.%s' or '.%s', got %q", FlagReconcileReport, ReconcileFormatCSV, ReconcileFormatJSON, path)
+	}
+}
+
+// ReconcileRows returns the label changes of every evaluated Merge Request, sorted by project and Merge Request ID.
+//
+// Merge Requests without label changes are included too, so the report covers every evaluated Merge Request
+func (r *EvaluationReport) ReconcileRows() []ReconcileRow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows := make([]ReconcileRow, 0, len(r.MergeRequests))
+
+	for _, mergeRequest := range r.MergeRequests {
+		mode := ReconcileModeResult
+		if mergeRequest.DryRun {
+			mode = ReconcileModePlan
+		}
+
+		rows = append(rows, ReconcileRow{
+			Project:        mergeRequest.Project,
+			MergeRequestID: mergeRequest.MergeRequestID,
+			Mode:           mode,
+			Added:          emptyIfNil(mergeRequest.Labels.Add),
+			Removed:        emptyIfNil(mergeRequest.Labels.Remove),
+			Labels:         emptyIfNil(mergeRequest.Labels.Current),
+			Error:          mergeRequest.Error,
+		})
+	}
+
+	slices.SortStableFunc(rows, func(a, b ReconcileRow) int {
+		return cmp.Or(cmp.Compare(a.Project, b.Project), cmp.Compare(a.MergeRequestID, b.MergeRequestID))
+	})
+
+	return rows
+}
+
+// WriteReconcile writes the reconcile report in the [format] ('csv' or 'json')
+func (r *EvaluationReport) WriteReconcile(w io.Writer, format string) error {
+	rows := r.ReconcileRows()
+
+	switch format {
+	case ReconcileFormatJSON:
+		out, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, string(out))
+
+		return err
+
+	case ReconcileFormatCSV:
+		writer := csv.NewWriter(w)
+
+		if err := writer.Write([]string{"project", "merge_request_id", "mode", "added", "removed", "labels", "error"}); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			record := []string{
+				row.Project,
+				row.MergeRequestID,
+				row.Mode,
+				strings.Join(row.Added, ", "),
+				strings.Join(row.Removed, ", "),
+				strings.Join(row.Labels, ", "),
+				row.Error,
+			}
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+
+		return writer.Error()
+
+	default:
+		return fmt.Errorf("unknown reconcile report format %q", format)
+	}
+}
+
+// emptyIfNil returns an empty list for a nil [labels], so the JSON report always has lists
+func emptyIfNil(labels []string) []string {
+	if labels == nil {
+		return []string{}
+	}
+
+	return labels
+}
+
+// writeReconcileReport writes the reconcile report to the file at [path], in the format of its file extension
+func writeReconcileReport(report *EvaluationReport, path string) error {
+	format, err := ReconcileFormat(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create the reconcile report: %w", err)
+	}
+	defer file.Close()
+
+	if err := report.WriteReconcile(file, format); err != nil {
+		return fmt.Errorf("could not write the reconcile report: %w", err)
+	}
+
+	return file.Close()
+}
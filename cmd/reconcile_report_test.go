@@ -0,0 +1,77 @@
+package cmd_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluationReport_WriteReconcile(t *testing.T) {
+	t.Parallel()
+
+	report := &cmd.EvaluationReport{
+		MergeRequests: []*cmd.MergeRequestReport{
+			{
+				Project:        "group/b",
+				MergeRequestID: "7",
+				Labels:         cmd.LabelReport{Add: []string{}, Remove: []string{}, Current: []string{"bug"}},
+				Error:          "boom",
+			},
+			{
+				Project:        "group/a",
+				MergeRequestID: "2",
+				DryRun:         true,
+				Labels:         cmd.LabelReport{Add: []string{"type::bug"}, Remove: []string{"bug"}, Current: []string{"priority, high", "type::bug"}},
+			},
+			{
+				Project:        "group/a",
+				MergeRequestID: "1",
+				DryRun:         true,
+				Labels:         cmd.LabelReport{Add: []string{"size/S", "type::bug"}, Remove: []string{}, Current: []string{"size/S", "type::bug"}},
+			},
+		},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		require.NoError(t, report.WriteReconcile(&out, cmd.ReconcileFormatCSV))
+
+		require.Equal(t, `project,merge_request_id,mode,added,removed,labels,error
+group/a,1,plan,"size/S, type::bug",,"size/S, type::bug",
+group/a,2,plan,type::bug,bug,"priority, high, type::bug",
+group/b,7,result,,,bug,boom
+`, out.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		require.NoError(t, report.WriteReconcile(&out, cmd.ReconcileFormatJSON))
+
+		require.JSONEq(t, `[
+			{"project": "group/a", "merge_request_id": "1", "mode": "plan", "added": ["size/S", "type::bug"], "removed": [], "labels": ["size/S", "type::bug"]},
+			{"project": "group/a", "merge_request_id": "2", "mode": "plan", "added": ["type::bug"], "removed": ["bug"], "labels": ["priority, high", "type::bug"]},
+			{"project": "group/b", "merge_request_id": "7", "mode": "result", "added": [], "removed": [], "labels": ["bug"], "error": "boom"}
+		]`, out.String())
+	})
+}
+
+func TestReconcileFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := cmd.ReconcileFormat("out/reconcile.CSV")
+	require.NoError(t, err)
+	require.Equal(t, cmd.ReconcileFormatCSV, format)
+
+	format, err = cmd.ReconcileFormat("reconcile.json")
+	require.NoError(t, err)
+	require.Equal(t, cmd.ReconcileFormatJSON, format)
+
+	_, err = cmd.ReconcileFormat("reconcile.xlsx")
+	require.ErrorContains(t, err, "--reconcile-report must end with either '.csv' or '.json'")
+}
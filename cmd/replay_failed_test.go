@@ -0,0 +1,114 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+const failingActionConfig = `
+actions:
+  - name: notify
+    if: 'true'
+    then:
+      - action: comment
+        message: hello
+`
+
+func TestProcessMR_FailedActionsRecord(t *testing.T) {
+	t.Parallel()
+
+	failing := func(context.Context, scm.ActionStep) error { return errors.New("the webhook timed out") }
+	succeeding := func(context.Context, scm.ActionStep) error { return nil }
+
+	recorded := scm.FormatFailedActions(&scm.FailedActions{CommitSHA: "abc123", Actions: []string{"notify"}})
+
+	notes, err := json.Marshal([]map[string]any{{"id": 7, "body": recorded}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		replay    bool
+		notes     string
+		applyStep func(context.Context, scm.ActionStep) error
+		wantErr   bool
+		wantNotes []string
+	}{
+		{
+			name:      "no failures, the record isn't read",
+			applyStep: succeeding,
+		},
+		{
+			name:      "failures are recorded in an internal note",
+			applyStep: failing,
+			wantErr:   true,
+			wantNotes: []string{"GET", "POST"},
+		},
+		{
+			name:      "replaying without failures clears the record",
+			replay:    true,
+			notes:     string(notes),
+			applyStep: succeeding,
+			wantNotes: []string{"GET", "GET", "PUT"},
+		},
+		{
+			name:      "replaying without a record doesn't write one",
+			replay:    true,
+			applyStep: succeeding,
+			wantNotes: []string{"GET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &testGitLab{
+				config:  func(string) string { return failingActionConfig },
+				headSHA: func() string { return "abc123" },
+				notes:   tt.notes,
+			}
+
+			ctx, gitlabClient := newTestGitLab(t, api)
+			ctx = state.WithReplayFailedActions(ctx, tt.replay)
+
+			client := &testClient{Client: gitlabClient, evalContext: &testEvalContext{}, applyStep: tt.applyStep}
+
+			err := cmd.ProcessMR(ctx, client, nil, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var methods []string
+
+			for _, method := range []string{"GET", "POST", "PUT"} {
+				for _, request := range api.Requests(method) {
+					if containsNotesPath(request) {
+						methods = append(methods, method)
+					}
+				}
+			}
+
+			require.Equal(t, tt.wantNotes, methods)
+
+			if posts := api.Requests("POST"); tt.wantErr {
+				require.Len(t, posts, 1)
+				require.Contains(t, posts[0], `"internal":true`)
+				require.Contains(t, posts[0], "notify")
+			}
+		})
+	}
+}
+
+func containsNotesPath(request string) bool {
+	return strings.Contains(request, testMergeRequestPath+"/notes")
+}
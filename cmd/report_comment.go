@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// postReportComment creates (or updates) the report comment summarizing the outcome of the evaluation, see [config.ReportComment]
+func postReportComment(ctx context.Context, client scm.Client, settings *config.ReportComment, report *MergeRequestReport, comments []string, evalErr error) {
+	if report == nil || state.IsReadOnly(ctx) {
+		return
+	}
+
+	body := report.Markdown(comments, evalErr)
+
+	if settings.InDescription() {
+		if state.IsDryRun(ctx) {
+			slogctx.Info(ctx, "(Dry Run) Updating report in the description", slog.String("body", body))
+
+			return
+		}
+
+		slogctx.Info(ctx, "Updating report in the description")
+
+		if err := client.MergeRequests().UpsertDescriptionBlock(ctx, body); err != nil {
+			slogctx.Error(ctx, "Failed to update report in the description", slog.Any("error", err))
+		}
+
+		return
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Updating report comment", slog.String("body", body))
+
+		return
+	}
+
+	slogctx.Info(ctx, "Updating report comment")
+
+	if err := client.MergeRequests().UpsertNote(ctx, config.ReportCommentMarker, body); err != nil {
+		slogctx.Error(ctx, "Failed to update report comment", slog.Any("error", err))
+	}
+}
+
+// Markdown renders the report comment, with a section for the label changes, the action outcomes,
+// the collected [comments] (see [scm.CommentCollector]) and the evaluation error (if any)
+func (r *MergeRequestReport) Markdown(comments []string, evalErr error) string {
+	var body strings.Builder
+
+	body.WriteString(config.ReportCommentMarker + "\n")
+	body.WriteString("### scm-engine report\n\n")
+	fmt.Fprintf(&body, "Evaluated commit `%s`.\n", r.CommitSHA)
+
+	body.WriteString("\n#### Labels\n\n")
+
+	switch {
+	case len(r.Labels.Add) == 0 && len(r.Labels.Remove) == 0:
+		body.WriteString("_No label changes._\n")
+
+	default:
+		if len(r.Labels.Add) > 0 {
+			body.WriteString("- Added: " + quoteLabels(r.Labels.Add) + "\n")
+		}
+
+		if len(r.Labels.Remove) > 0 {
+			body.WriteString("- Removed: " + quoteLabels(r.Labels.Remove) + "\n")
+		}
+	}
+
+	body.WriteString("\n#### Actions\n\n")
+
+	if len(r.Actions) == 0 {
+		body.WriteString("_No actions applied._\n")
+	} else {
+		body.WriteString("| Action | Outcome | Reason |\n")
+		body.WriteString("|--------|---------|--------|\n")
+
+		for _, action := range r.Actions {
+			fmt.Fprintf(&body, "| %s | %s | %s |\n", tableCell(action.Name), action.Outcome, tableCell(action.Reason))
+		}
+	}
+
+	if len(comments) > 0 {
+		body.WriteString("\n#### Comments\n\n")
+		body.WriteString(strings.Join(comments, "\n\n---\n\n") + "\n")
+	}
+
+	if evalErr != nil {
+		body.WriteString("\n#### Errors\n\n")
+		body.WriteString("```text\n" + evalErr.Error() + "\n```\n")
+	}
+
+	return body.String()
+}
+
+func quoteLabels(labels []string) string {
+	quoted := make([]string, 0, len(labels))
+
+	for _, label := range labels {
+		quoted = append(quoted, fmt.Sprintf("~%q", label))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// tableCell escapes [value] for use in a markdown table cell
+func tableCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(value)
+}
@@ -0,0 +1,221 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRequestReport_Markdown(t *testing.T) {
+	t.Parallel()
+
+	report := &cmd.MergeRequestReport{
+		CommitSHA: "abc123",
+		Labels:    cmd.LabelReport{Add: []string{"bug"}, Remove: []string{"needs-review"}},
+		Actions: []cmd.ActionReport{
+			{Name: "close stale", Outcome: cmd.ActionOutcomeApplied},
+			{Name: "merge", Outcome: cmd.ActionOutcomeSkipped, Reason: "pipeline | failed"},
+		},
+	}
+
+	require.Equal(t, config.ReportCommentMarker+`
+### scm-engine report
+
+Evaluated commit `+"`abc123`"+`.
+
+#### Labels
+
+- Added: ~"bug"
+- Removed: ~"needs-review"
+
+#### Actions
+
+| Action | Outcome | Reason |
+|--------|---------|--------|
+| close stale | applied |  |
+| merge | skipped | pipeline \| failed |
+
+#### Comments
+
+Please add a changelog entry
+
+---
+
+Thanks!
+
+#### Errors
+
+`+"```text\nboom\n```\n", report.Markdown([]string{"Please add a changelog entry", "Thanks!"}, errors.New("boom")))
+
+	empty := &cmd.MergeRequestReport{CommitSHA: "abc123"}
+
+	require.Contains(t, empty.Markdown(nil, nil), "_No label changes._")
+	require.Contains(t, empty.Markdown(nil, nil), "_No actions applied._")
+	require.NotContains(t, empty.Markdown(nil, nil), "#### Comments")
+	require.NotContains(t, empty.Markdown(nil, nil), "#### Errors")
+}
+
+func TestReportComment_UpdatedInPlace(t *testing.T) {
+	t.Parallel()
+
+	const notesPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1/notes"
+
+	var (
+		mu    sync.Mutex
+		notes []map[string]any
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		note := map[string]any{}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == notesPath:
+			out, _ := json.Marshal(notes)
+			w.Write(out)
+
+			return
+
+		case r.Method == http.MethodPost && r.URL.Path == notesPath:
+			require.NoError(t, json.Unmarshal(body, &note))
+
+			note["id"] = len(notes) + 1
+			notes = append(notes, note)
+
+		case r.Method == http.MethodPut && r.URL.Path == notesPath+"/1":
+			require.NoError(t, json.Unmarshal(body, &note))
+
+			note["id"] = 1
+			notes[0] = note
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		out, _ := json.Marshal(note)
+		w.Write(out)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+	ctx = state.WithDryRun(ctx, false)
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	// Collected 'comment' steps are not posted as separate comments
+	ctx, comments := scm.WithCommentCollector(ctx)
+
+	step := config.ActionStep{"action": "comment", "message": "Please add a changelog entry"}
+	require.NoError(t, client.ApplyStep(ctx, nil, &scm.UpdateMergeRequestOptions{}, step))
+	require.Equal(t, []string{"Please add a changelog entry"}, comments.Messages())
+	require.Empty(t, notes)
+
+	// The first evaluation creates the report comment
+	first := &cmd.MergeRequestReport{CommitSHA: "abc123", Labels: cmd.LabelReport{Add: []string{"bug"}}}
+	require.NoError(t, client.MergeRequests().UpsertNote(ctx, config.ReportCommentMarker, first.Markdown(comments.Messages(), nil)))
+	require.Len(t, notes, 1)
+
+	// The next evaluation updates it, rather than posting another comment
+	second := &cmd.MergeRequestReport{CommitSHA: "def456"}
+	require.NoError(t, client.MergeRequests().UpsertNote(ctx, config.ReportCommentMarker, second.Markdown(nil, nil)))
+	require.Len(t, notes, 1)
+	require.Contains(t, notes[0]["body"], "Evaluated commit `def456`.")
+	require.NotContains(t, notes[0]["body"], "Please add a changelog entry")
+}
+
+func TestReportComment_InDescription(t *testing.T) {
+	t.Parallel()
+
+	const mergeRequestPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1"
+
+	var (
+		mu          sync.Mutex
+		description = "## Summary\n\nFixes the thing.\n"
+		updates     int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == mergeRequestPath:
+		case r.Method == http.MethodPut && r.URL.Path == mergeRequestPath:
+			var body struct {
+				Description string `json:"description"`
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+			description = body.Description
+			updates++
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		out, _ := json.Marshal(map[string]any{"iid": 1, "description": description})
+		w.Write(out)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithDryRun(ctx, false)
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	// The first evaluation puts the report at the top of the description
+	first := &cmd.MergeRequestReport{CommitSHA: "abc123"}
+	require.NoError(t, client.MergeRequests().UpsertDescriptionBlock(ctx, first.Markdown(nil, nil)))
+	require.Equal(t, 1, updates)
+	require.Contains(t, description, "Evaluated commit `abc123`.")
+	require.True(t, strings.HasPrefix(description, scm.DescriptionBlockStartMarker))
+	require.True(t, strings.HasSuffix(description, "\n\n## Summary\n\nFixes the thing.\n"))
+
+	// The author edits their part of the description
+	description += "\nAlso fixes the other thing.\n"
+
+	// The next evaluation updates the report, keeping the author's changes
+	second := &cmd.MergeRequestReport{CommitSHA: "def456"}
+	require.NoError(t, client.MergeRequests().UpsertDescriptionBlock(ctx, second.Markdown(nil, nil)))
+	require.Equal(t, 2, updates)
+	require.Contains(t, description, "Evaluated commit `def456`.")
+	require.NotContains(t, description, "Evaluated commit `abc123`.")
+	require.True(t, strings.HasSuffix(description, "\n\n## Summary\n\nFixes the thing.\n\nAlso fixes the other thing.\n"))
+
+	// Nothing changed, so the description isn't updated
+	require.NoError(t, client.MergeRequests().UpsertDescriptionBlock(ctx, second.Markdown(nil, nil)))
+	require.Equal(t, 2, updates)
+}
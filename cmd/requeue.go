@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type requeuerKey struct{}
+
+// requeuer re-evaluates Merge Requests with deferred actions (see [scm.DeferredError]) after a delay,
+// keeping track of how many times each Merge Request commit has been deferred
+type requeuer struct {
+	mu        sync.Mutex
+	deferrals map[string]int
+	pending   map[*time.Timer]context.Context
+	running   sync.WaitGroup
+	stopped   bool
+}
+
+// WithRequeuer enables re-queuing deferred evaluations, which is only possible in long-running (server) mode
+func WithRequeuer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requeuerKey{}, &requeuer{deferrals: map[string]int{}, pending: map[*time.Timer]context.Context{}})
+}
+
+func requeuerFromContext(ctx context.Context) *requeuer {
+	r, _ := ctx.Value(requeuerKey{}).(*requeuer)
+
+	return r
+}
+
+func deferralKey(ctx context.Context) string {
+	return state.ProjectID(ctx) + "!" + state.MergeRequestID(ctx) + "@" + state.CommitSHA(ctx)
+}
+
+// requeue schedules a new evaluation of the Merge Request after [scm.DeferredError.Delay],
+// or returns an error if re-queuing isn't possible or the Merge Request was deferred too many times
+func requeue(ctx context.Context, client scm.Client, event any, deferred *scm.DeferredError) error {
+	r := requeuerFromContext(ctx)
+	if r == nil {
+		return fmt.Errorf("%w; re-queuing is only supported in server mode, use 'wait_for_pipeline: poll' instead", deferred)
+	}
+
+	key := deferralKey(ctx)
+
+	r.mu.Lock()
+	r.deferrals[key]++
+	count := r.deferrals[key]
+
+	if count > deferred.MaxDeferrals {
+		delete(r.deferrals, key)
+	}
+	r.mu.Unlock()
+
+	if count > deferred.MaxDeferrals {
+		return fmt.Errorf("gave up after deferring %d times: %w", deferred.MaxDeferrals, deferred)
+	}
+
+	slogctx.Info(ctx, "Re-queuing Merge Request evaluation", slog.String("reason", deferred.Reason), slog.Duration("delay", deferred.Delay), slog.Int("deferral", count), slog.Int("max_deferrals", deferred.MaxDeferrals))
+
+	// The original context (e.g. the webhook request) is likely done by the time we run again
+	ctx = context.WithoutCancel(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		slogctx.Warn(ctx, "Shutting down, dropping the re-queued Merge Request evaluation", slog.String("reason", deferred.Reason))
+
+		return nil
+	}
+
+	var timer *time.Timer
+
+	timer = time.AfterFunc(deferred.Delay, func() {
+		r.mu.Lock()
+
+		// Dropped by [requeuer.stop]
+		if _, ok := r.pending[timer]; !ok {
+			r.mu.Unlock()
+
+			return
+		}
+
+		delete(r.pending, timer)
+		r.running.Add(1)
+		r.mu.Unlock()
+
+		defer r.running.Done()
+		defer recoverEvaluation(ctx)
+
+		if err := ProcessMR(ctx, client, nil, event); err != nil {
+			slogctx.Error(ctx, "Re-queued Merge Request evaluation failed", slog.Any("error", err))
+		}
+	})
+
+	r.pending[timer] = ctx
+
+	return nil
+}
+
+// stop drops the pending re-queued evaluations, logging every dropped Merge Request, and waits for the running
+// ones to complete. Deferred actions are waiting for something (like a pipeline) to finish, so evaluating them
+// right away would only defer them again
+func (r *requeuer) stop() {
+	r.mu.Lock()
+
+	r.stopped = true
+
+	for timer, ctx := range r.pending {
+		timer.Stop()
+		delete(r.pending, timer)
+
+		slogctx.Warn(ctx, "Shutting down, dropping the re-queued Merge Request evaluation")
+	}
+
+	r.mu.Unlock()
+
+	r.running.Wait()
+}
+
+// forgetDeferrals resets the deferral count for the Merge Request commit once its evaluation completed
+func forgetDeferrals(ctx context.Context) {
+	r := requeuerFromContext(ctx)
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.deferrals, deferralKey(ctx))
+}
@@ -0,0 +1,157 @@
+package cmd_test
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+const deferredActionConfig = `
+actions:
+  - name: merge when green
+    if: 'true'
+    then:
+      - action: merge
+        wait_for_pipeline: requeue
+`
+
+func TestProcessMR_Requeue(t *testing.T) {
+	t.Parallel()
+
+	api := &testGitLab{
+		config:  func(string) string { return deferredActionConfig },
+		headSHA: func() string { return "abc123" },
+	}
+
+	ctx, gitlabClient := newTestGitLab(t, api)
+	ctx = cmd.WithRequeuer(ctx)
+
+	var attempts atomic.Int32
+
+	// The pipeline never finishes, so the action is deferred on every evaluation
+	client := &testClient{
+		Client:      gitlabClient,
+		evalContext: &testEvalContext{},
+		applyStep: func(ctx context.Context, step scm.ActionStep) error {
+			attempts.Add(1)
+
+			return &scm.DeferredError{Reason: "the head pipeline has not finished yet", Delay: time.Millisecond, MaxDeferrals: 2}
+		},
+	}
+
+	// Deferring re-queues the evaluation rather than failing it
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+	// The first evaluation and the 2 re-queued evaluations, after which the requeuer gives up
+	require.Eventually(t, func() bool { return attempts.Load() == 3 }, time.Second, time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(3), attempts.Load())
+
+	// The deferral count was reset after giving up, so the next evaluation is deferred again
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+	require.Eventually(t, func() bool { return attempts.Load() == 6 }, time.Second, time.Millisecond)
+}
+
+func TestProcessMR_RequeueWithoutServer(t *testing.T) {
+	t.Parallel()
+
+	api := &testGitLab{
+		config:  func(string) string { return deferredActionConfig },
+		headSHA: func() string { return "abc123" },
+	}
+
+	ctx, gitlabClient := newTestGitLab(t, api)
+
+	client := &testClient{
+		Client:      gitlabClient,
+		evalContext: &testEvalContext{},
+		applyStep: func(ctx context.Context, step scm.ActionStep) error {
+			return &scm.DeferredError{Reason: "the head pipeline has not finished yet", Delay: time.Millisecond, MaxDeferrals: 2}
+		},
+	}
+
+	err := cmd.ProcessMR(ctx, client, nil, nil)
+	require.ErrorContains(t, err, "re-queuing is only supported in server mode")
+}
+
+func TestProcessMR_RequeueDroppedOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	api := &testGitLab{
+		config:  func(string) string { return deferredActionConfig },
+		headSHA: func() string { return "abc123" },
+	}
+
+	ctx, gitlabClient := newTestGitLab(t, api)
+	ctx = cmd.WithRequeuer(ctx)
+
+	var attempts atomic.Int32
+
+	client := &testClient{
+		Client:      gitlabClient,
+		evalContext: &testEvalContext{},
+		applyStep: func(ctx context.Context, step scm.ActionStep) error {
+			attempts.Add(1)
+
+			return &scm.DeferredError{Reason: "the head pipeline has not finished yet", Delay: 50 * time.Millisecond, MaxDeferrals: 5}
+		},
+	}
+
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+	// The re-queued evaluation is dropped rather than run
+	cmd.DrainScheduledEvaluations(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), attempts.Load())
+
+	// ... and evaluations deferred after the shutdown started are not re-queued either
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestProcessMR_RequeuePanic(t *testing.T) {
+	t.Parallel()
+
+	api := &testGitLab{
+		config:  func(string) string { return deferredActionConfig },
+		headSHA: func() string { return "abc123" },
+	}
+
+	ctx, gitlabClient := newTestGitLab(t, api)
+	ctx = cmd.WithRequeuer(ctx)
+
+	var attempts atomic.Int32
+
+	// The first evaluation is deferred, and the re-queued evaluation panics
+	client := &testClient{
+		Client:      gitlabClient,
+		evalContext: &testEvalContext{},
+		applyStep: func(ctx context.Context, step scm.ActionStep) error {
+			if attempts.Add(1) > 1 {
+				panic("boom")
+			}
+
+			return &scm.DeferredError{Reason: "the head pipeline has not finished yet", Delay: time.Millisecond, MaxDeferrals: 2}
+		},
+	}
+
+	panics := expvar.Get("webhook_panics").(*expvar.Int).Value() //nolint:forcetypeassert
+
+	require.NoError(t, cmd.ProcessMR(ctx, client, nil, nil))
+
+	// The panic is recovered and counted, rather than taking down the server
+	require.Eventually(t, func() bool { return expvar.Get("webhook_panics").(*expvar.Int).Value() > panics }, time.Second, time.Millisecond) //nolint:forcetypeassert
+
+	cmd.DrainScheduledEvaluations(ctx)
+	require.Equal(t, int32(2), attempts.Load())
+}
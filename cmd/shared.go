@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/jippi/scm-engine/pkg/config"
@@ -13,12 +14,16 @@ import (
 	"github.com/jippi/scm-engine/pkg/scm/github"
 	"github.com/jippi/scm-engine/pkg/scm/gitlab"
 	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
 	"github.com/teris-io/shortid"
 	slogctx "github.com/veqryn/slog-context"
 )
 
 var sid = shortid.MustNew(1, shortid.DefaultABC, 2342)
 
+// How many times an evaluation is retried when the Merge Request head commit changes while processing it
+const maxHeadChangedRetries = 3
+
 func getClient(ctx context.Context) (scm.Client, error) {
 	switch state.Provider(ctx) {
 	case "github":
@@ -32,26 +37,121 @@ func getClient(ctx context.Context) (scm.Client, error) {
 	}
 }
 
-func ProcessMR(ctx context.Context, client scm.Client, cfg *config.Config, event any) (err error) {
+// ProcessMR evaluates the Merge Request, pinned to the commit in [state.CommitSHA].
+//
+// If a new commit is pushed while the evaluation is running, the (now stale) outcome is discarded
+// and the evaluation is retried against the new head commit.
+func ProcessMR(ctx context.Context, client scm.Client, cfg *config.Config, event any) error {
+	// Pin the evaluation to the current head commit if the caller didn't provide one,
+	// so the configuration file and the evaluation use the same commit
+	if _, ok := state.CommitSHAOk(ctx); !ok {
+		sha, err := client.MergeRequests().HeadSHA(ctx)
+		if err != nil {
+			return err
+		}
+
+		ctx = state.WithCommitSHA(ctx, sha)
+	}
+
+	// Record the outcome for 'evaluate --output json', the audit webhook and log, the post-evaluation hooks and the report comment
+	ctx, report := withMergeRequestReport(ctx)
+
+	// The outcome of the last evaluation attempt
+	var err error
+
+	startedAt := time.Now().UTC()
+
+	if audit := auditWebhookFromContext(ctx); audit != nil {
+		defer func() {
+			audit.Send(ctx, newAuditRecord(ctx, report, startedAt, err))
+		}()
+	}
+
+	if log := auditLogFromContext(ctx); log != nil {
+		defer func() {
+			if err := log.Write(newAuditRecord(ctx, report, startedAt, err)); err != nil {
+				slogctx.Error(ctx, "Could not record the evaluation in the audit log", slog.Any("error", err))
+			}
+		}()
+	}
+
+	if hasPostEvaluationHooks(ctx) {
+		defer func() {
+			runPostEvaluationHooksInBackground(ctx, newAuditRecord(ctx, report, startedAt, err))
+		}()
+	}
+
+	for attempt := 1; ; attempt++ {
+		report.reset(ctx)
+
+		err = processMR(ctx, client, cfg, event)
+		report.setError(err)
+
+		// An action asked for the evaluation to be tried again later
+		var deferred *scm.DeferredError
+		if errors.As(err, &deferred) {
+			return requeue(ctx, client, event, deferred)
+		}
+
+		var headChanged *scm.HeadChangedError
+		if !errors.As(err, &headChanged) || attempt > maxHeadChangedRetries {
+			if err == nil {
+				forgetDeferrals(ctx)
+			}
+
+			return err
+		}
+
+		// Retried right away rather than re-queued (see [requeue]): the new commit can be evaluated now,
+		// and re-queuing is only possible in server mode, while a stale outcome must be discarded everywhere
+		slogctx.Warn(ctx, "Merge Request head commit changed during evaluation, retrying", slog.Any("error", err), slog.Int("attempt", attempt))
+
+		// The configuration file must be read again from the new commit
+		ctx = state.WithCommitSHA(ctx, headChanged.Actual)
+		cfg = nil
+	}
+}
+
+func processMR(ctx context.Context, client scm.Client, cfg *config.Config, event any) (err error) {
 	// Track start time of the evaluation
 	ctx = state.WithStartTime(ctx, time.Now())
 
 	// Attach unique eval id to the logs so they are easy to filter on later
 	ctx = state.WithEvaluationID(ctx, sid.MustGenerate())
 
+	// Limit the number of action steps applied by this evaluation
+	ctx = state.WithActionStepCounter(ctx)
+
 	// Track where we grab the configuration file from
 	ctx = slogctx.With(ctx, slog.String("config_source_branch", "merge_request_branch"))
 
 	// Should we allow failing the CI pipeline?
 	allowPipelineFailure := false
 
+	// The 'comment' action step messages collected for the report comment (if enabled)
+	var comments *scm.CommentCollector
+
 	defer state.LockForProcessing(ctx)()
 
 	// Stop the pipeline when we leave this func
 	defer func() {
-		if stopErr := client.Stop(ctx, err, allowPipelineFailure); stopErr != nil {
+		evalErr := err
+
+		// Deferring an action is not an evaluation failure
+		var deferred *scm.DeferredError
+		if errors.As(err, &deferred) {
+			evalErr = nil
+		}
+
+		if stopErr := client.Stop(ctx, evalErr, allowPipelineFailure); stopErr != nil {
 			slogctx.Error(ctx, "Failed to update pipeline", slog.Any("error", stopErr))
 		}
+
+		postErrorComment(ctx, client, evalErr)
+
+		if cfg != nil && cfg.ReportComment.IsEnabled() {
+			postReportComment(ctx, client, cfg.ReportComment, mergeRequestReportFromContext(ctx), comments.Messages(), evalErr)
+		}
 	}()
 
 	// Start the pipeline
@@ -83,49 +183,25 @@ func ProcessMR(ctx context.Context, client scm.Client, cfg *config.Config, event
 	// (Optional) Download the .scm-engine.yml configuration file from the GitLab HTTP API
 	//
 
-	var (
-		configShouldBeDownloaded = cfg == nil
-		configSourceRef          = state.CommitSHA(ctx)
-	)
-
-	// If the current branch is not in a state where the config file can be trusted,
-	// we instead use the HEAD version of the file
-	if !evalContext.CanUseConfigurationFileFromChangeRequest(ctx) {
-		configShouldBeDownloaded = true
-		configSourceRef = "HEAD"
-
-		// Update the logger with new value
-		ctx = slogctx.With(ctx, slog.String("config_source_branch", configSourceRef))
-	}
-
-	// Download and parse the configuration file if necessary
-	if configShouldBeDownloaded {
-		slogctx.Debug(ctx, "Downloading scm-engine configuration from ref: "+configSourceRef)
-
-		file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), configSourceRef)
-		if err != nil {
-			return fmt.Errorf("could not read remote config file: %w", err)
-		}
+	ctx, cfg, err = resolveConfig(ctx, client, evalContext, cfg)
+	if err != nil {
+		// Projects without configuration file are allowed to be ignored, and projects can opt out, so don't report any errors
+		if errors.Is(err, config.ErrMissingConfigIgnored) || errors.Is(err, config.ErrProjectDisabled) {
+			slogctx.Info(ctx, err.Error())
 
-		// Parse the file
-		cfg, err = config.ParseFile(file)
-		if err != nil {
-			return fmt.Errorf("could not parse config file: %w", err)
+			return nil
 		}
-	}
 
-	// Sanity check for having a configuration loaded
-	if cfg == nil {
-		return errors.New("cfg==nil; this is unexpected an error, please report!")
+		return err
 	}
 
-	// Load any remote configuration files
-	if err := cfg.LoadIncludes(ctx, client); err != nil {
-		return fmt.Errorf("failed to load 'include' settings: %w", err)
+	// Let reviewers know the Merge Request changes the configuration file (see [AllowPipelineFailure])
+	if allowPipelineFailure {
+		postConfigChangeNotice(ctx, client)
 	}
 
 	// Allow changing the 'dry-run' mode via configuration file
-	if cfg.DryRun != nil && *cfg.DryRun != state.IsDryRun(ctx) {
+	if cfg.DryRun != nil && *cfg.DryRun != state.IsDryRun(ctx) && !state.IsReadOnly(ctx) {
 		slogctx.Info(ctx, "Configuration file has a 'dry_run' value, using that in favor of server default")
 
 		ctx = state.WithDryRun(ctx, *cfg.DryRun)
@@ -139,6 +215,40 @@ func ProcessMR(ctx context.Context, client scm.Client, cfg *config.Config, event
 	// Write the config to context so we can pull it out later
 	ctx = config.WithConfig(ctx, cfg)
 
+	// Only evaluate the rules that changed since the previous configuration file (see [FlagChangedRulesSince])
+	if previous := previousConfigFromContext(ctx); previous != nil {
+		ctx = withChangedRules(ctx, previous, cfg)
+	}
+
+	// Post the 'comment' action steps as part of the report comment
+	if cfg.ReportComment.IsEnabled() && cfg.ReportComment.CollectComments {
+		ctx, comments = scm.WithCommentCollector(ctx)
+	}
+
+	// Make the business hours available to the 'is_business_hours()' and 'business_days_since()' script functions
+	businessHours, err := cfg.BusinessHours.Parse()
+	if err != nil {
+		return err
+	}
+
+	ctx = stdlib.WithBusinessHours(ctx, businessHours)
+
+	// Make the size buckets available to the 'size_bucket()' script function
+	sizeBuckets, err := cfg.SizeBuckets.Parse()
+	if err != nil {
+		return err
+	}
+
+	ctx = scm.WithSizeBuckets(ctx, sizeBuckets)
+
+	// Make the deploy freezes available to the 'in_deploy_freeze()' script function and the 'block_merges' guard
+	deployFreezes, err := resolveDeployFreezes(ctx, client, cfg.DeployFreeze)
+	if err != nil {
+		return err
+	}
+
+	ctx = stdlib.WithDeployFreezes(ctx, deployFreezes)
+
 	//
 	// Do the actual context evaluation
 	//
@@ -148,13 +258,61 @@ func ProcessMR(ctx context.Context, client scm.Client, cfg *config.Config, event
 	evalContext.SetWebhookEvent(event)
 	evalContext.SetContext(ctx)
 
+	// Record the outcome of each script if the debug comment is enabled
+	var trace *config.Trace
+
+	if cfg.DebugComment || slices.Contains(evalContext.GetLabels(), config.DebugTraceLabel) {
+		ctx, trace = config.WithTrace(ctx)
+	}
+
+	// With 'isolate_section_errors', the sections that evaluated fine are still applied
+	var sectionErrors config.SectionErrors
+
 	labels, actions, err := cfg.Evaluate(ctx, evalContext)
+	if err != nil && !errors.As(err, &sectionErrors) {
+		return err
+	}
+
+	// Render the comment footer after the evaluation, so it can use 'vars'
+	footer, err := cfg.CommentFooter.Render(evalContext)
 	if err != nil {
 		return err
 	}
 
+	ctx = state.WithCommentFooter(ctx, footer)
+
+	// Select the locale of the 'message_key' action steps after the evaluation too, so the 'locales.locale' script can use 'vars'
+	if cfg.Locales != nil {
+		locale, err := cfg.Locales.Select(evalContext)
+		if err != nil {
+			return err
+		}
+
+		ctx = state.WithLocale(ctx, locale)
+	}
+
 	slogctx.Debug(ctx, "Evaluation complete", slog.Int("number_of_labels", len(labels)), slog.Int("number_of_actions", len(actions)))
 
+	// Make sure no new commits were pushed while we were evaluating, as the outcome would be stale
+	headSHA, err := client.MergeRequests().HeadSHA(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := scm.CheckHeadSHA(state.CommitSHA(ctx), headSHA); err != nil {
+		return err
+	}
+
+	// Keep labels that recently stopped matching until their removal grace period is over
+	if err := graceLabelRemovals(ctx, client, evalContext, labels); err != nil {
+		return err
+	}
+
+	// Remove labels that have been on the Merge Request for longer than they are allowed to
+	if err := expireLabels(ctx, client, labels); err != nil {
+		return err
+	}
+
 	//
 	// Post-evaluation sync of labels
 	//
@@ -189,20 +347,237 @@ func ProcessMR(ctx context.Context, client scm.Client, cfg *config.Config, event
 
 	slogctx.Info(ctx, "Applying actions")
 
-	if err := runActions(ctx, evalContext, client, update, actions); err != nil {
+	var previous *scm.FailedActions
+
+	if state.ReplayFailedActions(ctx) {
+		actions, previous, err = replayableActions(ctx, client, actions)
+		if err != nil {
+			return err
+		}
+	}
+
+	deferred, failed, err := runActions(ctx, evalContext, client, update, actions)
+
+	// The record is only read when replaying, so a run without failures only clears the record it replayed
+	if len(failed) > 0 || (previous != nil && len(previous.Actions) > 0) {
+		recordFailedActions(ctx, client, failed)
+	}
+
+	if err != nil {
 		return err
 	}
 
+	if trace != nil {
+		if err := updateDebugComment(ctx, client, trace); err != nil {
+			return err
+		}
+	}
+
 	//
 	// Update the Merge Request with the outcome of labels and actions
 	//
 
 	slogctx.Info(ctx, "Updating Merge Request")
 
-	return updateMergeRequest(ctx, client, update)
+	// Only send the label changes compared to the current labels, in a single request
+	update.DiffLabels(evalContext.GetLabels())
+
+	if cfg.ManagedLabelsOnly != nil && *cfg.ManagedLabelsOnly {
+		if dropped := update.RestrictLabels(managedLabels(labels)); len(dropped) > 0 {
+			slogctx.Info(ctx, "Not changing labels that are not managed by scm-engine", slog.Any("labels", dropped))
+		}
+	}
+
+	mergeRequestReportFromContext(ctx).setLabels(ctx, update, evalContext.GetLabels())
+
+	if err := updateMergeRequest(ctx, client, update); err != nil {
+		return err
+	}
+
+	// Return the deferral (if any) after all other changes were applied, so the evaluation gets re-queued
+	if deferred != nil {
+		return deferred
+	}
+
+	// Fail the evaluation for the sections that could not be applied
+	if len(sectionErrors) > 0 {
+		return fmt.Errorf("evaluation failed: %w", sectionErrors)
+	}
+
+	return nil
+}
+
+// resolveConfig returns the configuration the Merge Request should be evaluated with, with all 'include' settings and
+// the global configuration file loaded, and the selected profile (see [state.Profile]) applied.
+//
+// [cfg] is used if provided, unless the Merge Request branch can't be trusted, in which case the configuration
+// file is read from HEAD instead. The returned context has the configuration source added to the logger.
+func resolveConfig(ctx context.Context, client scm.Client, evalContext scm.EvalContext, cfg *config.Config) (context.Context, *config.Config, error) {
+	var (
+		configShouldBeDownloaded = cfg == nil
+		configSourceRef          = state.CommitSHA(ctx)
+	)
+
+	// If the current branch is not in a state where the config file can be trusted,
+	// we instead use the HEAD version of the file
+	if !evalContext.CanUseConfigurationFileFromChangeRequest(ctx) {
+		configShouldBeDownloaded = true
+		configSourceRef = "HEAD"
+
+		// Update the logger with new value
+		ctx = slogctx.With(ctx, slog.String("config_source_branch", configSourceRef))
+	}
+
+	// Download and parse the configuration file if necessary
+	if configShouldBeDownloaded {
+		slogctx.Debug(ctx, "Downloading scm-engine configuration from ref: "+configSourceRef)
+
+		file, err := client.MergeRequests().GetRemoteConfig(ctx, state.ConfigFilePath(ctx), configSourceRef)
+		if err != nil {
+			cfg, err = missingConfigFallback(ctx, err)
+			if err != nil {
+				if errors.Is(err, config.ErrMissingConfigIgnored) {
+					return ctx, nil, err
+				}
+
+				return ctx, nil, fmt.Errorf("could not read remote config file: %w", err)
+			}
+		} else {
+			// Parse the file
+			cfg, err = config.ParseFile(file)
+			if err != nil {
+				return ctx, nil, fmt.Errorf("could not parse config file: %w", err)
+			}
+		}
+	}
+
+	// Sanity check for having a configuration loaded
+	if cfg == nil {
+		return ctx, nil, errors.New("cfg==nil; this is unexpected an error, please report!")
+	}
+
+	cfg, err := completeConfig(ctx, client, cfg)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return ctx, cfg, nil
+}
+
+// completeConfig loads the 'include' files of the configuration file [cfg], and merges the global
+// configuration file and selected profile (if any) into it
+func completeConfig(ctx context.Context, client scm.Client, cfg *config.Config) (*config.Config, error) {
+	// Load any remote configuration files
+	if err := cfg.LoadIncludes(ctx, client); err != nil {
+		return nil, fmt.Errorf("failed to load 'include' settings: %w", err)
+	}
+
+	// Merge the global configuration file (if any), like an included configuration file
+	cfg = cfg.WithGlobalConfig(ctx, globalConfigFromContext(ctx))
+
+	// Apply the selected profile (if any) on top of the configuration
+	cfg, err := cfg.WithProfile(ctx, state.Profile(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.IsEnabled() {
+		return nil, config.ErrProjectDisabled
+	}
+
+	return cfg, nil
+}
+
+// checkProjectEnabled returns [config.ErrProjectDisabled] if the project configuration file [cfg], combined with the
+// global configuration file (if any), opted out of processing. Checked before the evaluation starts,
+// so opted out projects don't get a pipeline status either
+func checkProjectEnabled(ctx context.Context, cfg *config.Config) error {
+	if cfg == nil || cfg.WithGlobalConfig(ctx, globalConfigFromContext(ctx)).IsEnabled() {
+		return nil
+	}
+
+	return config.ErrProjectDisabled
+}
+
+// missingConfigFallback returns the configuration to use, according to the configured
+// [config.MissingConfigBehavior], when reading the configuration file failed
+func missingConfigFallback(ctx context.Context, err error) (*config.Config, error) {
+	behavior, parseErr := config.ParseMissingConfigBehavior(state.MissingConfigBehavior(ctx))
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	cfg, err := behavior.Fallback(err)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Info(ctx, "Project has no scm-engine configuration file, using the bundled default configuration")
+
+	return cfg, nil
+}
+
+// postErrorComment posts (or updates) the error comment on the Merge Request if the evaluation failed
+// with [evalErr] and an error comment template is configured (see [scm.StatusMessages])
+func postErrorComment(ctx context.Context, client scm.Client, evalErr error) {
+	body, ok := scm.StatusMessagesFromContext(ctx).ErrorComment(ctx, evalErr)
+	if !ok || state.IsReadOnly(ctx) {
+		return
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Posting error comment", slog.String("body", body))
+
+		return
+	}
+
+	if err := client.MergeRequests().UpsertNote(ctx, scm.ErrorCommentMarker, body); err != nil {
+		slogctx.Error(ctx, "Failed to post error comment", slog.Any("error", err))
+	}
+}
+
+// postConfigChangeNotice posts (or updates) the notice on a Merge Request changing the configuration file,
+// if the configured [config.ConfigChangePolicy] asks for one
+func postConfigChangeNotice(ctx context.Context, client scm.Client) {
+	policy, _ := config.ParseConfigChangePolicy(state.ConfigChangePolicy(ctx))
+
+	body, ok := policy.Notice(state.ConfigFilePath(ctx))
+	if !ok || state.IsReadOnly(ctx) {
+		return
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Posting config change notice", slog.String("body", body))
+
+		return
+	}
+
+	if err := client.MergeRequests().UpsertNote(ctx, config.ConfigChangeNoticeMarker, body); err != nil {
+		slogctx.Error(ctx, "Failed to post config change notice", slog.Any("error", err))
+	}
+}
+
+func updateDebugComment(ctx context.Context, client scm.Client, trace *config.Trace) error {
+	body := trace.Markdown(fmt.Sprintf("scm-engine evaluation trace for commit %s", state.CommitSHA(ctx)))
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Updating debug comment", slog.String("body", body))
+
+		return nil
+	}
+
+	slogctx.Info(ctx, "Updating debug comment")
+
+	return client.MergeRequests().UpsertNote(ctx, config.DebugTraceMarker, body)
 }
 
 func updateMergeRequest(ctx context.Context, client scm.Client, update *scm.UpdateMergeRequestOptions) error {
+	if update.IsEmpty() {
+		slogctx.Info(ctx, "No Merge Request changes to apply")
+
+		return nil
+	}
+
 	if state.IsDryRun(ctx) {
 		slogctx.Info(ctx, "In dry-run, dumping the update struct we would send to GitLab", slog.Any("changes", update))
 
@@ -216,31 +591,222 @@ func updateMergeRequest(ctx context.Context, client scm.Client, update *scm.Upda
 	return err
 }
 
-func runActions(ctx context.Context, evalContext scm.EvalContext, client scm.Client, update *scm.UpdateMergeRequestOptions, actions config.Actions) error {
+// runActions applies the steps of every action.
+//
+// Actions that are deferred or skipped by a step (see [scm.DeferredError] and [scm.ErrSkipAction]) stop running
+// their remaining steps, while other actions still run. The first deferral is returned, so the caller can re-queue the evaluation.
+//
+// A failing step stops all remaining actions; the names of the failed action and the actions that didn't run are returned
+func runActions(ctx context.Context, evalContext scm.EvalContext, client scm.Client, update *scm.UpdateMergeRequestOptions, actions config.Actions) (*scm.DeferredError, []string, error) {
 	if len(actions) == 0 {
 		slogctx.Debug(ctx, "No actions evaluated to true, skipping")
 
-		return nil
+		return nil, nil, nil
 	}
 
-	for _, action := range actions {
+	var firstDeferred *scm.DeferredError
+
+	for i, action := range actions {
 		ctx := slogctx.With(ctx, slog.String("action_name", action.Name))
 		slogctx.Info(ctx, "Applying action")
 
 		if evalContext.HasExecutedActionGroup(action.Group) {
 			slogctx.Warn(ctx, fmt.Sprintf("Already executed another action within group '%s'; skipping current action until next evaluation", action.Group))
+			recordAction(ctx, action.Name, ActionOutcomeSkipped, fmt.Errorf("already executed another action within group '%s'", action.Group))
 
 			continue
 		}
 
 		evalContext.TrackActionGroupExecution(action.Group)
+		config.RecordRuleMetric(ctx, "action", action.Name, config.RuleMetricExecutions)
+
+		outcome, reason := ActionOutcomeApplied, error(nil)
 
+	steps:
 		for _, task := range action.Then {
-			if err := client.ApplyStep(ctx, evalContext, update, task); err != nil {
+			err := state.CountActionStep(ctx)
+			if err == nil {
+				task, err = config.FromContext(ctx).Locales.LocalizeStep(state.Locale(ctx), task)
+			}
+
+			if err == nil {
+				err = client.ApplyStep(ctx, evalContext, update, task)
+			}
+
+			if err == nil {
+				continue
+			}
+
+			var deferred *scm.DeferredError
+
+			switch {
+			case errors.As(err, &deferred):
+				slogctx.Info(ctx, "Deferring remaining action steps", slog.String("reason", deferred.Reason))
+
+				if firstDeferred == nil {
+					firstDeferred = deferred
+				}
+
+				outcome, reason = ActionOutcomeDeferred, errors.New(deferred.Reason)
+
+				break steps
+
+			case errors.Is(err, scm.ErrSkipAction):
+				slogctx.Info(ctx, "Skipping remaining action steps", slog.Any("reason", err))
+
+				outcome, reason = ActionOutcomeSkipped, err
+
+				break steps
+
+			default:
 				slogctx.Error(ctx, "failed to apply action step", slog.Any("error", err))
+				config.RecordRuleMetric(ctx, "action", action.Name, config.RuleMetricErrors)
+				recordAction(ctx, action.Name, ActionOutcomeFailed, err)
+
+				failed := make([]string, 0, len(actions)-i)
+				for _, remaining := range actions[i:] {
+					failed = append(failed, remaining.Name)
+				}
+
+				return nil, failed, err
+			}
+		}
+
+		recordAction(ctx, action.Name, outcome, reason)
+
+		// Only use up the rate limit of the action once all of its steps were applied
+		if outcome == ActionOutcomeApplied {
+			action.RecordRun(ctx, time.Now())
+		}
+	}
+
+	return firstDeferred, nil, nil
+}
+
+// replayableActions returns the [actions] that failed during the previous evaluation run of the commit (see --replay-failed),
+// so the actions that were already applied don't repeat their side effects, and the previous record (nil if there is none)
+func replayableActions(ctx context.Context, client scm.Client, actions config.Actions) (config.Actions, *scm.FailedActions, error) {
+	body, err := client.MergeRequests().FindNote(ctx, scm.FailedActionsMarker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previous, err := scm.ParseFailedActions(body)
+	if err != nil && !errors.Is(err, scm.ErrNoFailedActions) {
+		return nil, nil, err
+	}
+
+	replay := slices.DeleteFunc(actions, func(action config.Action) bool {
+		return !previous.ShouldReplay(state.CommitSHA(ctx), action.Name)
+	})
+
+	slogctx.Info(ctx, "Replaying failed actions", slog.Int("number_of_actions", len(replay)))
+
+	return replay, previous, nil
+}
+
+// recordFailedActions records the [failed] actions of the evaluation run in an internal note on the Merge Request,
+// so they can be replayed later. Failing to record is logged, but doesn't fail the evaluation
+func recordFailedActions(ctx context.Context, client scm.Client, failed []string) {
+	body := scm.FormatFailedActions(&scm.FailedActions{
+		CommitSHA:    state.CommitSHA(ctx),
+		EvaluationID: state.EvaluationID(ctx),
+		Actions:      failed,
+	})
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Recording failed actions", slog.Any("failed_actions", failed))
+
+		return
+	}
+
+	if err := client.MergeRequests().UpsertInternalNote(ctx, scm.FailedActionsMarker, body); err != nil {
+		slogctx.Warn(ctx, "Could not record the failed actions", slog.Any("error", err))
+	}
+}
+
+// managedLabels returns the names of the labels declared in the configuration file (see 'managed_labels_only'),
+// including the labels generated by the 'generate' strategy
+func managedLabels(labels []scm.EvaluationResult) []string {
+	names := make([]string, 0, len(labels))
+
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
 
+	return names
+}
+
+// graceLabelRemovals keeps labels with a 'remove_after' setting on the Merge Request (marking them as matched)
+// until their script has evaluated negatively for the whole grace period, tracked in a hidden comment
+func graceLabelRemovals(ctx context.Context, client scm.Client, evalContext scm.EvalContext, labels []scm.EvaluationResult) error {
+	if !slices.ContainsFunc(labels, func(label scm.EvaluationResult) bool { return label.RemoveAfter > 0 }) {
+		return nil
+	}
+
+	existing, err := client.MergeRequests().FindNote(ctx, scm.PendingLabelRemovalsMarker)
+	if err != nil {
+		return err
+	}
+
+	pending, err := scm.ParsePendingLabelRemovals(existing)
+	if err != nil {
+		return err
+	}
+
+	pending, changed := scm.GraceLabelRemovals(labels, evalContext.GetLabels(), pending, time.Now())
+
+	for name, since := range pending {
+		slogctx.Info(ctx, "Label no longer applies, keeping it during its removal grace period", slog.String("label", name), slog.Time("since", since))
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Recording pending label removals", slog.Any("pending_label_removals", pending))
+
+		return nil
+	}
+
+	// Best effort, failing to record the removals only restarts their grace period
+	if err := client.MergeRequests().UpsertNote(ctx, scm.PendingLabelRemovalsMarker, scm.FormatPendingLabelRemovals(pending)); err != nil {
+		slogctx.Warn(ctx, "Could not record the pending label removals", slog.Any("error", err))
+	}
+
+	return nil
+}
+
+// expireLabels marks labels with an 'expires_at' setting as negatively matched (removing them)
+// once they have been on the Merge Request for longer than allowed
+func expireLabels(ctx context.Context, client scm.Client, labels []scm.EvaluationResult) error {
+	var (
+		events []scm.LabelEvent
+		loaded bool
+	)
+
+	for idx, label := range labels {
+		if label.ExpiresAfter == 0 || !label.Matched {
+			continue
+		}
+
+		// Lazy load the label history, only when there are labels that may expire
+		if !loaded {
+			var err error
+
+			events, err = client.MergeRequests().LabelEvents(ctx)
+			if err != nil {
 				return err
 			}
+
+			loaded = true
+		}
+
+		if scm.LabelExpired(events, label.Name, label.ExpiresAfter, time.Now()) {
+			slogctx.Info(ctx, "Label has expired, removing it", slog.String("label", label.Name), slog.Duration("expires_after", label.ExpiresAfter))
+
+			labels[idx].Matched = false
 		}
 	}
 
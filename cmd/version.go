@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+type buildInfoKey struct{}
+
+// BuildInfo describes the scm-engine build, as embedded at build time via ldflags
+type BuildInfo struct {
+	// The scm-engine version (example: '1.2.3')
+	Version string `json:"version"`
+
+	// The git commit the build was made from
+	Commit string `json:"commit"`
+
+	// The date of the git commit the build was made from
+	Date string `json:"date"`
+
+	// The Go version the build was compiled with
+	GoVersion string `json:"go_version"`
+}
+
+// NewBuildInfo returns the [BuildInfo] for the running binary
+func NewBuildInfo(version, commit, date string) BuildInfo {
+	return BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// WithBuildInfo makes the [BuildInfo] available to the '/_version' endpoint
+func WithBuildInfo(ctx context.Context, info BuildInfo) context.Context {
+	return context.WithValue(ctx, buildInfoKey{}, info)
+}
+
+// buildInfoFromContext returns the [BuildInfo] from the context, with 'unknown' fields if it has not been set
+func buildInfoFromContext(ctx context.Context) BuildInfo {
+	info, ok := ctx.Value(buildInfoKey{}).(BuildInfo)
+	if !ok {
+		return NewBuildInfo("unknown", "unknown", "unknown")
+	}
+
+	return info
+}
+
+// VersionHandler responds with the [BuildInfo] as JSON, to confirm which build is deployed
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	slogctx.Debug(ctx, "GET /_version")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(buildInfoFromContext(ctx)); err != nil {
+		slogctx.Error(ctx, "could not write version response", slog.Any("error", err))
+	}
+}
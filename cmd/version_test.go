@@ -0,0 +1,36 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := cmd.WithBuildInfo(context.Background(), cmd.NewBuildInfo("1.2.3", "abc123", "2024-01-02T03:04:05Z"))
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/_version", nil)
+	recorder := httptest.NewRecorder()
+
+	cmd.VersionHandler(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, map[string]string{
+		"version":    "1.2.3",
+		"commit":     "abc123",
+		"date":       "2024-01-02T03:04:05Z",
+		"go_version": runtime.Version(),
+	}, body)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// WebhookRoute is a webhook handler, served for 'POST' requests on [Path]
+type WebhookRoute struct {
+	// Name of the flag configuring the path, used in error messages
+	Flag    string
+	Path    string
+	Handler http.Handler
+}
+
+// reservedPaths are served by every scm-engine HTTP server, so webhooks can't use them
+var reservedPaths = map[string]bool{
+	"/_status":  true,
+	"/_version": true,
+	"/_metrics": true,
+}
+
+// NewWebhookRouter returns a router serving the status and version endpoints, and every webhook route on its own path.
+// Each route is responsible for validating its own secret or signature.
+//
+// Requests for any other path get a '404 Not Found' response
+func NewWebhookRouter(routes ...WebhookRoute) (*http.ServeMux, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_status", GitLabStatusHandler)
+	mux.HandleFunc("GET /_version", VersionHandler)
+
+	seen := map[string]string{}
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/") || strings.ContainsAny(route.Path, " {}") {
+			return nil, fmt.Errorf("invalid --%s: %q must be a path starting with '/' (example: '/gitlab')", route.Flag, route.Path)
+		}
+
+		if reservedPaths[route.Path] {
+			return nil, fmt.Errorf("invalid --%s: %q is reserved for scm-engine", route.Flag, route.Path)
+		}
+
+		if other, ok := seen[route.Path]; ok {
+			return nil, fmt.Errorf("invalid --%s: %q is already used by --%s", route.Flag, route.Path, other)
+		}
+
+		seen[route.Path] = route.Flag
+
+		mux.Handle("POST "+route.Path, RecoverHandler(route.Handler))
+	}
+
+	return mux, nil
+}
+
+// withGitHubState configures [ctx] for handling GitHub webhooks with the API [token]
+func withGitHubState(ctx context.Context, token string) context.Context {
+	ctx = state.WithProvider(ctx, "github")
+	ctx = state.WithToken(ctx, token)
+	ctx = state.WithUpdatePipeline(ctx, false, "")
+
+	return ctx
+}
+
+// GitHubRouteHandler serves GitHub webhooks from a server configured for another SCM provider,
+// by configuring every request for GitHub (see [withGitHubState]) before it's handled
+func GitHubRouteHandler(ctx context.Context, token, webhookSecret string) http.HandlerFunc {
+	handler := GitHubWebhookHandler(withGitHubState(ctx, token), webhookSecret)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(withGitHubState(r.Context(), token)))
+	}
+}
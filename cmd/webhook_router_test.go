@@ -0,0 +1,156 @@
+package cmd_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhookRouter(t *testing.T) {
+	t.Parallel()
+
+	const (
+		gitlabSecret = "gitlab-secret"
+		githubSecret = "github-secret"
+	)
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com/")
+	ctx = state.WithToken(ctx, "token")
+
+	gitlabHandler, err := cmd.GitLabWebhookHandler(ctx, gitlabSecret)
+	require.NoError(t, err)
+
+	router, err := cmd.NewWebhookRouter(
+		cmd.WebhookRoute{Flag: "webhook-path", Path: "/hooks/gitlab", Handler: gitlabHandler},
+		cmd.WebhookRoute{Flag: "github-webhook-path", Path: "/hooks/github", Handler: cmd.GitHubRouteHandler(ctx, "github-token", githubSecret)},
+	)
+	require.NoError(t, err)
+
+	sign := func(secret, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	const ping = `{"zen": "Keep it logically awesome."}`
+
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		wantCode int
+	}{
+		{
+			name:     "gitlab route without token",
+			path:     "/hooks/gitlab",
+			headers:  map[string]string{"X-Gitlab-Event": "Merge Request Hook"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "gitlab route with the GitHub secret",
+			path:     "/hooks/gitlab",
+			headers:  map[string]string{"X-Gitlab-Event": "Merge Request Hook", "X-Gitlab-Token": githubSecret},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "gitlab route with a GitHub signature",
+			path:     "/hooks/gitlab",
+			headers:  map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign(githubSecret, ping)},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "github route with the GitLab token",
+			path:     "/hooks/github",
+			headers:  map[string]string{"X-GitHub-Event": "ping", "X-Gitlab-Token": gitlabSecret},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "github route signed with the GitLab secret",
+			path:     "/hooks/github",
+			headers:  map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign(gitlabSecret, ping)},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "github route with a valid signature",
+			path:     "/hooks/github",
+			headers:  map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign(githubSecret, ping)},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unknown path",
+			path:     "/gitlab",
+			headers:  map[string]string{"X-Gitlab-Token": gitlabSecret},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(ping))
+			req.Header.Set("Content-Type", "application/json")
+
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+func TestNewWebhookRouter_InvalidPaths(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NotFoundHandler()
+
+	tests := []struct {
+		name    string
+		routes  []cmd.WebhookRoute
+		wantErr string
+	}{
+		{
+			name:    "relative path",
+			routes:  []cmd.WebhookRoute{{Flag: "webhook-path", Path: "gitlab", Handler: handler}},
+			wantErr: `invalid --webhook-path: "gitlab" must be a path starting with '/' (example: '/gitlab')`,
+		},
+		{
+			name:    "reserved path",
+			routes:  []cmd.WebhookRoute{{Flag: "webhook-path", Path: "/_status", Handler: handler}},
+			wantErr: `invalid --webhook-path: "/_status" is reserved for scm-engine`,
+		},
+		{
+			name: "duplicate path",
+			routes: []cmd.WebhookRoute{
+				{Flag: "webhook-path", Path: "/hooks", Handler: handler},
+				{Flag: "github-webhook-path", Path: "/hooks", Handler: handler},
+			},
+			wantErr: `invalid --github-webhook-path: "/hooks" is already used by --webhook-path`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := cmd.NewWebhookRouter(tt.routes...)
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
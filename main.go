@@ -8,9 +8,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/jippi/scm-engine/cmd"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/jippi/scm-engine/pkg/tui"
 	"github.com/urfave/cli/v2"
@@ -27,6 +30,9 @@ var (
 func main() {
 	spew.Config.DisableMethods = true
 
+	// Stops the profiling started by --profile-cpu and --profile-mem (if any)
+	stopProfiling := func() error { return nil }
+
 	app := &cli.App{
 		Name:                 "scm-engine",
 		Usage:                "GitHub/GitLab automation",
@@ -44,12 +50,78 @@ func main() {
 			// Setup global state
 			cCtx.Context = tui.NewContext(cCtx.Context, cCtx.App.Writer, cCtx.App.ErrWriter)
 			cCtx.Context = slogctx.With(cCtx.Context, "scm_engine_version", version)
+			cCtx.Context = cmd.WithBuildInfo(cCtx.Context, cmd.NewBuildInfo(version, commit, date))
 
 			// Write global flags to context
 			cCtx.Context = state.WithDryRun(cCtx.Context, cCtx.Bool(cmd.FlagDryRun))
+			cCtx.Context = state.WithReadOnly(cCtx.Context, cCtx.Bool(cmd.FlagReadOnly))
+			cCtx.Context = state.WithProfile(cCtx.Context, cCtx.String(cmd.FlagProfile))
+			cCtx.Context = state.WithOnlyTags(cCtx.Context, cCtx.StringSlice(cmd.FlagOnlyTags))
+			cCtx.Context = state.WithSkipTags(cCtx.Context, cCtx.StringSlice(cmd.FlagSkipTags))
+			cCtx.Context = state.WithAllowPartialData(cCtx.Context, cCtx.Bool(cmd.FlagAllowPartialData))
+			cCtx.Context = state.WithCommentRateLimit(cCtx.Context, cCtx.Int(cmd.FlagCommentRateLimit), cCtx.Duration(cmd.FlagCommentRateLimitWindow))
+			cCtx.Context = state.WithMaxActionSteps(cCtx.Context, cCtx.Int(cmd.FlagMaxActionSteps))
+
+			configChangePolicy, err := config.ParseConfigChangePolicy(cCtx.String(cmd.FlagConfigChangePolicy))
+			if err != nil {
+				return err
+			}
+
+			cCtx.Context = state.WithConfigChangePolicy(cCtx.Context, string(configChangePolicy))
+
+			apiHeaders, err := scm.ParseHeaders(cCtx.StringSlice(cmd.FlagAPIHeader))
+			if err != nil {
+				return fmt.Errorf("invalid --%s: %w", cmd.FlagAPIHeader, err)
+			}
+
+			if userAgent := cCtx.String(cmd.FlagUserAgent); len(userAgent) > 0 {
+				apiHeaders.Set("User-Agent", userAgent)
+			}
+
+			cCtx.Context = state.WithAPIHeaders(cCtx.Context, apiHeaders)
+
+			if err := state.ValidateAPIPageSize(cCtx.Int(cmd.FlagAPIPageSize)); err != nil {
+				return fmt.Errorf("invalid --%s: %w", cmd.FlagAPIPageSize, err)
+			}
+
+			cCtx.Context = state.WithAPIPageSize(cCtx.Context, cCtx.Int(cmd.FlagAPIPageSize))
+
+			if proxyURL, caBundle := cCtx.String(cmd.FlagProxyURL), cCtx.String(cmd.FlagCABundle); len(proxyURL) > 0 || len(caBundle) > 0 {
+				transport, err := scm.NewTransport(proxyURL, caBundle)
+				if err != nil {
+					return fmt.Errorf("invalid --%s or --%s: %w", cmd.FlagProxyURL, cmd.FlagCABundle, err)
+				}
+
+				cCtx.Context = state.WithHTTPTransport(cCtx.Context, transport)
+			}
+
+			statusMessages, err := scm.NewStatusMessages(
+				cCtx.String(cmd.FlagStatusNameTemplate),
+				cCtx.String(cmd.FlagStatusRunningTemplate),
+				cCtx.String(cmd.FlagStatusSuccessTemplate),
+				cCtx.String(cmd.FlagStatusFailureTemplate),
+				cCtx.String(cmd.FlagErrorCommentTemplate),
+			)
+			if err != nil {
+				return err
+			}
+
+			cCtx.Context = scm.WithStatusMessages(cCtx.Context, statusMessages)
+
+			if cCtx.IsSet(cmd.FlagProfileCPU) || cCtx.IsSet(cmd.FlagProfileMem) {
+				stop, err := cmd.StartProfiling(cCtx.Context, cCtx.String(cmd.FlagProfileCPU), cCtx.String(cmd.FlagProfileMem))
+				if err != nil {
+					return err
+				}
+
+				stopProfiling = stop
+			}
 
 			return nil
 		},
+		After: func(cCtx *cli.Context) error {
+			return stopProfiling()
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:      cmd.FlagConfigFile,
@@ -60,6 +132,43 @@ func main() {
 					"SCM_ENGINE_CONFIG_FILE",
 				},
 			},
+			&cli.StringFlag{
+				Name:  cmd.FlagProfile,
+				Usage: "(Optional) Name of the configuration file profile to apply on top of the configuration (example: 'prod')",
+				EnvVars: []string{
+					"SCM_ENGINE_PROFILE",
+				},
+			},
+			&cli.StringSliceFlag{
+				Name:  cmd.FlagOnlyTags,
+				Usage: "(Optional) Only evaluate labels and actions with at least one of these tags (example: 'security')",
+				EnvVars: []string{
+					"SCM_ENGINE_ONLY_TAGS",
+				},
+			},
+			&cli.StringSliceFlag{
+				Name:  cmd.FlagSkipTags,
+				Usage: "(Optional) Don't evaluate labels and actions with any of these tags (example: 'experimental')",
+				EnvVars: []string{
+					"SCM_ENGINE_SKIP_TAGS",
+				},
+			},
+			&cli.StringFlag{
+				Name:      cmd.FlagProfileCPU,
+				Usage:     "(Optional) Write a CPU profile of the command to this file, for use with 'go tool pprof'",
+				TakesFile: true,
+				EnvVars: []string{
+					"SCM_ENGINE_PROFILE_CPU",
+				},
+			},
+			&cli.StringFlag{
+				Name:      cmd.FlagProfileMem,
+				Usage:     "(Optional) Write a heap profile to this file when the command completes, for use with 'go tool pprof'",
+				TakesFile: true,
+				EnvVars: []string{
+					"SCM_ENGINE_PROFILE_MEM",
+				},
+			},
 			&cli.BoolFlag{
 				Name:  cmd.FlagDryRun,
 				Usage: "Dry run, don't actually _do_ actions, just print them",
@@ -68,10 +177,135 @@ func main() {
 					"SCM_ENGINE_DRY_RUN",
 				},
 			},
+			&cli.BoolFlag{
+				Name:  cmd.FlagReadOnly,
+				Usage: "Read-only mode, never change anything (like --dry-run, but can't be turned off by the 'dry_run' configuration file setting)",
+				Value: false,
+				EnvVars: []string{
+					"SCM_ENGINE_READ_ONLY",
+				},
+			},
+			&cli.BoolFlag{
+				Name:  cmd.FlagAllowPartialData,
+				Usage: "Keep evaluating when optional Merge Request data (like approvals or changed files) can't be loaded, skipping only the labels and actions that use it",
+				Value: false,
+				EnvVars: []string{
+					"SCM_ENGINE_ALLOW_PARTIAL_DATA",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagUserAgent,
+				Usage: "The User-Agent header sent with every GitLab API request",
+				Value: "scm-engine/" + version,
+				EnvVars: []string{
+					"SCM_ENGINE_USER_AGENT",
+				},
+			},
+			&cli.StringSliceFlag{
+				Name:  cmd.FlagAPIHeader,
+				Usage: "(Optional) Extra static header sent with every GitLab API request, in the format 'Name: value' (example: 'X-Caller-Id: platform-team'). Can be repeated",
+				EnvVars: []string{
+					"SCM_ENGINE_API_HEADERS",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagProxyURL,
+				Usage: "(Optional) Proxy to send every API request through (example: 'http://proxy.example.com:3128'). Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables",
+				EnvVars: []string{
+					"SCM_ENGINE_PROXY_URL",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagCABundle,
+				Usage: "(Optional) Path to a file with PEM encoded CA certificates to trust for API requests, in addition to the system certificates (example: the certificate of a TLS intercepting proxy)",
+				EnvVars: []string{
+					"SCM_ENGINE_CA_BUNDLE",
+				},
+			},
+			&cli.IntFlag{
+				Name:  cmd.FlagAPIPageSize,
+				Usage: "Number of items requested per page by paginated GitLab API list requests (e.g. notes, commits, diffs and label events). Between 1 and 100 (the GitLab maximum)",
+				Value: state.MaxAPIPageSize,
+				EnvVars: []string{
+					"SCM_ENGINE_API_PAGE_SIZE",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagConfigChangePolicy,
+				Usage: "What to do when a Merge Request changes the configuration file itself: 'allow' (use the changed file), 'notice' (use it, and post a notice), 'require_approval' (use the target branch file until the Merge Request is approved, and post a notice) or 'use_target' (always use the target branch file, and post a notice)",
+				Value: string(config.ConfigChangeAllow),
+				EnvVars: []string{
+					"SCM_ENGINE_CONFIG_CHANGE_POLICY",
+				},
+			},
+			&cli.IntFlag{
+				Name:  cmd.FlagCommentRateLimit,
+				Usage: "(Optional) Maximum number of comments posted on a single Merge Request within --comment-rate-limit-window; additional 'comment' actions are skipped. 0 disables the limit. The comment history is only kept in memory, so it starts over when the server restarts, isn't shared between server replicas, and only covers the current run of 'evaluate'",
+				Value: 0,
+				EnvVars: []string{
+					"SCM_ENGINE_COMMENT_RATE_LIMIT",
+				},
+			},
+			&cli.DurationFlag{
+				Name:  cmd.FlagCommentRateLimitWindow,
+				Usage: "The time window for --comment-rate-limit",
+				Value: time.Hour,
+				EnvVars: []string{
+					"SCM_ENGINE_COMMENT_RATE_LIMIT_WINDOW",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagStatusNameTemplate,
+				Usage: "Template of the commit status name, so multiple scm-engine instances can report their own status (Go 'text/template' syntax, example: 'scm-engine/security')",
+				Value: scm.DefaultStatusNameTemplate,
+				EnvVars: []string{
+					"SCM_ENGINE_STATUS_NAME_TEMPLATE",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagStatusRunningTemplate,
+				Usage: "Template of the commit status description while an evaluation is running (Go 'text/template' syntax)",
+				Value: scm.DefaultStatusRunningTemplate,
+				EnvVars: []string{
+					"SCM_ENGINE_STATUS_RUNNING_TEMPLATE",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagStatusSuccessTemplate,
+				Usage: "Template of the commit status description when an evaluation succeeded (Go 'text/template' syntax)",
+				Value: scm.DefaultStatusSuccessTemplate,
+				EnvVars: []string{
+					"SCM_ENGINE_STATUS_SUCCESS_TEMPLATE",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagStatusFailureTemplate,
+				Usage: "Template of the commit status description when an evaluation failed (Go 'text/template' syntax, example: '{{ .Error }} (request: {{ .RequestID }}, see https://wiki.example.com/scm-engine)')",
+				Value: scm.DefaultStatusFailureTemplate,
+				EnvVars: []string{
+					"SCM_ENGINE_STATUS_FAILURE_TEMPLATE",
+				},
+			},
+			&cli.StringFlag{
+				Name:  cmd.FlagErrorCommentTemplate,
+				Usage: "(Optional) Template of a comment posted on the Merge Request when an evaluation failed (Go 'text/template' syntax). No comment is posted if empty",
+				EnvVars: []string{
+					"SCM_ENGINE_ERROR_COMMENT_TEMPLATE",
+				},
+			},
+			&cli.IntFlag{
+				Name:  cmd.FlagMaxActionSteps,
+				Usage: "Maximum number of action steps applied in a single evaluation of a Merge Request; the remaining actions are aborted once exceeded. 0 disables the limit",
+				Value: state.DefaultMaxActionSteps,
+				EnvVars: []string{
+					"SCM_ENGINE_MAX_ACTION_STEPS",
+				},
+			},
 		},
 		Commands: []*cli.Command{
 			cmd.GitLab,
 			cmd.GitHub,
+			cmd.Init,
 
 			// DEPRECATED COMMANDS
 			{
@@ -85,6 +319,7 @@ func main() {
 					cCtx.Context = state.WithBaseURL(cCtx.Context, cCtx.String(cmd.FlagSCMBaseURL))
 					cCtx.Context = state.WithProvider(cCtx.Context, "gitlab")
 					cCtx.Context = state.WithToken(cCtx.Context, cCtx.String(cmd.FlagAPIToken))
+					cCtx.Context = cmd.WithGitLabCIDefaults(cCtx.Context, cCtx.IsSet(cmd.FlagSCMBaseURL), os.LookupEnv)
 
 					return nil
 				},
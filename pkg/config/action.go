@@ -2,12 +2,17 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
+	"time"
 
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/patcher"
 	"github.com/expr-lang/expr/vm"
+	"github.com/hashicorp/go-multierror"
 	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/jippi/scm-engine/pkg/stdlib"
 	slogctx "github.com/veqryn/slog-context"
 )
@@ -32,15 +37,63 @@ type (
 		// See: https://jippi.github.io/scm-engine/configuration/#actions.if
 		If string `json:"if" yaml:"if"`
 
+		// (Optional) A key controlling if the action is enabled at all. When the script returns false, the action is skipped entirely.
+		//
+		// This script is in Expr-lang: https://expr-lang.org/docs/language-definition
+		//
+		// See: https://jippi.github.io/scm-engine/configuration/#actions.enabled
+		Enabled string `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+		// (Optional) Name of a feature flag (see 'feature_flags') that must be on for the action to be enabled.
+		//
+		// See: https://jippi.github.io/scm-engine/configuration/#actions.feature_flag
+		FeatureFlag string `json:"feature_flag,omitempty" yaml:"feature_flag,omitempty"`
+
+		// (Optional) Whether a failing script fails the whole evaluation (true) or only skips this action (false).
+		//
+		// Defaults to the global 'strict_errors' setting.
+		//
+		// See: https://jippi.github.io/scm-engine/configuration/#strict_errors
+		StrictErrors *bool `json:"strict_errors,omitempty" yaml:"strict_errors,omitempty"`
+
+		// (Optional) Tags for selecting a subset of labels and actions at runtime with the '--only-tags' and '--skip-tags' flags.
+		//
+		// See: https://jippi.github.io/scm-engine/configuration/#actions.tags
+		Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+		// (Optional) Only run the action when the evaluation was triggered by this kind of event.
+		//
+		// - "open" when the Merge Request is opened
+		// - "reopen" when the Merge Request is reopened
+		// - "update" for any other Merge Request change, and evaluations not triggered by a webhook
+		// - "note" when a comment (or review) is made
+		// - "merge" when the Merge Request is merged
+		// - "any" for all of the above
+		//
+		// See: https://jippi.github.io/scm-engine/configuration/#actions.run_on
+		RunOn string `json:"run_on,omitempty" yaml:"run_on,omitempty" jsonschema:"default=any,enum=open,enum=reopen,enum=update,enum=note,enum=merge,enum=any"`
+
+		// (Optional) Limit how often the action runs for the same key (for example, per author) within a window,
+		// regardless of the Merge Request. The action is skipped while the key is hot.
+		//
+		// See: https://jippi.github.io/scm-engine/configuration/#actions.rate_limit
+		RateLimit *ActionRateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+
 		// The list of operations to take if the action.if returned true.
 		//
 		// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then
 		Then []ActionStep `json:"then" yaml:"then"`
+
+		// rateLimitKey is the evaluated [Action.RateLimit] key, so the run can be recorded once the steps were applied
+		rateLimitKey string
 	}
 )
 
 func (actions Actions) Evaluate(ctx context.Context, evalContext scm.EvalContext) ([]Action, error) {
-	results := []Action{}
+	var (
+		results          = []Action{}
+		evaluationErrors error
+	)
 
 	// Evaluate actions
 	for _, action := range actions {
@@ -48,9 +101,24 @@ func (actions Actions) Evaluate(ctx context.Context, evalContext scm.EvalContext
 
 		slogctx.Debug(ctx, "Evaluating action")
 
+		RecordRuleMetric(ctx, "action", action.Name, RuleMetricEvaluations)
+
 		ok, err := action.Evaluate(ctx, evalContext)
 		if err != nil {
-			return nil, err
+			RecordRuleMetric(ctx, "action", action.Name, RuleMetricErrors)
+
+			err = fmt.Errorf("action: %s; %w", action.Name, err)
+
+			if isStrictErrors(ctx, action.StrictErrors) {
+				evaluationErrors = multierror.Append(evaluationErrors, err)
+
+				continue
+			}
+
+			slogctx.Error(ctx, "Skipping action that failed to evaluate", slog.Any("error", err))
+			recordTrace(ctx, TraceEntry{Kind: "action", Name: action.Name, Script: action.If, Outcome: "skipped (error)"})
+
+			continue
 		}
 
 		if !ok {
@@ -61,23 +129,105 @@ func (actions Actions) Evaluate(ctx context.Context, evalContext scm.EvalContext
 
 		slogctx.Debug(ctx, "Action evaluated positively")
 
+		RecordRuleMetric(ctx, "action", action.Name, RuleMetricMatches)
+
 		results = append(results, action)
 	}
 
+	// Report every failing action at once, rather than one per evaluation
+	if evaluationErrors != nil {
+		return nil, evaluationErrors
+	}
+
 	return results, nil
 }
 
 func (p *Action) Evaluate(ctx context.Context, evalContext scm.EvalContext) (bool, error) {
+	if err := p.validateRunOn(); err != nil {
+		return false, err
+	}
+
+	if len(p.RunOn) > 0 && p.RunOn != "any" && p.RunOn != state.TriggerEvent(ctx) {
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Outcome: fmt.Sprintf("skipped (run_on: %s)", p.RunOn)})
+
+		return false, nil
+	}
+
+	if selected, outcome := isSelectedByTags(ctx, p.Tags); !selected {
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Outcome: outcome})
+
+		return false, nil
+	}
+
+	if selected, outcome := isSelectedByChangedRules(ctx, "action", p.Name); !selected {
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Outcome: outcome})
+
+		return false, nil
+	}
+
+	if err := checkUnavailableData(evalContext, p.If, p.Enabled); err != nil {
+		slogctx.Error(ctx, "Skipping action", slog.Any("error", err))
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Outcome: fmt.Sprintf("skipped (%s)", err)})
+
+		return false, nil
+	}
+
+	enabledProgram, err := compileEnabled(p.Enabled, evalContext)
+	if err != nil {
+		return false, err
+	}
+
+	enabled, outcome, err := isEnabled(ctx, p.FeatureFlag, enabledProgram, evalContext)
+	if err != nil {
+		return false, err
+	}
+
+	if !enabled {
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Script: p.Enabled, Outcome: outcome})
+
+		return false, nil
+	}
+
 	program, err := p.Setup(evalContext)
 	if err != nil {
 		return false, err
 	}
 
 	// Run the compiled expr-lang script
-	return runAndCheckBool(ctx, program, evalContext)
+	ok, err := runAndCheckBool(ctx, program, evalContext)
+	if err != nil {
+		return false, err
+	}
+
+	if ok {
+		key, allowed, err := p.RateLimit.allow(ctx, p.Name, evalContext, time.Now())
+		if err != nil {
+			return false, err
+		}
+
+		p.rateLimitKey = key
+
+		if !allowed {
+			recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Script: p.RateLimit.Key, Outcome: "skipped (rate limited)"})
+
+			return false, nil
+		}
+	}
+
+	recordTrace(ctx, TraceEntry{Kind: "action", Name: p.Name, Script: p.If, Outcome: fmt.Sprintf("%t", ok)})
+
+	return ok, nil
 }
 
 func (p *Action) Setup(evalContext scm.EvalContext) (*vm.Program, error) {
+	if err := p.validateRunOn(); err != nil {
+		return nil, err
+	}
+
+	if err := p.RateLimit.validate(evalContext); err != nil {
+		return nil, err
+	}
+
 	opts := []expr.Option{}
 	opts = append(opts, expr.AsBool())
 	opts = append(opts, expr.Env(evalContext))
@@ -87,3 +237,24 @@ func (p *Action) Setup(evalContext scm.EvalContext) (*vm.Program, error) {
 
 	return expr.Compile(p.If, opts...)
 }
+
+// issueOnlyStep returns the first step of the action that only applies to issues (see [issueActions]), if any
+func (p *Action) issueOnlyStep() (string, bool) {
+	for _, step := range p.Then {
+		if name, _ := step.RequiredString("action"); slices.Contains(issueActions, name) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *Action) validateRunOn() error {
+	switch p.RunOn {
+	case "", "any", state.TriggerEventOpen, state.TriggerEventReopen, state.TriggerEventUpdate, state.TriggerEventNote, state.TriggerEventMerge:
+		return nil
+
+	default:
+		return fmt.Errorf("unknown action [run_on] %q. use 'open', 'reopen', 'update', 'note', 'merge' or 'any'", p.RunOn)
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/expr-lang/expr/vm"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	slogctx "github.com/veqryn/slog-context"
+	"github.com/xhit/go-str2duration/v2"
+)
+
+// ActionRateLimit limits how often an action runs for the same key, regardless of the Merge Request
+type ActionRateLimit struct {
+	// The key the rate limit is tracked by, for example 'merge_request.author.username' to limit the action per author.
+	//
+	// This script is in Expr-lang: https://expr-lang.org/docs/language-definition
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.rate_limit.key
+	Key string `json:"key" yaml:"key"`
+
+	// The window (e.g. "1h" or "1d") the action runs at most 'max' times in, for each key.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.rate_limit.window
+	Window string `json:"window" yaml:"window"`
+
+	// (Optional) How many times the action may run within the window, for each key.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.rate_limit.max
+	Max int `json:"max,omitempty" yaml:"max,omitempty" jsonschema:"default=1"`
+}
+
+// parse returns the window and maximum number of runs of the rate limit
+func (r *ActionRateLimit) parse() (time.Duration, int, error) {
+	window, err := str2duration.ParseDuration(r.Window)
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("[rate_limit.window] must be a positive duration (e.g. '1h' or '1d'), got %q", r.Window)
+	}
+
+	switch {
+	case r.Max < 0:
+		return 0, 0, fmt.Errorf("[rate_limit.max] must be a positive number, got %d", r.Max)
+
+	case r.Max == 0:
+		return window, 1, nil
+
+	default:
+		return window, r.Max, nil
+	}
+}
+
+func (r *ActionRateLimit) compileKey(evalContext scm.EvalContext) (*vm.Program, error) {
+	if len(r.Key) == 0 {
+		return nil, fmt.Errorf("[rate_limit.key] is required")
+	}
+
+	opts := []expr.Option{}
+	opts = append(opts, expr.Env(evalContext))
+	opts = append(opts, stdlib.FunctionRenamer)
+	opts = append(opts, stdlib.Functions...)
+	opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+	program, err := expr.Compile(r.Key, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile [rate_limit.key] into valid expr-lang syntax: %w", err)
+	}
+
+	return program, nil
+}
+
+// validate checks the rate limit (if any) is valid, without evaluating it
+func (r *ActionRateLimit) validate(evalContext scm.EvalContext) error {
+	if r == nil {
+		return nil
+	}
+
+	if _, _, err := r.parse(); err != nil {
+		return err
+	}
+
+	_, err := r.compileKey(evalContext)
+
+	return err
+}
+
+// allow evaluates the key of the rate limit, and returns false if the key is hot at [now] and the [action] must be skipped.
+//
+// The run isn't recorded until its steps were applied, see [Action.RecordRun]
+func (r *ActionRateLimit) allow(ctx context.Context, action string, evalContext scm.EvalContext, now time.Time) (string, bool, error) {
+	if r == nil {
+		return "", true, nil
+	}
+
+	window, maxRuns, err := r.parse()
+	if err != nil {
+		return "", false, err
+	}
+
+	program, err := r.compileKey(evalContext)
+	if err != nil {
+		return "", false, err
+	}
+
+	output, err := expr.Run(program, evalContext)
+	if err != nil {
+		return "", false, fmt.Errorf("could not evaluate [rate_limit.key]: %w", err)
+	}
+
+	key := fmt.Sprint(output)
+
+	if !state.AllowActionRun(ctx, action, key, maxRuns, window, now) {
+		slogctx.Info(ctx, "Action rate limit reached, skipping action", slog.String("rate_limit_key", key), slog.Int("max", maxRuns), slog.Duration("window", window))
+
+		return key, false, nil
+	}
+
+	return key, true, nil
+}
+
+// RecordRun records a run of the action at [now] for its rate limit (if any), once all of its steps were applied.
+//
+// Runs aren't recorded in dry-run mode, since nothing was applied
+func (p *Action) RecordRun(ctx context.Context, now time.Time) {
+	if p.RateLimit == nil || state.IsDryRun(ctx) {
+		return
+	}
+
+	window, _, err := p.RateLimit.parse()
+	if err != nil {
+		return
+	}
+
+	state.RecordActionRun(ctx, p.Name, p.rateLimitKey, window, now)
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActions_Evaluate_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := state.WithProjectID(context.Background(), "jippi/config-action-rate-limit")
+	ctx = state.WithDryRun(ctx, false)
+
+	cfg := config.Config{
+		Actions: config.Actions{
+			{Name: "notify", If: `true`, RateLimit: &config.ActionRateLimit{Key: `Bucket`, Window: "1h"}},
+			{Name: "unlimited", If: `true`},
+		},
+	}
+
+	// evaluate returns the names of the matching actions, recording a run of each of them when [apply] is set
+	evaluate := func(ctx context.Context, bucket string, apply bool) []string {
+		_, actions, err := cfg.Evaluate(ctx, &testEvalContext{Bucket: bucket})
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, action := range actions {
+			names = append(names, action.Name)
+
+			if apply {
+				action.RecordRun(ctx, time.Now())
+			}
+		}
+
+		return names
+	}
+
+	// Matching the action doesn't use up the rate limit, only applying it does
+	require.Equal(t, []string{"notify", "unlimited"}, evaluate(ctx, "alice", false))
+	require.Equal(t, []string{"notify", "unlimited"}, evaluate(ctx, "alice", true))
+
+	// The key is hot, so the action is skipped
+	require.Equal(t, []string{"unlimited"}, evaluate(ctx, "alice", true))
+
+	// Other keys are not limited
+	require.Equal(t, []string{"notify", "unlimited"}, evaluate(ctx, "bob", true))
+
+	// Dry runs don't apply the action, so they don't record a run either
+	dryRun := state.WithDryRun(ctx, true)
+
+	require.Equal(t, []string{"notify", "unlimited"}, evaluate(dryRun, "carol", true))
+	require.Equal(t, []string{"notify", "unlimited"}, evaluate(dryRun, "carol", true))
+}
+
+func TestActionRateLimit_Lint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		rateLimit config.ActionRateLimit
+		wantErr   string
+	}{
+		{
+			name:      "valid",
+			rateLimit: config.ActionRateLimit{Key: `Bucket`, Window: "1d", Max: 3},
+		},
+		{
+			name:      "missing key",
+			rateLimit: config.ActionRateLimit{Window: "1h"},
+			wantErr:   "[rate_limit.key] is required",
+		},
+		{
+			name:      "invalid window",
+			rateLimit: config.ActionRateLimit{Key: `Bucket`, Window: "soon"},
+			wantErr:   "[rate_limit.window] must be a positive duration",
+		},
+		{
+			name:      "negative max",
+			rateLimit: config.ActionRateLimit{Key: `Bucket`, Window: "1h", Max: -1},
+			wantErr:   "[rate_limit.max] must be a positive number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.Config{
+				Actions: config.Actions{{Name: "notify", If: `true`, RateLimit: &tt.rateLimit}},
+			}
+
+			err := cfg.Lint(context.Background(), &testEvalContext{})
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/invopop/jsonschema"
+	"github.com/jippi/scm-engine/pkg/stdlib"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
 )
 
@@ -14,18 +15,42 @@ type actionList struct {
 }
 
 var actions = []actionList{
+	{name: "add_approval_rule", instance: AddApprovalRuleAction{}},
 	{name: "add_label", instance: AddLabelAction{}},
+	{name: "add_to_merge_train", instance: AddToMergeTrainAction{}},
 	{name: "approve", instance: ApproveAction{}},
+	{name: "clear_pipeline_variables", instance: ClearPipelineVariablesAction{}},
 	{name: "close", instance: CloseAction{}},
 	{name: "comment", instance: CommentAction{}},
+	{name: "create_issue", instance: CreateIssueAction{}},
 	{name: "lock_discussion", instance: LockDiscussionAction{}},
+	{name: "manage_approval_rule", instance: ManageApprovalRuleAction{}},
+	{name: "merge", instance: MergeAction{}},
+	{name: "move_to_project", instance: MoveToProjectAction{}},
+	{name: "quarantine", instance: QuarantineAction{}},
+	{name: "remove_approval_rule", instance: RemoveApprovalRuleAction{}},
+	{name: "remove_from_merge_train", instance: RemoveFromMergeTrainAction{}},
 	{name: "remove_label", instance: RemoveLabelAction{}},
+	{name: "remove_labels", instance: RemoveLabelsAction{}},
 	{name: "reopen", instance: ReopenAction{}},
+	{name: "require_description_template", instance: RequireDescriptionTemplateAction{}},
+	{name: "require_linked_issue", instance: RequireLinkedIssueAction{}},
+	{name: "set_pipeline_variables", instance: SetPipelineVariablesAction{}},
+	{name: "set_target_branch", instance: SetTargetBranchAction{}},
+	{name: "snapshot_labels", instance: SnapshotLabelsAction{}},
+	{name: "suggest", instance: SuggestAction{}},
+	{name: "title_normalize", instance: TitleNormalizeAction{}},
+	{name: "transform_labels", instance: TransformLabelsAction{}},
 	{name: "unapprove", instance: UnapproveAction{}},
 	{name: "unlock_discussion", instance: UnlockDiscussionAction{}},
+	{name: "unquarantine", instance: UnquarantineAction{}},
 	{name: "update_description", instance: UpdateDescriptionAction{}},
+	{name: "update_linked_issues", instance: UpdateLinkedIssuesAction{}},
 }
 
+// issueActions are the actions that only apply to issues, so they can only be used in 'on_issue'
+var issueActions = []string{"move_to_project"}
+
 type BaseAction struct {
 	// The action to take
 	//
@@ -36,6 +61,85 @@ type BaseAction struct {
 // Hello World?
 type ApproveAction struct {
 	BaseAction
+	WaitForPipelineOptions
+}
+
+// Merges the Merge Request
+type MergeAction struct {
+	BaseAction
+	WaitForPipelineOptions
+}
+
+// Adds the Merge Request to the merge train of its target branch
+type AddToMergeTrainAction struct {
+	BaseAction
+
+	// (Optional) Add the Merge Request to the merge train once its running pipeline succeeds.
+	//
+	// When off, a Merge Request with a running pipeline is skipped until the pipeline succeeded.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	WhenPipelineSucceeds *bool `json:"when_pipeline_succeeds,omitempty" yaml:"when_pipeline_succeeds" jsonschema:"default=true"`
+}
+
+// Creates a pipeline for the source branch with the variables added, carrying over the variables of the head pipeline
+type SetPipelineVariablesAction struct {
+	BaseAction
+
+	// The pipeline variables to set, keyed by their name. Names may only have letters, digits and '_'.
+	//
+	// The values are visible to everyone who can see the pipeline, so never use them for secrets.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Variables map[string]string `json:"variables" yaml:"variables"`
+}
+
+// Creates a pipeline for the source branch without the variables, carrying over the other variables of the head pipeline
+type ClearPipelineVariablesAction struct {
+	BaseAction
+
+	// The names of the pipeline variables to clear.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Variables []string `json:"variables" yaml:"variables"`
+}
+
+// Removes the Merge Request from the merge train
+type RemoveFromMergeTrainAction struct {
+	BaseAction
+}
+
+// Select the message of an action step from the 'locales' message strings
+type MessageKeyOptions struct {
+	// (Optional) The key of the message in 'locales.messages', used instead of 'message', in the locale of the Merge Request.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#locales
+	MessageKey string `json:"message_key,omitempty" yaml:"message_key"`
+}
+
+// Gate an action on the Merge Request head pipeline succeeding
+type WaitForPipelineOptions struct {
+	// (Optional) Only run the action once the head pipeline succeeded, skipping it if the pipeline fails.
+	//
+	// One of 'requeue' (re-evaluate the Merge Request later, server mode only) or 'poll' (block until the pipeline finished).
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	WaitForPipeline string `json:"wait_for_pipeline,omitempty" yaml:"wait_for_pipeline" jsonschema:"enum=requeue,enum=poll"`
+
+	// (Optional) How long to wait for the head pipeline to finish when using 'poll' (example: '10m').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	WaitForPipelineTimeout string `json:"wait_for_pipeline_timeout,omitempty" yaml:"wait_for_pipeline_timeout" jsonschema:"default=10m"`
+
+	// (Optional) How long to wait between re-evaluations when using 'requeue' (example: '2m').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	WaitForPipelineDelay string `json:"wait_for_pipeline_delay,omitempty" yaml:"wait_for_pipeline_delay" jsonschema:"default=1m"`
+
+	// (Optional) How many times the evaluation may be re-queued when using 'requeue' before giving up.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	WaitForPipelineMaxDeferrals int `json:"wait_for_pipeline_max_deferrals,omitempty" yaml:"wait_for_pipeline_max_deferrals" jsonschema:"default=30"`
 }
 
 type UnapproveAction struct {
@@ -63,13 +167,94 @@ type RemoveLabelAction struct {
 	Label string `json:"label" yaml:"label"`
 }
 
+// Renames labels on the Merge Request, for example when migrating to a new label scheme
+type TransformLabelsAction struct {
+	BaseAction
+
+	// Old label names mapped to their new name (example: 'bug: type::bug').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Mapping map[string]string `json:"mapping" yaml:"mapping"`
+}
+
+// Removes labels from the Merge Request, regardless of how they were added
+type RemoveLabelsAction struct {
+	BaseAction
+
+	// (Optional) The label names to remove.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Labels []string `json:"labels,omitempty" yaml:"labels"`
+
+	// (Optional) An Expr Lang expression returning a list of label names to remove.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Script string `json:"script,omitempty" yaml:"script"`
+}
+
 type CommentAction struct {
 	BaseAction
 
-	// The message that will be commented on the Merge Request
+	// The message that will be commented on the Merge Request, required unless 'message_key' is set
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+
+	// (Optional) Post the comment as an internal note, only visible to project members with at least the Reporter role.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Internal bool `json:"internal,omitempty" yaml:"internal" jsonschema:"default=false"`
+
+	// (Optional) Whether @-mentions in the message notify the mentioned users ('notify'), or render literally without notifying anyone ('suppress').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Mentions string `json:"mentions,omitempty" yaml:"mentions" jsonschema:"enum=notify,enum=suppress,default=notify"`
+}
+
+// Moves the issue to another project, only available in 'on_issue' actions
+type MoveToProjectAction struct {
+	BaseAction
+
+	// An Expr Lang expression returning the full path of the project to move the issue to (example: '"my-group/support"').
 	//
 	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
-	Message string `json:"message" yaml:"message"`
+	Project string `json:"project" yaml:"project"`
+}
+
+// Creates an issue (e.g. a follow-up) linking the Merge Request, and comments the issue link on the Merge Request
+type CreateIssueAction struct {
+	BaseAction
+
+	// An Expr Lang expression returning the issue title.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Title string `json:"title" yaml:"title"`
+
+	// (Optional) An Expr Lang expression returning the issue description. Defaults to a reference to the Merge Request.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Description string `json:"description,omitempty" yaml:"description"`
+
+	// (Optional) The label names to add to the issue.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Labels []string `json:"labels,omitempty" yaml:"labels"`
+
+	// (Optional) An Expr Lang expression returning the username to assign the issue to.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Assignee string `json:"assignee,omitempty" yaml:"assignee"`
+
+	// (Optional) The full path of the project to create the issue in. Defaults to the Merge Request project.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Project string `json:"project,omitempty" yaml:"project"`
+
+	// (Optional) Identifies the issue among other 'create_issue' steps for the same Merge Request, so each issue is only created once.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Key string `json:"key,omitempty" yaml:"key" jsonschema:"default=follow-up"`
 }
 
 type AddLabelAction struct {
@@ -81,6 +266,171 @@ type AddLabelAction struct {
 	Label string `json:"label" yaml:"label"`
 }
 
+// Records the Merge Request labels in a comment, so they can be restored with 'scm-engine gitlab labels restore'
+type SnapshotLabelsAction struct {
+	BaseAction
+
+	// (Optional) Replace an existing snapshot. When off, the first snapshot is kept.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Overwrite bool `json:"overwrite,omitempty" yaml:"overwrite" jsonschema:"default=false"`
+}
+
+// Posts a suggestion on a line in the Merge Request diff
+type SuggestAction struct {
+	BaseAction
+
+	// The path of the changed file to suggest a change in.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	File string `json:"file" yaml:"file"`
+
+	// The line number (in the new version of the file) the suggestion is anchored to.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Line int `json:"line" yaml:"line"`
+
+	// Number of lines above [line] that the suggestion replaces.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	LinesAbove int `json:"lines_above,omitempty" yaml:"lines_above"`
+
+	// Number of lines below [line] that the suggestion replaces.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	LinesBelow int `json:"lines_below,omitempty" yaml:"lines_below"`
+
+	// An Expr Lang expression returning the replacement text for the lines.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Replacement string `json:"replacement" yaml:"replacement"`
+
+	// (Optional) Message to show above the suggestion.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+}
+
+// Enforces the Merge Request title format (e.g. conventional commits), by rewriting or validating the title
+type TitleNormalizeAction struct {
+	BaseAction
+
+	// Regular expression the Merge Request title must match.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// (Optional) What to do when the title doesn't match [pattern].
+	//
+	// One of 'rewrite' (update the title using [template]) or 'validate' (set a failed commit status instead of editing).
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Mode string `json:"mode,omitempty" yaml:"mode" jsonschema:"default=rewrite,enum=rewrite,enum=validate"`
+
+	// An Expr Lang expression returning the normalized title. Required when [mode] is 'rewrite'.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Template string `json:"template,omitempty" yaml:"template"`
+
+	// (Optional) Comment explaining the required title format, posted when the title doesn't match [pattern].
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+
+	// (Optional) Name of the commit status set when [mode] is 'validate'.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	StatusName string `json:"status_name,omitempty" yaml:"status_name" jsonschema:"default=scm-engine/title"`
+}
+
+// Changes the target branch of the Merge Request, and posts a note explaining the change
+type SetTargetBranchAction struct {
+	BaseAction
+
+	// The branch to target. Either [branch] or [script] is required.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Branch string `json:"branch,omitempty" yaml:"branch"`
+
+	// An Expr Lang expression returning the branch to target.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Script string `json:"script,omitempty" yaml:"script"`
+
+	// (Optional) Note explaining the change, posted when the target branch is changed.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+}
+
+// Creates (or updates) a Merge Request level approval rule
+type AddApprovalRuleAction struct {
+	BaseAction
+
+	// The name of the approval rule. Used to find and update the rule on later evaluations.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Name string `json:"name" yaml:"name"`
+
+	// The number of approvals required by the rule.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	ApprovalsRequired int `json:"approvals_required" yaml:"approvals_required"`
+
+	// (Optional) Usernames of the users eligible to approve.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Users []string `json:"users,omitempty" yaml:"users"`
+
+	// (Optional) Full paths of the groups eligible to approve.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Groups []string `json:"groups,omitempty" yaml:"groups"`
+}
+
+// Creates (or updates) a Merge Request level approval rule while the [when] script returns true, and removes it otherwise
+type ManageApprovalRuleAction struct {
+	BaseAction
+
+	// (Optional) The name of the approval rule. Used to find, update and remove the rule on later evaluations.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Name string `json:"name,omitempty" yaml:"name" jsonschema:"default=scm-engine-policy"`
+
+	// Script (returning a boolean) deciding whether the approval rule is required.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	When string `json:"when" yaml:"when"`
+
+	// The number of approvals required by the rule.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	ApprovalsRequired int `json:"approvals_required" yaml:"approvals_required"`
+
+	// (Optional) Usernames of the users eligible to approve.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Users []string `json:"users,omitempty" yaml:"users"`
+
+	// (Optional) Full paths of the groups eligible to approve.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Groups []string `json:"groups,omitempty" yaml:"groups"`
+}
+
+// Removes a Merge Request level approval rule
+type RemoveApprovalRuleAction struct {
+	BaseAction
+
+	// The name of the approval rule to remove.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Name string `json:"name" yaml:"name"`
+}
+
 type UnlockDiscussionAction struct {
 	BaseAction
 }
@@ -177,6 +527,88 @@ func (step ActionStep) OptionalString(name, defaultValue string) (string, error)
 	return valueString, nil
 }
 
+func (step ActionStep) OptionalInt(name string, defaultValue int) (int, error) {
+	value, ok := step[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	switch val := value.(type) {
+	case int:
+		return val, nil
+
+	case uint64:
+		return int(val), nil //nolint:gosec
+
+	case float64:
+		return int(val), nil
+
+	default:
+		return defaultValue, fmt.Errorf("Optional step field '%s' must be of type int, got %T", name, value)
+	}
+}
+
+func (step ActionStep) RequiredInt(name string) (int, error) {
+	if _, ok := step[name]; !ok {
+		return 0, fmt.Errorf("Required 'step' key '%s' is missing", name)
+	}
+
+	return step.OptionalInt(name, 0)
+}
+
+func (step ActionStep) OptionalBool(name string, defaultValue bool) (bool, error) {
+	value, ok := step[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	valueBool, ok := value.(bool)
+	if !ok {
+		return defaultValue, fmt.Errorf("Optional step field '%s' must be of type bool, got %T", name, value)
+	}
+
+	return valueBool, nil
+}
+
+func (step ActionStep) OptionalStringSlice(name string) ([]string, error) {
+	value, ok := step[name]
+	if !ok {
+		return nil, nil
+	}
+
+	values, err := stdlib.ToStringSlice(value)
+	if err != nil {
+		return nil, fmt.Errorf("Optional step field '%s' must be a list of strings: %w", name, err)
+	}
+
+	return values, nil
+}
+
+func (step ActionStep) RequiredStringMap(name string) (map[string]string, error) {
+	value, ok := step[name]
+	if !ok {
+		return nil, fmt.Errorf("Required 'step' key '%s' is missing", name)
+	}
+
+	values, ok := value.(ActionStep)
+	if !ok {
+		return nil, fmt.Errorf(`Required 'step' key '%s' must be a dictionary with string keys and string values ("key": "value"), got %T`, name, value)
+	}
+
+	result := make(map[string]string, len(values))
+
+	for key, val := range values {
+		valString, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("Required 'step' key '%s' must only have string values, got %T for %q", name, val, key)
+		}
+
+		result[key] = valString
+	}
+
+	return result, nil
+}
+
 func (step ActionStep) Get(name string) (any, error) {
 	value, ok := step[name]
 	if !ok {
@@ -185,3 +617,143 @@ func (step ActionStep) Get(name string) (any, error) {
 
 	return value, nil
 }
+
+// Restricts the Merge Request by marking it as draft, removing all reviewers, adding a warning label and posting a notice
+type QuarantineAction struct {
+	BaseAction
+
+	// (Optional) The warning label to add.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Label string `json:"label,omitempty" yaml:"label" jsonschema:"default=quarantine"`
+
+	// (Optional) The notice to post on the Merge Request.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+}
+
+// Reverses the 'quarantine' action, restoring the draft status and reviewers the Merge Request had before
+type UnquarantineAction struct {
+	BaseAction
+
+	// (Optional) The warning label to remove. Must match the 'quarantine' action label.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Label string `json:"label,omitempty" yaml:"label" jsonschema:"default=quarantine"`
+
+	// (Optional) The text replacing the quarantine notice.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+}
+
+// Requires the Merge Request description to follow a template, by setting a commit status and (optionally) commenting or adding the missing sections
+type RequireDescriptionTemplateAction struct {
+	BaseAction
+
+	// The headings of the sections the description must have, with content (example: 'Summary').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Sections []string `json:"sections" yaml:"sections"`
+
+	// (Optional) Template placeholders that must be replaced (example: 'TODO: describe your change').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Placeholders []string `json:"placeholders,omitempty" yaml:"placeholders"`
+
+	// (Optional) Append the missing sections to the description.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Inject bool `json:"inject,omitempty" yaml:"inject"`
+
+	// (Optional) Content of the appended sections.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	InjectPlaceholder string `json:"inject_placeholder,omitempty" yaml:"inject_placeholder" jsonschema:"default=<!-- Please fill in this section -->"`
+
+	// (Optional) Set a commit status with the outcome of the check.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Status *bool `json:"status,omitempty" yaml:"status" jsonschema:"default=true"`
+
+	// (Optional) Name of the commit status.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	StatusName string `json:"status_name,omitempty" yaml:"status_name" jsonschema:"default=scm-engine/description-template"`
+
+	// (Optional) Comment posted when the description doesn't follow the template.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+
+	// (Optional) Text replacing the [message] comment once the description follows the template.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	ResolvedMessage string `json:"resolved_message,omitempty" yaml:"resolved_message"`
+}
+
+// Requires the Merge Request description to reference an issue, by setting a commit status and (optionally) commenting
+type RequireLinkedIssueAction struct {
+	BaseAction
+
+	// (Optional) Labels that bypass the check (example: 'no-issue-needed').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	BypassLabels []string `json:"bypass_labels,omitempty" yaml:"bypass_labels"`
+
+	// (Optional) Regular expression matching an issue reference, instead of GitLab issue references and URLs (example: a Jira key).
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Pattern string `json:"pattern,omitempty" yaml:"pattern"`
+
+	// (Optional) Set a commit status with the outcome of the check.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Status *bool `json:"status,omitempty" yaml:"status" jsonschema:"default=true"`
+
+	// (Optional) Name of the commit status.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	StatusName string `json:"status_name,omitempty" yaml:"status_name" jsonschema:"default=scm-engine/linked-issue"`
+
+	// (Optional) Comment posted when the Merge Request doesn't reference an issue.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+
+	// (Optional) Text replacing the [message] comment once an issue is referenced.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	ResolvedMessage string `json:"resolved_message,omitempty" yaml:"resolved_message"`
+}
+
+// Adds labels and (optionally) a comment to the issues closed by the Merge Request, when it's merged
+type UpdateLinkedIssuesAction struct {
+	BaseAction
+
+	// (Optional) The label names to add to the issues.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Labels []string `json:"labels,omitempty" yaml:"labels"`
+
+	// (Optional) An Expr Lang expression returning a list of label names to add to the issues (example: '["fixed-in::" + merge_request.target_branch]').
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Script string `json:"script,omitempty" yaml:"script"`
+
+	// (Optional) Comment posted on each issue, once per Merge Request.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Message string `json:"message,omitempty" yaml:"message"`
+	MessageKeyOptions
+
+	// (Optional) Also update the issues mentioned by the Merge Request, rather than only the issues it closes.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#actions.if.then.action
+	Related bool `json:"related,omitempty" yaml:"related" jsonschema:"default=false"`
+}
@@ -0,0 +1,60 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_Evaluate_RunOn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		runOn        string
+		triggerEvent string
+		want         bool
+	}{
+		{name: "open-only action runs on open", runOn: "open", triggerEvent: state.TriggerEventOpen, want: true},
+		{name: "open-only action doesn't run on update", runOn: "open", triggerEvent: state.TriggerEventUpdate, want: false},
+		{name: "open-only action doesn't run on note", runOn: "open", triggerEvent: state.TriggerEventNote, want: false},
+		{name: "open-only action doesn't run without webhook event", runOn: "open", want: false},
+		{name: "update action runs without webhook event", runOn: "update", want: true},
+		{name: "note action runs on note", runOn: "note", triggerEvent: state.TriggerEventNote, want: true},
+		{name: "any action runs on update", runOn: "any", triggerEvent: state.TriggerEventUpdate, want: true},
+		{name: "default runs on open", triggerEvent: state.TriggerEventOpen, want: true},
+		{name: "open-only action doesn't run on reopen", runOn: "open", triggerEvent: state.TriggerEventReopen, want: false},
+		{name: "reopen-only action runs on reopen", runOn: "reopen", triggerEvent: state.TriggerEventReopen, want: true},
+		{name: "reopen-only action doesn't run on open", runOn: "reopen", triggerEvent: state.TriggerEventOpen, want: false},
+		{name: "default runs on reopen", triggerEvent: state.TriggerEventReopen, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if len(tt.triggerEvent) > 0 {
+				ctx = state.WithTriggerEvent(ctx, tt.triggerEvent)
+			}
+
+			action := config.Action{Name: "welcome", If: "true", RunOn: tt.runOn}
+
+			ok, err := action.Evaluate(ctx, &testEvalContext{})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestAction_Evaluate_InvalidRunOn(t *testing.T) {
+	t.Parallel()
+
+	action := config.Action{Name: "welcome", If: "true", RunOn: "opened"}
+
+	_, err := action.Evaluate(context.Background(), &testEvalContext{})
+	require.ErrorContains(t, err, `unknown action [run_on] "opened"`)
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/stdlib"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+type BusinessHours struct {
+	// (Optional) The IANA timezone the business hours are in (example: 'Europe/Copenhagen')
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#business_hours.timezone
+	Timezone string `json:"timezone,omitempty" yaml:"timezone" jsonschema:"default=UTC"`
+
+	// (Optional) The weekly windows of business hours. Defaults to 09:00 to 17:00, Monday to Friday
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#business_hours.windows
+	Windows []BusinessHoursWindow `json:"windows,omitempty" yaml:"windows"`
+}
+
+type BusinessHoursWindow struct {
+	// The days of the week the window applies to (example: 'monday')
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#business_hours.windows
+	Days []string `json:"days" yaml:"days" jsonschema:"enum=monday,enum=tuesday,enum=wednesday,enum=thursday,enum=friday,enum=saturday,enum=sunday"`
+
+	// The start of the window, in 24 hour 'HH:MM' format (example: '09:00')
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#business_hours.windows
+	Start string `json:"start" yaml:"start"`
+
+	// The end of the window (exclusive), in 24 hour 'HH:MM' format (example: '17:00')
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#business_hours.windows
+	End string `json:"end" yaml:"end"`
+}
+
+// Parse returns the business hours for the script functions, or [stdlib.DefaultBusinessHours] if none are configured
+func (b *BusinessHours) Parse() (stdlib.BusinessHours, error) {
+	if b == nil {
+		return stdlib.DefaultBusinessHours, nil
+	}
+
+	result := stdlib.DefaultBusinessHours
+
+	if len(b.Timezone) > 0 {
+		location, err := time.LoadLocation(b.Timezone)
+		if err != nil {
+			return result, fmt.Errorf("business_hours: invalid 'timezone': %w", err)
+		}
+
+		result.Location = location
+	}
+
+	if len(b.Windows) == 0 {
+		return result, nil
+	}
+
+	result.Windows = nil
+
+	for i, window := range b.Windows {
+		parsed, err := window.parse()
+		if err != nil {
+			return result, fmt.Errorf("business_hours: invalid window #%d: %w", i+1, err)
+		}
+
+		result.Windows = append(result.Windows, parsed)
+	}
+
+	return result, nil
+}
+
+func (b *BusinessHours) Lint() error {
+	_, err := b.Parse()
+
+	return err
+}
+
+func (w BusinessHoursWindow) parse() (stdlib.BusinessHoursWindow, error) {
+	var result stdlib.BusinessHoursWindow
+
+	if len(w.Days) == 0 {
+		return result, errors.New("'days' must not be empty")
+	}
+
+	for _, name := range w.Days {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return result, fmt.Errorf("unknown day %q in 'days'", name)
+		}
+
+		result.Days = append(result.Days, day)
+	}
+
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return result, fmt.Errorf("invalid 'start': %w", err)
+	}
+
+	end, err := parseClock(w.End)
+	if err != nil {
+		return result, fmt.Errorf("invalid 'end': %w", err)
+	}
+
+	if end <= start {
+		return result, fmt.Errorf("'end' (%s) must be after 'start' (%s)", w.End, w.Start)
+	}
+
+	result.Start, result.End = start, end
+
+	return result, nil
+}
+
+// parseClock returns the time since midnight of a 24 hour 'HH:MM' clock, where '24:00' is the end of the day
+func parseClock(in string) (time.Duration, error) {
+	if in == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	clock, err := time.Parse("15:04", in)
+	if err != nil {
+		return 0, fmt.Errorf("%q must be in 24 hour 'HH:MM' format", in)
+	}
+
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
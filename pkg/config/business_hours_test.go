@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessHours_Parse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		config    string
+		wantZone  string
+		wantHours []stdlib.BusinessHoursWindow
+		wantErr   string
+	}{
+		{
+			name:      "defaults",
+			config:    `{}`,
+			wantZone:  "UTC",
+			wantHours: stdlib.DefaultBusinessHours.Windows,
+		},
+		{
+			name:      "timezone only",
+			config:    `business_hours: {timezone: Europe/Copenhagen}`,
+			wantZone:  "Europe/Copenhagen",
+			wantHours: stdlib.DefaultBusinessHours.Windows,
+		},
+		{
+			name: "windows",
+			config: `
+business_hours:
+  timezone: America/New_York
+  windows:
+    - days: [Monday, friday]
+      start: "08:30"
+      end: "12:00"
+    - days: [saturday]
+      start: "10:00"
+      end: "24:00"
+`,
+			wantZone: "America/New_York",
+			wantHours: []stdlib.BusinessHoursWindow{
+				{Days: []time.Weekday{time.Monday, time.Friday}, Start: 8*time.Hour + 30*time.Minute, End: 12 * time.Hour},
+				{Days: []time.Weekday{time.Saturday}, Start: 10 * time.Hour, End: 24 * time.Hour},
+			},
+		},
+		{
+			name:    "unknown timezone",
+			config:  `business_hours: {timezone: Mars/Olympus}`,
+			wantErr: "business_hours: invalid 'timezone'",
+		},
+		{
+			name:    "unknown day",
+			config:  `business_hours: {windows: [{days: [funday], start: "09:00", end: "17:00"}]}`,
+			wantErr: `business_hours: invalid window #1: unknown day "funday" in 'days'`,
+		},
+		{
+			name:    "invalid clock",
+			config:  `business_hours: {windows: [{days: [monday], start: "9am", end: "17:00"}]}`,
+			wantErr: `business_hours: invalid window #1: invalid 'start': "9am" must be in 24 hour 'HH:MM' format`,
+		},
+		{
+			name:    "end before start",
+			config:  `business_hours: {windows: [{days: [monday], start: "17:00", end: "09:00"}]}`,
+			wantErr: `business_hours: invalid window #1: 'end' (09:00) must be after 'start' (17:00)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.ParseFileString(tt.config)
+			require.NoError(t, err)
+
+			businessHours, err := cfg.BusinessHours.Parse()
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantZone, businessHours.Location.String())
+			require.Equal(t, tt.wantHours, businessHours.Windows)
+		})
+	}
+}
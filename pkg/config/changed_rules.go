@@ -0,0 +1,44 @@
+package config
+
+import (
+	"context"
+	"slices"
+)
+
+// changedRules are the labels and actions added or modified since the previous configuration file, by their [Diff] name
+type changedRules struct {
+	labels  []string
+	actions []string
+}
+
+// WithChangedRules limits the evaluation to the labels and actions added or modified in [diff], so re-evaluating
+// a Merge Request after a configuration change doesn't evaluate the rules that are the same as before.
+//
+// Labels and actions that are not evaluated are left as-is on the Merge Request
+func WithChangedRules(ctx context.Context, diff Diff) context.Context {
+	return context.WithValue(ctx, changedRulesKey, &changedRules{
+		labels:  slices.Concat(diff.Labels.Added, diff.Labels.Modified),
+		actions: slices.Concat(diff.Actions.Added, diff.Actions.Modified),
+	})
+}
+
+// isSelectedByChangedRules checks the label or action [name] against the changed rules set by [WithChangedRules].
+//
+// Returns the trace outcome to record if the label or action is filtered out
+func isSelectedByChangedRules(ctx context.Context, kind, name string) (bool, string) {
+	changed, ok := ctx.Value(changedRulesKey).(*changedRules)
+	if !ok {
+		return true, ""
+	}
+
+	names := changed.labels
+	if kind == "action" {
+		names = changed.actions
+	}
+
+	if slices.Contains(names, name) {
+		return true, ""
+	}
+
+	return false, "skipped (unchanged since the previous configuration file)"
+}
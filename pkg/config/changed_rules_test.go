@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_ChangedRules(t *testing.T) {
+	t.Parallel()
+
+	previous := &config.Config{
+		Labels: config.Labels{
+			{Name: "unchanged-label", Script: `true`},
+			{Name: "modified-label", Script: `false`},
+		},
+		Actions: config.Actions{
+			{Name: "unchanged-action", If: `true`},
+			{Name: "modified-action", If: `false`},
+		},
+	}
+
+	cfg := &config.Config{
+		Labels: config.Labels{
+			{Name: "unchanged-label", Script: `true`},
+			{Name: "modified-label", Script: `true`},
+			{Name: "added-label", Script: `true`},
+		},
+		Actions: config.Actions{
+			{Name: "unchanged-action", If: `true`},
+			{Name: "modified-action", If: `true`},
+			{Name: "added-action", If: `true`},
+		},
+	}
+
+	evaluate := func(ctx context.Context) ([]string, []string) {
+		labels, actions, err := cfg.Evaluate(ctx, &testEvalContext{})
+		require.NoError(t, err)
+
+		labelNames := []string{}
+		for _, label := range labels {
+			labelNames = append(labelNames, label.Name)
+		}
+
+		actionNames := []string{}
+		for _, action := range actions {
+			actionNames = append(actionNames, action.Name)
+		}
+
+		return labelNames, actionNames
+	}
+
+	t.Run("unchanged rules are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		labels, actions := evaluate(config.WithChangedRules(context.Background(), config.DiffConfigs(previous, cfg)))
+		require.Equal(t, []string{"modified-label", "added-label"}, labels)
+		require.Equal(t, []string{"modified-action", "added-action"}, actions)
+	})
+
+	t.Run("nothing is evaluated without changes", func(t *testing.T) {
+		t.Parallel()
+
+		labels, actions := evaluate(config.WithChangedRules(context.Background(), config.DiffConfigs(cfg, cfg)))
+		require.Empty(t, labels)
+		require.Empty(t, actions)
+	})
+
+	t.Run("all rules are evaluated without a diff", func(t *testing.T) {
+		t.Parallel()
+
+		labels, actions := evaluate(context.Background())
+		require.Equal(t, []string{"unchanged-label", "modified-label", "added-label"}, labels)
+		require.Equal(t, []string{"unchanged-action", "modified-action", "added-action"}, actions)
+	})
+}
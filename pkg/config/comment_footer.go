@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+)
+
+// CommentFooter is markdown appended to every comment scm-engine posts, so readers know the comment
+// is automated and where to find out more. It may contain "${{ script }}" segments, like a label name.
+type CommentFooter string
+
+// Render returns the footer with all "${{ script }}" segments evaluated
+func (footer CommentFooter) Render(evalContext scm.EvalContext) (string, error) {
+	segments, err := footer.compile(evalContext)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+
+	for _, segment := range segments {
+		if segment.program == nil {
+			result.WriteString(segment.literal)
+
+			continue
+		}
+
+		output, err := expr.Run(segment.program, evalContext)
+		if err != nil {
+			return "", fmt.Errorf("could not evaluate 'comment_footer' script %q: %w", segment.literal, err)
+		}
+
+		value, ok := output.(string)
+		if !ok {
+			return "", fmt.Errorf("'comment_footer' script %q must return a string, got %T", segment.literal, output)
+		}
+
+		result.WriteString(value)
+	}
+
+	return strings.TrimSpace(result.String()), nil
+}
+
+// Lint checks that all "${{ script }}" segments in the footer compile
+func (footer CommentFooter) Lint(evalContext scm.EvalContext) error {
+	_, err := footer.compile(evalContext)
+
+	return err
+}
+
+func (footer CommentFooter) compile(evalContext scm.EvalContext) ([]nameSegment, error) {
+	var (
+		text     = string(footer)
+		segments []nameSegment
+		offset   int
+	)
+
+	for _, match := range nameTemplateRegexp.FindAllStringSubmatchIndex(text, -1) {
+		if match[0] > offset {
+			segments = append(segments, nameSegment{literal: text[offset:match[0]]})
+		}
+
+		script := text[match[2]:match[3]]
+
+		opts := []expr.Option{}
+		opts = append(opts, expr.Env(evalContext))
+		opts = append(opts, stdlib.FunctionRenamer)
+		opts = append(opts, stdlib.Functions...)
+		opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+		program, err := expr.Compile(script, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile 'comment_footer' script %q into valid expr-lang syntax: %w", script, err)
+		}
+
+		segments = append(segments, nameSegment{literal: script, program: program})
+		offset = match[1]
+	}
+
+	if offset < len(text) {
+		segments = append(segments, nameSegment{literal: text[offset:]})
+	}
+
+	return segments, nil
+}
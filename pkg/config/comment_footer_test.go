@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentFooter_Render(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		footer  config.CommentFooter
+		want    string
+		wantErr string
+	}{
+		{
+			name: "empty footer",
+		},
+		{
+			name:   "plain markdown",
+			footer: "<sub>Posted by scm-engine</sub>\n",
+			want:   "<sub>Posted by scm-engine</sub>",
+		},
+		{
+			name:   "templated footer",
+			footer: "Posted by scm-engine for ${{ Bucket }} (${{ join(Modules, \", \") }})",
+			want:   "Posted by scm-engine for team-a (api, web)",
+		},
+		{
+			name:    "script must return a string",
+			footer:  "Posted by scm-engine for ${{ len(Modules) }}",
+			wantErr: `'comment_footer' script "len(Modules)" must return a string, got int`,
+		},
+		{
+			name:    "invalid script",
+			footer:  "Posted by scm-engine for ${{ Bucket + }}",
+			wantErr: `could not compile 'comment_footer' script "Bucket +" into valid expr-lang syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			evalContext := &testEvalContext{Bucket: "team-a", Modules: []string{"api", "web"}}
+
+			got, err := tt.footer.Render(evalContext)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, config.Config{CommentFooter: tt.footer}.Lint(context.Background(), evalContext))
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,23 +2,72 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
 	slogctx "github.com/veqryn/slog-context"
 )
 
+// ErrProjectDisabled is returned when the configuration of the project has 'enabled: false'
+var ErrProjectDisabled = errors.New("project has disabled scm-engine in its configuration file; ignoring")
+
 type Config struct {
+	// (Optional) When off, scm-engine doesn't process the project at all, so teams can opt out without central changes
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#enabled
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled" jsonschema:"default=true"`
+
 	// (Optional) When on, no actions will be taken, but instead logged for review
 	DryRun *bool `json:"dry_run,omitempty" yaml:"dry_run" jsonschema:"default=false"`
 
+	// (Optional) When on, a collapsed comment with the outcome of every label and action script is kept up to date on the Merge Request.
+	//
+	// Can also be turned on for a single Merge Request by adding the 'scm-engine:debug' label to it.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#debug_comment
+	DebugComment bool `json:"debug_comment,omitempty" yaml:"debug_comment" jsonschema:"default=false"`
+
+	// (Optional) A single comment summarizing the applied labels, the action outcomes and any errors, updated in place on every evaluation
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#report_comment
+	ReportComment *ReportComment `json:"report_comment,omitempty" yaml:"report_comment"`
+
 	// (Optional) Import configuration from other git repositories
 	//
 	// See: https://jippi.github.io/scm-engine/configuration/#include
 	Includes []Include `json:"include,omitempty" yaml:"include"`
 
+	// (Optional) How deep included configuration files may include other configuration files
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#max_include_depth
+	MaxIncludeDepth int `json:"max_include_depth,omitempty" yaml:"max_include_depth" jsonschema:"default=5"`
+
+	// (Optional) When on (the default), a label or action script that fails to evaluate fails the whole evaluation,
+	// after all remaining scripts have been evaluated so every error is reported. When off, failing labels and actions are skipped.
+	//
+	// Can be overridden for a single label or action with its own 'strict_errors' setting.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#strict_errors
+	StrictErrors *bool `json:"strict_errors,omitempty" yaml:"strict_errors" jsonschema:"default=true"`
+
+	// (Optional) When on, scm-engine only adds and removes the labels declared in the 'label' settings, and never
+	// touches other labels on the Merge Request, for example labels added by a human or by 'add_label' action steps.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#managed_labels_only
+	ManagedLabelsOnly *bool `json:"managed_labels_only,omitempty" yaml:"managed_labels_only" jsonschema:"default=false"`
+
+	// (Optional) When on, a 'label' or 'actions' section that fails to evaluate doesn't prevent the other section from being applied.
+	// The failed sections are reported per section, and still fail the evaluation once the other section has been applied.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#isolate_section_errors
+	IsolateSectionErrors *bool `json:"isolate_section_errors,omitempty" yaml:"isolate_section_errors" jsonschema:"default=false"`
+
 	// (Optional) Configure what users that should be ignored when considering activity on a Merge Request
 	//
 	// SCM-Engine defines activity as comments, reviews, commits, adding/removing labels and similar actions made on a change request.
@@ -26,24 +75,128 @@ type Config struct {
 	// See: https://jippi.github.io/scm-engine/configuration/#ignore_activity_from
 	IgnoreActivityFrom IgnoreActivityFrom `json:"ignore_activity_from,omitempty" yaml:"ignore_activity_from"`
 
+	// (Optional) Named on/off switches that labels and actions can be guarded by using their 'feature_flag' setting.
+	//
+	// A feature flag can be overridden with the 'SCM_ENGINE_FEATURE_FLAG_<NAME>' environment variable.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#feature_flags
+	FeatureFlags FeatureFlags `json:"feature_flags,omitempty" yaml:"feature_flags"`
+
+	// (Optional) Action types (e.g. 'merge' or 'approve') to skip while the Merge Request is a draft.
+	// The steps run again once the Merge Request is marked as ready, while other steps and labels are not affected.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#skip_actions_while_draft
+	SkipActionsWhileDraft []string `json:"skip_actions_while_draft,omitempty" yaml:"skip_actions_while_draft"`
+
+	// (Optional) Named Expr Lang expressions computed once per Merge Request, and available to all other scripts as 'vars.<name>'
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#vars
+	Vars Vars `json:"vars,omitempty" yaml:"vars"`
+
+	// (Optional) Markdown appended to every comment, suggestion and note scm-engine posts, so readers know the comment is automated.
+	//
+	// May contain "${{ script }}" Expr Lang segments, for example, to link to the configuration file.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#comment_footer
+	CommentFooter CommentFooter `json:"comment_footer,omitempty" yaml:"comment_footer"`
+
+	// (Optional) Message strings per locale, used by the 'message_key' of action steps, so comments can be posted in the language of the team or author.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#locales
+	Locales *Locales `json:"locales,omitempty" yaml:"locales"`
+
+	// (Optional) The timezone and weekly windows used by the 'is_business_hours()' and 'business_days_since()' script functions
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#business_hours
+	BusinessHours *BusinessHours `json:"business_hours,omitempty" yaml:"business_hours"`
+
+	// (Optional) Deploy freeze periods used by the 'in_deploy_freeze()' script function, and optionally to skip merges during a freeze
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze
+	DeployFreeze *DeployFreeze `json:"deploy_freeze,omitempty" yaml:"deploy_freeze"`
+
+	// (Optional) The thresholds and excluded files used by the 'size_bucket()' script function
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#size_buckets
+	SizeBuckets *SizeBuckets `json:"size_buckets,omitempty" yaml:"size_buckets"`
+
+	// (Optional) Named sets of overrides applied on top of this configuration, selected at runtime with the '--profile' flag
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#profiles
+	Profiles Profiles `json:"profiles,omitempty" yaml:"profiles"`
+
 	// (Optional) Actions can modify a Merge Request in various ways, for example, adding a comment or closing the Merge Request.
 	//
 	// See: https://jippi.github.io/scm-engine/configuration/#actions
 	Actions Actions `json:"actions,omitempty" yaml:"actions"`
 
+	// (Optional) Actions that only run when the Merge Request is merged, for example, to create follow-up issues.
+	//
+	// When configured, merge events only evaluate these actions (and 'actions' with 'run_on: merge'), and no labels.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#on_merge
+	OnMerge Actions `json:"on_merge,omitempty" yaml:"on_merge"`
+
+	// (Optional) Actions that run on issue events, for example, to move misfiled issues with 'move_to_project'.
+	//
+	// Scripts are evaluated against the issue, and Merge Request actions can't be used.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#on_issue
+	OnIssue Actions `json:"on_issue,omitempty" yaml:"on_issue"`
+
 	// (Optional) Labels are a way to categorize and filter issues, merge requests, and epics in GitLab. -- GitLab documentation
 	//
 	// See: https://jippi.github.io/scm-engine/configuration/#label
 	Labels Labels `json:"label,omitempty" yaml:"label"`
 }
 
-func (c Config) Lint(_ context.Context, evalContext scm.EvalContext) error {
+func (c Config) Lint(ctx context.Context, evalContext scm.EvalContext) error {
 	var errors error
 
-	for _, action := range c.Actions {
+	if err := c.Vars.Lint(evalContext); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.validateSkipActionsWhileDraft(); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.CommentFooter.Lint(evalContext); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.BusinessHours.Lint(); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.SizeBuckets.Lint(); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.DeployFreeze.Lint(); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.ReportComment.Lint(); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	if err := c.Locales.Lint(evalContext, slices.Concat(c.Actions, c.OnMerge)); err != nil {
+		errors = multierror.Append(errors, err)
+	}
+
+	for _, action := range slices.Concat(c.Actions, c.OnMerge) {
 		if _, err := action.Setup(evalContext); err != nil {
 			errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %w", action.Name, err))
 		}
+
+		if name, ok := action.issueOnlyStep(); ok {
+			errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %q only applies to issues, use it in 'on_issue' instead", action.Name, name))
+		}
+
+		if _, err := compileEnabled(action.Enabled, evalContext); err != nil {
+			errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %w", action.Name, err))
+		}
 	}
 
 	for _, label := range c.Labels {
@@ -52,25 +205,125 @@ func (c Config) Lint(_ context.Context, evalContext scm.EvalContext) error {
 		}
 	}
 
+	for name, profile := range c.Profiles {
+		profileConfig := Config{Actions: profile.Actions, Labels: profile.Labels}
+
+		if err := profileConfig.Lint(ctx, evalContext); err != nil {
+			errors = multierror.Append(errors, fmt.Errorf("Profile %q failed validation: %w", name, err))
+		}
+	}
+
 	return errors
 }
 
 func (c Config) Evaluate(ctx context.Context, evalContext scm.EvalContext) ([]scm.EvaluationResult, []Action, error) {
-	slogctx.Info(ctx, "Evaluating labels")
+	ctx = withFeatureFlags(ctx, c.FeatureFlags)
+
+	slogctx.Info(ctx, "Evaluating vars")
 
-	labels, err := c.Labels.Evaluate(ctx, evalContext)
-	if err != nil {
+	if err := c.Vars.Evaluate(ctx, evalContext); err != nil {
 		return nil, nil, fmt.Errorf("evaluation failed: %w", err)
 	}
 
+	ctx = withStrictErrors(ctx, c.StrictErrors == nil || *c.StrictErrors)
+
+	// Merge events only run the 'on_merge' actions, rather than re-running the rules for Merge Request updates
+	if state.TriggerEvent(ctx) == state.TriggerEventMerge && len(c.OnMerge) > 0 {
+		slogctx.Info(ctx, "Evaluating on_merge Actions")
+
+		actions, err := c.mergeActions().Evaluate(ctx, evalContext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, actions, nil
+	}
+
+	slogctx.Info(ctx, "Evaluating labels")
+
+	labels, labelsErr := c.Labels.Evaluate(ctx, evalContext)
+
+	// Evaluate the actions even if the labels failed, so every error is reported at once
 	slogctx.Info(ctx, "Evaluating Actions")
 
-	actions, err := c.Actions.Evaluate(ctx, evalContext)
-	if err != nil {
-		return nil, nil, err
+	actions, actionsErr := c.Actions.Evaluate(ctx, evalContext)
+
+	// Apply the sections that evaluated fine, and report the failed ones
+	if c.IsolateSectionErrors != nil && *c.IsolateSectionErrors && (labelsErr != nil || actionsErr != nil) {
+		sectionErrors := SectionErrors{}
+
+		if labelsErr != nil {
+			slogctx.Error(ctx, "Skipping the 'label' section that failed to evaluate", slog.Any("error", labelsErr))
+
+			sectionErrors[SectionLabels], labels = labelsErr, nil
+		}
+
+		if actionsErr != nil {
+			slogctx.Error(ctx, "Skipping the 'actions' section that failed to evaluate", slog.Any("error", actionsErr))
+
+			sectionErrors[SectionActions], actions = actionsErr, nil
+		}
+
+		return labels, c.skipMergesDuringDeployFreeze(ctx, c.skipActionsWhileDraft(ctx, evalContext, actions)), sectionErrors
+	}
+
+	if labelsErr != nil {
+		return nil, nil, fmt.Errorf("evaluation failed: %w", multierror.Append(labelsErr, actionsErr))
+	}
+
+	if actionsErr != nil {
+		return nil, nil, actionsErr
+	}
+
+	return labels, c.skipMergesDuringDeployFreeze(ctx, c.skipActionsWhileDraft(ctx, evalContext, actions)), nil
+}
+
+// EvaluateIssue evaluates the 'on_issue' actions for an issue event, and returns the ones that matched.
+//
+// The 'vars' scripts are written for Merge Requests, so they aren't evaluated
+func (c Config) EvaluateIssue(ctx context.Context, evalContext scm.EvalContext) ([]Action, error) {
+	ctx = withFeatureFlags(ctx, c.FeatureFlags)
+	ctx = withStrictErrors(ctx, c.StrictErrors == nil || *c.StrictErrors)
+
+	slogctx.Info(ctx, "Evaluating on_issue Actions")
+
+	return c.OnIssue.Evaluate(ctx, evalContext)
+}
+
+// LintIssue validates the 'on_issue' actions against the issue [evalContext]
+func (c Config) LintIssue(evalContext scm.EvalContext) error {
+	var errors error
+
+	for _, action := range c.OnIssue {
+		if _, err := action.Setup(evalContext); err != nil {
+			errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %w", action.Name, err))
+		}
+
+		if _, err := compileEnabled(action.Enabled, evalContext); err != nil {
+			errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %w", action.Name, err))
+		}
+
+		for _, step := range action.Then {
+			if name, _ := step.RequiredString("action"); !slices.Contains(issueActions, name) {
+				errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %q can't be used in 'on_issue', only %s applies to issues", action.Name, name, strings.Join(issueActions, ", ")))
+			}
+		}
 	}
 
-	return labels, actions, nil
+	return errors
+}
+
+// mergeActions returns the 'on_merge' actions, followed by the actions explicitly running on merge events
+func (c Config) mergeActions() Actions {
+	actions := slices.Clone(c.OnMerge)
+
+	for _, action := range c.Actions {
+		if action.RunOn == state.TriggerEventMerge {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions
 }
 
 func (c *Config) LoadIncludes(ctx context.Context, client scm.Client) error {
@@ -82,50 +335,188 @@ func (c *Config) LoadIncludes(ctx context.Context, client scm.Client) error {
 	// Update logger with a friendly tag to differentiate the events within
 	ctx = slogctx.With(ctx, slog.String("phase", "remote_include"))
 
+	if err := c.loadIncludes(ctx, client, c.Includes, nil); err != nil {
+		return err
+	}
+
+	slogctx.Debug(ctx, "Done loading remote configuration files")
+
+	return nil
+}
+
+// loadIncludes reads the [includes] and merges them into the configuration, followed by any includes of their own.
+//
+// [chain] is the list of files that included the [includes], used to detect cycles and enforce 'max_include_depth'
+func (c *Config) loadIncludes(ctx context.Context, client scm.Client, includes []Include, chain []string) error {
+	maxDepth := c.MaxIncludeDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxIncludeDepth
+	}
+
 	// For each project, do a read of all the files we need
-	for _, include := range c.Includes {
+	for _, include := range includes {
 		ctx := slogctx.With(ctx, slog.Any("remote_include_config", include))
 
 		slogctx.Debug(ctx, fmt.Sprintf("Loading remote configuration from project %q", include.Project))
 
+		// Check the chain before reading any files, so a cycle doesn't keep querying the same files
+		for _, fileName := range include.Files {
+			source := include.source(fileName)
+
+			if slices.Contains(chain, source) {
+				return fmt.Errorf("include cycle detected: %s", strings.Join(append(slices.Clone(chain), source), " -> "))
+			}
+
+			if len(chain) >= maxDepth {
+				return fmt.Errorf("include depth limit of %d exceeded (see 'max_include_depth'): %s", maxDepth, strings.Join(append(slices.Clone(chain), source), " -> "))
+			}
+		}
+
 		files, err := client.GetProjectFiles(ctx, include.Project, include.Ref, include.Files)
 		if err != nil {
 			return fmt.Errorf("failed to load included config files from project [%s]: %w", include.Project, err)
 		}
 
-		for fileName, fileContent := range files {
-			remoteConfig, err := ParseFileString(fileContent)
+		for _, fileName := range include.Files {
+			remoteConfig, err := ParseFileString(files[fileName])
 			if err != nil {
 				return fmt.Errorf("failed to parse remote config file [%s] from project [%s]: %w", fileName, include.Project, err)
 			}
 
-			// Disallow nested includes
+			c.include(ctx, remoteConfig, fmt.Sprintf("file [%s] from project [%s]", fileName, include.Project))
+
+			// Load the includes of the included file
 			if len(remoteConfig.Includes) != 0 {
-				slogctx.Warn(ctx, fmt.Sprintf("file [%s] from project [%s] may not have any 'include' settings; Recursive include is not supported", fileName, include.Project))
+				if err := c.loadIncludes(ctx, client, remoteConfig.Includes, append(slices.Clone(chain), include.source(fileName))); err != nil {
+					return err
+				}
 			}
+		}
+	}
 
-			// Disallow changing dry run
-			if remoteConfig.DryRun != nil {
-				slogctx.Warn(ctx, fmt.Sprintf("file [%s] from project [%s] may not have a 'dry_run' setting; Remote include are not allowed to change this setting", fileName, include.Project))
-			}
+	return nil
+}
 
-			// Append actions
-			if len(remoteConfig.Actions) != 0 {
-				slogctx.Debug(ctx, fmt.Sprintf("file [%s] from project [%s] added %d new actions to the config file", fileName, include.Project, len(remoteConfig.Actions)))
+// include merges an included configuration file, described by [source] in the logs, into the configuration.
+//
+// Actions and labels are appended, while the configuration takes precedence for feature flags and vars.
+func (c *Config) include(ctx context.Context, remoteConfig *Config, source string) {
+	// Disallow profiles
+	if len(remoteConfig.Profiles) != 0 {
+		slogctx.Warn(ctx, fmt.Sprintf("%s may not have a 'profiles' setting; Profiles are only supported in the project configuration file", source))
+	}
 
-				c.Actions = append(c.Actions, remoteConfig.Actions...)
-			}
+	// Disallow changing dry run
+	if remoteConfig.DryRun != nil {
+		slogctx.Warn(ctx, fmt.Sprintf("%s may not have a 'dry_run' setting; Included configuration files are not allowed to change this setting", source))
+	}
 
-			// Append labels
-			if len(remoteConfig.Labels) != 0 {
-				slogctx.Debug(ctx, fmt.Sprintf("file [%s] from project [%s] added %d new labels to the config file", fileName, include.Project, len(remoteConfig.Labels)))
+	// Disallow changing the include depth
+	if remoteConfig.MaxIncludeDepth != 0 {
+		slogctx.Warn(ctx, fmt.Sprintf("%s may not have a 'max_include_depth' setting; Only the project configuration file can change this setting", source))
+	}
 
-				c.Labels = append(c.Labels, remoteConfig.Labels...)
-			}
+	// Append actions
+	if len(remoteConfig.Actions) != 0 {
+		slogctx.Debug(ctx, fmt.Sprintf("%s added %d new actions to the config file", source, len(remoteConfig.Actions)))
+
+		c.Actions = append(c.Actions, remoteConfig.Actions...)
+	}
+
+	// Append merge actions
+	if len(remoteConfig.OnMerge) != 0 {
+		slogctx.Debug(ctx, fmt.Sprintf("%s added %d new on_merge actions to the config file", source, len(remoteConfig.OnMerge)))
+
+		c.OnMerge = append(c.OnMerge, remoteConfig.OnMerge...)
+	}
+
+	// Append issue actions
+	if len(remoteConfig.OnIssue) != 0 {
+		slogctx.Debug(ctx, fmt.Sprintf("%s added %d new on_issue actions to the config file", source, len(remoteConfig.OnIssue)))
+
+		c.OnIssue = append(c.OnIssue, remoteConfig.OnIssue...)
+	}
+
+	// Append labels
+	if len(remoteConfig.Labels) != 0 {
+		slogctx.Debug(ctx, fmt.Sprintf("%s added %d new labels to the config file", source, len(remoteConfig.Labels)))
+
+		c.Labels = append(c.Labels, remoteConfig.Labels...)
+	}
+
+	// Add feature flags, the project configuration file takes precedence
+	for name, enabled := range remoteConfig.FeatureFlags {
+		if _, ok := c.FeatureFlags[name]; ok {
+			continue
+		}
+
+		if c.FeatureFlags == nil {
+			c.FeatureFlags = FeatureFlags{}
 		}
+
+		c.FeatureFlags[name] = enabled
 	}
 
-	slogctx.Debug(ctx, "Done loading remote configuration files")
+	// Add vars, the project configuration file takes precedence
+	for name, script := range remoteConfig.Vars {
+		if _, ok := c.Vars[name]; ok {
+			continue
+		}
 
-	return nil
+		if c.Vars == nil {
+			c.Vars = Vars{}
+		}
+
+		c.Vars[name] = script
+	}
+
+	// Use the comment footer, unless the project configuration file has its own
+	if len(c.CommentFooter) == 0 {
+		c.CommentFooter = remoteConfig.CommentFooter
+	}
+
+	// Use the business hours, unless the project configuration file has its own
+	if c.BusinessHours == nil {
+		c.BusinessHours = remoteConfig.BusinessHours
+	}
+
+	// Use the deploy freezes, unless the project configuration file has its own
+	if c.DeployFreeze == nil {
+		c.DeployFreeze = remoteConfig.DeployFreeze
+	}
+
+	if c.SizeBuckets == nil {
+		c.SizeBuckets = remoteConfig.SizeBuckets
+	}
+
+	// Add the locales messages, the project configuration file takes precedence
+	c.Locales = c.Locales.merge(remoteConfig.Locales)
+
+	if c.ReportComment == nil {
+		c.ReportComment = remoteConfig.ReportComment
+	}
+
+	// Use the error handling, unless the project configuration file has its own
+	if c.StrictErrors == nil {
+		c.StrictErrors = remoteConfig.StrictErrors
+	}
+
+	if c.IsolateSectionErrors == nil {
+		c.IsolateSectionErrors = remoteConfig.IsolateSectionErrors
+	}
+
+	if c.ManagedLabelsOnly == nil {
+		c.ManagedLabelsOnly = remoteConfig.ManagedLabelsOnly
+	}
+
+	// Use the processing toggle, unless the project configuration file has its own, so a global configuration file
+	// with 'enabled: false' makes processing opt-in
+	if c.Enabled == nil {
+		c.Enabled = remoteConfig.Enabled
+	}
+}
+
+// IsEnabled returns whether the project should be processed, which is the default
+func (c Config) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+)
+
+// ConfigChangeNoticeMarker is the hidden marker used to find (and update) the notice posted when a
+// Merge Request changes the configuration file
+const ConfigChangeNoticeMarker = "<!-- scm-engine:config-change -->"
+
+// ConfigChangePolicy controls what happens when a Merge Request changes the configuration file itself,
+// since the change could weaken the policy it's evaluated with
+type ConfigChangePolicy string
+
+const (
+	// ConfigChangeAllow uses the changed configuration file, without any notice (default)
+	ConfigChangeAllow ConfigChangePolicy = "allow"
+
+	// ConfigChangeNotice uses the changed configuration file, and posts a notice on the Merge Request
+	ConfigChangeNotice ConfigChangePolicy = "notice"
+
+	// ConfigChangeRequireApproval uses the configuration file of the target branch until the Merge Request is approved,
+	// and posts a notice on the Merge Request
+	ConfigChangeRequireApproval ConfigChangePolicy = "require_approval"
+
+	// ConfigChangeUseTarget always uses the configuration file of the target branch, and posts a notice on the Merge Request
+	ConfigChangeUseTarget ConfigChangePolicy = "use_target"
+)
+
+// ParseConfigChangePolicy validates and converts the input into a [ConfigChangePolicy]
+func ParseConfigChangePolicy(in string) (ConfigChangePolicy, error) {
+	switch policy := ConfigChangePolicy(in); policy {
+	case ConfigChangeAllow, ConfigChangeNotice, ConfigChangeRequireApproval, ConfigChangeUseTarget:
+		return policy, nil
+
+	case "":
+		return ConfigChangeAllow, nil
+
+	default:
+		return "", fmt.Errorf("unknown config change policy %q; must be one of %q, %q, %q or %q", in, ConfigChangeAllow, ConfigChangeNotice, ConfigChangeRequireApproval, ConfigChangeUseTarget)
+	}
+}
+
+// TrustChangedConfig returns whether the configuration file changed by the Merge Request may be used,
+// where [approved] is whether the Merge Request has been approved
+func (policy ConfigChangePolicy) TrustChangedConfig(approved bool) bool {
+	switch policy {
+	case ConfigChangeRequireApproval:
+		return approved
+
+	case ConfigChangeUseTarget:
+		return false
+
+	default:
+		return true
+	}
+}
+
+// Notice returns the notice posted on a Merge Request changing the configuration file at [path],
+// or false if the policy doesn't post any notice
+func (policy ConfigChangePolicy) Notice(path string) (string, bool) {
+	var outcome string
+
+	switch policy {
+	case ConfigChangeNotice:
+		outcome = "The changed configuration file is used to evaluate this Merge Request, so please review the policy changes carefully."
+
+	case ConfigChangeRequireApproval:
+		outcome = "The configuration file of the target branch is used to evaluate this Merge Request until it has been approved."
+
+	case ConfigChangeUseTarget:
+		outcome = "The configuration file of the target branch is used to evaluate this Merge Request, so the changes only take effect once merged."
+
+	default:
+		return "", false
+	}
+
+	return fmt.Sprintf("%s\n:warning: This Merge Request changes the scm-engine configuration file `%s`. %s", ConfigChangeNoticeMarker, path, outcome), true
+}
@@ -0,0 +1,29 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigChangePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := config.ParseConfigChangePolicy("")
+	require.NoError(t, err)
+	require.Equal(t, config.ConfigChangeAllow, policy)
+
+	_, err = config.ParseConfigChangePolicy("block")
+	require.ErrorContains(t, err, `unknown config change policy "block"`)
+
+	// No notice when changes are allowed
+	_, ok := config.ConfigChangeAllow.Notice(".scm-engine.yml")
+	require.False(t, ok)
+
+	notice, ok := config.ConfigChangeRequireApproval.Notice(".scm-engine.yml")
+	require.True(t, ok)
+	require.Contains(t, notice, config.ConfigChangeNoticeMarker)
+	require.Contains(t, notice, "changes the scm-engine configuration file `.scm-engine.yml`")
+	require.Contains(t, notice, "until it has been approved")
+}
@@ -8,6 +8,8 @@ type contextKey uint
 
 const (
 	configKey contextKey = iota
+	strictErrorsKey
+	changedRulesKey
 )
 
 func WithConfig(ctx context.Context, config *Config) context.Context {
@@ -17,3 +19,20 @@ func WithConfig(ctx context.Context, config *Config) context.Context {
 func FromContext(ctx context.Context) *Config {
 	return ctx.Value(configKey).(*Config) //nolint:forcetypeassert
 }
+
+func withStrictErrors(ctx context.Context, strict bool) context.Context {
+	return context.WithValue(ctx, strictErrorsKey, strict)
+}
+
+// isStrictErrors reports whether a script error fails the evaluation, using the label or action [override] if set.
+//
+// Scripts are strict, unless turned off with the 'strict_errors' setting
+func isStrictErrors(ctx context.Context, override *bool) bool {
+	if override != nil {
+		return *override
+	}
+
+	strict, ok := ctx.Value(strictErrorsKey).(bool)
+
+	return !ok || strict
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// mergeActionTypes are the action types skipped during a deploy freeze with 'block_merges'
+var mergeActionTypes = []string{"merge", "add_to_merge_train"}
+
+type DeployFreeze struct {
+	// (Optional) Also use the deploy freeze periods of the GitLab project (Settings → CI/CD → Deploy freezes)
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze.gitlab
+	GitLab bool `json:"gitlab,omitempty" yaml:"gitlab" jsonschema:"default=false"`
+
+	// (Optional) Skip the 'merge' and 'add_to_merge_train' action steps during a deploy freeze
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze.block_merges
+	BlockMerges bool `json:"block_merges,omitempty" yaml:"block_merges" jsonschema:"default=false"`
+
+	// (Optional) The deploy freeze periods, in the same format as the GitLab deploy freezes
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze.periods
+	Periods []DeployFreezePeriod `json:"periods,omitempty" yaml:"periods"`
+}
+
+type DeployFreezePeriod struct {
+	// The start of the freeze, as a cron expression (example: '0 23 * * 5' for Friday at 23:00)
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze.periods
+	FreezeStart string `json:"freeze_start" yaml:"freeze_start"`
+
+	// The end of the freeze, as a cron expression (example: '0 7 * * 1' for Monday at 07:00)
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze.periods
+	FreezeEnd string `json:"freeze_end" yaml:"freeze_end"`
+
+	// (Optional) The IANA timezone of the cron expressions (example: 'Europe/Copenhagen')
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#deploy_freeze.periods
+	CronTimezone string `json:"cron_timezone,omitempty" yaml:"cron_timezone" jsonschema:"default=UTC"`
+}
+
+// Parse returns the configured deploy freeze periods for the script functions
+func (d *DeployFreeze) Parse() (stdlib.DeployFreezes, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	freezes := make(stdlib.DeployFreezes, 0, len(d.Periods))
+
+	for i, period := range d.Periods {
+		freeze, err := stdlib.NewDeployFreeze(period.FreezeStart, period.FreezeEnd, period.CronTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("deploy_freeze: invalid period #%d: %w", i+1, err)
+		}
+
+		freezes = append(freezes, freeze)
+	}
+
+	return freezes, nil
+}
+
+// Lint validates the deploy freeze periods
+func (d *DeployFreeze) Lint() error {
+	_, err := d.Parse()
+
+	return err
+}
+
+// UseGitLab returns whether the deploy freeze periods of the GitLab project are used
+func (d *DeployFreeze) UseGitLab() bool {
+	return d != nil && d.GitLab
+}
+
+// skipMergesDuringDeployFreeze removes the 'merge' and 'add_to_merge_train' steps from the [actions] during a
+// deploy freeze (see [stdlib.WithDeployFreezes]), if 'block_merges' is on. Actions without any steps left are dropped.
+func (c Config) skipMergesDuringDeployFreeze(ctx context.Context, actions []Action) []Action {
+	if c.DeployFreeze == nil || !c.DeployFreeze.BlockMerges || !stdlib.DeployFreezesFromContext(ctx).Active(time.Now()) {
+		return actions
+	}
+
+	results := make([]Action, 0, len(actions))
+
+	for _, action := range actions {
+		var skipped []string
+
+		steps := slices.DeleteFunc(slices.Clone(action.Then), func(step ActionStep) bool {
+			name, _ := step.OptionalString("action", "")
+			if !slices.Contains(mergeActionTypes, name) {
+				return false
+			}
+
+			skipped = append(skipped, name)
+
+			return true
+		})
+
+		if len(skipped) == 0 {
+			results = append(results, action)
+
+			continue
+		}
+
+		slogctx.Info(ctx, "Skipping merge action steps during a deploy freeze", slog.String("action_name", action.Name), slog.Any("skipped_steps", skipped))
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: action.Name, Outcome: fmt.Sprintf("skipped during deploy freeze (%s)", strings.Join(skipped, ", "))})
+
+		if len(steps) == 0 {
+			continue
+		}
+
+		action.Then = steps
+		results = append(results, action)
+	}
+
+	return results
+}
@@ -0,0 +1,87 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_DeployFreeze(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{
+		DeployFreeze: &config.DeployFreeze{
+			BlockMerges: true,
+			Periods:     []config.DeployFreezePeriod{{FreezeStart: "0 0 1 1 *", FreezeEnd: "* * * * *", CronTimezone: "Europe/Copenhagen"}},
+		},
+		Actions: config.Actions{
+			{Name: "auto merge", If: "true", Then: []config.ActionStep{{"action": "merge"}}},
+			{Name: "merge train and comment", If: "true", Then: []config.ActionStep{{"action": "add_to_merge_train"}, {"action": "comment", "message": "queued"}}},
+			{Name: "approve", If: "true", Then: []config.ActionStep{{"action": "approve"}}},
+		},
+	}
+
+	require.NoError(t, cfg.Lint(context.Background(), &testEvalContext{}))
+
+	// Starting on new year, and ending every minute, is (nearly) always frozen
+	frozen, err := cfg.DeployFreeze.Parse()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		freezes   stdlib.DeployFreezes
+		wantSteps map[string][]string
+	}{
+		{
+			name:    "in freeze skips merge actions",
+			freezes: frozen,
+			wantSteps: map[string][]string{
+				"merge train and comment": {"comment"},
+				"approve":                 {"approve"},
+			},
+		},
+		{
+			name: "out of freeze runs all actions",
+			wantSteps: map[string][]string{
+				"auto merge":              {"merge"},
+				"merge train and comment": {"add_to_merge_train", "comment"},
+				"approve":                 {"approve"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := stdlib.WithDeployFreezes(context.Background(), tt.freezes)
+
+			_, actions, err := cfg.Evaluate(ctx, &testEvalContext{})
+			require.NoError(t, err)
+
+			steps := map[string][]string{}
+
+			for _, action := range actions {
+				for _, step := range action.Then {
+					name, err := step.RequiredString("action")
+					require.NoError(t, err)
+
+					steps[action.Name] = append(steps[action.Name], name)
+				}
+			}
+
+			require.Equal(t, tt.wantSteps, steps)
+		})
+	}
+}
+
+func TestDeployFreeze_Lint(t *testing.T) {
+	t.Parallel()
+
+	freeze := &config.DeployFreeze{Periods: []config.DeployFreezePeriod{{FreezeStart: "0 23 * * 5", FreezeEnd: "every monday"}}}
+
+	require.ErrorContains(t, freeze.Lint(), `deploy_freeze: invalid period #1: invalid freeze end "every monday"`)
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Diff is the rule-level difference between two configuration files
+type Diff struct {
+	Labels  DiffEntries
+	Actions DiffEntries
+}
+
+// DiffEntries are the added, removed and modified rules, identified by their name
+type DiffEntries struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// HasChanges returns whether any rules were added, removed or modified
+func (d Diff) HasChanges() bool {
+	return d.Labels.hasChanges() || d.Actions.hasChanges()
+}
+
+// Write writes a human readable report of the difference to [w]
+func (d Diff) Write(w io.Writer) {
+	if !d.HasChanges() {
+		fmt.Fprintln(w, "No label or action changes")
+
+		return
+	}
+
+	d.Labels.write(w, "Labels")
+	d.Actions.write(w, "Actions")
+}
+
+func (entries DiffEntries) hasChanges() bool {
+	return len(entries.Added)+len(entries.Removed)+len(entries.Modified) > 0
+}
+
+func (entries DiffEntries) write(w io.Writer, title string) {
+	if !entries.hasChanges() {
+		return
+	}
+
+	fmt.Fprintf(w, "%s:\n", title)
+
+	for _, name := range entries.Added {
+		fmt.Fprintf(w, "  + %s\n", name)
+	}
+
+	for _, name := range entries.Removed {
+		fmt.Fprintf(w, "  - %s\n", name)
+	}
+
+	for _, name := range entries.Modified {
+		fmt.Fprintf(w, "  ~ %s\n", name)
+	}
+}
+
+// DiffConfigs compares the labels and actions in the [previous] and [next] configuration files
+func DiffConfigs(previous, next *Config) Diff {
+	return Diff{
+		Labels:  diffRules(labelRules(previous.Labels), labelRules(next.Labels)),
+		Actions: diffRules(actionRules(previous.Actions), actionRules(next.Actions)),
+	}
+}
+
+// rule is a named label or action, in the (ordered) configuration file
+type rule struct {
+	name  string
+	value any
+}
+
+func labelRules(labels Labels) []rule {
+	rules := make([]rule, 0, len(labels))
+
+	for _, label := range labels {
+		rules = append(rules, rule{name: labelRuleName(label), value: label})
+	}
+
+	return rules
+}
+
+// labelRuleName identifies the [label] in a [Diff]
+func labelRuleName(label *Label) string {
+	// "generate" labels has no name, so identify them by their script instead
+	if len(label.Name) == 0 {
+		return fmt.Sprintf("(generate) %s", label.Script)
+	}
+
+	return label.Name
+}
+
+func actionRules(actions Actions) []rule {
+	rules := make([]rule, 0, len(actions))
+
+	for _, action := range actions {
+		rules = append(rules, rule{name: action.Name, value: action})
+	}
+
+	return rules
+}
+
+func diffRules(previous, next []rule) DiffEntries {
+	var (
+		entries     DiffEntries
+		previousMap = map[string]any{}
+		nextMap     = map[string]any{}
+	)
+
+	for _, rule := range previous {
+		previousMap[rule.name] = rule.value
+	}
+
+	for _, rule := range next {
+		nextMap[rule.name] = rule.value
+
+		previousValue, ok := previousMap[rule.name]
+		if !ok {
+			entries.Added = append(entries.Added, rule.name)
+
+			continue
+		}
+
+		if !sameRule(previousValue, rule.value) {
+			entries.Modified = append(entries.Modified, rule.name)
+		}
+	}
+
+	for _, rule := range previous {
+		if _, ok := nextMap[rule.name]; !ok {
+			entries.Removed = append(entries.Removed, rule.name)
+		}
+	}
+
+	return entries
+}
+
+// sameRule compares the public (configuration) shape of two rules, ignoring any internal state
+func sameRule(a, b any) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+
+	return string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	t.Parallel()
+
+	previous, err := config.ParseFileString(`
+label:
+  - name: unchanged
+    script: "true"
+  - name: modified
+    script: "true"
+  - name: removed
+    script: "true"
+actions:
+  - name: removed action
+    if: "true"
+    then:
+      - action: close
+`)
+	require.NoError(t, err)
+
+	next, err := config.ParseFileString(`
+label:
+  - name: unchanged
+    script: "true"
+  - name: modified
+    script: "false"
+  - strategy: generate
+    script: '["a", "b"]'
+actions:
+  - name: added action
+    if: "true"
+    then:
+      - action: close
+`)
+	require.NoError(t, err)
+
+	diff := config.DiffConfigs(previous, next)
+
+	require.True(t, diff.HasChanges())
+	require.Equal(t, config.DiffEntries{
+		Added:    []string{`(generate) ["a", "b"]`},
+		Removed:  []string{"removed"},
+		Modified: []string{"modified"},
+	}, diff.Labels)
+	require.Equal(t, config.DiffEntries{
+		Added:   []string{"added action"},
+		Removed: []string{"removed action"},
+	}, diff.Actions)
+
+	require.False(t, config.DiffConfigs(previous, previous).HasChanges())
+}
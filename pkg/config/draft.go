@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// skipActionsWhileDraft removes the steps with an action type listed in 'skip_actions_while_draft' from
+// the [actions] while the Merge Request is a draft. Actions without any steps left are dropped.
+func (c Config) skipActionsWhileDraft(ctx context.Context, evalContext scm.EvalContext, actions []Action) []Action {
+	if len(c.SkipActionsWhileDraft) == 0 || !evalContext.IsDraft() {
+		return actions
+	}
+
+	results := make([]Action, 0, len(actions))
+
+	for _, action := range actions {
+		var skipped []string
+
+		steps := slices.DeleteFunc(slices.Clone(action.Then), func(step ActionStep) bool {
+			name, _ := step.OptionalString("action", "")
+			if !slices.Contains(c.SkipActionsWhileDraft, name) {
+				return false
+			}
+
+			skipped = append(skipped, name)
+
+			return true
+		})
+
+		if len(skipped) == 0 {
+			results = append(results, action)
+
+			continue
+		}
+
+		slogctx.Info(ctx, "Skipping action steps while the Merge Request is a draft", slog.String("action_name", action.Name), slog.Any("skipped_steps", skipped))
+		recordTrace(ctx, TraceEntry{Kind: "action", Name: action.Name, Outcome: fmt.Sprintf("skipped while draft (%s)", strings.Join(skipped, ", "))})
+
+		if len(steps) == 0 {
+			continue
+		}
+
+		action.Then = steps
+		results = append(results, action)
+	}
+
+	return results
+}
+
+// validateSkipActionsWhileDraft checks that 'skip_actions_while_draft' only lists known action types
+func (c Config) validateSkipActionsWhileDraft() error {
+	for _, name := range c.SkipActionsWhileDraft {
+		known := slices.ContainsFunc(actions, func(action actionList) bool {
+			return action.name == name
+		})
+
+		if !known {
+			return fmt.Errorf("'skip_actions_while_draft' contains unknown action %q", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_SkipActionsWhileDraft(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{
+		SkipActionsWhileDraft: []string{"merge", "approve"},
+		Labels: config.Labels{
+			{Name: "label", Script: "true"},
+		},
+		Actions: config.Actions{
+			{Name: "merge", If: "true", Then: []config.ActionStep{{"action": "approve"}, {"action": "merge"}}},
+			{Name: "merge and comment", If: "true", Then: []config.ActionStep{{"action": "merge"}, {"action": "comment", "message": "merged"}}},
+			{Name: "comment", If: "true", Then: []config.ActionStep{{"action": "comment", "message": "hello"}}},
+		},
+	}
+
+	require.NoError(t, cfg.Lint(context.Background(), &testEvalContext{}))
+
+	tests := []struct {
+		name      string
+		draft     bool
+		wantSteps map[string][]string
+	}{
+		{
+			name:  "draft Merge Request skips merge actions",
+			draft: true,
+			wantSteps: map[string][]string{
+				"merge and comment": {"comment"},
+				"comment":           {"comment"},
+			},
+		},
+		{
+			name: "ready Merge Request runs all actions",
+			wantSteps: map[string][]string{
+				"merge":             {"approve", "merge"},
+				"merge and comment": {"merge", "comment"},
+				"comment":           {"comment"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			labels, actions, err := cfg.Evaluate(context.Background(), &testEvalContext{Draft: tt.draft})
+			require.NoError(t, err)
+
+			// Labels are not affected by the Merge Request being a draft
+			require.Len(t, labels, 1)
+			require.True(t, labels[0].Matched)
+
+			steps := map[string][]string{}
+
+			for _, action := range actions {
+				for _, step := range action.Then {
+					name, err := step.RequiredString("action")
+					require.NoError(t, err)
+
+					steps[action.Name] = append(steps[action.Name], name)
+				}
+			}
+
+			require.Equal(t, tt.wantSteps, steps)
+
+			// The configuration must not be changed
+			require.Len(t, cfg.Actions[1].Then, 2)
+		})
+	}
+}
+
+func TestConfig_Lint_SkipActionsWhileDraft(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{SkipActionsWhileDraft: []string{"merge", "deploy"}}
+
+	require.ErrorContains(t, cfg.Lint(context.Background(), &testEvalContext{}), `'skip_actions_while_draft' contains unknown action "deploy"`)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/expr-lang/expr/vm"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+)
+
+type featureFlagsContextKey uint
+
+const featureFlagsKey featureFlagsContextKey = iota
+
+// FeatureFlagEnvPrefix is the prefix of environment variables overriding a feature flag
+// (example: 'SCM_ENGINE_FEATURE_FLAG_AUTO_MERGE=true' for the 'auto_merge' flag)
+const FeatureFlagEnvPrefix = "SCM_ENGINE_FEATURE_FLAG_"
+
+// featureFlagEnvReplacer matches the characters in a feature flag name that are not allowed in an environment variable name
+var featureFlagEnvReplacer = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// FeatureFlags are named on/off switches that labels and actions can be guarded by using 'feature_flag'
+type FeatureFlags map[string]bool
+
+// Enabled returns if the feature flag [name] is on.
+//
+// The environment variable [FeatureFlagEnvPrefix]<NAME> takes precedence over the configuration file,
+// and unknown feature flags are off.
+func (flags FeatureFlags) Enabled(name string) (bool, error) {
+	envName := FeatureFlagEnvName(name)
+
+	if value, ok := os.LookupEnv(envName); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("environment variable %s must be a boolean, got %q", envName, value)
+		}
+
+		return enabled, nil
+	}
+
+	return flags[name], nil
+}
+
+// FeatureFlagEnvName returns the name of the environment variable overriding the feature flag [name]
+func FeatureFlagEnvName(name string) string {
+	return FeatureFlagEnvPrefix + featureFlagEnvReplacer.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+func withFeatureFlags(ctx context.Context, flags FeatureFlags) context.Context {
+	return context.WithValue(ctx, featureFlagsKey, flags)
+}
+
+func featureFlagsFromContext(ctx context.Context) FeatureFlags {
+	flags, _ := ctx.Value(featureFlagsKey).(FeatureFlags)
+
+	return flags
+}
+
+// isEnabled checks the 'feature_flag' and 'enabled' guards of a label or action.
+//
+// The (optional) [program] is the compiled 'enabled' script
+func isEnabled(ctx context.Context, featureFlag string, program *vm.Program, evalContext scm.EvalContext) (bool, string, error) {
+	if len(featureFlag) > 0 {
+		enabled, err := featureFlagsFromContext(ctx).Enabled(featureFlag)
+		if err != nil || !enabled {
+			return false, fmt.Sprintf("skipped (feature_flag %q is off)", featureFlag), err
+		}
+	}
+
+	if program == nil {
+		return true, "", nil
+	}
+
+	enabled, err := runAndCheckBool(ctx, program, evalContext)
+	if err != nil {
+		return false, "", fmt.Errorf("could not evaluate 'enabled' script: %w", err)
+	}
+
+	if !enabled {
+		return false, "skipped (enabled)", nil
+	}
+
+	return true, "", nil
+}
+
+func compileEnabled(script string, evalContext scm.EvalContext) (*vm.Program, error) {
+	if len(script) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	opts := []expr.Option{}
+	opts = append(opts, expr.AsBool())
+	opts = append(opts, expr.Env(evalContext))
+	opts = append(opts, stdlib.FunctionRenamer)
+	opts = append(opts, stdlib.Functions...)
+	opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+	program, err := expr.Compile(script, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile 'enabled' into valid expr-lang syntax: %w", err)
+	}
+
+	return program, nil
+}
@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_Enabled(t *testing.T) {
+	t.Parallel()
+
+	evalContext := &testEvalContext{Bucket: "large"}
+
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		wantLabels  []string
+		wantActions []string
+	}{
+		{
+			name: "enabled script on",
+			cfg: config.Config{
+				Labels:  config.Labels{{Name: "label", Script: "true", Enabled: `Bucket == "large"`}},
+				Actions: config.Actions{{Name: "action", If: "true", Enabled: `Bucket == "large"`}},
+			},
+			wantLabels:  []string{"label"},
+			wantActions: []string{"action"},
+		},
+		{
+			name: "enabled script off",
+			cfg: config.Config{
+				Labels:  config.Labels{{Name: "label", Script: "true", Enabled: `Bucket == "small"`}},
+				Actions: config.Actions{{Name: "action", If: "true", Enabled: `Bucket == "small"`}},
+			},
+		},
+		{
+			name: "feature flag on",
+			cfg: config.Config{
+				FeatureFlags: config.FeatureFlags{"rollout": true},
+				Labels:       config.Labels{{Name: "label", Script: "true", FeatureFlag: "rollout"}},
+				Actions:      config.Actions{{Name: "action", If: "true", FeatureFlag: "rollout"}},
+			},
+			wantLabels:  []string{"label"},
+			wantActions: []string{"action"},
+		},
+		{
+			name: "feature flag off",
+			cfg: config.Config{
+				FeatureFlags: config.FeatureFlags{"rollout": false},
+				Labels:       config.Labels{{Name: "label", Script: "true", FeatureFlag: "rollout"}},
+				Actions:      config.Actions{{Name: "action", If: "true", FeatureFlag: "rollout"}},
+			},
+		},
+		{
+			name: "unknown feature flag is off",
+			cfg: config.Config{
+				Labels:  config.Labels{{Name: "label", Script: "true", FeatureFlag: "unknown"}},
+				Actions: config.Actions{{Name: "action", If: "true", FeatureFlag: "unknown"}},
+			},
+		},
+		{
+			name: "feature flag on but enabled script off",
+			cfg: config.Config{
+				FeatureFlags: config.FeatureFlags{"rollout": true},
+				Labels:       config.Labels{{Name: "label", Script: "true", FeatureFlag: "rollout", Enabled: "false"}},
+				Actions:      config.Actions{{Name: "action", If: "true", FeatureFlag: "rollout", Enabled: "false"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			labels, actions, err := tt.cfg.Evaluate(context.Background(), evalContext)
+			require.NoError(t, err)
+
+			var labelNames []string
+			for _, label := range labels {
+				labelNames = append(labelNames, label.Name)
+			}
+
+			var actionNames []string
+			for _, action := range actions {
+				actionNames = append(actionNames, action.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labelNames)
+			require.Equal(t, tt.wantActions, actionNames)
+		})
+	}
+}
+
+//nolint:paralleltest // t.Setenv can't be used in parallel tests
+func TestFeatureFlags_Enabled_Environment(t *testing.T) {
+	flags := config.FeatureFlags{"auto-merge": false}
+
+	enabled, err := flags.Enabled("auto-merge")
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	t.Setenv("SCM_ENGINE_FEATURE_FLAG_AUTO_MERGE", "true")
+
+	enabled, err = flags.Enabled("auto-merge")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	t.Setenv("SCM_ENGINE_FEATURE_FLAG_AUTO_MERGE", "nope")
+
+	_, err = flags.Enabled("auto-merge")
+	require.ErrorContains(t, err, "must be a boolean")
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// GlobalConfig is a configuration file in a central project, merged into the configuration of every project
+// like an included configuration file
+type GlobalConfig struct {
+	// The project hosting the configuration file (example: 'platform/scm-engine-policy')
+	Project string
+
+	// The path to the configuration file, relative to the repository root
+	File string
+
+	// (Optional) Git reference to read the configuration file from, HEAD if omitted
+	Ref *string
+
+	// The parsed configuration file
+	Config *Config
+}
+
+// LoadGlobalConfig reads and parses the global configuration file, failing if it doesn't exist
+func LoadGlobalConfig(ctx context.Context, client scm.Client, project, file string, ref *string) (*GlobalConfig, error) {
+	files, err := client.GetProjectFiles(ctx, project, ref, []string{file})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the global config file [%s] from project [%s]: %w", file, project, err)
+	}
+
+	cfg, err := ParseFileString(files[file])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the global config file [%s] from project [%s]: %w", file, project, err)
+	}
+
+	return &GlobalConfig{Project: project, File: file, Ref: ref, Config: cfg}, nil
+}
+
+// WithGlobalConfig returns a copy of the configuration with the [global] configuration file merged into it,
+// exactly like an included configuration file.
+//
+// A nil [global] returns the configuration as-is.
+func (c Config) WithGlobalConfig(ctx context.Context, global *GlobalConfig) *Config {
+	if global == nil {
+		return &c
+	}
+
+	ctx = slogctx.With(ctx, slog.String("phase", "global_config"))
+
+	// Don't modify the slices and maps shared with the original configuration
+	c.Actions = slices.Clone(c.Actions)
+	c.OnIssue = slices.Clone(c.OnIssue)
+	c.Labels = slices.Clone(c.Labels)
+	c.FeatureFlags = maps.Clone(c.FeatureFlags)
+	c.Vars = maps.Clone(c.Vars)
+
+	// Disallow includes, since the global configuration file is only read once at startup
+	if len(global.Config.Includes) != 0 {
+		slogctx.Warn(ctx, fmt.Sprintf("global config file [%s] from project [%s] may not have any 'include' settings; they are ignored", global.File, global.Project))
+	}
+
+	c.include(ctx, global.Config, fmt.Sprintf("global config file [%s] from project [%s]", global.File, global.Project))
+
+	return &c
+}
@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_WithGlobalConfig(t *testing.T) {
+	t.Parallel()
+
+	base, err := config.ParseFileString(`
+feature_flags:
+  auto_merge: false
+
+label:
+  - name: project
+    script: "true"
+`)
+	require.NoError(t, err)
+
+	globalConfig, err := config.ParseFileString(`
+dry_run: true
+
+feature_flags:
+  auto_merge: true
+  stale: true
+
+label:
+  - name: global
+    script: "true"
+
+actions:
+  - name: global action
+    if: "true"
+`)
+	require.NoError(t, err)
+
+	t.Run("no global config", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base.WithGlobalConfig(context.Background(), nil)
+		require.Equal(t, base, cfg)
+	})
+
+	t.Run("global config is merged like an include", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base.WithGlobalConfig(context.Background(), &config.GlobalConfig{Project: "platform/policy", File: ".scm-engine.yml", Config: globalConfig})
+
+		require.Len(t, cfg.Labels, 2)
+		require.Equal(t, "project", cfg.Labels[0].Name)
+		require.Equal(t, "global", cfg.Labels[1].Name)
+		require.Len(t, cfg.Actions, 1)
+		require.Equal(t, "global action", cfg.Actions[0].Name)
+
+		// The project configuration takes precedence, and the global config may not change 'dry_run'
+		require.Equal(t, config.FeatureFlags{"auto_merge": false, "stale": true}, cfg.FeatureFlags)
+		require.Nil(t, cfg.DryRun)
+
+		// The base configuration must not be changed
+		require.Len(t, base.Labels, 1)
+		require.Empty(t, base.Actions)
+		require.Equal(t, config.FeatureFlags{"auto_merge": false}, base.FeatureFlags)
+	})
+}
+
+func TestConfig_IsEnabled(t *testing.T) {
+	t.Parallel()
+
+	parse := func(t *testing.T, content string) *config.Config {
+		t.Helper()
+
+		cfg, err := config.ParseFileString(content)
+		require.NoError(t, err)
+
+		return cfg
+	}
+
+	optIn := &config.GlobalConfig{Project: "platform/policy", File: ".scm-engine.yml", Config: parse(t, "enabled: false\n")}
+
+	tests := []struct {
+		name    string
+		project string
+		global  *config.GlobalConfig
+		want    bool
+	}{
+		{name: "enabled by default", project: "label: []\n", want: true},
+		{name: "projects can opt out", project: "enabled: false\n", want: false},
+		{name: "a global 'enabled: false' makes processing opt-in", project: "label: []\n", global: optIn, want: false},
+		{name: "projects can opt in", project: "enabled: true\n", global: optIn, want: true},
+		{name: "projects opt out of a global 'enabled: true'", project: "enabled: false\n", global: &config.GlobalConfig{Config: parse(t, "enabled: true\n")}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, parse(t, tt.project).WithGlobalConfig(context.Background(), tt.global).IsEnabled())
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package config
 
+import "fmt"
+
+// DefaultMaxIncludeDepth is how deep included configuration files may include other configuration files,
+// unless changed with the 'max_include_depth' setting
+const DefaultMaxIncludeDepth = 5
+
 type Include struct {
 	// The project to include files from
 	//
@@ -18,3 +24,13 @@ type Include struct {
 	// See: https://jippi.github.io/scm-engine/configuration/#include.ref
 	Ref *string `json:"ref,omitempty" yaml:"ref"`
 }
+
+// source describes the included [file] in errors, for example when detecting include cycles
+func (i Include) source(file string) string {
+	ref := "HEAD"
+	if i.Ref != nil {
+		ref = *i.Ref
+	}
+
+	return fmt.Sprintf("%s@%s:%s", i.Project, ref, file)
+}
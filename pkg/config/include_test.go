@@ -0,0 +1,130 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// includeClient serves included configuration files from memory, keyed by project and file name
+type includeClient struct {
+	scm.Client
+
+	files map[string]map[string]string
+}
+
+func (c includeClient) GetProjectFiles(_ context.Context, project string, _ *string, files []string) (map[string]string, error) {
+	result := map[string]string{}
+
+	for _, file := range files {
+		result[file] = c.files[project][file]
+	}
+
+	return result, nil
+}
+
+func TestConfig_LoadIncludes(t *testing.T) {
+	t.Parallel()
+
+	client := includeClient{
+		files: map[string]map[string]string{
+			"lib/self": {
+				"self.yml": `
+include:
+  - project: lib/self
+    files: [self.yml]
+`,
+			},
+			"lib/chain": {
+				"one.yml": `
+label:
+  - name: one
+    script: "true"
+
+include:
+  - project: lib/chain
+    files: [two.yml]
+`,
+				"two.yml": `
+label:
+  - name: two
+    script: "true"
+
+include:
+  - project: lib/chain
+    files: [three.yml]
+`,
+				"three.yml": `
+label:
+  - name: three
+    script: "true"
+`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		config     string
+		wantLabels []string
+		wantErr    string
+	}{
+		{
+			name: "nested includes are merged",
+			config: `
+include:
+  - project: lib/chain
+    files: [one.yml]
+`,
+			wantLabels: []string{"one", "two", "three"},
+		},
+		{
+			name: "self-including file",
+			config: `
+include:
+  - project: lib/self
+    files: [self.yml]
+`,
+			wantErr: "include cycle detected: lib/self@HEAD:self.yml -> lib/self@HEAD:self.yml",
+		},
+		{
+			name: "3-deep chain exceeding the limit",
+			config: `
+max_include_depth: 2
+
+include:
+  - project: lib/chain
+    files: [one.yml]
+`,
+			wantErr: "include depth limit of 2 exceeded (see 'max_include_depth'): lib/chain@HEAD:one.yml -> lib/chain@HEAD:two.yml -> lib/chain@HEAD:three.yml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.ParseFileString(tt.config)
+			require.NoError(t, err)
+
+			err = cfg.LoadIncludes(context.Background(), client)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			var labels []string
+			for _, label := range cfg.Labels {
+				labels = append(labels, label.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labels)
+		})
+	}
+}
@@ -6,17 +6,26 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/patcher"
 	"github.com/expr-lang/expr/vm"
+	"github.com/hashicorp/go-multierror"
 	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/jippi/scm-engine/pkg/stdlib"
 	"github.com/jippi/scm-engine/pkg/tui"
 	"github.com/jippi/scm-engine/pkg/types"
 	slogctx "github.com/veqryn/slog-context"
+	"github.com/xhit/go-str2duration/v2"
 )
 
+// nameTemplateRegexp matches the "${{ script }}" segments in a label name
+var nameTemplateRegexp = regexp.MustCompile(`\$\{\{\s*(.+?)\s*\}\}`)
+
 // labelType is a custom type for our enum
 type labelType string
 
@@ -27,8 +36,33 @@ const (
 
 type Labels []*Label
 
+// ExpiresAfter returns the 'expires_at' duration of each conditional label with a static name, without evaluating any scripts.
+//
+// The label expiry sweep uses this to remove expired labels without running the rules
+func (labels Labels) ExpiresAfter() (map[string]time.Duration, error) {
+	result := map[string]time.Duration{}
+
+	for _, label := range labels {
+		if len(label.ExpiresAt) == 0 || label.Strategy == GenerateLabels || nameTemplateRegexp.MatchString(label.Name) {
+			continue
+		}
+
+		ttl, err := str2duration.ParseDuration(label.ExpiresAt)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("label: %s; [expires_at] must be a positive duration (e.g. '24h' or '7d'), got %q", label.Name, label.ExpiresAt)
+		}
+
+		result[label.Name] = ttl
+	}
+
+	return result, nil
+}
+
 func (labels Labels) Evaluate(ctx context.Context, evalContext scm.EvalContext) ([]scm.EvaluationResult, error) {
-	var results []scm.EvaluationResult
+	var (
+		results          []scm.EvaluationResult
+		evaluationErrors error
+	)
 
 	// Evaluate labels
 	for _, label := range labels {
@@ -36,9 +70,24 @@ func (labels Labels) Evaluate(ctx context.Context, evalContext scm.EvalContext)
 
 		slogctx.Debug(ctx, "Evaluating label")
 
+		RecordRuleMetric(ctx, "label", labelRuleName(label), RuleMetricEvaluations)
+
 		evaluationResult, err := label.Evaluate(ctx, evalContext)
 		if err != nil {
-			return nil, fmt.Errorf("label: %s; %w", label.Name, err)
+			RecordRuleMetric(ctx, "label", labelRuleName(label), RuleMetricErrors)
+
+			err = fmt.Errorf("label: %s; %w", label.Name, err)
+
+			if isStrictErrors(ctx, label.StrictErrors) {
+				evaluationErrors = multierror.Append(evaluationErrors, err)
+
+				continue
+			}
+
+			slogctx.Error(ctx, "Skipping label that failed to evaluate", slog.Any("error", err))
+			recordTrace(ctx, TraceEntry{Kind: "label", Name: label.Name, Script: label.Script, Outcome: "skipped (error)"})
+
+			continue
 		}
 
 		if evaluationResult == nil {
@@ -47,18 +96,28 @@ func (labels Labels) Evaluate(ctx context.Context, evalContext scm.EvalContext)
 			continue
 		}
 
+		if slices.ContainsFunc(evaluationResult, func(result scm.EvaluationResult) bool { return result.Matched }) {
+			RecordRuleMetric(ctx, "label", labelRuleName(label), RuleMetricMatches)
+		}
+
 		slogctx.Debug(ctx, "Label evaluation done", slog.Any("label_eval_result", evaluationResult))
 
 		results = append(results, evaluationResult...)
 	}
 
+	// Report every failing label at once, rather than one per evaluation
+	if evaluationErrors != nil {
+		return nil, evaluationErrors
+	}
+
 	// Sanity/validation checks
 	seen := map[string]bool{}
+	scopes := map[string]int{}
 
-	for _, result := range results {
+	for i, result := range results {
 		// Check labels has a proper name
-		if len(result.Name) == 0 {
-			return nil, errors.New("A label was generated with empty name, please check your configuration.")
+		if err := validateLabelName(result.Name); err != nil {
+			return nil, err
 		}
 
 		// Check uniqueness of labels
@@ -67,11 +126,49 @@ func (labels Labels) Evaluate(ctx context.Context, evalContext scm.EvalContext)
 		}
 
 		seen[result.Name] = true
+
+		// Only one scoped label can be added per scope, so the last matching label in the configuration file wins
+		scope, ok := scm.LabelScope(result.Name)
+		if !ok || !result.Matched {
+			continue
+		}
+
+		if other, ok := scopes[scope]; ok {
+			slogctx.Warn(ctx, "Multiple labels in the same scope matched, only adding the last one",
+				slog.String("scope", scope),
+				slog.String("label", result.Name),
+				slog.String("replaced_label", results[other].Name),
+			)
+
+			results[other].Matched = false
+		}
+
+		scopes[scope] = i
 	}
 
 	return results, nil
 }
 
+// validateLabelName checks that [name] can be used as a GitLab label name
+func validateLabelName(name string) error {
+	switch {
+	case len(name) == 0:
+		return errors.New("A label was generated with empty name, please check your configuration.")
+
+	case strings.TrimSpace(name) != name:
+		return fmt.Errorf("The label %q must not start or end with whitespace, please check your configuration.", name)
+
+	case strings.Contains(name, ","):
+		return fmt.Errorf("The label %q must not contain a comma, please check your configuration.", name)
+
+	case len(name) > 255:
+		return fmt.Errorf("The label %q must not be longer than 255 characters, please check your configuration.", name)
+
+	default:
+		return nil
+	}
+}
+
 type Label struct {
 	// (Optional) Strategy used for the label
 	//
@@ -83,6 +180,9 @@ type Label struct {
 
 	// Name of the label being generated.
 	//
+	// May contain "${{ script }}" segments, where each (https://expr-lang.org/) script returns a string
+	// or a list of strings. A list will generate a label for each of its values.
+	//
 	// May only be used with [conditional] labelling type
 	//
 	// See: https://jippi.github.io/scm-engine/configuration/#label.name
@@ -113,12 +213,50 @@ type Label struct {
 	// See: https://jippi.github.io/scm-engine/configuration/#label.script
 	Script string `json:"script" yaml:"script,omitempty"`
 
+	// (Optional) ExpiresAt is a duration (e.g. "24h" or "7d") after which the label is automatically removed
+	// from the Merge Request, counted from when the label was added.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#label.expires_at
+	ExpiresAt string `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+
+	// (Optional) RemoveAfter is a grace period (e.g. "15m" or "1h") the label stays on the Merge Request after its script
+	// started evaluating negatively, so a noisy condition (like a retried pipeline) doesn't add and remove the label repeatedly.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#label.remove_after
+	RemoveAfter string `json:"remove_after,omitempty" yaml:"remove_after,omitempty"`
+
+	// (Optional) Whether a failing script fails the whole evaluation (true) or only skips this label (false).
+	//
+	// Defaults to the global 'strict_errors' setting.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#strict_errors
+	StrictErrors *bool `json:"strict_errors,omitempty" yaml:"strict_errors,omitempty"`
+
 	// SkipIf is an optional (https://expr-lang.org/) script, returning a boolean, wether to
 	// skip (true) or process (false) this label step.
 	//
 	// See: https://jippi.github.io/scm-engine/configuration/#label.skip_if
 	SkipIf string `json:"skip_if,omitempty" yaml:"skip_if,omitempty"`
 
+	// (Optional) An (https://expr-lang.org/) script, returning a boolean, wether the label is enabled at all.
+	//
+	// A disabled label is skipped entirely, it's neither added nor removed.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#label.enabled
+	Enabled string `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// (Optional) Name of a feature flag (see 'feature_flags') that must be on for the label to be enabled.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#label.feature_flag
+	FeatureFlag string `json:"feature_flag,omitempty" yaml:"feature_flag,omitempty"`
+
+	// (Optional) Tags for selecting a subset of labels and actions at runtime with the '--only-tags' and '--skip-tags' flags.
+	//
+	// A label filtered out by its tags is skipped entirely, it's neither added nor removed.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#label.tags
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
 	//
 	// -- Internal state
 	//
@@ -129,6 +267,18 @@ type Label struct {
 	// skipIfCompiled is the [expr-lang](https://expr-lang.org/) [SkipIf] script pre-compiled
 	skipIfCompiled *vm.Program `json:"-" yaml:"-"`
 
+	// enabledCompiled is the [expr-lang](https://expr-lang.org/) [Enabled] script pre-compiled
+	enabledCompiled *vm.Program `json:"-" yaml:"-"`
+
+	// nameSegments are the literal and pre-compiled "${{ script }}" segments of a templated [Name]
+	nameSegments []nameSegment `json:"-" yaml:"-"`
+
+	// expiresAfter is the parsed [ExpiresAt] duration
+	expiresAfter time.Duration `json:"-" yaml:"-"`
+
+	// removeAfter is the parsed [RemoveAfter] duration
+	removeAfter time.Duration `json:"-" yaml:"-"`
+
 	expectedReturnType any `json:"-" yaml:"-"`
 }
 
@@ -169,6 +319,20 @@ func (p *Label) Setup(evalContext scm.EvalContext) error {
 
 	var err error
 
+	if len(p.ExpiresAt) > 0 {
+		p.expiresAfter, err = str2duration.ParseDuration(p.ExpiresAt)
+		if err != nil || p.expiresAfter <= 0 {
+			return fmt.Errorf("[expires_at] must be a positive duration (e.g. '24h' or '7d'), got %q", p.ExpiresAt)
+		}
+	}
+
+	if len(p.RemoveAfter) > 0 {
+		p.removeAfter, err = str2duration.ParseDuration(p.RemoveAfter)
+		if err != nil || p.removeAfter <= 0 {
+			return fmt.Errorf("[remove_after] must be a positive duration (e.g. '15m' or '1h'), got %q", p.RemoveAfter)
+		}
+	}
+
 	if p.scriptCompiled == nil {
 		p.Color = tui.Replace(p.Color)
 
@@ -185,6 +349,13 @@ func (p *Label) Setup(evalContext scm.EvalContext) error {
 		}
 	}
 
+	if p.nameSegments == nil && nameTemplateRegexp.MatchString(p.Name) {
+		p.nameSegments, err = compileNameTemplate(p.Name, evalContext)
+		if err != nil {
+			return err
+		}
+	}
+
 	if p.skipIfCompiled == nil && len(p.SkipIf) > 0 {
 		p.Color = tui.Replace(p.Color)
 
@@ -201,6 +372,13 @@ func (p *Label) Setup(evalContext scm.EvalContext) error {
 		}
 	}
 
+	if p.enabledCompiled == nil {
+		p.enabledCompiled, err = compileEnabled(p.Enabled, evalContext)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -217,8 +395,44 @@ func (p *Label) Evaluate(ctx context.Context, evalContext scm.EvalContext) ([]sc
 		return nil, fmt.Errorf("failed to initialize expr script engine: %w", err)
 	}
 
+	// Check if the label is selected by the runtime tag filters
+	if selected, outcome := isSelectedByTags(ctx, p.Tags); !selected {
+		recordTrace(ctx, TraceEntry{Kind: "label", Name: p.Name, Outcome: outcome})
+
+		return nil, nil
+	}
+
+	// Check if the label changed since the previous configuration file (if any)
+	if selected, outcome := isSelectedByChangedRules(ctx, "label", labelRuleName(p)); !selected {
+		recordTrace(ctx, TraceEntry{Kind: "label", Name: p.Name, Outcome: outcome})
+
+		return nil, nil
+	}
+
+	// Labels using data that could not be loaded are left alone, instead of being evaluated against the missing data
+	if err := checkUnavailableData(evalContext, p.scripts()...); err != nil {
+		slogctx.Error(ctx, "Skipping label", slog.Any("error", err))
+		recordTrace(ctx, TraceEntry{Kind: "label", Name: p.Name, Outcome: fmt.Sprintf("skipped (%s)", err)})
+
+		return nil, nil
+	}
+
+	// Check if the label is enabled at all
+	enabled, outcome, err := isEnabled(ctx, p.FeatureFlag, p.enabledCompiled, evalContext)
+	if err != nil || !enabled {
+		if err == nil {
+			recordTrace(ctx, TraceEntry{Kind: "label", Name: p.Name, Script: p.Enabled, Outcome: outcome})
+		}
+
+		return nil, err
+	}
+
 	// Check if the label should be skipped
 	if skip, err := p.ShouldSkip(ctx, evalContext); err != nil || skip {
+		if skip && err == nil {
+			recordTrace(ctx, TraceEntry{Kind: "label", Name: p.Name, Script: p.SkipIf, Outcome: "skipped (skip_if)"})
+		}
+
 		return nil, err
 	}
 
@@ -236,7 +450,14 @@ func (p *Label) Evaluate(ctx context.Context, evalContext scm.EvalContext) ([]sc
 			return nil, errors.New("Script returned an unexpected boolean; Did you forget the 'type: computed' on your label?")
 		}
 
-		result = append(result, p.resultForLabel(p.Name, outputValue))
+		names, err := p.names(evalContext)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			result = append(result, p.resultForLabel(name, outputValue))
+		}
 
 	// When using 'uniq' function, the result is a correct []string slice
 	case []string:
@@ -268,16 +489,147 @@ func (p *Label) Evaluate(ctx context.Context, evalContext scm.EvalContext) ([]sc
 		return nil, fmt.Errorf("rule evaluation returned %T (%+v); must return %T", output, output, p.expectedReturnType)
 	}
 
+	recordTrace(ctx, p.traceEntry(result))
+
 	return result, nil
 }
 
+func (p *Label) traceEntry(results []scm.EvaluationResult) TraceEntry {
+	entry := TraceEntry{Kind: "label", Name: p.Name, Script: p.Script}
+
+	switch p.Strategy {
+	case GenerateLabels:
+		names := make([]string, 0, len(results))
+		for _, result := range results {
+			names = append(names, result.Name)
+		}
+
+		entry.Name = "(generate)"
+		entry.Outcome = "[" + strings.Join(names, ", ") + "]"
+
+	default:
+		matched := make([]string, 0, len(results))
+		for _, result := range results {
+			matched = append(matched, fmt.Sprintf("%s=%t", result.Name, result.Matched))
+		}
+
+		entry.Outcome = strings.Join(matched, ", ")
+
+		// Regular labels just shows the boolean outcome
+		if len(results) == 1 && results[0].Name == p.Name {
+			entry.Outcome = fmt.Sprintf("%t", results[0].Matched)
+		}
+	}
+
+	return entry
+}
+
+// names returns the label names for [conditional] labels, rendering any "${{ script }}" segments in [Name]
+func (p *Label) names(evalContext scm.EvalContext) ([]string, error) {
+	if len(p.nameSegments) == 0 {
+		return []string{p.Name}, nil
+	}
+
+	names := []string{""}
+
+	for _, segment := range p.nameSegments {
+		if segment.program == nil {
+			for i := range names {
+				names[i] += segment.literal
+			}
+
+			continue
+		}
+
+		output, err := expr.Run(segment.program, evalContext)
+		if err != nil {
+			return nil, fmt.Errorf("could not evaluate 'name' script %q: %w", segment.literal, err)
+		}
+
+		var values []string
+
+		if value, ok := output.(string); ok {
+			values = []string{value}
+		} else if values, err = stdlib.ToStringSlice(output); err != nil {
+			return nil, fmt.Errorf("'name' script %q must return a string or a list of strings, got %T", segment.literal, output)
+		}
+
+		// Each value creates a new label name for each of the names generated so far
+		product := make([]string, 0, len(names)*len(values))
+
+		for _, name := range names {
+			for _, value := range values {
+				product = append(product, name+value)
+			}
+		}
+
+		names = product
+	}
+
+	return stdlib.UniqueSlice(names), nil
+}
+
+// nameSegment is either a literal part of a templated label name, or a pre-compiled "${{ script }}"
+type nameSegment struct {
+	literal string
+	program *vm.Program
+}
+
+func compileNameTemplate(name string, evalContext scm.EvalContext) ([]nameSegment, error) {
+	var (
+		segments []nameSegment
+		offset   int
+	)
+
+	for _, match := range nameTemplateRegexp.FindAllStringSubmatchIndex(name, -1) {
+		if match[0] > offset {
+			segments = append(segments, nameSegment{literal: name[offset:match[0]]})
+		}
+
+		script := name[match[2]:match[3]]
+
+		opts := []expr.Option{}
+		opts = append(opts, expr.Env(evalContext))
+		opts = append(opts, stdlib.FunctionRenamer)
+		opts = append(opts, stdlib.Functions...)
+		opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+		program, err := expr.Compile(script, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile 'name' script %q into valid expr-lang syntax: %w", script, err)
+		}
+
+		segments = append(segments, nameSegment{literal: script, program: program})
+		offset = match[1]
+	}
+
+	if offset < len(name) {
+		segments = append(segments, nameSegment{literal: name[offset:]})
+	}
+
+	return segments, nil
+}
+
+// scripts returns all scripts of the label, including the "${{ script }}" segments in [Name]
+func (p *Label) scripts() []string {
+	scripts := []string{p.Script, p.SkipIf, p.Enabled}
+
+	for _, match := range nameTemplateRegexp.FindAllStringSubmatch(p.Name, -1) {
+		scripts = append(scripts, match[1])
+	}
+
+	return scripts
+}
+
 func (p Label) resultForLabel(name string, matched bool) scm.EvaluationResult {
 	return scm.EvaluationResult{
-		Name:        name,
-		Matched:     matched,
-		Color:       p.Color,
-		Description: p.Description,
-		Priority:    p.Priority,
+		Name:         name,
+		Matched:      matched,
+		Color:        p.Color,
+		Description:  p.Description,
+		Priority:     p.Priority,
+		ExpiresAfter: p.expiresAfter,
+		RemoveAfter:  p.removeAfter,
 	}
 }
 
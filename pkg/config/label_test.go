@@ -0,0 +1,172 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvalContext struct {
+	Bucket  string
+	Modules []string
+	Vars    map[string]any `expr:"vars"`
+	Draft   bool
+
+	// Unavailable is returned by UnavailableData
+	Unavailable map[string]string `expr:"-"`
+}
+
+func (testEvalContext) AllowPipelineFailure(context.Context) bool                     { return false }
+func (testEvalContext) CanUseConfigurationFileFromChangeRequest(context.Context) bool { return true }
+func (testEvalContext) GetDescription() string                                        { return "" }
+func (testEvalContext) GetLabels() []string                                           { return nil }
+func (testEvalContext) GetTargetBranch() string                                       { return "" }
+func (testEvalContext) GetTitle() string                                              { return "" }
+func (testEvalContext) HasExecutedActionGroup(string) bool                            { return false }
+func (c testEvalContext) IsDraft() bool                                               { return c.Draft }
+func (testEvalContext) IsValid() bool                                                 { return true }
+func (testEvalContext) SetContext(context.Context)                                    {}
+func (c *testEvalContext) SetVars(vars map[string]any)                                { c.Vars = vars }
+func (testEvalContext) SetWebhookEvent(any)                                           {}
+func (testEvalContext) TrackActionGroupExecution(string)                              {}
+func (c testEvalContext) UnavailableData() map[string]string                          { return c.Unavailable }
+
+func TestLabels_Evaluate_TemplatedNames(t *testing.T) {
+	t.Parallel()
+
+	evalContext := &testEvalContext{
+		Bucket:  "large",
+		Modules: []string{"api", "web", "api"},
+	}
+
+	tests := []struct {
+		name    string
+		labels  config.Labels
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "single dynamic label",
+			labels: config.Labels{
+				{Name: "size::${{ Bucket }}", Script: "true"},
+			},
+			want: []string{"size::large"},
+		},
+		{
+			name: "list of dynamic labels",
+			labels: config.Labels{
+				{Name: "module/${{ Modules }}", Script: "true"},
+			},
+			want: []string{"module/api", "module/web"},
+		},
+		{
+			name: "computed scoped labels conflicting with a static scoped label",
+			labels: config.Labels{
+				{Name: "size::small", Script: "true"},
+				{Name: "size::${{ Bucket }}", Script: "true"},
+			},
+			want: []string{"size::small", "size::large"},
+		},
+		{
+			name: "scoped labels not being added do not conflict",
+			labels: config.Labels{
+				{Name: "size::small", Script: "false"},
+				{Name: "size::${{ Bucket }}", Script: "true"},
+			},
+			want: []string{"size::small", "size::large"},
+		},
+		{
+			name: "illegal computed label name",
+			labels: config.Labels{
+				{Name: "${{ Modules | join(',') }}", Script: "true"},
+			},
+			wantErr: "must not contain a comma",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results, err := tt.labels.Evaluate(context.Background(), evalContext)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			var names []string
+			for _, result := range results {
+				names = append(names, result.Name)
+			}
+
+			require.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestLabels_Evaluate_ScopedLabelConflict(t *testing.T) {
+	t.Parallel()
+
+	// Overlapping rules, where every size matches a large Merge Request
+	labels := config.Labels{
+		{Name: "size::small", Script: "true"},
+		{Name: "size::medium", Script: "true"},
+		{Name: "size::large", Script: `Bucket == "large"`},
+		{Name: "team::api", Script: "true"},
+		{Name: "bug", Script: "true"},
+	}
+
+	tests := []struct {
+		name        string
+		bucket      string
+		wantMatched []string
+	}{
+		{name: "last matching label in the scope wins", bucket: "large", wantMatched: []string{"size::large", "team::api", "bug"}},
+		{name: "labels that don't match are ignored", bucket: "small", wantMatched: []string{"size::medium", "team::api", "bug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results, err := labels.Evaluate(context.Background(), &testEvalContext{Bucket: tt.bucket})
+			require.NoError(t, err)
+			require.Len(t, results, len(labels))
+
+			var matched []string
+
+			for _, result := range results {
+				if result.Matched {
+					matched = append(matched, result.Name)
+				}
+			}
+
+			// The other labels in the scope are removed, like GitLab does when adding a scoped label
+			require.Equal(t, tt.wantMatched, matched)
+		})
+	}
+}
+
+func TestLabels_ExpiresAfter(t *testing.T) {
+	t.Parallel()
+
+	labels := config.Labels{
+		{Name: "needs-review", Script: "true", ExpiresAt: "2d"},
+		{Name: "hotfix", Script: "true"},
+		{Name: "team/${{ vars.team }}", Script: "true", ExpiresAt: "1h"},
+		{Strategy: config.GenerateLabels, Script: `["a"]`, ExpiresAt: "1h"},
+	}
+
+	expiresAfter, err := labels.ExpiresAfter()
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Duration{"needs-review": 48 * time.Hour}, expiresAfter)
+
+	_, err = config.Labels{{Name: "stale", Script: "true", ExpiresAt: "soon"}}.ExpiresAfter()
+	require.ErrorContains(t, err, `label: stale; [expires_at] must be a positive duration (e.g. '24h' or '7d'), got "soon"`)
+}
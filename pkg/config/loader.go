@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	_ "embed"
 	"io"
 	"os"
 	"strings"
@@ -9,6 +10,19 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+//go:embed default.scm-engine.yml
+var defaultConfig string
+
+// Default returns the bundled default configuration
+func Default() (*Config, error) {
+	return ParseFileString(defaultConfig)
+}
+
+// DefaultSource returns the YAML source of the bundled default configuration
+func DefaultSource() string {
+	return defaultConfig
+}
+
 // LoadFile loads and parses a GITLAB_LABELS file at the path specified.
 func LoadFile(path string) (*Config, error) {
 	f, err := os.Open(path)
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/expr-lang/expr/vm"
+	"github.com/hashicorp/go-multierror"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+)
+
+// DefaultLocale is the locale used when 'locales.default' isn't configured
+const DefaultLocale = "en"
+
+type Locales struct {
+	// (Optional) The locale used when the 'locale' script returns an empty string, or a message is missing in the selected locale.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#locales.default
+	Default string `json:"default,omitempty" yaml:"default" jsonschema:"default=en"`
+
+	// (Optional) An Expr Lang expression returning the locale of the Merge Request (example: 'fr' or 'pt-BR'), or an empty string for the default locale.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#locales.locale
+	Locale string `json:"locale,omitempty" yaml:"locale"`
+
+	// The message strings of each locale, keyed by locale and then by message key
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#locales.messages
+	Messages map[string]map[string]string `json:"messages" yaml:"messages"`
+}
+
+// defaultLocale returns the configured default locale, or [DefaultLocale]
+func (l *Locales) defaultLocale() string {
+	if l == nil || len(l.Default) == 0 {
+		return DefaultLocale
+	}
+
+	return l.Default
+}
+
+// Select returns the locale of the Merge Request, as returned by the 'locale' script, or the default locale
+func (l *Locales) Select(evalContext scm.EvalContext) (string, error) {
+	if l == nil || len(l.Locale) == 0 {
+		return l.defaultLocale(), nil
+	}
+
+	program, err := l.compile(evalContext)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := expr.Run(program, evalContext)
+	if err != nil {
+		return "", fmt.Errorf("could not evaluate 'locales.locale' script: %w", err)
+	}
+
+	locale, ok := output.(string)
+	if !ok {
+		return "", fmt.Errorf("'locales.locale' script must return a string, got %T", output)
+	}
+
+	if len(locale) == 0 {
+		return l.defaultLocale(), nil
+	}
+
+	return locale, nil
+}
+
+// Message returns the message with [key] in [locale], falling back to the language of the locale
+// (example: 'pt' for 'pt-BR') and then to the default locale, if the message is missing
+func (l *Locales) Message(locale, key string) (string, error) {
+	if l == nil {
+		return "", fmt.Errorf("message_key %q is used, but no 'locales' are configured", key)
+	}
+
+	candidates := []string{locale}
+	if language, _, ok := strings.Cut(locale, "-"); ok {
+		candidates = append(candidates, language)
+	}
+
+	candidates = append(candidates, l.defaultLocale())
+
+	for _, candidate := range candidates {
+		if message, ok := l.Messages[candidate][key]; ok {
+			return message, nil
+		}
+	}
+
+	return "", fmt.Errorf("message_key %q is not defined in locale %q or the default locale %q", key, locale, l.defaultLocale())
+}
+
+// LocalizeStep returns the [step] with its 'message_key' (if any) replaced by the 'message' in [locale]
+func (l *Locales) LocalizeStep(locale string, step ActionStep) (ActionStep, error) {
+	key, ok := step["message_key"]
+	if !ok {
+		return step, nil
+	}
+
+	name, ok := key.(string)
+	if !ok || len(name) == 0 {
+		return nil, fmt.Errorf("step field 'message_key' must be a non-empty string, got %T", key)
+	}
+
+	if _, ok := step["message"]; ok {
+		return nil, fmt.Errorf("step fields 'message' and 'message_key' can't both be set")
+	}
+
+	message, err := l.Message(locale, name)
+	if err != nil {
+		return nil, err
+	}
+
+	localized := maps.Clone(step)
+	delete(localized, "message_key")
+	localized["message"] = message
+
+	return localized, nil
+}
+
+// Lint checks the 'locale' script compiles, and that the 'message_key' of every step in [actions] is defined in the default locale
+func (l *Locales) Lint(evalContext scm.EvalContext, actions Actions) error {
+	var errors error
+
+	if l != nil && len(l.Locale) > 0 {
+		if _, err := l.compile(evalContext); err != nil {
+			errors = multierror.Append(errors, err)
+		}
+	}
+
+	for _, action := range actions {
+		for _, step := range action.Then {
+			// Messages missing in other locales fall back to the default locale, so it must have them all
+			if _, err := l.LocalizeStep(l.defaultLocale(), step); err != nil {
+				errors = multierror.Append(errors, fmt.Errorf("Action %q failed validation: %w", action.Name, err))
+			}
+		}
+	}
+
+	return errors
+}
+
+// merge returns the locales with the default locale, 'locale' script and messages of [other] added, unless already set
+func (l *Locales) merge(other *Locales) *Locales {
+	if other == nil {
+		return l
+	}
+
+	merged := &Locales{Messages: map[string]map[string]string{}}
+	if l != nil {
+		merged.Default, merged.Locale = l.Default, l.Locale
+	}
+
+	merged.Default = cmp.Or(merged.Default, other.Default)
+	merged.Locale = cmp.Or(merged.Locale, other.Locale)
+
+	for _, source := range []*Locales{other, l} {
+		if source == nil {
+			continue
+		}
+
+		for locale, messages := range source.Messages {
+			if merged.Messages[locale] == nil {
+				merged.Messages[locale] = map[string]string{}
+			}
+
+			maps.Copy(merged.Messages[locale], messages)
+		}
+	}
+
+	return merged
+}
+
+func (l *Locales) compile(evalContext scm.EvalContext) (*vm.Program, error) {
+	opts := []expr.Option{}
+	opts = append(opts, expr.Env(evalContext))
+	opts = append(opts, stdlib.FunctionRenamer)
+	opts = append(opts, stdlib.Functions...)
+	opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+	program, err := expr.Compile(l.Locale, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile 'locales.locale' script into valid expr-lang syntax: %w", err)
+	}
+
+	return program, nil
+}
@@ -0,0 +1,171 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocales_Select(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		locales *config.Locales
+		vars    map[string]any
+		want    string
+	}{
+		{name: "no locales", locales: nil, want: "en"},
+		{name: "no script", locales: &config.Locales{Default: "de"}, want: "de"},
+		{name: "script", locales: &config.Locales{Locale: `vars.team == "paris" ? "fr" : ""`}, vars: map[string]any{"team": "paris"}, want: "fr"},
+		{name: "empty script result uses the default locale", locales: &config.Locales{Default: "da", Locale: `vars.team == "paris" ? "fr" : ""`}, vars: map[string]any{"team": "berlin"}, want: "da"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			locale, err := tt.locales.Select(&testEvalContext{Vars: tt.vars})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, locale)
+		})
+	}
+
+	_, err := (&config.Locales{Locale: `len(vars)`}).Select(&testEvalContext{})
+	require.ErrorContains(t, err, "'locales.locale' script must return a string, got int")
+}
+
+func TestLocales_LocalizeStep(t *testing.T) {
+	t.Parallel()
+
+	locales := &config.Locales{
+		Messages: map[string]map[string]string{
+			"en":    {"changelog": "Please add a changelog entry", "thanks": "Thanks!"},
+			"pt":    {"changelog": "Adicione uma entrada no changelog", "thanks": "Obrigado!"},
+			"pt-BR": {"thanks": "Valeu!"},
+		},
+	}
+
+	tests := []struct {
+		locale  string
+		key     string
+		want    string
+		wantErr string
+	}{
+		{locale: "en", key: "changelog", want: "Please add a changelog entry"},
+		{locale: "pt-BR", key: "thanks", want: "Valeu!"},
+		{locale: "pt-BR", key: "changelog", want: "Adicione uma entrada no changelog"},
+		{locale: "fr", key: "thanks", want: "Thanks!"},
+		{locale: "fr", key: "missing", wantErr: `message_key "missing" is not defined in locale "fr" or the default locale "en"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.key, func(t *testing.T) {
+			t.Parallel()
+
+			step := config.ActionStep{"action": "comment", "message_key": tt.key, "internal": true}
+
+			localized, err := locales.LocalizeStep(tt.locale, step)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, config.ActionStep{"action": "comment", "message": tt.want, "internal": true}, localized)
+
+			// The configured step is left untouched
+			require.Equal(t, tt.key, step["message_key"])
+		})
+	}
+
+	// Steps without a 'message_key' are used as-is, even without locales
+	step := config.ActionStep{"action": "comment", "message": "Hello"}
+
+	localized, err := (*config.Locales)(nil).LocalizeStep("", step)
+	require.NoError(t, err)
+	require.Equal(t, step, localized)
+
+	_, err = (*config.Locales)(nil).LocalizeStep("", config.ActionStep{"action": "comment", "message_key": "thanks"})
+	require.ErrorContains(t, err, `message_key "thanks" is used, but no 'locales' are configured`)
+
+	_, err = locales.LocalizeStep("en", config.ActionStep{"action": "comment", "message": "Hello", "message_key": "thanks"})
+	require.ErrorContains(t, err, "step fields 'message' and 'message_key' can't both be set")
+}
+
+func TestLocales_Lint(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.ParseFileString(`
+locales:
+  default: en
+  locale: 'vars.team == "paris" ? "fr" : ""'
+  messages:
+    en:
+      changelog: Please add a changelog entry
+    fr:
+      thanks: Merci !
+
+actions:
+  - name: changelog
+    if: "true"
+    then:
+      - action: comment
+        message_key: changelog
+
+  - name: thanks
+    if: "true"
+    then:
+      - action: comment
+        message_key: thanks
+`)
+	require.NoError(t, err)
+
+	// Messages missing in other locales fall back to the default, so only the default locale must have them all
+	err = cfg.Lint(context.Background(), &testEvalContext{})
+	require.ErrorContains(t, err, `Action "thanks" failed validation: message_key "thanks" is not defined in locale "en"`)
+	require.NotContains(t, err.Error(), "changelog")
+}
+
+func TestLocales_IncludedMessages(t *testing.T) {
+	t.Parallel()
+
+	base, err := config.ParseFileString(`
+locales:
+  messages:
+    en:
+      changelog: Please add a CHANGELOG.md entry
+    fr:
+      changelog: Merci d'ajouter une entrée au CHANGELOG.md
+`)
+	require.NoError(t, err)
+
+	globalConfig, err := config.ParseFileString(`
+locales:
+  default: en
+  locale: 'vars.team == "paris" ? "fr" : ""'
+  messages:
+    en:
+      changelog: Please add a changelog entry
+      thanks: Thanks!
+    de:
+      thanks: Danke!
+`)
+	require.NoError(t, err)
+
+	cfg := base.WithGlobalConfig(context.Background(), &config.GlobalConfig{Project: "platform/policy", File: ".scm-engine.yml", Config: globalConfig})
+
+	// Messages are merged per locale and key, the project configuration file takes precedence
+	require.Equal(t, &config.Locales{
+		Default: "en",
+		Locale:  `vars.team == "paris" ? "fr" : ""`,
+		Messages: map[string]map[string]string{
+			"en": {"changelog": "Please add a CHANGELOG.md entry", "thanks": "Thanks!"},
+			"fr": {"changelog": "Merci d'ajouter une entrée au CHANGELOG.md"},
+			"de": {"thanks": "Danke!"},
+		},
+	}, cfg.Locales)
+}
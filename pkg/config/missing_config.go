@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+)
+
+// MissingConfigBehavior controls what happens when a project does not have a configuration file
+type MissingConfigBehavior string
+
+const (
+	// MissingConfigError surfaces the missing configuration file as an error (default)
+	MissingConfigError MissingConfigBehavior = "error"
+
+	// MissingConfigIgnore logs and skips projects without a configuration file
+	MissingConfigIgnore MissingConfigBehavior = "ignore"
+
+	// MissingConfigUseDefault falls back to the bundled default configuration file
+	MissingConfigUseDefault MissingConfigBehavior = "use_default"
+)
+
+// ErrMissingConfigIgnored is returned by [MissingConfigBehavior.Fallback] when the
+// missing configuration file should be ignored
+var ErrMissingConfigIgnored = errors.New("project has no scm-engine configuration file; ignoring")
+
+// ParseMissingConfigBehavior validates and converts the input into a [MissingConfigBehavior]
+func ParseMissingConfigBehavior(in string) (MissingConfigBehavior, error) {
+	switch behavior := MissingConfigBehavior(in); behavior {
+	case MissingConfigError, MissingConfigIgnore, MissingConfigUseDefault:
+		return behavior, nil
+
+	case "":
+		return MissingConfigError, nil
+
+	default:
+		return "", fmt.Errorf("unknown missing config behavior %q; must be one of %q, %q or %q", in, MissingConfigError, MissingConfigIgnore, MissingConfigUseDefault)
+	}
+}
+
+// Fallback decides what configuration to use when reading the configuration file failed with [err].
+//
+// Errors not caused by a missing configuration file are always returned as-is.
+func (behavior MissingConfigBehavior) Fallback(err error) (*Config, error) {
+	if !errors.Is(err, scm.ErrConfigFileNotFound) {
+		return nil, err
+	}
+
+	switch behavior {
+	case MissingConfigIgnore:
+		return nil, fmt.Errorf("%w: %w", ErrMissingConfigIgnored, err)
+
+	case MissingConfigUseDefault:
+		return Default()
+
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingConfigBehavior_Fallback(t *testing.T) {
+	t.Parallel()
+
+	notFoundErr := fmt.Errorf("failed to read remote raw file: %w", scm.ErrConfigFileNotFound)
+	otherErr := errors.New("500 Internal Server Error")
+
+	tests := []struct {
+		name       string
+		behavior   config.MissingConfigBehavior
+		err        error
+		wantConfig bool
+		wantErr    error
+	}{
+		{
+			name:     "error mode surfaces the missing file",
+			behavior: config.MissingConfigError,
+			err:      notFoundErr,
+			wantErr:  scm.ErrConfigFileNotFound,
+		},
+		{
+			name:     "ignore mode returns the ignored sentinel",
+			behavior: config.MissingConfigIgnore,
+			err:      notFoundErr,
+			wantErr:  config.ErrMissingConfigIgnored,
+		},
+		{
+			name:       "use_default mode returns the bundled configuration",
+			behavior:   config.MissingConfigUseDefault,
+			err:        notFoundErr,
+			wantConfig: true,
+		},
+		{
+			name:     "unrelated errors are returned as-is",
+			behavior: config.MissingConfigUseDefault,
+			err:      otherErr,
+			wantErr:  otherErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := tt.behavior.Fallback(tt.err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, cfg)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantConfig, cfg != nil)
+		})
+	}
+}
+
+func TestParseMissingConfigBehavior(t *testing.T) {
+	t.Parallel()
+
+	behavior, err := config.ParseMissingConfigBehavior("")
+	require.NoError(t, err)
+	require.Equal(t, config.MissingConfigError, behavior)
+
+	_, err = config.ParseMissingConfigBehavior("skip")
+	require.Error(t, err)
+}
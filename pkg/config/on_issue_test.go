@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_EvaluateIssue(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.ParseFileString(`
+label:
+  - name: api
+    script: "true"
+actions:
+  - name: merge request action
+    if: "true"
+on_issue:
+  - name: move
+    if: "true"
+    then:
+      - action: move_to_project
+        project: '"group/support"'
+  - name: not matching
+    if: "false"
+`)
+	require.NoError(t, err)
+
+	actions, err := cfg.EvaluateIssue(context.Background(), &testEvalContext{})
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	require.Equal(t, "move", actions[0].Name)
+}
+
+func TestConfig_Lint_IssueActions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("move_to_project can't be used on Merge Requests", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.ParseFileString(`
+actions:
+  - name: move
+    if: "true"
+    then:
+      - action: move_to_project
+        project: '"group/support"'
+`)
+		require.NoError(t, err)
+
+		err = cfg.Lint(context.Background(), &testEvalContext{})
+		require.ErrorContains(t, err, `Action "move" failed validation: "move_to_project" only applies to issues, use it in 'on_issue' instead`)
+	})
+
+	t.Run("on_issue only allows the issue actions", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.ParseFileString(`
+on_issue:
+  - name: comment
+    if: "true"
+    then:
+      - action: comment
+        message: hello
+`)
+		require.NoError(t, err)
+
+		err = cfg.LintIssue(&testEvalContext{})
+		require.ErrorContains(t, err, `Action "comment" failed validation: "comment" can't be used in 'on_issue', only move_to_project applies to issues`)
+	})
+
+	t.Run("valid on_issue actions", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.ParseFileString(`
+on_issue:
+  - name: move
+    if: "true"
+    then:
+      - action: move_to_project
+        project: '"group/support"'
+`)
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.LintIssue(&testEvalContext{}))
+		require.NoError(t, cfg.Lint(context.Background(), &testEvalContext{}))
+	})
+}
@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_OnMerge(t *testing.T) {
+	t.Parallel()
+
+	const withOnMerge = `
+label:
+  - name: api
+    script: "true"
+actions:
+  - name: generic
+    if: "true"
+  - name: on update
+    run_on: update
+    if: "true"
+  - name: explicit merge
+    run_on: merge
+    if: "true"
+on_merge:
+  - name: follow-up
+    if: "true"
+  - name: not matching
+    if: "false"
+`
+
+	const withoutOnMerge = `
+label:
+  - name: api
+    script: "true"
+actions:
+  - name: generic
+    if: "true"
+  - name: explicit merge
+    run_on: merge
+    if: "true"
+`
+
+	tests := []struct {
+		name         string
+		config       string
+		triggerEvent string
+		wantLabels   []string
+		wantActions  []string
+	}{
+		{
+			name:         "merge only runs on_merge and explicit merge actions",
+			config:       withOnMerge,
+			triggerEvent: state.TriggerEventMerge,
+			wantLabels:   []string{},
+			wantActions:  []string{"follow-up", "explicit merge"},
+		},
+		{
+			name:         "on_merge never runs on update",
+			config:       withOnMerge,
+			triggerEvent: state.TriggerEventUpdate,
+			wantLabels:   []string{"api"},
+			wantActions:  []string{"generic", "on update"},
+		},
+		{
+			name:         "merge without on_merge runs every rule",
+			config:       withoutOnMerge,
+			triggerEvent: state.TriggerEventMerge,
+			wantLabels:   []string{"api"},
+			wantActions:  []string{"generic", "explicit merge"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.ParseFileString(tt.config)
+			require.NoError(t, err)
+
+			ctx := state.WithTriggerEvent(context.Background(), tt.triggerEvent)
+
+			labels, actions, err := cfg.Evaluate(ctx, &testEvalContext{})
+			require.NoError(t, err)
+
+			labelNames := []string{}
+			for _, label := range labels {
+				labelNames = append(labelNames, label.Name)
+			}
+
+			actionNames := []string{}
+			for _, action := range actions {
+				actionNames = append(actionNames, action.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labelNames)
+			require.Equal(t, tt.wantActions, actionNames)
+		})
+	}
+}
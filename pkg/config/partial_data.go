@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+	"github.com/jippi/scm-engine/pkg/scm"
+)
+
+// ErrUnavailableData is returned when a script uses data that could not be loaded, see [scm.EvalContext.UnavailableData]
+var ErrUnavailableData = errors.New("script uses data that could not be loaded")
+
+// checkUnavailableData returns [ErrUnavailableData] if any of the [scripts] use a script attribute that could not be loaded,
+// so the script isn't evaluated against missing (and thus misleading) data
+func checkUnavailableData(evalContext scm.EvalContext, scripts ...string) error {
+	unavailable := evalContext.UnavailableData()
+	if len(unavailable) == 0 {
+		return nil
+	}
+
+	for _, script := range scripts {
+		if len(script) == 0 {
+			continue
+		}
+
+		// Invalid scripts are reported when they are compiled
+		tree, err := parser.Parse(script)
+		if err != nil {
+			continue
+		}
+
+		for _, attribute := range attributeReferences(tree.Node) {
+			for _, name := range slices.Sorted(maps.Keys(unavailable)) {
+				if attribute == name || strings.HasPrefix(attribute, name+".") {
+					return fmt.Errorf("%w: %s (%s)", ErrUnavailableData, name, unavailable[name])
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// attributeReferences returns the dotted path (e.g. 'merge_request.diff_stats') of every attribute used in [node]
+func attributeReferences(node ast.Node) []string {
+	visitor := &attributeVisitor{}
+	ast.Walk(&node, visitor)
+
+	return visitor.paths
+}
+
+type attributeVisitor struct {
+	paths []string
+}
+
+func (v *attributeVisitor) Visit(node *ast.Node) {
+	if path, ok := attributePath(*node); ok {
+		v.paths = append(v.paths, path)
+	}
+}
+
+func attributePath(node ast.Node) (string, bool) {
+	switch node := node.(type) {
+	case *ast.IdentifierNode:
+		return node.Value, true
+
+	case *ast.MemberNode:
+		parent, ok := attributePath(node.Node)
+		if !ok {
+			return "", false
+		}
+
+		property, ok := node.Property.(*ast.StringNode)
+		if !ok {
+			return "", false
+		}
+
+		return parent + "." + property.Value, true
+
+	default:
+		return "", false
+	}
+}
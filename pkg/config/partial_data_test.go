@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_UnavailableData(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{
+		Labels: config.Labels{
+			{Name: "bucket", Script: `Bucket == "large"`},
+			{Name: "size/${{ Bucket }}", Script: `true`},
+			{Name: "modules", Script: `len(Modules) > 1`},
+		},
+		Actions: config.Actions{
+			{Name: "bucket", If: `Bucket == "large"`},
+			{Name: "modules", If: `len(Modules) > 1`, Enabled: `Bucket != ""`},
+			{Name: "always", If: `true`},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		unavailable map[string]string
+		wantLabels  []string
+		wantActions []string
+	}{
+		{
+			name:        "all data available",
+			wantLabels:  []string{"bucket", "size/large", "modules"},
+			wantActions: []string{"bucket", "modules", "always"},
+		},
+		{
+			name:        "scripts using unavailable data are skipped",
+			unavailable: map[string]string{"Bucket": "permission denied"},
+			wantLabels:  []string{"modules"},
+			wantActions: []string{"always"},
+		},
+		{
+			name:        "unrelated unavailable data",
+			unavailable: map[string]string{"Other": "permission denied"},
+			wantLabels:  []string{"bucket", "size/large", "modules"},
+			wantActions: []string{"bucket", "modules", "always"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, trace := config.WithTrace(context.Background())
+			evalContext := &testEvalContext{Bucket: "large", Modules: []string{"api", "web"}, Unavailable: tt.unavailable}
+
+			labels, actions, err := cfg.Evaluate(ctx, evalContext)
+			require.NoError(t, err)
+
+			labelNames := []string{}
+			for _, label := range labels {
+				labelNames = append(labelNames, label.Name)
+			}
+
+			actionNames := []string{}
+			for _, action := range actions {
+				actionNames = append(actionNames, action.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labelNames)
+			require.Equal(t, tt.wantActions, actionNames)
+
+			if len(tt.wantLabels) < 3 {
+				require.Contains(t, trace.Entries(), config.TraceEntry{Kind: "label", Name: "bucket", Outcome: `skipped (script uses data that could not be loaded: Bucket (permission denied))`})
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// Profiles are named sets of overrides, selected at runtime with the '--profile' flag
+type Profiles map[string]Profile
+
+type Profile struct {
+	// (Optional) Overrides the 'dry_run' setting when the profile is selected
+	DryRun *bool `json:"dry_run,omitempty" yaml:"dry_run"`
+
+	// (Optional) Feature flags to set when the profile is selected, taking precedence over the base configuration
+	FeatureFlags FeatureFlags `json:"feature_flags,omitempty" yaml:"feature_flags"`
+
+	// (Optional) Actions to append to the base configuration when the profile is selected
+	Actions Actions `json:"actions,omitempty" yaml:"actions"`
+
+	// (Optional) Labels to append to the base configuration when the profile is selected
+	Labels Labels `json:"label,omitempty" yaml:"label"`
+}
+
+// WithProfile returns a copy of the configuration with the overrides of the profile [name] applied on top.
+//
+// Like included configuration files, the actions and labels of the profile are appended to the configuration,
+// while the 'dry_run' and 'feature_flags' settings of the profile take precedence.
+//
+// An empty [name] returns the configuration as-is, while an unknown profile is an error.
+func (c Config) WithProfile(ctx context.Context, name string) (*Config, error) {
+	if len(name) == 0 {
+		return &c, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q, must be one of: %v", name, slices.Sorted(maps.Keys(c.Profiles)))
+	}
+
+	if profile.DryRun != nil {
+		slogctx.Debug(ctx, fmt.Sprintf("profile [%s] changed 'dry_run' to %t", name, *profile.DryRun))
+
+		c.DryRun = profile.DryRun
+	}
+
+	if len(profile.Actions) != 0 {
+		slogctx.Debug(ctx, fmt.Sprintf("profile [%s] added %d new actions to the config file", name, len(profile.Actions)))
+
+		c.Actions = slices.Concat(c.Actions, profile.Actions)
+	}
+
+	if len(profile.Labels) != 0 {
+		slogctx.Debug(ctx, fmt.Sprintf("profile [%s] added %d new labels to the config file", name, len(profile.Labels)))
+
+		c.Labels = slices.Concat(c.Labels, profile.Labels)
+	}
+
+	if len(profile.FeatureFlags) != 0 {
+		flags := maps.Clone(c.FeatureFlags)
+		if flags == nil {
+			flags = FeatureFlags{}
+		}
+
+		maps.Copy(flags, profile.FeatureFlags)
+
+		c.FeatureFlags = flags
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,123 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const profileConfig = `
+dry_run: true
+
+feature_flags:
+  auto_merge: false
+  stale: true
+
+label:
+  - name: base
+    script: "true"
+
+actions:
+  - name: base action
+    if: "true"
+
+profiles:
+  prod:
+    dry_run: false
+    feature_flags:
+      auto_merge: true
+    label:
+      - name: prod
+        script: "true"
+    actions:
+      - name: prod action
+        if: "true"
+  staging:
+    label:
+      - name: staging
+        script: "true"
+`
+
+func TestConfig_WithProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		profile          string
+		wantErr          string
+		wantDryRun       bool
+		wantFeatureFlags config.FeatureFlags
+		wantLabels       []string
+		wantActions      []string
+	}{
+		{
+			name:             "no profile",
+			wantDryRun:       true,
+			wantFeatureFlags: config.FeatureFlags{"auto_merge": false, "stale": true},
+			wantLabels:       []string{"base"},
+			wantActions:      []string{"base action"},
+		},
+		{
+			name:             "profile overrides settings and appends labels and actions",
+			profile:          "prod",
+			wantDryRun:       false,
+			wantFeatureFlags: config.FeatureFlags{"auto_merge": true, "stale": true},
+			wantLabels:       []string{"base", "prod"},
+			wantActions:      []string{"base action", "prod action"},
+		},
+		{
+			name:             "profile without overrides keeps the base settings",
+			profile:          "staging",
+			wantDryRun:       true,
+			wantFeatureFlags: config.FeatureFlags{"auto_merge": false, "stale": true},
+			wantLabels:       []string{"base", "staging"},
+			wantActions:      []string{"base action"},
+		},
+		{
+			name:    "unknown profile",
+			profile: "dev",
+			wantErr: `unknown profile "dev", must be one of: [prod staging]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			base, err := config.ParseFileString(profileConfig)
+			require.NoError(t, err)
+
+			cfg, err := base.WithProfile(context.Background(), tt.profile)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDryRun, *cfg.DryRun)
+			require.Equal(t, tt.wantFeatureFlags, cfg.FeatureFlags)
+
+			var labels, actions []string
+
+			for _, label := range cfg.Labels {
+				labels = append(labels, label.Name)
+			}
+
+			for _, action := range cfg.Actions {
+				actions = append(actions, action.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labels)
+			require.Equal(t, tt.wantActions, actions)
+
+			// The base configuration must not be changed
+			require.True(t, *base.DryRun)
+			require.Len(t, base.Labels, 1)
+			require.Len(t, base.Actions, 1)
+			require.Equal(t, config.FeatureFlags{"auto_merge": false, "stale": true}, base.FeatureFlags)
+		})
+	}
+}
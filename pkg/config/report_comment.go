@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+)
+
+// ReportCommentMarker is the hidden marker used to find (and update) the report comment
+const ReportCommentMarker = "<!-- scm-engine:report -->"
+
+// Placements of the report, see [ReportComment.Placement]
+const (
+	ReportPlacementComment     = "comment"
+	ReportPlacementDescription = "description"
+)
+
+// ReportComment configures the single comment summarizing the outcome of every evaluation (the applied labels,
+// the action outcomes and any errors), which is updated in place rather than posting new comments
+type ReportComment struct {
+	// (Optional) Whether the report comment is kept up to date on the Merge Request
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#report_comment.enabled
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled" jsonschema:"default=false"`
+
+	// (Optional) When on, the messages of 'comment' action steps are added to the report comment,
+	// rather than posted as separate comments. Internal comments are always posted separately.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#report_comment.collect_comments
+	CollectComments bool `json:"collect_comments,omitempty" yaml:"collect_comments" jsonschema:"default=false"`
+
+	// (Optional) Where the report is kept: in a comment, or in a managed block at the top of the Merge Request
+	// description, so it's always visible. The rest of the description is never changed.
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#report_comment.placement
+	Placement string `json:"placement,omitempty" yaml:"placement" jsonschema:"default=comment,enum=comment,enum=description"`
+}
+
+// IsEnabled returns whether the report comment is kept up to date
+func (r *ReportComment) IsEnabled() bool {
+	return r != nil && r.Enabled
+}
+
+// InDescription returns whether the report is kept in the Merge Request description rather than in a comment
+func (r *ReportComment) InDescription() bool {
+	return r != nil && r.Placement == ReportPlacementDescription
+}
+
+// Lint validates the placement of the report
+func (r *ReportComment) Lint() error {
+	if r == nil {
+		return nil
+	}
+
+	switch r.Placement {
+	case "", ReportPlacementComment, ReportPlacementDescription:
+		return nil
+
+	default:
+		return fmt.Errorf("report_comment: 'placement' must be either %q or %q, got %q", ReportPlacementComment, ReportPlacementDescription, r.Placement)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"expvar"
+	"sync"
+)
+
+// DefaultRuleMetricsMaxRules is the default number of distinct rules tracked by [RuleMetrics]
+const DefaultRuleMetricsMaxRules = 100
+
+// RuleMetricsOverflow is the rule name the counters of rules above the [RuleMetrics] cap are added to
+const RuleMetricsOverflow = "_other"
+
+// Counters of a rule in [RuleMetrics]
+const (
+	RuleMetricEvaluations = "evaluations"
+	RuleMetricMatches     = "matches"
+	RuleMetricErrors      = "errors"
+	RuleMetricExecutions  = "executions"
+)
+
+type ruleMetricsContextKey uint
+
+const ruleMetricsKey ruleMetricsContextKey = iota
+
+// RuleMetrics counts evaluations, matches, errors and action executions by rule (example: 'label:bug' or 'action:close stale'),
+// to find the rules that are hot, failing or expensive.
+//
+// Only the first [maxRules] distinct rules get their own counters, the rest are added up under [RuleMetricsOverflow],
+// so configurations with many (or generated) rules can't grow the metrics without bounds.
+//
+// It's an [expvar.Var], published on the '/_metrics' endpoint of the server with '--rule-metrics'
+type RuleMetrics struct {
+	mu       sync.Mutex
+	maxRules int
+	rules    int
+	vars     expvar.Map
+}
+
+// NewRuleMetrics creates a [RuleMetrics] tracking up to [maxRules] distinct rules
+func NewRuleMetrics(maxRules int) *RuleMetrics {
+	return &RuleMetrics{maxRules: maxRules}
+}
+
+// WithRuleMetrics returns a context recording rule metrics into [metrics]
+func WithRuleMetrics(ctx context.Context, metrics *RuleMetrics) context.Context {
+	return context.WithValue(ctx, ruleMetricsKey, metrics)
+}
+
+// String implements [expvar.Var]
+func (m *RuleMetrics) String() string {
+	return m.vars.String()
+}
+
+// Get returns the [counter] of [rule] (example: 'label:bug', or [RuleMetricsOverflow]), zero if it was never recorded
+func (m *RuleMetrics) Get(rule, counter string) int64 {
+	counters, ok := m.vars.Get(rule).(*expvar.Map)
+	if !ok {
+		return 0
+	}
+
+	value, ok := counters.Get(counter).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+
+	return value.Value()
+}
+
+// rule returns the counters of [key], or the overflow counters if the cap is reached
+func (m *RuleMetrics) rule(key string) *expvar.Map {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rule, ok := m.vars.Get(key).(*expvar.Map); ok {
+		return rule
+	}
+
+	if m.rules >= m.maxRules {
+		key = RuleMetricsOverflow
+
+		if rule, ok := m.vars.Get(key).(*expvar.Map); ok {
+			return rule
+		}
+	} else {
+		m.rules++
+	}
+
+	rule := new(expvar.Map)
+	m.vars.Set(key, rule)
+
+	return rule
+}
+
+// RecordRuleMetric adds one to the [counter] of the rule of [kind] and [name], if the context has [RuleMetrics]
+func RecordRuleMetric(ctx context.Context, kind, name, counter string) {
+	metrics, ok := ctx.Value(ruleMetricsKey).(*RuleMetrics)
+	if !ok {
+		return
+	}
+
+	metrics.rule(kind+":"+name).Add(counter, 1)
+}
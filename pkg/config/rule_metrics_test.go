@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_RuleMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := config.NewRuleMetrics(config.DefaultRuleMetricsMaxRules)
+	ctx := config.WithRuleMetrics(context.Background(), metrics)
+
+	// Skip the failing label rather than failing the evaluation
+	lenient := false
+
+	cfg := config.Config{
+		Labels: config.Labels{
+			{Name: "large", Script: `Bucket == "large"`},
+			{Name: "broken", Script: `Bucket > 1`, StrictErrors: &lenient},
+		},
+		Actions: config.Actions{
+			{Name: "notify", If: `Bucket == "large"`},
+		},
+	}
+
+	for _, bucket := range []string{"large", "small", "large"} {
+		_, _, err := cfg.Evaluate(ctx, &testEvalContext{Bucket: bucket})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int64(3), metrics.Get("label:large", config.RuleMetricEvaluations))
+	require.Equal(t, int64(2), metrics.Get("label:large", config.RuleMetricMatches))
+	require.Equal(t, int64(0), metrics.Get("label:large", config.RuleMetricErrors))
+
+	require.Equal(t, int64(3), metrics.Get("label:broken", config.RuleMetricEvaluations))
+	require.Equal(t, int64(0), metrics.Get("label:broken", config.RuleMetricMatches))
+	require.Equal(t, int64(3), metrics.Get("label:broken", config.RuleMetricErrors))
+
+	require.Equal(t, int64(3), metrics.Get("action:notify", config.RuleMetricEvaluations))
+	require.Equal(t, int64(2), metrics.Get("action:notify", config.RuleMetricMatches))
+
+	// The metrics are published as JSON on the /_metrics endpoint
+	var published map[string]map[string]int64
+	require.NoError(t, json.Unmarshal([]byte(metrics.String()), &published))
+	require.Equal(t, map[string]int64{"evaluations": 3, "matches": 2}, published["action:notify"])
+}
+
+func TestRuleMetrics_MaxRules(t *testing.T) {
+	t.Parallel()
+
+	metrics := config.NewRuleMetrics(2)
+	ctx := config.WithRuleMetrics(context.Background(), metrics)
+
+	for _, name := range []string{"first", "second", "third", "fourth", "first"} {
+		config.RecordRuleMetric(ctx, "label", name, config.RuleMetricEvaluations)
+	}
+
+	require.Equal(t, int64(2), metrics.Get("label:first", config.RuleMetricEvaluations))
+	require.Equal(t, int64(1), metrics.Get("label:second", config.RuleMetricEvaluations))
+
+	// Rules above the cap are added up under the overflow rule
+	require.Equal(t, int64(0), metrics.Get("label:third", config.RuleMetricEvaluations))
+	require.Equal(t, int64(2), metrics.Get(config.RuleMetricsOverflow, config.RuleMetricEvaluations))
+}
+
+func TestRecordRuleMetric_Disabled(t *testing.T) {
+	t.Parallel()
+
+	// Rule metrics are opt-in, recording without them is a no-op
+	require.NotPanics(t, func() {
+		config.RecordRuleMetric(context.Background(), "label", "bug", config.RuleMetricEvaluations)
+	})
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Top-level config sections evaluated in isolation, see [Config.IsolateSectionErrors]
+const (
+	SectionLabels  = "label"
+	SectionActions = "actions"
+)
+
+// SectionErrors are the errors of the top-level config sections that failed to evaluate, keyed by section name,
+// while the remaining sections evaluated fine and can still be applied
+type SectionErrors map[string]error
+
+func (e SectionErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, section := range slices.Sorted(maps.Keys(e)) {
+		messages = append(messages, fmt.Sprintf("section '%s': %s", section, e[section]))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Failed returns whether the [section] failed to evaluate
+func (e SectionErrors) Failed(section string) bool {
+	_, ok := e[section]
+
+	return ok
+}
@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_IsolateSectionErrors(t *testing.T) {
+	t.Parallel()
+
+	const sections = `
+label:
+  - name: ok
+    script: "true"
+actions:
+  - name: broken
+    if: Modules[5] == "web"
+  - name: ok
+    if: "true"
+`
+
+	t.Run("an invalid action doesn't prevent the labels", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.ParseFileString("isolate_section_errors: true\n" + sections)
+		require.NoError(t, err)
+
+		labels, actions, err := cfg.Evaluate(context.Background(), &testEvalContext{Modules: []string{"api"}})
+		require.ErrorContains(t, err, "section 'actions': ")
+		require.ErrorContains(t, err, "action: broken;")
+
+		var sectionErrors config.SectionErrors
+		require.True(t, errors.As(err, &sectionErrors))
+		require.True(t, sectionErrors.Failed(config.SectionActions))
+		require.False(t, sectionErrors.Failed(config.SectionLabels))
+
+		require.Len(t, labels, 1)
+		require.Equal(t, "ok", labels[0].Name)
+		require.Empty(t, actions)
+	})
+
+	t.Run("an invalid label doesn't prevent the actions", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.ParseFileString(`
+isolate_section_errors: true
+label:
+  - name: broken
+    script: Modules[5] == "api"
+actions:
+  - name: ok
+    if: "true"
+`)
+		require.NoError(t, err)
+
+		labels, actions, err := cfg.Evaluate(context.Background(), &testEvalContext{Modules: []string{"api"}})
+		require.ErrorContains(t, err, "section 'label': ")
+
+		require.Empty(t, labels)
+		require.Len(t, actions, 1)
+		require.Equal(t, "ok", actions[0].Name)
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.ParseFileString(sections)
+		require.NoError(t, err)
+
+		labels, actions, err := cfg.Evaluate(context.Background(), &testEvalContext{Modules: []string{"api"}})
+		require.ErrorContains(t, err, "action: broken;")
+
+		var sectionErrors config.SectionErrors
+		require.False(t, errors.As(err, &sectionErrors))
+		require.Nil(t, labels)
+		require.Nil(t, actions)
+	})
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+)
+
+type SizeBuckets struct {
+	// (Optional) The buckets, from smallest to largest. Defaults to 'S' (up to 50 lines), 'M' (250), 'L' (1000) and 'XL'
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#size_buckets.buckets
+	Buckets []SizeBucket `json:"buckets,omitempty" yaml:"buckets"`
+
+	// (Optional) File patterns (like generated files or lock files) that don't count towards the size, in the same syntax as 'modified_files()'
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#size_buckets.exclude
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude"`
+}
+
+type SizeBucket struct {
+	// The name of the bucket (example: 'M')
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#size_buckets.buckets
+	Name string `json:"name" yaml:"name"`
+
+	// The maximum number of changed (added plus deleted) lines in the bucket. Must be omitted for the last bucket
+	//
+	// See: https://jippi.github.io/scm-engine/configuration/#size_buckets.buckets
+	MaxLines int `json:"max_lines,omitempty" yaml:"max_lines"`
+}
+
+// Parse returns the size buckets for the script functions, or [scm.DefaultSizeBuckets] if none are configured
+func (s *SizeBuckets) Parse() (scm.SizeBuckets, error) {
+	if s == nil {
+		return scm.DefaultSizeBuckets, nil
+	}
+
+	result := scm.DefaultSizeBuckets
+	result.Exclude = s.Exclude
+
+	if len(s.Buckets) == 0 {
+		return result, nil
+	}
+
+	result.Buckets = nil
+
+	for i, bucket := range s.Buckets {
+		last := i == len(s.Buckets)-1
+
+		switch {
+		case len(bucket.Name) == 0:
+			return result, fmt.Errorf("size_buckets: bucket #%d: 'name' must not be empty", i+1)
+
+		case last && bucket.MaxLines != 0:
+			return result, fmt.Errorf("size_buckets: bucket %q: the last bucket has no upper bound, so it must not have 'max_lines'", bucket.Name)
+
+		case !last && bucket.MaxLines <= 0:
+			return result, fmt.Errorf("size_buckets: bucket %q: 'max_lines' must be greater than 0", bucket.Name)
+
+		case !last && i > 0 && bucket.MaxLines <= s.Buckets[i-1].MaxLines:
+			return result, fmt.Errorf("size_buckets: bucket %q: 'max_lines' must be greater than the previous bucket's (%d)", bucket.Name, s.Buckets[i-1].MaxLines)
+		}
+
+		result.Buckets = append(result.Buckets, scm.SizeBucket{Name: bucket.Name, MaxLines: bucket.MaxLines})
+	}
+
+	if len(result.Buckets) < 2 {
+		return result, errors.New("size_buckets: at least two buckets are required")
+	}
+
+	return result, nil
+}
+
+func (s *SizeBuckets) Lint() error {
+	_, err := s.Parse()
+
+	return err
+}
@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeBuckets_Parse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  string
+		want    scm.SizeBuckets
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			config: `{}`,
+			want:   scm.DefaultSizeBuckets,
+		},
+		{
+			name:   "exclude only",
+			config: `size_buckets: {exclude: ["*.lock"]}`,
+			want:   scm.SizeBuckets{Buckets: scm.DefaultSizeBuckets.Buckets, Exclude: []string{"*.lock"}},
+		},
+		{
+			name:   "custom buckets",
+			config: `size_buckets: {buckets: [{name: small, max_lines: 10}, {name: large}]}`,
+			want:   scm.SizeBuckets{Buckets: []scm.SizeBucket{{Name: "small", MaxLines: 10}, {Name: "large"}}},
+		},
+		{
+			name:    "single bucket",
+			config:  `size_buckets: {buckets: [{name: any}]}`,
+			wantErr: "at least two buckets are required",
+		},
+		{
+			name:    "missing name",
+			config:  `size_buckets: {buckets: [{max_lines: 10}, {name: large}]}`,
+			wantErr: "bucket #1: 'name' must not be empty",
+		},
+		{
+			name:    "missing max_lines",
+			config:  `size_buckets: {buckets: [{name: small}, {name: large}]}`,
+			wantErr: `bucket "small": 'max_lines' must be greater than 0`,
+		},
+		{
+			name:    "last bucket with max_lines",
+			config:  `size_buckets: {buckets: [{name: small, max_lines: 10}, {name: large, max_lines: 100}]}`,
+			wantErr: `bucket "large": the last bucket has no upper bound`,
+		},
+		{
+			name:    "unordered",
+			config:  `size_buckets: {buckets: [{name: small, max_lines: 100}, {name: medium, max_lines: 10}, {name: large}]}`,
+			wantErr: `bucket "medium": 'max_lines' must be greater than the previous bucket's (100)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.ParseFileString(tt.config)
+			require.NoError(t, err)
+
+			got, err := cfg.SizeBuckets.Parse()
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
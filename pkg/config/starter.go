@@ -0,0 +1,26 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+//go:embed starter.gitlab.scm-engine.yml
+var starterGitLabConfig string
+
+//go:embed starter.github.scm-engine.yml
+var starterGitHubConfig string
+
+// StarterSource returns the YAML source of the commented starter configuration file for the [provider] ('gitlab' or 'github')
+func StarterSource(provider string) (string, error) {
+	switch provider {
+	case "gitlab":
+		return starterGitLabConfig, nil
+
+	case "github":
+		return starterGitHubConfig, nil
+
+	default:
+		return "", fmt.Errorf("unsupported provider %q, must be either 'gitlab' or 'github'", provider)
+	}
+}
@@ -0,0 +1,115 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_StrictErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		config      string
+		wantErrs    []string
+		wantLabels  []string
+		wantActions []string
+	}{
+		{
+			name: "strict by default, reporting every error",
+			config: `
+label:
+  - name: broken
+    script: Modules[5] == "api"
+  - name: ok
+    script: "true"
+actions:
+  - name: broken
+    if: Modules[5] == "web"
+  - name: ok
+    if: "true"
+`,
+			wantErrs: []string{"label: broken;", "action: broken;"},
+		},
+		{
+			name: "lenient skips failing rules",
+			config: `
+strict_errors: false
+label:
+  - name: broken
+    script: Modules[5] == "api"
+  - name: ok
+    script: "true"
+actions:
+  - name: broken
+    if: Modules[5] == "web"
+  - name: ok
+    if: "true"
+`,
+			wantLabels:  []string{"ok"},
+			wantActions: []string{"ok"},
+		},
+		{
+			name: "lenient with a strict rule",
+			config: `
+strict_errors: false
+label:
+  - name: broken
+    script: Modules[5] == "api"
+  - name: critical
+    script: Modules[5] == "web"
+    strict_errors: true
+`,
+			wantErrs: []string{"label: critical;"},
+		},
+		{
+			name: "strict with a lenient rule",
+			config: `
+label:
+  - name: optional
+    script: Modules[5] == "api"
+    strict_errors: false
+  - name: ok
+    script: "true"
+`,
+			wantLabels:  []string{"ok"},
+			wantActions: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.ParseFileString(tt.config)
+			require.NoError(t, err)
+
+			labels, actions, err := cfg.Evaluate(context.Background(), &testEvalContext{Modules: []string{"api"}})
+			if len(tt.wantErrs) > 0 {
+				for _, wantErr := range tt.wantErrs {
+					require.ErrorContains(t, err, wantErr)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			labelNames := []string{}
+			for _, label := range labels {
+				labelNames = append(labelNames, label.Name)
+			}
+
+			actionNames := []string{}
+			for _, action := range actions {
+				actionNames = append(actionNames, action.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labelNames)
+			require.Equal(t, tt.wantActions, actionNames)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// isSelectedByTags checks the [tags] of a label or action against the runtime '--only-tags' and '--skip-tags' filters.
+//
+// Returns the trace outcome to record if the label or action is filtered out
+func isSelectedByTags(ctx context.Context, tags []string) (bool, string) {
+	if only := state.OnlyTags(ctx); len(only) > 0 && !containsAny(tags, only) {
+		return false, fmt.Sprintf("skipped (tags not in --only-tags %v)", only)
+	}
+
+	for _, tag := range tags {
+		if slices.Contains(state.SkipTags(ctx), tag) {
+			return false, fmt.Sprintf("skipped (tag %q in --skip-tags)", tag)
+		}
+	}
+
+	return true, ""
+}
+
+func containsAny(haystack, needles []string) bool {
+	for _, needle := range needles {
+		if slices.Contains(haystack, needle) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_Tags(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{
+		Labels: config.Labels{
+			{Name: "security-label", Script: `true`, Tags: []string{"security"}},
+			{Name: "experimental-label", Script: `true`, Tags: []string{"security", "experimental"}},
+			{Name: "untagged-label", Script: `true`},
+		},
+		Actions: config.Actions{
+			{Name: "security-action", If: `true`, Tags: []string{"security"}},
+			{Name: "experimental-action", If: `true`, Tags: []string{"experimental"}},
+			{Name: "untagged-action", If: `true`},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		onlyTags    []string
+		skipTags    []string
+		wantLabels  []string
+		wantActions []string
+	}{
+		{
+			name:        "no filters",
+			wantLabels:  []string{"security-label", "experimental-label", "untagged-label"},
+			wantActions: []string{"security-action", "experimental-action", "untagged-action"},
+		},
+		{
+			name:        "only tags",
+			onlyTags:    []string{"security"},
+			wantLabels:  []string{"security-label", "experimental-label"},
+			wantActions: []string{"security-action"},
+		},
+		{
+			name:        "skip tags",
+			skipTags:    []string{"experimental"},
+			wantLabels:  []string{"security-label", "untagged-label"},
+			wantActions: []string{"security-action", "untagged-action"},
+		},
+		{
+			name:        "skip tags take precedence over only tags",
+			onlyTags:    []string{"security"},
+			skipTags:    []string{"experimental"},
+			wantLabels:  []string{"security-label"},
+			wantActions: []string{"security-action"},
+		},
+		{
+			name:        "unknown only tag",
+			onlyTags:    []string{"unknown"},
+			wantLabels:  []string{},
+			wantActions: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = state.WithOnlyTags(ctx, tt.onlyTags)
+			ctx = state.WithSkipTags(ctx, tt.skipTags)
+
+			labels, actions, err := cfg.Evaluate(ctx, &testEvalContext{})
+			require.NoError(t, err)
+
+			labelNames := []string{}
+			for _, label := range labels {
+				labelNames = append(labelNames, label.Name)
+			}
+
+			actionNames := []string{}
+			for _, action := range actions {
+				actionNames = append(actionNames, action.Name)
+			}
+
+			require.Equal(t, tt.wantLabels, labelNames)
+			require.Equal(t, tt.wantActions, actionNames)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type traceContextKey uint
+
+const traceKey traceContextKey = iota
+
+// DebugTraceLabel enables the debug trace comment on a Merge Request, regardless of the 'debug_comment' setting
+const DebugTraceLabel = "scm-engine:debug"
+
+// DebugTraceMarker is the hidden marker used to find (and update) the debug trace comment
+const DebugTraceMarker = "<!-- scm-engine:debug-trace -->"
+
+// Trace records the outcome of each label and action script during an evaluation
+type Trace struct {
+	mu      sync.Mutex
+	entries []TraceEntry
+}
+
+// TraceEntry is the outcome of a single label or action script
+type TraceEntry struct {
+	Kind    string
+	Name    string
+	Script  string
+	Outcome string
+}
+
+// WithTrace returns a context that records label and action outcomes into the returned [Trace]
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+
+	return context.WithValue(ctx, traceKey, trace), trace
+}
+
+// recordTrace records an entry if the context has a [Trace]
+func recordTrace(ctx context.Context, entry TraceEntry) {
+	trace, ok := ctx.Value(traceKey).(*Trace)
+	if !ok {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	trace.entries = append(trace.entries, entry)
+}
+
+// Entries returns a copy of the recorded entries
+func (t *Trace) Entries() []TraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]TraceEntry{}, t.entries...)
+}
+
+// Markdown renders the trace as a collapsed markdown table, prefixed with the [DebugTraceMarker]
+func (t *Trace) Markdown(title string) string {
+	var out strings.Builder
+
+	out.WriteString(DebugTraceMarker + "\n")
+	out.WriteString("<details>\n")
+	out.WriteString(fmt.Sprintf("<summary>%s</summary>\n\n", title))
+	out.WriteString("| Type | Name | Script | Result |\n")
+	out.WriteString("| ---- | ---- | ------ | ------ |\n")
+
+	for _, entry := range t.Entries() {
+		out.WriteString(fmt.Sprintf("| %s | %s | `%s` | `%s` |\n", entry.Kind, escapeTableCell(entry.Name), escapeTableCell(entry.Script), escapeTableCell(entry.Outcome)))
+	}
+
+	out.WriteString("\n</details>\n")
+
+	return out.String()
+}
+
+// escapeTableCell makes sure [in] can be rendered within a single markdown table cell
+func escapeTableCell(in string) string {
+	in = strings.TrimSpace(in)
+	in = strings.ReplaceAll(in, "\n", " ")
+	in = strings.ReplaceAll(in, "|", `\|`)
+	in = strings.ReplaceAll(in, "`", "'")
+
+	return in
+}
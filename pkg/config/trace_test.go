@@ -0,0 +1,43 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.ParseFileString(`
+label:
+  - name: matched
+    script: Bucket == "large"
+  - name: skipped
+    script: "true"
+    skip_if: "true"
+actions:
+  - name: close
+    if: len(Modules) > 5 || false
+    then:
+      - action: close
+`)
+	require.NoError(t, err)
+
+	ctx, trace := config.WithTrace(context.Background())
+
+	_, _, err = cfg.Evaluate(ctx, &testEvalContext{Bucket: "large"})
+	require.NoError(t, err)
+
+	require.Equal(t, []config.TraceEntry{
+		{Kind: "label", Name: "matched", Script: `Bucket == "large"`, Outcome: "true"},
+		{Kind: "label", Name: "skipped", Script: "true", Outcome: "skipped (skip_if)"},
+		{Kind: "action", Name: "close", Script: "len(Modules) > 5 || false", Outcome: "false"},
+	}, trace.Entries())
+
+	markdown := trace.Markdown("trace")
+	require.Contains(t, markdown, config.DebugTraceMarker)
+	require.Contains(t, markdown, "| action | close | `len(Modules) > 5 \\|\\| false` | `false` |")
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/expr-lang/expr/vm"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// Vars are named Expr Lang expressions computed once per evaluation, and exposed to all other
+// scripts as 'vars.<name>'. A var may use other vars, as long as they don't reference each other in a cycle.
+type Vars map[string]string
+
+// compiledVar is a var ready to be evaluated
+type compiledVar struct {
+	name    string
+	program *vm.Program
+}
+
+// Evaluate computes the value of every var in dependency order, and exposes them in the [evalContext]
+func (vars Vars) Evaluate(ctx context.Context, evalContext scm.EvalContext) error {
+	if len(vars) == 0 {
+		return nil
+	}
+
+	compiled, err := vars.compile(evalContext)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]any, len(compiled))
+
+	// Expose the values up front, so vars can use vars evaluated before them
+	evalContext.SetVars(values)
+
+	for _, item := range compiled {
+		value, err := expr.Run(item.program, evalContext)
+		if err != nil {
+			return fmt.Errorf("var %q failed: %w", item.name, err)
+		}
+
+		slogctx.Debug(ctx, "Evaluated var", slog.String("var_name", item.name), slog.Any("var_value", value))
+
+		values[item.name] = value
+	}
+
+	return nil
+}
+
+// Lint checks that every var compiles, and that no vars reference each other in a cycle
+func (vars Vars) Lint(evalContext scm.EvalContext) error {
+	_, err := vars.compile(evalContext)
+
+	return err
+}
+
+// compile returns the vars in the order they must be evaluated, so every var is evaluated after the vars it uses
+func (vars Vars) compile(evalContext scm.EvalContext) ([]compiledVar, error) {
+	dependencies := make(map[string][]string, len(vars))
+
+	for name, script := range vars {
+		tree, err := parser.Parse(script)
+		if err != nil {
+			return nil, fmt.Errorf("var %q could not be parsed into valid expr-lang syntax: %w", name, err)
+		}
+
+		for _, dependency := range varReferences(tree.Node) {
+			if _, ok := vars[dependency]; !ok {
+				return nil, fmt.Errorf("var %q uses unknown var %q", name, dependency)
+			}
+
+			dependencies[name] = append(dependencies[name], dependency)
+		}
+	}
+
+	order, err := sortVars(dependencies, slices.Sorted(maps.Keys(vars)))
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := make([]compiledVar, 0, len(order))
+
+	for _, name := range order {
+		opts := []expr.Option{}
+		opts = append(opts, expr.Env(evalContext))
+		opts = append(opts, stdlib.FunctionRenamer)
+		opts = append(opts, stdlib.Functions...)
+		opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+		program, err := expr.Compile(vars[name], opts...)
+		if err != nil {
+			return nil, fmt.Errorf("var %q could not be compiled into valid expr-lang syntax: %w", name, err)
+		}
+
+		compiled = append(compiled, compiledVar{name: name, program: program})
+	}
+
+	return compiled, nil
+}
+
+// sortVars orders the [names] so every var comes after its [dependencies], failing on cyclic references
+func sortVars(dependencies map[string][]string, names []string) ([]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	var (
+		order []string
+		seen  = map[string]int{}
+		path  []string
+		visit func(name string) error
+	)
+
+	visit = func(name string) error {
+		switch seen[name] {
+		case visited:
+			return nil
+
+		case visiting:
+			cycle := append(slices.Clone(path[slices.Index(path, name):]), name)
+
+			return fmt.Errorf("vars have a cyclic reference: %s", strings.Join(cycle, " -> "))
+		}
+
+		seen[name] = visiting
+		path = append(path, name)
+
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		seen[name] = visited
+		order = append(order, name)
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// varReferences returns the names of the vars used in a script, e.g. 'size' for 'vars.size' or 'vars["size"]'
+func varReferences(node ast.Node) []string {
+	visitor := &varVisitor{}
+	ast.Walk(&node, visitor)
+
+	return visitor.names
+}
+
+type varVisitor struct {
+	names []string
+}
+
+func (v *varVisitor) Visit(node *ast.Node) {
+	member, ok := (*node).(*ast.MemberNode)
+	if !ok {
+		return
+	}
+
+	identifier, ok := member.Node.(*ast.IdentifierNode)
+	if !ok || identifier.Value != "vars" {
+		return
+	}
+
+	property, ok := member.Property.(*ast.StringNode)
+	if !ok || slices.Contains(v.names, property.Value) {
+		return
+	}
+
+	v.names = append(v.names, property.Value)
+}
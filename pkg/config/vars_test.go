@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Evaluate_Vars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		vars       config.Vars
+		wantVars   map[string]any
+		wantLabels []string
+		wantErr    string
+	}{
+		{
+			name: "var used by multiple labels",
+			vars: config.Vars{
+				"modules": `len(Modules)`,
+			},
+			wantVars:   map[string]any{"modules": 3},
+			wantLabels: []string{"many-modules", "multiple-modules"},
+		},
+		{
+			name: "vars are evaluated in dependency order",
+			vars: config.Vars{
+				"a_many":  `vars.modules > 2`,
+				"modules": `vars["count"]`,
+				"count":   `len(Modules)`,
+			},
+			wantVars:   map[string]any{"a_many": true, "modules": 3, "count": 3},
+			wantLabels: []string{"many-modules", "multiple-modules"},
+		},
+		{
+			name: "cyclic reference",
+			vars: config.Vars{
+				"a":       `vars.b`,
+				"b":       `vars.c`,
+				"c":       `vars.a`,
+				"modules": `1`,
+			},
+			wantErr: "evaluation failed: vars have a cyclic reference: a -> b -> c -> a",
+		},
+		{
+			name: "self reference",
+			vars: config.Vars{
+				"modules": `vars.modules + 1`,
+			},
+			wantErr: "evaluation failed: vars have a cyclic reference: modules -> modules",
+		},
+		{
+			name: "unknown var",
+			vars: config.Vars{
+				"modules": `vars.count`,
+			},
+			wantErr: `evaluation failed: var "modules" uses unknown var "count"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			evalContext := &testEvalContext{Modules: []string{"api", "web", "cli"}}
+
+			cfg := config.Config{
+				Vars: tt.vars,
+				Labels: config.Labels{
+					{Name: "many-modules", Script: `vars.modules > 2`},
+					{Name: "multiple-modules", Script: `vars.modules > 1`},
+					{Name: "single-module", Script: `vars.modules == 1`},
+				},
+			}
+
+			labels, _, err := cfg.Evaluate(context.Background(), evalContext)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				require.Error(t, cfg.Lint(context.Background(), evalContext))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, cfg.Lint(context.Background(), evalContext))
+			require.Equal(t, tt.wantVars, evalContext.Vars)
+
+			var matched []string
+
+			for _, label := range labels {
+				if label.Matched {
+					matched = append(matched, label.Name)
+				}
+			}
+
+			require.Equal(t, tt.wantLabels, matched)
+		})
+	}
+}
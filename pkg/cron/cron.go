@@ -0,0 +1,209 @@
+// Package cron parses standard 5-field cron expressions and computes their activation times
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors are the supported shorthands for common schedules
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7}, // both 0 and 7 are Sunday
+}
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64
+
+	// Whether the day of month or day of week fields were restricted (not starting with '*');
+	// if both are, a time matches when either of them match (like cron does)
+	daysRestricted, weekdaysRestricted bool
+}
+
+// Parse parses a cron expression in the standard "minute hour day-of-month month day-of-week" format
+//
+// Every field supports '*', single values, ranges ('1-5'), steps ('*/15' or '0-30/10') and lists ('1,15').
+// The '@yearly', '@monthly', '@weekly', '@daily' and '@hourly' shorthands are supported as well.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if descriptor, ok := descriptors[spec]; ok {
+		spec = descriptor
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("unknown cron descriptor %q", spec)
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields (minute, hour, day of month, month, day of week), got %d", spec, len(fields), len(parts))
+	}
+
+	bits := make([]uint64, len(fields))
+
+	for i, part := range parts {
+		value, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+
+		bits[i] = value
+	}
+
+	schedule := &Schedule{
+		minutes:            bits[0],
+		hours:              bits[1],
+		days:               bits[2],
+		months:             bits[3],
+		weekdays:           bits[4],
+		daysRestricted:     !strings.HasPrefix(parts[2], "*"),
+		weekdaysRestricted: !strings.HasPrefix(parts[4], "*"),
+	}
+
+	// Sunday may be written as both 0 and 7
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+
+	return schedule, nil
+}
+
+func parseField(in string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(in, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value < 1 {
+				return 0, fmt.Errorf("%s step %q must be a positive number", f.name, stepPart)
+			}
+
+			step = value
+		}
+
+		low, high := f.min, f.max
+
+		switch {
+		case rangePart == "*":
+
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+
+			var err error
+
+			if low, err = parseValue(from, f); err != nil {
+				return 0, err
+			}
+
+			if high, err = parseValue(to, f); err != nil {
+				return 0, err
+			}
+
+			if low > high {
+				return 0, fmt.Errorf("%s range %q must not end before it starts", f.name, rangePart)
+			}
+
+		default:
+			value, err := parseValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+
+			low = value
+
+			// "5/10" means "every 10th starting at 5", while "5" alone is just "5"
+			if !hasStep {
+				high = value
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(in string, f field) (int, error) {
+	value, err := strconv.Atoi(in)
+	if err != nil {
+		return 0, fmt.Errorf("%s value %q must be a number", f.name, in)
+	}
+
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%s value %d must be between %d and %d", f.name, value, f.min, f.max)
+	}
+
+	return value, nil
+}
+
+// ErrNoActivation is returned by [Schedule.Next] if the schedule never activates (e.g. February 30th)
+var ErrNoActivation = errors.New("cron schedule never activates")
+
+// Next returns the first activation time strictly after [after], in the location of [after]
+func (s *Schedule) Next(after time.Time) (time.Time, error) {
+	// Cron has a minute granularity, so start at the beginning of the next minute
+	next := after.Truncate(time.Minute).Add(time.Minute)
+
+	// Any valid schedule activates within a few years (leap days being the worst case)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		switch {
+		case s.months&(1<<uint(next.Month())) == 0:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+
+		case !s.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+
+		case s.hours&(1<<uint(next.Hour())) == 0:
+			// Advance in absolute time, since wall clock hours may be skipped by daylight saving time
+			next = next.Add(time.Duration(60-next.Minute()) * time.Minute)
+
+		case s.minutes&(1<<uint(next.Minute())) == 0:
+			next = next.Add(time.Minute)
+
+		default:
+			return next, nil
+		}
+	}
+
+	return time.Time{}, ErrNoActivation
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+
+	return day && weekday
+}
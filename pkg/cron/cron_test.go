@@ -0,0 +1,124 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/cron"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{spec: "*/15 * * * *"},
+		{spec: "0 9-17 * * 1-5"},
+		{spec: "30 2 1,15 * 7"},
+		{spec: "@daily"},
+		{spec: "* * * *", wantErr: `cron expression "* * * *" must have 5 fields (minute, hour, day of month, month, day of week), got 4`},
+		{spec: "60 * * * *", wantErr: `invalid cron expression "60 * * * *": minute value 60 must be between 0 and 59`},
+		{spec: "* 5-1 * * *", wantErr: `invalid cron expression "* 5-1 * * *": hour range "5-1" must not end before it starts`},
+		{spec: "*/0 * * * *", wantErr: `invalid cron expression "*/0 * * * *": minute step "0" must be a positive number`},
+		{spec: "* * * jan *", wantErr: `invalid cron expression "* * * jan *": month value "jan" must be a number`},
+		{spec: "@sometimes", wantErr: `unknown cron descriptor "@sometimes"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := cron.Parse(tt.spec)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "every 15 minutes",
+			spec:  "*/15 * * * *",
+			after: time.Date(2024, 3, 4, 10, 7, 30, 0, time.UTC),
+			want:  time.Date(2024, 3, 4, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:  "activation time itself is excluded",
+			spec:  "*/15 * * * *",
+			after: time.Date(2024, 3, 4, 10, 15, 0, 0, time.UTC),
+			want:  time.Date(2024, 3, 4, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekday mornings skip the weekend",
+			spec:  "0 9 * * 1-5",
+			after: time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC), // Friday
+			want:  time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "day of month or day of week",
+			spec:  "0 0 13 * 5",
+			after: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), // Friday
+			want:  time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "sunday as 7",
+			spec:  "0 12 * * 7",
+			after: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			want:  time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "leap day",
+			spec:  "0 0 29 2 *",
+			after: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:  time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "wall clock time across daylight saving time",
+			spec:  "0 9 * * *",
+			after: time.Date(2024, 3, 9, 10, 0, 0, 0, newYork),
+			want:  time.Date(2024, 3, 10, 9, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			schedule, err := cron.Parse(tt.spec)
+			require.NoError(t, err)
+
+			next, err := schedule.Next(tt.after)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, next)
+		})
+	}
+}
+
+func TestSchedule_Next_NoActivation(t *testing.T) {
+	t.Parallel()
+
+	schedule, err := cron.Parse("0 0 30 2 *")
+	require.NoError(t, err)
+
+	_, err = schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, cron.ErrNoActivation)
+}
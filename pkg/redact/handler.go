@@ -0,0 +1,74 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Handler is a [slog.Handler] middleware scrubbing the registered secrets from the message and attributes of every log record
+type Handler struct {
+	next slog.Handler
+}
+
+// NewHandler wraps [next] so the registered secrets never reach it
+func NewHandler(next slog.Handler) slog.Handler {
+	return &Handler{next: next}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, String(record.Message), record.PC)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+
+		return true
+	})
+
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+
+	return &Handler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, String(value.String()))
+
+	case slog.KindGroup:
+		group := value.Group()
+
+		redacted := make([]any, 0, len(group))
+		for _, child := range group {
+			redacted = append(redacted, redactAttr(child))
+		}
+
+		return slog.Group(attr.Key, redacted...)
+
+	case slog.KindAny:
+		// Errors (and other values) can embed secrets in their text, e.g. a URL with a token in an API client error
+		text := fmt.Sprint(value.Any())
+		if redacted := String(text); redacted != text {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,58 @@
+// Package redact scrubs secret values (like API tokens and webhook secrets) from log lines and error output
+package redact
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces every secret value
+const Placeholder = "<redacted>"
+
+// Values shorter than this are not registered, to avoid scrubbing common words from every log line
+const minSecretLength = 4
+
+//nolint:gochecknoglobals
+var (
+	mu       sync.RWMutex
+	secrets  []string
+	replacer = strings.NewReplacer()
+)
+
+// Register adds [values] to the secrets scrubbed by [String] and the log [Handler].
+//
+// Empty and very short values are ignored
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, value := range values {
+		if len(value) < minSecretLength || slices.Contains(secrets, value) {
+			continue
+		}
+
+		secrets = append(secrets, value)
+	}
+
+	// Replace the longest secrets first, so a secret containing another secret is fully redacted
+	slices.SortFunc(secrets, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+
+	pairs := make([]string, 0, len(secrets)*2)
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, Placeholder)
+	}
+
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// String returns [in] with every registered secret replaced by [Placeholder]
+func String(in string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return replacer.Replace(in)
+}
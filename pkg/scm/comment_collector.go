@@ -0,0 +1,51 @@
+package scm
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type commentCollectorKey struct{}
+
+// CommentCollector gathers the messages of 'comment' action steps, so they can be posted as part of a
+// single report comment rather than as separate comments
+type CommentCollector struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// WithCommentCollector returns a context where 'comment' action steps are added to the returned [CommentCollector]
+func WithCommentCollector(ctx context.Context) (context.Context, *CommentCollector) {
+	collector := &CommentCollector{}
+
+	return context.WithValue(ctx, commentCollectorKey{}, collector), collector
+}
+
+// CollectComment adds the [message] to the [CommentCollector] of the context, or returns false if
+// there is none and the comment must be posted
+func CollectComment(ctx context.Context, message string) bool {
+	collector, ok := ctx.Value(commentCollectorKey{}).(*CommentCollector)
+	if !ok {
+		return false
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.messages = append(collector.messages, message)
+
+	return true
+}
+
+// Messages returns the collected messages, in the order they were collected
+func (c *CommentCollector) Messages() []string {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.messages)
+}
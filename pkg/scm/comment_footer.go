@@ -0,0 +1,28 @@
+package scm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// CommentFooterMarker separates a comment body from the 'comment_footer' appended to it
+const CommentFooterMarker = "<!-- scm-engine:footer -->"
+
+// AppendCommentFooter appends the configured 'comment_footer' (see [state.CommentFooter]) to [body].
+//
+// Any footer already in [body] is replaced, so appending is idempotent and notes being
+// updated in place never end up with more than one footer.
+func AppendCommentFooter(ctx context.Context, body string) string {
+	footer := state.CommentFooter(ctx)
+	if len(footer) == 0 {
+		return body
+	}
+
+	if before, _, found := strings.Cut(body, CommentFooterMarker); found {
+		body = strings.TrimRight(before, "\n")
+	}
+
+	return body + "\n\n" + CommentFooterMarker + "\n" + footer
+}
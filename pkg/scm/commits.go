@@ -0,0 +1,98 @@
+package scm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Commit is a commit in a Merge Request, as exposed to scripts
+type Commit struct {
+	// SHA1 ID of the commit
+	SHA string `expr:"sha"`
+	// Title (first line) of the commit message
+	Title string `expr:"title"`
+	// Raw commit message
+	Message string `expr:"message"`
+	// Name of the commit author
+	AuthorName string `expr:"author_name"`
+	// Email of the commit author
+	AuthorEmail string `expr:"author_email"`
+	// Trailers (e.g. "Signed-off-by") from the last paragraph of the commit message
+	Trailers map[string][]string `expr:"trailers"`
+}
+
+// HasTrailer checks if the commit has the trailer [name] (case-insensitive, like git)
+func (c Commit) HasTrailer(name string) bool {
+	for key := range c.Trailers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var trailerRegexp = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// ParseTrailers parses the git trailers in the last paragraph of [message].
+//
+// The paragraph is only considered a trailer block if every line is a "Key: value" trailer
+// (or a continuation line of one), and it's not the subject of the commit.
+func ParseTrailers(message string) map[string][]string {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+
+	var (
+		trailers = map[string][]string{}
+		lastKey  string
+	)
+
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		// Continuation of the previous trailer value
+		if len(lastKey) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			values := trailers[lastKey]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+
+			continue
+		}
+
+		match := trailerRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+
+		lastKey = match[1]
+		trailers[lastKey] = append(trailers[lastKey], strings.TrimSpace(match[2]))
+	}
+
+	return trailers
+}
+
+// AllCommitsHaveTrailer checks if every commit has the trailer [name]
+func AllCommitsHaveTrailer(commits []Commit, name string) bool {
+	for _, commit := range commits {
+		if !commit.HasTrailer(name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AnyCommitMessageMatches checks if any commit message matches the regular expression [pattern]
+func AnyCommitMessageMatches(commits []Commit, pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	for _, commit := range commits {
+		if re.MatchString(commit.Message) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
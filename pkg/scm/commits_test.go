@@ -0,0 +1,83 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrailers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		message string
+		want    map[string][]string
+	}{
+		{
+			name:    "subject only",
+			message: "Signed-off-by: Jane Doe <jane@example.com>",
+			want:    nil,
+		},
+		{
+			name:    "no trailers",
+			message: "Fix the thing\n\nIt was broken.",
+			want:    nil,
+		},
+		{
+			name:    "trailers",
+			message: "Fix the thing\n\nIt was broken.\n\nSigned-off-by: Jane Doe <jane@example.com>\nCo-authored-by: John Doe <john@example.com>\nSigned-off-by: John Doe <john@example.com>\n",
+			want: map[string][]string{
+				"Signed-off-by":  {"Jane Doe <jane@example.com>", "John Doe <john@example.com>"},
+				"Co-authored-by": {"John Doe <john@example.com>"},
+			},
+		},
+		{
+			name:    "continuation lines",
+			message: "Fix the thing\n\nFixes: a very long\n  description",
+			want: map[string][]string{
+				"Fixes": {"a very long description"},
+			},
+		},
+		{
+			name:    "mixed paragraph is not a trailer block",
+			message: "Fix the thing\n\nSee the issue for details\nSigned-off-by: Jane Doe <jane@example.com>",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, scm.ParseTrailers(tt.message))
+		})
+	}
+}
+
+func TestCommitHelpers(t *testing.T) {
+	t.Parallel()
+
+	newCommit := func(message string) scm.Commit {
+		return scm.Commit{Message: message, Trailers: scm.ParseTrailers(message)}
+	}
+
+	signed := newCommit("Add feature\n\nsigned-off-by: Jane Doe <jane@example.com>")
+	unsigned := newCommit("WIP: fix tests")
+
+	require.True(t, scm.AllCommitsHaveTrailer([]scm.Commit{signed, signed}, "Signed-off-by"))
+	require.False(t, scm.AllCommitsHaveTrailer([]scm.Commit{signed, unsigned}, "Signed-off-by"))
+	require.True(t, scm.AllCommitsHaveTrailer(nil, "Signed-off-by"))
+
+	matched, err := scm.AnyCommitMessageMatches([]scm.Commit{signed, unsigned}, `^WIP:`)
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	matched, err = scm.AnyCommitMessageMatches([]scm.Commit{signed}, `^WIP:`)
+	require.NoError(t, err)
+	require.False(t, matched)
+
+	_, err = scm.AnyCommitMessageMatches([]scm.Commit{signed}, `(`)
+	require.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package scm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dependency is a Merge Request that must be merged before the evaluated Merge Request (or, for the 'blocked' source,
+// a Merge Request waiting for the evaluated Merge Request to be merged), as exposed to scripts
+type Dependency struct {
+	// Full path of the project the Merge Request belongs to
+	Project string `expr:"project"`
+	// Internal ID of the Merge Request within its project
+	IID int `expr:"iid"`
+	// Reference to the Merge Request (example: 'gitlab-org/gitlab!123')
+	Reference string `expr:"reference"`
+	// Title of the Merge Request
+	Title string `expr:"title"`
+	// State of the Merge Request (example: 'opened', 'merged' or 'closed')
+	State string `expr:"state"`
+	// Web URL of the Merge Request
+	WebURL string `expr:"web_url"`
+	// Where the dependency was found; 'dependency' for GitLab Merge Request dependencies, 'description' for description links,
+	// and 'blocked' for Merge Requests that have the evaluated Merge Request as a GitLab Merge Request dependency
+	Source string `expr:"source"`
+}
+
+// IsMerged returns true if the dependency has been merged
+func (d Dependency) IsMerged() bool {
+	return d.State == "merged"
+}
+
+// DependencyReference points at a Merge Request in [Project] with the internal ID [IID]
+type DependencyReference struct {
+	Project string
+	IID     int
+}
+
+// String returns the GitLab style reference (example: 'gitlab-org/gitlab!123')
+func (r DependencyReference) String() string {
+	return r.Project + "!" + strconv.Itoa(r.IID)
+}
+
+// dependencyLineRegexp matches the description lines declaring dependencies (example: 'Depends on !123')
+var dependencyLineRegexp = regexp.MustCompile(`(?i)\b(?:depends on|blocked by)\b:?(.*)$`)
+
+// dependencyReferenceRegexp matches Merge Request URLs and references, with or without a project path
+var dependencyReferenceRegexp = regexp.MustCompile(`https?://[^/\s]+/([\w.\-/]+?)/-/merge_requests/(\d+)|([\w.\-]+(?:/[\w.\-]+)+)?!(\d+)`)
+
+// ParseDependencyReferences returns the Merge Requests referenced on 'Depends on' and 'Blocked by' lines
+// in the [description] (outside fenced code blocks), in the order they appear and without duplicates.
+//
+// References without a project path (example: '!123') belong to [project]
+func ParseDependencyReferences(description, project string) []DependencyReference {
+	var references []DependencyReference
+
+	seen := map[string]bool{}
+
+	for _, line := range descriptionLines(description) {
+		if line.fenced {
+			continue
+		}
+
+		declaration := dependencyLineRegexp.FindStringSubmatch(line.text)
+		if declaration == nil {
+			continue
+		}
+
+		for _, match := range dependencyReferenceRegexp.FindAllStringSubmatch(declaration[1], -1) {
+			reference := DependencyReference{Project: project}
+
+			switch {
+			case len(match[2]) > 0:
+				reference.Project = match[1]
+				reference.IID, _ = strconv.Atoi(match[2])
+
+			default:
+				if len(match[3]) > 0 {
+					reference.Project = match[3]
+				}
+
+				reference.IID, _ = strconv.Atoi(match[4])
+			}
+
+			key := strings.ToLower(reference.String())
+			if reference.IID == 0 || seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			references = append(references, reference)
+		}
+	}
+
+	return references
+}
+
+// AllDependenciesMerged returns true if every dependency has been merged, including when there are none
+func AllDependenciesMerged(dependencies []Dependency) bool {
+	for _, dependency := range dependencies {
+		if !dependency.IsMerged() {
+			return false
+		}
+	}
+
+	return true
+}
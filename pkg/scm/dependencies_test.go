@@ -0,0 +1,39 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDependencyReferences(t *testing.T) {
+	t.Parallel()
+
+	description := `Part 3 of the refactoring.
+
+Depends on !12, jippi/other-project!4 and https://gitlab.com/jippi/scm-engine/-/merge_requests/13
+blocked by: !12
+
+Mentions !99 without declaring a dependency.
+
+` + "```" + `
+Depends on !100
+` + "```"
+
+	require.Equal(t, []scm.DependencyReference{
+		{Project: "jippi/scm-engine", IID: 12},
+		{Project: "jippi/other-project", IID: 4},
+		{Project: "jippi/scm-engine", IID: 13},
+	}, scm.ParseDependencyReferences(description, "jippi/scm-engine"))
+
+	require.Empty(t, scm.ParseDependencyReferences("No dependencies", "jippi/scm-engine"))
+}
+
+func TestAllDependenciesMerged(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, scm.AllDependenciesMerged(nil))
+	require.True(t, scm.AllDependenciesMerged([]scm.Dependency{{State: "merged"}, {State: "merged"}}))
+	require.False(t, scm.AllDependenciesMerged([]scm.Dependency{{State: "merged"}, {State: "opened"}}))
+}
@@ -0,0 +1,97 @@
+package scm
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	checkboxRegexp = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s+(.*)$`)
+	headingRegexp  = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+)
+
+// DescriptionCheckboxChecked checks if the Markdown task list item [label] in [description] is checked.
+//
+// The label is matched case-insensitively, ignoring surrounding whitespace. Returns false if there is no such checkbox.
+func DescriptionCheckboxChecked(description, label string) bool {
+	label = strings.TrimSpace(label)
+
+	for _, line := range descriptionLines(description) {
+		if line.fenced {
+			continue
+		}
+
+		match := checkboxRegexp.FindStringSubmatch(line.text)
+		if match == nil {
+			continue
+		}
+
+		if strings.EqualFold(strings.TrimSpace(match[2]), label) {
+			return match[1] != " "
+		}
+	}
+
+	return false
+}
+
+// DescriptionSection returns the content below the Markdown heading [heading] in [description],
+// up to the next heading of the same or a higher level.
+//
+// The heading is matched case-insensitively, at any level. Returns an empty string if there is no such section.
+func DescriptionSection(description, heading string) string {
+	heading = strings.TrimSpace(heading)
+
+	var (
+		content []string
+		level   int // level of the matched heading, 0 while the section wasn't found yet
+	)
+
+	for _, line := range descriptionLines(description) {
+		var match []string
+		if !line.fenced {
+			match = headingRegexp.FindStringSubmatch(line.text)
+		}
+
+		if level == 0 {
+			if match != nil && strings.EqualFold(match[2], heading) {
+				level = len(match[1])
+			}
+
+			continue
+		}
+
+		if match != nil && len(match[1]) <= level {
+			break
+		}
+
+		content = append(content, line.text)
+	}
+
+	return strings.TrimSpace(strings.Join(content, "\n"))
+}
+
+type descriptionLine struct {
+	text string
+
+	// Whether the line is within a fenced code block, where checkboxes and headings are just examples
+	fenced bool
+}
+
+// descriptionLines splits [description] into lines, tracking which lines are within fenced code blocks
+func descriptionLines(description string) []descriptionLine {
+	var (
+		lines  []descriptionLine
+		fenced bool
+	)
+
+	for _, text := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		fence := strings.HasPrefix(strings.TrimSpace(text), "```") || strings.HasPrefix(strings.TrimSpace(text), "~~~")
+		if fence {
+			fenced = !fenced
+		}
+
+		lines = append(lines, descriptionLine{text: text, fenced: fenced || fence})
+	}
+
+	return lines
+}
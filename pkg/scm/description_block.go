@@ -0,0 +1,46 @@
+package scm
+
+import (
+	"strings"
+)
+
+// Markers around the block managed by scm-engine at the top of the Merge Request description
+const (
+	DescriptionBlockStartMarker = "<!-- scm-engine:description-block:start -->"
+	DescriptionBlockEndMarker   = "<!-- scm-engine:description-block:end -->"
+)
+
+// InjectDescriptionBlock puts [block] between the managed block markers at the top of [description],
+// replacing the managed block already in [description] (if any).
+//
+// Everything outside the markers is written by the Merge Request author, and is kept as-is.
+func InjectDescriptionBlock(description, block string) string {
+	managed := DescriptionBlockStartMarker + "\n" + strings.TrimSpace(block) + "\n" + DescriptionBlockEndMarker
+
+	userContent := strings.TrimLeft(StripDescriptionBlock(description), "\n")
+	if len(userContent) == 0 {
+		return managed
+	}
+
+	return managed + "\n\n" + userContent
+}
+
+// StripDescriptionBlock returns [description] without the block managed by scm-engine
+func StripDescriptionBlock(description string) string {
+	before, rest, found := strings.Cut(description, DescriptionBlockStartMarker)
+	if !found {
+		return description
+	}
+
+	_, after, found := strings.Cut(rest, DescriptionBlockEndMarker)
+	if !found {
+		// A broken block (missing its end marker) is only the start marker, so no user content is lost
+		after = rest
+	}
+
+	if len(strings.TrimSpace(before)) == 0 {
+		return strings.TrimLeft(after, "\n")
+	}
+
+	return before + strings.TrimLeft(after, "\n")
+}
@@ -0,0 +1,76 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectDescriptionBlock(t *testing.T) {
+	t.Parallel()
+
+	const (
+		start = scm.DescriptionBlockStartMarker
+		end   = scm.DescriptionBlockEndMarker
+	)
+
+	tests := []struct {
+		name        string
+		description string
+		block       string
+		want        string
+	}{
+		{
+			name:        "empty description",
+			description: "",
+			block:       "report v1",
+			want:        start + "\nreport v1\n" + end,
+		},
+		{
+			name:        "prepended to the user content",
+			description: "## Summary\n\nFixes the thing.\n",
+			block:       "report v1\n",
+			want:        start + "\nreport v1\n" + end + "\n\n## Summary\n\nFixes the thing.\n",
+		},
+		{
+			name:        "existing block is updated in place",
+			description: start + "\nreport v1\n" + end + "\n\n## Summary\n\nFixes the thing.\n",
+			block:       "report v2",
+			want:        start + "\nreport v2\n" + end + "\n\n## Summary\n\nFixes the thing.\n",
+		},
+		{
+			name:        "user edits around the block are kept",
+			description: "Written first\n\n" + start + "\nreport v1\n" + end + "\n\n## Summary\n",
+			block:       "report v2",
+			want:        start + "\nreport v2\n" + end + "\n\nWritten first\n\n## Summary\n",
+		},
+		{
+			name:        "block without an end marker",
+			description: start + "\n## Summary\n",
+			block:       "report v2",
+			want:        start + "\nreport v2\n" + end + "\n\n## Summary\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := scm.InjectDescriptionBlock(tt.description, tt.block)
+			require.Equal(t, tt.want, got)
+
+			// Injecting the same block again doesn't change the description
+			require.Equal(t, got, scm.InjectDescriptionBlock(got, tt.block))
+		})
+	}
+}
+
+func TestStripDescriptionBlock(t *testing.T) {
+	t.Parallel()
+
+	description := scm.InjectDescriptionBlock("## Summary\n\nFixes the thing.\n", "report")
+
+	require.Equal(t, "## Summary\n\nFixes the thing.\n", scm.StripDescriptionBlock(description))
+	require.Equal(t, "no block", scm.StripDescriptionBlock("no block"))
+}
@@ -0,0 +1,104 @@
+package scm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultDescriptionSectionPlaceholder is the content of sections injected by [InjectDescriptionSections]
+const DefaultDescriptionSectionPlaceholder = "<!-- Please fill in this section -->"
+
+var htmlCommentRegexp = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// DescriptionTemplateResult is the outcome of [CheckDescriptionTemplate]
+type DescriptionTemplateResult struct {
+	// Required sections without a heading in the description
+	Missing []string
+
+	// Required sections with a heading, but no content besides placeholders and HTML comments
+	Empty []string
+
+	// Placeholders of the template still in the description
+	Placeholders []string
+}
+
+// Satisfied returns whether the description follows the template
+func (r DescriptionTemplateResult) Satisfied() bool {
+	return len(r.Missing) == 0 && len(r.Empty) == 0 && len(r.Placeholders) == 0
+}
+
+// CheckDescriptionTemplate checks that [description] has a Markdown heading with content for every one of the [sections],
+// and none of the template [placeholders] (example: 'TODO: describe your change') are left.
+//
+// Sections are matched like [DescriptionSection]; HTML comments and placeholders in a section don't count as content
+func CheckDescriptionTemplate(description string, sections, placeholders []string) DescriptionTemplateResult {
+	var result DescriptionTemplateResult
+
+	for _, section := range sections {
+		if !hasDescriptionHeading(description, section) {
+			result.Missing = append(result.Missing, section)
+
+			continue
+		}
+
+		content := htmlCommentRegexp.ReplaceAllString(DescriptionSection(description, section), "")
+		for _, placeholder := range placeholders {
+			content = strings.ReplaceAll(content, placeholder, "")
+		}
+
+		if len(strings.TrimSpace(content)) == 0 {
+			result.Empty = append(result.Empty, section)
+		}
+	}
+
+	for _, placeholder := range placeholders {
+		if len(placeholder) > 0 && strings.Contains(description, placeholder) {
+			result.Placeholders = append(result.Placeholders, placeholder)
+		}
+	}
+
+	return result
+}
+
+// InjectDescriptionSections appends a level 2 heading for each of the [missing] sections to [description], with [placeholder]
+// as its content. The existing description is kept as-is, so nothing the author wrote is lost
+func InjectDescriptionSections(description string, missing []string, placeholder string) string {
+	if len(missing) == 0 {
+		return description
+	}
+
+	var out strings.Builder
+
+	out.WriteString(strings.TrimRight(description, "\n"))
+
+	for _, section := range missing {
+		if out.Len() > 0 {
+			out.WriteString("\n\n")
+		}
+
+		out.WriteString("## " + strings.TrimSpace(section))
+
+		if len(placeholder) > 0 {
+			out.WriteString("\n\n" + placeholder)
+		}
+	}
+
+	return out.String()
+}
+
+// hasDescriptionHeading checks if [description] has a Markdown heading [heading] outside fenced code blocks, at any level
+func hasDescriptionHeading(description, heading string) bool {
+	heading = strings.TrimSpace(heading)
+
+	for _, line := range descriptionLines(description) {
+		if line.fenced {
+			continue
+		}
+
+		if match := headingRegexp.FindStringSubmatch(line.text); match != nil && strings.EqualFold(match[2], heading) {
+			return true
+		}
+	}
+
+	return false
+}
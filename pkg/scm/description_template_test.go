@@ -0,0 +1,78 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDescriptionTemplate(t *testing.T) {
+	t.Parallel()
+
+	sections := []string{"Summary", "Testing"}
+	placeholders := []string{"TODO: describe your change"}
+
+	tests := []struct {
+		name        string
+		description string
+		want        scm.DescriptionTemplateResult
+	}{
+		{
+			name:        "compliant",
+			description: "## Summary\n\nAdds a feature\n\n### Testing\n\n- [x] Unit tests",
+		},
+		{
+			name:        "headings are matched case-insensitively, with sub-sections as content",
+			description: "# summary #\n\n## Details\n\nAdds a feature\n\n# TESTING\n\nManually",
+		},
+		{
+			name:        "missing section",
+			description: "## Summary\n\nAdds a feature\n\n```\n## Testing\n```",
+			want:        scm.DescriptionTemplateResult{Missing: []string{"Testing"}},
+		},
+		{
+			name:        "empty sections",
+			description: "## Summary\n\n<!-- What does this change? -->\n\n## Testing\n\nTODO: describe your change",
+			want: scm.DescriptionTemplateResult{
+				Empty:        []string{"Summary", "Testing"},
+				Placeholders: []string{"TODO: describe your change"},
+			},
+		},
+		{
+			name:        "placeholder left next to content",
+			description: "## Summary\n\nAdds a feature. TODO: describe your change\n\n## Testing\n\nManually",
+			want:        scm.DescriptionTemplateResult{Placeholders: []string{"TODO: describe your change"}},
+		},
+		{
+			name: "empty description",
+			want: scm.DescriptionTemplateResult{Missing: []string{"Summary", "Testing"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := scm.CheckDescriptionTemplate(tt.description, sections, placeholders)
+			require.Equal(t, tt.want, result)
+			require.Equal(t, len(tt.want.Missing)+len(tt.want.Empty)+len(tt.want.Placeholders) == 0, result.Satisfied())
+		})
+	}
+}
+
+func TestInjectDescriptionSections(t *testing.T) {
+	t.Parallel()
+
+	description := "Fixes the login page\n\n## Summary\n\nThe button was broken\n"
+
+	injected := scm.InjectDescriptionSections(description, []string{"Testing", "Rollback"}, scm.DefaultDescriptionSectionPlaceholder)
+	require.Equal(t, "Fixes the login page\n\n## Summary\n\nThe button was broken\n\n## Testing\n\n<!-- Please fill in this section -->\n\n## Rollback\n\n<!-- Please fill in this section -->", injected)
+
+	// The injected sections exist, but still need to be filled in
+	result := scm.CheckDescriptionTemplate(injected, []string{"Summary", "Testing", "Rollback"}, nil)
+	require.Equal(t, scm.DescriptionTemplateResult{Empty: []string{"Testing", "Rollback"}}, result)
+
+	require.Equal(t, "## Testing", scm.InjectDescriptionSections("", []string{"Testing"}, ""))
+	require.Equal(t, description, scm.InjectDescriptionSections(description, nil, scm.DefaultDescriptionSectionPlaceholder))
+}
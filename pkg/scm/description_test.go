@@ -0,0 +1,83 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+const testDescription = `## Summary
+
+Fixes the thing.
+
+### Details
+
+It was broken.
+
+## Testing checklist
+
+- [x] Unit tests added
+* [X] Integration tests pass
+- [ ] Manually tested in staging
+1. [ ] Load tested
+
+` + "```markdown" + `
+- [x] Manually tested in staging
+## Rollback
+` + "```" + `
+
+## Rollback
+
+Revert the Merge Request.
+`
+
+func TestDescriptionCheckboxChecked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{label: "Unit tests added", want: true},
+		{label: "integration tests pass", want: true},
+		{label: "  Unit tests added  ", want: true},
+		{label: "Manually tested in staging", want: false},
+		{label: "Load tested", want: false},
+		{label: "Unit tests", want: false},
+		{label: "Missing checkbox", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, scm.DescriptionCheckboxChecked(testDescription, tt.label))
+		})
+	}
+}
+
+func TestDescriptionSection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{heading: "Summary", want: "Fixes the thing.\n\n### Details\n\nIt was broken."},
+		{heading: "details", want: "It was broken."},
+		{heading: "Rollback", want: "Revert the Merge Request."},
+		{heading: "Testing checklist", want: "- [x] Unit tests added\n* [X] Integration tests pass\n- [ ] Manually tested in staging\n1. [ ] Load tested\n\n```markdown\n- [x] Manually tested in staging\n## Rollback\n```"},
+		{heading: "Missing", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.heading, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, scm.DescriptionSection(testDescription, tt.heading))
+		})
+	}
+
+	require.Empty(t, scm.DescriptionSection("", "Summary"))
+}
@@ -0,0 +1,83 @@
+package scm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxDiffContentBytes caps the added and removed lines kept by [DiffContent], so very large Merge Requests
+// don't use unbounded memory (or time, when matching patterns)
+const MaxDiffContentBytes = 1 << 20
+
+// DiffContent is the added and removed lines of a Merge Request diff, without the '+' and '-' prefixes
+type DiffContent struct {
+	// Lines added by the Merge Request
+	Added []string
+
+	// Lines removed by the Merge Request
+	Removed []string
+
+	// Whether lines were left out, because the diff is larger than [MaxDiffContentBytes],
+	// or GitLab didn't return the diff of a file (for example, because it's too large)
+	Truncated bool
+
+	size int
+	full bool
+}
+
+// Full returns whether [MaxDiffContentBytes] is reached, and no more lines are added
+func (d *DiffContent) Full() bool {
+	return d.full
+}
+
+// AddFileDiff adds the lines of the unified [diff] of a single file, stopping (and marking the content
+// as truncated) once [MaxDiffContentBytes] is reached
+func (d *DiffContent) AddFileDiff(diff string) {
+	for _, line := range strings.Split(diff, "\n") {
+		if d.full {
+			return
+		}
+
+		var target *[]string
+
+		switch {
+		// File headers, in case the diff includes them
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+			continue
+
+		case strings.HasPrefix(line, "+"):
+			target = &d.Added
+
+		case strings.HasPrefix(line, "-"):
+			target = &d.Removed
+
+		default:
+			continue
+		}
+
+		if d.size+len(line)-1 > MaxDiffContentBytes {
+			d.full, d.Truncated = true, true
+
+			return
+		}
+
+		d.size += len(line) - 1
+		*target = append(*target, line[1:])
+	}
+}
+
+// AnyLineMatches checks if any of the [lines] matches the regular expression [pattern]
+func AnyLineMatches(lines []string, pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	for _, line := range lines {
+		if re.MatchString(line) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
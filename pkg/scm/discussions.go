@@ -0,0 +1,34 @@
+package scm
+
+// Discussion is a thread on a Merge Request, as exposed to scripts
+type Discussion struct {
+	// ID of the discussion
+	ID string `expr:"id"`
+	// Username of the author of the first note in the discussion
+	AuthorUsername string `expr:"author_username"`
+	// Wether the discussion can be resolved (e.g. a review thread, not a system note)
+	Resolvable bool `expr:"resolvable"`
+	// Wether the discussion has been resolved
+	Resolved bool `expr:"resolved"`
+}
+
+// UnresolvedDiscussions returns the resolvable discussions that are not resolved yet.
+//
+// When [author] is not empty, only discussions started by that username are returned.
+func UnresolvedDiscussions(discussions []Discussion, author string) []Discussion {
+	var unresolved []Discussion
+
+	for _, discussion := range discussions {
+		if !discussion.Resolvable || discussion.Resolved {
+			continue
+		}
+
+		if len(author) > 0 && discussion.AuthorUsername != author {
+			continue
+		}
+
+		unresolved = append(unresolved, discussion)
+	}
+
+	return unresolved
+}
@@ -0,0 +1,47 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrConfigFileNotFound is returned when the scm-engine configuration file does not exist in the repository
+var ErrConfigFileNotFound = errors.New("scm-engine configuration file not found")
+
+// ErrSkipAction is returned (wrapped) when the remaining steps of an action should be skipped, without failing the evaluation
+var ErrSkipAction = errors.New("action skipped")
+
+// DeferredError is returned when an action can't be applied yet, and the evaluation
+// of the Merge Request should be re-queued and tried again after [Delay]
+type DeferredError struct {
+	Reason       string
+	Delay        time.Duration
+	MaxDeferrals int
+}
+
+func (e *DeferredError) Error() string {
+	return fmt.Sprintf("action deferred: %s", e.Reason)
+}
+
+// HeadChangedError is returned when the head commit of a Merge Request changed while it was being evaluated,
+// meaning the evaluation outcome is based on stale data
+type HeadChangedError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *HeadChangedError) Error() string {
+	return fmt.Sprintf("merge request head commit changed from %q to %q during evaluation", e.Expected, e.Actual)
+}
+
+// CheckHeadSHA returns a [HeadChangedError] if [actual] is not the [expected] head commit.
+//
+// An empty [expected] value means the evaluation was not pinned to a commit, and is never considered stale.
+func CheckHeadSHA(expected, actual string) error {
+	if len(expected) == 0 || expected == actual {
+		return nil
+	}
+
+	return &HeadChangedError{Expected: expected, Actual: actual}
+}
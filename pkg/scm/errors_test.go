@@ -0,0 +1,25 @@
+package scm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHeadSHA(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, scm.CheckHeadSHA("", "abc"), "unpinned evaluations are never stale")
+	require.NoError(t, scm.CheckHeadSHA("abc", "abc"))
+
+	// Simulate a new push landing while the evaluation was running
+	err := scm.CheckHeadSHA("abc", "def")
+
+	var headChanged *scm.HeadChangedError
+
+	require.True(t, errors.As(err, &headChanged))
+	require.Equal(t, "abc", headChanged.Expected)
+	require.Equal(t, "def", headChanged.Actual)
+}
@@ -0,0 +1,87 @@
+package scm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FailedActionsMarker is the hidden marker used to find (and update) the comment recording the failed actions
+const FailedActionsMarker = "<!-- scm-engine:failed-actions -->"
+
+// failedActionsDataPrefix starts the hidden comment holding the failed actions as JSON
+const failedActionsDataPrefix = "<!-- scm-engine:failed-actions-data "
+
+// ErrNoFailedActions is returned when a Merge Request doesn't have any failed actions recorded
+var ErrNoFailedActions = errors.New("no failed actions recorded")
+
+// FailedActions are the actions that didn't complete during an evaluation run of a Merge Request,
+// so they can be replayed without repeating the side effects of the actions that did complete
+type FailedActions struct {
+	// The commit the evaluation run was pinned to
+	CommitSHA string `json:"commit_sha"`
+
+	// The ID of the evaluation run
+	EvaluationID string `json:"evaluation_id"`
+
+	// Names of the actions that failed, or didn't run because an earlier action failed
+	Actions []string `json:"actions"`
+}
+
+// ShouldReplay returns true if the action [name] failed during the evaluation run of the commit [commitSHA].
+//
+// Failures recorded for another commit are stale, so nothing is replayed for them
+func (f *FailedActions) ShouldReplay(commitSHA, name string) bool {
+	if f == nil || f.CommitSHA != commitSHA {
+		return false
+	}
+
+	return slices.Contains(f.Actions, name)
+}
+
+// FormatFailedActions renders the comment body recording the [failed] actions, readable by [ParseFailedActions]
+func FormatFailedActions(failed *FailedActions) string {
+	if failed.Actions == nil {
+		failed.Actions = []string{}
+	}
+
+	data, _ := json.Marshal(failed) //nolint:errchkjson
+
+	readable := fmt.Sprintf("All actions were applied for commit `%s`.", failed.CommitSHA)
+	if len(failed.Actions) > 0 {
+		lines := make([]string, 0, len(failed.Actions))
+
+		for _, name := range failed.Actions {
+			lines = append(lines, fmt.Sprintf("- `%s`", name))
+		}
+
+		readable = fmt.Sprintf("Some actions failed for commit `%s`, re-run only those with `scm-engine gitlab evaluate --replay-failed`:\n\n", failed.CommitSHA) +
+			strings.Join(lines, "\n")
+	}
+
+	return FailedActionsMarker + "\n" +
+		readable + "\n\n" +
+		failedActionsDataPrefix + string(data) + " -->"
+}
+
+// ParseFailedActions returns the failed actions recorded by [FormatFailedActions] in the comment [body]
+func ParseFailedActions(body string) (*FailedActions, error) {
+	_, data, found := strings.Cut(body, failedActionsDataPrefix)
+	if !found {
+		return nil, ErrNoFailedActions
+	}
+
+	data, _, found = strings.Cut(data, " -->")
+	if !found {
+		return nil, errors.New("the failed actions comment is malformed")
+	}
+
+	failed := &FailedActions{}
+	if err := json.Unmarshal([]byte(data), failed); err != nil {
+		return nil, fmt.Errorf("the failed actions comment is malformed: %w", err)
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,58 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedActions_Roundtrip(t *testing.T) {
+	t.Parallel()
+
+	body := scm.FormatFailedActions(&scm.FailedActions{CommitSHA: "abc123", EvaluationID: "eval-1", Actions: []string{"notify slack", "assign reviewers"}})
+	require.Contains(t, body, scm.FailedActionsMarker)
+	require.Contains(t, body, "- `notify slack`")
+
+	failed, err := scm.ParseFailedActions(body)
+	require.NoError(t, err)
+	require.Equal(t, &scm.FailedActions{CommitSHA: "abc123", EvaluationID: "eval-1", Actions: []string{"notify slack", "assign reviewers"}}, failed)
+
+	// A run without failures is recorded too, so earlier failures aren't replayed again
+	body = scm.FormatFailedActions(&scm.FailedActions{CommitSHA: "abc123"})
+	require.Contains(t, body, "All actions were applied")
+
+	failed, err = scm.ParseFailedActions(body)
+	require.NoError(t, err)
+	require.Empty(t, failed.Actions)
+}
+
+func TestParseFailedActions_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := scm.ParseFailedActions("some other comment")
+	require.ErrorIs(t, err, scm.ErrNoFailedActions)
+
+	_, err = scm.ParseFailedActions(scm.FailedActionsMarker + "\n<!-- scm-engine:failed-actions-data {")
+	require.ErrorContains(t, err, "malformed")
+
+	_, err = scm.ParseFailedActions(scm.FailedActionsMarker + "\n<!-- scm-engine:failed-actions-data nope -->")
+	require.ErrorContains(t, err, "malformed")
+}
+
+func TestFailedActions_ShouldReplay(t *testing.T) {
+	t.Parallel()
+
+	failed := &scm.FailedActions{CommitSHA: "abc123", Actions: []string{"notify slack"}}
+
+	// Only the failed actions are replayed, the succeeded ones are not applied again
+	require.True(t, failed.ShouldReplay("abc123", "notify slack"))
+	require.False(t, failed.ShouldReplay("abc123", "add label"))
+
+	// Failures of another commit are stale
+	require.False(t, failed.ShouldReplay("def456", "notify slack"))
+
+	// Nothing is replayed without a recorded run
+	var none *scm.FailedActions
+	require.False(t, none.ShouldReplay("abc123", "notify slack"))
+}
@@ -3,6 +3,8 @@ package github
 import (
 	"context"
 	"errors"
+	"net/http"
+	"sync"
 
 	go_github "github.com/google/go-github/v65/github"
 	"github.com/jippi/scm-engine/pkg/scm"
@@ -16,19 +18,36 @@ var _ scm.Client = (*Client)(nil)
 type Client struct {
 	wrapped *go_github.Client
 
+	// Guards the lazily created clients, as the server shares the client between concurrent evaluations
+	mu            sync.Mutex
 	labels        *LabelClient
 	mergeRequests *MergeRequestClient
 }
 
 // NewClient creates a new GitLab client
 func NewClient(ctx context.Context) *Client {
-	client := go_github.NewClient(nil).WithAuthToken(state.Token(ctx))
+	var httpClient *http.Client
+
+	// Route the requests through the custom transport (if any)
+	if transport := state.HTTPTransport(ctx); transport != nil {
+		httpClient = &http.Client{Transport: transport}
+	}
+
+	// Refuse any mutating requests in read-only mode
+	if state.IsReadOnly(ctx) {
+		httpClient = &http.Client{Transport: scm.ReadOnlyTransport{Base: state.HTTPTransport(ctx)}}
+	}
+
+	client := go_github.NewClient(httpClient).WithAuthToken(state.Token(ctx))
 
 	return &Client{wrapped: client}
 }
 
 // Labels returns a client target at managing labels/tags
 func (client *Client) Labels() scm.LabelClient {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
 	if client.labels == nil {
 		client.labels = NewLabelClient(client)
 	}
@@ -38,6 +57,9 @@ func (client *Client) Labels() scm.LabelClient {
 
 // MergeRequests returns a client target at managing merge/pull requests
 func (client *Client) MergeRequests() scm.MergeRequestClient {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
 	if client.mergeRequests == nil {
 		client.mergeRequests = NewMergeRequestClient(client)
 	}
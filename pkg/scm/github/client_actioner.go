@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	go_github "github.com/google/go-github/v65/github"
 	"github.com/jippi/scm-engine/pkg/scm"
@@ -51,6 +52,16 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 
 		update.AddLabels = &tmp
 
+	case "transform_labels":
+		mapping, err := step.RequiredStringMap("mapping")
+		if err != nil {
+			return err
+		}
+
+		if renamed := update.TransformLabels(evalContext.GetLabels(), mapping); len(renamed) > 0 {
+			slogctx.Info(ctx, "Renaming labels", slog.Any("labels", renamed))
+		}
+
 	case "close":
 		update.StateEvent = scm.Ptr("close")
 
@@ -64,6 +75,10 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 		update.DiscussionLocked = scm.Ptr(false)
 
 	case "approve":
+		if strategy, _ := step.OptionalString("wait_for_pipeline", ""); len(strategy) > 0 {
+			return errors.New("step field 'wait_for_pipeline' is not supported by the GitHub client")
+		}
+
 		if state.IsDryRun(ctx) {
 			slogctx.Info(ctx, "Approving MR")
 
@@ -97,12 +112,32 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 			return errors.New("step field 'message' must not be an empty string")
 		}
 
+		mentions, err := step.OptionalString("mentions", scm.MentionsNotify)
+		if err != nil {
+			return err
+		}
+
+		msg, err = scm.ApplyMentionPolicy(msg, mentions)
+		if err != nil {
+			return err
+		}
+
+		if internal, _ := step.OptionalBool("internal", false); internal {
+			slogctx.Warn(ctx, "GitHub does not support internal comments, posting a regular comment instead")
+		}
+
+		msg = scm.AppendCommentFooter(ctx, msg)
+
 		if state.IsDryRun(ctx) {
 			slogctx.Info(ctx, "Commenting on MR", slog.String("message", msg))
 
 			return nil
 		}
 
+		if !state.AllowComment(ctx, time.Now()) {
+			return nil
+		}
+
 		_, _, err = c.wrapped.PullRequests.CreateComment(ctx, owner, repo, state.MergeRequestIDInt(ctx), &go_github.PullRequestComment{
 			Body: scm.Ptr(msg),
 		})
@@ -2,6 +2,7 @@ package github
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 
@@ -24,8 +25,10 @@ func (client *MergeRequestClient) Update(ctx context.Context, opt *scm.UpdateMer
 	owner, repo := ownerAndRepo(ctx)
 
 	// Add labels
-	if _, resp, err := client.client.wrapped.Issues.AddLabelsToIssue(ctx, owner, repo, state.MergeRequestIDInt(ctx), *opt.AddLabels); err != nil {
-		return convertResponse(resp), err
+	if opt.AddLabels != nil && len(*opt.AddLabels) > 0 {
+		if _, resp, err := client.client.wrapped.Issues.AddLabelsToIssue(ctx, owner, repo, state.MergeRequestIDInt(ctx), *opt.AddLabels); err != nil {
+			return convertResponse(resp), err
+		}
 	}
 
 	// Remove labels
@@ -57,6 +60,37 @@ func (client *MergeRequestClient) GetRemoteConfig(ctx context.Context, filename,
 	return nil, nil //nolint:nilnil
 }
 
+func (client *MergeRequestClient) HeadSHA(ctx context.Context) (string, error) {
+	owner, repo := ownerAndRepo(ctx)
+
+	pullRequest, _, err := client.client.wrapped.PullRequests.Get(ctx, owner, repo, state.MergeRequestIDInt(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	return pullRequest.GetHead().GetSHA(), nil
+}
+
+func (client *MergeRequestClient) LabelEvents(ctx context.Context) ([]scm.LabelEvent, error) {
+	return nil, nil //nolint:nilnil
+}
+
+func (client *MergeRequestClient) FindNote(ctx context.Context, marker string) (string, error) {
+	return "", errors.New("reading comments is not supported on GitHub yet")
+}
+
+func (client *MergeRequestClient) UpsertNote(ctx context.Context, marker, body string) error {
+	return errors.New("updating comments is not supported on GitHub yet")
+}
+
+func (client *MergeRequestClient) UpsertInternalNote(ctx context.Context, marker, body string) error {
+	return errors.New("updating comments is not supported on GitHub yet")
+}
+
+func (client *MergeRequestClient) UpsertDescriptionBlock(ctx context.Context, block string) error {
+	return errors.New("updating the description block is not supported on GitHub yet")
+}
+
 func (client *MergeRequestClient) List(ctx context.Context, options *scm.ListMergeRequestsOptions) ([]scm.ListMergeRequest, error) {
 	return nil, nil //nolint:nilnil
 }
@@ -2,6 +2,7 @@ package github
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/hasura/go-graphql-client"
@@ -13,8 +14,14 @@ import (
 var _ scm.EvalContext = (*Context)(nil)
 
 func NewContext(ctx context.Context, _, token string) (*Context, error) {
+	// Route the requests through the custom transport (if any)
+	tokenCtx := ctx
+	if transport := state.HTTPTransport(ctx); transport != nil {
+		tokenCtx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+	}
+
 	httpClient := oauth2.NewClient(
-		ctx,
+		tokenCtx,
 		oauth2.StaticTokenSource(
 			&oauth2.Token{
 				AccessToken: token,
@@ -46,6 +53,17 @@ func NewContext(ctx context.Context, _, token string) (*Context, error) {
 	evalContext.PullRequest = evalContext.Repository.PullRequest
 	evalContext.Repository.PullRequest = nil
 
+	// Move 'topics' to the repository context as a list of names
+	if evalContext.Repository.ResponseTopics != nil {
+		for _, node := range evalContext.Repository.ResponseTopics.Nodes {
+			if node.Topic != nil {
+				evalContext.Repository.Topics = append(evalContext.Repository.Topics, node.Topic.Name)
+			}
+		}
+	}
+
+	evalContext.Repository.ResponseTopics = nil
+
 	// Move 'files' to MR context without nesting
 	evalContext.PullRequest.Files = evalContext.PullRequest.ResponseFiles.Nodes
 	evalContext.PullRequest.ResponseFiles = nil
@@ -80,10 +98,23 @@ func NewContext(ctx context.Context, _, token string) (*Context, error) {
 	return evalContext, nil
 }
 
+func (c *Context) IsDraft() bool {
+	return c.PullRequest.IsDraft
+}
+
 func (c *Context) IsValid() bool {
 	return c != nil
 }
 
+func (c *Context) SetVars(vars map[string]any) {
+	c.Vars = vars
+}
+
+// UnavailableData always returns nil, since all GitHub data is required
+func (c *Context) UnavailableData() map[string]string {
+	return nil
+}
+
 func (c *Context) SetWebhookEvent(in any) {
 	c.WebhookEvent = in
 }
@@ -96,6 +127,18 @@ func (c *Context) GetDescription() string {
 	return c.PullRequest.Body
 }
 
+func (c *Context) GetTitle() string {
+	return c.PullRequest.Title
+}
+
+func (c *Context) GetTargetBranch() string {
+	return c.PullRequest.BaseRefName
+}
+
+func (c *Context) GetLabels() []string {
+	return nil
+}
+
 func (c *Context) CanUseConfigurationFileFromChangeRequest(ctx context.Context) bool {
 	return true
 }
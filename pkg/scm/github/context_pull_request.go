@@ -47,6 +47,16 @@ func (e ContextPullRequest) ModifiedFiles(patterns ...string) bool {
 	return len(e.findModifiedFiles(patterns...)) > 0
 }
 
+// DescriptionCheckboxChecked checks if the task list item [label] in the Pull Request body is checked
+func (e ContextPullRequest) DescriptionCheckboxChecked(label string) bool {
+	return scm.DescriptionCheckboxChecked(e.Body, label)
+}
+
+// DescriptionSection returns the content of the section below [heading] in the Pull Request body
+func (e ContextPullRequest) DescriptionSection(heading string) string {
+	return scm.DescriptionSection(e.Body, heading)
+}
+
 func (e ContextPullRequest) findModifiedFiles(patterns ...string) []string {
 	files := []string{}
 	for _, f := range e.Files {
@@ -10,6 +10,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aquilax/truncate"
@@ -18,11 +19,8 @@ import (
 	"github.com/jippi/scm-engine/pkg/state"
 	slogctx "github.com/veqryn/slog-context"
 	go_gitlab "github.com/xanzy/go-gitlab"
-	"golang.org/x/oauth2"
 )
 
-var pipelineName = scm.Ptr("scm-engine")
-
 // Skip the "update external pipeline" step if the HEAD pipeline is any
 // of the configured options
 //
@@ -60,13 +58,28 @@ var _ scm.Client = (*Client)(nil)
 type Client struct {
 	wrapped *go_gitlab.Client
 
+	// Guards the lazily created clients, as the server shares the client between concurrent evaluations
+	mu            sync.Mutex
 	labels        *LabelClient
 	mergeRequests *MergeRequestClient
 }
 
 // NewClient creates a new GitLab client
 func NewClient(ctx context.Context) (*Client, error) {
-	client, err := go_gitlab.NewClient(state.Token(ctx), go_gitlab.WithBaseURL(state.BaseURL(ctx)))
+	// Slow down before running into the rate limit
+	transport := newAPITransport(ctx, state.Token(ctx))
+
+	// Refuse any mutating requests in read-only mode
+	if state.IsReadOnly(ctx) {
+		transport = scm.ReadOnlyTransport{Base: transport}
+	}
+
+	// Reuse the responses of an earlier run (if enabled)
+	if dir, ttl, ok := state.ResponseCache(ctx); ok {
+		transport = scm.NewResponseCacheTransport(transport, dir, ttl)
+	}
+
+	client, err := newAPIClient(ctx, go_gitlab.WithHTTPClient(&http.Client{Transport: transport}))
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +89,9 @@ func NewClient(ctx context.Context) (*Client, error) {
 
 // Labels returns a client target at managing labels/tags
 func (client *Client) Labels() scm.LabelClient {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
 	if client.labels == nil {
 		client.labels = NewLabelClient(client)
 	}
@@ -85,6 +101,9 @@ func (client *Client) Labels() scm.LabelClient {
 
 // MergeRequests returns a client target at managing merge/pull requests
 func (client *Client) MergeRequests() scm.MergeRequestClient {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
 	if client.mergeRequests == nil {
 		client.mergeRequests = NewMergeRequestClient(client)
 	}
@@ -143,6 +162,45 @@ func (client *Client) EvalContext(ctx context.Context) (scm.EvalContext, error)
 	return NewContext(ctx, graphqlBaseURL(client.wrapped.BaseURL()), state.Token(ctx))
 }
 
+// GroupProject is a project within a GitLab group
+type GroupProject struct {
+	PathWithNamespace string
+	DefaultBranch     string
+}
+
+// GroupProjects lists all (non-archived) projects within [group] and its subgroups
+func (client *Client) GroupProjects(ctx context.Context, group string) ([]GroupProject, error) {
+	var (
+		projects []GroupProject
+		options  = &go_gitlab.ListGroupProjectsOptions{
+			ListOptions:      go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)},
+			Archived:         scm.Ptr(false),
+			IncludeSubGroups: scm.Ptr(true),
+			Simple:           scm.Ptr(true),
+		}
+	)
+
+	for {
+		page, resp, err := client.wrapped.Groups.ListGroupProjects(group, options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not list projects in group [%s]: %w", group, err)
+		}
+
+		for _, project := range page {
+			projects = append(projects, GroupProject{
+				PathWithNamespace: project.PathWithNamespace,
+				DefaultBranch:     project.DefaultBranch,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return projects, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
 func (client *Client) GetProjectFiles(ctx context.Context, project string, ref *string, files []string) (map[string]string, error) {
 	if len(project) == 0 {
 		return nil, errors.New("Missing required 'project' value for include")
@@ -209,8 +267,8 @@ func (client *Client) Start(ctx context.Context) error {
 
 	_, response, err := client.wrapped.Commits.SetCommitStatus(state.ProjectID(ctx), state.CommitSHA(ctx), &go_gitlab.SetCommitStatusOptions{
 		State:       go_gitlab.Running,
-		Context:     pipelineName,
-		Description: scm.Ptr("Currently evaluating MR"),
+		Context:     scm.Ptr(scm.StatusMessagesFromContext(ctx).Name(ctx)),
+		Description: scm.Ptr(truncate.Truncate(scm.StatusMessagesFromContext(ctx).Running(ctx), 250, "...", truncate.PositionEnd)),
 		TargetURL:   targetURL,
 	})
 
@@ -247,22 +305,16 @@ func (client *Client) Stop(ctx context.Context, evalError error, allowPipelineFa
 		targetURL = &link
 	}
 
-	var (
-		status      = go_gitlab.Success
-		description = "OK"
-	)
-
-	if evalError != nil {
-		if allowPipelineFailure {
-			status = go_gitlab.Failed
-		}
-
-		description = truncate.Truncate(evalError.Error(), 250, "...", truncate.PositionEnd)
+	status := go_gitlab.Success
+	if evalError != nil && allowPipelineFailure {
+		status = go_gitlab.Failed
 	}
 
+	description := truncate.Truncate(scm.StatusMessagesFromContext(ctx).Finished(ctx, evalError), 250, "...", truncate.PositionEnd)
+
 	_, response, err := client.wrapped.Commits.SetCommitStatus(state.ProjectID(ctx), state.CommitSHA(ctx), &go_gitlab.SetCommitStatusOptions{
 		State:       status,
-		Context:     pipelineName,
+		Context:     scm.Ptr(scm.StatusMessagesFromContext(ctx).Name(ctx)),
 		Description: scm.Ptr(description),
 		TargetURL:   targetURL,
 	})
@@ -309,15 +361,8 @@ func graphqlBaseURL(inputURL *url.URL) string {
 	return buf.String()
 }
 
-func (client Client) newGraphQLClient(ctx context.Context) *graphql.Client {
-	httpClient := oauth2.NewClient(
-		ctx,
-		oauth2.StaticTokenSource(
-			&oauth2.Token{
-				AccessToken: state.Token(ctx),
-			},
-		),
-	)
+func (client *Client) newGraphQLClient(ctx context.Context) *graphql.Client {
+	httpClient := newGraphQLHTTPClient(ctx, state.Token(ctx))
 
 	return graphql.NewClient(
 		graphqlBaseURL(client.wrapped.BaseURL())+"/api/graphql",
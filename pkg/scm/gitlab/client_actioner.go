@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/patcher"
@@ -54,32 +55,12 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 
 			replacedAnything = true
 
-			// Build the ExprLang VM program
-			// TODO(jippi): make this something generic/shared somewhere more central so we keep settings in sync
-			opts := []expr.Option{}
-			opts = append(opts, expr.AsKind(reflect.TypeFor[string]().Kind()))
-			opts = append(opts, expr.Env(evalContext))
-			opts = append(opts, stdlib.FunctionRenamer)
-			opts = append(opts, stdlib.Functions...)
-			opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
-
-			program, err := expr.Compile(fmt.Sprintf("%s", script), opts...)
+			val, err := runStringScript(evalContext, fmt.Sprintf("%s", script))
 			if err != nil {
 				return fmt.Errorf("could not evaluate value for 'replace' key '%s': %w", key, err)
 			}
 
-			output, err := expr.Run(program, evalContext)
-			if err != nil {
-				return err
-			}
-
-			switch val := output.(type) {
-			case string:
-				body = strings.ReplaceAll(body, key, val)
-
-			default:
-				return fmt.Errorf("'replace' value for key '%s' did not return a string: %w", key, err)
-			}
+			body = strings.ReplaceAll(body, key, val)
 		}
 
 		// Don't update the body if there were no replacements
@@ -119,6 +100,40 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 
 		update.AddLabels = &tmp
 
+	case "remove_labels":
+		labels, err := step.OptionalStringSlice("labels")
+		if err != nil {
+			return err
+		}
+
+		script, err := step.OptionalString("script", "")
+		if err != nil {
+			return err
+		}
+
+		if len(script) > 0 {
+			fromScript, err := runStringSliceScript(evalContext, script)
+			if err != nil {
+				return fmt.Errorf("could not evaluate 'script': %w", err)
+			}
+
+			labels = append(labels, fromScript...)
+		}
+
+		if removed := update.ForceRemoveLabels(evalContext.GetLabels(), labels...); len(removed) > 0 {
+			slogctx.Info(ctx, "Removing labels", slog.Any("labels", removed))
+		}
+
+	case "transform_labels":
+		mapping, err := step.RequiredStringMap("mapping")
+		if err != nil {
+			return err
+		}
+
+		if renamed := update.TransformLabels(evalContext.GetLabels(), mapping); len(renamed) > 0 {
+			slogctx.Info(ctx, "Renaming labels", slog.Any("labels", renamed))
+		}
+
 	case "close":
 		update.StateEvent = scm.Ptr("close")
 
@@ -132,6 +147,10 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 		update.DiscussionLocked = scm.Ptr(false)
 
 	case "approve":
+		if err := c.waitForPipeline(ctx, step); err != nil {
+			return err
+		}
+
 		if state.IsDryRun(ctx) {
 			slogctx.Info(ctx, "(Dry Run) Approving MR")
 
@@ -142,6 +161,24 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 
 		return err
 
+	case "merge":
+		if err := c.waitForPipeline(ctx, step); err != nil {
+			return err
+		}
+
+		if state.IsDryRun(ctx) {
+			slogctx.Info(ctx, "(Dry Run) Merging MR")
+
+			return nil
+		}
+
+		// Pin the merge to the evaluated commit, so we never merge commits that wasn't evaluated
+		_, _, err := c.wrapped.MergeRequests.AcceptMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), &gitlab.AcceptMergeRequestOptions{
+			SHA: scm.Ptr(state.CommitSHA(ctx)),
+		})
+
+		return err
+
 	case "unapprove":
 		if state.IsDryRun(ctx) {
 			slogctx.Info(ctx, "(Dry Run) Unapproving MR")
@@ -163,17 +200,91 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 			return errors.New("step field 'message' must not be an empty string")
 		}
 
+		mentions, err := step.OptionalString("mentions", scm.MentionsNotify)
+		if err != nil {
+			return err
+		}
+
+		message, err = scm.ApplyMentionPolicy(message, mentions)
+		if err != nil {
+			return err
+		}
+
+		internal, err := step.OptionalBool("internal", false)
+		if err != nil {
+			return err
+		}
+
+		// Post the message as part of the report comment instead, internal comments are never collected into the (public) report
+		if !internal && scm.CollectComment(ctx, message) {
+			return nil
+		}
+
 		if state.IsDryRun(ctx) {
-			slogctx.Info(ctx, "(Dry Run) Commenting on MR", slog.String("message", message))
+			slogctx.Info(ctx, "(Dry Run) Commenting on MR", slog.String("message", message), slog.Bool("internal", internal))
 
 			return nil
 		}
 
-		_, _, err = c.wrapped.Notes.CreateMergeRequestNote(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), &gitlab.CreateMergeRequestNoteOptions{
-			Body: scm.Ptr(message),
-		})
+		if !state.AllowComment(ctx, time.Now()) {
+			return nil
+		}
 
-		return err
+		return c.comment(ctx, message, internal)
+
+	case "add_approval_rule":
+		return c.addApprovalRule(ctx, step)
+
+	case "remove_approval_rule":
+		return c.removeApprovalRule(ctx, step)
+
+	case "manage_approval_rule":
+		return c.manageApprovalRule(ctx, evalContext, step)
+
+	case "suggest":
+		return c.suggest(ctx, evalContext, step)
+
+	case "add_to_merge_train":
+		return c.addToMergeTrain(ctx, step)
+
+	case "remove_from_merge_train":
+		return c.removeFromMergeTrain(ctx)
+
+	case "set_pipeline_variables":
+		return c.setPipelineVariables(ctx, step)
+
+	case "clear_pipeline_variables":
+		return c.clearPipelineVariables(ctx, step)
+
+	case "set_target_branch":
+		return c.setTargetBranch(ctx, evalContext, update, step)
+
+	case "title_normalize":
+		return c.titleNormalize(ctx, evalContext, update, step)
+
+	case "create_issue":
+		return c.createIssue(ctx, evalContext, step)
+
+	case "snapshot_labels":
+		return c.snapshotLabels(ctx, evalContext, step)
+
+	case "require_description_template":
+		return c.requireDescriptionTemplate(ctx, evalContext, update, step)
+
+	case "require_linked_issue":
+		return c.requireLinkedIssue(ctx, evalContext, step)
+
+	case "update_linked_issues":
+		return c.updateLinkedIssues(ctx, evalContext, step)
+
+	case "quarantine":
+		return c.quarantine(ctx, evalContext, update, step)
+
+	case "unquarantine":
+		return c.unquarantine(ctx, evalContext, update, step)
+
+	case "move_to_project":
+		return fmt.Errorf("action %q only applies to issues, use it in 'on_issue' instead", action)
 
 	default:
 		return fmt.Errorf("GitLab client does not know how to apply action %q", action)
@@ -181,3 +292,80 @@ func (c *Client) ApplyStep(ctx context.Context, evalContext scm.EvalContext, upd
 
 	return nil
 }
+
+// runStringScript compiles and runs an Expr Lang script that must return a string
+//
+// TODO(jippi): make this something generic/shared somewhere more central so we keep settings in sync
+func runStringScript(evalContext scm.EvalContext, script string) (string, error) {
+	opts := []expr.Option{}
+	opts = append(opts, expr.AsKind(reflect.TypeFor[string]().Kind()))
+	opts = append(opts, expr.Env(evalContext))
+	opts = append(opts, stdlib.FunctionRenamer)
+	opts = append(opts, stdlib.Functions...)
+	opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+	program, err := expr.Compile(script, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := expr.Run(program, evalContext)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := output.(string)
+	if !ok {
+		return "", fmt.Errorf("script did not return a string, got %T", output)
+	}
+
+	return val, nil
+}
+
+// runBoolScript compiles and runs an Expr Lang script that must return a boolean
+func runBoolScript(evalContext scm.EvalContext, script string) (bool, error) {
+	opts := []expr.Option{}
+	opts = append(opts, expr.AsBool())
+	opts = append(opts, expr.Env(evalContext))
+	opts = append(opts, stdlib.FunctionRenamer)
+	opts = append(opts, stdlib.Functions...)
+	opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+	program, err := expr.Compile(script, opts...)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := expr.Run(program, evalContext)
+	if err != nil {
+		return false, err
+	}
+
+	val, ok := output.(bool)
+	if !ok {
+		return false, fmt.Errorf("script did not return a boolean, got %T", output)
+	}
+
+	return val, nil
+}
+
+// runStringSliceScript compiles and runs an Expr Lang script that must return a list of strings
+func runStringSliceScript(evalContext scm.EvalContext, script string) ([]string, error) {
+	opts := []expr.Option{}
+	opts = append(opts, expr.Env(evalContext))
+	opts = append(opts, stdlib.FunctionRenamer)
+	opts = append(opts, stdlib.Functions...)
+	opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+	program, err := expr.Compile(script, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := expr.Run(program, evalContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return stdlib.ToStringSlice(output)
+}
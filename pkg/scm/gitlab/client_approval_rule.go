@@ -0,0 +1,270 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// addApprovalRule creates the Merge Request level approval rule, or updates it if a rule with the same name exists
+//
+// See: https://docs.gitlab.com/ee/api/merge_request_approvals.html#create-merge-request-level-rule
+func (c *Client) addApprovalRule(ctx context.Context, step scm.ActionStep) error {
+	name, err := step.RequiredString("name")
+	if err != nil {
+		return err
+	}
+
+	approvalsRequired, err := step.RequiredInt("approvals_required")
+	if err != nil {
+		return err
+	}
+
+	usernames, err := step.OptionalStringSlice("users")
+	if err != nil {
+		return err
+	}
+
+	groups, err := step.OptionalStringSlice("groups")
+	if err != nil {
+		return err
+	}
+
+	return c.ensureApprovalRule(ctx, name, approvalsRequired, usernames, groups)
+}
+
+// manageApprovalRule keeps a Merge Request level approval rule in sync with the step 'when' script:
+// the rule is created (or updated) while the script returns true, and removed once it returns false
+func (c *Client) manageApprovalRule(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	name, err := step.OptionalString("name", "scm-engine-policy")
+	if err != nil {
+		return err
+	}
+
+	when, err := step.RequiredString("when")
+	if err != nil {
+		return err
+	}
+
+	approvalsRequired, err := step.RequiredInt("approvals_required")
+	if err != nil {
+		return err
+	}
+
+	usernames, err := step.OptionalStringSlice("users")
+	if err != nil {
+		return err
+	}
+
+	groups, err := step.OptionalStringSlice("groups")
+	if err != nil {
+		return err
+	}
+
+	required, err := runBoolScript(evalContext, when)
+	if err != nil {
+		return fmt.Errorf("could not evaluate step field 'when': %w", err)
+	}
+
+	if !required {
+		return c.deleteApprovalRule(ctx, name)
+	}
+
+	return c.ensureApprovalRule(ctx, name, approvalsRequired, usernames, groups)
+}
+
+func (c *Client) ensureApprovalRule(ctx context.Context, name string, approvalsRequired int, usernames, groups []string) error {
+	if approvalsRequired < 0 {
+		return errors.New("step field 'approvals_required' must not be negative")
+	}
+
+	ctx = slogctx.With(ctx, slog.String("approval_rule", name))
+
+	userIDs, err := c.userIDs(ctx, usernames)
+	if err != nil {
+		return err
+	}
+
+	groupIDs, err := c.groupIDs(ctx, groups)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.findApprovalRule(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// Nothing to do if the rule already is in the desired state
+	if existing != nil && existing.ApprovalsRequired == approvalsRequired && sameIDs(approvalRuleUserIDs(existing), userIDs) && sameIDs(approvalRuleGroupIDs(existing), groupIDs) {
+		slogctx.Debug(ctx, "Approval rule is up to date")
+
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Creating or updating approval rule", slog.Int("approvals_required", approvalsRequired), slog.Any("users", usernames), slog.Any("groups", groups))
+
+		return nil
+	}
+
+	var resp *go_gitlab.Response
+
+	if existing == nil {
+		_, resp, err = c.wrapped.MergeRequestApprovals.CreateApprovalRule(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), &go_gitlab.CreateMergeRequestApprovalRuleOptions{
+			Name:              scm.Ptr(name),
+			ApprovalsRequired: scm.Ptr(approvalsRequired),
+			UserIDs:           scm.Ptr(userIDs),
+			GroupIDs:          scm.Ptr(groupIDs),
+		}, go_gitlab.WithContext(ctx))
+	} else {
+		_, resp, err = c.wrapped.MergeRequestApprovals.UpdateApprovalRule(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), existing.ID, &go_gitlab.UpdateMergeRequestApprovalRuleOptions{
+			ApprovalsRequired: scm.Ptr(approvalsRequired),
+			UserIDs:           scm.Ptr(userIDs),
+			GroupIDs:          scm.Ptr(groupIDs),
+		}, go_gitlab.WithContext(ctx))
+	}
+
+	if err := approvalRuleError(ctx, resp, err); err != nil {
+		return fmt.Errorf("could not save approval rule %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// removeApprovalRule removes the Merge Request level approval rule with the provided name, if it exists
+func (c *Client) removeApprovalRule(ctx context.Context, step scm.ActionStep) error {
+	name, err := step.RequiredString("name")
+	if err != nil {
+		return err
+	}
+
+	return c.deleteApprovalRule(ctx, name)
+}
+
+func (c *Client) deleteApprovalRule(ctx context.Context, name string) error {
+	ctx = slogctx.With(ctx, slog.String("approval_rule", name))
+
+	existing, err := c.findApprovalRule(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		slogctx.Debug(ctx, "Approval rule does not exist, nothing to remove")
+
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Removing approval rule")
+
+		return nil
+	}
+
+	resp, err := c.wrapped.MergeRequestApprovals.DeleteApprovalRule(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), existing.ID, go_gitlab.WithContext(ctx))
+	if err := approvalRuleError(ctx, resp, err); err != nil {
+		return fmt.Errorf("could not remove approval rule %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func (c *Client) findApprovalRule(ctx context.Context, name string) (*go_gitlab.MergeRequestApprovalRule, error) {
+	rules, resp, err := c.wrapped.MergeRequestApprovals.GetApprovalRules(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), go_gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, approvalRuleError(ctx, resp, err)
+	}
+
+	for _, rule := range rules {
+		// Only Merge Request level rules can be changed, project level rules are inherited
+		if rule.Name == name && rule.RuleType == "regular" {
+			return rule, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+func (c *Client) userIDs(ctx context.Context, usernames []string) ([]int, error) {
+	ids := make([]int, 0, len(usernames))
+
+	for _, username := range usernames {
+		users, _, err := c.wrapped.Users.ListUsers(&go_gitlab.ListUsersOptions{Username: scm.Ptr(username)}, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not look up user %q: %w", username, err)
+		}
+
+		if len(users) == 0 {
+			return nil, fmt.Errorf("user %q does not exist", username)
+		}
+
+		ids = append(ids, users[0].ID)
+	}
+
+	return ids, nil
+}
+
+func (c *Client) groupIDs(ctx context.Context, paths []string) ([]int, error) {
+	ids := make([]int, 0, len(paths))
+
+	for _, path := range paths {
+		group, _, err := c.wrapped.Groups.GetGroup(path, &go_gitlab.GetGroupOptions{WithProjects: scm.Ptr(false)}, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not look up group %q: %w", path, err)
+		}
+
+		ids = append(ids, group.ID)
+	}
+
+	return ids, nil
+}
+
+// approvalRuleError turns the (common) "not permitted" error into something actionable
+func approvalRuleError(ctx context.Context, resp *go_gitlab.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("project %q does not permit Merge Request level approval rules (either 'Prevent editing approval rules in merge requests' is enabled, or the GitLab tier does not support it): %w", state.ProjectID(ctx), err)
+	}
+
+	return err
+}
+
+func approvalRuleUserIDs(rule *go_gitlab.MergeRequestApprovalRule) []int {
+	ids := make([]int, 0, len(rule.Users))
+	for _, user := range rule.Users {
+		ids = append(ids, user.ID)
+	}
+
+	return ids
+}
+
+func approvalRuleGroupIDs(rule *go_gitlab.MergeRequestApprovalRule) []int {
+	ids := make([]int, 0, len(rule.Groups))
+	for _, group := range rule.Groups {
+		ids = append(ids, group.ID)
+	}
+
+	return ids
+}
+
+// sameIDs compares two lists of IDs, ignoring their order
+func sameIDs(a, b []int) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
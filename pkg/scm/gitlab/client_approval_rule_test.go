@@ -0,0 +1,141 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_ManageApprovalRule(t *testing.T) {
+	t.Parallel()
+
+	const rulesPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1/approval_rules"
+
+	tests := []struct {
+		name         string
+		files        []string
+		rules        string
+		status       int // status code for mutating requests; defaults to 200
+		wantErr      string
+		wantRequests []string
+	}{
+		{
+			name:         "rule is added when matching files change",
+			files:        []string{"db/migrations/001.sql"},
+			rules:        `[]`,
+			wantRequests: []string{"GET " + rulesPath, "POST " + rulesPath + ` {"name":"scm-engine-policy","approvals_required":2,"user_ids":[],"group_ids":[]}`},
+		},
+		{
+			name:         "outdated rule is updated",
+			files:        []string{"db/migrations/001.sql"},
+			rules:        `[{"id": 7, "name": "scm-engine-policy", "rule_type": "regular", "approvals_required": 1}]`,
+			wantRequests: []string{"GET " + rulesPath, "PUT " + rulesPath + `/7 {"approvals_required":2,"user_ids":[],"group_ids":[]}`},
+		},
+		{
+			name:         "up to date rule is left alone",
+			files:        []string{"db/migrations/001.sql"},
+			rules:        `[{"id": 7, "name": "scm-engine-policy", "rule_type": "regular", "approvals_required": 2}]`,
+			wantRequests: []string{"GET " + rulesPath},
+		},
+		{
+			name:         "rule is removed when no matching files change",
+			files:        []string{"README.md"},
+			rules:        `[{"id": 7, "name": "scm-engine-policy", "rule_type": "regular", "approvals_required": 2}]`,
+			wantRequests: []string{"GET " + rulesPath, "DELETE " + rulesPath + "/7"},
+		},
+		{
+			name:         "missing rule is not removed",
+			files:        []string{"README.md"},
+			rules:        `[{"id": 3, "name": "scm-engine-policy", "rule_type": "any_approver", "approvals_required": 1}]`,
+			wantRequests: []string{"GET " + rulesPath},
+		},
+		{
+			name:         "forbidden rule changes are explained",
+			files:        []string{"db/migrations/001.sql"},
+			rules:        `[]`,
+			status:       http.StatusForbidden,
+			wantErr:      `could not save approval rule "scm-engine-policy": project "jippi/scm-engine" does not permit Merge Request level approval rules`,
+			wantRequests: []string{"GET " + rulesPath, "POST " + rulesPath + ` {"name":"scm-engine-policy","approvals_required":2,"user_ids":[],"group_ids":[]}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.Path
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				if r.Method == http.MethodGet {
+					w.Write([]byte(tt.rules))
+
+					return
+				}
+
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(`{"message": "403 Forbidden"}`))
+
+					return
+				}
+
+				w.Write([]byte(`{"id": 7}`))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithDryRun(ctx, false)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			diffStats := make([]gitlab.ContextDiffStat, 0, len(tt.files))
+			for _, file := range tt.files {
+				diffStats = append(diffStats, gitlab.ContextDiffStat{Path: file})
+			}
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{DiffStats: diffStats}}
+
+			err = client.ApplyStep(ctx, evalContext, &scm.UpdateMergeRequestOptions{}, config.ActionStep{
+				"action":             "manage_approval_rule",
+				"when":               `merge_request.modified_files("db/**")`,
+				"approvals_required": 2,
+			})
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
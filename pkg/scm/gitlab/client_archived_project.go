@@ -0,0 +1,18 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// IsProjectArchived returns whether the [project] is archived, which makes it (and its Merge Requests) read-only
+func (c *Client) IsProjectArchived(ctx context.Context, project string) (bool, error) {
+	remote, _, err := c.wrapped.Projects.GetProject(project, nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("could not read project %q: %w", project, err)
+	}
+
+	return remote.Archived, nil
+}
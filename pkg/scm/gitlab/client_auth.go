@@ -0,0 +1,67 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	go_gitlab "github.com/xanzy/go-gitlab"
+	"golang.org/x/oauth2"
+)
+
+// newAPIClient creates a GitLab REST API client for [state.BaseURL], authenticating
+// with the 'JOB-TOKEN' header when the token is a GitLab CI job token
+func newAPIClient(ctx context.Context, options ...go_gitlab.ClientOptionFunc) (*go_gitlab.Client, error) {
+	// Unless the caller brings its own HTTP client, send the static headers and route the requests through the custom transport (if any)
+	options = append([]go_gitlab.ClientOptionFunc{
+		go_gitlab.WithBaseURL(state.BaseURL(ctx)),
+		go_gitlab.WithHTTPClient(&http.Client{Transport: newAPITransport(ctx, state.Token(ctx))}),
+	}, options...)
+
+	if state.IsJobToken(ctx) {
+		return go_gitlab.NewJobClient(state.Token(ctx), options...)
+	}
+
+	return go_gitlab.NewClient(state.Token(ctx), options...)
+}
+
+// newAPITransport creates the transport for GitLab API requests authenticated with [token], sending the static headers
+// and pacing the requests with the rate limiter shared by every client using the same GitLab instance and token
+func newAPITransport(ctx context.Context, token string) http.RoundTripper {
+	return scm.NewRateLimitTransport(scm.HeaderTransport{Base: state.HTTPTransport(ctx), Headers: state.APIHeaders(ctx)}, token)
+}
+
+// newGraphQLHTTPClient creates the HTTP client used for GitLab GraphQL queries
+func newGraphQLHTTPClient(ctx context.Context, token string) *http.Client {
+	base := newAPITransport(ctx, token)
+
+	// Reuse the responses of an earlier run (if enabled)
+	if dir, ttl, ok := state.ResponseCache(ctx); ok {
+		base = scm.NewResponseCacheTransport(base, dir, ttl)
+	}
+
+	if state.IsJobToken(ctx) {
+		return &http.Client{Transport: jobTokenTransport{token: token, base: base}}
+	}
+
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+			Base:   base,
+		},
+	}
+}
+
+// jobTokenTransport authenticates requests with a GitLab CI job token
+type jobTokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t jobTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("JOB-TOKEN", t.token)
+
+	return t.base.RoundTrip(req)
+}
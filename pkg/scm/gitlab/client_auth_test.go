@@ -0,0 +1,69 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_SharedByAllClients(t *testing.T) {
+	t.Parallel()
+
+	// Every response uses up one more request of the rate limit
+	remaining := &atomic.Int32{}
+	remaining.Store(1000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("RateLimit-Limit", "1000")
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(remaining.Add(-1))))
+
+		switch r.URL.Path {
+		case "/api/graphql":
+			w.Write([]byte(`{"data": {"project": {"mergeRequests": {"nodes": []}}}}`))
+
+		default:
+			w.Write([]byte(`{"rules": []}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "rate-limited-token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	limiter := scm.SharedRateLimiter(strings.TrimPrefix(server.URL, "http://"), "rate-limited-token")
+
+	// A GraphQL request
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	_, err = client.MergeRequests().List(ctx, &scm.ListMergeRequestsOptions{State: "opened", First: 10})
+	require.NoError(t, err)
+	require.Equal(t, 999, limiter.Remaining())
+
+	// A REST request by a script function
+	evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+	evalContext.SetContext(ctx)
+
+	program, err := expr.Compile(`merge_request.approval_rules()`, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+	require.NoError(t, err)
+
+	_, err = expr.Run(program, evalContext)
+	require.NoError(t, err)
+	require.Equal(t, 998, limiter.Remaining())
+}
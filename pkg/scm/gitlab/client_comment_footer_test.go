@@ -0,0 +1,159 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CommentFooter(t *testing.T) {
+	t.Parallel()
+
+	const (
+		marker = "<!-- test-marker -->"
+		footer = "<sub>Posted by scm-engine</sub>"
+	)
+
+	withFooter := func(body string) string {
+		return body + "\n\n" + scm.CommentFooterMarker + "\n" + footer
+	}
+
+	tests := []struct {
+		name         string
+		notes        string
+		run          func(ctx context.Context, client scm.Client) error
+		wantRequests []string
+	}{
+		{
+			name: "comment has the footer",
+			run: func(ctx context.Context, client scm.Client) error {
+				return client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, config.ActionStep{
+					"action":  "comment",
+					"message": "Hello",
+				})
+			},
+			wantRequests: []string{
+				"POST /api/v4/projects/jippi/scm-engine/merge_requests/1/notes " + withFooter("Hello"),
+			},
+		},
+		{
+			name: "comment with suppressed mentions",
+			run: func(ctx context.Context, client scm.Client) error {
+				return client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, config.ActionStep{
+					"action":   "comment",
+					"message":  "Hello @alice",
+					"mentions": "suppress",
+				})
+			},
+			wantRequests: []string{
+				"POST /api/v4/projects/jippi/scm-engine/merge_requests/1/notes " + withFooter("Hello `@alice`"),
+			},
+		},
+		{
+			name:  "new note has the footer",
+			notes: `[]`,
+			run: func(ctx context.Context, client scm.Client) error {
+				return client.MergeRequests().UpsertNote(ctx, marker, marker+"\nHello")
+			},
+			wantRequests: []string{
+				"POST /api/v4/projects/jippi/scm-engine/merge_requests/1/notes " + withFooter(marker+"\nHello"),
+			},
+		},
+		{
+			name:  "updated note keeps a single footer",
+			notes: mustJSON(t, []map[string]any{{"id": 10, "body": withFooter(marker + "\nHello")}}),
+			run: func(ctx context.Context, client scm.Client) error {
+				return client.MergeRequests().UpsertNote(ctx, marker, marker+"\nHello again")
+			},
+			wantRequests: []string{
+				"PUT /api/v4/projects/jippi/scm-engine/merge_requests/1/notes/10 " + withFooter(marker+"\nHello again"),
+			},
+		},
+		{
+			name:  "note missing the footer is updated",
+			notes: mustJSON(t, []map[string]any{{"id": 10, "body": marker + "\nHello"}}),
+			run: func(ctx context.Context, client scm.Client) error {
+				return client.MergeRequests().UpsertNote(ctx, marker, marker+"\nHello")
+			},
+			wantRequests: []string{
+				"PUT /api/v4/projects/jippi/scm-engine/merge_requests/1/notes/10 " + withFooter(marker+"\nHello"),
+			},
+		},
+		{
+			name:  "unchanged note with the footer is left alone",
+			notes: mustJSON(t, []map[string]any{{"id": 10, "body": withFooter(marker + "\nHello")}}),
+			run: func(ctx context.Context, client scm.Client) error {
+				return client.MergeRequests().UpsertNote(ctx, marker, marker+"\nHello")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				if r.Method == http.MethodGet {
+					w.Write([]byte(tt.notes))
+
+					return
+				}
+
+				var payload struct {
+					Body string `json:"body"`
+				}
+
+				data, _ := io.ReadAll(r.Body)
+				require.NoError(t, json.Unmarshal(data, &payload))
+
+				mu.Lock()
+				requests = append(requests, r.Method+" "+r.URL.Path+" "+payload.Body)
+				mu.Unlock()
+
+				w.Write([]byte("{}"))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, false)
+			ctx = state.WithCommentFooter(ctx, footer)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			require.NoError(t, tt.run(ctx, client))
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+func mustJSON(t *testing.T, value any) string {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	return string(data)
+}
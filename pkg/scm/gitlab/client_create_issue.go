@@ -0,0 +1,159 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// createIssue creates an issue (e.g. a follow-up) linking the Merge Request, and comments the issue link on the Merge Request.
+//
+// The issue description carries a hidden marker for the Merge Request and step 'key', so an issue is only created once
+func (c *Client) createIssue(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	titleScript, err := step.RequiredString("title")
+	if err != nil {
+		return err
+	}
+
+	descriptionScript, err := step.OptionalString("description", "")
+	if err != nil {
+		return err
+	}
+
+	assigneeScript, err := step.OptionalString("assignee", "")
+	if err != nil {
+		return err
+	}
+
+	labels, err := step.OptionalStringSlice("labels")
+	if err != nil {
+		return err
+	}
+
+	project, err := step.OptionalString("project", state.ProjectID(ctx))
+	if err != nil {
+		return err
+	}
+
+	key, err := step.OptionalString("key", "follow-up")
+	if err != nil {
+		return err
+	}
+
+	reference := state.ProjectID(ctx) + "!" + state.MergeRequestID(ctx)
+	marker := createIssueMarker(reference, key)
+
+	existing, err := c.findIssueWithMarker(ctx, project, marker)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		slogctx.Debug(ctx, "Issue already exists for the Merge Request", slog.String("issue_url", existing.WebURL))
+
+		return nil
+	}
+
+	title, err := runStringScript(evalContext, titleScript)
+	if err != nil {
+		return fmt.Errorf("could not evaluate 'title': %w", err)
+	}
+
+	title = strings.TrimSpace(title)
+	if len(title) == 0 {
+		return errors.New("step field 'title' must not evaluate to an empty string")
+	}
+
+	description := "Follow-up for " + reference
+
+	if len(descriptionScript) > 0 {
+		description, err = runStringScript(evalContext, descriptionScript)
+		if err != nil {
+			return fmt.Errorf("could not evaluate 'description': %w", err)
+		}
+	}
+
+	description += "\n\n" + marker
+
+	opt := &go_gitlab.CreateIssueOptions{
+		Title:       &title,
+		Description: &description,
+	}
+
+	if len(labels) > 0 {
+		opt.Labels = scm.Ptr(go_gitlab.LabelOptions(labels))
+	}
+
+	var assignee string
+
+	if len(assigneeScript) > 0 {
+		assignee, err = runStringScript(evalContext, assigneeScript)
+		if err != nil {
+			return fmt.Errorf("could not evaluate 'assignee': %w", err)
+		}
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Creating issue", slog.String("project", project), slog.String("title", title), slog.Any("labels", labels), slog.String("assignee", assignee))
+
+		return nil
+	}
+
+	if len(assignee) > 0 {
+		assigneeIDs, err := c.userIDs(ctx, []string{assignee})
+		if err != nil {
+			return err
+		}
+
+		opt.AssigneeIDs = &assigneeIDs
+	}
+
+	issue, _, err := c.wrapped.Issues.CreateIssue(project, opt, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not create issue in project [%s]: %w", project, err)
+	}
+
+	slogctx.Info(ctx, "Created issue", slog.String("issue_url", issue.WebURL))
+
+	return c.comment(ctx, "Created follow-up issue "+issue.WebURL, false)
+}
+
+// createIssueMarker is the hidden marker used to find the issue created by the 'create_issue' step [key] for the Merge Request [reference]
+func createIssueMarker(reference, key string) string {
+	return fmt.Sprintf("<!-- scm-engine:create-issue:%s:%s -->", reference, key)
+}
+
+func (c *Client) findIssueWithMarker(ctx context.Context, project, marker string) (*go_gitlab.Issue, error) {
+	options := &go_gitlab.ListProjectIssuesOptions{
+		ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)},
+		Search:      scm.Ptr(strings.Trim(marker, "<!-> ")),
+		In:          scm.Ptr("description"),
+	}
+
+	for {
+		issues, resp, err := c.wrapped.Issues.ListProjectIssues(project, options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not search for an existing issue in project [%s]: %w", project, err)
+		}
+
+		// The search is fuzzy, so make sure the marker is actually in the description
+		for _, issue := range issues {
+			if strings.Contains(issue.Description, marker) {
+				return issue, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
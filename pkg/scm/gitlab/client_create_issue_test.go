@@ -0,0 +1,133 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_CreateIssue(t *testing.T) {
+	t.Parallel()
+
+	const (
+		issuesPath = "/api/v4/projects/jippi/scm-engine/issues"
+		notesPath  = "/api/v4/projects/jippi/scm-engine/merge_requests/1/notes"
+		usersPath  = "/api/v4/users"
+		marker     = "<!-- scm-engine:create-issue:jippi/scm-engine!1:follow-up -->"
+		createBody = `{"title":"Follow-up","description":"Follow-up for jippi/scm-engine!1\n\n\u003c!-- scm-engine:create-issue:jippi/scm-engine!1:follow-up --\u003e","assignee_ids":[42],"labels":"follow-up"}`
+	)
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		existing     string // JSON list of issues returned by the search
+		wantRequests []string
+	}{
+		{
+			name:     "issue is created and linked on the Merge Request",
+			existing: `[]`,
+			wantRequests: []string{
+				"GET " + issuesPath,
+				"GET " + usersPath,
+				"POST " + issuesPath + " " + createBody,
+				"POST " + notesPath + ` {"body":"Created follow-up issue https://gitlab.example.com/jippi/scm-engine/-/issues/7"}`,
+			},
+		},
+		{
+			name:     "existing issue with the marker is not created again",
+			existing: mustJSON(t, []map[string]any{{"id": 70, "iid": 7, "description": "Follow-up\n\n" + marker}}),
+			wantRequests: []string{
+				"GET " + issuesPath,
+			},
+		},
+		{
+			name:     "issue mentioning the Merge Request without the marker doesn't count",
+			existing: `[{"id": 30, "iid": 3, "description": "see jippi/scm-engine!1"}]`,
+			wantRequests: []string{
+				"GET " + issuesPath,
+				"GET " + usersPath,
+				"POST " + issuesPath + " " + createBody,
+				"POST " + notesPath + ` {"body":"Created follow-up issue https://gitlab.example.com/jippi/scm-engine/-/issues/7"}`,
+			},
+		},
+		{
+			name:     "dry run doesn't create the issue",
+			dryRun:   true,
+			existing: `[]`,
+			wantRequests: []string{
+				"GET " + issuesPath,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.Path
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == issuesPath:
+					w.Write([]byte(tt.existing))
+
+				case r.Method == http.MethodGet && r.URL.Path == usersPath:
+					w.Write([]byte(`[{"id": 42, "username": "jane"}]`))
+
+				case r.Method == http.MethodPost && r.URL.Path == issuesPath:
+					w.Write([]byte(`{"id": 70, "iid": 7, "web_url": "https://gitlab.example.com/jippi/scm-engine/-/issues/7"}`))
+
+				default:
+					w.Write([]byte(`{}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, tt.dryRun)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			step := config.ActionStep{
+				"action":   "create_issue",
+				"title":    `"Follow-up"`,
+				"assignee": `"jane"`,
+				"labels":   []any{"follow-up"},
+			}
+
+			err = client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, step)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
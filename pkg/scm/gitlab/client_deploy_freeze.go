@@ -0,0 +1,46 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// DeployFreezes returns the deploy freeze periods of the project (Settings → CI/CD → Deploy freezes).
+//
+// Periods GitLab accepted, but scm-engine can't parse, are logged and ignored
+func (c *Client) DeployFreezes(ctx context.Context) (stdlib.DeployFreezes, error) {
+	var (
+		freezes stdlib.DeployFreezes
+		options = &go_gitlab.ListFreezePeriodsOptions{PerPage: state.APIPageSize(ctx)}
+	)
+
+	for {
+		periods, resp, err := c.wrapped.FreezePeriods.ListFreezePeriods(state.ProjectID(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not list the deploy freeze periods: %w", err)
+		}
+
+		for _, period := range periods {
+			freeze, err := stdlib.NewDeployFreeze(period.FreezeStart, period.FreezeEnd, period.CronTimezone)
+			if err != nil {
+				slogctx.Warn(ctx, "Ignoring deploy freeze period", slog.Int("freeze_period_id", period.ID), slog.Any("error", err))
+
+				continue
+			}
+
+			freezes = append(freezes, freeze)
+		}
+
+		if resp.NextPage == 0 {
+			return freezes, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,68 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_APIHeaders(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		headers = map[string]http.Header{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/api/graphql" {
+			w.Write([]byte(strings.Replace(mergeRequestResponse, "%s", `"targetProjectId": 1, "sourceProjectId": 1`, 1)))
+
+			return
+		}
+
+		w.Write([]byte(`{"iid": 1, "sha": "abc123"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithAPIHeaders(ctx, http.Header{
+		"User-Agent":  []string{"scm-engine/1.2.3"},
+		"X-Caller-Id": []string{"platform-team"},
+	})
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	// REST request
+	_, err = client.MergeRequests().HeadSHA(ctx)
+	require.NoError(t, err)
+
+	// GraphQL request
+	_, err = gitlab.NewContext(ctx, server.URL, "token")
+	require.NoError(t, err)
+
+	require.Len(t, headers, 2)
+
+	for path, header := range headers {
+		require.Equal(t, "scm-engine/1.2.3", header.Get("User-Agent"), path)
+		require.Equal(t, "platform-team", header.Get("X-Caller-Id"), path)
+	}
+}
@@ -1,10 +1,15 @@
 package gitlab
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"strconv"
 
 	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
 	go_gitlab "github.com/xanzy/go-gitlab"
 )
 
@@ -46,3 +51,32 @@ func convertResponse(upstream *go_gitlab.Response) *scm.Response {
 		// LastLink:     upstream.LastLink,
 	}
 }
+
+// setPolicyStatus sets the commit status [name] on the Merge Request head commit to success if [passed], and failed otherwise
+func (c *Client) setPolicyStatus(ctx context.Context, name string, passed bool, description string) error {
+	status := go_gitlab.Success
+	if !passed {
+		status = go_gitlab.Failed
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Setting commit status", slog.String("status", string(status)), slog.String("status_name", name))
+
+		return nil
+	}
+
+	_, response, err := c.wrapped.Commits.SetCommitStatus(state.ProjectID(ctx), state.CommitSHA(ctx), &go_gitlab.SetCommitStatusOptions{
+		State:       status,
+		Name:        scm.Ptr(name),
+		Description: scm.Ptr(description),
+	}, go_gitlab.WithContext(ctx))
+
+	// GitLab returns '400 Cannot transition status' if the status didn't change
+	if response != nil && response.StatusCode == http.StatusBadRequest {
+		slogctx.Debug(ctx, "could not update commit status", slog.Any("err", err))
+
+		return nil
+	}
+
+	return err
+}
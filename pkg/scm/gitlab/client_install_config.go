@@ -0,0 +1,58 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// ErrConfigFileExists is returned by [Client.InstallConfig] when the project already has a configuration file
+var ErrConfigFileExists = errors.New("configuration file already exists")
+
+// InstallConfig commits [content] as [filename] to the default branch of [project]
+//
+// Projects without a default branch (e.g. empty repositories) get the file committed to 'main'
+func (c *Client) InstallConfig(ctx context.Context, project, filename, content string) error {
+	remote, _, err := c.wrapped.Projects.GetProject(project, nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not read project %q: %w", project, err)
+	}
+
+	branch := remote.DefaultBranch
+	if len(branch) == 0 {
+		branch = "main"
+	}
+
+	_, resp, err := c.wrapped.RepositoryFiles.GetFileMetaData(project, filename, &go_gitlab.GetFileMetaDataOptions{Ref: scm.Ptr(branch)}, go_gitlab.WithContext(ctx))
+	if err == nil {
+		return fmt.Errorf("%w: %s@%s:%s", ErrConfigFileExists, project, branch, filename)
+	}
+
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("could not check for an existing configuration file: %w", err)
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Would install configuration file", slog.String("project", project), slog.String("branch", branch), slog.String("file", filename))
+
+		return nil
+	}
+
+	_, _, err = c.wrapped.RepositoryFiles.CreateFile(project, filename, &go_gitlab.CreateFileOptions{
+		Branch:        scm.Ptr(branch),
+		Content:       scm.Ptr(content),
+		CommitMessage: scm.Ptr("Add scm-engine configuration"),
+	}, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not create configuration file: %w", err)
+	}
+
+	return nil
+}
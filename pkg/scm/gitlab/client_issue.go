@@ -0,0 +1,145 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// Ensure the GitLab client can evaluate issue events
+var _ scm.IssueClient = (*Client)(nil)
+
+// IssueEvalContext returns the evaluation context of the 'on_issue' actions for the issue [iid] of the project
+func (c *Client) IssueEvalContext(ctx context.Context, iid int) (scm.EvalContext, error) {
+	issue, _, err := c.wrapped.Issues.GetIssue(state.ProjectID(ctx), iid, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not read issue #%d: %w", iid, err)
+	}
+
+	return newIssueContext(state.ProjectID(ctx), issue), nil
+}
+
+// ApplyIssueStep applies an 'on_issue' action [step] to the issue of [evalContext]
+func (c *Client) ApplyIssueStep(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	action, err := step.RequiredString("action")
+	if err != nil {
+		return err
+	}
+
+	issueContext, ok := evalContext.(*IssueContext)
+	if !ok {
+		return fmt.Errorf("action %q can only be applied to an issue, got %T", action, evalContext)
+	}
+
+	switch action {
+	case "move_to_project":
+		return c.moveIssue(ctx, issueContext, step)
+
+	default:
+		return fmt.Errorf("action %q can't be applied to an issue", action)
+	}
+}
+
+// moveIssue moves the issue to the project returned by the 'project' script, and comments the new location on the issue.
+//
+// Issues that were moved before are never moved again, so configuration files moving issues back and forth can't loop
+func (c *Client) moveIssue(ctx context.Context, evalContext *IssueContext, step scm.ActionStep) error {
+	script, err := step.RequiredString("project")
+	if err != nil {
+		return err
+	}
+
+	target, err := runStringScript(evalContext, script)
+	if err != nil {
+		return fmt.Errorf("could not evaluate step field 'project': %w", err)
+	}
+
+	if len(target) == 0 {
+		return errors.New("step field 'project' returned an empty project path")
+	}
+
+	issue := evalContext.Issue
+	ctx = slogctx.With(ctx, slog.String("target_project", target))
+
+	if strings.EqualFold(target, issue.Project) {
+		slogctx.Debug(ctx, "Issue is already in the target project")
+
+		return nil
+	}
+
+	if issue.MovedToID != 0 {
+		slogctx.Info(ctx, "Issue was already moved, not moving it again")
+
+		return nil
+	}
+
+	moved, err := c.issueWasMovedHere(ctx, issue)
+	if err != nil {
+		return err
+	}
+
+	if moved {
+		slogctx.Info(ctx, "Issue was moved to the project from another project, not moving it again")
+
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Moving issue")
+
+		return nil
+	}
+
+	project, _, err := c.wrapped.Projects.GetProject(target, nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not find the project %q to move the issue to: %w", target, err)
+	}
+
+	newIssue, _, err := c.wrapped.Issues.MoveIssue(state.ProjectID(ctx), issue.IID, &go_gitlab.MoveIssueOptions{ToProjectID: &project.ID}, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not move the issue to %q: %w", target, err)
+	}
+
+	slogctx.Info(ctx, "Moved issue", slog.String("new_issue_url", newIssue.WebURL))
+
+	// The moved issue is closed, comment the new location so people following it know where it went
+	body := scm.AppendCommentFooter(ctx, fmt.Sprintf("Moved to %s by scm-engine.", newIssue.WebURL))
+
+	if _, _, err := c.wrapped.Notes.CreateIssueNote(state.ProjectID(ctx), issue.IID, &go_gitlab.CreateIssueNoteOptions{Body: &body}, go_gitlab.WithContext(ctx)); err != nil {
+		return fmt.Errorf("could not comment the new location on the moved issue: %w", err)
+	}
+
+	return nil
+}
+
+// issueWasMovedHere reports whether [issue] was moved to its project from another project, going by the
+// "moved from" system note GitLab adds to the new issue
+func (c *Client) issueWasMovedHere(ctx context.Context, issue *ContextIssue) (bool, error) {
+	options := &go_gitlab.ListIssueNotesOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+
+	for {
+		notes, resp, err := c.wrapped.Notes.ListIssueNotes(state.ProjectID(ctx), issue.IID, options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return false, fmt.Errorf("could not list the issue comments: %w", err)
+		}
+
+		for _, note := range notes {
+			if note.System && strings.HasPrefix(note.Body, "moved from ") {
+				return true, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
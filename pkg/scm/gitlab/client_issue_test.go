@@ -0,0 +1,167 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyIssueStep_MoveToProject(t *testing.T) {
+	t.Parallel()
+
+	const (
+		issuePath = "/api/v4/projects/jippi/scm-engine/issues/3"
+		notesPath = issuePath + "/notes"
+		newIssue  = "https://gitlab.example.com/group/support/-/issues/7"
+	)
+
+	tests := []struct {
+		name         string
+		project      string
+		dryRun       bool
+		movedToID    int
+		notes        string // JSON list of the issue notes
+		wantRequests []string
+	}{
+		{
+			name:    "moves the issue and comments the new location",
+			project: `"group/support"`,
+			notes:   `[{"id": 1, "body": "changed the description", "system": true}]`,
+			wantRequests: []string{
+				"GET " + issuePath,
+				"GET " + notesPath,
+				"GET /api/v4/projects/group/support",
+				`POST ` + issuePath + `/move {"to_project_id":20}`,
+				`POST ` + notesPath + ` {"body":"Moved to ` + newIssue + ` by scm-engine."}`,
+			},
+		},
+		{
+			name:    "issues moved from another project are not moved again",
+			project: `"group/support"`,
+			notes:   `[{"id": 1, "body": "moved from group/support#7", "system": true}]`,
+			wantRequests: []string{
+				"GET " + issuePath,
+				"GET " + notesPath,
+			},
+		},
+		{
+			name:    "comments mentioning a move are not system notes",
+			project: `"group/support"`,
+			notes:   `[{"id": 1, "body": "moved from group/support#7", "system": false}]`,
+			wantRequests: []string{
+				"GET " + issuePath,
+				"GET " + notesPath,
+				"GET /api/v4/projects/group/support",
+				`POST ` + issuePath + `/move {"to_project_id":20}`,
+				`POST ` + notesPath + ` {"body":"Moved to ` + newIssue + ` by scm-engine."}`,
+			},
+		},
+		{
+			name:         "moved issues are not moved again",
+			project:      `"group/support"`,
+			movedToID:    70,
+			wantRequests: []string{"GET " + issuePath},
+		},
+		{
+			name:         "issues already in the project are left as-is",
+			project:      `issue.project`,
+			wantRequests: []string{"GET " + issuePath},
+		},
+		{
+			name:    "dry run doesn't move the issue",
+			project: `"group/support"`,
+			dryRun:  true,
+			notes:   `[]`,
+			wantRequests: []string{
+				"GET " + issuePath,
+				"GET " + notesPath,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.Path
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == issuePath:
+					w.Write([]byte(mustJSON(t, map[string]any{"id": 30, "iid": 3, "title": "Printer on fire", "moved_to_id": tt.movedToID, "author": map[string]any{"username": "jippi"}})))
+
+				case r.Method == http.MethodGet && r.URL.Path == notesPath:
+					w.Write([]byte(tt.notes))
+
+				case r.URL.Path == "/api/v4/projects/group/support":
+					w.Write([]byte(`{"id": 20, "path_with_namespace": "group/support"}`))
+
+				case r.URL.Path == issuePath+"/move":
+					w.Write([]byte(`{"id": 31, "iid": 7, "web_url": "` + newIssue + `"}`))
+
+				default:
+					w.Write([]byte(`{"id": 1}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithDryRun(ctx, tt.dryRun)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			evalContext, err := client.IssueEvalContext(ctx, 3)
+			require.NoError(t, err)
+			require.Equal(t, "Printer on fire", evalContext.GetTitle())
+
+			err = client.ApplyIssueStep(ctx, evalContext, config.ActionStep{"action": "move_to_project", "project": tt.project})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+
+	t.Run("only applies to issues", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, "https://gitlab.example.com")
+		ctx = state.WithToken(ctx, "token")
+
+		client, err := gitlab.NewClient(ctx)
+		require.NoError(t, err)
+
+		err = client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": "move_to_project", "project": `"group/support"`})
+		require.ErrorContains(t, err, `action "move_to_project" only applies to issues, use it in 'on_issue' instead`)
+
+		err = client.ApplyIssueStep(ctx, &gitlab.IssueContext{Issue: &gitlab.ContextIssue{}}, config.ActionStep{"action": "comment", "message": "hello"})
+		require.ErrorContains(t, err, `action "comment" can't be applied to an issue`)
+	})
+}
@@ -36,7 +36,7 @@ func (client *LabelClient) List(ctx context.Context) ([]*scm.Label, error) {
 	opts := &scm.ListLabelsOptions{
 		IncludeAncestorGroups: scm.Ptr(true),
 		ListOptions: scm.ListOptions{
-			PerPage: 100,
+			PerPage: state.APIPageSize(ctx),
 			Page:    1,
 		},
 	}
@@ -0,0 +1,44 @@
+package gitlab
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// snapshotLabels records the labels the Merge Request had before this evaluation in a comment,
+// so they can be restored with 'scm-engine gitlab labels restore'.
+//
+// An existing snapshot is kept, unless the step 'overwrite' option is on
+func (c *Client) snapshotLabels(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	overwrite, err := step.OptionalBool("overwrite", false)
+	if err != nil {
+		return err
+	}
+
+	if !overwrite {
+		existing, err := c.MergeRequests().FindNote(ctx, scm.LabelSnapshotMarker)
+		if err != nil {
+			return err
+		}
+
+		if len(existing) > 0 {
+			slogctx.Debug(ctx, "Merge Request already has a label snapshot")
+
+			return nil
+		}
+	}
+
+	labels := evalContext.GetLabels()
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Taking label snapshot", slog.Any("labels", labels))
+
+		return nil
+	}
+
+	return c.MergeRequests().UpsertNote(ctx, scm.LabelSnapshotMarker, scm.FormatLabelSnapshot(labels))
+}
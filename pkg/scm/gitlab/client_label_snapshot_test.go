@@ -0,0 +1,137 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_LabelSnapshot(t *testing.T) {
+	t.Parallel()
+
+	const (
+		notesPath        = "/api/v4/projects/jippi/scm-engine/merge_requests/1/notes"
+		mergeRequestPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1"
+	)
+
+	var (
+		mu       sync.Mutex
+		notes    []map[string]any
+		restored string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == notesPath:
+			w.Write([]byte(mustJSON(t, notes)))
+
+		case r.Method == http.MethodPost && r.URL.Path == notesPath:
+			note := map[string]any{}
+			require.NoError(t, json.Unmarshal(body, &note))
+
+			note["id"] = len(notes) + 1
+			notes = append(notes, note)
+
+			w.Write([]byte(mustJSON(t, note)))
+
+		case r.Method == http.MethodPut && r.URL.Path == notesPath+"/1":
+			note := map[string]any{}
+			require.NoError(t, json.Unmarshal(body, &note))
+
+			note["id"] = 1
+			notes[0] = note
+
+			w.Write([]byte(mustJSON(t, note)))
+
+		case r.Method == http.MethodPut && r.URL.Path == mergeRequestPath:
+			restored = string(body)
+
+			w.Write([]byte(`{"iid": 1}`))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+	ctx = state.WithDryRun(ctx, false)
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	withLabels := func(labels ...string) *gitlab.Context {
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+
+		for _, label := range labels {
+			evalContext.MergeRequest.Labels = append(evalContext.MergeRequest.Labels, gitlab.ContextLabel{Title: label})
+		}
+
+		return evalContext
+	}
+
+	snapshot := func(evalContext *gitlab.Context, overwrite bool) {
+		err := client.ApplyStep(ctx, evalContext, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": "snapshot_labels", "overwrite": overwrite})
+		require.NoError(t, err)
+	}
+
+	restore := func() []string {
+		body, err := client.MergeRequests().FindNote(ctx, scm.LabelSnapshotMarker)
+		require.NoError(t, err)
+
+		labels, err := scm.ParseLabelSnapshot(body)
+		require.NoError(t, err)
+
+		return labels
+	}
+
+	// No snapshot yet
+	body, err := client.MergeRequests().FindNote(ctx, scm.LabelSnapshotMarker)
+	require.NoError(t, err)
+	require.Empty(t, body)
+
+	_, err = scm.ParseLabelSnapshot(body)
+	require.ErrorIs(t, err, scm.ErrNoLabelSnapshot)
+
+	// The first snapshot is kept by later evaluations
+	snapshot(withLabels("bug", "security"), false)
+	snapshot(withLabels("bug", "wrong-label"), false)
+
+	require.Len(t, notes, 1)
+	require.Equal(t, []string{"bug", "security"}, restore())
+
+	// Unless the snapshot is overwritten
+	snapshot(withLabels(), true)
+
+	require.Len(t, notes, 1)
+	require.Equal(t, []string{}, restore())
+
+	snapshot(withLabels("bug", "security"), true)
+
+	// Restoring replaces all labels with the snapshot
+	_, err = client.MergeRequests().Update(ctx, &scm.UpdateMergeRequestOptions{Labels: scm.Ptr(scm.LabelOptions(restore()))})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"labels": ["bug", "security"]}`, restored)
+}
@@ -0,0 +1,216 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// updateLinkedIssues adds labels and (optionally) a comment to the issues the merged Merge Request closes,
+// and with 'related' also the issues it mentions.
+//
+// The comment carries a hidden marker for the Merge Request, so redelivered merge events don't comment twice
+func (c *Client) updateLinkedIssues(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	labels, err := step.OptionalStringSlice("labels")
+	if err != nil {
+		return err
+	}
+
+	script, err := step.OptionalString("script", "")
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", "")
+	if err != nil {
+		return err
+	}
+
+	related, err := step.OptionalBool("related", false)
+	if err != nil {
+		return err
+	}
+
+	if len(script) > 0 {
+		fromScript, err := runStringSliceScript(evalContext, script)
+		if err != nil {
+			return fmt.Errorf("could not evaluate 'script': %w", err)
+		}
+
+		labels = append(labels, fromScript...)
+	}
+
+	if len(labels) == 0 && len(message) == 0 {
+		return errors.New("step field 'labels' (or 'script') or 'message' is required")
+	}
+
+	if event := state.TriggerEvent(ctx); event != state.TriggerEventMerge {
+		slogctx.Debug(ctx, "Not updating linked issues, the Merge Request is not being merged", slog.String("trigger_event", event))
+
+		return nil
+	}
+
+	issues, err := c.linkedIssues(ctx, related)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		slogctx.Info(ctx, "The Merge Request has no linked issues to update")
+
+		return nil
+	}
+
+	reference := state.ProjectID(ctx) + "!" + state.MergeRequestID(ctx)
+	marker := linkedIssueMarker(reference)
+
+	for _, issue := range issues {
+		ctx := slogctx.With(ctx, slog.String("issue_url", issue.WebURL))
+
+		if err := c.updateLinkedIssue(ctx, issue, labels, message, marker); err != nil {
+			return fmt.Errorf("could not update linked issue %s: %w", issue.WebURL, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) updateLinkedIssue(ctx context.Context, issue *go_gitlab.Issue, labels []string, message, marker string) error {
+	var missing []string
+
+	for _, label := range labels {
+		if !slices.Contains(issue.Labels, label) && !slices.Contains(missing, label) {
+			missing = append(missing, label)
+		}
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Updating linked issue", slog.Any("labels", missing), slog.String("message", message))
+
+		return nil
+	}
+
+	if len(missing) > 0 {
+		_, resp, err := c.wrapped.Issues.UpdateIssue(issue.ProjectID, issue.IID, &go_gitlab.UpdateIssueOptions{
+			AddLabels: scm.Ptr(go_gitlab.LabelOptions(missing)),
+		}, go_gitlab.WithContext(ctx))
+		if err != nil {
+			// The issue was deleted or moved since the Merge Request linked it, or the token can't access it
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				slogctx.Warn(ctx, "Linked issue not found, skipping it")
+
+				return nil
+			}
+
+			return err
+		}
+
+		slogctx.Info(ctx, "Added labels to linked issue", slog.Any("labels", missing))
+	}
+
+	if len(message) == 0 {
+		return nil
+	}
+
+	commented, err := c.issueHasNoteWithMarker(ctx, issue, marker)
+	if err != nil {
+		return err
+	}
+
+	if commented {
+		slogctx.Debug(ctx, "Linked issue already has a comment for the Merge Request")
+
+		return nil
+	}
+
+	body := scm.AppendCommentFooter(ctx, message) + "\n\n" + marker
+
+	_, _, err = c.wrapped.Notes.CreateIssueNote(issue.ProjectID, issue.IID, &go_gitlab.CreateIssueNoteOptions{Body: &body}, go_gitlab.WithContext(ctx))
+
+	return err
+}
+
+// linkedIssues returns the issues closed by merging the Merge Request, and with [related] also the issues it mentions
+func (c *Client) linkedIssues(ctx context.Context, related bool) ([]*go_gitlab.Issue, error) {
+	var issues []*go_gitlab.Issue
+
+	options := &go_gitlab.GetIssuesClosedOnMergeOptions{PerPage: state.APIPageSize(ctx)}
+
+	for {
+		page, resp, err := c.wrapped.MergeRequests.GetIssuesClosedOnMerge(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not list the issues closed by the Merge Request: %w", err)
+		}
+
+		issues = append(issues, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	if !related {
+		return issues, nil
+	}
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/related_issues", go_gitlab.PathEscape(state.ProjectID(ctx)), state.MergeRequestIDInt(ctx))
+
+	req, err := c.wrapped.NewRequest(http.MethodGet, endpoint, &go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}, []go_gitlab.RequestOptionFunc{go_gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
+	var mentioned []*go_gitlab.Issue
+
+	if _, err := c.wrapped.Do(req, &mentioned); err != nil {
+		return nil, fmt.Errorf("could not list the issues related to the Merge Request: %w", err)
+	}
+
+	// Issues that are both closed and mentioned by the Merge Request are only updated once
+	for _, issue := range mentioned {
+		if !slices.ContainsFunc(issues, func(existing *go_gitlab.Issue) bool { return existing.ID == issue.ID }) {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// linkedIssueMarker is the hidden marker used to find the linked issue comment for the Merge Request [reference]
+func linkedIssueMarker(reference string) string {
+	return fmt.Sprintf("<!-- scm-engine:linked-issue:%s -->", reference)
+}
+
+func (c *Client) issueHasNoteWithMarker(ctx context.Context, issue *go_gitlab.Issue, marker string) (bool, error) {
+	options := &go_gitlab.ListIssueNotesOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+
+	for {
+		notes, resp, err := c.wrapped.Notes.ListIssueNotes(issue.ProjectID, issue.IID, options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return false, fmt.Errorf("could not list the issue comments: %w", err)
+		}
+
+		for _, note := range notes {
+			if strings.Contains(note.Body, marker) {
+				return true, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
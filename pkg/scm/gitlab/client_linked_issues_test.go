@@ -0,0 +1,182 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_UpdateLinkedIssues(t *testing.T) {
+	t.Parallel()
+
+	const (
+		closesPath  = "/api/v4/projects/jippi/scm-engine/merge_requests/1/closes_issues"
+		relatedPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1/related_issues"
+		marker      = "<!-- scm-engine:linked-issue:jippi/scm-engine!1 -->"
+		noteBody    = `{"body":"Fixed in main\n\n\u003c!-- scm-engine:linked-issue:jippi/scm-engine!1 --\u003e"}`
+		issue3      = `{"id": 30, "iid": 3, "project_id": 10, "labels": ["bug"], "web_url": "https://gitlab.example.com/group/a/-/issues/3"}`
+		issue4      = `{"id": 40, "iid": 4, "project_id": 11, "labels": ["fixed-in::main"], "web_url": "https://gitlab.example.com/group/b/-/issues/4"}`
+		missing     = `{"id": 50, "iid": 5, "project_id": 12, "labels": [], "web_url": "https://gitlab.example.com/group/c/-/issues/5"}`
+	)
+
+	tests := []struct {
+		name         string
+		event        string
+		dryRun       bool
+		related      bool
+		closes       string // JSON list of issues closed by the Merge Request
+		wantRequests []string
+	}{
+		{
+			name:   "labels and comments every closed issue",
+			event:  state.TriggerEventMerge,
+			closes: "[" + issue3 + "," + issue4 + "]",
+			wantRequests: []string{
+				"GET " + closesPath,
+				`PUT /api/v4/projects/10/issues/3 {"add_labels":"fixed-in::main"}`,
+				"GET /api/v4/projects/10/issues/3/notes",
+				"POST /api/v4/projects/10/issues/3/notes " + noteBody,
+				// Already labeled and commented, so nothing changes
+				"GET /api/v4/projects/11/issues/4/notes",
+			},
+		},
+		{
+			name:   "missing issues are skipped",
+			event:  state.TriggerEventMerge,
+			closes: "[" + missing + "," + issue3 + "]",
+			wantRequests: []string{
+				"GET " + closesPath,
+				`PUT /api/v4/projects/12/issues/5 {"add_labels":"fixed-in::main"}`,
+				`PUT /api/v4/projects/10/issues/3 {"add_labels":"fixed-in::main"}`,
+				"GET /api/v4/projects/10/issues/3/notes",
+				"POST /api/v4/projects/10/issues/3/notes " + noteBody,
+			},
+		},
+		{
+			name:    "related issues are updated too, but only once",
+			event:   state.TriggerEventMerge,
+			related: true,
+			closes:  "[" + issue4 + "]",
+			wantRequests: []string{
+				"GET " + closesPath,
+				"GET " + relatedPath,
+				"GET /api/v4/projects/11/issues/4/notes",
+				`PUT /api/v4/projects/10/issues/3 {"add_labels":"fixed-in::main"}`,
+				"GET /api/v4/projects/10/issues/3/notes",
+				"POST /api/v4/projects/10/issues/3/notes " + noteBody,
+			},
+		},
+		{
+			name:         "no linked issues",
+			event:        state.TriggerEventMerge,
+			closes:       `[]`,
+			wantRequests: []string{"GET " + closesPath},
+		},
+		{
+			name:         "dry run doesn't update the issues",
+			event:        state.TriggerEventMerge,
+			dryRun:       true,
+			closes:       "[" + issue3 + "]",
+			wantRequests: []string{"GET " + closesPath},
+		},
+		{
+			name:   "only runs when the Merge Request is merged",
+			event:  state.TriggerEventUpdate,
+			closes: "[" + issue3 + "]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.Path
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch {
+				case r.URL.Path == closesPath:
+					w.Write([]byte(tt.closes))
+
+				case r.URL.Path == relatedPath:
+					w.Write([]byte("[" + issue3 + "," + issue4 + "]"))
+
+				case r.URL.Path == "/api/v4/projects/12/issues/5":
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message": "404 Not found"}`))
+
+				case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/11/issues/4/notes":
+					w.Write([]byte(mustJSON(t, []map[string]any{{"id": 1, "body": "Fixed in main\n\n" + marker}})))
+
+				case r.Method == http.MethodGet:
+					w.Write([]byte(`[]`))
+
+				default:
+					w.Write([]byte(`{"id": 1}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithTriggerEvent(ctx, tt.event)
+			ctx = state.WithDryRun(ctx, tt.dryRun)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			step := config.ActionStep{
+				"action":  "update_linked_issues",
+				"script":  `["fixed-in::" + merge_request.target_branch]`,
+				"message": "Fixed in main",
+				"related": tt.related,
+			}
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{TargetBranch: "main"}}
+
+			err = client.ApplyStep(ctx, evalContext, &scm.UpdateMergeRequestOptions{}, step)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+
+	t.Run("labels or a message is required", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, "https://gitlab.example.com")
+		ctx = state.WithToken(ctx, "token")
+
+		client, err := gitlab.NewClient(ctx)
+		require.NoError(t, err)
+
+		err = client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": "update_linked_issues"})
+		require.ErrorContains(t, err, "step field 'labels' (or 'script') or 'message' is required")
+	})
+}
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/hasura/go-graphql-client"
 	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/jippi/scm-engine/pkg/state"
 	go_gitlab "github.com/xanzy/go-gitlab"
-	"golang.org/x/oauth2"
 )
 
 var _ scm.MergeRequestClient = (*MergeRequestClient)(nil)
@@ -60,52 +60,223 @@ func (client *MergeRequestClient) GetRemoteConfig(ctx context.Context, filename,
 		refPtr = scm.Ptr(ref)
 	}
 
-	file, _, err := client.client.wrapped.RepositoryFiles.GetRawFile(project, filename, &go_gitlab.GetRawFileOptions{Ref: refPtr})
+	file, resp, err := client.client.wrapped.RepositoryFiles.GetRawFile(project, filename, &go_gitlab.GetRawFileOptions{Ref: refPtr})
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("failed to read remote raw file: %w: %w", scm.ErrConfigFileNotFound, err)
+		}
+
 		return nil, fmt.Errorf("failed to read remote raw file: %w", err)
 	}
 
 	return bytes.NewReader(file), nil
 }
 
-func (client *MergeRequestClient) List(ctx context.Context, options *scm.ListMergeRequestsOptions) ([]scm.ListMergeRequest, error) {
-	httpClient := oauth2.NewClient(
-		ctx,
-		oauth2.StaticTokenSource(
-			&oauth2.Token{
-				AccessToken: state.Token(ctx),
-			},
-		),
+func (client *MergeRequestClient) HeadSHA(ctx context.Context) (string, error) {
+	mergeRequest, _, err := client.client.wrapped.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("could not read merge request head commit: %w", err)
+	}
+
+	return mergeRequest.SHA, nil
+}
+
+func (client *MergeRequestClient) LabelEvents(ctx context.Context) ([]scm.LabelEvent, error) {
+	var (
+		events  []scm.LabelEvent
+		options = &go_gitlab.ListLabelEventsOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
 	)
 
+	for {
+		page, resp, err := client.client.wrapped.ResourceLabelEvents.ListMergeRequestsLabelEvents(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not read merge request label events: %w", err)
+		}
+
+		for _, event := range page {
+			if event.CreatedAt == nil {
+				continue
+			}
+
+			events = append(events, scm.LabelEvent{
+				Label:     event.Label.Name,
+				Action:    event.Action,
+				User:      event.User.Username,
+				CreatedAt: *event.CreatedAt,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return events, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+// FindNote returns the body of the first note containing [marker], or an empty string if none does
+func (client *MergeRequestClient) FindNote(ctx context.Context, marker string) (string, error) {
+	note, err := client.findNote(ctx, marker)
+	if err != nil || note == nil {
+		return "", err
+	}
+
+	return note.Body, nil
+}
+
+// UpsertNote updates the Merge Request note containing [marker] with [body], or creates a new note if none exists
+//
+// The configured 'comment_footer' is appended to [body] before comparing it with the existing note, so the note
+// is also updated when only the footer changed.
+func (client *MergeRequestClient) UpsertNote(ctx context.Context, marker, body string) error {
+	return client.upsertNote(ctx, marker, body, false)
+}
+
+// UpsertInternalNote works like [MergeRequestClient.UpsertNote], but creates an internal note, only visible to
+// project members, for records meant for scm-engine rather than the author of the Merge Request.
+//
+// Internal notes fall back to regular notes (with a warning) if the GitLab instance doesn't support them.
+func (client *MergeRequestClient) UpsertInternalNote(ctx context.Context, marker, body string) error {
+	return client.upsertNote(ctx, marker, body, true)
+}
+
+func (client *MergeRequestClient) upsertNote(ctx context.Context, marker, body string, internal bool) error {
+	body = scm.AppendCommentFooter(ctx, body)
+	project, mergeRequestID := state.ProjectID(ctx), state.MergeRequestIDInt(ctx)
+
+	note, err := client.findNote(ctx, marker)
+	if err != nil {
+		return err
+	}
+
+	if note == nil {
+		return client.client.createNote(ctx, body, internal)
+	}
+
+	// Nothing changed, so no reason to update the note
+	if note.Body == body {
+		return nil
+	}
+
+	_, _, err = client.client.wrapped.Notes.UpdateMergeRequestNote(project, mergeRequestID, note.ID, &go_gitlab.UpdateMergeRequestNoteOptions{Body: scm.Ptr(body)}, go_gitlab.WithContext(ctx))
+
+	return err
+}
+
+// UpsertDescriptionBlock puts [block] in the managed block at the top of the Merge Request description,
+// see [scm.InjectDescriptionBlock]. The description is read again, so changes made by this evaluation are kept
+func (client *MergeRequestClient) UpsertDescriptionBlock(ctx context.Context, block string) error {
+	mergeRequest, _, err := client.client.wrapped.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not read merge request description: %w", err)
+	}
+
+	description := scm.InjectDescriptionBlock(mergeRequest.Description, block)
+
+	// Nothing changed, so no reason to update the description
+	if description == mergeRequest.Description {
+		return nil
+	}
+
+	_, err = client.Update(ctx, &scm.UpdateMergeRequestOptions{Description: scm.Ptr(description)})
+
+	return err
+}
+
+func (client *MergeRequestClient) findNote(ctx context.Context, marker string) (*go_gitlab.Note, error) {
+	options := &go_gitlab.ListMergeRequestNotesOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+
+	for {
+		notes, resp, err := client.client.wrapped.Notes.ListMergeRequestNotes(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not list merge request notes: %w", err)
+		}
+
+		for _, note := range notes {
+			if strings.Contains(note.Body, marker) {
+				return note, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+// List returns the Merge Requests matching [options], reading all pages of [scm.ListMergeRequestsOptions.First] Merge Requests
+func (client *MergeRequestClient) List(ctx context.Context, options *scm.ListMergeRequestsOptions) ([]scm.ListMergeRequest, error) {
+	httpClient := newGraphQLHTTPClient(ctx, state.Token(ctx))
+
 	graphqlClient := graphql.NewClient(graphqlBaseURL(client.client.wrapped.BaseURL())+"/api/graphql", httpClient)
 
 	var (
-		result    *ListMergeRequestsQuery
-		variables = map[string]any{
-			"project_id": graphql.ID(state.ProjectID(ctx)),
-			"state":      MergeRequestState(options.State),
-			"first":      options.First,
-		}
+		results = []scm.ListMergeRequest{}
+		cursor  *string
 	)
 
-	if err := graphqlClient.Query(ctx, &result, variables); err != nil {
-		return nil, err
-	}
+	for {
+		var (
+			result    *ListMergeRequestsQuery
+			variables = map[string]any{
+				"project_id": graphql.ID(state.ProjectID(ctx)),
+				"state":      MergeRequestState(options.State),
+				"first":      options.First,
+				"after":      cursor,
+
+				// Filters that aren't set are sent as null, which GitLab ignores
+				"labels":          optionalList(options.Labels),
+				"author_username": optionalString(options.AuthorUsername),
+				"milestone_title": optionalString(options.MilestoneTitle),
+				"search":          optionalString(options.Search),
+			}
+		)
+
+		if err := graphqlClient.Query(ctx, &result, variables); err != nil {
+			return nil, err
+		}
+
+		if result == nil || result.Project == nil || result.Project.MergeRequests == nil {
+			return results, nil
+		}
 
-	results := []scm.ListMergeRequest{}
+		for _, mergeRequest := range result.Project.MergeRequests.Nodes {
+			// If there are no DiffHeadSha; there are no commits on the MR; so don't process it
+			if mergeRequest.DiffHeadSha == nil {
+				continue
+			}
 
-	for _, mergeRequest := range result.Project.MergeRequests.Nodes {
-		// If there are no DiffHeadSha; there are no commits on the MR; so don't process it
-		if mergeRequest.DiffHeadSha == nil {
-			continue
+			results = append(results, scm.ListMergeRequest{
+				ID:  mergeRequest.ID,
+				SHA: *mergeRequest.DiffHeadSha,
+			})
 		}
 
-		results = append(results, scm.ListMergeRequest{
-			ID:  mergeRequest.ID,
-			SHA: *mergeRequest.DiffHeadSha,
-		})
+		pageInfo := result.Project.MergeRequests.PageInfo
+		if pageInfo == nil || !pageInfo.HasNextPage || pageInfo.EndCursor == nil {
+			return results, nil
+		}
+
+		cursor = pageInfo.EndCursor
+	}
+}
+
+// optionalString returns nil for an empty [value], so the GraphQL variable is sent as null
+func optionalString(value string) *string {
+	if len(value) == 0 {
+		return nil
+	}
+
+	return &value
+}
+
+// optionalList returns nil for an empty [values], so the GraphQL variable is sent as null
+func optionalList(values []string) *[]string {
+	if len(values) == 0 {
+		return nil
 	}
 
-	return results, nil
+	return &values
 }
@@ -0,0 +1,145 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRequestClient_List_Filters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		options       scm.ListMergeRequestsOptions
+		wantVariables map[string]any
+	}{
+		{
+			name:    "no filters",
+			options: scm.ListMergeRequestsOptions{State: "opened", First: 100},
+			wantVariables: map[string]any{
+				"project_id":      "jippi/scm-engine",
+				"state":           "opened",
+				"first":           float64(100),
+				"labels":          nil,
+				"author_username": nil,
+				"milestone_title": nil,
+				"search":          nil,
+				"after":           nil,
+			},
+		},
+		{
+			name: "all filters",
+			options: scm.ListMergeRequestsOptions{
+				State:          "opened",
+				First:          100,
+				Labels:         []string{"stale", "backend"},
+				AuthorUsername: "alice",
+				MilestoneTitle: "v1.2",
+				Search:         "migration",
+			},
+			wantVariables: map[string]any{
+				"project_id":      "jippi/scm-engine",
+				"state":           "opened",
+				"first":           float64(100),
+				"labels":          []any{"stale", "backend"},
+				"author_username": "alice",
+				"milestone_title": "v1.2",
+				"search":          "migration",
+				"after":           nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var request struct {
+				Query     string         `json:"query"`
+				Variables map[string]any `json:"variables"`
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/api/graphql", r.URL.Path)
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "1", "diffHeadSha": "abc123"}, {"iid": "2", "diffHeadSha": null}]}}}}`))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			mergeRequests, err := client.MergeRequests().List(ctx, &tt.options)
+			require.NoError(t, err)
+			require.Equal(t, []scm.ListMergeRequest{{ID: "1", SHA: "abc123"}}, mergeRequests)
+
+			require.Contains(t, request.Query, "mergeRequests(state: $state, first: $first, labels: $labels, authorUsername: $author_username, milestoneTitle: $milestone_title, search: $search, after: $after)")
+			require.Contains(t, request.Query, "$labels:[String!]")
+			require.Contains(t, request.Query, "$author_username:String")
+			require.Equal(t, tt.wantVariables, request.Variables)
+		})
+	}
+}
+
+func TestMergeRequestClient_List_Pagination(t *testing.T) {
+	t.Parallel()
+
+	pages := map[string]string{
+		"":       `{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "1", "diffHeadSha": "abc"}, {"iid": "2", "diffHeadSha": "def"}], "pageInfo": {"hasNextPage": true, "endCursor": "page-2"}}}}}`,
+		"page-2": `{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "3", "diffHeadSha": null}, {"iid": "4", "diffHeadSha": "ghi"}], "pageInfo": {"hasNextPage": true, "endCursor": "page-3"}}}}}`,
+		"page-3": `{"data": {"project": {"mergeRequests": {"nodes": [{"iid": "5", "diffHeadSha": "jkl"}], "pageInfo": {"hasNextPage": false, "endCursor": "page-4"}}}}}`,
+	}
+
+	var cursors []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Variables struct {
+				After *string `json:"after"`
+			} `json:"variables"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		cursor := ""
+		if request.Variables.After != nil {
+			cursor = *request.Variables.After
+		}
+
+		cursors = append(cursors, cursor)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(pages[cursor]))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	mergeRequests, err := client.MergeRequests().List(ctx, &scm.ListMergeRequestsOptions{State: "opened", First: 2})
+	require.NoError(t, err)
+
+	// Every page is read, following the cursor of the previous page
+	require.Equal(t, []string{"", "page-2", "page-3"}, cursors)
+	require.Equal(t, []scm.ListMergeRequest{{ID: "1", SHA: "abc"}, {ID: "2", SHA: "def"}, {ID: "4", SHA: "ghi"}, {ID: "5", SHA: "jkl"}}, mergeRequests)
+}
@@ -0,0 +1,145 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// mergeTrainActiveStatuses are the merge train car statuses of a Merge Request that is still on the train
+//
+// See: https://docs.gitlab.com/ee/api/merge_trains.html
+var mergeTrainActiveStatuses = []string{"idle", "stale", "fresh"}
+
+// mergeTrainIneligibleReasons explains the 'detailed_merge_status' values that prevent adding a Merge Request to the merge train
+//
+// See: https://docs.gitlab.com/ee/api/merge_requests.html#merge-status
+var mergeTrainIneligibleReasons = map[string]string{
+	"blocked_status":           "the Merge Request is blocked by another Merge Request",
+	"checking":                 "GitLab is still checking if the Merge Request can be merged",
+	"ci_must_pass":             "the pipeline must succeed first",
+	"ci_still_running":         "the pipeline is still running",
+	"conflict":                 "the Merge Request has conflicts",
+	"discussions_not_resolved": "all discussions must be resolved first",
+	"draft_status":             "the Merge Request is a draft",
+	"external_status_checks":   "the external status checks must pass first",
+	"jira_association_missing": "the title or description must reference a Jira issue",
+	"need_rebase":              "the Merge Request must be rebased",
+	"not_approved":             "the Merge Request must be approved first",
+	"not_open":                 "the Merge Request is not open",
+	"requested_changes":        "a reviewer requested changes",
+	"unchecked":                "GitLab has not checked if the Merge Request can be merged yet",
+}
+
+// addToMergeTrain adds the Merge Request to the merge train of its target branch, unless it's already on it.
+//
+// Returns [scm.ErrSkipAction] with the reason if the Merge Request isn't eligible (e.g. missing approvals or a failed pipeline)
+func (c *Client) addToMergeTrain(ctx context.Context, step scm.ActionStep) error {
+	whenPipelineSucceeds, err := step.OptionalBool("when_pipeline_succeeds", true)
+	if err != nil {
+		return err
+	}
+
+	onTrain, err := c.isOnMergeTrain(ctx)
+	if err != nil {
+		return err
+	}
+
+	if onTrain {
+		slogctx.Debug(ctx, "Merge Request is already on the merge train")
+
+		return nil
+	}
+
+	mergeRequest, _, err := c.wrapped.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not read the Merge Request merge status: %w", err)
+	}
+
+	if reason, eligible := mergeTrainEligibility(mergeRequest.DetailedMergeStatus, whenPipelineSucceeds); !eligible {
+		return fmt.Errorf("%w: the Merge Request can't be added to the merge train: %s", scm.ErrSkipAction, reason)
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Adding MR to the merge train", slog.Bool("when_pipeline_succeeds", whenPipelineSucceeds))
+
+		return nil
+	}
+
+	// Pin the merge to the evaluated commit, so we never merge commits that wasn't evaluated
+	_, _, err = c.wrapped.MergeTrains.AddMergeRequestToMergeTrain(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), &go_gitlab.AddMergeRequestToMergeTrainOptions{
+		WhenPipelineSucceeds: scm.Ptr(whenPipelineSucceeds),
+		SHA:                  scm.Ptr(state.CommitSHA(ctx)),
+	}, go_gitlab.WithContext(ctx))
+
+	return err
+}
+
+// removeFromMergeTrain removes the Merge Request from the merge train, if it's on it
+func (c *Client) removeFromMergeTrain(ctx context.Context) error {
+	onTrain, err := c.isOnMergeTrain(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !onTrain {
+		slogctx.Debug(ctx, "Merge Request is not on the merge train")
+
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Removing MR from the merge train")
+
+		return nil
+	}
+
+	// GitLab has no dedicated API for leaving the merge train; canceling the auto-merge removes it
+	_, _, err = c.wrapped.MergeRequests.CancelMergeWhenPipelineSucceeds(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), go_gitlab.WithContext(ctx))
+
+	return err
+}
+
+func (c *Client) isOnMergeTrain(ctx context.Context) (bool, error) {
+	car, response, err := c.wrapped.MergeTrains.GetMergeRequestOnAMergeTrain(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), go_gitlab.WithContext(ctx))
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("could not read the merge train status: %w", err)
+	}
+
+	if car == nil {
+		return false, errors.New("could not read the merge train status: empty response")
+	}
+
+	return slices.Contains(mergeTrainActiveStatuses, car.Status), nil
+}
+
+// mergeTrainEligibility returns if a Merge Request with the [status] 'detailed_merge_status' can be added to the
+// merge train, and the reason if it can't
+func mergeTrainEligibility(status string, whenPipelineSucceeds bool) (string, bool) {
+	switch {
+	case status == "mergeable":
+		return "", true
+
+	// The Merge Request joins the train once the running pipeline succeeds
+	case status == "ci_still_running" && whenPipelineSucceeds:
+		return "", true
+	}
+
+	if reason, ok := mergeTrainIneligibleReasons[status]; ok {
+		return reason, false
+	}
+
+	return fmt.Sprintf("unexpected merge status %q", status), false
+}
@@ -0,0 +1,155 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_MergeTrain(t *testing.T) {
+	t.Parallel()
+
+	const (
+		mergeTrainPath   = "/api/v4/projects/jippi/scm-engine/merge_trains/merge_requests/1"
+		mergeRequestPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1"
+	)
+
+	tests := []struct {
+		name         string
+		action       string
+		dryRun       bool
+		trainStatus  string // empty if the Merge Request is not on the merge train
+		mergeStatus  string
+		wantSkip     string
+		wantRequests []string
+	}{
+		{
+			name:         "eligible Merge Request is added",
+			action:       "add_to_merge_train",
+			mergeStatus:  "mergeable",
+			wantRequests: []string{"GET " + mergeTrainPath, "GET " + mergeRequestPath, "POST " + mergeTrainPath + ` {"when_pipeline_succeeds":true,"sha":"abc123"}`},
+		},
+		{
+			name:         "Merge Request with a running pipeline is added",
+			action:       "add_to_merge_train",
+			mergeStatus:  "ci_still_running",
+			wantRequests: []string{"GET " + mergeTrainPath, "GET " + mergeRequestPath, "POST " + mergeTrainPath + ` {"when_pipeline_succeeds":true,"sha":"abc123"}`},
+		},
+		{
+			name:         "Merge Request already on the merge train is not re-added",
+			action:       "add_to_merge_train",
+			trainStatus:  "fresh",
+			wantRequests: []string{"GET " + mergeTrainPath},
+		},
+		{
+			name:         "merged Merge Request train car is not active",
+			action:       "add_to_merge_train",
+			trainStatus:  "merged",
+			mergeStatus:  "not_open",
+			wantSkip:     "action skipped: the Merge Request can't be added to the merge train: the Merge Request is not open",
+			wantRequests: []string{"GET " + mergeTrainPath, "GET " + mergeRequestPath},
+		},
+		{
+			name:         "unapproved Merge Request is skipped",
+			action:       "add_to_merge_train",
+			mergeStatus:  "not_approved",
+			wantSkip:     "action skipped: the Merge Request can't be added to the merge train: the Merge Request must be approved first",
+			wantRequests: []string{"GET " + mergeTrainPath, "GET " + mergeRequestPath},
+		},
+		{
+			name:         "dry run doesn't add the Merge Request",
+			action:       "add_to_merge_train",
+			dryRun:       true,
+			mergeStatus:  "mergeable",
+			wantRequests: []string{"GET " + mergeTrainPath, "GET " + mergeRequestPath},
+		},
+		{
+			name:         "Merge Request on the merge train is removed",
+			action:       "remove_from_merge_train",
+			trainStatus:  "idle",
+			wantRequests: []string{"GET " + mergeTrainPath, "POST " + mergeRequestPath + "/cancel_merge_when_pipeline_succeeds"},
+		},
+		{
+			name:         "Merge Request not on the merge train is left alone",
+			action:       "remove_from_merge_train",
+			wantRequests: []string{"GET " + mergeTrainPath},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.Path
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == mergeTrainPath:
+					if len(tt.trainStatus) == 0 {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"message": "404 Not found"}`))
+
+						return
+					}
+
+					w.Write([]byte(`{"id": 1, "status": "` + tt.trainStatus + `"}`))
+
+				case r.Method == http.MethodGet && r.URL.Path == mergeRequestPath:
+					w.Write([]byte(`{"iid": 1, "detailed_merge_status": "` + tt.mergeStatus + `"}`))
+
+				case r.Method == http.MethodPost && r.URL.Path == mergeTrainPath:
+					w.Write([]byte(`[]`))
+
+				default:
+					w.Write([]byte(`{}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, tt.dryRun)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			err = client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": tt.action})
+			if len(tt.wantSkip) > 0 {
+				require.ErrorIs(t, err, scm.ErrSkipAction)
+				require.EqualError(t, err, tt.wantSkip)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
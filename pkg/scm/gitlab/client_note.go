@@ -0,0 +1,78 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// The first GitLab version with internal notes
+//
+// See: https://docs.gitlab.com/ee/user/discussions/#add-an-internal-note
+const internalNotesMinimumMajorVersion = 15
+
+// createMergeRequestNoteOptions extends [go_gitlab.CreateMergeRequestNoteOptions] with the 'internal' flag
+type createMergeRequestNoteOptions struct {
+	Body     *string `json:"body,omitempty"     url:"body,omitempty"`
+	Internal *bool   `json:"internal,omitempty" url:"internal,omitempty"`
+}
+
+// comment creates a note on the Merge Request.
+//
+// Internal notes fall back to regular notes (with a warning) if the GitLab instance doesn't support them.
+func (c *Client) comment(ctx context.Context, message string, internal bool) error {
+	return c.createNote(ctx, scm.AppendCommentFooter(ctx, message), internal)
+}
+
+// createNote creates a note with [body] on the Merge Request, internal if the GitLab instance supports it
+func (c *Client) createNote(ctx context.Context, body string, internal bool) error {
+	if internal && !c.supportsInternalNotes(ctx) {
+		slogctx.Warn(ctx, "GitLab instance does not support internal notes, posting a regular comment instead")
+
+		internal = false
+	}
+
+	opt := &createMergeRequestNoteOptions{Body: &body}
+	if internal {
+		opt.Internal = &internal
+	}
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/notes", go_gitlab.PathEscape(state.ProjectID(ctx)), state.MergeRequestIDInt(ctx))
+
+	req, err := c.wrapped.NewRequest(http.MethodPost, endpoint, opt, []go_gitlab.RequestOptionFunc{go_gitlab.WithContext(ctx)})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.wrapped.Do(req, new(go_gitlab.Note))
+
+	return err
+}
+
+func (c *Client) supportsInternalNotes(ctx context.Context) bool {
+	version, _, err := c.wrapped.Version.GetVersion(go_gitlab.WithContext(ctx))
+	if err != nil {
+		slogctx.Warn(ctx, "Could not read GitLab version", slog.Any("error", err))
+
+		return false
+	}
+
+	major, _, _ := strings.Cut(version.Version, ".")
+
+	majorVersion, err := strconv.Atoi(major)
+	if err != nil {
+		slogctx.Warn(ctx, "Could not parse GitLab version", slog.String("version", version.Version))
+
+		return false
+	}
+
+	return majorVersion >= internalNotesMinimumMajorVersion
+}
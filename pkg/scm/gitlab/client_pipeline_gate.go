@@ -0,0 +1,188 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+const (
+	pipelineGatePending = "pending"
+	pipelineGateSuccess = "success"
+	pipelineGateFailed  = "failed"
+
+	// Backoff between polls of the head pipeline status
+	pipelineGateMinBackoff = 5 * time.Second
+	pipelineGateMaxBackoff = time.Minute
+)
+
+// waitForPipeline gates an action on the head pipeline as configured by the step 'wait_for_pipeline' option.
+//
+// Returns nil if the action may run, [scm.ErrSkipAction] if the pipeline failed, and [scm.DeferredError] if
+// the pipeline is still running and the 'requeue' strategy is used
+func (c *Client) waitForPipeline(ctx context.Context, step scm.ActionStep) error {
+	strategy, err := step.OptionalString("wait_for_pipeline", "")
+	if err != nil {
+		return err
+	}
+
+	switch strategy {
+	case "":
+		return nil
+
+	case "requeue":
+		return c.waitForPipelineRequeue(ctx, step)
+
+	case "poll":
+		return c.waitForPipelinePoll(ctx, step)
+
+	default:
+		return fmt.Errorf("step field 'wait_for_pipeline' must be either 'requeue' or 'poll', got %q", strategy)
+	}
+}
+
+func (c *Client) waitForPipelineRequeue(ctx context.Context, step scm.ActionStep) error {
+	delay, err := optionalDuration(step, "wait_for_pipeline_delay", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	maxDeferrals, err := step.OptionalInt("wait_for_pipeline_max_deferrals", 30)
+	if err != nil {
+		return err
+	}
+
+	status, err := c.pipelineGateStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status != pipelineGatePending {
+		return pipelineGateOutcome(status)
+	}
+
+	return &scm.DeferredError{
+		Reason:       "the head pipeline has not finished yet",
+		Delay:        delay,
+		MaxDeferrals: maxDeferrals,
+	}
+}
+
+func (c *Client) waitForPipelinePoll(ctx context.Context, step scm.ActionStep) error {
+	timeout, err := optionalDuration(step, "wait_for_pipeline_timeout", 10*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	backoff := pipelineGateMinBackoff
+
+	for {
+		status, err := c.pipelineGateStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if status != pipelineGatePending {
+			return pipelineGateOutcome(status)
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the head pipeline to finish", timeout)
+		}
+
+		slogctx.Info(ctx, "Waiting for the head pipeline to finish", slog.Duration("backoff", backoff))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, pipelineGateMaxBackoff)
+	}
+}
+
+// pipelineGateStatus combines the latest status of every job for the pinned commit into
+// one of [pipelineGatePending], [pipelineGateSuccess] or [pipelineGateFailed].
+//
+// The scm-engine commit status is ignored, since it's always 'running' while we evaluate. A commit without
+// any other status is pending, since webhooks for a push usually arrive before CI created the pipeline.
+func (c *Client) pipelineGateStatus(ctx context.Context) (string, error) {
+	options := &go_gitlab.GetCommitStatusesOptions{
+		ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)},
+	}
+
+	var (
+		result     = pipelineGateSuccess
+		jobs       int
+		statusName = scm.StatusMessagesFromContext(ctx).Name(ctx)
+	)
+
+	for {
+		statuses, resp, err := c.wrapped.Commits.GetCommitStatuses(state.ProjectID(ctx), state.CommitSHA(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return "", fmt.Errorf("could not read the head pipeline status: %w", err)
+		}
+
+		for _, status := range statuses {
+			if status.Name == statusName {
+				continue
+			}
+
+			jobs++
+
+			switch status.Status {
+			case "success", "skipped", "manual":
+				// Finished, or not blocking the pipeline from finishing
+
+			case "failed", "canceled":
+				if !status.AllowFailure {
+					return pipelineGateFailed, nil
+				}
+
+			default:
+				result = pipelineGatePending
+			}
+		}
+
+		if resp.NextPage == 0 {
+			if jobs == 0 {
+				return pipelineGatePending, nil
+			}
+
+			return result, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+func pipelineGateOutcome(status string) error {
+	if status == pipelineGateFailed {
+		return fmt.Errorf("%w: the head pipeline failed", scm.ErrSkipAction)
+	}
+
+	return nil
+}
+
+func optionalDuration(step scm.ActionStep, name string, defaultValue time.Duration) (time.Duration, error) {
+	value, err := step.OptionalString(name, "")
+	if err != nil || len(value) == 0 {
+		return defaultValue, err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("step field '%s' must be a duration (example: '5m'): %w", name, err)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,136 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_WaitForPipeline(t *testing.T) {
+	t.Parallel()
+
+	const (
+		statusesPath = "/api/v4/projects/jippi/scm-engine/repository/commits/abc123/statuses"
+		approvePath  = "/api/v4/projects/jippi/scm-engine/merge_requests/1/approve"
+	)
+
+	tests := []struct {
+		name         string
+		statuses     string
+		wantDeferred bool
+		wantSkip     string
+		wantApproved bool
+	}{
+		{
+			name:         "commit without any statuses is pending",
+			statuses:     `[]`,
+			wantDeferred: true,
+		},
+		{
+			name:         "commit with only the scm-engine status is pending",
+			statuses:     `[{"name": "scm-engine", "status": "running"}]`,
+			wantDeferred: true,
+		},
+		{
+			name:         "running job is pending",
+			statuses:     `[{"name": "scm-engine", "status": "running"}, {"name": "build", "status": "success"}, {"name": "test", "status": "running"}]`,
+			wantDeferred: true,
+		},
+		{
+			name:         "successful pipeline runs the action",
+			statuses:     `[{"name": "scm-engine", "status": "running"}, {"name": "build", "status": "success"}, {"name": "deploy", "status": "manual"}]`,
+			wantApproved: true,
+		},
+		{
+			name:         "failed job that is allowed to fail runs the action",
+			statuses:     `[{"name": "build", "status": "success"}, {"name": "lint", "status": "failed", "allow_failure": true}]`,
+			wantApproved: true,
+		},
+		{
+			name:     "failed job skips the action",
+			statuses: `[{"name": "build", "status": "failed"}, {"name": "test", "status": "running"}]`,
+			wantSkip: "action skipped: the head pipeline failed",
+		},
+		{
+			name:     "canceled job skips the action",
+			statuses: `[{"name": "build", "status": "canceled"}]`,
+			wantSkip: "action skipped: the head pipeline failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				approved bool
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Path {
+				case statusesPath:
+					w.Write([]byte(tt.statuses))
+
+				case approvePath:
+					mu.Lock()
+					approved = true
+					mu.Unlock()
+
+					w.Write([]byte(`{}`))
+
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, false)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			step := config.ActionStep{"action": "approve", "wait_for_pipeline": "requeue", "wait_for_pipeline_delay": "2m", "wait_for_pipeline_max_deferrals": 5}
+
+			err = client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, step)
+
+			switch {
+			case tt.wantDeferred:
+				var deferred *scm.DeferredError
+				require.ErrorAs(t, err, &deferred)
+				require.Equal(t, "the head pipeline has not finished yet", deferred.Reason)
+				require.Equal(t, 5, deferred.MaxDeferrals)
+				require.Equal(t, "2m0s", deferred.Delay.String())
+
+			case len(tt.wantSkip) > 0:
+				require.ErrorIs(t, err, scm.ErrSkipAction)
+				require.EqualError(t, err, tt.wantSkip)
+
+			default:
+				require.NoError(t, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Equal(t, tt.wantApproved, approved)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// pipelineVariableNamePattern is the format GitLab allows for CI/CD variable names
+var pipelineVariableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// setPipelineVariables passes the step 'variables' to the pipelines of the Merge Request
+func (c *Client) setPipelineVariables(ctx context.Context, step scm.ActionStep) error {
+	variables, err := step.RequiredStringMap("variables")
+	if err != nil {
+		return err
+	}
+
+	if err := validatePipelineVariableNames(slices.Collect(maps.Keys(variables))); err != nil {
+		return err
+	}
+
+	return c.updatePipelineVariables(ctx, variables, nil)
+}
+
+// clearPipelineVariables stops passing the step 'variables' (a list of names) to the pipelines of the Merge Request
+func (c *Client) clearPipelineVariables(ctx context.Context, step scm.ActionStep) error {
+	names, err := step.OptionalStringSlice("variables")
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return errors.New("Required 'step' key 'variables' is missing")
+	}
+
+	if err := validatePipelineVariableNames(names); err != nil {
+		return err
+	}
+
+	return c.updatePipelineVariables(ctx, nil, names)
+}
+
+func validatePipelineVariableNames(names []string) error {
+	for _, name := range names {
+		if !pipelineVariableNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid pipeline variable name %q; only letters, digits and '_' are allowed", name)
+		}
+	}
+
+	return nil
+}
+
+// updatePipelineVariables creates a pipeline for the source branch of the Merge Request, with the variables of its
+// head pipeline updated with [set] and without [unset], unless the head pipeline already has exactly those variables.
+//
+// GitLab has no Merge Request level variables, so the variables are carried from pipeline to pipeline instead.
+// Only the variable names are logged, since the values may be sensitive.
+//
+// Returns [scm.ErrSkipAction] for Merge Requests from forks, and projects where the pipeline can't be created
+// (e.g. CI/CD is disabled, or there is no CI/CD configuration file)
+func (c *Client) updatePipelineVariables(ctx context.Context, set map[string]string, unset []string) error {
+	mergeRequest, _, err := c.wrapped.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not read the Merge Request: %w", err)
+	}
+
+	// The source branch of a fork lives in another project, so its pipelines can't be created here
+	if mergeRequest.SourceProjectID != mergeRequest.TargetProjectID {
+		return fmt.Errorf("%w: pipeline variables can't be set for Merge Requests from forks", scm.ErrSkipAction)
+	}
+
+	current := map[string]string{}
+
+	if mergeRequest.HeadPipeline != nil {
+		variables, _, err := c.wrapped.Pipelines.GetPipelineVariables(state.ProjectID(ctx), mergeRequest.HeadPipeline.ID, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("could not read the head pipeline variables: %w", err)
+		}
+
+		for _, variable := range variables {
+			current[variable.Key] = variable.Value
+		}
+	}
+
+	wanted := maps.Clone(current)
+	maps.Copy(wanted, set)
+
+	for _, name := range unset {
+		delete(wanted, name)
+	}
+
+	if maps.Equal(current, wanted) {
+		slogctx.Debug(ctx, "Head pipeline already has the pipeline variables")
+
+		return nil
+	}
+
+	names := slices.Sorted(maps.Keys(wanted))
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Creating pipeline with variables", slog.String("ref", mergeRequest.SourceBranch), slog.Any("variables", names))
+
+		return nil
+	}
+
+	variables := make([]*go_gitlab.PipelineVariableOptions, 0, len(names))
+	for _, name := range names {
+		variables = append(variables, &go_gitlab.PipelineVariableOptions{Key: scm.Ptr(name), Value: scm.Ptr(wanted[name])})
+	}
+
+	_, response, err := c.wrapped.Pipelines.CreatePipeline(state.ProjectID(ctx), &go_gitlab.CreatePipelineOptions{
+		Ref:       scm.Ptr(mergeRequest.SourceBranch),
+		Variables: &variables,
+	}, go_gitlab.WithContext(ctx))
+	if err != nil {
+		if response != nil && (response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusForbidden) {
+			return fmt.Errorf("%w: pipelines can't be created for the Merge Request: %w", scm.ErrSkipAction, err)
+		}
+
+		return fmt.Errorf("could not create pipeline with variables: %w", err)
+	}
+
+	slogctx.Info(ctx, "Created pipeline with variables", slog.String("ref", mergeRequest.SourceBranch), slog.Any("variables", names))
+
+	return nil
+}
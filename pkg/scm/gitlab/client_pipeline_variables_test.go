@@ -0,0 +1,188 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_PipelineVariables(t *testing.T) {
+	t.Parallel()
+
+	const (
+		mergeRequestPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1"
+		variablesPath    = "/api/v4/projects/jippi/scm-engine/pipelines/10/variables"
+		pipelinePath     = "/api/v4/projects/jippi/scm-engine/pipeline"
+
+		sameProject = `{"iid": 1, "source_branch": "feature", "source_project_id": 1, "target_project_id": 1, "head_pipeline": {"id": 10}}`
+	)
+
+	tests := []struct {
+		name         string
+		step         config.ActionStep
+		dryRun       bool
+		mergeRequest string
+		variables    string
+		createStatus int
+		wantErr      string
+		wantSkip     string
+		wantRequests []string
+	}{
+		{
+			name:         "set variables",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			mergeRequest: sameProject,
+			variables:    `[]`,
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath, "POST " + pipelinePath + ` {"ref":"feature","variables":[{"key":"DEPLOY_ENV","value":"staging"}]}`},
+		},
+		{
+			name:         "set variables carries over the head pipeline variables",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			mergeRequest: sameProject,
+			variables:    `[{"key": "REGION", "value": "eu"}, {"key": "DEPLOY_ENV", "value": "production"}]`,
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath, "POST " + pipelinePath + ` {"ref":"feature","variables":[{"key":"DEPLOY_ENV","value":"staging"},{"key":"REGION","value":"eu"}]}`},
+		},
+		{
+			name:         "set variables the head pipeline already has",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			mergeRequest: sameProject,
+			variables:    `[{"key": "DEPLOY_ENV", "value": "staging"}]`,
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath},
+		},
+		{
+			name:         "set variables without a head pipeline",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			mergeRequest: `{"iid": 1, "source_branch": "feature", "source_project_id": 1, "target_project_id": 1}`,
+			wantRequests: []string{"GET " + mergeRequestPath, "POST " + pipelinePath + ` {"ref":"feature","variables":[{"key":"DEPLOY_ENV","value":"staging"}]}`},
+		},
+		{
+			name:         "dry run doesn't create a pipeline",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			dryRun:       true,
+			mergeRequest: sameProject,
+			variables:    `[]`,
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath},
+		},
+		{
+			name:         "clear variables",
+			step:         config.ActionStep{"action": "clear_pipeline_variables", "variables": []any{"DEPLOY_ENV"}},
+			mergeRequest: sameProject,
+			variables:    `[{"key": "REGION", "value": "eu"}, {"key": "DEPLOY_ENV", "value": "staging"}]`,
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath, "POST " + pipelinePath + ` {"ref":"feature","variables":[{"key":"REGION","value":"eu"}]}`},
+		},
+		{
+			name:         "clear variables the head pipeline doesn't have",
+			step:         config.ActionStep{"action": "clear_pipeline_variables", "variables": []any{"DEPLOY_ENV"}},
+			mergeRequest: sameProject,
+			variables:    `[{"key": "REGION", "value": "eu"}]`,
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath},
+		},
+		{
+			name:         "Merge Request from a fork is skipped",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			mergeRequest: `{"iid": 1, "source_branch": "feature", "source_project_id": 2, "target_project_id": 1, "head_pipeline": {"id": 10}}`,
+			wantSkip:     "action skipped: pipeline variables can't be set for Merge Requests from forks",
+			wantRequests: []string{"GET " + mergeRequestPath},
+		},
+		{
+			name:         "project without CI/CD configuration is skipped",
+			step:         config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY_ENV": "staging"}},
+			mergeRequest: sameProject,
+			variables:    `[]`,
+			createStatus: http.StatusBadRequest,
+			wantSkip:     "action skipped: pipelines can't be created for the Merge Request",
+			wantRequests: []string{"GET " + mergeRequestPath, "GET " + variablesPath, "POST " + pipelinePath + ` {"ref":"feature","variables":[{"key":"DEPLOY_ENV","value":"staging"}]}`},
+		},
+		{
+			name:    "invalid variable name",
+			step:    config.ActionStep{"action": "set_pipeline_variables", "variables": config.ActionStep{"DEPLOY-ENV": "staging"}},
+			wantErr: `invalid pipeline variable name "DEPLOY-ENV"; only letters, digits and '_' are allowed`,
+		},
+		{
+			name:    "clear without variables",
+			step:    config.ActionStep{"action": "clear_pipeline_variables"},
+			wantErr: "Required 'step' key 'variables' is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				request := r.Method + " " + r.URL.Path
+				if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+					request += " " + string(body)
+				}
+
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == mergeRequestPath:
+					w.Write([]byte(tt.mergeRequest))
+
+				case r.Method == http.MethodGet && r.URL.Path == variablesPath:
+					w.Write([]byte(tt.variables))
+
+				case r.Method == http.MethodPost && r.URL.Path == pipelinePath && tt.createStatus != 0:
+					w.WriteHeader(tt.createStatus)
+					w.Write([]byte(`{"message": {"base": ["Missing CI config file"]}}`))
+
+				case r.Method == http.MethodPost && r.URL.Path == pipelinePath:
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"id": 11}`))
+
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message": "404 Not found"}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithDryRun(ctx, tt.dryRun)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			err = client.ApplyStep(ctx, &gitlab.Context{}, &scm.UpdateMergeRequestOptions{}, tt.step)
+
+			switch {
+			case len(tt.wantErr) > 0:
+				require.EqualError(t, err, tt.wantErr)
+
+			case len(tt.wantSkip) > 0:
+				require.ErrorIs(t, err, scm.ErrSkipAction)
+				require.ErrorContains(t, err, tt.wantSkip)
+
+			default:
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
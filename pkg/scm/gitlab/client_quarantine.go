@@ -0,0 +1,214 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// QuarantineMarker is the hidden marker used to find (and update) the quarantine notice comment
+const QuarantineMarker = "<!-- scm-engine:quarantine -->"
+
+// quarantineDataPrefix starts the hidden comment holding the state needed to lift the quarantine as JSON
+const quarantineDataPrefix = "<!-- scm-engine:quarantine-data "
+
+const (
+	defaultQuarantineLabel   = "quarantine"
+	defaultQuarantineMessage = ":warning: This Merge Request has been quarantined and can't be merged until a maintainer has reviewed it."
+	defaultLiftedMessage     = "The quarantine of this Merge Request has been lifted."
+)
+
+// draftPrefixRegexp matches the title prefixes GitLab uses to mark a Merge Request as draft
+var draftPrefixRegexp = regexp.MustCompile(`(?i)^\s*(draft:|\[draft\]|\(draft\))\s*`)
+
+// quarantineRecord is what the Merge Request looked like before the quarantine, so it can be restored
+type quarantineRecord struct {
+	WasDraft    bool  `json:"was_draft"`
+	ReviewerIDs []int `json:"reviewer_ids"`
+}
+
+// quarantine restricts the Merge Request by marking it as draft, removing all reviewers, adding a warning label
+// and posting a notice. The title, reviewer and label changes are part of the regular Merge Request update,
+// so they are applied together; every sub-step is skipped if the Merge Request is already in that state
+func (c *Client) quarantine(ctx context.Context, evalContext scm.EvalContext, update *scm.UpdateMergeRequestOptions, step scm.ActionStep) error {
+	label, err := step.OptionalString("label", defaultQuarantineLabel)
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", defaultQuarantineMessage)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.MergeRequests().FindNote(ctx, QuarantineMarker)
+	if err != nil {
+		return err
+	}
+
+	// Keep the state recorded by an earlier quarantine, so unquarantine restores the original Merge Request
+	record, err := parseQuarantineRecord(existing)
+	if err != nil {
+		if !errors.Is(err, errNotQuarantined) {
+			return err
+		}
+
+		record = &quarantineRecord{WasDraft: evalContext.IsDraft()}
+	}
+
+	mergeRequest, _, err := c.wrapped.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not read Merge Request reviewers: %w", err)
+	}
+
+	if len(mergeRequest.Reviewers) > 0 {
+		for _, reviewer := range mergeRequest.Reviewers {
+			if !slices.Contains(record.ReviewerIDs, reviewer.ID) {
+				record.ReviewerIDs = append(record.ReviewerIDs, reviewer.ID)
+			}
+		}
+
+		update.ReviewerIDs = &[]int{}
+	}
+
+	// Use the raw MR title, unless something else already updated the title in the Update struct
+	title := evalContext.GetTitle()
+	if update.Title != nil {
+		title = *update.Title
+	}
+
+	if !evalContext.IsDraft() && !draftPrefixRegexp.MatchString(title) {
+		update.Title = scm.Ptr("Draft: " + title)
+	}
+
+	if !slices.Contains(evalContext.GetLabels(), label) {
+		labels := update.AddLabels
+		if labels == nil {
+			labels = &scm.LabelOptions{}
+		}
+
+		tmp := append(*labels, label)
+
+		update.AddLabels = &tmp
+	}
+
+	body := formatQuarantineNotice(message, record)
+	if body == existing {
+		return nil
+	}
+
+	slogctx.Info(ctx, "Quarantining Merge Request", slog.String("label", label), slog.Any("removed_reviewer_ids", record.ReviewerIDs))
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Posting quarantine notice", slog.String("message", message))
+
+		return nil
+	}
+
+	return c.MergeRequests().UpsertNote(ctx, QuarantineMarker, body)
+}
+
+// unquarantine reverses [Client.quarantine], using the state recorded in the quarantine notice.
+//
+// Nothing happens if the Merge Request isn't quarantined
+func (c *Client) unquarantine(ctx context.Context, evalContext scm.EvalContext, update *scm.UpdateMergeRequestOptions, step scm.ActionStep) error {
+	label, err := step.OptionalString("label", defaultQuarantineLabel)
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", defaultLiftedMessage)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.MergeRequests().FindNote(ctx, QuarantineMarker)
+	if err != nil {
+		return err
+	}
+
+	record, err := parseQuarantineRecord(existing)
+	if err != nil {
+		if errors.Is(err, errNotQuarantined) {
+			slogctx.Debug(ctx, "Merge Request is not quarantined")
+
+			return nil
+		}
+
+		return err
+	}
+
+	// Only remove the draft status if the quarantine added it
+	if !record.WasDraft {
+		title := evalContext.GetTitle()
+		if update.Title != nil {
+			title = *update.Title
+		}
+
+		if draftPrefixRegexp.MatchString(title) {
+			update.Title = scm.Ptr(draftPrefixRegexp.ReplaceAllString(title, ""))
+		}
+	}
+
+	if len(record.ReviewerIDs) > 0 {
+		update.ReviewerIDs = scm.Ptr(record.ReviewerIDs)
+	}
+
+	update.ForceRemoveLabels(evalContext.GetLabels(), label)
+
+	slogctx.Info(ctx, "Lifting Merge Request quarantine", slog.String("label", label), slog.Any("restored_reviewer_ids", record.ReviewerIDs))
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Updating quarantine notice", slog.String("message", message))
+
+		return nil
+	}
+
+	return c.MergeRequests().UpsertNote(ctx, QuarantineMarker, QuarantineMarker+"\n"+message)
+}
+
+// errNotQuarantined is returned when the quarantine notice doesn't hold any quarantine state
+var errNotQuarantined = errors.New("merge request is not quarantined")
+
+// formatQuarantineNotice renders the quarantine notice comment body, readable by [parseQuarantineRecord]
+func formatQuarantineNotice(message string, record *quarantineRecord) string {
+	if record.ReviewerIDs == nil {
+		record.ReviewerIDs = []int{}
+	}
+
+	data, _ := json.Marshal(record) //nolint:errchkjson
+
+	return QuarantineMarker + "\n" +
+		message + "\n\n" +
+		quarantineDataPrefix + string(data) + " -->"
+}
+
+// parseQuarantineRecord returns the state recorded by [formatQuarantineNotice] in the comment [body]
+func parseQuarantineRecord(body string) (*quarantineRecord, error) {
+	_, data, found := strings.Cut(body, quarantineDataPrefix)
+	if !found {
+		return nil, errNotQuarantined
+	}
+
+	data, _, found = strings.Cut(data, " -->")
+	if !found {
+		return nil, errors.New("the quarantine notice comment is malformed")
+	}
+
+	record := &quarantineRecord{}
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		return nil, fmt.Errorf("the quarantine notice comment is malformed: %w", err)
+	}
+
+	return record, nil
+}
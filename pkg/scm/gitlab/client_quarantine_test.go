@@ -0,0 +1,142 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Quarantine(t *testing.T) {
+	t.Parallel()
+
+	const (
+		notesPath        = "/api/v4/projects/jippi/scm-engine/merge_requests/1/notes"
+		mergeRequestPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1"
+	)
+
+	var (
+		mu        sync.Mutex
+		notes     []map[string]any
+		reviewers = []map[string]any{{"id": 3}, {"id": 4}}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == notesPath:
+			w.Write([]byte(mustJSON(t, notes)))
+
+		case r.Method == http.MethodPost && r.URL.Path == notesPath:
+			note := map[string]any{}
+			require.NoError(t, json.Unmarshal(body, &note))
+
+			note["id"] = len(notes) + 1
+			notes = append(notes, note)
+
+			w.Write([]byte(mustJSON(t, note)))
+
+		case r.Method == http.MethodPut && r.URL.Path == notesPath+"/1":
+			note := map[string]any{}
+			require.NoError(t, json.Unmarshal(body, &note))
+
+			note["id"] = 1
+			notes[0] = note
+
+			w.Write([]byte(mustJSON(t, note)))
+
+		case r.Method == http.MethodGet && r.URL.Path == mergeRequestPath:
+			w.Write([]byte(mustJSON(t, map[string]any{"iid": 1, "reviewers": reviewers})))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	mergeRequest := func(title string, draft bool, labels ...string) *gitlab.Context {
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Title: title, Draft: draft}}
+
+		for _, label := range labels {
+			evalContext.MergeRequest.Labels = append(evalContext.MergeRequest.Labels, gitlab.ContextLabel{Title: label})
+		}
+
+		return evalContext
+	}
+
+	apply := func(ctx context.Context, evalContext *gitlab.Context, action string) *scm.UpdateMergeRequestOptions {
+		update := &scm.UpdateMergeRequestOptions{}
+
+		err := client.ApplyStep(ctx, evalContext, update, config.ActionStep{"action": action})
+		require.NoError(t, err)
+
+		return update
+	}
+
+	// Dry run computes the whole bundle, but doesn't post the notice
+	update := apply(state.WithDryRun(ctx, true), mergeRequest("Fix bug", false), "quarantine")
+	require.Equal(t, "Draft: Fix bug", *update.Title)
+	require.Empty(t, notes)
+
+	ctx = state.WithDryRun(ctx, false)
+
+	// Quarantine marks the Merge Request as draft, removes reviewers, adds the label and posts the notice
+	update = apply(ctx, mergeRequest("Fix bug", false), "quarantine")
+	require.Equal(t, "Draft: Fix bug", *update.Title)
+	require.Equal(t, []int{}, *update.ReviewerIDs)
+	require.Equal(t, scm.LabelOptions{"quarantine"}, *update.AddLabels)
+	require.Len(t, notes, 1)
+	require.Contains(t, notes[0]["body"], gitlab.QuarantineMarker)
+
+	// Quarantining again is a no-op
+	reviewers = nil
+
+	update = apply(ctx, mergeRequest("Draft: Fix bug", true, "quarantine"), "quarantine")
+	require.True(t, update.IsEmpty())
+	require.Len(t, notes, 1)
+
+	// Unquarantine restores the original Merge Request and replaces the notice
+	update = apply(ctx, mergeRequest("Draft: Fix bug", true, "quarantine"), "unquarantine")
+	require.Equal(t, "Fix bug", *update.Title)
+	require.Equal(t, []int{3, 4}, *update.ReviewerIDs)
+	require.Equal(t, scm.LabelOptions{"quarantine"}, *update.RemoveLabels)
+	require.Len(t, notes, 1)
+	require.NotContains(t, notes[0]["body"], "quarantine-data")
+
+	// Unquarantining again is a no-op
+	update = apply(ctx, mergeRequest("Fix bug", false), "unquarantine")
+	require.True(t, update.IsEmpty())
+
+	// A Merge Request that was draft before the quarantine stays draft
+	update = apply(ctx, mergeRequest("Draft: WIP", true), "quarantine")
+	require.Nil(t, update.Title)
+
+	update = apply(ctx, mergeRequest("Draft: WIP", true, "quarantine"), "unquarantine")
+	require.Nil(t, update.Title)
+	require.Nil(t, update.ReviewerIDs)
+}
@@ -0,0 +1,153 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// RequireDescriptionTemplateMarker is the hidden marker used to find (and update) the description template comment
+const RequireDescriptionTemplateMarker = "<!-- scm-engine:require-description-template -->"
+
+const defaultDescriptionTemplateResolvedMessage = ":white_check_mark: Thanks, the Merge Request description now follows the template."
+
+// requireDescriptionTemplate checks that the Merge Request description has the required sections filled in and no
+// template placeholders left, setting a commit status and (optionally) commenting when it doesn't.
+//
+// With 'inject', missing sections are appended to the description (in the same update as the other actions),
+// keeping everything the author wrote. The status keeps failing until the injected sections are filled in
+func (c *Client) requireDescriptionTemplate(ctx context.Context, evalContext scm.EvalContext, update *scm.UpdateMergeRequestOptions, step scm.ActionStep) error {
+	sections, err := step.OptionalStringSlice("sections")
+	if err != nil {
+		return err
+	}
+
+	if len(sections) == 0 {
+		return errors.New("step field 'sections' is required")
+	}
+
+	placeholders, err := step.OptionalStringSlice("placeholders")
+	if err != nil {
+		return err
+	}
+
+	inject, err := step.OptionalBool("inject", false)
+	if err != nil {
+		return err
+	}
+
+	injectPlaceholder, err := step.OptionalString("inject_placeholder", scm.DefaultDescriptionSectionPlaceholder)
+	if err != nil {
+		return err
+	}
+
+	statusName, err := step.OptionalString("status_name", "scm-engine/description-template")
+	if err != nil {
+		return err
+	}
+
+	setStatus, err := step.OptionalBool("status", true)
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", "")
+	if err != nil {
+		return err
+	}
+
+	resolvedMessage, err := step.OptionalString("resolved_message", defaultDescriptionTemplateResolvedMessage)
+	if err != nil {
+		return err
+	}
+
+	// Use the raw MR description, unless something else already updated the description in the Update struct
+	description := evalContext.GetDescription()
+	if update.Description != nil {
+		description = *update.Description
+	}
+
+	result := scm.CheckDescriptionTemplate(description, sections, placeholders)
+
+	slogctx.Debug(ctx, "Checked Merge Request description template",
+		slog.Any("missing_sections", result.Missing),
+		slog.Any("empty_sections", result.Empty),
+		slog.Any("placeholders", result.Placeholders),
+	)
+
+	if inject && len(result.Missing) > 0 {
+		slogctx.Info(ctx, "Adding missing sections to the Merge Request description", slog.Any("sections", result.Missing))
+
+		body := scm.InjectDescriptionSections(description, result.Missing, injectPlaceholder)
+		update.Description = &body
+
+		result = scm.CheckDescriptionTemplate(body, sections, placeholders)
+	}
+
+	satisfied := result.Satisfied()
+
+	if setStatus {
+		if err := c.setPolicyStatus(ctx, statusName, satisfied, descriptionTemplateStatus(result)); err != nil {
+			return err
+		}
+	}
+
+	if len(message) == 0 {
+		return nil
+	}
+
+	existing, err := c.MergeRequests().FindNote(ctx, RequireDescriptionTemplateMarker)
+	if err != nil {
+		return err
+	}
+
+	body := RequireDescriptionTemplateMarker + "\n" + message
+	if satisfied {
+		// Only clear the comment if one was posted, never comment on Merge Requests that already follow the template
+		if len(existing) == 0 {
+			return nil
+		}
+
+		body = RequireDescriptionTemplateMarker + "\n" + resolvedMessage
+	}
+
+	if scm.AppendCommentFooter(ctx, body) == existing {
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Commenting on the description template", slog.Bool("satisfied", satisfied))
+
+		return nil
+	}
+
+	return c.MergeRequests().UpsertNote(ctx, RequireDescriptionTemplateMarker, body)
+}
+
+// descriptionTemplateStatus is the commit status description of the description template [result]
+func descriptionTemplateStatus(result scm.DescriptionTemplateResult) string {
+	if result.Satisfied() {
+		return "The Merge Request description follows the template"
+	}
+
+	var problems []string
+
+	if len(result.Missing) > 0 {
+		problems = append(problems, "missing sections: "+strings.Join(result.Missing, ", "))
+	}
+
+	if len(result.Empty) > 0 {
+		problems = append(problems, "empty sections: "+strings.Join(result.Empty, ", "))
+	}
+
+	if len(result.Placeholders) > 0 {
+		problems = append(problems, "placeholders left: "+strings.Join(result.Placeholders, ", "))
+	}
+
+	return "The Merge Request description doesn't follow the template (" + strings.Join(problems, "; ") + ")"
+}
@@ -0,0 +1,152 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_RequireDescriptionTemplate(t *testing.T) {
+	t.Parallel()
+
+	const (
+		statusPath = "/api/v4/projects/jippi/scm-engine/statuses/abc123"
+		notesPath  = "/api/v4/projects/jippi/scm-engine/merge_requests/1/notes"
+
+		// The marker, as encoded in the JSON request body
+		encodedMarker = `\u003c!-- scm-engine:require-description-template --\u003e`
+	)
+
+	tests := []struct {
+		name            string
+		description     string
+		inject          bool
+		notes           string
+		wantRequests    []string
+		wantDescription *string
+	}{
+		{
+			name:        "compliant passes",
+			description: "## Summary\n\nAdds a feature\n\n## Testing\n\nUnit tests",
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"success","name":"scm-engine/description-template","description":"The Merge Request description follows the template"}`,
+				`GET ` + notesPath + ` `,
+			},
+		},
+		{
+			name:        "missing section fails and comments",
+			description: "## Summary\n\nAdds a feature",
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"failed","name":"scm-engine/description-template","description":"The Merge Request description doesn't follow the template (missing sections: Testing)"}`,
+				`GET ` + notesPath + ` `,
+				`GET ` + notesPath + ` `,
+				`POST ` + notesPath + ` {"body":"` + encodedMarker + `\nPlease follow the template"}`,
+			},
+		},
+		{
+			name:        "missing section is injected, keeping the description",
+			description: "Adds a feature\n\n## Summary\n\nThe button was broken",
+			inject:      true,
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"failed","name":"scm-engine/description-template","description":"The Merge Request description doesn't follow the template (empty sections: Testing)"}`,
+				`GET ` + notesPath + ` `,
+				`GET ` + notesPath + ` `,
+				`POST ` + notesPath + ` {"body":"` + encodedMarker + `\nPlease follow the template"}`,
+			},
+			wantDescription: scm.Ptr("Adds a feature\n\n## Summary\n\nThe button was broken\n\n## Testing\n\n<!-- Please fill in this section -->"),
+		},
+		{
+			name:        "following the template clears the comment",
+			description: "## Summary\n\nAdds a feature\n\n## Testing\n\nUnit tests",
+			inject:      true,
+			notes:       `[{"id": 1, "body": "` + gitlab.RequireDescriptionTemplateMarker + `\nPlease follow the template"}]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"success","name":"scm-engine/description-template","description":"The Merge Request description follows the template"}`,
+				`GET ` + notesPath + ` `,
+				`GET ` + notesPath + ` `,
+				`PUT ` + notesPath + `/1 {"body":"` + encodedMarker + `\n:white_check_mark: Thanks, the Merge Request description now follows the template."}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+				w.Header().Set("Content-Type", "application/json")
+
+				if r.Method == http.MethodGet && r.URL.Path == notesPath {
+					w.Write([]byte(tt.notes))
+
+					return
+				}
+
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, false)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Description: scm.Ptr(tt.description)}}
+
+			update := &scm.UpdateMergeRequestOptions{}
+
+			err = client.ApplyStep(ctx, evalContext, update, config.ActionStep{
+				"action":   "require_description_template",
+				"sections": []any{"Summary", "Testing"},
+				"inject":   tt.inject,
+				"message":  "Please follow the template",
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequests, requests)
+			require.Equal(t, tt.wantDescription, update.Description)
+		})
+	}
+
+	t.Run("sections are required", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, "http://127.0.0.1:0")
+		ctx = state.WithToken(ctx, "token")
+
+		client, err := gitlab.NewClient(ctx)
+		require.NoError(t, err)
+
+		err = client.ApplyStep(ctx, &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": "require_description_template"})
+		require.EqualError(t, err, "step field 'sections' is required")
+	})
+}
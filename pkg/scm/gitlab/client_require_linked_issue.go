@@ -0,0 +1,116 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// RequireLinkedIssueMarker is the hidden marker used to find (and update) the missing issue comment
+const RequireLinkedIssueMarker = "<!-- scm-engine:require-linked-issue -->"
+
+const defaultLinkedIssueResolvedMessage = ":white_check_mark: Thanks, the Merge Request now links an issue."
+
+// requireLinkedIssue checks that the Merge Request description references an issue, setting a commit status
+// and (optionally) commenting when it doesn't. Both are cleared again once an issue is referenced, or a
+// label in 'bypass_labels' is added
+func (c *Client) requireLinkedIssue(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	statusName, err := step.OptionalString("status_name", "scm-engine/linked-issue")
+	if err != nil {
+		return err
+	}
+
+	setStatus, err := step.OptionalBool("status", true)
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", "")
+	if err != nil {
+		return err
+	}
+
+	resolvedMessage, err := step.OptionalString("resolved_message", defaultLinkedIssueResolvedMessage)
+	if err != nil {
+		return err
+	}
+
+	bypassLabels, err := step.OptionalStringSlice("bypass_labels")
+	if err != nil {
+		return err
+	}
+
+	pattern, err := step.OptionalString("pattern", "")
+	if err != nil {
+		return err
+	}
+
+	var re *regexp.Regexp
+
+	if len(pattern) > 0 {
+		if re, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("step field 'pattern' must be a valid regular expression: %w", err)
+		}
+	}
+
+	linked, description := scm.HasIssueReference(evalContext.GetDescription(), re), "The Merge Request links an issue"
+
+	if !linked {
+		for _, label := range evalContext.GetLabels() {
+			if slices.Contains(bypassLabels, label) {
+				linked, description = true, "The linked issue check is bypassed by the '"+label+"' label"
+
+				break
+			}
+		}
+	}
+
+	if !linked {
+		description = "The Merge Request description must reference an issue"
+	}
+
+	slogctx.Debug(ctx, "Checked Merge Request for a linked issue", slog.Bool("linked", linked))
+
+	if setStatus {
+		if err := c.setPolicyStatus(ctx, statusName, linked, description); err != nil {
+			return err
+		}
+	}
+
+	if len(message) == 0 {
+		return nil
+	}
+
+	existing, err := c.MergeRequests().FindNote(ctx, RequireLinkedIssueMarker)
+	if err != nil {
+		return err
+	}
+
+	body := RequireLinkedIssueMarker + "\n" + message
+	if linked {
+		// Only clear the comment if one was posted, never comment on Merge Requests that already link an issue
+		if len(existing) == 0 {
+			return nil
+		}
+
+		body = RequireLinkedIssueMarker + "\n" + resolvedMessage
+	}
+
+	if scm.AppendCommentFooter(ctx, body) == existing {
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Commenting on the missing linked issue", slog.Bool("linked", linked))
+
+		return nil
+	}
+
+	return c.MergeRequests().UpsertNote(ctx, RequireLinkedIssueMarker, body)
+}
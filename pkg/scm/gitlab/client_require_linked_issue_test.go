@@ -0,0 +1,141 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_RequireLinkedIssue(t *testing.T) {
+	t.Parallel()
+
+	const (
+		statusPath = "/api/v4/projects/jippi/scm-engine/statuses/abc123"
+		notesPath  = "/api/v4/projects/jippi/scm-engine/merge_requests/1/notes"
+
+		// The marker, as encoded in the JSON request body
+		encodedMarker = `\u003c!-- scm-engine:require-linked-issue --\u003e`
+	)
+
+	tests := []struct {
+		name         string
+		description  string
+		labels       []string
+		notes        string
+		wantRequests []string
+	}{
+		{
+			name:        "linked issue passes",
+			description: "Closes #42",
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"success","name":"scm-engine/linked-issue","description":"The Merge Request links an issue"}`,
+				`GET ` + notesPath + ` `,
+			},
+		},
+		{
+			name:        "issue URL passes",
+			description: "See https://gitlab.com/jippi/scm-engine/-/issues/42 for details",
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"success","name":"scm-engine/linked-issue","description":"The Merge Request links an issue"}`,
+				`GET ` + notesPath + ` `,
+			},
+		},
+		{
+			name:        "unlinked fails and comments",
+			description: "Fixes the thing\n\n```\n#42\n```",
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"failed","name":"scm-engine/linked-issue","description":"The Merge Request description must reference an issue"}`,
+				`GET ` + notesPath + ` `,
+				`GET ` + notesPath + ` `,
+				`POST ` + notesPath + ` {"body":"` + encodedMarker + `\nPlease link an issue"}`,
+			},
+		},
+		{
+			name:        "bypass label passes",
+			description: "Fixes a typo",
+			labels:      []string{"no-issue-needed"},
+			notes:       `[]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"success","name":"scm-engine/linked-issue","description":"The linked issue check is bypassed by the 'no-issue-needed' label"}`,
+				`GET ` + notesPath + ` `,
+			},
+		},
+		{
+			name:        "linking an issue clears the comment",
+			description: "Closes jippi/other#42",
+			notes:       `[{"id": 1, "body": "` + gitlab.RequireLinkedIssueMarker + `\nPlease link an issue"}]`,
+			wantRequests: []string{
+				`POST ` + statusPath + ` {"state":"success","name":"scm-engine/linked-issue","description":"The Merge Request links an issue"}`,
+				`GET ` + notesPath + ` `,
+				`GET ` + notesPath + ` `,
+				`PUT ` + notesPath + `/1 {"body":"` + encodedMarker + `\n:white_check_mark: Thanks, the Merge Request now links an issue."}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+				w.Header().Set("Content-Type", "application/json")
+
+				if r.Method == http.MethodGet && r.URL.Path == notesPath {
+					w.Write([]byte(tt.notes))
+
+					return
+				}
+
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, false)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Description: scm.Ptr(tt.description)}}
+			for _, label := range tt.labels {
+				evalContext.MergeRequest.Labels = append(evalContext.MergeRequest.Labels, gitlab.ContextLabel{Title: label})
+			}
+
+			err = client.ApplyStep(ctx, evalContext, &scm.UpdateMergeRequestOptions{}, config.ActionStep{
+				"action":        "require_linked_issue",
+				"bypass_labels": []any{"no-issue-needed"},
+				"message":       "Please link an issue",
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
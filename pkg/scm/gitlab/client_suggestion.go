@@ -0,0 +1,132 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// suggest posts a GitLab suggestion anchored to a line in the Merge Request diff
+//
+// See: https://docs.gitlab.com/ee/user/project/merge_requests/reviews/suggestions.html
+func (c *Client) suggest(ctx context.Context, evalContext scm.EvalContext, step scm.ActionStep) error {
+	file, err := step.RequiredString("file")
+	if err != nil {
+		return err
+	}
+
+	line, err := step.RequiredInt("line")
+	if err != nil {
+		return err
+	}
+
+	linesAbove, err := step.OptionalInt("lines_above", 0)
+	if err != nil {
+		return err
+	}
+
+	linesBelow, err := step.OptionalInt("lines_below", 0)
+	if err != nil {
+		return err
+	}
+
+	script, err := step.RequiredString("replacement")
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", "")
+	if err != nil {
+		return err
+	}
+
+	if line < 1 || linesAbove < 0 || linesBelow < 0 {
+		return errors.New("step field 'line' must be positive, and 'lines_above' and 'lines_below' must not be negative")
+	}
+
+	replacement, err := runStringScript(evalContext, script)
+	if err != nil {
+		return fmt.Errorf("could not evaluate 'replacement': %w", err)
+	}
+
+	ctx = slogctx.With(ctx, slog.String("file", file), slog.Int("line", line))
+
+	mergeRequest, _, err := c.wrapped.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), nil, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	diff, err := c.findMergeRequestDiff(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	if diff == nil {
+		slogctx.Info(ctx, "File was not changed in the Merge Request, skipping suggestion")
+
+		return nil
+	}
+
+	if !scm.DiffContainsNewLine(diff.Diff, line) {
+		return fmt.Errorf("line %d in file %q is not part of the Merge Request diff", line, file)
+	}
+
+	body := fmt.Sprintf("```suggestion:-%d+%d\n%s\n```", linesAbove, linesBelow, strings.TrimSuffix(replacement, "\n"))
+	if len(message) > 0 {
+		body = message + "\n\n" + body
+	}
+
+	body = scm.AppendCommentFooter(ctx, body)
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Suggesting change on MR", slog.String("body", body))
+
+		return nil
+	}
+
+	_, _, err = c.wrapped.Discussions.CreateMergeRequestDiscussion(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), &go_gitlab.CreateMergeRequestDiscussionOptions{
+		Body: scm.Ptr(body),
+		Position: &go_gitlab.PositionOptions{
+			PositionType: scm.Ptr("text"),
+			BaseSHA:      scm.Ptr(mergeRequest.DiffRefs.BaseSha),
+			HeadSHA:      scm.Ptr(mergeRequest.DiffRefs.HeadSha),
+			StartSHA:     scm.Ptr(mergeRequest.DiffRefs.StartSha),
+			OldPath:      scm.Ptr(diff.OldPath),
+			NewPath:      scm.Ptr(diff.NewPath),
+			NewLine:      scm.Ptr(line),
+		},
+	}, go_gitlab.WithContext(ctx))
+
+	return err
+}
+
+// findMergeRequestDiff returns the diff of the changed (and not deleted) [file] in the Merge Request, or nil if the file wasn't changed
+func (c *Client) findMergeRequestDiff(ctx context.Context, file string) (*go_gitlab.MergeRequestDiff, error) {
+	options := &go_gitlab.ListMergeRequestDiffsOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+
+	for {
+		diffs, resp, err := c.wrapped.MergeRequests.ListMergeRequestDiffs(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, candidate := range diffs {
+			if candidate.NewPath == file && !candidate.DeletedFile {
+				return candidate, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,91 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// setTargetBranch retargets the Merge Request to the branch from the 'branch' field (or 'script'), and posts a note
+// explaining the change. The new target branch is part of the regular Merge Request update; Merge Requests already
+// targeting the branch are left alone
+func (c *Client) setTargetBranch(ctx context.Context, evalContext scm.EvalContext, update *scm.UpdateMergeRequestOptions, step scm.ActionStep) error {
+	branch, err := step.OptionalString("branch", "")
+	if err != nil {
+		return err
+	}
+
+	script, err := step.OptionalString("script", "")
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", "")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(branch) > 0 && len(script) > 0:
+		return errors.New("step fields 'branch' and 'script' can't be used together")
+
+	case len(script) > 0:
+		branch, err = runStringScript(evalContext, script)
+		if err != nil {
+			return fmt.Errorf("could not evaluate 'script': %w", err)
+		}
+
+	case len(branch) == 0:
+		return errors.New("step field 'branch' (or 'script') is required")
+	}
+
+	branch = strings.TrimSpace(branch)
+	if len(branch) == 0 {
+		return errors.New("the target branch must not be empty")
+	}
+
+	// Use the current target branch, unless something else already retargeted the Merge Request in the Update struct
+	current := evalContext.GetTargetBranch()
+	if update.TargetBranch != nil {
+		current = *update.TargetBranch
+	}
+
+	if branch == current {
+		slogctx.Debug(ctx, "Merge Request already targets the branch", slog.String("target_branch", branch))
+
+		return nil
+	}
+
+	_, resp, err := c.wrapped.Branches.GetBranch(state.ProjectID(ctx), branch, go_gitlab.WithContext(ctx))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("the target branch %q does not exist", branch)
+		}
+
+		return fmt.Errorf("could not read the target branch %q: %w", branch, err)
+	}
+
+	slogctx.Info(ctx, "Changing Merge Request target branch", slog.String("target_branch", current), slog.String("new_target_branch", branch))
+
+	update.TargetBranch = &branch
+
+	if len(message) == 0 {
+		message = fmt.Sprintf("Changed the target branch from `%s` to `%s`.", current, branch)
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Commenting on the target branch change", slog.String("message", message))
+
+		return nil
+	}
+
+	return c.comment(ctx, message, false)
+}
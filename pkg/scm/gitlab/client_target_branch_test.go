@@ -0,0 +1,122 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_SetTargetBranch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		targetBranch     string
+		step             config.ActionStep
+		wantTargetBranch *string
+		wantRequests     []string
+		wantErr          string
+	}{
+		{
+			name:             "retargets the merge request",
+			targetBranch:     "master",
+			step:             config.ActionStep{"action": "set_target_branch", "branch": "main"},
+			wantTargetBranch: scm.Ptr("main"),
+			wantRequests: []string{
+				"GET /api/v4/projects/jippi/scm-engine/repository/branches/main ",
+				"POST /api/v4/projects/jippi/scm-engine/merge_requests/1/notes {\"body\":\"Changed the target branch from `master` to `main`.\"}",
+			},
+		},
+		{
+			name:             "the branch can be a script",
+			targetBranch:     "master",
+			step:             config.ActionStep{"action": "set_target_branch", "script": `"release/" + merge_request.title`, "message": "Retargeted"},
+			wantTargetBranch: scm.Ptr("release/1.0"),
+			wantRequests: []string{
+				"GET /api/v4/projects/jippi/scm-engine/repository/branches/release/1.0 ",
+				`POST /api/v4/projects/jippi/scm-engine/merge_requests/1/notes {"body":"Retargeted"}`,
+			},
+		},
+		{
+			name:         "already targeting the branch is a no-op",
+			targetBranch: "main",
+			step:         config.ActionStep{"action": "set_target_branch", "branch": "main"},
+		},
+		{
+			name:         "the branch must exist",
+			targetBranch: "master",
+			step:         config.ActionStep{"action": "set_target_branch", "branch": "missing"},
+			wantRequests: []string{"GET /api/v4/projects/jippi/scm-engine/repository/branches/missing "},
+			wantErr:      `the target branch "missing" does not exist`,
+		},
+		{
+			name:         "the branch is required",
+			targetBranch: "master",
+			step:         config.ActionStep{"action": "set_target_branch"},
+			wantErr:      "step field 'branch' (or 'script') is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+				w.Header().Set("Content-Type", "application/json")
+
+				if r.URL.Path == "/api/v4/projects/jippi/scm-engine/repository/branches/missing" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message": "404 Branch Not Found"}`))
+
+					return
+				}
+
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithDryRun(ctx, false)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Title: "1.0", TargetBranch: tt.targetBranch}}
+			update := &scm.UpdateMergeRequestOptions{}
+
+			err = client.ApplyStep(ctx, evalContext, update, tt.step)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantTargetBranch, update.TargetBranch)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
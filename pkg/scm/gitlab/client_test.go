@@ -0,0 +1,279 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+	ctx = state.WithUpdatePipeline(ctx, true, "")
+	ctx = state.WithReadOnly(ctx, true)
+
+	// The 'dry_run: false' configuration file setting must not disable read-only mode
+	ctx = state.WithDryRun(ctx, false)
+	require.True(t, state.IsDryRun(ctx))
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	// Changes guarded by the dry-run check are never sent
+	require.NoError(t, client.Start(ctx))
+	require.NoError(t, client.ApplyStep(ctx, nil, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": "approve"}))
+	require.NoError(t, client.ApplyStep(ctx, nil, &scm.UpdateMergeRequestOptions{}, config.ActionStep{"action": "comment", "message": "hello"}))
+	require.NoError(t, client.Stop(ctx, nil, false))
+
+	// Changes that bypass the dry-run check are refused by the HTTP transport
+	_, err = client.MergeRequests().Update(ctx, &scm.UpdateMergeRequestOptions{Title: scm.Ptr("changed")})
+	require.ErrorIs(t, err, scm.ErrReadOnly)
+
+	// Reading is still allowed
+	client.MergeRequests().GetRemoteConfig(ctx, ".scm-engine.yml", "HEAD") //nolint:errcheck
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, request := range requests {
+		require.Regexp(t, `^GET `, request)
+	}
+
+	require.NotEmpty(t, requests)
+}
+
+func TestClient_StatusName(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		statuses []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body struct {
+			State   string `json:"state"`
+			Context string `json:"context"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "/api/v4/projects/jippi/scm-engine/statuses/abc123", r.URL.Path)
+
+		statuses = append(statuses, body.Context+" "+body.State)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+	ctx = state.WithUpdatePipeline(ctx, true, "")
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	// The default name
+	require.NoError(t, client.Start(ctx))
+	require.NoError(t, client.Stop(ctx, nil, false))
+
+	// Each instance keys its status on its own name, for both the start and the stop
+	for _, name := range []string{"scm-engine/security", "scm-engine/{{ .Project }}"} {
+		messages, err := scm.NewStatusMessages(name, "", "", "", "")
+		require.NoError(t, err)
+
+		ctx := scm.WithStatusMessages(ctx, messages)
+
+		require.NoError(t, client.Start(ctx))
+		require.NoError(t, client.Stop(ctx, nil, false))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Equal(t, []string{
+		"scm-engine running",
+		"scm-engine success",
+		"scm-engine/security running",
+		"scm-engine/security success",
+		"scm-engine/jippi/scm-engine running",
+		"scm-engine/jippi/scm-engine success",
+	}, statuses)
+}
+
+func TestClient_APIPageSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		pageSize     *int
+		wantPerPage  string
+		wantRequests int
+	}{
+		{name: "defaults to the GitLab maximum", wantPerPage: "100", wantRequests: 1},
+		{name: "configured page size", pageSize: scm.Ptr(2), wantPerPage: "2", wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests int
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				requests++
+
+				require.Equal(t, "/api/v4/projects/jippi/scm-engine/merge_requests/1/resource_label_events", r.URL.Path)
+				require.Equal(t, tt.wantPerPage, r.URL.Query().Get("per_page"))
+
+				w.Header().Set("Content-Type", "application/json")
+
+				// Three label events, split into pages of 'per_page' items
+				if tt.wantPerPage == "2" && r.URL.Query().Get("page") != "2" {
+					w.Header().Set("X-Next-Page", "2")
+					w.Write([]byte(`[{"id": 1, "action": "add", "label": {"name": "a"}, "created_at": "2024-01-01T00:00:00Z"}, {"id": 2, "action": "add", "label": {"name": "b"}, "created_at": "2024-01-01T00:00:00Z"}]`))
+
+					return
+				}
+
+				if tt.wantPerPage == "2" {
+					w.Write([]byte(`[{"id": 3, "action": "add", "label": {"name": "c"}, "created_at": "2024-01-01T00:00:00Z"}]`))
+
+					return
+				}
+
+				w.Write([]byte(`[{"id": 1, "action": "add", "label": {"name": "a"}, "created_at": "2024-01-01T00:00:00Z"}, {"id": 2, "action": "add", "label": {"name": "b"}, "created_at": "2024-01-01T00:00:00Z"}, {"id": 3, "action": "add", "label": {"name": "c"}, "created_at": "2024-01-01T00:00:00Z"}]`))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			if tt.pageSize != nil {
+				ctx = state.WithAPIPageSize(ctx, *tt.pageSize)
+			}
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			events, err := client.MergeRequests().LabelEvents(ctx)
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+// stubTransport is a [http.RoundTripper] answering every request with an empty JSON object, recording the requests
+type stubTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.requests = append(t.requests, req)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+func TestClient_HTTPTransport(t *testing.T) {
+	t.Parallel()
+
+	transport := &stubTransport{}
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, "https://gitlab.example.com")
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithAPIHeaders(ctx, http.Header{"X-Caller-Id": []string{"platform-team"}})
+	ctx = state.WithUpdatePipeline(ctx, false, "")
+	ctx = state.WithHTTPTransport(ctx, transport)
+
+	client, err := gitlab.NewClient(ctx)
+	require.NoError(t, err)
+
+	// REST API requests of the client
+	client.MergeRequests().GetRemoteConfig(ctx, ".scm-engine.yml", "HEAD") //nolint:errcheck
+
+	// GraphQL requests of the client
+	client.FindMergeRequestsForPeriodicEvaluation(ctx, scm.MergeRequestListFilters{}) //nolint:errcheck
+
+	// REST API requests of the script functions
+	evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+	evalContext.SetContext(ctx)
+	evalContext.MergeRequest.ApprovalRules(evalContext.Context)
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	paths := []string{}
+
+	for _, req := range transport.requests {
+		require.Equal(t, "gitlab.example.com", req.URL.Host)
+		require.Equal(t, "platform-team", req.Header.Get("X-Caller-Id"))
+
+		paths = append(paths, req.URL.Path)
+	}
+
+	require.Contains(t, paths, "/api/v4/projects/jippi/scm-engine/repository/files/.scm-engine.yml/raw")
+	require.Contains(t, paths, "/api/graphql")
+	require.Contains(t, paths, "/api/v4/projects/jippi/scm-engine/merge_requests/1/approval_state")
+}
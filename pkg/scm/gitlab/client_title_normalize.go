@@ -0,0 +1,115 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// TitleNormalizeMarker is the hidden marker used to find (and update) the title format comment
+const TitleNormalizeMarker = "<!-- scm-engine:title-normalize -->"
+
+// titleNormalize enforces the Merge Request title format, either by rewriting the title using
+// the 'template' script, or by setting a failed commit status in 'validate' mode
+func (c *Client) titleNormalize(ctx context.Context, evalContext scm.EvalContext, update *scm.UpdateMergeRequestOptions, step scm.ActionStep) error {
+	pattern, err := step.RequiredString("pattern")
+	if err != nil {
+		return err
+	}
+
+	mode, err := step.OptionalString("mode", "rewrite")
+	if err != nil {
+		return err
+	}
+
+	template, err := step.OptionalString("template", "")
+	if err != nil {
+		return err
+	}
+
+	message, err := step.OptionalString("message", "")
+	if err != nil {
+		return err
+	}
+
+	statusName, err := step.OptionalString("status_name", "scm-engine/title")
+	if err != nil {
+		return err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("step field 'pattern' must be a valid regular expression: %w", err)
+	}
+
+	// Use the raw MR title, unless something else already updated the title in the Update struct
+	title := evalContext.GetTitle()
+	if update.Title != nil {
+		title = *update.Title
+	}
+
+	matched := re.MatchString(title)
+
+	switch mode {
+	case "rewrite":
+		if matched {
+			return nil
+		}
+
+		if len(template) == 0 {
+			return fmt.Errorf("step field 'template' is required when 'mode' is %q", mode)
+		}
+
+		normalized, err := runStringScript(evalContext, template)
+		if err != nil {
+			return fmt.Errorf("could not evaluate 'template': %w", err)
+		}
+
+		normalized = strings.TrimSpace(normalized)
+		if !re.MatchString(normalized) {
+			return fmt.Errorf("the normalized title %q does not match the 'pattern' %q", normalized, pattern)
+		}
+
+		slogctx.Info(ctx, "Normalizing Merge Request title", slog.String("title", title), slog.String("normalized_title", normalized))
+
+		update.Title = &normalized
+
+	case "validate":
+		if err := c.setTitleStatus(ctx, statusName, pattern, matched); err != nil {
+			return err
+		}
+
+		if matched {
+			return nil
+		}
+
+	default:
+		return fmt.Errorf("step field 'mode' must be either 'rewrite' or 'validate', got %q", mode)
+	}
+
+	if len(message) == 0 {
+		return nil
+	}
+
+	if state.IsDryRun(ctx) {
+		slogctx.Info(ctx, "(Dry Run) Commenting on the Merge Request title format", slog.String("message", message))
+
+		return nil
+	}
+
+	return c.MergeRequests().UpsertNote(ctx, TitleNormalizeMarker, TitleNormalizeMarker+"\n"+message)
+}
+
+func (c *Client) setTitleStatus(ctx context.Context, name, pattern string, matched bool) error {
+	if matched {
+		return c.setPolicyStatus(ctx, name, true, "The Merge Request title has the required format")
+	}
+
+	return c.setPolicyStatus(ctx, name, false, "The Merge Request title must match: "+pattern)
+}
@@ -0,0 +1,102 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyStep_TitleNormalize(t *testing.T) {
+	t.Parallel()
+
+	const pattern = `^(feat|fix|chore): .+`
+
+	tests := []struct {
+		name         string
+		title        string
+		mode         string
+		wantTitle    *string
+		wantRequests []string
+	}{
+		{
+			name:  "matching title is left alone",
+			title: "feat: add title_normalize action",
+			mode:  "rewrite",
+		},
+		{
+			name:      "non-matching title is rewritten",
+			title:     "add title_normalize action",
+			mode:      "rewrite",
+			wantTitle: scm.Ptr("chore: add title_normalize action"),
+		},
+		{
+			name:         "non-matching title fails validation",
+			title:        "add title_normalize action",
+			mode:         "validate",
+			wantRequests: []string{`POST /api/v4/projects/jippi/scm-engine/statuses/abc123 {"state":"failed","name":"scm-engine/title","description":"The Merge Request title must match: ^(feat|fix|chore): .+"}`},
+		},
+		{
+			name:         "matching title passes validation",
+			title:        "fix: title_normalize action",
+			mode:         "validate",
+			wantRequests: []string{`POST /api/v4/projects/jippi/scm-engine/statuses/abc123 {"state":"success","name":"scm-engine/title","description":"The Merge Request title has the required format"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithCommitSHA(ctx, "abc123")
+			ctx = state.WithDryRun(ctx, false)
+
+			client, err := gitlab.NewClient(ctx)
+			require.NoError(t, err)
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Title: tt.title}}
+			update := &scm.UpdateMergeRequestOptions{}
+
+			err = client.ApplyStep(ctx, evalContext, update, config.ActionStep{
+				"action":   "title_normalize",
+				"pattern":  pattern,
+				"mode":     tt.mode,
+				"template": `"chore: " + merge_request.title`,
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, tt.wantTitle, update.Title)
+			require.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// ErrTokenScopesUnavailable is returned by [Client.TokenScopes] when GitLab can't tell the scopes of the API token,
+// for example before GitLab 15.5, or for tokens that aren't access tokens
+var ErrTokenScopesUnavailable = errors.New("the API token scopes are not available")
+
+// Version returns the version of the GitLab instance, confirming it's reachable with the API token
+func (c *Client) Version(ctx context.Context) (string, error) {
+	version, _, err := c.wrapped.Version.GetVersion(go_gitlab.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("could not read the GitLab version: %w", err)
+	}
+
+	return version.Version, nil
+}
+
+// TokenScopes returns the scopes of the API token (example: 'api' or 'read_api')
+func (c *Client) TokenScopes(ctx context.Context) ([]string, error) {
+	token, resp, err := c.wrapped.PersonalAccessTokens.GetSinglePersonalAccessToken(go_gitlab.WithContext(ctx))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %w", ErrTokenScopesUnavailable, err)
+		}
+
+		return nil, fmt.Errorf("could not read the API token: %w", err)
+	}
+
+	if !token.Active || token.Revoked {
+		return nil, errors.New("the API token is expired or revoked")
+	}
+
+	return token.Scopes, nil
+}
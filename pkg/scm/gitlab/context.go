@@ -2,26 +2,20 @@ package gitlab
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
 	"github.com/hasura/go-graphql-client"
+	"github.com/jippi/scm-engine/pkg/config"
 	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/jippi/scm-engine/pkg/state"
 	slogctx "github.com/veqryn/slog-context"
-	"golang.org/x/oauth2"
 )
 
 var _ scm.EvalContext = (*Context)(nil)
 
 func NewContext(ctx context.Context, baseURL, token string) (*Context, error) {
-	httpClient := oauth2.NewClient(
-		ctx,
-		oauth2.StaticTokenSource(
-			&oauth2.Token{
-				AccessToken: token,
-			},
-		),
-	)
+	httpClient := newGraphQLHTTPClient(ctx, token)
 
 	client := graphql.NewClient(baseURL+"/api/graphql", httpClient)
 
@@ -33,14 +27,27 @@ func NewContext(ctx context.Context, baseURL, token string) (*Context, error) {
 		}
 	)
 
+	var missingData map[string]any
+
 	if err := client.Query(ctx, &evalContext, variables); err != nil {
-		return nil, err
+		// Optional data (like approvals) may fail to load, e.g. due to missing permissions, without failing the evaluation.
+		// Scripts using the missing data fail instead, see [Context.UnavailableData]
+		missing, ok := missingOptionalData(err)
+		if !ok || !state.AllowPartialData(ctx) || evalContext == nil {
+			return nil, err
+		}
+
+		slogctx.Warn(ctx, "Some optional Merge Request data could not be loaded, scripts using it will be skipped", slog.Any("error", err))
+
+		missingData = missing
 	}
 
 	if evalContext.Project == nil || evalContext.Project.MergeRequest == nil {
 		return nil, nil //nolint:nilnil
 	}
 
+	evalContext.MissingData = missingData
+
 	// Initialize null-able types
 	evalContext.ActionGroups = make(map[string]any)
 
@@ -82,6 +89,11 @@ func NewContext(ctx context.Context, baseURL, token string) (*Context, error) {
 
 	evalContext.MergeRequest.ResponseLastCommits = nil
 
+	// A Merge Request from a deleted fork has no source project
+	evalContext.MergeRequest.IsFork = evalContext.MergeRequest.SourceProjectID == nil || *evalContext.MergeRequest.SourceProjectID != evalContext.MergeRequest.TargetProjectID
+
+	evalContext.setCoverage(ctx, client)
+
 	if evalContext.MergeRequest.FirstCommit != nil && evalContext.MergeRequest.LastCommit != nil {
 		tmp := evalContext.MergeRequest.FirstCommit.CommittedDate.Sub(*evalContext.MergeRequest.LastCommit.CommittedDate).Round(time.Hour)
 		evalContext.MergeRequest.TimeBetweenFirstAndLastCommit = &tmp
@@ -90,16 +102,43 @@ func NewContext(ctx context.Context, baseURL, token string) (*Context, error) {
 	return evalContext, nil
 }
 
+func (c *Context) IsDraft() bool {
+	return c.MergeRequest.Draft
+}
+
 func (c *Context) IsValid() bool {
 	return c != nil
 }
 
+func (c *Context) SetVars(vars map[string]any) {
+	c.Vars = vars
+}
+
 func (c *Context) SetWebhookEvent(in any) {
 	c.WebhookEvent = in
+	c.WebhookAction = webhookAction(in)
+}
+
+// webhookAction returns the 'object_attributes.action' of a 'merge_request' webhook event payload
+func webhookAction(in any) string {
+	payload, ok := in.(map[string]any)
+	if !ok || payload["event_type"] != "merge_request" {
+		return ""
+	}
+
+	attributes, ok := payload["object_attributes"].(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	action, _ := attributes["action"].(string)
+
+	return action
 }
 
 func (c *Context) SetContext(ctx context.Context) {
-	c.Context = ctx
+	c.TriggerEvent = state.TriggerEvent(ctx)
+	c.Context = withLoaders(ctx)
 }
 
 func (c *Context) GetDescription() string {
@@ -110,7 +149,32 @@ func (c *Context) GetDescription() string {
 	return *c.MergeRequest.Description
 }
 
+func (c *Context) GetTitle() string {
+	return c.MergeRequest.Title
+}
+
+func (c *Context) GetTargetBranch() string {
+	return c.MergeRequest.TargetBranch
+}
+
+func (c *Context) GetLabels() []string {
+	labels := make([]string, 0, len(c.MergeRequest.Labels))
+
+	for _, label := range c.MergeRequest.Labels {
+		labels = append(labels, label.Title)
+	}
+
+	return labels
+}
+
 func (c *Context) CanUseConfigurationFileFromChangeRequest(ctx context.Context) bool {
+	// If the Merge Request is from a fork, anyone with access to the fork could change the configuration
+	if c.MergeRequest.IsFork {
+		slogctx.Warn(ctx, "The Merge Request is from a fork; will use the scm-engine config from HEAD of the target project instead")
+
+		return false
+	}
+
 	// If the Merge Request has diverged from HEAD we can't trust the configuration
 	if c.MergeRequest.DivergedFromTargetBranch {
 		slogctx.Warn(ctx, "The Merge Request branch has diverged from HEAD; will use the scm-engine config from HEAD instead")
@@ -125,6 +189,14 @@ func (c *Context) CanUseConfigurationFileFromChangeRequest(ctx context.Context)
 		return false
 	}
 
+	// The Merge Request changes the configuration file itself, which could weaken the policy it's evaluated with
+	policy, _ := config.ParseConfigChangePolicy(state.ConfigChangePolicy(ctx))
+	if policy != config.ConfigChangeAllow && len(c.MergeRequest.findModifiedFiles(state.ConfigFilePath(ctx))) > 0 && !policy.TrustChangedConfig(c.MergeRequest.Approved) {
+		slogctx.Warn(ctx, "The Merge Request changes the scm-engine config; will use the scm-engine config from HEAD instead", slog.String("config_change_policy", string(policy)))
+
+		return false
+	}
+
 	slogctx.Info(ctx, "The Merge Request branch is up to date with HEAD; will use the scm-engine config from the branch")
 
 	return true
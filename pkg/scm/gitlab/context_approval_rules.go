@@ -0,0 +1,149 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// ApprovalRule is an approval rule of the Merge Request, with who still needs to approve, as exposed to scripts
+type ApprovalRule struct {
+	// The name of the rule (example: 'Security' or a CODEOWNERS pattern)
+	Name string `expr:"name"`
+	// The type of the rule (example: 'regular', 'any_approver', 'code_owner' or 'report_approver')
+	Type string `expr:"type"`
+	// The number of approvals the rule requires, from any of the eligible approvers
+	ApprovalsRequired int `expr:"approvals_required"`
+	// The number of approvals the rule still needs
+	ApprovalsLeft int `expr:"approvals_left"`
+	// The usernames of the users that may approve, including the members of the groups of the rule
+	EligibleApprovers []string `expr:"eligible_approvers"`
+	// The full paths of the groups whose members may approve (example: 'acme/security')
+	Groups []string `expr:"groups"`
+	// The usernames of the users that approved
+	ApprovedBy []string `expr:"approved_by"`
+	// The usernames of the eligible approvers that didn't approve yet, empty once the rule is approved
+	PendingApprovers []string `expr:"pending_approvers"`
+	// Whether the rule got the approvals it requires
+	Approved bool `expr:"approved"`
+}
+
+func loadApprovalState(ctx context.Context) (*go_gitlab.MergeRequestApprovalState, error) {
+	loaders, err := loadersFromContext(ctx, "merge request approvals")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.approvalState.get(ctx, fetchApprovalState)
+}
+
+func fetchApprovalState(ctx context.Context) (*go_gitlab.MergeRequestApprovalState, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request approval state")
+
+	approvalState, _, err := client.MergeRequestApprovals.GetApprovalState(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), go_gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not load the merge request approval state: %w", err)
+	}
+
+	return approvalState, nil
+}
+
+// newApprovalRule converts an approval [rule] of the approval state.
+//
+// Rules are 'any-of-N': any [ApprovalRule.ApprovalsRequired] of the eligible approvers can approve, so every eligible
+// approver that didn't approve yet is pending until the rule is approved, and none are afterwards
+func newApprovalRule(rule *go_gitlab.MergeRequestApprovalRule) ApprovalRule {
+	approvedBy := usernames(rule.ApprovedBy)
+
+	groups := []string{}
+	for _, group := range rule.Groups {
+		groups = append(groups, group.FullPath)
+	}
+
+	pending := []string{}
+
+	if !rule.Approved {
+		for _, username := range usernames(rule.EligibleApprovers) {
+			if !slices.Contains(approvedBy, username) {
+				pending = append(pending, username)
+			}
+		}
+	}
+
+	return ApprovalRule{
+		Name:              rule.Name,
+		Type:              rule.RuleType,
+		ApprovalsRequired: rule.ApprovalsRequired,
+		ApprovalsLeft:     max(rule.ApprovalsRequired-len(approvedBy), 0),
+		EligibleApprovers: usernames(rule.EligibleApprovers),
+		Groups:            groups,
+		ApprovedBy:        approvedBy,
+		PendingApprovers:  pending,
+		Approved:          rule.Approved,
+	}
+}
+
+// approval_rules
+func (e ContextMergeRequest) ApprovalRules(ctx context.Context) []ApprovalRule {
+	approvalState, err := loadApprovalState(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	rules := []ApprovalRule{}
+	for _, rule := range approvalState.Rules {
+		rules = append(rules, newApprovalRule(rule))
+	}
+
+	return rules
+}
+
+// pending_approvers
+func (e ContextMergeRequest) PendingApprovers(ctx context.Context) []string {
+	val := []string{}
+
+	for _, rule := range e.ApprovalRules(ctx) {
+		val = append(val, rule.PendingApprovers...)
+	}
+
+	slices.Sort(val)
+	val = slices.Compact(val)
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.pending_approvers"),
+		slog.Any("function_result", val),
+	)
+
+	return val
+}
+
+// pending_approval_groups
+func (e ContextMergeRequest) PendingApprovalGroups(ctx context.Context) []string {
+	val := []string{}
+
+	for _, rule := range e.ApprovalRules(ctx) {
+		if !rule.Approved {
+			val = append(val, rule.Groups...)
+		}
+	}
+
+	slices.Sort(val)
+	val = slices.Compact(val)
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.pending_approval_groups"),
+		slog.Any("function_result", val),
+	)
+
+	return val
+}
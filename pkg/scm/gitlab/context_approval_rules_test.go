@@ -0,0 +1,98 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_PendingApprovers(t *testing.T) {
+	t.Parallel()
+
+	const (
+		// Security (any 1 of the group) is approved by alice, Backend (any 2 of 3) only has one of its two approvals
+		partial = `{"rules": [
+			{"name": "All Members", "rule_type": "any_approver", "approvals_required": 1, "eligible_approvers": [], "approved_by": [], "approved": false},
+			{"name": "Security", "rule_type": "regular", "approvals_required": 1,
+				"groups": [{"full_path": "acme/security"}],
+				"eligible_approvers": [{"username": "alice"}, {"username": "erin"}], "approved_by": [{"username": "alice"}], "approved": true},
+			{"name": "Backend", "rule_type": "regular", "approvals_required": 2,
+				"groups": [{"full_path": "acme/backend"}], "users": [{"username": "dave"}],
+				"eligible_approvers": [{"username": "bob"}, {"username": "carol"}, {"username": "dave"}], "approved_by": [{"username": "bob"}], "approved": false},
+			{"name": "*.go", "rule_type": "code_owner", "section": "Backend", "approvals_required": 1,
+				"eligible_approvers": [{"username": "carol"}, {"username": "frank"}], "approved_by": [], "approved": false}
+		]}`
+
+		approved = `{"rules": [
+			{"name": "Backend", "rule_type": "regular", "approvals_required": 1,
+				"groups": [{"full_path": "acme/backend"}],
+				"eligible_approvers": [{"username": "bob"}, {"username": "carol"}], "approved_by": [{"username": "carol"}], "approved": true}
+		]}`
+	)
+
+	tests := []struct {
+		name          string
+		approvalState string
+		script        string
+		want          any
+	}{
+		{name: "partial approvals", approvalState: partial, script: `merge_request.pending_approvers()`, want: []string{"carol", "dave", "frank"}},
+		{name: "partial approvals groups", approvalState: partial, script: `merge_request.pending_approval_groups()`, want: []string{"acme/backend"}},
+		{name: "partial approvals rule mentions", approvalState: partial, script: `merge_request.pending_approvers() | map("@" + #) | join(" ")`, want: "@carol @dave @frank"},
+		{name: "partial approvals unapproved rules", approvalState: partial, script: `merge_request.approval_rules() | filter(!.approved) | map(.name)`, want: []any{"All Members", "Backend", "*.go"}},
+		{name: "partial approvals approvals left", approvalState: partial, script: `merge_request.approval_rules()[2].approvals_left`, want: 1},
+		{name: "partial approvals rule pending approvers", approvalState: partial, script: `merge_request.approval_rules()[2].pending_approvers`, want: []string{"carol", "dave"}},
+		{name: "approved rule has no pending approvers", approvalState: partial, script: `merge_request.approval_rules()[1].pending_approvers`, want: []string{}},
+		{name: "approved", approvalState: approved, script: `merge_request.pending_approvers()`, want: []string{}},
+		{name: "approved groups", approvalState: approved, script: `len(merge_request.pending_approval_groups())`, want: 0},
+		{name: "code owners share the approval state", approvalState: partial, script: `merge_request.code_owners_satisfied() || len(merge_request.pending_approvers()) == 0`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := &atomic.Int32{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1/approval_state", r.URL.EscapedPath())
+
+				requests.Add(1)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.approvalState))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+			evalContext.SetContext(ctx)
+
+			program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+			require.NoError(t, err)
+
+			for range 2 {
+				output, err := expr.Run(program, evalContext)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, output)
+			}
+
+			// The approval state is only loaded once per evaluation
+			require.Equal(t, int32(1), requests.Load())
+		})
+	}
+}
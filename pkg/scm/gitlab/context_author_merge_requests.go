@@ -0,0 +1,138 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// Scopes of [ContextMergeRequest.AuthorOpenMrCount]
+const (
+	AuthorOpenMRScopeProject = "project"
+	AuthorOpenMRScopeGroup   = "group"
+)
+
+// maxAuthorOpenMRCount caps the open Merge Requests counted by [ContextMergeRequest.AuthorOpenMrCount],
+// so prolific authors only cost a single page of results
+const maxAuthorOpenMRCount = 100
+
+// loadAuthorOpenMRCount returns the (cached) result of [fetchAuthorOpenMRCount] for [username] in [scope]
+func loadAuthorOpenMRCount(ctx context.Context, username, scope string) (int, error) {
+	loaders, err := loadersFromContext(ctx, "author Merge Requests")
+	if err != nil {
+		return 0, err
+	}
+
+	return loaders.authorOpenMRCount.get(ctx, scope+":"+strings.ToLower(username), func(ctx context.Context) (int, error) {
+		return fetchAuthorOpenMRCount(ctx, username, scope)
+	})
+}
+
+// fetchAuthorOpenMRCount returns the number of open Merge Requests of [username] in the project (or its group),
+// not counting the evaluated Merge Request, and capped at [maxAuthorOpenMRCount]
+func fetchAuthorOpenMRCount(ctx context.Context, username, scope string) (int, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	slogctx.Debug(ctx, "Loading open Merge Requests of the author", slog.String("username", username), slog.String("scope", scope))
+
+	listOptions := go_gitlab.ListOptions{PerPage: maxAuthorOpenMRCount}
+
+	var mergeRequests []*go_gitlab.MergeRequest
+
+	switch scope {
+	case AuthorOpenMRScopeProject:
+		mergeRequests, _, err = client.MergeRequests.ListProjectMergeRequests(state.ProjectID(ctx), &go_gitlab.ListProjectMergeRequestsOptions{
+			ListOptions:    listOptions,
+			State:          go_gitlab.Ptr("opened"),
+			AuthorUsername: go_gitlab.Ptr(username),
+		}, go_gitlab.WithContext(ctx))
+
+	case AuthorOpenMRScopeGroup:
+		group := path.Dir(state.ProjectID(ctx))
+		if group == "." {
+			return 0, fmt.Errorf("project %q is not in a group", state.ProjectID(ctx))
+		}
+
+		mergeRequests, _, err = client.MergeRequests.ListGroupMergeRequests(group, &go_gitlab.ListGroupMergeRequestsOptions{
+			ListOptions:    listOptions,
+			State:          go_gitlab.Ptr("opened"),
+			AuthorUsername: go_gitlab.Ptr(username),
+		}, go_gitlab.WithContext(ctx))
+
+	default:
+		return 0, fmt.Errorf("unknown scope %q, use %q or %q", scope, AuthorOpenMRScopeProject, AuthorOpenMRScopeGroup)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not load open Merge Requests of %q: %w", username, err)
+	}
+
+	count := 0
+
+	for _, mergeRequest := range mergeRequests {
+		if isEvaluatedMergeRequest(ctx, mergeRequest, scope) {
+			continue
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// isEvaluatedMergeRequest returns whether [mergeRequest] is the Merge Request being evaluated.
+//
+// Group results include other projects, where the same Merge Request ID is another Merge Request
+func isEvaluatedMergeRequest(ctx context.Context, mergeRequest *go_gitlab.MergeRequest, scope string) bool {
+	if mergeRequest.IID != state.MergeRequestIDInt(ctx) {
+		return false
+	}
+
+	if scope == AuthorOpenMRScopeProject {
+		return true
+	}
+
+	return mergeRequest.References != nil && strings.EqualFold(mergeRequest.References.Full, state.ProjectID(ctx)+"!"+state.MergeRequestID(ctx))
+}
+
+// author_open_mr_count
+func (e ContextMergeRequest) AuthorOpenMrCount(ctx context.Context, scope ...string) int {
+	if e.Author == nil {
+		panic(fmt.Errorf("%w: the Merge Request author is not available", state.ErrMissingContext))
+	}
+
+	if len(scope) > 1 {
+		panic(fmt.Errorf("author_open_mr_count() takes at most one scope, got %d", len(scope)))
+	}
+
+	selected := AuthorOpenMRScopeProject
+	if len(scope) == 1 {
+		selected = scope[0]
+	}
+
+	val, err := loadAuthorOpenMRCount(ctx, e.Author.Username, selected)
+	if err != nil {
+		// Treat the author as having no other open Merge Requests when the lookup fails (for example, when rate limited),
+		// so a failed lookup never throttles a contributor or fails the whole evaluation
+		slogctx.Warn(ctx, "Could not count the open Merge Requests of the author, assuming there are none", slog.Any("error", err))
+
+		val = 0
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.author_open_mr_count"),
+		withInput(selected),
+		slog.Int("function_result", val),
+	)
+
+	return val
+}
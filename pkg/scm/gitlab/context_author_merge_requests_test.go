@@ -0,0 +1,106 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_AuthorOpenMrCount(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		author := r.URL.Query().Get("author_username")
+
+		mu.Lock()
+		requests[r.URL.Path+" "+author]++
+		mu.Unlock()
+
+		require.Equal(t, "opened", r.URL.Query().Get("state"))
+		require.Equal(t, "100", r.URL.Query().Get("per_page"))
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path + " " + author {
+		case "/api/v4/projects/jippi/scm-engine/merge_requests busy":
+			// The evaluated Merge Request itself isn't counted
+			w.Write([]byte(`[{"iid": 1}, {"iid": 2}, {"iid": 3}]`))
+
+		case "/api/v4/groups/jippi/merge_requests busy":
+			// Another project in the group has a Merge Request with the same ID
+			w.Write([]byte(`[
+				{"iid": 1, "references": {"full": "jippi/scm-engine!1"}},
+				{"iid": 1, "references": {"full": "jippi/other!1"}},
+				{"iid": 2, "references": {"full": "jippi/scm-engine!2"}}
+			]`))
+
+		case "/api/v4/projects/jippi/scm-engine/merge_requests rate-limited":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "403 Forbidden"}`))
+
+		default:
+			w.Write([]byte(`[{"iid": 1}]`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	tests := []struct {
+		author string
+		script string
+		want   int
+	}{
+		{author: "idle", script: `merge_request.author_open_mr_count()`, want: 0},
+		{author: "busy", script: `merge_request.author_open_mr_count()`, want: 2},
+		{author: "busy", script: `merge_request.author_open_mr_count("project")`, want: 2},
+		{author: "busy", script: `merge_request.author_open_mr_count("group")`, want: 2},
+		// A failed lookup treats the author as having no open Merge Requests
+		{author: "rate-limited", script: `merge_request.author_open_mr_count()`, want: 0},
+		// An unknown scope is logged and counted as zero, without calling the API
+		{author: "busy", script: `merge_request.author_open_mr_count("instance")`, want: 0},
+	}
+
+	// A single evaluation, so the default scope and "project" share the cached count
+	evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Author: &gitlab.ContextUser{}}}
+	evalContext.SetContext(ctx)
+
+	for _, tt := range tests {
+		evalContext.MergeRequest.Author.Username = tt.author
+
+		program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		// Run each script twice, the second run must be served from the cache
+		for range 2 {
+			output, err := expr.Run(program, evalContext)
+			require.NoError(t, err, tt.script)
+			require.Equal(t, tt.want, output, tt.author+": "+tt.script)
+		}
+	}
+
+	require.Equal(t, map[string]int{
+		"/api/v4/projects/jippi/scm-engine/merge_requests idle":         1,
+		"/api/v4/projects/jippi/scm-engine/merge_requests busy":         1,
+		"/api/v4/groups/jippi/merge_requests busy":                      1,
+		"/api/v4/projects/jippi/scm-engine/merge_requests rate-limited": 1,
+	}, requests)
+}
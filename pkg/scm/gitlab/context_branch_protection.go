@@ -0,0 +1,166 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// BranchProtection is the protection of a branch, as exposed to scripts
+type BranchProtection struct {
+	// Name of the branch
+	Branch string `expr:"branch"`
+	// Whether the branch exists
+	Exists bool `expr:"exists"`
+	// Whether the branch is protected, directly or by a wildcard rule
+	Protected bool `expr:"protected"`
+	// The protected branch rules matching the branch
+	Rules []BranchProtectionRule `expr:"rules"`
+}
+
+// BranchProtectionRule is a protected branch rule, as exposed to scripts
+type BranchProtectionRule struct {
+	// Name of the protected branch, or the wildcard pattern (example: 'release/*')
+	Name string `expr:"name"`
+	// The access levels allowed to push (example: 40 for Maintainers, 0 for no one)
+	PushAccessLevels []int `expr:"push_access_levels"`
+	// The access levels allowed to merge (example: 30 for Developers)
+	MergeAccessLevels []int `expr:"merge_access_levels"`
+	// Whether force pushes are allowed
+	AllowForcePush bool `expr:"allow_force_push"`
+	// Whether Code Owner approval is required for changes
+	CodeOwnerApprovalRequired bool `expr:"code_owner_approval_required"`
+}
+
+// loadBranchProtection returns the (cached) protection of [branch] in the project
+func loadBranchProtection(ctx context.Context, branch string) (*BranchProtection, error) {
+	loaders, err := loadersFromContext(ctx, "branch protection")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.branchProtection.get(ctx, branch, func(ctx context.Context) (*BranchProtection, error) {
+		return fetchBranchProtection(ctx, branch)
+	})
+}
+
+// fetchBranchProtection reads whether [branch] is protected, and the protected branch rules matching it.
+//
+// A branch that doesn't exist is reported as unprotected, instead of failing the evaluation
+func fetchBranchProtection(ctx context.Context, branch string) (*BranchProtection, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading branch protection", slog.String("branch", branch))
+
+	protection := &BranchProtection{Branch: branch, Rules: []BranchProtectionRule{}}
+
+	result, resp, err := client.Branches.GetBranch(state.ProjectID(ctx), branch, go_gitlab.WithContext(ctx))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			slogctx.Debug(ctx, "Branch does not exist", slog.String("branch", branch))
+
+			return protection, nil
+		}
+
+		return nil, fmt.Errorf("could not load branch %q: %w", branch, err)
+	}
+
+	protection.Exists = true
+	protection.Protected = result.Protected
+
+	if !protection.Protected {
+		return protection, nil
+	}
+
+	options := &go_gitlab.ListProtectedBranchesOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+
+	for {
+		page, resp, err := client.ProtectedBranches.ListProtectedBranches(state.ProjectID(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load protected branches: %w", err)
+		}
+
+		for _, rule := range page {
+			if !matchesProtectedBranch(rule.Name, branch) {
+				continue
+			}
+
+			protection.Rules = append(protection.Rules, BranchProtectionRule{
+				Name:                      rule.Name,
+				PushAccessLevels:          accessLevels(rule.PushAccessLevels),
+				MergeAccessLevels:         accessLevels(rule.MergeAccessLevels),
+				AllowForcePush:            rule.AllowForcePush,
+				CodeOwnerApprovalRequired: rule.CodeOwnerApprovalRequired,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	return protection, nil
+}
+
+// matchesProtectedBranch reports whether the protected branch [name] applies to [branch].
+// A '*' in the name matches any characters (including '/'), like GitLab wildcard protected branches
+func matchesProtectedBranch(name, branch string) bool {
+	if !strings.Contains(name, "*") {
+		return name == branch
+	}
+
+	parts := strings.Split(name, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(branch)
+}
+
+func accessLevels(descriptions []*go_gitlab.BranchAccessDescription) []int {
+	levels := []int{}
+	for _, description := range descriptions {
+		levels = append(levels, int(description.AccessLevel))
+	}
+
+	return levels
+}
+
+// target_branch_protection
+func (e ContextMergeRequest) TargetBranchProtection(ctx context.Context) *BranchProtection {
+	if len(e.TargetBranch) == 0 {
+		panic(errors.New("the Merge Request target branch is not available"))
+	}
+
+	protection, err := loadBranchProtection(ctx, e.TargetBranch)
+	if err != nil {
+		panic(err)
+	}
+
+	return protection
+}
+
+// target_branch_is_protected
+func (e ContextMergeRequest) TargetBranchIsProtected(ctx context.Context) bool {
+	val := e.TargetBranchProtection(ctx).Protected
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.target_branch_is_protected"),
+		withResult(val),
+	)
+
+	return val
+}
@@ -0,0 +1,105 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_TargetBranchProtection(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.EscapedPath()]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/jippi%2Fscm-engine/repository/branches/main":
+			w.Write([]byte(`{"name": "main", "protected": true}`))
+
+		case "/api/v4/projects/jippi%2Fscm-engine/repository/branches/release%2F1.0":
+			w.Write([]byte(`{"name": "release/1.0", "protected": true}`))
+
+		case "/api/v4/projects/jippi%2Fscm-engine/repository/branches/feature":
+			w.Write([]byte(`{"name": "feature", "protected": false}`))
+
+		case "/api/v4/projects/jippi%2Fscm-engine/protected_branches":
+			w.Write([]byte(`[
+				{"name": "main", "push_access_levels": [{"access_level": 0}], "merge_access_levels": [{"access_level": 40}], "code_owner_approval_required": true},
+				{"name": "release/*", "push_access_levels": [{"access_level": 40}], "merge_access_levels": [{"access_level": 30}], "allow_force_push": true}
+			]`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Branch Not Found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	tests := []struct {
+		branch string
+		script string
+		want   any
+	}{
+		{branch: "main", script: `merge_request.target_branch_is_protected()`, want: true},
+		{branch: "main", script: `merge_request.target_branch_protection().rules[0].merge_access_levels`, want: []int{40}},
+		{branch: "main", script: `merge_request.target_branch_protection().rules[0].code_owner_approval_required`, want: true},
+		{branch: "release/1.0", script: `merge_request.target_branch_protection().rules | map(.name)`, want: []any{"release/*"}},
+		{branch: "release/1.0", script: `merge_request.target_branch_protection().rules[0].allow_force_push`, want: true},
+		{branch: "feature", script: `merge_request.target_branch_is_protected()`, want: false},
+		{branch: "feature", script: `merge_request.target_branch_protection().exists`, want: true},
+		{branch: "deleted", script: `merge_request.target_branch_is_protected()`, want: false},
+		{branch: "deleted", script: `merge_request.target_branch_protection().exists`, want: false},
+	}
+
+	evalContexts := map[string]*gitlab.Context{}
+
+	for _, tt := range tests {
+		evalContext, ok := evalContexts[tt.branch]
+		if !ok {
+			evalContext = &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{TargetBranch: tt.branch}}
+			evalContext.SetContext(ctx)
+
+			evalContexts[tt.branch] = evalContext
+		}
+
+		program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, tt.script)
+		require.Equal(t, tt.want, output, tt.script)
+	}
+
+	// Every branch is only looked up once, and unprotected (or missing) branches have no rules to load
+	require.Equal(t, map[string]int{
+		"/api/v4/projects/jippi%2Fscm-engine/repository/branches/main":          1,
+		"/api/v4/projects/jippi%2Fscm-engine/repository/branches/release%2F1.0": 1,
+		"/api/v4/projects/jippi%2Fscm-engine/repository/branches/feature":       1,
+		"/api/v4/projects/jippi%2Fscm-engine/repository/branches/deleted":       1,
+		"/api/v4/projects/jippi%2Fscm-engine/protected_branches":                2,
+	}, requests)
+}
@@ -0,0 +1,94 @@
+package gitlab
+
+import (
+	"context"
+
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// CodeOwnerRule is the approval rule GitLab creates for a CODEOWNERS entry matching the changed files, as exposed to scripts
+type CodeOwnerRule struct {
+	// The CODEOWNERS pattern (example: '*.go' or '/docs/')
+	Name string `expr:"name"`
+	// The CODEOWNERS section the pattern is in (example: 'Backend'), 'codeowners' for the default section
+	Section string `expr:"section"`
+	// The number of approvals GitLab requires, 0 if Code Owner approval isn't required (or the section is optional)
+	ApprovalsRequired int `expr:"approvals_required"`
+	// The usernames of the owners that may approve
+	EligibleApprovers []string `expr:"eligible_approvers"`
+	// The usernames of the owners that approved
+	ApprovedBy []string `expr:"approved_by"`
+	// Whether enough owners approved, which is at least one approval even when GitLab doesn't require
+	// Code Owner approval, so the rule is meaningful regardless of the branch protection
+	Approved bool `expr:"approved"`
+}
+
+// codeOwnerRules returns the Code Owner rules of the Merge Request approval state, which GitLab computes from the owners
+// of the changed files (in the CODEOWNERS file of the target branch) and the current approvals
+func codeOwnerRules(ctx context.Context) ([]CodeOwnerRule, error) {
+	approvalState, err := loadApprovalState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []CodeOwnerRule{}
+
+	for _, rule := range approvalState.Rules {
+		if rule.RuleType != "code_owner" {
+			continue
+		}
+
+		approvedBy := usernames(rule.ApprovedBy)
+
+		rules = append(rules, CodeOwnerRule{
+			Name:              rule.Name,
+			Section:           rule.Section,
+			ApprovalsRequired: rule.ApprovalsRequired,
+			EligibleApprovers: usernames(rule.EligibleApprovers),
+			ApprovedBy:        approvedBy,
+			Approved:          len(approvedBy) >= max(rule.ApprovalsRequired, 1),
+		})
+	}
+
+	return rules, nil
+}
+
+func usernames(users []*go_gitlab.BasicUser) []string {
+	names := []string{}
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+
+	return names
+}
+
+// code_owner_rules
+func (e ContextMergeRequest) CodeOwnerRules(ctx context.Context) []CodeOwnerRule {
+	rules, err := codeOwnerRules(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return rules
+}
+
+// code_owners_satisfied
+func (e ContextMergeRequest) CodeOwnersSatisfied(ctx context.Context) bool {
+	val := true
+
+	for _, rule := range e.CodeOwnerRules(ctx) {
+		if !rule.Approved {
+			val = false
+
+			break
+		}
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.code_owners_satisfied"),
+		withResult(val),
+	)
+
+	return val
+}
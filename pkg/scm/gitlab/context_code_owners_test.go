@@ -0,0 +1,95 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_CodeOwnersSatisfied(t *testing.T) {
+	t.Parallel()
+
+	const (
+		// The backend owners approved, the docs owners didn't
+		partial = `{"rules": [
+			{"name": "All Members", "rule_type": "any_approver", "approvals_required": 2, "approved_by": []},
+			{"name": "*.go", "rule_type": "code_owner", "section": "Backend", "approvals_required": 1,
+				"eligible_approvers": [{"username": "alice"}, {"username": "bob"}], "approved_by": [{"username": "alice"}], "approved": true},
+			{"name": "/docs/", "rule_type": "code_owner", "section": "codeowners", "approvals_required": 0,
+				"eligible_approvers": [{"username": "carol"}], "approved_by": [], "approved": true}
+		]}`
+
+		full = `{"rules": [
+			{"name": "*.go", "rule_type": "code_owner", "section": "Backend", "approvals_required": 2,
+				"eligible_approvers": [{"username": "alice"}, {"username": "bob"}], "approved_by": [{"username": "alice"}, {"username": "bob"}], "approved": true},
+			{"name": "/docs/", "rule_type": "code_owner", "section": "codeowners", "approvals_required": 0,
+				"eligible_approvers": [{"username": "carol"}], "approved_by": [{"username": "carol"}], "approved": true}
+		]}`
+
+		// None of the changed files have owners
+		unowned = `{"rules": [{"name": "All Members", "rule_type": "any_approver", "approvals_required": 1, "approved_by": []}]}`
+	)
+
+	tests := []struct {
+		name          string
+		approvalState string
+		script        string
+		want          any
+	}{
+		{name: "partial owner approval", approvalState: partial, script: `merge_request.code_owners_satisfied()`, want: false},
+		{name: "partial owner approval rules", approvalState: partial, script: `merge_request.code_owner_rules() | filter(!.approved) | map(.name)`, want: []any{"/docs/"}},
+		{name: "partial owner approval approvers", approvalState: partial, script: `merge_request.code_owner_rules()[0].approved_by`, want: []string{"alice"}},
+		{name: "full owner approval", approvalState: full, script: `merge_request.code_owners_satisfied()`, want: true},
+		{name: "full owner approval sections", approvalState: full, script: `merge_request.code_owner_rules() | map(.section)`, want: []any{"Backend", "codeowners"}},
+		{name: "no owned files", approvalState: unowned, script: `merge_request.code_owners_satisfied()`, want: true},
+		{name: "no owned files rules", approvalState: unowned, script: `len(merge_request.code_owner_rules())`, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := &atomic.Int32{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1/approval_state", r.URL.EscapedPath())
+
+				requests.Add(1)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.approvalState))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+			evalContext.SetContext(ctx)
+
+			program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+			require.NoError(t, err)
+
+			for range 2 {
+				output, err := expr.Run(program, evalContext)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, output)
+			}
+
+			// The approval state is only loaded once per evaluation
+			require.Equal(t, int32(1), requests.Load())
+		})
+	}
+}
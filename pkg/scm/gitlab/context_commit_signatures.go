@@ -0,0 +1,162 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+const (
+	// CommitSignatureVerified is the status of a commit with a signature GitLab verified
+	CommitSignatureVerified = "verified"
+	// CommitSignatureUnverified is the status of a commit with a signature GitLab could not verify, or of an unsupported type
+	CommitSignatureUnverified = "unverified"
+	// CommitSignatureUnsigned is the status of a commit without a signature
+	CommitSignatureUnsigned = "unsigned"
+)
+
+// supportedSignatureTypes are the signature types GitLab verifies
+var supportedSignatureTypes = []string{"PGP", "SSH", "X509"}
+
+// verifiedSignatureStatuses are the GitLab verification statuses of a trusted signature
+var verifiedSignatureStatuses = []string{"verified", "verified_system"}
+
+// CommitSignature is the signature verification of a commit in the Merge Request, as exposed to scripts
+type CommitSignature struct {
+	// SHA1 ID of the commit
+	SHA string `expr:"sha"`
+	// Type of the signature ('PGP', 'SSH' or 'X509'), empty if the commit is unsigned
+	Type string `expr:"signature_type"`
+	// Status of the signature ('verified', 'unverified' or 'unsigned')
+	Status string `expr:"status"`
+	// The verification status reported by GitLab (example: 'verified', 'unknown_key' or 'other_user'), empty if the commit is unsigned
+	VerificationStatus string `expr:"verification_status"`
+}
+
+// loadCommitSignatures returns the signatures of the Merge Request commits, loaded once since it takes a request per commit
+func loadCommitSignatures(ctx context.Context) ([]CommitSignature, error) {
+	loaders, err := loadersFromContext(ctx, "commit signatures")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.commitSignatures.get(ctx, fetchCommitSignatures)
+}
+
+func fetchCommitSignatures(ctx context.Context) ([]CommitSignature, error) {
+	commits, err := loadCommits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request commit signatures", slog.Int("number_of_commits", len(commits)))
+
+	signatures := make([]CommitSignature, 0, len(commits))
+
+	for _, commit := range commits {
+		signature, err := fetchCommitSignature(ctx, client, commit.SHA)
+		if err != nil {
+			return nil, err
+		}
+
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
+
+// fetchCommitSignature reads the signature of the commit [sha]. go-gitlab only knows about GPG signatures,
+// so the response is read into our own struct to get the signature type too
+func fetchCommitSignature(ctx context.Context, client *go_gitlab.Client, sha string) (CommitSignature, error) {
+	signature := CommitSignature{SHA: sha, Status: CommitSignatureUnsigned}
+
+	project, err := ParseID(state.ProjectID(ctx))
+	if err != nil {
+		return signature, err
+	}
+
+	endpoint := fmt.Sprintf("projects/%s/repository/commits/%s/signature", go_gitlab.PathEscape(project), url.PathEscape(sha))
+
+	req, err := client.NewRequest(http.MethodGet, endpoint, nil, []go_gitlab.RequestOptionFunc{go_gitlab.WithContext(ctx)})
+	if err != nil {
+		return signature, err
+	}
+
+	var response struct {
+		SignatureType      string `json:"signature_type"`
+		VerificationStatus string `json:"verification_status"`
+	}
+
+	resp, err := client.Do(req, &response)
+	if err != nil {
+		// GitLab responds '404 Not Found' for commits without a signature
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return signature, nil
+		}
+
+		return signature, fmt.Errorf("could not load the signature of commit %s: %w", sha, err)
+	}
+
+	signature.Type = response.SignatureType
+	signature.VerificationStatus = response.VerificationStatus
+	signature.Status = CommitSignatureUnverified
+
+	// Older GitLab versions only return GPG signatures, without a signature type
+	if len(signature.Type) == 0 {
+		signature.Type = "PGP"
+	}
+
+	if !slices.Contains(supportedSignatureTypes, signature.Type) {
+		slogctx.Warn(ctx, "Unsupported commit signature type, treating the commit as unverified", slog.String("sha", sha), slog.String("signature_type", signature.Type))
+
+		return signature, nil
+	}
+
+	if slices.Contains(verifiedSignatureStatuses, signature.VerificationStatus) {
+		signature.Status = CommitSignatureVerified
+	}
+
+	return signature, nil
+}
+
+// commit_signatures
+func (e ContextMergeRequest) CommitSignatures(ctx context.Context) []CommitSignature {
+	signatures, err := loadCommitSignatures(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return signatures
+}
+
+// all_commits_verified
+func (e ContextMergeRequest) AllCommitsVerified(ctx context.Context) bool {
+	val := true
+
+	for _, signature := range e.CommitSignatures(ctx) {
+		if signature.Status != CommitSignatureVerified {
+			val = false
+
+			break
+		}
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.all_commits_verified"),
+		withResult(val),
+	)
+
+	return val
+}
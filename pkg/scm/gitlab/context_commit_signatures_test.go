@@ -0,0 +1,114 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_CommitSignatures(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, signatures map[string]string) *gitlab.Context {
+		t.Helper()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			if r.URL.EscapedPath() == "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1/commits" {
+				commits := []string{}
+				for _, sha := range []string{"aaa", "bbb", "ccc", "ddd"} {
+					if _, ok := signatures[sha]; ok {
+						commits = append(commits, `{"id": "`+sha+`", "title": "commit `+sha+`"}`)
+					}
+				}
+
+				w.Write([]byte("[" + strings.Join(commits, ",") + "]"))
+
+				return
+			}
+
+			sha := strings.TrimSuffix(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/projects/jippi%2Fscm-engine/repository/commits/"), "/signature")
+
+			signature, ok := signatures[sha]
+			if !ok || len(signature) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "404 GPG Signature Not Found"}`))
+
+				return
+			}
+
+			w.Write([]byte(signature))
+		}))
+		t.Cleanup(server.Close)
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+		ctx = state.WithMergeRequestID(ctx, "1")
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+		evalContext.SetContext(ctx)
+
+		return evalContext
+	}
+
+	run := func(t *testing.T, evalContext *gitlab.Context, script string) any {
+		t.Helper()
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, script)
+
+		return output
+	}
+
+	t.Run("a mix of verified, unverified and unsigned commits", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, map[string]string{
+			"aaa": `{"signature_type": "PGP", "verification_status": "verified", "gpg_key_id": 1}`,
+			"bbb": `{"signature_type": "SSH", "verification_status": "unknown_key"}`,
+			"ccc": "",
+			"ddd": `{"signature_type": "SIGSTORE", "verification_status": "verified"}`,
+		})
+
+		tests := []struct {
+			script string
+			want   any
+		}{
+			{script: `merge_request.all_commits_verified()`, want: false},
+			{script: `merge_request.commit_signatures() | map(.status)`, want: []any{"verified", "unverified", "unsigned", "unverified"}},
+			{script: `merge_request.commit_signatures() | map(.signature_type)`, want: []any{"PGP", "SSH", "", "SIGSTORE"}},
+			{script: `merge_request.commit_signatures()[1].verification_status`, want: "unknown_key"},
+			{script: `merge_request.commit_signatures() | filter(.status == "unsigned") | map(.sha)`, want: []any{"ccc"}},
+		}
+
+		for _, tt := range tests {
+			require.Equal(t, tt.want, run(t, evalContext, tt.script), tt.script)
+		}
+	})
+
+	t.Run("all commits are verified", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, map[string]string{
+			"aaa": `{"signature_type": "PGP", "verification_status": "verified"}`,
+			"bbb": `{"signature_type": "X509", "verification_status": "verified_system"}`,
+		})
+
+		require.Equal(t, true, run(t, evalContext, `merge_request.all_commits_verified()`))
+	})
+}
@@ -0,0 +1,102 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+func loadCommits(ctx context.Context) ([]scm.Commit, error) {
+	loaders, err := loadersFromContext(ctx, "merge request commits")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.commits.get(ctx, fetchCommits)
+}
+
+func fetchCommits(ctx context.Context) ([]scm.Commit, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request commits")
+
+	var (
+		commits []scm.Commit
+		options = &go_gitlab.GetMergeRequestCommitsOptions{PerPage: state.APIPageSize(ctx)}
+	)
+
+	for {
+		page, resp, err := client.MergeRequests.GetMergeRequestCommits(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load merge request commits: %w", err)
+		}
+
+		for _, commit := range page {
+			commits = append(commits, scm.Commit{
+				SHA:         commit.ID,
+				Title:       commit.Title,
+				Message:     commit.Message,
+				AuthorName:  commit.AuthorName,
+				AuthorEmail: commit.AuthorEmail,
+				Trailers:    scm.ParseTrailers(commit.Message),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	slogctx.Debug(ctx, "Loaded Merge Request commits", slog.Int("number_of_commits", len(commits)))
+
+	return commits, nil
+}
+
+// commits
+func (e ContextMergeRequest) Commits(ctx context.Context) []scm.Commit {
+	commits, err := loadCommits(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return commits
+}
+
+// all_commits_have_trailer
+func (e ContextMergeRequest) AllCommitsHaveTrailer(ctx context.Context, name string) bool {
+	val := scm.AllCommitsHaveTrailer(e.Commits(ctx), name)
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.all_commits_have_trailer"),
+		withInput(name),
+		withResult(val),
+	)
+
+	return val
+}
+
+// any_commit_message_matches
+func (e ContextMergeRequest) AnyCommitMessageMatches(ctx context.Context, pattern string) bool {
+	val, err := scm.AnyCommitMessageMatches(e.Commits(ctx), pattern)
+	if err != nil {
+		panic(fmt.Errorf("invalid regular expression %q: %w", pattern, err))
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.any_commit_message_matches"),
+		withInput(pattern),
+		withResult(val),
+	)
+
+	return val
+}
@@ -0,0 +1,47 @@
+package gitlab_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_CanUseConfigurationFileFromChangeRequest_ConfigChange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		policy        config.ConfigChangePolicy
+		changedFiles  []string
+		approved      bool
+		wantUseBranch bool
+	}{
+		{name: "allow uses the changed config", policy: config.ConfigChangeAllow, changedFiles: []string{".scm-engine.yml"}, wantUseBranch: true},
+		{name: "notice uses the changed config", policy: config.ConfigChangeNotice, changedFiles: []string{".scm-engine.yml"}, wantUseBranch: true},
+		{name: "require_approval uses the target config until approved", policy: config.ConfigChangeRequireApproval, changedFiles: []string{".scm-engine.yml"}},
+		{name: "require_approval uses the changed config once approved", policy: config.ConfigChangeRequireApproval, changedFiles: []string{".scm-engine.yml"}, approved: true, wantUseBranch: true},
+		{name: "use_target never uses the changed config", policy: config.ConfigChangeUseTarget, changedFiles: []string{".scm-engine.yml"}, approved: true},
+		{name: "use_target uses the branch config if it's not changed", policy: config.ConfigChangeUseTarget, changedFiles: []string{"main.go"}, wantUseBranch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+			ctx = state.WithConfigChangePolicy(ctx, string(tt.policy))
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Approved: tt.approved}}
+			for _, path := range tt.changedFiles {
+				evalContext.MergeRequest.DiffStats = append(evalContext.MergeRequest.DiffStats, gitlab.ContextDiffStat{Path: path})
+			}
+
+			require.Equal(t, tt.wantUseBranch, evalContext.CanUseConfigurationFileFromChangeRequest(ctx))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// loadFirstContribution returns the (cached) result of [fetchFirstContribution] for [username]
+func loadFirstContribution(ctx context.Context, username string) (bool, error) {
+	loaders, err := loadersFromContext(ctx, "contributions")
+	if err != nil {
+		return false, err
+	}
+
+	return loaders.firstContribution.get(ctx, strings.ToLower(username), func(ctx context.Context) (bool, error) {
+		return fetchFirstContribution(ctx, username)
+	})
+}
+
+// fetchFirstContribution returns true if [username] has no merged Merge Requests in the project,
+// not counting the evaluated Merge Request
+func fetchFirstContribution(ctx context.Context, username string) (bool, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	slogctx.Debug(ctx, "Loading merged contributions", slog.String("username", username))
+
+	// Two results are enough to tell, even when one of them is the evaluated Merge Request
+	options := &go_gitlab.ListProjectMergeRequestsOptions{
+		ListOptions:    go_gitlab.ListOptions{PerPage: 2},
+		State:          go_gitlab.Ptr("merged"),
+		AuthorUsername: go_gitlab.Ptr(username),
+	}
+
+	mergeRequests, _, err := client.MergeRequests.ListProjectMergeRequests(state.ProjectID(ctx), options, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("could not load merged Merge Requests of %q: %w", username, err)
+	}
+
+	for _, mergeRequest := range mergeRequests {
+		if mergeRequest.IID != state.MergeRequestIDInt(ctx) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// is_first_contribution
+func (e ContextMergeRequest) IsFirstContribution(ctx context.Context) bool {
+	if e.Author == nil {
+		panic(fmt.Errorf("%w: the Merge Request author is not available", state.ErrMissingContext))
+	}
+
+	val, err := loadFirstContribution(ctx, e.Author.Username)
+	if err != nil {
+		// Treat the author as a returning contributor when the lookup fails (for example, when rate limited),
+		// so a failed lookup never welcomes a returning contributor or fails the whole evaluation
+		slogctx.Warn(ctx, "Could not determine if this is the author's first contribution, assuming it is not", slog.Any("error", err))
+
+		val = false
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.is_first_contribution"),
+		withResult(val),
+	)
+
+	return val
+}
@@ -0,0 +1,89 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_IsFirstContribution(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		author := r.URL.Query().Get("author_username")
+
+		mu.Lock()
+		requests[author]++
+		mu.Unlock()
+
+		require.Equal(t, "/api/v4/projects/jippi/scm-engine/merge_requests", r.URL.Path)
+		require.Equal(t, "merged", r.URL.Query().Get("state"))
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch author {
+		case "returning":
+			w.Write([]byte(`[{"iid": 7}]`))
+
+		case "merged-now":
+			// Only the evaluated Merge Request itself has been merged
+			w.Write([]byte(`[{"iid": 1}]`))
+
+		case "rate-limited":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "403 Forbidden"}`))
+
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	tests := []struct {
+		author string
+		want   bool
+	}{
+		{author: "newcomer", want: true},
+		{author: "merged-now", want: true},
+		{author: "returning", want: false},
+		// A failed lookup treats the author as a returning contributor
+		{author: "rate-limited", want: false},
+	}
+
+	for _, tt := range tests {
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Author: &gitlab.ContextUser{Username: tt.author}}}
+		evalContext.SetContext(ctx)
+
+		program, err := expr.Compile(`merge_request.is_first_contribution()`, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		// Run each script twice, the second run must be served from the cache
+		for range 2 {
+			output, err := expr.Run(program, evalContext)
+			require.NoError(t, err, tt.author)
+			require.Equal(t, tt.want, output, tt.author)
+		}
+	}
+
+	require.Equal(t, map[string]int{"newcomer": 1, "merged-now": 1, "returning": 1, "rate-limited": 1}, requests)
+}
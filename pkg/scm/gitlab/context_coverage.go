@@ -0,0 +1,44 @@
+package gitlab
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/hasura/go-graphql-client"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// setCoverage computes the target branch coverage and coverage delta of the Merge Request.
+//
+// Both are left as nil when the head pipeline (or the latest successful target branch pipeline) has no coverage data,
+// and the target branch isn't queried at all unless the head pipeline has coverage.
+func (c *Context) setCoverage(ctx context.Context, client *graphql.Client) {
+	if c.MergeRequest.HeadPipeline == nil || c.MergeRequest.HeadPipeline.Coverage == nil {
+		return
+	}
+
+	var (
+		result    TargetBranchCoverageResult
+		variables = map[string]any{
+			"project_id": graphql.ID(state.ProjectID(ctx)),
+			"ref":        c.MergeRequest.TargetBranch,
+		}
+	)
+
+	if err := client.Query(ctx, &result, variables); err != nil {
+		slogctx.Warn(ctx, "Could not read the target branch pipeline coverage", slog.Any("error", err))
+
+		return
+	}
+
+	if result.Project == nil || len(result.Project.Pipelines.Nodes) == 0 || result.Project.Pipelines.Nodes[0].Coverage == nil {
+		return
+	}
+
+	target := *result.Project.Pipelines.Nodes[0].Coverage
+	delta := *c.MergeRequest.HeadPipeline.Coverage - target
+
+	c.MergeRequest.TargetBranchCoverage = &target
+	c.MergeRequest.CoverageDelta = &delta
+}
@@ -0,0 +1,106 @@
+package gitlab_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContext_Coverage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                     string
+		headPipeline             string
+		targetPipelines          string
+		wantHeadCoverage         *float64
+		wantTargetBranchCoverage *float64
+		wantCoverageDelta        *float64
+		wantCoverageQueries      int32
+	}{
+		{
+			name:         "no head pipeline",
+			headPipeline: `null`,
+		},
+		{
+			name:         "head pipeline without coverage",
+			headPipeline: `{"coverage": null}`,
+		},
+		{
+			name:                     "coverage dropped",
+			headPipeline:             `{"coverage": 80.5}`,
+			targetPipelines:          `[{"coverage": 82}]`,
+			wantHeadCoverage:         scm.Ptr(80.5),
+			wantTargetBranchCoverage: scm.Ptr(82.0),
+			wantCoverageDelta:        scm.Ptr(-1.5),
+			wantCoverageQueries:      1,
+		},
+		{
+			name:                "target branch without pipelines",
+			headPipeline:        `{"coverage": 80.5}`,
+			targetPipelines:     `[]`,
+			wantHeadCoverage:    scm.Ptr(80.5),
+			wantCoverageQueries: 1,
+		},
+		{
+			name:                "target branch pipeline without coverage",
+			headPipeline:        `{"coverage": 80.5}`,
+			targetPipelines:     `[{"coverage": null}]`,
+			wantHeadCoverage:    scm.Ptr(80.5),
+			wantCoverageQueries: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var coverageQueries atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				body, _ := io.ReadAll(r.Body)
+				if strings.Contains(string(body), "pipelines(ref: $ref") {
+					coverageQueries.Add(1)
+
+					w.Write([]byte(`{"data": {"project": {"pipelines": {"nodes": ` + tt.targetPipelines + `}}}}`))
+
+					return
+				}
+
+				w.Write([]byte(strings.Replace(mergeRequestResponse, "%s", `"targetProjectId": 1, "sourceProjectId": 1, "targetBranch": "main", "headPipeline": `+tt.headPipeline, 1)))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			evalContext, err := gitlab.NewContext(ctx, server.URL, "token")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCoverageQueries, coverageQueries.Load())
+			require.Equal(t, tt.wantTargetBranchCoverage, evalContext.MergeRequest.TargetBranchCoverage)
+			require.Equal(t, tt.wantCoverageDelta, evalContext.MergeRequest.CoverageDelta)
+
+			if tt.wantHeadCoverage != nil {
+				require.Equal(t, tt.wantHeadCoverage, evalContext.MergeRequest.HeadPipeline.Coverage)
+			}
+
+			// Missing coverage data is exposed as 'nil', so scripts can guard against it
+			output, err := expr.Eval(`merge_request.coverage_delta != nil && merge_request.coverage_delta < 0`, evalContext)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCoverageDelta != nil && *tt.wantCoverageDelta < 0, output)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+func loadDependencies(ctx context.Context, description string) ([]scm.Dependency, error) {
+	loaders, err := loadersFromContext(ctx, "merge request dependencies")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.dependencies.get(ctx, func(ctx context.Context) ([]scm.Dependency, error) {
+		return fetchDependencies(ctx, description)
+	})
+}
+
+// loadBlockingMergeRequests returns the GitLab Merge Request dependencies ("is blocked by")
+func loadBlockingMergeRequests(ctx context.Context) ([]scm.Dependency, error) {
+	loaders, err := loadersFromContext(ctx, "merge request dependencies")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.blockingMergeRequests.get(ctx, func(ctx context.Context) ([]scm.Dependency, error) {
+		return fetchMergeRequestBlocks(ctx, "blocks")
+	})
+}
+
+// loadBlockedMergeRequests returns the Merge Requests that have this Merge Request as a GitLab dependency ("blocks")
+func loadBlockedMergeRequests(ctx context.Context) ([]scm.Dependency, error) {
+	loaders, err := loadersFromContext(ctx, "merge request dependencies")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.blockedMergeRequests.get(ctx, func(ctx context.Context) ([]scm.Dependency, error) {
+		return fetchMergeRequestBlocks(ctx, "blockees")
+	})
+}
+
+// blockMergeRequest is a Merge Request in a [mergeRequestBlock]
+type blockMergeRequest struct {
+	IID        int    `json:"iid"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	WebURL     string `json:"web_url"`
+	References struct {
+		Full string `json:"full"`
+	} `json:"references"`
+}
+
+// dependency converts the Merge Request into a [scm.Dependency] found by [source]
+func (mergeRequest blockMergeRequest) dependency(source string) scm.Dependency {
+	project, _, _ := strings.Cut(mergeRequest.References.Full, "!")
+
+	return scm.Dependency{
+		Project:   project,
+		IID:       mergeRequest.IID,
+		Reference: scm.DependencyReference{Project: project, IID: mergeRequest.IID}.String(),
+		Title:     mergeRequest.Title,
+		State:     mergeRequest.State,
+		WebURL:    mergeRequest.WebURL,
+		Source:    source,
+	}
+}
+
+// mergeRequestBlock is a GitLab Merge Request dependency, as returned by the '/merge_requests/:iid/blocks'
+// and '/merge_requests/:iid/blockees' APIs
+type mergeRequestBlock struct {
+	BlockingMergeRequest blockMergeRequest `json:"blocking_merge_request"`
+	BlockedMergeRequest  blockMergeRequest `json:"blocked_merge_request"`
+}
+
+// fetchDependencies combines the GitLab Merge Request dependencies with the Merge Requests linked
+// from 'Depends on' lines in the [description]
+func fetchDependencies(ctx context.Context, description string) ([]scm.Dependency, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request dependencies")
+
+	var dependencies []scm.Dependency
+
+	seen := map[string]bool{}
+
+	blocking, err := loadBlockingMergeRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dependency := range blocking {
+		seen[strings.ToLower(dependency.Reference)] = true
+
+		dependencies = append(dependencies, dependency)
+	}
+
+	for _, reference := range scm.ParseDependencyReferences(description, state.ProjectID(ctx)) {
+		if seen[strings.ToLower(reference.String())] {
+			continue
+		}
+
+		mergeRequest, _, err := client.MergeRequests.GetMergeRequest(reference.Project, reference.IID, nil, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load dependency %s: %w", reference, err)
+		}
+
+		dependencies = append(dependencies, scm.Dependency{
+			Project:   reference.Project,
+			IID:       reference.IID,
+			Reference: reference.String(),
+			Title:     mergeRequest.Title,
+			State:     mergeRequest.State,
+			WebURL:    mergeRequest.WebURL,
+			Source:    "description",
+		})
+	}
+
+	slogctx.Debug(ctx, "Loaded Merge Request dependencies", slog.Int("number_of_dependencies", len(dependencies)))
+
+	return dependencies, nil
+}
+
+// fetchMergeRequestBlocks lists the GitLab Merge Request dependencies, either the Merge Requests blocking this one ('blocks')
+// or the Merge Requests blocked by this one ('blockees'). They require GitLab Premium, so a project without the feature
+// is treated as having no dependencies
+func fetchMergeRequestBlocks(ctx context.Context, relation string) ([]scm.Dependency, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/%s", go_gitlab.PathEscape(state.ProjectID(ctx)), state.MergeRequestIDInt(ctx), relation)
+
+	req, err := client.NewRequest(http.MethodGet, endpoint, nil, []go_gitlab.RequestOptionFunc{go_gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []mergeRequestBlock
+
+	response, err := client.Do(req, &blocks)
+	if err != nil {
+		if response != nil && (response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusForbidden) {
+			slogctx.Debug(ctx, "Merge Request dependencies are not available", slog.String("relation", relation), slog.Any("err", err))
+
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not load merge request dependencies: %w", err)
+	}
+
+	dependencies := make([]scm.Dependency, 0, len(blocks))
+
+	for _, block := range blocks {
+		if relation == "blockees" {
+			dependencies = append(dependencies, block.BlockedMergeRequest.dependency("blocked"))
+		} else {
+			dependencies = append(dependencies, block.BlockingMergeRequest.dependency("dependency"))
+		}
+	}
+
+	return dependencies, nil
+}
+
+// dependencies
+func (e ContextMergeRequest) Dependencies(ctx context.Context) []scm.Dependency {
+	dependencies, err := loadDependencies(ctx, e.description())
+	if err != nil {
+		panic(err)
+	}
+
+	return dependencies
+}
+
+// all_dependencies_merged
+func (e ContextMergeRequest) AllDependenciesMerged(ctx context.Context) bool {
+	val := scm.AllDependenciesMerged(e.Dependencies(ctx))
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.all_dependencies_merged"),
+		withResult(val),
+	)
+
+	return val
+}
+
+// blocked_by
+func (e ContextMergeRequest) BlockedBy(ctx context.Context) []scm.Dependency {
+	blocking, err := loadBlockingMergeRequests(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return blocking
+}
+
+// blocks
+func (e ContextMergeRequest) Blocks(ctx context.Context) []scm.Dependency {
+	blocked, err := loadBlockedMergeRequests(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return blocked
+}
+
+// is_blocked
+func (e ContextMergeRequest) IsBlocked(ctx context.Context) bool {
+	val := !scm.AllDependenciesMerged(e.BlockedBy(ctx))
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.is_blocked"),
+		withResult(val),
+	)
+
+	return val
+}
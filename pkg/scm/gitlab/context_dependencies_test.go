@@ -0,0 +1,175 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_Dependencies(t *testing.T) {
+	t.Parallel()
+
+	// A stack of Merge Requests !1 <- !2 <- !3, where !3 declares !2 as a GitLab Merge Request dependency
+	// and lists both in its description
+	states := map[string]string{"1": "merged", "2": "opened"}
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests[r.URL.EscapedPath()]++
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/jippi%2Fscm-engine/merge_requests/3/blocks":
+			w.Write([]byte(`[{"blocking_merge_request": {"iid": 2, "title": "Part 2", "state": "` + states["2"] + `", "references": {"full": "jippi/scm-engine!2"}}}]`))
+
+		case "/api/v4/projects/jippi%2Fscm-engine/merge_requests/1":
+			w.Write([]byte(`{"iid": 1, "title": "Part 1", "state": "` + states["1"] + `"}`))
+
+		case "/api/v4/projects/jippi%2Fscm-engine/merge_requests/4/blocks":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "403 Forbidden"}`))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	run := func(mergeRequestID, description, script string) any {
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+		ctx = state.WithMergeRequestID(ctx, mergeRequestID)
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Description: scm.Ptr(description)}}
+		evalContext.SetContext(ctx)
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		var output any
+
+		// Run the script twice, the second run must be served from the cache
+		for range 2 {
+			output, err = expr.Run(program, evalContext)
+			require.NoError(t, err, script)
+		}
+
+		return output
+	}
+
+	description := "Depends on !2 and !1"
+
+	require.Equal(t, false, run("3", description, `merge_request.all_dependencies_merged()`))
+	require.Equal(t, []any{"jippi/scm-engine!2:dependency:opened", "jippi/scm-engine!1:description:merged"},
+		run("3", description, `map(merge_request.dependencies(), { .reference + ":" + .source + ":" + .state })`))
+
+	// Once the parent is merged, the whole chain is merged
+	mu.Lock()
+	states["2"] = "merged"
+	mu.Unlock()
+
+	require.Equal(t, true, run("3", description, `merge_request.all_dependencies_merged()`))
+
+	// Each evaluation loads the dependencies once, and !2 is never loaded twice
+	mu.Lock()
+	require.Equal(t, 3, requests["/api/v4/projects/jippi%2Fscm-engine/merge_requests/3/blocks"])
+	require.Equal(t, 3, requests["/api/v4/projects/jippi%2Fscm-engine/merge_requests/1"])
+	require.Zero(t, requests["/api/v4/projects/jippi%2Fscm-engine/merge_requests/2"])
+	mu.Unlock()
+
+	// No dependencies at all, on a project without Merge Request dependencies
+	require.Equal(t, true, run("4", "", `merge_request.all_dependencies_merged()`))
+	require.Equal(t, 0, run("4", "", `len(merge_request.dependencies())`))
+}
+
+func TestContextMergeRequest_Blocks(t *testing.T) {
+	t.Parallel()
+
+	// !3 is blocked by the open !2, and blocks !5. Project 'jippi/free' doesn't have the feature
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests[r.URL.EscapedPath()]++
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/jippi%2Fscm-engine/merge_requests/3/blocks":
+			w.Write([]byte(`[{"blocking_merge_request": {"iid": 2, "title": "Part 2", "state": "opened", "references": {"full": "jippi/scm-engine!2"}}, "blocked_merge_request": {"iid": 3}}]`))
+
+		case "/api/v4/projects/jippi%2Fscm-engine/merge_requests/3/blockees":
+			w.Write([]byte(`[{"blocking_merge_request": {"iid": 3}, "blocked_merge_request": {"iid": 5, "title": "Part 4", "state": "opened", "references": {"full": "jippi/scm-engine!5"}}}]`))
+
+		case "/api/v4/projects/jippi%2Ffree/merge_requests/3/blocks", "/api/v4/projects/jippi%2Ffree/merge_requests/3/blockees":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Not found"}`))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	run := func(project, script string) any {
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, project)
+		ctx = state.WithMergeRequestID(ctx, "3")
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+		evalContext.SetContext(ctx)
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		var output any
+
+		// Run the script twice, the second run must be served from the cache
+		for range 2 {
+			output, err = expr.Run(program, evalContext)
+			require.NoError(t, err, script)
+		}
+
+		return output
+	}
+
+	require.Equal(t, true, run("jippi/scm-engine", `merge_request.is_blocked()`))
+	require.Equal(t, []any{"jippi/scm-engine!2"}, run("jippi/scm-engine", `map(merge_request.blocked_by(), { .reference })`))
+	require.Equal(t, []any{"opened"}, run("jippi/scm-engine", `map(merge_request.blocked_by(), { .state })`))
+	require.Equal(t, []any{"jippi/scm-engine!5"}, run("jippi/scm-engine", `map(merge_request.blocks(), { .reference })`))
+	require.Equal(t, []any{"blocked"}, run("jippi/scm-engine", `map(merge_request.blocks(), { .source })`))
+
+	// Editions without Merge Request dependencies have no relationships
+	require.Equal(t, false, run("jippi/free", `merge_request.is_blocked()`))
+	require.Equal(t, 0, run("jippi/free", `len(merge_request.blocks())`))
+
+	require.Equal(t, 3, requests["/api/v4/projects/jippi%2Fscm-engine/merge_requests/3/blocks"])
+	require.Equal(t, 2, requests["/api/v4/projects/jippi%2Fscm-engine/merge_requests/3/blockees"])
+}
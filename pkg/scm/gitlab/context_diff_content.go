@@ -0,0 +1,152 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// loadDiffContent returns the Merge Request diff, loaded once since most configurations only look at the modified file paths
+func loadDiffContent(ctx context.Context) (*scm.DiffContent, error) {
+	loaders, err := loadersFromContext(ctx, "merge request diff")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.diffContent.get(ctx, fetchDiffContent)
+}
+
+// fetchDiffContent reads the added and removed lines of the Merge Request diff, up to [scm.MaxDiffContentBytes].
+//
+// The diffs are read directly from the API rather than with go-gitlab, since it doesn't expose
+// whether GitLab left out the diff of a file for being too large
+func fetchDiffContent(ctx context.Context) (*scm.DiffContent, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := ParseID(state.ProjectID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request diff")
+
+	var (
+		content  = &scm.DiffContent{}
+		endpoint = fmt.Sprintf("projects/%s/merge_requests/%d/diffs", go_gitlab.PathEscape(project), state.MergeRequestIDInt(ctx))
+		options  = &go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}
+	)
+
+	for !content.Full() {
+		req, err := client.NewRequest(http.MethodGet, endpoint, options, []go_gitlab.RequestOptionFunc{go_gitlab.WithContext(ctx)})
+		if err != nil {
+			return nil, err
+		}
+
+		var diffs []struct {
+			Diff      string `json:"diff"`
+			TooLarge  bool   `json:"too_large"`
+			Collapsed bool   `json:"collapsed"`
+		}
+
+		resp, err := client.Do(req, &diffs)
+		if err != nil {
+			return nil, fmt.Errorf("could not load merge request diff: %w", err)
+		}
+
+		for _, diff := range diffs {
+			if diff.TooLarge || (diff.Collapsed && len(diff.Diff) == 0) {
+				content.Truncated = true
+
+				continue
+			}
+
+			content.AddFileDiff(diff.Diff)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	slogctx.Debug(ctx, "Loaded Merge Request diff",
+		slog.Int("number_of_added_lines", len(content.Added)),
+		slog.Int("number_of_removed_lines", len(content.Removed)),
+		slog.Bool("truncated", content.Truncated),
+	)
+
+	return content, nil
+}
+
+func (e ContextMergeRequest) diffContent(ctx context.Context) *scm.DiffContent {
+	content, err := loadDiffContent(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return content
+}
+
+// added_lines
+func (e ContextMergeRequest) AddedLines(ctx context.Context) []string {
+	return e.diffContent(ctx).Added
+}
+
+// removed_lines
+func (e ContextMergeRequest) RemovedLines(ctx context.Context) []string {
+	return e.diffContent(ctx).Removed
+}
+
+// diff_truncated
+func (e ContextMergeRequest) DiffTruncated(ctx context.Context) bool {
+	val := e.diffContent(ctx).Truncated
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.diff_truncated"),
+		withResult(val),
+	)
+
+	return val
+}
+
+// added_lines_match
+func (e ContextMergeRequest) AddedLinesMatch(ctx context.Context, pattern string) bool {
+	val, err := scm.AnyLineMatches(e.AddedLines(ctx), pattern)
+	if err != nil {
+		panic(fmt.Errorf("invalid regular expression %q: %w", pattern, err))
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.added_lines_match"),
+		withInput(pattern),
+		withResult(val),
+	)
+
+	return val
+}
+
+// removed_lines_match
+func (e ContextMergeRequest) RemovedLinesMatch(ctx context.Context, pattern string) bool {
+	val, err := scm.AnyLineMatches(e.RemovedLines(ctx), pattern)
+	if err != nil {
+		panic(fmt.Errorf("invalid regular expression %q: %w", pattern, err))
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.removed_lines_match"),
+		withInput(pattern),
+		withResult(val),
+	)
+
+	return val
+}
@@ -0,0 +1,127 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+func loadDiscussions(ctx context.Context) ([]scm.Discussion, error) {
+	loaders, err := loadersFromContext(ctx, "merge request discussions")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.discussions.get(ctx, fetchDiscussions)
+}
+
+func fetchDiscussions(ctx context.Context) ([]scm.Discussion, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request discussions")
+
+	var (
+		discussions []scm.Discussion
+		options     = &go_gitlab.ListMergeRequestDiscussionsOptions{PerPage: state.APIPageSize(ctx)}
+	)
+
+	for {
+		page, resp, err := client.Discussions.ListMergeRequestDiscussions(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load merge request discussions: %w", err)
+		}
+
+		for _, discussion := range page {
+			discussions = append(discussions, convertDiscussion(discussion))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	slogctx.Debug(ctx, "Loaded Merge Request discussions", slog.Int("number_of_discussions", len(discussions)))
+
+	return discussions, nil
+}
+
+// convertDiscussion considers a discussion resolved once all of its resolvable notes are resolved
+func convertDiscussion(discussion *go_gitlab.Discussion) scm.Discussion {
+	result := scm.Discussion{ID: discussion.ID, Resolved: true}
+
+	for idx, note := range discussion.Notes {
+		if idx == 0 {
+			result.AuthorUsername = note.Author.Username
+		}
+
+		if !note.Resolvable {
+			continue
+		}
+
+		result.Resolvable = true
+		result.Resolved = result.Resolved && note.Resolved
+	}
+
+	if !result.Resolvable {
+		result.Resolved = false
+	}
+
+	return result
+}
+
+// discussions
+func (e ContextMergeRequest) Discussions(ctx context.Context) []scm.Discussion {
+	discussions, err := loadDiscussions(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return discussions
+}
+
+// unresolved_discussions_count
+func (e ContextMergeRequest) UnresolvedDiscussionsCount(ctx context.Context) int {
+	val := len(scm.UnresolvedDiscussions(e.Discussions(ctx), ""))
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.unresolved_discussions_count"),
+		slog.Int("function_result", val),
+	)
+
+	return val
+}
+
+// unresolved_discussions_count_by
+func (e ContextMergeRequest) UnresolvedDiscussionsCountBy(ctx context.Context, username string) int {
+	val := len(scm.UnresolvedDiscussions(e.Discussions(ctx), username))
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.unresolved_discussions_count_by"),
+		withInput(username),
+		slog.Int("function_result", val),
+	)
+
+	return val
+}
+
+// all_discussions_resolved
+func (e ContextMergeRequest) AllDiscussionsResolved(ctx context.Context) bool {
+	val := len(scm.UnresolvedDiscussions(e.Discussions(ctx), "")) == 0
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.all_discussions_resolved"),
+		withResult(val),
+	)
+
+	return val
+}
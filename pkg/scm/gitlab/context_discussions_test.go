@@ -0,0 +1,85 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+const discussionsResponse = `[
+	{"id": "resolved", "notes": [
+		{"author": {"username": "alice"}, "resolvable": true, "resolved": true},
+		{"author": {"username": "bob"}, "resolvable": true, "resolved": true}
+	]},
+	{"id": "partially-resolved", "notes": [
+		{"author": {"username": "alice"}, "resolvable": true, "resolved": true},
+		{"author": {"username": "bob"}, "resolvable": true, "resolved": false}
+	]},
+	{"id": "unresolved", "notes": [
+		{"author": {"username": "bob"}, "resolvable": true, "resolved": false}
+	]},
+	{"id": "system-note", "notes": [
+		{"author": {"username": "alice"}, "resolvable": false, "resolved": false, "system": true}
+	]}
+]`
+
+func TestContextMergeRequest_Discussions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Serve the discussions across two pages to exercise pagination
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"id": "page-two", "notes": [{"author": {"username": "alice"}, "resolvable": true, "resolved": false}]}]`))
+
+			return
+		}
+
+		w.Header().Set("X-Next-Page", "2")
+		w.Write([]byte(discussionsResponse))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+	evalContext.SetContext(ctx)
+
+	tests := []struct {
+		script string
+		want   any
+	}{
+		{script: `merge_request.unresolved_discussions_count()`, want: 3},
+		{script: `merge_request.unresolved_discussions_count_by("alice")`, want: 2},
+		{script: `merge_request.unresolved_discussions_count_by("bob")`, want: 1},
+		{script: `merge_request.unresolved_discussions_count_by("carol")`, want: 0},
+		{script: `merge_request.all_discussions_resolved()`, want: false},
+		{script: `len(merge_request.discussions())`, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			t.Parallel()
+
+			program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, evalContext)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+const mergeRequestResponse = `{"data": {
+	"currentUser": {"username": "scm-engine"},
+	"project": {
+		"fullPath": "jippi/scm-engine",
+		"labels": {"nodes": []},
+		"mergeRequest": {
+			"iid": "1",
+			"labels": {"nodes": []},
+			"notes": {"nodes": []},
+			"first_commit": {"nodes": []},
+			"last_commit": {"nodes": []},
+			%s
+		}
+	}
+}}`
+
+func TestNewContext_Fork(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		mergeRequest      string
+		wantFork          bool
+		wantSourceProject string
+	}{
+		{
+			name:              "same project",
+			mergeRequest:      `"targetProjectId": 1, "sourceProjectId": 1, "sourceProject": {"fullPath": "jippi/scm-engine"}`,
+			wantSourceProject: "jippi/scm-engine",
+		},
+		{
+			name:              "fork",
+			mergeRequest:      `"targetProjectId": 1, "sourceProjectId": 2, "sourceProject": {"fullPath": "contributor/scm-engine"}`,
+			wantFork:          true,
+			wantSourceProject: "contributor/scm-engine",
+		},
+		{
+			name:         "deleted fork",
+			mergeRequest: `"targetProjectId": 1, "sourceProjectId": null, "sourceProject": null`,
+			wantFork:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(strings.Replace(mergeRequestResponse, "%s", tt.mergeRequest, 1)))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			evalContext, err := gitlab.NewContext(ctx, server.URL, "token")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFork, evalContext.MergeRequest.IsFork)
+
+			// The configuration file of a fork can't be trusted
+			require.Equal(t, !tt.wantFork, evalContext.CanUseConfigurationFileFromChangeRequest(ctx))
+
+			output, err := expr.Eval(`merge_request.is_fork`, evalContext)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFork, output)
+
+			if len(tt.wantSourceProject) > 0 {
+				output, err = expr.Eval(`merge_request.source_project.full_path`, evalContext)
+				require.NoError(t, err)
+				require.Equal(t, tt.wantSourceProject, output)
+			} else {
+				require.Nil(t, evalContext.MergeRequest.SourceProject)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package gitlab
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/hasura/go-graphql-client"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+func loadIncidents(ctx context.Context, description string) ([]scm.Incident, error) {
+	loaders, err := loadersFromContext(ctx, "merge request incidents")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.incidents.get(ctx, func(ctx context.Context) ([]scm.Incident, error) {
+		return fetchIncidents(ctx, description)
+	})
+}
+
+// fetchIncidents reads the severity and status of the incidents referenced in the [description].
+//
+// References that can't be read (for example, because they don't exist, or the token can't access them)
+// or that point at issues rather than incidents are skipped
+func fetchIncidents(ctx context.Context, description string) ([]scm.Incident, error) {
+	references := scm.ParseIncidentReferences(description, state.ProjectID(ctx))
+	if len(references) == 0 {
+		return nil, nil
+	}
+
+	apiClient, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := graphql.NewClient(graphqlBaseURL(apiClient.BaseURL())+"/api/graphql", newGraphQLHTTPClient(ctx, state.Token(ctx)))
+
+	slogctx.Debug(ctx, "Loading Merge Request incidents", slog.Int("number_of_references", len(references)))
+
+	var incidents []scm.Incident
+
+	for _, reference := range references {
+		var (
+			result    IncidentResult
+			variables = map[string]any{
+				"project_id": graphql.ID(reference.Project),
+				"iid":        strconv.Itoa(reference.IID),
+			}
+		)
+
+		if err := client.Query(ctx, &result, variables); err != nil {
+			slogctx.Warn(ctx, "Could not load incident, skipping it", slog.String("reference", reference.String()), slog.Any("error", err))
+
+			continue
+		}
+
+		if result.Project == nil || result.Project.Issue == nil || result.Project.Issue.Type != "INCIDENT" {
+			slogctx.Debug(ctx, "Reference is not an incident, skipping it", slog.String("reference", reference.String()))
+
+			continue
+		}
+
+		issue := result.Project.Issue
+
+		incident := scm.Incident{
+			Project:   reference.Project,
+			IID:       reference.IID,
+			Reference: reference.String(),
+			Title:     issue.Title,
+			State:     issue.State,
+			Severity:  strings.ToLower(issue.Severity),
+			WebURL:    issue.WebURL,
+		}
+
+		if issue.EscalationStatus != nil {
+			incident.Status = strings.ToLower(*issue.EscalationStatus)
+		}
+
+		incidents = append(incidents, incident)
+	}
+
+	slogctx.Debug(ctx, "Loaded Merge Request incidents", slog.Int("number_of_incidents", len(incidents)))
+
+	return incidents, nil
+}
+
+// incidents
+func (e ContextMergeRequest) Incidents(ctx context.Context) []scm.Incident {
+	incidents, err := loadIncidents(ctx, e.description())
+	if err != nil {
+		panic(err)
+	}
+
+	return incidents
+}
+
+// incident_severity
+func (e ContextMergeRequest) IncidentSeverity(ctx context.Context) string {
+	val := scm.HighestIncidentSeverity(e.Incidents(ctx))
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.incident_severity"),
+		slog.String("result", val),
+	)
+
+	return val
+}
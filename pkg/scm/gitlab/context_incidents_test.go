@@ -0,0 +1,87 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_Incidents(t *testing.T) {
+	t.Parallel()
+
+	// jippi/ops#1 is a critical incident, jippi/ops#2 a low severity incident,
+	// jippi/ops#3 a regular issue, and jippi/ops#4 doesn't exist
+	issues := map[string]any{
+		"1": map[string]any{"iid": "1", "title": "API is down", "state": "opened", "type": "INCIDENT", "severity": "CRITICAL", "escalationStatus": "TRIGGERED", "webUrl": "https://gitlab.com/jippi/ops/-/issues/incident/1"},
+		"2": map[string]any{"iid": "2", "title": "Slow dashboard", "state": "closed", "type": "INCIDENT", "severity": "LOW", "escalationStatus": "RESOLVED"},
+		"3": map[string]any{"iid": "3", "title": "Feature request", "state": "opened", "type": "ISSUE", "severity": "UNKNOWN"},
+	}
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.URL.Path != "/api/graphql" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+
+			return
+		}
+
+		var request struct {
+			Variables map[string]string `json:"variables"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "jippi/ops", request.Variables["project_id"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(mustJSON(t, map[string]any{"data": map[string]any{"project": map[string]any{"issue": issues[request.Variables["iid"]]}}})))
+	}))
+	t.Cleanup(server.Close)
+
+	run := func(description, script string) any {
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, "jippi/ops")
+		ctx = state.WithMergeRequestID(ctx, "7")
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{Description: scm.Ptr(description)}}
+		evalContext.SetContext(ctx)
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, script)
+
+		return output
+	}
+
+	description := "Fixes incident #1 and #2\nRelated incident #3, and incident #4"
+
+	require.Equal(t, "critical", run(description, `merge_request.incident_severity()`))
+	require.Equal(t, true, run(description, `merge_request.incident_severity() == "critical"`))
+	require.Equal(t, []any{"jippi/ops#1", "jippi/ops#2"}, run(description, `map(merge_request.incidents(), { .reference })`))
+	require.Equal(t, []any{"triggered", "resolved"}, run(description, `map(merge_request.incidents(), { .status })`))
+	require.Equal(t, []any{"opened", "closed"}, run(description, `map(merge_request.incidents(), { .state })`))
+
+	// Without incident references, GitLab isn't queried at all
+	before := requests.Load()
+
+	require.Equal(t, "", run("Closes #1", `merge_request.incident_severity()`))
+	require.Equal(t, 0, run("", `len(merge_request.incidents())`))
+	require.Equal(t, before, requests.Load())
+}
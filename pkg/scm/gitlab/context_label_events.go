@@ -0,0 +1,83 @@
+package gitlab
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// LabelEvent is a label being added to or removed from the Merge Request, as exposed to scripts
+type LabelEvent struct {
+	// The name of the label
+	Label string `expr:"label"`
+	// Either 'add' or 'remove'
+	Action string `expr:"action"`
+	// The username of the user that added or removed the label
+	User string `expr:"user"`
+	// When the label was added or removed
+	CreatedAt time.Time `expr:"created_at"`
+}
+
+// label_events
+func (e ContextMergeRequest) LabelEvents(ctx context.Context) []LabelEvent {
+	events, err := loadLabelEvents(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	val := make([]LabelEvent, 0, len(events))
+	for _, event := range events {
+		val = append(val, LabelEvent{Label: event.Label, Action: event.Action, User: event.User, CreatedAt: event.CreatedAt})
+	}
+
+	return val
+}
+
+// ever_had_label
+func (e ContextMergeRequest) EverHadLabel(ctx context.Context, name string) bool {
+	// The label events are only needed if the label is no longer on the Merge Request
+	val := e.HasLabel(ctx, name)
+
+	if !val {
+		for _, event := range e.LabelEvents(ctx) {
+			if event.Label == name && event.Action == "add" {
+				val = true
+
+				break
+			}
+		}
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.ever_had_label"),
+		withInput(name),
+		withResult(val),
+	)
+
+	return val
+}
+
+// label_added_by
+func (e ContextMergeRequest) LabelAddedBy(ctx context.Context, name string) string {
+	var (
+		val    string
+		latest time.Time
+	)
+
+	// The most recent event wins, since the label may have been removed and added again by someone else
+	for _, event := range e.LabelEvents(ctx) {
+		if event.Label == name && event.Action == "add" && !event.CreatedAt.Before(latest) {
+			val, latest = event.User, event.CreatedAt
+		}
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.label_added_by"),
+		withInput(name),
+		slog.String("function_result", val),
+	)
+
+	return val
+}
@@ -0,0 +1,108 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_LabelEvents(t *testing.T) {
+	t.Parallel()
+
+	const labelEventsPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1/resource_label_events"
+
+	start := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hours int) string {
+		return start.Add(time.Duration(hours) * time.Hour).Format(time.RFC3339)
+	}
+
+	// The label event history, split over two pages
+	pages := [][]map[string]any{
+		{
+			{"action": "add", "label": map[string]any{"name": "security-reviewed"}, "user": map[string]any{"username": "alice"}, "created_at": at(0)},
+			{"action": "add", "label": map[string]any{"name": "needs-rework"}, "user": map[string]any{"username": "bob"}, "created_at": at(1)},
+		},
+		{
+			{"action": "remove", "label": map[string]any{"name": "security-reviewed"}, "user": map[string]any{"username": "carol"}, "created_at": at(2)},
+			{"action": "remove", "label": map[string]any{"name": "needs-rework"}, "user": map[string]any{"username": "bob"}, "created_at": at(3)},
+			{"action": "add", "label": map[string]any{"name": "needs-rework"}, "user": map[string]any{"username": "dave"}, "created_at": at(4)},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		labels       []string
+		script       string
+		want         any
+		wantRequests int32
+	}{
+		{name: "events of all pages", script: `len(merge_request.label_events())`, want: 5, wantRequests: 2},
+		{name: "event fields", script: `merge_request.label_events()[2]`, want: gitlab.LabelEvent{Label: "security-reviewed", Action: "remove", User: "carol", CreatedAt: start.Add(2 * time.Hour)}, wantRequests: 2},
+		{name: "ever had a removed label", script: `merge_request.ever_had_label("security-reviewed")`, want: true, wantRequests: 2},
+		{name: "never had the label", script: `merge_request.ever_had_label("approved")`, want: false, wantRequests: 2},
+		{name: "current label doesn't need the events", labels: []string{"approved"}, script: `merge_request.ever_had_label("approved")`, want: true, wantRequests: 0},
+		{name: "label added by", script: `merge_request.label_added_by("security-reviewed")`, want: "alice", wantRequests: 2},
+		{name: "label added again by someone else", script: `merge_request.label_added_by("needs-rework")`, want: "dave", wantRequests: 2},
+		{name: "label never added", script: `merge_request.label_added_by("approved")`, want: "", wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := &atomic.Int32{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, labelEventsPath, r.URL.Path)
+
+				requests.Add(1)
+
+				page := pages[0]
+				if r.URL.Query().Get("page") == "2" {
+					page = pages[1]
+				} else {
+					w.Header().Set("X-Next-Page", "2")
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(mustJSON(t, page)))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+			for _, label := range tt.labels {
+				evalContext.MergeRequest.Labels = append(evalContext.MergeRequest.Labels, gitlab.ContextLabel{Title: label})
+			}
+
+			evalContext.SetContext(ctx)
+
+			program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+			require.NoError(t, err)
+
+			// The label events are only loaded once per evaluation
+			for range 2 {
+				output, err := expr.Run(program, evalContext)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, output)
+			}
+
+			require.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
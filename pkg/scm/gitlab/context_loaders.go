@@ -0,0 +1,95 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+type loadersKey struct{}
+
+// loaders holds the data script functions read from the API the first time a script needs it, since most
+// configurations don't, and remembers it for the rest of the evaluation. See [Context.SetContext]
+type loaders struct {
+	accessLevels          lazyMap[int]
+	approvalState         lazy[*go_gitlab.MergeRequestApprovalState]
+	authorOpenMRCount     lazyMap[int]
+	blockedMergeRequests  lazy[[]scm.Dependency]
+	blockingMergeRequests lazy[[]scm.Dependency]
+	branchProtection      lazyMap[*BranchProtection]
+	commitSignatures      lazy[[]CommitSignature]
+	commits               lazy[[]scm.Commit]
+	dependencies          lazy[[]scm.Dependency]
+	diffContent           lazy[*scm.DiffContent]
+	discussions           lazy[[]scm.Discussion]
+	firstContribution     lazyMap[bool]
+	headSecurityReport    lazy[*SecurityReport]
+	headTestReport        lazy[*TestReport]
+	incidents             lazy[[]scm.Incident]
+	labelEvents           lazy[[]scm.LabelEvent]
+	mergeability          lazy[mergeability]
+	pipelineJobs          lazy[[]PipelineJob]
+	stateEvents           lazy[[]scm.StateEvent]
+	targetTestReport      lazy[*TestReport]
+	variables             lazy[map[string]string]
+}
+
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{})
+}
+
+// loadersFromContext returns the loaders of the evaluation, or an error naming the [data] that can't be loaded without them
+func loadersFromContext(ctx context.Context, data string) (*loaders, error) {
+	loaders, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		return nil, fmt.Errorf("%w: can't load the %s", state.ErrMissingContext, data)
+	}
+
+	return loaders, nil
+}
+
+// lazy is a value fetched the first time it's needed. Errors are remembered too, so a failing
+// (or rate limited) API isn't asked again by every script
+type lazy[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+// get returns the value, calling [fetch] with [ctx] the first time
+func (l *lazy[T]) get(ctx context.Context, fetch func(ctx context.Context) (T, error)) (T, error) {
+	l.once.Do(func() {
+		l.value, l.err = fetch(ctx)
+	})
+
+	return l.value, l.err
+}
+
+// lazyMap is a [lazy] value per key, for data that depends on the script function arguments (like a username)
+type lazyMap[T any] struct {
+	mu     sync.Mutex
+	values map[string]*lazy[T]
+}
+
+// get returns the value for [key], calling [fetch] with [ctx] the first time
+func (m *lazyMap[T]) get(ctx context.Context, key string, fetch func(ctx context.Context) (T, error)) (T, error) {
+	m.mu.Lock()
+
+	if m.values == nil {
+		m.values = map[string]*lazy[T]{}
+	}
+
+	value, ok := m.values[key]
+	if !ok {
+		value = &lazy[T]{}
+		m.values[key] = value
+	}
+
+	m.mu.Unlock()
+
+	return value.get(ctx, fetch)
+}
@@ -0,0 +1,117 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// loadAccessLevel returns the (cached) access level of [username] in the project or group [id], or 0 for non-members
+func loadAccessLevel(ctx context.Context, kind, id, username string) (int, error) {
+	loaders, err := loadersFromContext(ctx, "membership")
+	if err != nil {
+		return 0, err
+	}
+
+	return loaders.accessLevels.get(ctx, kind+":"+id+":"+strings.ToLower(username), func(ctx context.Context) (int, error) {
+		return fetchAccessLevel(ctx, kind, id, username)
+	})
+}
+
+// fetchAccessLevel searches the members (including inherited members) of the project or group [id] for [username]
+func fetchAccessLevel(ctx context.Context, kind, id, username string) (int, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	slogctx.Debug(ctx, "Loading membership", slog.String("member_of", kind+" "+id), slog.String("username", username))
+
+	listOptions := go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}
+
+	for {
+		var (
+			members []memberAccess
+			resp    *go_gitlab.Response
+		)
+
+		switch kind {
+		case "project":
+			var page []*go_gitlab.ProjectMember
+
+			page, resp, err = client.ProjectMembers.ListAllProjectMembers(id, &go_gitlab.ListProjectMembersOptions{ListOptions: listOptions, Query: &username}, go_gitlab.WithContext(ctx))
+			for _, member := range page {
+				members = append(members, memberAccess{member.Username, member.AccessLevel})
+			}
+
+		default:
+			var page []*go_gitlab.GroupMember
+
+			page, resp, err = client.Groups.ListAllGroupMembers(id, &go_gitlab.ListGroupMembersOptions{ListOptions: listOptions, Query: &username}, go_gitlab.WithContext(ctx))
+			for _, member := range page {
+				members = append(members, memberAccess{member.Username, member.AccessLevel})
+			}
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("could not load members of %s %q: %w", kind, id, err)
+		}
+
+		// The query is a search, so only an exact (case-insensitive) username match counts
+		for _, member := range members {
+			if strings.EqualFold(member.username, username) {
+				return int(member.level), nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return 0, nil
+		}
+
+		listOptions.Page = resp.NextPage
+	}
+}
+
+type memberAccess struct {
+	username string
+	level    go_gitlab.AccessLevelValue
+}
+
+// member_access_level
+func (e ContextMergeRequest) MemberAccessLevel(ctx context.Context, username string) int {
+	level, err := loadAccessLevel(ctx, "project", state.ProjectID(ctx), username)
+	if err != nil {
+		panic(err)
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.member_access_level"),
+		withInput(username),
+		slog.Int("function_result", level),
+	)
+
+	return level
+}
+
+// is_member_of
+func (e ContextMergeRequest) IsMemberOf(ctx context.Context, group, username string) bool {
+	level, err := loadAccessLevel(ctx, "group", group, username)
+	if err != nil {
+		panic(err)
+	}
+
+	val := level > 0
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.is_member_of"),
+		withInput([]string{group, username}),
+		withResult(val),
+	)
+
+	return val
+}
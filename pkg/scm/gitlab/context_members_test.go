@@ -0,0 +1,90 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_Membership(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path+"?query="+r.URL.Query().Get("query")]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/jippi%2Fscm-engine/members/all":
+			// The query is a search, so it may return other users with a similar username
+			w.Write([]byte(`[{"username": "alice-bot", "access_level": 50}, {"username": "alice", "access_level": 40}]`))
+
+		case "/api/v4/groups/jippi%2Fsecurity/members/all":
+			if r.URL.Query().Get("query") == "alice" {
+				w.Write([]byte(`[{"username": "alice", "access_level": 30}]`))
+
+				return
+			}
+
+			w.Write([]byte(`[]`))
+
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+	evalContext.SetContext(ctx)
+
+	tests := []struct {
+		script string
+		want   any
+	}{
+		{script: `merge_request.member_access_level("alice") >= 40`, want: true},
+		{script: `merge_request.member_access_level("carol")`, want: 0},
+		{script: `merge_request.is_member_of("jippi/security", "alice")`, want: true},
+		{script: `merge_request.is_member_of("jippi/security", "carol")`, want: false},
+	}
+
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		// Run each script twice, the second run must be served from the cache
+		for range 2 {
+			output, err := expr.Run(program, evalContext)
+			require.NoError(t, err, tt.script)
+			require.Equal(t, tt.want, output, tt.script)
+		}
+	}
+
+	require.Equal(t, map[string]int{
+		"/api/v4/projects/jippi/scm-engine/members/all?query=alice": 1,
+		"/api/v4/projects/jippi/scm-engine/members/all?query=carol": 1,
+		"/api/v4/groups/jippi/security/members/all?query=alice":     1,
+		"/api/v4/groups/jippi/security/members/all?query=carol":     1,
+	}, requests)
+}
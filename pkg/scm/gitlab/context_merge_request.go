@@ -270,6 +270,58 @@ func (e ContextMergeRequest) ModifiedFiles(patterns ...string) bool {
 	return len(e.findModifiedFiles(patterns...)) > 0
 }
 
+// DescriptionCheckboxChecked checks if the task list item [label] in the Merge Request description is checked
+func (e ContextMergeRequest) DescriptionCheckboxChecked(label string) bool {
+	return scm.DescriptionCheckboxChecked(e.description(), label)
+}
+
+// DescriptionSection returns the content of the section below [heading] in the Merge Request description
+func (e ContextMergeRequest) DescriptionSection(heading string) string {
+	return scm.DescriptionSection(e.description(), heading)
+}
+
+// ChangedLines returns the number of added and deleted lines, except for files excluded by the 'size_buckets' configuration
+func (e ContextMergeRequest) ChangedLines(ctx context.Context) int {
+	val := scm.SizeBucketsFromContext(ctx).ChangedLines(e.fileChanges())
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.changed_lines"),
+		slog.Int("function_result", val),
+	)
+
+	return val
+}
+
+// SizeBucket returns the name of the 'size_buckets' bucket that fits the number of [ChangedLines]
+func (e ContextMergeRequest) SizeBucket(ctx context.Context) string {
+	sizeBuckets := scm.SizeBucketsFromContext(ctx)
+	val := sizeBuckets.Bucket(sizeBuckets.ChangedLines(e.fileChanges()))
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.size_bucket"),
+		slog.String("function_result", val),
+	)
+
+	return val
+}
+
+func (e ContextMergeRequest) fileChanges() []scm.FileChanges {
+	changes := make([]scm.FileChanges, 0, len(e.DiffStats))
+	for _, f := range e.DiffStats {
+		changes = append(changes, scm.FileChanges{Path: f.Path, Additions: f.Additions, Deletions: f.Deletions})
+	}
+
+	return changes
+}
+
+func (e ContextMergeRequest) description() string {
+	if e.Description == nil {
+		return ""
+	}
+
+	return *e.Description
+}
+
 func (e ContextMergeRequest) findModifiedFiles(patterns ...string) []string {
 	files := []string{}
 	for _, f := range e.DiffStats {
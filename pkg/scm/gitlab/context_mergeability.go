@@ -0,0 +1,147 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+const (
+	// How often the mergeability is refreshed while GitLab is still computing it, backing off exponentially
+	mergeabilityMaxRefreshes    = 3
+	mergeabilityRefreshMinDelay = 500 * time.Millisecond
+)
+
+// mergeabilityTransientStatuses are the 'detailed_merge_status' values of a mergeability GitLab is still computing
+var mergeabilityTransientStatuses = []string{"unchecked", "checking", "preparing", "approvals_syncing"}
+
+// mergeability is whether the Merge Request can be merged into its target branch
+type mergeability struct {
+	status    string // The lowercase 'detailed_merge_status' (example: 'mergeable' or 'conflict')
+	conflicts bool
+	diverged  bool
+}
+
+// loadMergeability refreshes the mergeability of [e] the first time a script needs it,
+// if the evaluated data has a status GitLab was still computing
+func loadMergeability(ctx context.Context, e ContextMergeRequest) (mergeability, error) {
+	loaders, err := loadersFromContext(ctx, "merge request mergeability")
+	if err != nil {
+		return mergeability{}, err
+	}
+
+	return loaders.mergeability.get(ctx, func(ctx context.Context) (mergeability, error) {
+		return refreshMergeability(ctx, e)
+	})
+}
+
+// refreshMergeability returns the mergeability of [e], re-reading it (a bounded number of times) while GitLab is still
+// computing it. The last known mergeability is returned if GitLab didn't finish in time
+func refreshMergeability(ctx context.Context, e ContextMergeRequest) (mergeability, error) {
+	result := mergeability{conflicts: e.Conflicts, diverged: e.DivergedFromTargetBranch}
+	if e.DetailedMergeStatus != nil {
+		result.status = strings.ToLower(e.DetailedMergeStatus.AsString())
+	}
+
+	if !slices.Contains(mergeabilityTransientStatuses, result.status) {
+		return result, nil
+	}
+
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	delay := mergeabilityRefreshMinDelay
+
+	for range mergeabilityMaxRefreshes {
+		slogctx.Debug(ctx, "Waiting for GitLab to check the Merge Request mergeability", slog.String("merge_status", result.status), slog.Duration("backoff", delay))
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+
+		case <-time.After(delay):
+		}
+
+		mergeRequest, _, err := client.MergeRequests.GetMergeRequest(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), &go_gitlab.GetMergeRequestsOptions{
+			IncludeDivergedCommitsCount: scm.Ptr(true),
+		}, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return result, fmt.Errorf("could not read the merge request mergeability: %w", err)
+		}
+
+		result = mergeability{
+			status:    mergeRequest.DetailedMergeStatus,
+			conflicts: mergeRequest.HasConflicts,
+			diverged:  mergeRequest.DivergedCommitsCount > 0,
+		}
+
+		if !slices.Contains(mergeabilityTransientStatuses, result.status) {
+			return result, nil
+		}
+
+		delay *= 2
+	}
+
+	slogctx.Warn(ctx, "GitLab is still checking the Merge Request mergeability, using the last known status", slog.String("merge_status", result.status))
+
+	return result, nil
+}
+
+// merge_status
+func (e ContextMergeRequest) MergeStatus(ctx context.Context) string {
+	result, err := loadMergeability(ctx, e)
+	if err != nil {
+		panic(err)
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.merge_status"),
+		slog.String("function_result", result.status),
+	)
+
+	return result.status
+}
+
+// has_conflicts
+func (e ContextMergeRequest) HasConflicts(ctx context.Context) bool {
+	result, err := loadMergeability(ctx, e)
+	if err != nil {
+		panic(err)
+	}
+
+	val := result.conflicts || result.status == "conflict"
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.has_conflicts"),
+		withResult(val),
+	)
+
+	return val
+}
+
+// needs_rebase
+func (e ContextMergeRequest) NeedsRebase(ctx context.Context) bool {
+	result, err := loadMergeability(ctx, e)
+	if err != nil {
+		panic(err)
+	}
+
+	val := result.diverged || result.status == "need_rebase"
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.needs_rebase"),
+		withResult(val),
+	)
+
+	return val
+}
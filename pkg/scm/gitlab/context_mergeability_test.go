@@ -0,0 +1,115 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_Mergeability(t *testing.T) {
+	t.Parallel()
+
+	const (
+		checking = `{"iid": 1, "detailed_merge_status": "checking", "has_conflicts": false, "diverged_commits_count": 0}`
+		conflict = `{"iid": 1, "detailed_merge_status": "conflict", "has_conflicts": true, "diverged_commits_count": 3}`
+	)
+
+	tests := []struct {
+		name         string
+		status       gitlab.DetailedMergeStatus
+		conflicts    bool
+		diverged     bool
+		responses    []string // The REST API responses while refreshing a transient status, the last one is repeated
+		script       string
+		want         any
+		wantRequests int32
+	}{
+		{name: "clean", status: gitlab.DetailedMergeStatusMergeable, script: `merge_request.merge_status()`, want: "mergeable"},
+		{name: "clean has no conflicts", status: gitlab.DetailedMergeStatusMergeable, script: `merge_request.has_conflicts()`, want: false},
+		{name: "clean doesn't need a rebase", status: gitlab.DetailedMergeStatusMergeable, script: `merge_request.needs_rebase()`, want: false},
+		{name: "conflicting", status: gitlab.DetailedMergeStatusConflict, conflicts: true, script: `merge_request.has_conflicts()`, want: true},
+		{name: "conflicting status", status: gitlab.DetailedMergeStatusConflict, conflicts: true, script: `merge_request.merge_status()`, want: "conflict"},
+		{name: "behind the target branch", status: gitlab.DetailedMergeStatusMergeable, diverged: true, script: `merge_request.needs_rebase()`, want: true},
+		{name: "fast-forward merge needs a rebase", status: gitlab.DetailedMergeStatusNeedRebase, script: `merge_request.needs_rebase()`, want: true},
+		{
+			name:         "checking is refreshed",
+			status:       gitlab.DetailedMergeStatusChecking,
+			responses:    []string{conflict},
+			script:       `[merge_request.merge_status(), merge_request.has_conflicts(), merge_request.needs_rebase()]`,
+			want:         []any{"conflict", true, true},
+			wantRequests: 1,
+		},
+		{
+			name:         "checking is refreshed until GitLab is done",
+			status:       gitlab.DetailedMergeStatusUnchecked,
+			responses:    []string{checking, conflict},
+			script:       `merge_request.has_conflicts()`,
+			want:         true,
+			wantRequests: 2,
+		},
+		{
+			name:         "still checking after the retries",
+			status:       gitlab.DetailedMergeStatusChecking,
+			responses:    []string{checking},
+			script:       `merge_request.merge_status()`,
+			want:         "checking",
+			wantRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := &atomic.Int32{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/api/v4/projects/jippi/scm-engine/merge_requests/1", r.URL.Path)
+				require.Equal(t, "true", r.URL.Query().Get("include_diverged_commits_count"))
+
+				request := int(requests.Add(1))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.responses[min(request, len(tt.responses))-1]))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithBaseURL(ctx, server.URL)
+			ctx = state.WithToken(ctx, "token")
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+
+			evalContext := &gitlab.Context{
+				MergeRequest: &gitlab.ContextMergeRequest{
+					DetailedMergeStatus:      scm.Ptr(tt.status),
+					Conflicts:                tt.conflicts,
+					DivergedFromTargetBranch: tt.diverged,
+				},
+			}
+			evalContext.SetContext(ctx)
+
+			program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+			require.NoError(t, err)
+
+			// The mergeability is only refreshed once per evaluation
+			for range 2 {
+				output, err := expr.Run(program, evalContext)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, output)
+			}
+
+			require.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
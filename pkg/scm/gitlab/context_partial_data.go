@@ -0,0 +1,61 @@
+package gitlab
+
+import (
+	"errors"
+
+	"github.com/hasura/go-graphql-client"
+)
+
+// optionalMergeRequestFields maps the GraphQL Merge Request fields the evaluation can do without
+// to the script attributes (and methods) depending on them
+var optionalMergeRequestFields = map[string][]string{
+	"approvalsLeft":     {"merge_request.approvals_left"},
+	"approvalsRequired": {"merge_request.approvals_required"},
+	"diffStats":         {"merge_request.diff_stats", "merge_request.modified_files", "merge_request.modified_files_list", "merge_request.changed_lines", "merge_request.size_bucket"},
+}
+
+// missingOptionalData returns the script attributes that could not be loaded, and why.
+//
+// Returns false if [err] isn't exclusively about optional Merge Request fields (see [optionalMergeRequestFields])
+func missingOptionalData(err error) (map[string]any, bool) {
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		return nil, false
+	}
+
+	missing := map[string]any{}
+
+	for _, graphqlErr := range errs {
+		if len(graphqlErr.Path) < 3 || graphqlErr.Path[0] != "project" || graphqlErr.Path[1] != "mergeRequest" {
+			return nil, false
+		}
+
+		field, _ := graphqlErr.Path[2].(string)
+
+		attributes, ok := optionalMergeRequestFields[field]
+		if !ok {
+			return nil, false
+		}
+
+		for _, attribute := range attributes {
+			missing[attribute] = graphqlErr.Message
+		}
+	}
+
+	return missing, len(missing) > 0
+}
+
+// UnavailableData returns the script attributes that could not be loaded, and why
+func (c *Context) UnavailableData() map[string]string {
+	if len(c.MissingData) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(c.MissingData))
+
+	for attribute, reason := range c.MissingData {
+		result[attribute], _ = reason.(string)
+	}
+
+	return result
+}
@@ -0,0 +1,85 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContext_PartialData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		allowPartialData bool
+		errorPath        string
+		wantErr          string
+		wantUnavailable  map[string]string
+	}{
+		{
+			name: "all data loaded",
+		},
+		{
+			name:      "optional data fails without allow-partial-data",
+			errorPath: `["project", "mergeRequest", "diffStats"]`,
+			wantErr:   "Message: permission denied",
+		},
+		{
+			name:             "optional data fails with allow-partial-data",
+			allowPartialData: true,
+			errorPath:        `["project", "mergeRequest", "diffStats"]`,
+			wantUnavailable: map[string]string{
+				"merge_request.changed_lines":       "permission denied",
+				"merge_request.diff_stats":          "permission denied",
+				"merge_request.modified_files":      "permission denied",
+				"merge_request.modified_files_list": "permission denied",
+				"merge_request.size_bucket":         "permission denied",
+			},
+		},
+		{
+			name:             "required data fails with allow-partial-data",
+			allowPartialData: true,
+			errorPath:        `["project", "mergeRequest", "author"]`,
+			wantErr:          "Message: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response := strings.Replace(mergeRequestResponse, "%s", `"targetProjectId": 1, "sourceProjectId": 1, "diffStats": null`, 1)
+
+				if len(tt.errorPath) > 0 {
+					response = strings.TrimSuffix(response, "}") + `, "errors": [{"message": "permission denied", "path": ` + tt.errorPath + `}]}`
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(response))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := context.Background()
+			ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+			ctx = state.WithMergeRequestID(ctx, "1")
+			ctx = state.WithAllowPartialData(ctx, tt.allowPartialData)
+
+			evalContext, err := gitlab.NewContext(ctx, server.URL, "token")
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantUnavailable, evalContext.UnavailableData())
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// PipelineJob is a job in the head pipeline of the Merge Request, as exposed to scripts
+type PipelineJob struct {
+	// Name of the job (example: 'security-scan')
+	Name string `expr:"name"`
+	// Stage of the job (example: 'test')
+	Stage string `expr:"stage"`
+	// Status of the job (example: 'success', 'failed', 'skipped', 'manual' or 'running')
+	Status string `expr:"status"`
+	// Whether the job is allowed to fail without failing the pipeline
+	AllowFailure bool `expr:"allow_failure"`
+	// URL of the job
+	WebURL string `expr:"web_url"`
+	// The artifacts the job uploaded
+	Artifacts []PipelineJobArtifact `expr:"artifacts"`
+}
+
+// PipelineJobArtifact is the metadata of an artifact uploaded by a job, as exposed to scripts
+type PipelineJobArtifact struct {
+	// Type of the artifact (example: 'archive', 'junit' or 'sast')
+	FileType string `expr:"file_type"`
+	// Filename of the artifact (example: 'gl-sast-report.json')
+	Filename string `expr:"filename"`
+	// Size of the artifact in bytes
+	Size int `expr:"size"`
+}
+
+func loadPipelineJobs(ctx context.Context, pipeline *ContextPipeline) ([]PipelineJob, error) {
+	loaders, err := loadersFromContext(ctx, "pipeline jobs")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.pipelineJobs.get(ctx, func(ctx context.Context) ([]PipelineJob, error) {
+		return fetchPipelineJobs(ctx, pipeline)
+	})
+}
+
+// fetchPipelineJobs reads the (latest attempt of the) jobs in the [pipeline], a Merge Request without a pipeline has no jobs
+func fetchPipelineJobs(ctx context.Context, pipeline *ContextPipeline) ([]PipelineJob, error) {
+	jobs := []PipelineJob{}
+
+	if pipeline == nil {
+		return jobs, nil
+	}
+
+	pipelineID, err := parsePipelineID(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading head pipeline jobs", slog.Int("pipeline_id", pipelineID))
+
+	options := &go_gitlab.ListJobsOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+
+	for {
+		page, resp, err := client.Jobs.ListPipelineJobs(state.ProjectID(ctx), pipelineID, options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load the jobs of pipeline %d: %w", pipelineID, err)
+		}
+
+		for _, job := range page {
+			artifacts := []PipelineJobArtifact{}
+			for _, artifact := range job.Artifacts {
+				artifacts = append(artifacts, PipelineJobArtifact{FileType: artifact.FileType, Filename: artifact.Filename, Size: artifact.Size})
+			}
+
+			jobs = append(jobs, PipelineJob{
+				Name:         job.Name,
+				Stage:        job.Stage,
+				Status:       job.Status,
+				AllowFailure: job.AllowFailure,
+				WebURL:       job.WebURL,
+				Artifacts:    artifacts,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	return jobs, nil
+}
+
+// head_pipeline_jobs
+func (e ContextMergeRequest) HeadPipelineJobs(ctx context.Context) []PipelineJob {
+	jobs, err := loadPipelineJobs(ctx, e.HeadPipeline)
+	if err != nil {
+		panic(err)
+	}
+
+	return jobs
+}
+
+// job_status
+func (e ContextMergeRequest) JobStatus(ctx context.Context, name string) string {
+	if len(name) == 0 {
+		panic(errors.New("job_status: the job name must not be empty"))
+	}
+
+	val := ""
+
+	for _, job := range e.HeadPipelineJobs(ctx) {
+		if job.Name == name {
+			val = job.Status
+
+			break
+		}
+	}
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.job_status"),
+		slog.String("job_name", name),
+		slog.String("result", val),
+	)
+
+	return val
+}
@@ -0,0 +1,115 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_HeadPipelineJobs(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, pipeline *gitlab.ContextPipeline) (*gitlab.Context, *atomic.Int32) {
+		t.Helper()
+
+		requests := &atomic.Int32{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v4/projects/jippi%2Fscm-engine/pipelines/123/jobs", r.URL.EscapedPath())
+
+			requests.Add(1)
+
+			w.Header().Set("Content-Type", "application/json")
+
+			// The jobs are split across two pages
+			if r.URL.Query().Get("page") != "2" {
+				w.Header().Set("X-Next-Page", "2")
+				w.Write([]byte(`[
+					{"name": "build", "stage": "build", "status": "success", "web_url": "https://gitlab.example.com/jobs/1"},
+					{"name": "lint", "stage": "test", "status": "failed", "allow_failure": true}
+				]`))
+
+				return
+			}
+
+			w.Write([]byte(`[
+				{"name": "security-scan", "stage": "test", "status": "success", "artifacts": [
+					{"file_type": "archive", "filename": "artifacts.zip", "size": 1024},
+					{"file_type": "sast", "filename": "gl-sast-report.json", "size": 512}
+				]}
+			]`))
+		}))
+		t.Cleanup(server.Close)
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+		ctx = state.WithMergeRequestID(ctx, "1")
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{HeadPipeline: pipeline}}
+		evalContext.SetContext(ctx)
+
+		return evalContext, requests
+	}
+
+	run := func(t *testing.T, evalContext *gitlab.Context, script string) any {
+		t.Helper()
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, script)
+
+		return output
+	}
+
+	t.Run("jobs of the head pipeline", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext, requests := setup(t, &gitlab.ContextPipeline{ID: "gid://gitlab/Ci::Pipeline/123"})
+
+		tests := []struct {
+			script string
+			want   any
+		}{
+			{script: `merge_request.job_status("security-scan")`, want: "success"},
+			{script: `merge_request.job_status("lint")`, want: "failed"},
+			{script: `merge_request.head_pipeline_jobs() | map(.name)`, want: []any{"build", "lint", "security-scan"}},
+			{script: `merge_request.head_pipeline_jobs() | filter(.allow_failure) | map(.name)`, want: []any{"lint"}},
+			{script: `any(merge_request.head_pipeline_jobs(), { any(.artifacts, .file_type == "sast") })`, want: true},
+			{script: `merge_request.head_pipeline_jobs()[0].web_url`, want: "https://gitlab.example.com/jobs/1"},
+
+			// Pipelines without the named job
+			{script: `merge_request.job_status("deploy")`, want: ""},
+			{script: `merge_request.job_status("deploy") in ["", "success"]`, want: true},
+		}
+
+		for _, tt := range tests {
+			require.Equal(t, tt.want, run(t, evalContext, tt.script), tt.script)
+		}
+
+		// Both pages are loaded once per evaluation
+		require.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("merge requests without a head pipeline", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext, requests := setup(t, nil)
+
+		require.Equal(t, "", run(t, evalContext, `merge_request.job_status("security-scan")`))
+		require.Equal(t, 0, run(t, evalContext, `len(merge_request.head_pipeline_jobs())`))
+		require.Equal(t, int32(0), requests.Load())
+	})
+}
@@ -0,0 +1,150 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hasura/go-graphql-client"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// SecurityReport is the number of vulnerabilities by severity found by the SAST and dependency scanning
+// jobs of a pipeline, as exposed to scripts
+type SecurityReport struct {
+	// Number of vulnerabilities
+	Total int `expr:"total"`
+	// Number of critical vulnerabilities
+	Critical int `expr:"critical"`
+	// Number of high severity vulnerabilities
+	High int `expr:"high"`
+	// Number of medium severity vulnerabilities
+	Medium int `expr:"medium"`
+	// Number of low severity vulnerabilities
+	Low int `expr:"low"`
+	// Number of informational findings
+	Info int `expr:"info"`
+	// Number of vulnerabilities with an unknown severity
+	Unknown int `expr:"unknown"`
+}
+
+func loadHeadSecurityReport(ctx context.Context, pipeline *ContextPipeline) (*SecurityReport, error) {
+	loaders, err := loadersFromContext(ctx, "security reports")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.headSecurityReport.get(ctx, func(ctx context.Context) (*SecurityReport, error) {
+		if pipeline == nil {
+			return nil, nil //nolint:nilnil
+		}
+
+		return fetchSecurityReport(ctx, pipeline.ID)
+	})
+}
+
+// fetchSecurityReport counts the SAST and dependency scanning findings of the pipeline by severity.
+//
+// Pipelines without a SAST or dependency scanning job have no report (nil), and so do GitLab editions without
+// security reports, since their GraphQL schema doesn't have the fields
+func fetchSecurityReport(ctx context.Context, pipelineID string) (*SecurityReport, error) {
+	apiClient, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := graphql.NewClient(graphqlBaseURL(apiClient.BaseURL())+"/api/graphql", newGraphQLHTTPClient(ctx, state.Token(ctx)))
+
+	slogctx.Debug(ctx, "Loading pipeline security report", slog.String("pipeline_id", pipelineID))
+
+	var (
+		report = &SecurityReport{}
+		cursor *string
+	)
+
+	for {
+		var (
+			result    SecurityReportResult
+			variables = map[string]any{
+				"project_id":  graphql.ID(state.ProjectID(ctx)),
+				"pipeline_id": CiPipelineID(pipelineID),
+				"cursor":      cursor,
+			}
+		)
+
+		if err := client.Query(ctx, &result, variables); err != nil {
+			if strings.Contains(err.Error(), "doesn't exist on type") {
+				slogctx.Debug(ctx, "Security reports are not available in this GitLab edition", slog.Any("error", err))
+
+				return nil, nil //nolint:nilnil
+			}
+
+			return nil, fmt.Errorf("could not load the security report of pipeline %q: %w", pipelineID, err)
+		}
+
+		if result.Project == nil || result.Project.Pipeline == nil {
+			return nil, nil //nolint:nilnil
+		}
+
+		pipeline := result.Project.Pipeline
+
+		// Scanners that didn't run in the pipeline have no summary
+		if cursor == nil && (pipeline.Summary == nil || (pipeline.Summary.Sast == nil && pipeline.Summary.DependencyScanning == nil)) {
+			return nil, nil //nolint:nilnil
+		}
+
+		if pipeline.Findings == nil {
+			break
+		}
+
+		for _, finding := range pipeline.Findings.Nodes {
+			report.add(finding.Severity)
+		}
+
+		if !pipeline.Findings.PageInfo.HasNextPage {
+			break
+		}
+
+		cursor = &pipeline.Findings.PageInfo.EndCursor
+	}
+
+	slogctx.Debug(ctx, "Loaded pipeline security report", slog.Int("number_of_vulnerabilities", report.Total))
+
+	return report, nil
+}
+
+func (report *SecurityReport) add(severity string) {
+	report.Total++
+
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		report.Critical++
+
+	case "HIGH":
+		report.High++
+
+	case "MEDIUM":
+		report.Medium++
+
+	case "LOW":
+		report.Low++
+
+	case "INFO":
+		report.Info++
+
+	default:
+		report.Unknown++
+	}
+}
+
+// head_pipeline_security_report
+func (e ContextMergeRequest) HeadPipelineSecurityReport(ctx context.Context) *SecurityReport {
+	report, err := loadHeadSecurityReport(ctx, e.HeadPipeline)
+	if err != nil {
+		panic(err)
+	}
+
+	return report
+}
@@ -0,0 +1,138 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_HeadPipelineSecurityReport(t *testing.T) {
+	t.Parallel()
+
+	scanned := map[string]any{"sast": map[string]any{"vulnerabilitiesCount": 3}, "dependencyScanning": map[string]any{"vulnerabilitiesCount": 1}}
+
+	// Findings are returned in two pages
+	pages := map[string]any{
+		"": map[string]any{
+			"nodes":    []any{map[string]any{"severity": "CRITICAL"}, map[string]any{"severity": "HIGH"}},
+			"pageInfo": map[string]any{"hasNextPage": true, "endCursor": "page-2"},
+		},
+		"page-2": map[string]any{
+			"nodes":    []any{map[string]any{"severity": "HIGH"}, map[string]any{"severity": "LOW"}},
+			"pageInfo": map[string]any{"hasNextPage": false, "endCursor": "page-3"},
+		},
+	}
+
+	setup := func(t *testing.T, pipeline *gitlab.ContextPipeline, handler func(cursor string) any) *gitlab.Context {
+		t.Helper()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/graphql", r.URL.Path)
+
+			var request struct {
+				Query     string         `json:"query"`
+				Variables map[string]any `json:"variables"`
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			require.Contains(t, request.Query, "$pipeline_id:CiPipelineID!")
+			require.Equal(t, "jippi/scm-engine", request.Variables["project_id"])
+			require.Equal(t, "gid://gitlab/Ci::Pipeline/123", request.Variables["pipeline_id"])
+
+			cursor, _ := request.Variables["cursor"].(string)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(mustJSON(t, handler(cursor))))
+		}))
+		t.Cleanup(server.Close)
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+		ctx = state.WithMergeRequestID(ctx, "1")
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{HeadPipeline: pipeline}}
+		evalContext.SetContext(ctx)
+
+		return evalContext
+	}
+
+	run := func(t *testing.T, evalContext *gitlab.Context, script string) any {
+		t.Helper()
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, script)
+
+		return output
+	}
+
+	pipeline := &gitlab.ContextPipeline{ID: "gid://gitlab/Ci::Pipeline/123"}
+
+	t.Run("counts the findings by severity", func(t *testing.T) {
+		t.Parallel()
+
+		requests := 0
+
+		evalContext := setup(t, pipeline, func(cursor string) any {
+			requests++
+
+			return map[string]any{"data": map[string]any{"project": map[string]any{"pipeline": map[string]any{
+				"securityReportSummary":  scanned,
+				"securityReportFindings": pages[cursor],
+			}}}}
+		})
+
+		require.Equal(t, &gitlab.SecurityReport{Total: 4, Critical: 1, High: 2, Low: 1}, run(t, evalContext, `merge_request.head_pipeline_security_report()`))
+		require.Equal(t, true, run(t, evalContext, `(merge_request.head_pipeline_security_report()?.critical ?? 0) > 0`))
+		require.Equal(t, 2, requests, "the report must only be loaded once")
+	})
+
+	t.Run("pipeline without security scanning", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, pipeline, func(string) any {
+			return map[string]any{"data": map[string]any{"project": map[string]any{"pipeline": map[string]any{
+				"securityReportSummary":  map[string]any{"sast": nil, "dependencyScanning": nil},
+				"securityReportFindings": map[string]any{"nodes": []any{}, "pageInfo": map[string]any{"hasNextPage": false}},
+			}}}}
+		})
+
+		require.Nil(t, run(t, evalContext, `merge_request.head_pipeline_security_report()`))
+		require.Equal(t, false, run(t, evalContext, `(merge_request.head_pipeline_security_report()?.critical ?? 0) > 0`))
+	})
+
+	t.Run("GitLab edition without security reports", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, pipeline, func(string) any {
+			return map[string]any{"errors": []any{map[string]any{"message": "Field 'securityReportSummary' doesn't exist on type 'Pipeline'"}}}
+		})
+
+		require.Nil(t, run(t, evalContext, `merge_request.head_pipeline_security_report()`))
+	})
+
+	t.Run("Merge Request without pipeline", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, nil, func(string) any {
+			t.Error("unexpected request")
+
+			return nil
+		})
+
+		require.Nil(t, run(t, evalContext, `merge_request.head_pipeline_security_report()`))
+	})
+}
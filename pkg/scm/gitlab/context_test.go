@@ -0,0 +1,119 @@
+package gitlab_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_SetWebhookEvent_WebhookAction(t *testing.T) {
+	t.Parallel()
+
+	for _, action := range []string{"open", "close", "reopen", "update", "approved", "unapproved", "approval", "unapproval", "merge"} {
+		t.Run(action, func(t *testing.T) {
+			t.Parallel()
+
+			evalContext := &gitlab.Context{}
+			evalContext.SetWebhookEvent(map[string]any{
+				"event_type": "merge_request",
+				"object_attributes": map[string]any{
+					"iid":    1,
+					"action": action,
+				},
+			})
+
+			require.Equal(t, action, evalContext.WebhookAction)
+
+			output, err := expr.Eval(`webhook_action == "`+action+`"`, evalContext)
+			require.NoError(t, err)
+			require.Equal(t, true, output)
+		})
+	}
+}
+
+func TestContext_SetWebhookEvent_WebhookActionOtherEvents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		event any
+	}{
+		{
+			name: "note event",
+			event: map[string]any{
+				"event_type":        "note",
+				"object_attributes": map[string]any{"action": "create"},
+			},
+		},
+		{
+			name:  "no webhook event",
+			event: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			evalContext := &gitlab.Context{}
+			evalContext.SetWebhookEvent(tt.event)
+
+			require.Empty(t, evalContext.WebhookAction)
+		})
+	}
+}
+
+func TestContext_ProjectClassification(t *testing.T) {
+	t.Parallel()
+
+	evalContext := &gitlab.Context{
+		Project: &gitlab.ContextProject{
+			Topics:     []string{"production", "payments"},
+			Visibility: "internal",
+		},
+	}
+
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{script: `"production" in project.topics`, want: true},
+		{script: `"staging" in project.topics`, want: false},
+		{script: `any(project.topics, # startsWith "pay")`, want: true},
+		{script: `project.visibility == "internal"`, want: true},
+		{script: `"production" in project.topics && project.visibility != "public"`, want: true},
+	}
+
+	for _, tt := range tests {
+		output, err := expr.Eval(tt.script, evalContext)
+		require.NoError(t, err, tt.script)
+		require.Equal(t, tt.want, output, tt.script)
+	}
+}
+
+func TestContext_SetContext_TriggerEvent(t *testing.T) {
+	t.Parallel()
+
+	for _, event := range []string{state.TriggerEventOpen, state.TriggerEventReopen, state.TriggerEventUpdate, state.TriggerEventNote, state.TriggerEventMerge} {
+		t.Run(event, func(t *testing.T) {
+			t.Parallel()
+
+			evalContext := &gitlab.Context{}
+			evalContext.SetContext(state.WithTriggerEvent(context.Background(), event))
+
+			output, err := expr.Eval(`trigger_event`, evalContext)
+			require.NoError(t, err)
+			require.Equal(t, event, output)
+		})
+	}
+
+	// Evaluations not triggered by a webhook event are updates
+	evalContext := &gitlab.Context{}
+	evalContext.SetContext(context.Background())
+
+	require.Equal(t, state.TriggerEventUpdate, evalContext.TriggerEvent)
+}
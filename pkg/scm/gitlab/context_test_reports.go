@@ -0,0 +1,187 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+// TestReport is the test report summary of a pipeline, as exposed to scripts
+type TestReport struct {
+	// Number of tests
+	Total int `expr:"total"`
+	// Number of tests that passed
+	Success int `expr:"success"`
+	// Number of tests that failed
+	Failed int `expr:"failed"`
+	// Number of tests that were skipped
+	Skipped int `expr:"skipped"`
+	// Number of tests that errored
+	Error int `expr:"error"`
+}
+
+func loadHeadTestReport(ctx context.Context, pipeline *ContextPipeline) (*TestReport, error) {
+	loaders, err := loadersFromContext(ctx, "test reports")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.headTestReport.get(ctx, func(ctx context.Context) (*TestReport, error) {
+		if pipeline == nil {
+			return nil, nil //nolint:nilnil
+		}
+
+		pipelineID, err := parsePipelineID(pipeline)
+		if err != nil {
+			return nil, err
+		}
+
+		return fetchTestReport(ctx, pipelineID)
+	})
+}
+
+// loadTargetBranchTestReport returns the test report of [branch], loaded separately since it's only needed for the delta
+func loadTargetBranchTestReport(ctx context.Context, branch string) (*TestReport, error) {
+	loaders, err := loadersFromContext(ctx, "test reports")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.targetTestReport.get(ctx, func(ctx context.Context) (*TestReport, error) {
+		return fetchTargetBranchTestReport(ctx, branch)
+	})
+}
+
+// parsePipelineID returns the numeric ID of the [pipeline], the GraphQL ID looks like 'gid://gitlab/Ci::Pipeline/123'
+func parsePipelineID(pipeline *ContextPipeline) (int, error) {
+	pipelineID, err := strconv.Atoi(path.Base(pipeline.ID))
+	if err != nil {
+		return 0, fmt.Errorf("could not parse the pipeline ID %q: %w", pipeline.ID, err)
+	}
+
+	return pipelineID, nil
+}
+
+// fetchTargetBranchTestReport reads the test report summary of the latest successful pipeline on [branch]
+func fetchTargetBranchTestReport(ctx context.Context, branch string) (*TestReport, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines, _, err := client.Pipelines.ListProjectPipelines(state.ProjectID(ctx), &go_gitlab.ListProjectPipelinesOptions{
+		ListOptions: go_gitlab.ListOptions{PerPage: 1},
+		Ref:         go_gitlab.Ptr(branch),
+		Status:      go_gitlab.Ptr(go_gitlab.Success),
+		OrderBy:     go_gitlab.Ptr("id"),
+		Sort:        go_gitlab.Ptr("desc"),
+	}, go_gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not load the latest pipeline of branch %q: %w", branch, err)
+	}
+
+	if len(pipelines) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return fetchTestReport(ctx, pipelines[0].ID)
+}
+
+// fetchTestReport reads the test report summary of the pipeline, rather than the full report with every test case.
+//
+// Pipelines without a test report have no report (nil)
+func fetchTestReport(ctx context.Context, pipelineID int) (*TestReport, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := ParseID(state.ProjectID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading pipeline test report summary", slog.Int("pipeline_id", pipelineID))
+
+	endpoint := fmt.Sprintf("projects/%s/pipelines/%d/test_report_summary", go_gitlab.PathEscape(project), pipelineID)
+
+	req, err := client.NewRequest(http.MethodGet, endpoint, nil, []go_gitlab.RequestOptionFunc{go_gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Total struct {
+			Count   int `json:"count"`
+			Success int `json:"success"`
+			Failed  int `json:"failed"`
+			Skipped int `json:"skipped"`
+			Error   int `json:"error"`
+		} `json:"total"`
+		TestSuites []struct{} `json:"test_suites"`
+	}
+
+	if _, err := client.Do(req, &response); err != nil {
+		return nil, fmt.Errorf("could not load the test report of pipeline %d: %w", pipelineID, err)
+	}
+
+	if response.Total.Count == 0 && len(response.TestSuites) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &TestReport{
+		Total:   response.Total.Count,
+		Success: response.Total.Success,
+		Failed:  response.Total.Failed,
+		Skipped: response.Total.Skipped,
+		Error:   response.Total.Error,
+	}, nil
+}
+
+// head_pipeline_test_report
+func (e ContextMergeRequest) HeadPipelineTestReport(ctx context.Context) *TestReport {
+	report, err := loadHeadTestReport(ctx, e.HeadPipeline)
+	if err != nil {
+		panic(err)
+	}
+
+	return report
+}
+
+// target_branch_test_report
+func (e ContextMergeRequest) TargetBranchTestReport(ctx context.Context) *TestReport {
+	report, err := loadTargetBranchTestReport(ctx, e.TargetBranch)
+	if err != nil {
+		panic(err)
+	}
+
+	return report
+}
+
+// test_report_delta
+func (e ContextMergeRequest) TestReportDelta(ctx context.Context) *TestReport {
+	head := e.HeadPipelineTestReport(ctx)
+	if head == nil {
+		return nil
+	}
+
+	target := e.TargetBranchTestReport(ctx)
+	if target == nil {
+		return nil
+	}
+
+	return &TestReport{
+		Total:   head.Total - target.Total,
+		Success: head.Success - target.Success,
+		Failed:  head.Failed - target.Failed,
+		Skipped: head.Skipped - target.Skipped,
+		Error:   head.Error - target.Error,
+	}
+}
@@ -0,0 +1,125 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_TestReports(t *testing.T) {
+	t.Parallel()
+
+	const (
+		withReport    = `{"total": {"time": 12.5, "count": 120, "success": 115, "failed": 3, "skipped": 2, "error": 0}, "test_suites": [{"name": "unit"}]}`
+		withoutReport = `{"total": {"time": 0, "count": 0, "success": 0, "failed": 0, "skipped": 0, "error": 0}, "test_suites": []}`
+	)
+
+	setup := func(t *testing.T, head, target string) *gitlab.Context {
+		t.Helper()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			switch r.URL.EscapedPath() {
+			case "/api/v4/projects/jippi%2Fscm-engine/pipelines":
+				require.Equal(t, "main", r.URL.Query().Get("ref"))
+				require.Equal(t, "success", r.URL.Query().Get("status"))
+
+				w.Write([]byte(`[{"id": 100}]`))
+
+			case "/api/v4/projects/jippi%2Fscm-engine/pipelines/123/test_report_summary":
+				w.Write([]byte(head))
+
+			case "/api/v4/projects/jippi%2Fscm-engine/pipelines/100/test_report_summary":
+				w.Write([]byte(target))
+
+			default:
+				t.Errorf("unexpected request: %s %s", r.Method, r.URL.EscapedPath())
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		ctx := context.Background()
+		ctx = state.WithBaseURL(ctx, server.URL)
+		ctx = state.WithToken(ctx, "token")
+		ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+		ctx = state.WithMergeRequestID(ctx, "1")
+
+		evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{
+			TargetBranch: "main",
+			HeadPipeline: &gitlab.ContextPipeline{ID: "gid://gitlab/Ci::Pipeline/123"},
+		}}
+		evalContext.SetContext(ctx)
+
+		return evalContext
+	}
+
+	run := func(t *testing.T, evalContext *gitlab.Context, script string) any {
+		t.Helper()
+
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, script)
+
+		return output
+	}
+
+	t.Run("pipelines with a test report", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, withReport, `{"total": {"count": 125, "success": 124, "failed": 1, "skipped": 0, "error": 0}, "test_suites": [{"name": "unit"}]}`)
+
+		tests := []struct {
+			script string
+			want   any
+		}{
+			{script: `merge_request.head_pipeline_test_report().total`, want: 120},
+			{script: `merge_request.head_pipeline_test_report().failed`, want: 3},
+			{script: `merge_request.head_pipeline_test_report().skipped`, want: 2},
+			{script: `merge_request.target_branch_test_report().total`, want: 125},
+			{script: `merge_request.test_report_delta().total`, want: -5},
+			{script: `merge_request.test_report_delta().failed`, want: 2},
+			{script: `merge_request.test_report_delta()?.total < 0`, want: true},
+		}
+
+		for _, tt := range tests {
+			require.Equal(t, tt.want, run(t, evalContext, tt.script), tt.script)
+		}
+	})
+
+	t.Run("pipelines without a test report", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, withoutReport, withReport)
+
+		require.Equal(t, true, run(t, evalContext, `merge_request.head_pipeline_test_report() == nil`))
+		require.Equal(t, true, run(t, evalContext, `merge_request.test_report_delta() == nil`))
+		require.Equal(t, 120, run(t, evalContext, `merge_request.target_branch_test_report().total`))
+
+		// The head pipeline has a report, but the target branch doesn't
+		evalContext = setup(t, withReport, withoutReport)
+
+		require.Equal(t, 120, run(t, evalContext, `merge_request.head_pipeline_test_report().total`))
+		require.Equal(t, true, run(t, evalContext, `merge_request.test_report_delta() == nil`))
+	})
+
+	t.Run("merge requests without a head pipeline", func(t *testing.T) {
+		t.Parallel()
+
+		evalContext := setup(t, withReport, withReport)
+		evalContext.MergeRequest.HeadPipeline = nil
+
+		require.Equal(t, true, run(t, evalContext, `merge_request.head_pipeline_test_report() == nil`))
+		require.Equal(t, true, run(t, evalContext, `merge_request.test_report_delta() == nil`))
+	})
+}
@@ -0,0 +1,159 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+func loadLabelEvents(ctx context.Context) ([]scm.LabelEvent, error) {
+	loaders, err := loadersFromContext(ctx, "merge request events")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.labelEvents.get(ctx, func(ctx context.Context) ([]scm.LabelEvent, error) {
+		slogctx.Debug(ctx, "Loading Merge Request label events")
+
+		client, err := NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.MergeRequests().LabelEvents(ctx)
+	})
+}
+
+func loadStateEvents(ctx context.Context) ([]scm.StateEvent, error) {
+	loaders, err := loadersFromContext(ctx, "merge request events")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.stateEvents.get(ctx, fetchStateEvents)
+}
+
+func fetchStateEvents(ctx context.Context) ([]scm.StateEvent, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading Merge Request state events")
+
+	var (
+		events  []scm.StateEvent
+		options = &go_gitlab.ListStateEventsOptions{ListOptions: go_gitlab.ListOptions{PerPage: state.APIPageSize(ctx)}}
+	)
+
+	for {
+		page, resp, err := client.ResourceStateEvents.ListMergeStateEvents(state.ProjectID(ctx), state.MergeRequestIDInt(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load merge request state events: %w", err)
+		}
+
+		for _, event := range page {
+			if event.CreatedAt == nil {
+				continue
+			}
+
+			events = append(events, scm.StateEvent{State: string(event.State), CreatedAt: *event.CreatedAt})
+		}
+
+		if resp.NextPage == 0 {
+			return events, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+// time_since_last_activity
+func (e ContextMergeRequest) TimeSinceLastActivity(ctx context.Context) time.Duration {
+	timestamps := []time.Time{e.UpdatedAt}
+
+	if e.LastCommit != nil && e.LastCommit.CommittedDate != nil {
+		timestamps = append(timestamps, *e.LastCommit.CommittedDate)
+	}
+
+	// Use the same definition of activity as 'has_any_activity_within'
+	cfg := config.FromContext(ctx)
+
+	for _, note := range e.Notes {
+		if cfg.IgnoreActivityFrom.Matches(note.Author.ToActor()) {
+			continue
+		}
+
+		timestamps = append(timestamps, note.UpdatedAt)
+	}
+
+	val := scm.TimeSince(time.Now(), timestamps...)
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.time_since_last_activity"),
+		slog.Duration("function_result", val),
+	)
+
+	return val
+}
+
+// time_open
+func (e ContextMergeRequest) TimeOpen(ctx context.Context) time.Duration {
+	var events []scm.StateEvent
+
+	// Only open Merge Requests need to know when they were last reopened
+	if e.State == "opened" {
+		var err error
+
+		events, err = loadStateEvents(ctx)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	val := scm.TimeOpen(e.State, e.CreatedAt, events, time.Now())
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.time_open"),
+		slog.Duration("function_result", val),
+	)
+
+	return val
+}
+
+// time_in_review
+func (e ContextMergeRequest) TimeInReview(ctx context.Context, label string) time.Duration {
+	present := slices.ContainsFunc(e.Labels, func(l ContextLabel) bool {
+		return l.Title == label
+	})
+
+	var events []scm.LabelEvent
+
+	// Label events are only needed while the Merge Request is in review
+	if present {
+		var err error
+
+		events, err = loadLabelEvents(ctx)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	val := scm.TimeLabeled(label, present, events, time.Now())
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.time_in_review"),
+		withInput(label),
+		slog.Duration("function_result", val),
+	)
+
+	return val
+}
@@ -0,0 +1,109 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/config"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_TimeInState(t *testing.T) {
+	t.Parallel()
+
+	const (
+		labelEventsPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1/resource_label_events"
+		stateEventsPath = "/api/v4/projects/jippi/scm-engine/merge_requests/1/resource_state_events"
+	)
+
+	now := time.Now().UTC()
+	ago := func(d time.Duration) string {
+		return now.Add(-d).Format(time.RFC3339)
+	}
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case labelEventsPath:
+			w.Write([]byte(mustJSON(t, []map[string]any{
+				{"action": "add", "label": map[string]any{"name": "in-review"}, "created_at": ago(96 * time.Hour)},
+				{"action": "remove", "label": map[string]any{"name": "in-review"}, "created_at": ago(72 * time.Hour)},
+				{"action": "add", "label": map[string]any{"name": "in-review"}, "created_at": ago(50 * time.Hour)},
+			})))
+
+		case stateEventsPath:
+			w.Write([]byte(mustJSON(t, []map[string]any{
+				{"state": "closed", "created_at": ago(120 * time.Hour)},
+				{"state": "reopened", "created_at": ago(24 * time.Hour)},
+			})))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = config.WithConfig(ctx, &config.Config{})
+
+	committedAt := now.Add(-3 * time.Hour)
+
+	evalContext := &gitlab.Context{
+		MergeRequest: &gitlab.ContextMergeRequest{
+			State:      "opened",
+			CreatedAt:  now.Add(-240 * time.Hour),
+			UpdatedAt:  now.Add(-5 * time.Hour),
+			LastCommit: &gitlab.ContextCommit{CommittedDate: &committedAt},
+			Labels:     []gitlab.ContextLabel{{Title: "in-review"}},
+		},
+	}
+	evalContext.SetContext(ctx)
+
+	scripts := []string{
+		// The last commit is the most recent activity
+		`merge_request.time_since_last_activity() > duration("2h59m") && merge_request.time_since_last_activity() < duration("3h1m")`,
+		// Open since it was reopened
+		`merge_request.time_open() > duration("23h59m") && merge_request.time_open() < duration("24h1m")`,
+		// In review since the label was last added
+		`merge_request.time_in_review("in-review") > duration("49h59m") && merge_request.time_in_review("in-review") < duration("50h1m")`,
+		// Not in review
+		`merge_request.time_in_review("ready") == duration("0s")`,
+	}
+
+	for _, script := range scripts {
+		program, err := expr.Compile(script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		// Run each script twice, the second run must be served from the cache
+		for range 2 {
+			output, err := expr.Run(program, evalContext)
+			require.NoError(t, err)
+			require.Equal(t, true, output, script)
+		}
+	}
+
+	require.Equal(t, map[string]int{labelEventsPath: 1, stateEventsPath: 1}, requests)
+}
@@ -0,0 +1,102 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+func loadVariables(ctx context.Context) (map[string]string, error) {
+	loaders, err := loadersFromContext(ctx, "project variables")
+	if err != nil {
+		return nil, err
+	}
+
+	return loaders.variables.get(ctx, fetchVariables)
+}
+
+// fetchVariables returns the project CI/CD variables that are safe to expose to scripts.
+//
+// Masked variables are secrets, and protected variables are only meant for protected branches and tags,
+// so both are left out entirely, as are variables limited to specific environments
+func fetchVariables(ctx context.Context) (map[string]string, error) {
+	client, err := newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slogctx.Debug(ctx, "Loading project variables")
+
+	variables := map[string]string{}
+	options := &go_gitlab.ListProjectVariablesOptions{PerPage: state.APIPageSize(ctx)}
+
+	for {
+		page, resp, err := client.ProjectVariables.ListVariables(state.ProjectID(ctx), options, go_gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not load project variables: %w", err)
+		}
+
+		for _, variable := range page {
+			if !isExposableVariable(variable) {
+				continue
+			}
+
+			variables[variable.Key] = variable.Value
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	slogctx.Debug(ctx, "Loaded project variables", slog.Int("number_of_variables", len(variables)))
+
+	return variables, nil
+}
+
+func isExposableVariable(variable *go_gitlab.ProjectVariable) bool {
+	return !variable.Masked && !variable.Protected && (variable.EnvironmentScope == "" || variable.EnvironmentScope == "*")
+}
+
+// project_variable
+func (e ContextMergeRequest) ProjectVariable(ctx context.Context, key string) string {
+	variables, err := loadVariables(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	val, found := variables[key]
+
+	// The value is left out, so variables don't end up in the logs
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.project_variable"),
+		withInput(key),
+		slog.Bool("found", found),
+	)
+
+	return val
+}
+
+// has_project_variable
+func (e ContextMergeRequest) HasProjectVariable(ctx context.Context, key string) bool {
+	variables, err := loadVariables(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	_, val := variables[key]
+
+	slogctx.Debug(ctx, defaultScriptEvalResult,
+		withFunction("merge_request.has_project_variable"),
+		withInput(key),
+		withResult(val),
+	)
+
+	return val
+}
@@ -0,0 +1,99 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/scm/gitlab"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextMergeRequest_ProjectVariables(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path+"?page="+r.URL.Query().Get("page")]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.EscapedPath() != "/api/v4/projects/jippi%2Fscm-engine/variables" {
+			w.Write([]byte(`[]`))
+
+			return
+		}
+
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[
+				{"key": "SECOND_PAGE", "value": "yes", "environment_scope": "*"},
+				{"key": "HIDDEN_TOKEN", "value": "", "masked": true, "environment_scope": "*"}
+			]`))
+
+			return
+		}
+
+		w.Header().Set("X-Next-Page", "2")
+		w.Write([]byte(`[
+			{"key": "REVIEW_POLICY", "value": "strict", "environment_scope": "*"},
+			{"key": "API_TOKEN", "value": "super-secret", "masked": true, "environment_scope": "*"},
+			{"key": "DEPLOY_KEY", "value": "protected-value", "protected": true, "environment_scope": "*"},
+			{"key": "STAGING_URL", "value": "https://staging.example.com", "environment_scope": "staging"}
+		]`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	ctx = state.WithBaseURL(ctx, server.URL)
+	ctx = state.WithToken(ctx, "token")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	evalContext := &gitlab.Context{MergeRequest: &gitlab.ContextMergeRequest{}}
+	evalContext.SetContext(ctx)
+
+	tests := []struct {
+		script string
+		want   any
+	}{
+		{script: `merge_request.project_variable("REVIEW_POLICY")`, want: "strict"},
+		{script: `merge_request.project_variable("SECOND_PAGE")`, want: "yes"},
+		{script: `merge_request.has_project_variable("REVIEW_POLICY")`, want: true},
+		{script: `merge_request.project_variable("MISSING")`, want: ""},
+		{script: `merge_request.has_project_variable("MISSING")`, want: false},
+		// Masked variables are never exposed
+		{script: `merge_request.project_variable("API_TOKEN")`, want: ""},
+		{script: `merge_request.has_project_variable("API_TOKEN")`, want: false},
+		{script: `merge_request.has_project_variable("HIDDEN_TOKEN")`, want: false},
+		// Protected and environment scoped variables aren't exposed either
+		{script: `merge_request.project_variable("DEPLOY_KEY")`, want: ""},
+		{script: `merge_request.project_variable("STAGING_URL")`, want: ""},
+	}
+
+	for _, tt := range tests {
+		program, err := expr.Compile(tt.script, expr.Env(evalContext), stdlib.FunctionRenamer, expr.Patch(patcher.WithContext{Name: "ctx"}))
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, evalContext)
+		require.NoError(t, err, tt.script)
+		require.Equal(t, tt.want, output, tt.script)
+	}
+
+	// The variables are loaded once (both pages) and served from the cache afterwards
+	require.Equal(t, map[string]int{
+		"/api/v4/projects/jippi/scm-engine/variables?page=":  1,
+		"/api/v4/projects/jippi/scm-engine/variables?page=2": 1,
+	}, requests)
+}
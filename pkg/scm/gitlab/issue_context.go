@@ -0,0 +1,136 @@
+package gitlab
+
+import (
+	"context"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	go_gitlab "github.com/xanzy/go-gitlab"
+)
+
+var _ scm.EvalContext = (*IssueContext)(nil)
+
+// IssueContext is the evaluation context of the 'on_issue' actions, see [Client.IssueEvalContext]
+type IssueContext struct {
+	Issue *ContextIssue `expr:"issue"`
+
+	TriggerEvent string          `expr:"trigger_event"`
+	WebhookEvent any             `expr:"webhook_event"`
+	Vars         map[string]any  `expr:"vars"`
+	ActionGroups map[string]any  `expr:"-"`
+	Context      context.Context `expr:"ctx"`
+}
+
+// ContextIssue is the issue an 'on_issue' action is evaluated for
+type ContextIssue struct {
+	IID          int      `expr:"iid"`
+	Title        string   `expr:"title"`
+	Description  string   `expr:"description"`
+	Labels       []string `expr:"labels"`
+	State        string   `expr:"state"`
+	Confidential bool     `expr:"confidential"`
+	Author       string   `expr:"author"`
+	WebURL       string   `expr:"web_url"`
+
+	// The full path of the project the issue is in
+	Project string `expr:"project"`
+
+	// The ID of the issue this issue was moved to, 0 unless it was moved
+	MovedToID int `expr:"-"`
+}
+
+func newIssueContext(project string, issue *go_gitlab.Issue) *IssueContext {
+	evalContext := &IssueContext{
+		Issue: &ContextIssue{
+			IID:          issue.IID,
+			Title:        issue.Title,
+			Description:  issue.Description,
+			Labels:       issue.Labels,
+			State:        issue.State,
+			Confidential: issue.Confidential,
+			WebURL:       issue.WebURL,
+			Project:      project,
+			MovedToID:    issue.MovedToID,
+		},
+		Vars:         map[string]any{},
+		ActionGroups: map[string]any{},
+	}
+
+	if issue.Author != nil {
+		evalContext.Issue.Author = issue.Author.Username
+	}
+
+	return evalContext
+}
+
+// Issues have no pipeline to fail
+func (c *IssueContext) AllowPipelineFailure(context.Context) bool {
+	return false
+}
+
+// Issues have no branch, the configuration file is always read from the default branch
+func (c *IssueContext) CanUseConfigurationFileFromChangeRequest(context.Context) bool {
+	return false
+}
+
+func (c *IssueContext) GetDescription() string {
+	return c.Issue.Description
+}
+
+func (c *IssueContext) GetTitle() string {
+	return c.Issue.Title
+}
+
+func (c *IssueContext) GetTargetBranch() string {
+	return ""
+}
+
+func (c *IssueContext) GetLabels() []string {
+	return c.Issue.Labels
+}
+
+func (c *IssueContext) IsDraft() bool {
+	return false
+}
+
+func (c *IssueContext) IsValid() bool {
+	return c != nil && c.Issue != nil
+}
+
+func (c *IssueContext) SetContext(ctx context.Context) {
+	c.TriggerEvent = state.TriggerEvent(ctx)
+	c.Context = ctx
+}
+
+func (c *IssueContext) SetVars(vars map[string]any) {
+	c.Vars = vars
+}
+
+func (c *IssueContext) SetWebhookEvent(in any) {
+	c.WebhookEvent = in
+}
+
+func (c *IssueContext) TrackActionGroupExecution(group string) {
+	// Ungrouped actions shouldn't be tracked
+	if len(group) == 0 {
+		return
+	}
+
+	c.ActionGroups[group] = true
+}
+
+func (c *IssueContext) HasExecutedActionGroup(group string) bool {
+	// Ungrouped actions shouldn't be tracked
+	if len(group) == 0 {
+		return false
+	}
+
+	_, ok := c.ActionGroups[group]
+
+	return ok
+}
+
+// The issue is read with a single request, so there is no optional data that may be missing
+func (c *IssueContext) UnavailableData() map[string]string {
+	return nil
+}
@@ -134,3 +134,121 @@ type IncludeConfigurationRepository struct {
 	// read from the projects default branch at the time of reading
 	Blobs graphqlNodesOf[BlobNode] `graphql:"blobs(paths: $files, ref: $ref, first: 100)"`
 }
+
+// TargetBranchCoverageResult is the GraphQL response for reading the coverage of the
+// latest successful pipeline on the target branch of a Merge Request
+//
+// GraphQL query:
+//
+//	query ($project_id: ID!, $ref: String!) {
+//	  project(fullPath: $project_id) {
+//	    pipelines(ref: $ref, status: SUCCESS, first: 1) {
+//	      nodes {
+//	        coverage
+//	      }
+//	    }
+//	  }
+//	}
+type TargetBranchCoverageResult struct {
+	Project *TargetBranchCoverageProject `graphql:"project(fullPath: $project_id)"`
+}
+
+type TargetBranchCoverageProject struct {
+	Pipelines graphqlNodesOf[TargetBranchCoveragePipeline] `graphql:"pipelines(ref: $ref, status: SUCCESS, first: 1)"`
+}
+
+type TargetBranchCoveragePipeline struct {
+	Coverage *float64 `graphql:"coverage"`
+}
+
+// IncidentResult is the GraphQL response for reading an incident referenced by a Merge Request
+//
+// GraphQL query:
+//
+//	query ($project_id: ID!, $iid: String!) {
+//	  project(fullPath: $project_id) {
+//	    issue(iid: $iid) {
+//	      iid
+//	      title
+//	      state
+//	      type
+//	      severity
+//	      escalationStatus
+//	      webUrl
+//	    }
+//	  }
+//	}
+type IncidentResult struct {
+	Project *IncidentProject `graphql:"project(fullPath: $project_id)"`
+}
+
+type IncidentProject struct {
+	Issue *IncidentIssue `graphql:"issue(iid: $iid)"`
+}
+
+type IncidentIssue struct {
+	IID              string  `graphql:"iid"`
+	Title            string  `graphql:"title"`
+	State            string  `graphql:"state"`
+	Type             string  `graphql:"type"`
+	Severity         string  `graphql:"severity"`
+	EscalationStatus *string `graphql:"escalationStatus"`
+	WebURL           string  `graphql:"webUrl"`
+}
+
+// SecurityReportResult is the GraphQL response for reading the security report of a pipeline
+//
+// GraphQL query:
+//
+//	query ($project_id: ID!, $pipeline_id: CiPipelineID!, $cursor: String) {
+//	  project(fullPath: $project_id) {
+//	    pipeline(id: $pipeline_id) {
+//	      securityReportSummary {
+//	        sast { vulnerabilitiesCount }
+//	        dependencyScanning { vulnerabilitiesCount }
+//	      }
+//	      securityReportFindings(reportType: ["sast", "dependency_scanning"], first: 100, after: $cursor) {
+//	        nodes { severity }
+//	        pageInfo { hasNextPage endCursor }
+//	      }
+//	    }
+//	  }
+//	}
+type SecurityReportResult struct {
+	Project *SecurityReportProject `graphql:"project(fullPath: $project_id)"`
+}
+
+type SecurityReportProject struct {
+	Pipeline *SecurityReportPipeline `graphql:"pipeline(id: $pipeline_id)"`
+}
+
+type SecurityReportPipeline struct {
+	Summary  *SecurityReportSummary  `graphql:"securityReportSummary"`
+	Findings *SecurityReportFindings `graphql:"securityReportFindings(reportType: [\"sast\", \"dependency_scanning\"], first: 100, after: $cursor)"`
+}
+
+type SecurityReportSummary struct {
+	Sast               *SecurityReportScanner `graphql:"sast"`
+	DependencyScanning *SecurityReportScanner `graphql:"dependencyScanning"`
+}
+
+type SecurityReportScanner struct {
+	VulnerabilitiesCount int `graphql:"vulnerabilitiesCount"`
+}
+
+type SecurityReportFindings struct {
+	Nodes    []SecurityReportFinding `graphql:"nodes"`
+	PageInfo SecurityReportPageInfo  `graphql:"pageInfo"`
+}
+
+type SecurityReportPageInfo struct {
+	HasNextPage bool   `graphql:"hasNextPage"`
+	EndCursor   string `graphql:"endCursor"`
+}
+
+type SecurityReportFinding struct {
+	Severity string `graphql:"severity"`
+}
+
+// CiPipelineID is the GraphQL ID of a pipeline (example: 'gid://gitlab/Ci::Pipeline/123')
+type CiPipelineID string
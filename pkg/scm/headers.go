@@ -0,0 +1,52 @@
+package scm
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HeaderTransport is a [http.RoundTripper] adding static headers (like the 'User-Agent') to every request,
+// for example so the API requests can be audited
+type HeaderTransport struct {
+	Base    http.RoundTripper
+	Headers http.Header
+}
+
+func (t HeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if len(t.Headers) == 0 {
+		return base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+
+	for name, values := range t.Headers {
+		req.Header[name] = values
+	}
+
+	return base.RoundTrip(req)
+}
+
+// ParseHeaders parses the "Name: value" [headers] into a [http.Header]
+func ParseHeaders(headers []string) (http.Header, error) {
+	result := http.Header{}
+
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+
+		name = strings.TrimSpace(name)
+		if !ok || len(name) == 0 || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q; must be in the format 'Name: value'", header)
+		}
+
+		result.Add(name, strings.TrimSpace(value))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package scm_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTransport is a mock [http.RoundTripper] recording the requests it receives
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestHeaderTransport(t *testing.T) {
+	t.Parallel()
+
+	headers, err := scm.ParseHeaders([]string{"X-Caller-Id: platform-team", "X-Audit:  nightly batch "})
+	require.NoError(t, err)
+
+	headers.Set("User-Agent", "scm-engine/1.2.3")
+
+	mock := &recordingTransport{}
+	client := &http.Client{Transport: scm.HeaderTransport{Base: mock, Headers: headers}}
+
+	req, err := http.NewRequest(http.MethodGet, "https://gitlab.example.com/api/v4/projects", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("User-Agent", "go-gitlab")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, mock.requests, 1)
+	require.Equal(t, "scm-engine/1.2.3", mock.requests[0].Header.Get("User-Agent"))
+	require.Equal(t, "platform-team", mock.requests[0].Header.Get("X-Caller-Id"))
+	require.Equal(t, "nightly batch", mock.requests[0].Header.Get("X-Audit"))
+
+	// The original request is not modified
+	require.Equal(t, "go-gitlab", req.Header.Get("User-Agent"))
+	require.Empty(t, req.Header.Get("X-Caller-Id"))
+}
+
+func TestParseHeaders_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, header := range []string{"X-Caller-Id", ": value", "X Caller: value"} {
+		_, err := scm.ParseHeaders([]string{header})
+		require.ErrorContains(t, err, "must be in the format 'Name: value'", header)
+	}
+}
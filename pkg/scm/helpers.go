@@ -2,11 +2,17 @@ package scm
 
 import (
 	"errors"
+	"maps"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
+var hunkHeaderRegexp = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
 // Ptr is a helper that returns a pointer to v.
 func Ptr[T any](v T) *T {
 	return &v
@@ -203,3 +209,262 @@ func buildPatternRegex(pattern string) (*regexp.Regexp, error) {
 
 	return regexp.Compile(regexString.String())
 }
+
+// DiffContainsNewLine returns whether [line] (in the new version of the file) is visible in the unified [diff]
+func DiffContainsNewLine(diff string, line int) bool {
+	current := 0
+
+	for _, row := range strings.Split(diff, "\n") {
+		if matches := hunkHeaderRegexp.FindStringSubmatch(row); matches != nil {
+			current, _ = strconv.Atoi(matches[1])
+
+			continue
+		}
+
+		// Lines before the first hunk header, or lines only in the old version of the file
+		if current == 0 || len(row) == 0 || strings.HasPrefix(row, "-") || strings.HasPrefix(row, `\`) {
+			continue
+		}
+
+		if current == line {
+			return true
+		}
+
+		current++
+	}
+
+	return false
+}
+
+// LabelExpired returns whether the label [name] has been on the Merge Request for longer than [ttl],
+// based on the label [events] history.
+//
+// A label that was removed before it expired (e.g. because its script evaluated negatively) does not count
+// as expired, so it can be added again. A label removed because it expired stays expired until it's added again,
+// or it has been off the Merge Request for [ttl] too, so the label can be added again once its script matches again.
+func LabelExpired(events []LabelEvent, name string, ttl time.Duration, now time.Time) bool {
+	var lastAdd, lastRemove time.Time
+
+	for _, event := range events {
+		if event.Label != name {
+			continue
+		}
+
+		switch event.Action {
+		case "add":
+			if event.CreatedAt.After(lastAdd) {
+				lastAdd = event.CreatedAt
+			}
+
+		case "remove":
+			if event.CreatedAt.After(lastRemove) {
+				lastRemove = event.CreatedAt
+			}
+		}
+	}
+
+	// The label was never added, so it can't have expired
+	if lastAdd.IsZero() {
+		return false
+	}
+
+	expiresAt := lastAdd.Add(ttl)
+
+	if lastRemove.After(lastAdd) {
+		// The label was removed (for other reasons) before it expired
+		if lastRemove.Before(expiresAt) {
+			return false
+		}
+
+		// The label was removed after it expired, and has been off the Merge Request for long enough to reset the expiry
+		if !now.Before(lastRemove.Add(ttl)) {
+			return false
+		}
+	}
+
+	return !now.Before(expiresAt)
+}
+
+// ForceRemoveLabels removes the [labels] that are [present] on the Merge Request, even if they were
+// going to be added by the update. Returns the labels that will be removed.
+func (opt *UpdateMergeRequestOptions) ForceRemoveLabels(present []string, labels ...string) []string {
+	var removed LabelOptions
+
+	for _, label := range labels {
+		if slices.Contains(present, label) && !slices.Contains(removed, label) {
+			removed = append(removed, label)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if opt.AddLabels != nil {
+		add := slices.DeleteFunc(slices.Clone(*opt.AddLabels), func(label string) bool {
+			return slices.Contains(removed, label)
+		})
+
+		opt.AddLabels = &add
+	}
+
+	remove := removed
+	if opt.RemoveLabels != nil {
+		remove = append(slices.Clone(*opt.RemoveLabels), removed...)
+	}
+
+	opt.RemoveLabels = &remove
+
+	return removed
+}
+
+// TransformLabels renames the labels [present] on the Merge Request using [mapping] (old name to new name),
+// by force removing the old label and adding the new one. Labels that are not present are ignored, so
+// running it again after the rename does nothing. Returns the old labels that will be renamed, in sorted order.
+func (opt *UpdateMergeRequestOptions) TransformLabels(present []string, mapping map[string]string) []string {
+	var renamed []string
+
+	for _, from := range slices.Sorted(maps.Keys(mapping)) {
+		to := mapping[from]
+		if from == to || !slices.Contains(present, from) {
+			continue
+		}
+
+		opt.ForceRemoveLabels(present, from)
+
+		add := LabelOptions{}
+		if opt.AddLabels != nil {
+			add = slices.Clone(*opt.AddLabels)
+		}
+
+		if !slices.Contains(add, to) {
+			add = append(add, to)
+		}
+
+		opt.AddLabels = &add
+
+		renamed = append(renamed, from)
+	}
+
+	return renamed
+}
+
+// DiffLabels computes the final set of labels for the Merge Request from its [current] labels, and rewrites
+// the update to only add and remove the labels that differ from it, so all label changes are applied in a single request.
+//
+// A label that is both added and removed by the update is kept, and adding a scoped label removes the
+// other labels in the same scope.
+func (opt *UpdateMergeRequestOptions) DiffLabels(current []string) {
+	var add, remove LabelOptions
+
+	if opt.AddLabels != nil {
+		add = *opt.AddLabels
+	}
+
+	if opt.RemoveLabels != nil {
+		remove = *opt.RemoveLabels
+	}
+
+	desired := slices.DeleteFunc(slices.Clone(current), func(label string) bool {
+		if slices.Contains(add, label) {
+			return false
+		}
+
+		if slices.Contains(remove, label) {
+			return true
+		}
+
+		// Adding a scoped label replaces the other labels in the same scope
+		scope, ok := LabelScope(label)
+
+		return ok && slices.ContainsFunc(add, func(added string) bool {
+			addedScope, ok := LabelScope(added)
+
+			return ok && addedScope == scope
+		})
+	})
+
+	for _, label := range add {
+		if !slices.Contains(desired, label) {
+			desired = append(desired, label)
+		}
+	}
+
+	var toAdd, toRemove LabelOptions
+
+	for _, label := range desired {
+		if !slices.Contains(current, label) {
+			toAdd = append(toAdd, label)
+		}
+	}
+
+	for _, label := range current {
+		if !slices.Contains(desired, label) {
+			toRemove = append(toRemove, label)
+		}
+	}
+
+	opt.AddLabels = nil
+	if len(toAdd) > 0 {
+		opt.AddLabels = &toAdd
+	}
+
+	opt.RemoveLabels = nil
+	if len(toRemove) > 0 {
+		opt.RemoveLabels = &toRemove
+	}
+}
+
+// RestrictLabels drops the label additions and removals of labels that are not [managed], so labels
+// a human added (or scm-engine doesn't know about) are never touched. Run it after [UpdateMergeRequestOptions.DiffLabels].
+//
+// Returns the labels that were dropped from the update, in sorted order
+func (opt *UpdateMergeRequestOptions) RestrictLabels(managed []string) []string {
+	var dropped []string
+
+	restrict := func(labels *LabelOptions) *LabelOptions {
+		if labels == nil {
+			return nil
+		}
+
+		kept := slices.DeleteFunc(slices.Clone(*labels), func(label string) bool {
+			if slices.Contains(managed, label) {
+				return false
+			}
+
+			dropped = append(dropped, label)
+
+			return true
+		})
+
+		if len(kept) == 0 {
+			return nil
+		}
+
+		return &kept
+	}
+
+	opt.AddLabels = restrict(opt.AddLabels)
+	opt.RemoveLabels = restrict(opt.RemoveLabels)
+
+	slices.Sort(dropped)
+
+	return dropped
+}
+
+// IsEmpty returns true if the update doesn't change anything
+func (opt UpdateMergeRequestOptions) IsEmpty() bool {
+	return opt == UpdateMergeRequestOptions{}
+}
+
+// LabelScope returns the scope of a scoped label (e.g. "size" for "size::large")
+//
+// See: https://docs.gitlab.com/ee/user/project/labels.html#scoped-labels
+func LabelScope(name string) (string, bool) {
+	idx := strings.LastIndex(name, "::")
+	if idx <= 0 {
+		return "", false
+	}
+
+	return name[:idx], true
+}
@@ -2,6 +2,7 @@ package scm_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/jippi/scm-engine/pkg/scm"
 	"github.com/stretchr/testify/require"
@@ -66,3 +67,275 @@ func TestFindModifiedFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffContainsNewLine(t *testing.T) {
+	t.Parallel()
+
+	diff := "@@ -1,3 +1,4 @@\n package main\n-import \"fmt\"\n+import (\n+\t\"fmt\"\n+)\n@@ -10,2 +11,2 @@ func main() {\n \tfmt.Println(\"a\")\n+\tfmt.Println(\"b\")\n"
+
+	for _, line := range []int{1, 2, 3, 4, 11, 12} {
+		require.True(t, scm.DiffContainsNewLine(diff, line), "line %d should be in the diff", line)
+	}
+
+	for _, line := range []int{0, 5, 10, 13} {
+		require.False(t, scm.DiffContainsNewLine(diff, line), "line %d should not be in the diff", line)
+	}
+}
+
+func TestLabelExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name   string
+		events []scm.LabelEvent
+		want   bool
+	}{
+		{
+			name: "never added",
+			want: false,
+		},
+		{
+			name:   "added recently",
+			events: []scm.LabelEvent{{Label: "temp", Action: "add", CreatedAt: now.Add(-time.Hour)}},
+			want:   false,
+		},
+		{
+			name:   "added before the ttl",
+			events: []scm.LabelEvent{{Label: "temp", Action: "add", CreatedAt: now.Add(-2 * day)}},
+			want:   true,
+		},
+		{
+			name: "other labels are ignored",
+			events: []scm.LabelEvent{
+				{Label: "other", Action: "add", CreatedAt: now.Add(-2 * day)},
+			},
+			want: false,
+		},
+		{
+			name: "removed before expiry may be added again",
+			events: []scm.LabelEvent{
+				{Label: "temp", Action: "add", CreatedAt: now.Add(-3 * day)},
+				{Label: "temp", Action: "remove", CreatedAt: now.Add(-3*day + time.Hour)},
+			},
+			want: false,
+		},
+		{
+			name: "removed by expiry stays expired",
+			events: []scm.LabelEvent{
+				{Label: "temp", Action: "add", CreatedAt: now.Add(-2 * day)},
+				{Label: "temp", Action: "remove", CreatedAt: now.Add(-12 * time.Hour)},
+			},
+			want: true,
+		},
+		{
+			name: "removed by expiry resets after being off the Merge Request for the ttl",
+			events: []scm.LabelEvent{
+				{Label: "temp", Action: "add", CreatedAt: now.Add(-5 * day)},
+				{Label: "temp", Action: "remove", CreatedAt: now.Add(-3 * day)},
+			},
+			want: false,
+		},
+		{
+			name: "removed by expiry just now stays expired",
+			events: []scm.LabelEvent{
+				{Label: "temp", Action: "add", CreatedAt: now.Add(-day - time.Hour)},
+				{Label: "temp", Action: "remove", CreatedAt: now.Add(-time.Minute)},
+			},
+			want: true,
+		},
+		{
+			name: "added again after expiry",
+			events: []scm.LabelEvent{
+				{Label: "temp", Action: "add", CreatedAt: now.Add(-3 * day)},
+				{Label: "temp", Action: "remove", CreatedAt: now.Add(-2 * day)},
+				{Label: "temp", Action: "add", CreatedAt: now.Add(-time.Hour)},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, scm.LabelExpired(tt.events, "temp", day, now))
+		})
+	}
+}
+
+func TestUpdateMergeRequestOptions_ForceRemoveLabels(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no-op when none of the labels are present", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{}
+
+		require.Empty(t, update.ForceRemoveLabels([]string{"bug"}, "do-not-merge"))
+		require.Nil(t, update.AddLabels)
+		require.Nil(t, update.RemoveLabels)
+	})
+
+	t.Run("removes present labels, even if they were going to be added", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{
+			AddLabels:    &scm.LabelOptions{"do-not-merge", "ready"},
+			RemoveLabels: &scm.LabelOptions{"stale"},
+		}
+
+		removed := update.ForceRemoveLabels([]string{"bug", "do-not-merge", "wip"}, "do-not-merge", "wip", "wip", "missing")
+
+		require.Equal(t, []string{"do-not-merge", "wip"}, removed)
+		require.Equal(t, &scm.LabelOptions{"ready"}, update.AddLabels)
+		require.Equal(t, &scm.LabelOptions{"stale", "do-not-merge", "wip"}, update.RemoveLabels)
+	})
+}
+
+func TestUpdateMergeRequestOptions_TransformLabels(t *testing.T) {
+	t.Parallel()
+
+	mapping := map[string]string{
+		"bug":         "type::bug",
+		"feature":     "type::feature",
+		"type::chore": "type::chore",
+	}
+
+	t.Run("no-op when none of the old labels are present", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{}
+
+		require.Empty(t, update.TransformLabels([]string{"type::bug", "type::chore", "unrelated"}, mapping))
+		require.True(t, update.IsEmpty())
+	})
+
+	t.Run("renames present labels", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{AddLabels: &scm.LabelOptions{"bug", "ready"}}
+
+		renamed := update.TransformLabels([]string{"bug", "feature", "unrelated"}, mapping)
+
+		require.Equal(t, []string{"bug", "feature"}, renamed)
+		require.Equal(t, &scm.LabelOptions{"ready", "type::bug", "type::feature"}, update.AddLabels)
+		require.Equal(t, &scm.LabelOptions{"bug", "feature"}, update.RemoveLabels)
+
+		// Applying the update leaves nothing to rename
+		update.DiffLabels([]string{"bug", "feature", "unrelated"})
+		require.Equal(t, &scm.LabelOptions{"ready", "type::bug", "type::feature"}, update.AddLabels)
+
+		require.Empty(t, (&scm.UpdateMergeRequestOptions{}).TransformLabels([]string{"unrelated", "ready", "type::bug", "type::feature"}, mapping))
+	})
+
+	t.Run("new label already present", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{}
+
+		require.Equal(t, []string{"bug"}, update.TransformLabels([]string{"bug", "type::bug"}, mapping))
+
+		update.DiffLabels([]string{"bug", "type::bug"})
+		require.Nil(t, update.AddLabels)
+		require.Equal(t, &scm.LabelOptions{"bug"}, update.RemoveLabels)
+	})
+}
+
+func TestUpdateMergeRequestOptions_DiffLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		current    []string
+		add        scm.LabelOptions
+		remove     scm.LabelOptions
+		wantAdd    *scm.LabelOptions
+		wantRemove *scm.LabelOptions
+	}{
+		{
+			name:    "no changes",
+			current: []string{"bug", "ready"},
+			add:     scm.LabelOptions{"bug", "ready"},
+			remove:  scm.LabelOptions{"stale", "wip"},
+		},
+		{
+			name:       "many rules result in one add/remove diff",
+			current:    []string{"bug", "stale", "unrelated"},
+			add:        scm.LabelOptions{"bug", "ready", "lgtm", "ready"},
+			remove:     scm.LabelOptions{"stale", "wip", "do-not-merge"},
+			wantAdd:    &scm.LabelOptions{"ready", "lgtm"},
+			wantRemove: &scm.LabelOptions{"stale"},
+		},
+		{
+			name:    "label both added and removed is kept",
+			current: []string{"ready"},
+			add:     scm.LabelOptions{"ready"},
+			remove:  scm.LabelOptions{"ready"},
+		},
+		{
+			name:       "scoped label replaces the other labels in the scope",
+			current:    []string{"size::small", "priority::high"},
+			add:        scm.LabelOptions{"size::large"},
+			wantAdd:    &scm.LabelOptions{"size::large"},
+			wantRemove: &scm.LabelOptions{"size::small"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			update := &scm.UpdateMergeRequestOptions{
+				AddLabels:    &tt.add,
+				RemoveLabels: &tt.remove,
+			}
+
+			update.DiffLabels(tt.current)
+
+			require.Equal(t, tt.wantAdd, update.AddLabels)
+			require.Equal(t, tt.wantRemove, update.RemoveLabels)
+			require.Equal(t, tt.wantAdd == nil && tt.wantRemove == nil, update.IsEmpty())
+		})
+	}
+}
+
+func TestUpdateMergeRequestOptions_RestrictLabels(t *testing.T) {
+	t.Parallel()
+
+	managed := []string{"ready", "stale", "size::large", "size::small"}
+
+	t.Run("unmanaged labels survive a sync", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{
+			AddLabels:    &scm.LabelOptions{"ready", "size::large", "do-not-merge"},
+			RemoveLabels: &scm.LabelOptions{"stale", "needs-review"},
+		}
+
+		// 'size::custom' was added by a human, and would be replaced by the scoped 'size::large' label
+		update.DiffLabels([]string{"stale", "needs-review", "size::custom"})
+
+		dropped := update.RestrictLabels(managed)
+
+		require.Equal(t, []string{"do-not-merge", "needs-review", "size::custom"}, dropped)
+		require.Equal(t, &scm.LabelOptions{"ready", "size::large"}, update.AddLabels)
+		require.Equal(t, &scm.LabelOptions{"stale"}, update.RemoveLabels)
+	})
+
+	t.Run("update is empty when only unmanaged labels change", func(t *testing.T) {
+		t.Parallel()
+
+		update := &scm.UpdateMergeRequestOptions{
+			AddLabels:    &scm.LabelOptions{"do-not-merge"},
+			RemoveLabels: &scm.LabelOptions{"needs-review"},
+		}
+
+		update.DiffLabels([]string{"needs-review"})
+
+		require.Equal(t, []string{"do-not-merge", "needs-review"}, update.RestrictLabels(managed))
+		require.True(t, update.IsEmpty())
+	})
+}
@@ -0,0 +1,125 @@
+package scm
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Incident is a GitLab incident referenced by the evaluated Merge Request, as exposed to scripts
+type Incident struct {
+	// Full path of the project the incident belongs to
+	Project string `expr:"project"`
+	// Internal ID of the incident within its project
+	IID int `expr:"iid"`
+	// Reference to the incident (example: 'gitlab-org/gitlab#123')
+	Reference string `expr:"reference"`
+	// Title of the incident
+	Title string `expr:"title"`
+	// State of the incident (example: 'opened' or 'closed')
+	State string `expr:"state"`
+	// Severity of the incident; 'critical', 'high', 'medium', 'low' or 'unknown'
+	Severity string `expr:"severity"`
+	// Escalation status of the incident; 'triggered', 'acknowledged', 'resolved', 'ignored', or empty if not available
+	Status string `expr:"status"`
+	// Web URL of the incident
+	WebURL string `expr:"web_url"`
+}
+
+// IncidentReference points at an incident in [Project] with the internal ID [IID]
+type IncidentReference struct {
+	Project string
+	IID     int
+}
+
+// String returns the GitLab style reference (example: 'gitlab-org/gitlab#123')
+func (r IncidentReference) String() string {
+	return r.Project + "#" + strconv.Itoa(r.IID)
+}
+
+// incidentSeverities are the GitLab incident severities, from lowest to highest
+var incidentSeverities = []string{"unknown", "low", "medium", "high", "critical"}
+
+// incidentLineRegexp matches the description lines referencing incidents (example: 'Fixes incident #123')
+var incidentLineRegexp = regexp.MustCompile(`(?i)\bincidents?\b:?(.*)$`)
+
+// incidentURLRegexp matches incident URLs, which reference an incident on any line
+var incidentURLRegexp = regexp.MustCompile(`https?://[^/\s]+/([\w.\-/]+?)/-/issues/incident/(\d+)`)
+
+// incidentReferenceRegexp matches issue URLs and references, with or without a project path
+var incidentReferenceRegexp = regexp.MustCompile(`https?://[^/\s]+/([\w.\-/]+?)/-/(?:issues|work_items)/(\d+)|([\w.\-]+(?:/[\w.\-]+)+)?#(\d+)`)
+
+// ParseIncidentReferences returns the incidents linked by their URL, or referenced on lines mentioning an incident,
+// in the [description] (outside fenced code blocks), in the order they appear and without duplicates.
+//
+// References without a project path (example: '#123') belong to [project]
+func ParseIncidentReferences(description, project string) []IncidentReference {
+	var references []IncidentReference
+
+	seen := map[string]bool{}
+
+	add := func(reference IncidentReference) {
+		key := strings.ToLower(reference.String())
+		if reference.IID == 0 || seen[key] {
+			return
+		}
+
+		seen[key] = true
+
+		references = append(references, reference)
+	}
+
+	for _, line := range descriptionLines(description) {
+		if line.fenced {
+			continue
+		}
+
+		for _, match := range incidentURLRegexp.FindAllStringSubmatch(line.text, -1) {
+			iid, _ := strconv.Atoi(match[2])
+
+			add(IncidentReference{Project: match[1], IID: iid})
+		}
+
+		declaration := incidentLineRegexp.FindStringSubmatch(incidentURLRegexp.ReplaceAllString(line.text, ""))
+		if declaration == nil {
+			continue
+		}
+
+		for _, match := range incidentReferenceRegexp.FindAllStringSubmatch(declaration[1], -1) {
+			reference := IncidentReference{Project: project}
+
+			switch {
+			case len(match[2]) > 0:
+				reference.Project = match[1]
+				reference.IID, _ = strconv.Atoi(match[2])
+
+			default:
+				if len(match[3]) > 0 {
+					reference.Project = match[3]
+				}
+
+				reference.IID, _ = strconv.Atoi(match[4])
+			}
+
+			add(reference)
+		}
+	}
+
+	return references
+}
+
+// HighestIncidentSeverity returns the highest severity of the [incidents], or an empty string if there are none
+func HighestIncidentSeverity(incidents []Incident) string {
+	highest := -1
+
+	for _, incident := range incidents {
+		highest = max(highest, slices.Index(incidentSeverities, incident.Severity))
+	}
+
+	if highest < 0 {
+		return ""
+	}
+
+	return incidentSeverities[highest]
+}
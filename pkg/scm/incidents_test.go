@@ -0,0 +1,40 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIncidentReferences(t *testing.T) {
+	t.Parallel()
+
+	description := `Roll back the cache change.
+
+Fixes incident #12 and jippi/ops#4
+See https://gitlab.com/jippi/scm-engine/-/issues/incident/13 for the timeline
+Incident: #12
+
+Closes #99 without mentioning an incident.
+
+` + "```" + `
+incident #100
+` + "```"
+
+	require.Equal(t, []scm.IncidentReference{
+		{Project: "jippi/scm-engine", IID: 12},
+		{Project: "jippi/ops", IID: 4},
+		{Project: "jippi/scm-engine", IID: 13},
+	}, scm.ParseIncidentReferences(description, "jippi/scm-engine"))
+
+	require.Empty(t, scm.ParseIncidentReferences("Closes #99", "jippi/scm-engine"))
+}
+
+func TestHighestIncidentSeverity(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, scm.HighestIncidentSeverity(nil))
+	require.Equal(t, "unknown", scm.HighestIncidentSeverity([]scm.Incident{{Severity: "unknown"}}))
+	require.Equal(t, "critical", scm.HighestIncidentSeverity([]scm.Incident{{Severity: "low"}, {Severity: "critical"}, {Severity: "high"}}))
+}
@@ -16,6 +16,12 @@ type Client interface {
 	Stop(ctx context.Context, err error, allowPipelineFailure bool) error
 }
 
+// IssueClient is implemented by the clients that can evaluate the 'on_issue' actions of issue events
+type IssueClient interface {
+	ApplyIssueStep(ctx context.Context, evalContext EvalContext, step ActionStep) error
+	IssueEvalContext(ctx context.Context, iid int) (EvalContext, error)
+}
+
 type LabelClient interface {
 	Create(ctx context.Context, opt *CreateLabelOptions) (*Label, *Response, error)
 	List(ctx context.Context) ([]*Label, error)
@@ -23,24 +29,41 @@ type LabelClient interface {
 }
 
 type MergeRequestClient interface {
+	FindNote(ctx context.Context, marker string) (string, error)
 	GetRemoteConfig(ctx context.Context, name string, ref string) (io.Reader, error)
+	HeadSHA(ctx context.Context) (string, error)
+	LabelEvents(ctx context.Context) ([]LabelEvent, error)
 	List(ctx context.Context, options *ListMergeRequestsOptions) ([]ListMergeRequest, error)
 	Update(ctx context.Context, opt *UpdateMergeRequestOptions) (*Response, error)
+	UpsertNote(ctx context.Context, marker, body string) error
+	UpsertInternalNote(ctx context.Context, marker, body string) error
+	UpsertDescriptionBlock(ctx context.Context, block string) error
 }
 
 type EvalContext interface {
 	AllowPipelineFailure(ctx context.Context) bool
 	CanUseConfigurationFileFromChangeRequest(ctx context.Context) bool
 	GetDescription() string
+	GetTitle() string
+	GetTargetBranch() string
+	GetLabels() []string
 	HasExecutedActionGroup(name string) bool
+	IsDraft() bool
 	IsValid() bool
 	SetContext(ctx context.Context)
+	SetVars(vars map[string]any)
 	SetWebhookEvent(in any)
 	TrackActionGroupExecution(name string)
+	UnavailableData() map[string]string
 }
 
 type ActionStep interface {
 	RequiredString(name string) (string, error)
 	OptionalString(name, fallback string) (string, error)
+	RequiredInt(name string) (int, error)
+	OptionalInt(name string, fallback int) (int, error)
+	OptionalStringSlice(name string) ([]string, error)
+	OptionalBool(name string, fallback bool) (bool, error)
+	RequiredStringMap(name string) (map[string]string, error)
 	Get(name string) (any, error)
 }
@@ -0,0 +1,28 @@
+package scm
+
+import "regexp"
+
+// issueReferenceRegexp matches GitLab issue references (example: '#123' or 'group/project#123') and issue URLs
+var issueReferenceRegexp = regexp.MustCompile(`(?:^|[^\w&/])(?:[\w.\-]+(?:/[\w.\-]+)+)?#\d+\b|https?://\S+/-/(?:issues|work_items)/\d+`)
+
+// HasIssueReference checks if the [description] references an issue, outside fenced code blocks.
+//
+// An issue is referenced by [pattern] if not empty (example: a Jira key like '[A-Z]+-\d+'), or else by a
+// GitLab issue reference or URL
+func HasIssueReference(description string, pattern *regexp.Regexp) bool {
+	if pattern == nil {
+		pattern = issueReferenceRegexp
+	}
+
+	for _, line := range descriptionLines(description) {
+		if line.fenced {
+			continue
+		}
+
+		if pattern.MatchString(line.text) {
+			return true
+		}
+	}
+
+	return false
+}
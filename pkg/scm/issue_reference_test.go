@@ -0,0 +1,37 @@
+package scm_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasIssueReference(t *testing.T) {
+	t.Parallel()
+
+	jira := regexp.MustCompile(`\b[A-Z]+-\d+\b`)
+
+	tests := []struct {
+		description string
+		pattern     *regexp.Regexp
+		want        bool
+	}{
+		{description: "Closes #42", want: true},
+		{description: "Part of (#42)", want: true},
+		{description: "Relates to jippi/scm-engine#42", want: true},
+		{description: "See https://gitlab.com/jippi/scm-engine/-/issues/42", want: true},
+		{description: "See https://gitlab.com/jippi/scm-engine/-/work_items/42", want: true},
+		{description: "Depends on !42", want: false},
+		{description: "# Heading\n\nNo issue here", want: false},
+		{description: "Color &#42; entity", want: false},
+		{description: "```\nCloses #42\n```", want: false},
+		{description: "Fixes PROJ-123", pattern: jira, want: true},
+		{description: "Closes #42", pattern: jira, want: false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, scm.HasIssueReference(tt.description, tt.pattern), tt.description)
+	}
+}
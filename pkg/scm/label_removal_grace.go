@@ -0,0 +1,102 @@
+package scm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PendingLabelRemovalsMarker is the hidden marker used to find (and update) the comment recording the pending label removals
+const PendingLabelRemovalsMarker = "<!-- scm-engine:pending-label-removals -->"
+
+// pendingLabelRemovalsDataPrefix starts the hidden comment holding the pending label removals as JSON
+const pendingLabelRemovalsDataPrefix = "<!-- scm-engine:pending-label-removals-data "
+
+// PendingLabelRemovals is when the script of each label (with a 'remove_after' grace period) on the
+// Merge Request started evaluating negatively, keyed by label name
+type PendingLabelRemovals map[string]time.Time
+
+// GraceLabelRemovals keeps the [labels] with a [EvaluationResult.RemoveAfter] grace period that evaluated negatively
+// on the Merge Request (marking them as matched again), until they have evaluated negatively for the whole grace period.
+//
+// [current] are the labels currently on the Merge Request, and [pending] the removals recorded by earlier evaluations.
+// The updated pending removals are returned, together with whether they changed.
+func GraceLabelRemovals(labels []EvaluationResult, current []string, pending PendingLabelRemovals, now time.Time) (PendingLabelRemovals, bool) {
+	updated := PendingLabelRemovals{}
+
+	for idx, label := range labels {
+		if label.RemoveAfter == 0 || label.Matched || !slices.Contains(current, label.Name) {
+			continue
+		}
+
+		since, ok := pending[label.Name]
+		if !ok {
+			since = now
+		}
+
+		// Remove the label once the grace period is over
+		if now.Sub(since) >= label.RemoveAfter {
+			continue
+		}
+
+		labels[idx].Matched = true
+		updated[label.Name] = since
+	}
+
+	return updated, !maps.EqualFunc(pending, updated, time.Time.Equal)
+}
+
+// FormatPendingLabelRemovals renders the comment body recording the [pending] label removals, readable by [ParsePendingLabelRemovals]
+func FormatPendingLabelRemovals(pending PendingLabelRemovals) string {
+	if pending == nil {
+		pending = PendingLabelRemovals{}
+	}
+
+	data, _ := json.Marshal(pending) //nolint:errchkjson
+
+	readable := "No labels are pending removal."
+	if len(pending) > 0 {
+		lines := make([]string, 0, len(pending))
+
+		for _, name := range slices.Sorted(maps.Keys(pending)) {
+			lines = append(lines, fmt.Sprintf("- ~%q since %s", name, pending[name].UTC().Format(time.RFC3339)))
+		}
+
+		readable = "These labels no longer apply, and are removed once their `remove_after` grace period is over:\n\n" +
+			strings.Join(lines, "\n")
+	}
+
+	return PendingLabelRemovalsMarker + "\n" +
+		readable + "\n\n" +
+		pendingLabelRemovalsDataPrefix + string(data) + " -->"
+}
+
+// ParsePendingLabelRemovals returns the pending label removals recorded by [FormatPendingLabelRemovals] in the comment [body].
+//
+// An empty [body] (no comment yet) has no pending removals
+func ParsePendingLabelRemovals(body string) (PendingLabelRemovals, error) {
+	if len(body) == 0 {
+		return PendingLabelRemovals{}, nil
+	}
+
+	_, data, found := strings.Cut(body, pendingLabelRemovalsDataPrefix)
+	if !found {
+		return nil, errors.New("the pending label removals comment is malformed")
+	}
+
+	data, _, found = strings.Cut(data, " -->")
+	if !found {
+		return nil, errors.New("the pending label removals comment is malformed")
+	}
+
+	pending := PendingLabelRemovals{}
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		return nil, fmt.Errorf("the pending label removals comment is malformed: %w", err)
+	}
+
+	return pending, nil
+}
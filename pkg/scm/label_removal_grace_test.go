@@ -0,0 +1,93 @@
+package scm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraceLabelRemovals(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	current := []string{"pipeline::failed"}
+
+	evaluate := func(matched bool, pending scm.PendingLabelRemovals, now time.Time) (bool, scm.PendingLabelRemovals, bool) {
+		labels := []scm.EvaluationResult{{Name: "pipeline::failed", Matched: matched, RemoveAfter: 15 * time.Minute}}
+
+		pending, changed := scm.GraceLabelRemovals(labels, current, pending, now)
+
+		return labels[0].Matched, pending, changed
+	}
+
+	t.Run("a transient flip within the grace period keeps the label", func(t *testing.T) {
+		t.Parallel()
+
+		// The pipeline is retried, so the condition goes false
+		matched, pending, changed := evaluate(false, scm.PendingLabelRemovals{}, start)
+		require.True(t, matched)
+		require.True(t, changed)
+		require.Equal(t, scm.PendingLabelRemovals{"pipeline::failed": start}, pending)
+
+		// Still false 10 minutes later, within the grace period
+		matched, pending, changed = evaluate(false, pending, start.Add(10*time.Minute))
+		require.True(t, matched)
+		require.False(t, changed)
+
+		// The retried pipeline failed again, so the condition is true again
+		matched, pending, changed = evaluate(true, pending, start.Add(12*time.Minute))
+		require.True(t, matched)
+		require.True(t, changed)
+		require.Empty(t, pending)
+	})
+
+	t.Run("the label is removed once the grace period is over", func(t *testing.T) {
+		t.Parallel()
+
+		matched, pending, _ := evaluate(false, scm.PendingLabelRemovals{}, start)
+		require.True(t, matched)
+
+		matched, pending, changed := evaluate(false, pending, start.Add(15*time.Minute))
+		require.False(t, matched)
+		require.True(t, changed)
+		require.Empty(t, pending)
+	})
+
+	t.Run("labels without a grace period or not on the Merge Request are left alone", func(t *testing.T) {
+		t.Parallel()
+
+		labels := []scm.EvaluationResult{
+			{Name: "pipeline::failed", Matched: false},
+			{Name: "stale", Matched: false, RemoveAfter: time.Hour},
+		}
+
+		pending, changed := scm.GraceLabelRemovals(labels, current, scm.PendingLabelRemovals{"stale": start}, start)
+		require.False(t, labels[0].Matched)
+		require.False(t, labels[1].Matched)
+		require.True(t, changed)
+		require.Empty(t, pending)
+	})
+}
+
+func TestPendingLabelRemovals_FormatParse(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+
+	body := scm.FormatPendingLabelRemovals(scm.PendingLabelRemovals{"pipeline::failed": since})
+	require.Contains(t, body, scm.PendingLabelRemovalsMarker)
+	require.Contains(t, body, `~"pipeline::failed" since 2024-06-03T10:00:00Z`)
+
+	pending, err := scm.ParsePendingLabelRemovals(body)
+	require.NoError(t, err)
+	require.Equal(t, scm.PendingLabelRemovals{"pipeline::failed": since}, pending)
+
+	pending, err = scm.ParsePendingLabelRemovals("")
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	_, err = scm.ParsePendingLabelRemovals(scm.PendingLabelRemovalsMarker + "\nedited by hand")
+	require.ErrorContains(t, err, "malformed")
+}
@@ -0,0 +1,62 @@
+package scm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LabelSnapshotMarker is the hidden marker used to find (and update) the label snapshot comment
+const LabelSnapshotMarker = "<!-- scm-engine:label-snapshot -->"
+
+// labelSnapshotDataPrefix starts the hidden comment holding the snapshot labels as JSON
+const labelSnapshotDataPrefix = "<!-- scm-engine:label-snapshot-data "
+
+// ErrNoLabelSnapshot is returned when a Merge Request doesn't have a label snapshot to restore
+var ErrNoLabelSnapshot = errors.New("no label snapshot found; use the 'snapshot_labels' action to take one")
+
+// FormatLabelSnapshot renders the comment body recording the [labels] of a Merge Request, readable by [ParseLabelSnapshot]
+func FormatLabelSnapshot(labels []string) string {
+	if labels == nil {
+		labels = []string{}
+	}
+
+	data, _ := json.Marshal(labels) //nolint:errchkjson
+
+	readable := "_(no labels)_"
+	if len(labels) > 0 {
+		quoted := make([]string, 0, len(labels))
+
+		for _, label := range labels {
+			quoted = append(quoted, fmt.Sprintf("~%q", label))
+		}
+
+		readable = strings.Join(quoted, " ")
+	}
+
+	return LabelSnapshotMarker + "\n" +
+		"Label snapshot, restore it with `scm-engine gitlab labels restore`:\n\n" +
+		readable + "\n\n" +
+		labelSnapshotDataPrefix + string(data) + " -->"
+}
+
+// ParseLabelSnapshot returns the labels recorded by [FormatLabelSnapshot] in the comment [body]
+func ParseLabelSnapshot(body string) ([]string, error) {
+	_, data, found := strings.Cut(body, labelSnapshotDataPrefix)
+	if !found {
+		return nil, ErrNoLabelSnapshot
+	}
+
+	data, _, found = strings.Cut(data, " -->")
+	if !found {
+		return nil, errors.New("the label snapshot comment is malformed")
+	}
+
+	var labels []string
+	if err := json.Unmarshal([]byte(data), &labels); err != nil {
+		return nil, fmt.Errorf("the label snapshot comment is malformed: %w", err)
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,75 @@
+package scm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MentionsNotify posts @-mentions as-is, so the mentioned users and groups are notified
+	MentionsNotify = "notify"
+	// MentionsSuppress renders @-mentions literally, so nobody is notified
+	MentionsSuppress = "suppress"
+)
+
+// mentionRegexp matches a user or group @-mention (example: '@alice' or '@my-org/team'), and the character before it.
+// Mentions must not be preceded by a word character, so email addresses are left alone
+var mentionRegexp = regexp.MustCompile(`(^|[^\w.@/` + "`" + `])@([\w][\w.\-/]*)`)
+
+// ApplyMentionPolicy returns [message] with its @-mentions handled according to [policy],
+// either [MentionsNotify] or [MentionsSuppress]
+func ApplyMentionPolicy(message, policy string) (string, error) {
+	switch policy {
+	case MentionsNotify:
+		return message, nil
+
+	case MentionsSuppress:
+		return SuppressMentions(message), nil
+
+	default:
+		return "", fmt.Errorf("step field 'mentions' must be either %q or %q, got %q", MentionsNotify, MentionsSuppress, policy)
+	}
+}
+
+// SuppressMentions wraps every @-mention in [message] in inline code, so it renders literally and doesn't notify anyone.
+//
+// Mentions within fenced code blocks and inline code already render literally, and are left alone
+func SuppressMentions(message string) string {
+	lines := descriptionLines(message)
+	output := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if line.fenced {
+			output = append(output, line.text)
+
+			continue
+		}
+
+		// Every other segment between backticks is inline code
+		segments := strings.Split(line.text, "`")
+
+		for i := range segments {
+			// An unterminated backtick doesn't start inline code
+			if i%2 == 0 || (i == len(segments)-1 && len(segments)%2 == 0) {
+				segments[i] = suppressLineMentions(segments[i])
+			}
+		}
+
+		output = append(output, strings.Join(segments, "`"))
+	}
+
+	return strings.Join(output, "\n")
+}
+
+func suppressLineMentions(text string) string {
+	return mentionRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		parts := mentionRegexp.FindStringSubmatch(match)
+
+		// Trailing punctuation (example: 'thanks @alice.') isn't part of the username
+		name := strings.TrimRight(parts[2], ".-/")
+		rest := parts[2][len(name):]
+
+		return parts[1] + "`@" + name + "`" + rest
+	})
+}
@@ -0,0 +1,87 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppressMentions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "user mention",
+			message: "Hello @alice, please review",
+			want:    "Hello `@alice`, please review",
+		},
+		{
+			name:    "mention at the start and trailing punctuation",
+			message: "@bob.smith thanks @carol.",
+			want:    "`@bob.smith` thanks `@carol`.",
+		},
+		{
+			name:    "group mention",
+			message: "cc @my-org/security-team",
+			want:    "cc `@my-org/security-team`",
+		},
+		{
+			name:    "special mentions",
+			message: "(@all) and @here",
+			want:    "(`@all`) and `@here`",
+		},
+		{
+			name:    "email addresses are not mentions",
+			message: "Mail alice@example.com",
+			want:    "Mail alice@example.com",
+		},
+		{
+			name:    "inline code is left alone",
+			message: "Run `git blame @alice` and ask @bob",
+			want:    "Run `git blame @alice` and ask `@bob`",
+		},
+		{
+			name:    "unterminated backtick",
+			message: "Oops ` @alice",
+			want:    "Oops ` `@alice`",
+		},
+		{
+			name:    "fenced code blocks are left alone",
+			message: "@alice\n```\n@bob\n```\n@carol",
+			want:    "`@alice`\n```\n@bob\n```\n`@carol`",
+		},
+		{
+			name:    "no mentions",
+			message: "Nothing to see here",
+			want:    "Nothing to see here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, scm.SuppressMentions(tt.message))
+		})
+	}
+}
+
+func TestApplyMentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	got, err := scm.ApplyMentionPolicy("Hello @alice", scm.MentionsNotify)
+	require.NoError(t, err)
+	require.Equal(t, "Hello @alice", got)
+
+	got, err = scm.ApplyMentionPolicy("Hello @alice", scm.MentionsSuppress)
+	require.NoError(t, err)
+	require.Equal(t, "Hello `@alice`", got)
+
+	_, err = scm.ApplyMentionPolicy("Hello @alice", "loud")
+	require.EqualError(t, err, `step field 'mentions' must be either "notify" or "suppress", got "loud"`)
+}
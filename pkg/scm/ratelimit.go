@@ -0,0 +1,151 @@
+package scm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Start pacing requests once less than this fraction of the rate limit is left
+	rateLimitPacingThreshold = 0.2
+
+	// Never delay a single request longer than this, in case the reset header is off
+	rateLimitMaxDelay = time.Minute
+)
+
+// Remaining requests before hitting the rate limit, by API host
+var rateLimitRemaining = expvar.NewMap("rate_limit_remaining")
+
+// rateLimiters are the [RateLimiter] of every API host and token, see [SharedRateLimiter]
+var rateLimiters sync.Map
+
+// RateLimiter tracks the rate limit of an API host and token from the 'RateLimit-*' response headers
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// NewRateLimiter creates a [RateLimiter] with an unknown rate limit
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{remaining: -1}
+}
+
+// SharedRateLimiter returns the [RateLimiter] of the API [host] for [token], shared by every client
+// (REST and GraphQL) using it, since they all count towards the same rate limit.
+//
+// The token is only kept as a hash
+func SharedRateLimiter(host, token string) *RateLimiter {
+	hash := sha256.Sum256([]byte(token))
+	key := host + "#" + hex.EncodeToString(hash[:])
+
+	limiter, _ := rateLimiters.LoadOrStore(key, NewRateLimiter())
+
+	return limiter.(*RateLimiter) //nolint:forcetypeassert
+}
+
+// Remaining returns the remaining requests before hitting the rate limit, or -1 if the rate limit is unknown
+func (l *RateLimiter) Remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.remaining
+}
+
+func (l *RateLimiter) delay(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return RateLimitDelay(l.limit, l.remaining, l.reset, now)
+}
+
+func (l *RateLimiter) update(host string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.remaining = remaining
+
+	if limit, err := strconv.Atoi(header.Get("RateLimit-Limit")); err == nil {
+		l.limit = limit
+	}
+
+	if reset, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		l.reset = time.Unix(reset, 0)
+	}
+
+	metric := new(expvar.Int)
+	metric.Set(int64(remaining))
+
+	rateLimitRemaining.Set(host, metric)
+}
+
+// RateLimitTransport is a [http.RoundTripper] that reads the 'RateLimit-*' response headers into the [SharedRateLimiter]
+// of the request host and [Token], and proactively slows down requests as the remaining headroom shrinks, rather than waiting for a 429
+type RateLimitTransport struct {
+	Base  http.RoundTripper
+	Token string
+}
+
+func NewRateLimitTransport(base http.RoundTripper, token string) *RateLimitTransport {
+	return &RateLimitTransport{Base: base, Token: token}
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := SharedRateLimiter(req.URL.Host, t.Token)
+
+	if delay := limiter.delay(time.Now()); delay > 0 {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+
+		case <-time.After(delay):
+		}
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	limiter.update(req.URL.Host, resp.Header)
+
+	return resp, nil
+}
+
+// RateLimitDelay returns how long to wait before sending the next request, spreading the [remaining]
+// requests evenly until the [reset] time once less than 20% of the [limit] is left.
+//
+// A negative [remaining] value means the rate limit is unknown, and never causes a delay.
+func RateLimitDelay(limit, remaining int, reset, now time.Time) time.Duration {
+	untilReset := reset.Sub(now)
+
+	switch {
+	case remaining < 0, untilReset <= 0:
+		return 0
+
+	case remaining == 0:
+		return min(untilReset, rateLimitMaxDelay)
+
+	case limit > 0 && float64(remaining) > float64(limit)*rateLimitPacingThreshold:
+		return 0
+
+	default:
+		return min(untilReset/time.Duration(remaining+1), rateLimitMaxDelay)
+	}
+}
@@ -0,0 +1,71 @@
+package scm_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name      string
+		limit     int
+		remaining int
+		reset     time.Time
+		want      time.Duration
+	}{
+		{name: "unknown rate limit", limit: 0, remaining: -1, reset: time.Time{}, want: 0},
+		{name: "plenty of headroom", limit: 2000, remaining: 1500, reset: now.Add(time.Minute), want: 0},
+		{name: "reset already passed", limit: 2000, remaining: 10, reset: now.Add(-time.Second), want: 0},
+		{name: "headroom shrinking", limit: 2000, remaining: 99, reset: now.Add(time.Minute), want: 600 * time.Millisecond},
+		{name: "exhausted", limit: 2000, remaining: 0, reset: now.Add(30 * time.Second), want: 30 * time.Second},
+		{name: "exhausted with far away reset", limit: 2000, remaining: 0, reset: now.Add(time.Hour), want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, scm.RateLimitDelay(tt.limit, tt.remaining, tt.reset, now))
+		})
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "2000")
+		w.Header().Set("RateLimit-Remaining", "1500")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	limiter := scm.SharedRateLimiter(host, "token")
+	require.Equal(t, -1, limiter.Remaining())
+
+	// Every client of the API host and token shares the rate limiter
+	require.Same(t, limiter, scm.SharedRateLimiter(host, "token"))
+	require.NotSame(t, limiter, scm.SharedRateLimiter(host, "other-token"))
+	require.NotSame(t, limiter, scm.SharedRateLimiter("gitlab.example.com", "token"))
+
+	client := &http.Client{Transport: scm.NewRateLimitTransport(nil, "token")}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, 1500, limiter.Remaining())
+	require.Equal(t, -1, scm.SharedRateLimiter(host, "other-token").Remaining())
+}
@@ -0,0 +1,36 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrReadOnly is returned when a request that could change something is attempted in read-only mode
+var ErrReadOnly = errors.New("refusing to send a mutating request in read-only mode")
+
+// ReadOnlyTransport is a [http.RoundTripper] that only allows safe (read) requests through
+//
+// It's the last line of defense in read-only mode, all code paths should check [state.IsDryRun] first.
+type ReadOnlyTransport struct {
+	Base http.RoundTripper
+}
+
+func (t ReadOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		base := t.Base
+		if base == nil {
+			base = http.DefaultTransport
+		}
+
+		return base.RoundTrip(req)
+
+	default:
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, fmt.Errorf("%w: %s %s", ErrReadOnly, req.Method, req.URL.Path)
+	}
+}
@@ -0,0 +1,239 @@
+package scm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// ResponseCacheTransport is a [http.RoundTripper] that caches successful read responses (REST 'GET' requests
+// and GraphQL queries) on disk, gzip compressed, keyed by the request, the credentials it's sent with, and the commit SHA
+// being evaluated, so responses are never shared between tokens with different permissions.
+//
+// Mutating requests (including GraphQL mutations) are never cached, and always sent to the API
+type ResponseCacheTransport struct {
+	Base http.RoundTripper
+
+	// Directory the responses are stored in, created if it doesn't exist
+	Dir string
+
+	// How long a cached response is used for
+	TTL time.Duration
+
+	// Returns the current time, defaults to [time.Now]
+	Now func() time.Time
+}
+
+func NewResponseCacheTransport(base http.RoundTripper, dir string, ttl time.Duration) *ResponseCacheTransport {
+	return &ResponseCacheTransport{Base: base, Dir: dir, TTL: ttl}
+}
+
+func (t *ResponseCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+
+	key, ok, err := t.key(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return base.RoundTrip(req)
+	}
+
+	path := filepath.Join(t.Dir, key+".gz")
+
+	if resp, ok := t.read(path, req); ok {
+		slogctx.Debug(ctx, "Using cached API response", slog.String("url", req.URL.String()))
+
+		return resp, nil
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Failing to cache a response must never fail the request
+	if err := t.write(path, resp, body); err != nil {
+		slogctx.Warn(ctx, "Could not cache API response", slog.String("url", req.URL.String()), slog.Any("error", err))
+	}
+
+	return resp, nil
+}
+
+// key returns the cache key of the request, or false if the request must not be cached
+func (t *ResponseCacheTransport) key(req *http.Request) (string, bool, error) {
+	var body []byte
+
+	switch req.Method {
+	case http.MethodGet:
+
+	// GraphQL queries are sent as POST requests, only cache the queries and never the mutations
+	case http.MethodPost:
+		if !strings.HasSuffix(req.URL.Path, "/graphql") || req.Body == nil {
+			return "", false, nil
+		}
+
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return "", false, err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !isGraphQLQuery(body) {
+			return "", false, nil
+		}
+
+	default:
+		return "", false, nil
+	}
+
+	sha, _ := state.CommitSHAOk(req.Context())
+
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + "\n" + req.URL.String() + "\n" + sha + "\n" + credentialsHash(req) + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), true, nil
+}
+
+// read returns the cached response at [path], or false if there is none or it has expired
+func (t *ResponseCacheTransport) read(path string, req *http.Request) (*http.Response, bool) {
+	info, err := os.Stat(path)
+	if err != nil || t.now().Sub(info.ModTime()) > t.TTL {
+		return nil, false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, false
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(reader), req)
+	if err != nil {
+		return nil, false
+	}
+
+	// Read the body before the file is closed
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, false
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, true
+}
+
+// write stores the response at [path], through a temporary file so a crash never leaves a partial response behind
+func (t *ResponseCacheTransport) write(path string, resp *http.Response, body []byte) error {
+	if err := os.MkdirAll(t.Dir, 0o700); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(t.Dir, ".response-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	cached := *resp
+	cached.Body = io.NopCloser(bytes.NewReader(body))
+	cached.ContentLength = int64(len(body))
+	cached.TransferEncoding = nil
+
+	writer := gzip.NewWriter(file)
+
+	if err := cached.Write(writer); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (t *ResponseCacheTransport) now() time.Time {
+	if t.Now != nil {
+		return t.Now()
+	}
+
+	return time.Now()
+}
+
+// isGraphQLQuery returns whether the GraphQL request [body] is a query, rather than a mutation
+func isGraphQLQuery(body []byte) bool {
+	var request struct {
+		Query string `json:"query"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return false
+	}
+
+	query := strings.TrimSpace(request.Query)
+
+	return len(query) > 0 && !strings.HasPrefix(query, "mutation")
+}
+
+// credentialHeaders are the request headers GitLab reads the API token from
+var credentialHeaders = []string{"Authorization", "Private-Token", "Job-Token"}
+
+// credentialsHash returns a hash of the API token the request is authenticated with, so the token itself is never
+// part of the cache key
+func credentialsHash(req *http.Request) string {
+	hash := sha256.New()
+
+	for _, header := range credentialHeaders {
+		hash.Write([]byte(header + ":" + req.Header.Get(header) + "\n"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
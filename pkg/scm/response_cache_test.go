@@ -0,0 +1,169 @@
+package scm_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCacheTransport(t *testing.T) {
+	t.Parallel()
+
+	type fixture struct {
+		transport *scm.ResponseCacheTransport
+		requests  *atomic.Int32
+		url       string
+		do        func(ctx context.Context, method, path, body string) (int, string)
+	}
+
+	setup := func(t *testing.T) fixture {
+		t.Helper()
+
+		requests := &atomic.Int32{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+
+			w.Write(append([]byte(r.URL.Path+" "), body...))
+		}))
+		t.Cleanup(server.Close)
+
+		transport := scm.NewResponseCacheTransport(nil, t.TempDir(), time.Hour)
+
+		do := func(ctx context.Context, method, path, body string) (int, string) {
+			var reader io.Reader
+			if len(body) > 0 {
+				reader = strings.NewReader(body)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, method, server.URL+path, reader)
+			require.NoError(t, err)
+
+			resp, err := (&http.Client{Transport: transport}).Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			output, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			return resp.StatusCode, string(output)
+		}
+
+		return fixture{transport: transport, requests: requests, url: server.URL, do: do}
+	}
+
+	ctx := state.WithCommitSHA(context.Background(), "abc123")
+
+	t.Run("GET hit and miss", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t)
+
+		_, first := f.do(ctx, http.MethodGet, "/api/v4/projects/1", "")
+		before := f.requests.Load()
+
+		status, second := f.do(ctx, http.MethodGet, "/api/v4/projects/1", "")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, first, second)
+		require.Equal(t, before, f.requests.Load(), "the second request must be served from the cache")
+
+		// Another commit SHA is a different cache key
+		f.do(state.WithCommitSHA(context.Background(), "def456"), http.MethodGet, "/api/v4/projects/1", "")
+		require.Equal(t, before+1, f.requests.Load())
+	})
+
+	t.Run("responses are not shared between tokens", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t)
+
+		// do sends a GET request authenticated with [token] in [header]
+		do := func(header, token string) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url+"/api/v4/projects/3", nil)
+			require.NoError(t, err)
+
+			req.Header.Set(header, token)
+
+			resp, err := (&http.Client{Transport: f.transport}).Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		do("PRIVATE-TOKEN", "maintainer-token")
+		do("PRIVATE-TOKEN", "maintainer-token")
+		require.Equal(t, int32(1), f.requests.Load())
+
+		do("PRIVATE-TOKEN", "reporter-token")
+		do("Authorization", "Bearer maintainer-token")
+		do("JOB-TOKEN", "job-token")
+		require.Equal(t, int32(4), f.requests.Load())
+	})
+
+	t.Run("GraphQL queries are cached, mutations are not", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t)
+		query := `{"query":"query { project { id } }"}`
+		mutation := `{"query":"mutation { mergeRequestSetDraft { errors } }"}`
+
+		f.do(ctx, http.MethodPost, "/api/graphql", query)
+		before := f.requests.Load()
+
+		_, output := f.do(ctx, http.MethodPost, "/api/graphql", query)
+		require.Contains(t, output, "query { project { id } }")
+		require.Equal(t, before, f.requests.Load())
+
+		f.do(ctx, http.MethodPost, "/api/graphql", mutation)
+		f.do(ctx, http.MethodPost, "/api/graphql", mutation)
+		require.Equal(t, before+2, f.requests.Load())
+	})
+
+	t.Run("writes and failed responses are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		f := setup(t)
+		before := f.requests.Load()
+
+		f.do(ctx, http.MethodPut, "/api/v4/projects/1/merge_requests/1", `{"labels":"a"}`)
+		f.do(ctx, http.MethodPut, "/api/v4/projects/1/merge_requests/1", `{"labels":"a"}`)
+
+		status, _ := f.do(ctx, http.MethodGet, "/missing", "")
+		require.Equal(t, http.StatusNotFound, status)
+		f.do(ctx, http.MethodGet, "/missing", "")
+
+		require.Equal(t, before+4, f.requests.Load())
+	})
+
+	t.Run("expired responses are fetched again", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now()
+		f := setup(t)
+		f.transport.Now = func() time.Time { return now }
+
+		f.do(ctx, http.MethodGet, "/api/v4/projects/2", "")
+		before := f.requests.Load()
+
+		now = now.Add(2 * time.Hour)
+
+		f.do(ctx, http.MethodGet, "/api/v4/projects/2", "")
+		require.Equal(t, before+1, f.requests.Load())
+	})
+}
@@ -0,0 +1,94 @@
+package scm
+
+import "context"
+
+// SizeBuckets are the Merge Request size buckets used by the 'size_bucket()' script function, from smallest to largest
+type SizeBuckets struct {
+	// Buckets are ordered by [SizeBucket.MaxLines]; the last bucket has no upper bound
+	Buckets []SizeBucket
+	// Exclude are the file patterns (in 'modified_files' syntax) left out of the changed lines count, like generated files
+	Exclude []string
+}
+
+// SizeBucket is a named size, for Merge Requests changing up to (and including) [MaxLines] lines
+type SizeBucket struct {
+	Name     string
+	MaxLines int
+}
+
+// DefaultSizeBuckets are the 'S', 'M', 'L' and 'XL' buckets, without any excluded files
+var DefaultSizeBuckets = SizeBuckets{
+	Buckets: []SizeBucket{
+		{Name: "S", MaxLines: 50},
+		{Name: "M", MaxLines: 250},
+		{Name: "L", MaxLines: 1000},
+		{Name: "XL"},
+	},
+}
+
+// FileChanges is the number of lines changed in a single file
+type FileChanges struct {
+	Path      string
+	Additions int
+	Deletions int
+}
+
+// ChangedLines returns the number of added and deleted lines in [changes], except for files matching [SizeBuckets.Exclude]
+func (s SizeBuckets) ChangedLines(changes []FileChanges) int {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+
+	excluded := map[string]bool{}
+
+	if len(s.Exclude) > 0 {
+		for _, path := range FindModifiedFiles(paths, s.Exclude...) {
+			excluded[path] = true
+		}
+	}
+
+	lines := 0
+
+	for _, change := range changes {
+		if excluded[change.Path] {
+			continue
+		}
+
+		lines += change.Additions + change.Deletions
+	}
+
+	return lines
+}
+
+// Bucket returns the name of the smallest bucket that fits [lines] changed lines
+func (s SizeBuckets) Bucket(lines int) string {
+	for i, bucket := range s.Buckets {
+		if lines <= bucket.MaxLines || i == len(s.Buckets)-1 {
+			return bucket.Name
+		}
+	}
+
+	return ""
+}
+
+type sizeBucketsKey struct{}
+
+// WithSizeBuckets sets the size buckets used by the script functions evaluated with [ctx]
+func WithSizeBuckets(ctx context.Context, sizeBuckets SizeBuckets) context.Context {
+	return context.WithValue(ctx, sizeBucketsKey{}, sizeBuckets)
+}
+
+// SizeBucketsFromContext returns the size buckets set with [WithSizeBuckets], or [DefaultSizeBuckets]
+func SizeBucketsFromContext(ctx context.Context) SizeBuckets {
+	if ctx == nil {
+		return DefaultSizeBuckets
+	}
+
+	sizeBuckets, ok := ctx.Value(sizeBucketsKey{}).(SizeBuckets)
+	if !ok {
+		return DefaultSizeBuckets
+	}
+
+	return sizeBuckets
+}
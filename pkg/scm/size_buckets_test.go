@@ -0,0 +1,49 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeBuckets_Bucket(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		lines int
+		want  string
+	}{
+		{lines: 0, want: "S"},
+		{lines: 50, want: "S"},
+		{lines: 51, want: "M"},
+		{lines: 250, want: "M"},
+		{lines: 251, want: "L"},
+		{lines: 1000, want: "L"},
+		{lines: 1001, want: "XL"},
+		{lines: 100000, want: "XL"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, scm.DefaultSizeBuckets.Bucket(tt.lines), tt.lines)
+	}
+}
+
+func TestSizeBuckets_ChangedLines(t *testing.T) {
+	t.Parallel()
+
+	changes := []scm.FileChanges{
+		{Path: "main.go", Additions: 10, Deletions: 5},
+		{Path: "go.sum", Additions: 400, Deletions: 100},
+		{Path: "pkg/scm/gitlab/context.gen.go", Additions: 2000},
+		{Path: "docs/index.md", Additions: 1, Deletions: 1},
+	}
+
+	require.Equal(t, 2517, scm.DefaultSizeBuckets.ChangedLines(changes))
+
+	sizeBuckets := scm.DefaultSizeBuckets
+	sizeBuckets.Exclude = []string{"go.sum", "*.gen.go"}
+
+	require.Equal(t, 17, sizeBuckets.ChangedLines(changes))
+	require.Equal(t, "S", sizeBuckets.Bucket(sizeBuckets.ChangedLines(changes)))
+}
@@ -0,0 +1,176 @@
+package scm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// ErrorCommentMarker is the hidden marker used to find (and update) the evaluation error comment
+const ErrorCommentMarker = "<!-- scm-engine:error -->"
+
+// Default templates of the [StatusMessages]
+const (
+	DefaultStatusNameTemplate    = "scm-engine"
+	DefaultStatusRunningTemplate = "Currently evaluating MR"
+	DefaultStatusSuccessTemplate = "OK"
+	DefaultStatusFailureTemplate = "{{ .Error }}"
+)
+
+// StatusMessages are the templates (using Go 'text/template' syntax) of the commit status name and descriptions, and of the
+// (optional) comment posted when an evaluation fails, so the wording can match the tone of the organization
+type StatusMessages struct {
+	name         *template.Template
+	running      *template.Template
+	success      *template.Template
+	failure      *template.Template
+	errorComment *template.Template
+}
+
+// StatusMessageData is the data available to the [StatusMessages] templates
+type StatusMessageData struct {
+	// The evaluation error, empty if the evaluation succeeded (or is still running)
+	Error string
+
+	// The ID of the webhook request being handled, empty outside of server mode
+	RequestID string
+
+	// The unique ID of the evaluation, as used in the logs
+	EvaluationID string
+
+	// Full path of the project (example: 'gitlab-org/gitlab')
+	Project string
+
+	// The Merge Request ID (IID) within the project
+	MergeRequestID string
+
+	// The commit the evaluation was pinned to
+	CommitSHA string
+}
+
+type statusMessagesKey struct{}
+
+// NewStatusMessages parses the templates of the commit status name, descriptions and the error comment,
+// with the built-in and registered [TemplateFunctions] available.
+//
+// An empty [errorComment] template doesn't post any error comment
+func NewStatusMessages(name, running, success, failure, errorComment string) (*StatusMessages, error) {
+	messages := &StatusMessages{}
+
+	for _, entry := range []struct {
+		name     string
+		text     string
+		template **template.Template
+	}{
+		{name: "name", text: name, template: &messages.name},
+		{name: "running", text: running, template: &messages.running},
+		{name: "success", text: success, template: &messages.success},
+		{name: "failure", text: failure, template: &messages.failure},
+		{name: "error comment", text: errorComment, template: &messages.errorComment},
+	} {
+		if len(entry.text) == 0 {
+			continue
+		}
+
+		tmpl, err := template.New(entry.name).Option("missingkey=error").Funcs(TemplateFunctions()).Parse(entry.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", entry.name, err)
+		}
+
+		*entry.template = tmpl
+	}
+
+	return messages, nil
+}
+
+// defaultStatusMessages are used when the context has no [StatusMessages]
+var defaultStatusMessages, _ = NewStatusMessages(DefaultStatusNameTemplate, DefaultStatusRunningTemplate, DefaultStatusSuccessTemplate, DefaultStatusFailureTemplate, "")
+
+// WithStatusMessages returns a context using the [messages] for commit statuses and error comments
+func WithStatusMessages(ctx context.Context, messages *StatusMessages) context.Context {
+	return context.WithValue(ctx, statusMessagesKey{}, messages)
+}
+
+// StatusMessagesFromContext returns the [StatusMessages] of the context, or the defaults if there are none
+func StatusMessagesFromContext(ctx context.Context) *StatusMessages {
+	if messages, ok := ctx.Value(statusMessagesKey{}).(*StatusMessages); ok {
+		return messages
+	}
+
+	return defaultStatusMessages
+}
+
+// Name renders the name (context) of the commit status.
+//
+// The status is updated by its name, so only the project and Merge Request ID are available to the template;
+// the name must be the same for every evaluation of the Merge Request
+func (m *StatusMessages) Name(ctx context.Context) string {
+	if m.name == nil {
+		return DefaultStatusNameTemplate
+	}
+
+	var data StatusMessageData
+
+	data.Project, _ = state.ProjectIDOk(ctx)
+	data.MergeRequestID, _ = state.MergeRequestIDOk(ctx)
+
+	var buf bytes.Buffer
+	if err := m.name.Execute(&buf, data); err != nil || len(buf.String()) == 0 {
+		return DefaultStatusNameTemplate
+	}
+
+	return buf.String()
+}
+
+// Running renders the commit status description while the evaluation is running
+func (m *StatusMessages) Running(ctx context.Context) string {
+	return render(ctx, m.running, DefaultStatusRunningTemplate, nil)
+}
+
+// Finished renders the commit status description once the evaluation finished, with [evalErr] if it failed
+func (m *StatusMessages) Finished(ctx context.Context, evalErr error) string {
+	if evalErr != nil {
+		return render(ctx, m.failure, evalErr.Error(), evalErr)
+	}
+
+	return render(ctx, m.success, DefaultStatusSuccessTemplate, nil)
+}
+
+// ErrorComment renders the comment posted when the evaluation failed with [evalErr], or returns false
+// if no error comment template is configured
+func (m *StatusMessages) ErrorComment(ctx context.Context, evalErr error) (string, bool) {
+	if m.errorComment == nil || evalErr == nil {
+		return "", false
+	}
+
+	return ErrorCommentMarker + "\n" + render(ctx, m.errorComment, evalErr.Error(), evalErr), true
+}
+
+// render executes [tmpl] with the evaluation details from the context, falling back to [fallback]
+// if there is no template or it fails to render, so a broken template never hides the outcome
+func render(ctx context.Context, tmpl *template.Template, fallback string, evalErr error) string {
+	if tmpl == nil {
+		return fallback
+	}
+
+	data := StatusMessageData{RequestID: state.RequestID(ctx)}
+
+	if evalErr != nil {
+		data.Error = evalErr.Error()
+	}
+
+	data.EvaluationID, _ = state.EvaluationIDOk(ctx)
+	data.Project, _ = state.ProjectIDOk(ctx)
+	data.MergeRequestID, _ = state.MergeRequestIDOk(ctx)
+	data.CommitSHA, _ = state.CommitSHAOk(ctx)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fallback
+	}
+
+	return buf.String()
+}
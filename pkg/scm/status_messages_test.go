@@ -0,0 +1,87 @@
+package scm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusMessages(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithRequestID(ctx, "req-123")
+	ctx = state.WithEvaluationID(ctx, "eval-456")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+
+	evalErr := errors.New("could not parse config file")
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		messages := scm.StatusMessagesFromContext(context.Background())
+
+		require.Equal(t, "scm-engine", messages.Name(ctx))
+		require.Equal(t, "Currently evaluating MR", messages.Running(ctx))
+		require.Equal(t, "OK", messages.Finished(ctx, nil))
+		require.Equal(t, "could not parse config file", messages.Finished(ctx, evalErr))
+
+		_, ok := messages.ErrorComment(ctx, evalErr)
+		require.False(t, ok)
+	})
+
+	t.Run("custom templates render with the error context", func(t *testing.T) {
+		t.Parallel()
+
+		messages, err := scm.NewStatusMessages(
+			"",
+			"Checking {{ .CommitSHA }}",
+			"All good",
+			"Failed: {{ .Error }} (request {{ .RequestID }}, see https://wiki.example.com/scm-engine)",
+			"scm-engine failed on !{{ .MergeRequestID }} in {{ .Project }} (evaluation `{{ .EvaluationID }}`): {{ .Error }}",
+		)
+		require.NoError(t, err)
+
+		ctx := scm.WithStatusMessages(ctx, messages)
+		messages = scm.StatusMessagesFromContext(ctx)
+
+		require.Equal(t, "Checking abc123", messages.Running(ctx))
+		require.Equal(t, "All good", messages.Finished(ctx, nil))
+		require.Equal(t, "Failed: could not parse config file (request req-123, see https://wiki.example.com/scm-engine)", messages.Finished(ctx, evalErr))
+
+		comment, ok := messages.ErrorComment(ctx, evalErr)
+		require.True(t, ok)
+		require.Equal(t, scm.ErrorCommentMarker+"\nscm-engine failed on !1 in jippi/scm-engine (evaluation `eval-456`): could not parse config file", comment)
+
+		// No error comment when the evaluation succeeded
+		_, ok = messages.ErrorComment(ctx, nil)
+		require.False(t, ok)
+	})
+
+	t.Run("values missing from the context render empty", func(t *testing.T) {
+		t.Parallel()
+
+		messages, err := scm.NewStatusMessages("", "", "", "{{ .Error }} [{{ .RequestID }}]", "")
+		require.NoError(t, err)
+
+		require.Equal(t, "boom []", messages.Finished(context.Background(), errors.New("boom")))
+	})
+
+	t.Run("broken templates fall back to the default wording", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := scm.NewStatusMessages("", "{{ .Error", "", "", "")
+		require.ErrorContains(t, err, "invalid running template")
+
+		messages, err := scm.NewStatusMessages("", "", "", "{{ .Unknown }}", "")
+		require.NoError(t, err)
+
+		require.Equal(t, "could not parse config file", messages.Finished(ctx, evalErr))
+	})
+}
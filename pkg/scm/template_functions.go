@@ -0,0 +1,174 @@
+package scm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var (
+	templateFunctionsMu sync.RWMutex
+	templateFunctions   = template.FuncMap{
+		"bold":            markdownBold,
+		"code":            markdownCode,
+		"escape_markdown": EscapeMarkdown,
+		"format_date":     formatDate,
+		"italic":          markdownItalic,
+		"link":            markdownLink,
+		"now":             time.Now,
+		"quote":           markdownQuote,
+	}
+)
+
+// templateFunctionNameRegexp matches the names text/template accepts for functions
+var templateFunctionNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// errorType is used to check the second return value of template functions
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterTemplateFunction makes [fn] available as [name] in the comment and commit status templates (see [NewStatusMessages]).
+//
+// Library consumers register their own functions, for example internal URL builders, at startup before any templates are parsed;
+// templates parsed earlier don't see the function. Like with text/template, [fn] must return either a single value, or a value and an error.
+//
+// Built-in and already registered functions can't be replaced
+func RegisterTemplateFunction(name string, fn any) error {
+	if !templateFunctionNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid template function name %q", name)
+	}
+
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func {
+		return fmt.Errorf("template function %q must be a function, got %T", name, fn)
+	}
+
+	switch out := value.Type(); {
+	case out.NumOut() == 1:
+	case out.NumOut() == 2 && out.Out(1) == errorType:
+	default:
+		return fmt.Errorf("template function %q must return a single value, or a value and an error", name)
+	}
+
+	templateFunctionsMu.Lock()
+	defer templateFunctionsMu.Unlock()
+
+	if _, ok := templateFunctions[name]; ok {
+		return fmt.Errorf("template function %q is already registered", name)
+	}
+
+	templateFunctions[name] = fn
+
+	return nil
+}
+
+// TemplateFunctions returns a copy of the built-in and registered template functions
+func TemplateFunctions() template.FuncMap {
+	templateFunctionsMu.RLock()
+	defer templateFunctionsMu.RUnlock()
+
+	functions := make(template.FuncMap, len(templateFunctions))
+	for name, fn := range templateFunctions {
+		functions[name] = fn
+	}
+
+	return functions
+}
+
+// markdownEscaper escapes the characters with a meaning in (GitLab flavored) markdown
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "(", `\(`, ")", `\)`, "#", `\#`, "+", `\+`, "-", `\-`, "!", `\!`, "|", `\|`, "~", `\~`,
+)
+
+// EscapeMarkdown escapes [text] so it renders literally in markdown, for example an error message in a comment
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func markdownBold(text string) string {
+	return "**" + text + "**"
+}
+
+func markdownItalic(text string) string {
+	return "_" + text + "_"
+}
+
+// markdownCode renders [text] as inline code, with a fence longer than any run of backticks in [text]
+func markdownCode(text string) string {
+	longest, run := 0, 0
+
+	for _, char := range text {
+		if char != '`' {
+			run = 0
+
+			continue
+		}
+
+		run++
+		longest = max(longest, run)
+	}
+
+	fence := strings.Repeat("`", longest+1)
+
+	// A space keeps backticks at the start or end of [text] apart from the fence
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+
+	return fence + text + fence
+}
+
+func markdownLink(text, url string) string {
+	return "[" + text + "](" + url + ")"
+}
+
+// markdownQuote renders every line of [text] as a blockquote
+func markdownQuote(text string) string {
+	return "> " + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n> ")
+}
+
+// formatDate formats [date] with the Go time [layout] (example: '2006-01-02'), or the layout names
+// 'date', 'datetime' and 'rfc3339'. A string [date] must be in RFC 3339 format
+func formatDate(layout string, date any) (string, error) {
+	var value time.Time
+
+	switch input := date.(type) {
+	case time.Time:
+		value = input
+
+	case *time.Time:
+		if input == nil {
+			return "", nil
+		}
+
+		value = *input
+
+	case string:
+		parsed, err := time.Parse(time.RFC3339, input)
+		if err != nil {
+			return "", fmt.Errorf("format_date: %w", err)
+		}
+
+		value = parsed
+
+	default:
+		return "", fmt.Errorf("format_date: unsupported date type %T", date)
+	}
+
+	switch layout {
+	case "date":
+		layout = time.DateOnly
+
+	case "datetime":
+		layout = time.DateTime
+
+	case "rfc3339":
+		layout = time.RFC3339
+	}
+
+	return value.Format(layout), nil
+}
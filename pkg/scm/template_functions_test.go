@@ -0,0 +1,81 @@
+package scm_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterTemplateFunction(t *testing.T) {
+	t.Parallel()
+
+	// The registry is global; names are unique to this test
+	require.NoError(t, scm.RegisterTemplateFunction("runbook_url", func(project, requestID string) string {
+		return "https://wiki.example.com/scm-engine?project=" + url.QueryEscape(project) + "&request=" + requestID
+	}))
+
+	messages, err := scm.NewStatusMessages("", "", "", "", `scm-engine failed: {{ code .Error }}, see the {{ link "runbook" (runbook_url .Project .RequestID) }}`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = state.WithRequestID(ctx, "req-123")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+
+	comment, ok := messages.ErrorComment(ctx, errors.New("invalid `label` script"))
+	require.True(t, ok)
+	require.Equal(t, scm.ErrorCommentMarker+"\nscm-engine failed: ``invalid `label` script``, see the [runbook](https://wiki.example.com/scm-engine?project=jippi%2Fscm-engine&request=req-123)", comment)
+
+	t.Run("invalid registrations", func(t *testing.T) {
+		t.Parallel()
+
+		require.ErrorContains(t, scm.RegisterTemplateFunction("runbook_url", func() string { return "" }), `template function "runbook_url" is already registered`)
+		require.ErrorContains(t, scm.RegisterTemplateFunction("bold", func() string { return "" }), `template function "bold" is already registered`)
+		require.ErrorContains(t, scm.RegisterTemplateFunction("runbook-url", func() string { return "" }), `invalid template function name "runbook-url"`)
+		require.ErrorContains(t, scm.RegisterTemplateFunction("not_a_function", "value"), `template function "not_a_function" must be a function, got string`)
+		require.ErrorContains(t, scm.RegisterTemplateFunction("no_result", func() {}), `template function "no_result" must return a single value, or a value and an error`)
+		require.ErrorContains(t, scm.RegisterTemplateFunction("no_error", func() (string, string) { return "", "" }), `template function "no_error" must return a single value, or a value and an error`)
+	})
+}
+
+func TestTemplateFunctions_BuiltIn(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2024, 5, 17, 13, 37, 0, 0, time.UTC)
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{template: `{{ bold "important" }}`, want: "**important**"},
+		{template: `{{ italic "maybe" }}`, want: "_maybe_"},
+		{template: `{{ code "go test" }}`, want: "`go test`"},
+		{template: "{{ code \"``\" }}", want: "``` `` ```"},
+		{template: `{{ link "docs" "https://example.com" }}`, want: "[docs](https://example.com)"},
+		{template: `{{ quote .Error }}`, want: "> line one\n> line two"},
+		{template: `{{ escape_markdown "*not* [a] link" }}`, want: `\*not\* \[a\] link`},
+		{template: `{{ format_date "date" .Created }}`, want: "2024-05-17"},
+		{template: `{{ format_date "Jan 2, 2006 15:04" .Created }}`, want: "May 17, 2024 13:37"},
+		{template: `{{ format_date "datetime" "2024-05-17T13:37:00Z" }}`, want: "2024-05-17 13:37:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := template.New("test").Funcs(scm.TemplateFunctions()).Parse(tt.template)
+			require.NoError(t, err)
+
+			var out strings.Builder
+			require.NoError(t, tmpl.Execute(&out, map[string]any{"Error": "line one\nline two\n", "Created": created}))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
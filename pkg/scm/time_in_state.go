@@ -0,0 +1,70 @@
+package scm
+
+import (
+	"time"
+)
+
+// StateEvent is a Merge Request being closed, reopened or merged
+type StateEvent struct {
+	State     string // "closed", "reopened" or "merged"
+	CreatedAt time.Time
+}
+
+// TimeSince returns how long ago the most recent of the [timestamps] was at [now], ignoring zero timestamps.
+//
+// Returns 0 if all [timestamps] are zero, or in the future
+func TimeSince(now time.Time, timestamps ...time.Time) time.Duration {
+	var latest time.Time
+
+	for _, timestamp := range timestamps {
+		if timestamp.After(latest) {
+			latest = timestamp
+		}
+	}
+
+	if latest.IsZero() || latest.After(now) {
+		return 0
+	}
+
+	return now.Sub(latest)
+}
+
+// TimeOpen returns how long a Merge Request in [state] has been open at [now]; since it was created at [createdAt],
+// or since it was last reopened according to its state [events].
+//
+// A Merge Request that isn't open (for example, closed or merged) returns 0
+func TimeOpen(state string, createdAt time.Time, events []StateEvent, now time.Time) time.Duration {
+	if state != "opened" {
+		return 0
+	}
+
+	openedAt := createdAt
+
+	for _, event := range events {
+		if event.State == "reopened" && event.CreatedAt.After(openedAt) {
+			openedAt = event.CreatedAt
+		}
+	}
+
+	return TimeSince(now, openedAt)
+}
+
+// TimeLabeled returns how long the [label] has been on the Merge Request at [now], since it was last added according
+// to the label [events]. A label that isn't [present] on the Merge Request returns 0.
+//
+// A label present without an 'add' event (for example, added before label events were recorded) returns 0 as well
+func TimeLabeled(label string, present bool, events []LabelEvent, now time.Time) time.Duration {
+	if !present {
+		return 0
+	}
+
+	var addedAt time.Time
+
+	for _, event := range events {
+		if event.Label == label && event.Action == "add" && event.CreatedAt.After(addedAt) {
+			addedAt = event.CreatedAt
+		}
+	}
+
+	return TimeSince(now, addedAt)
+}
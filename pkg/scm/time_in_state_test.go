@@ -0,0 +1,63 @@
+package scm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 2*time.Hour, scm.TimeSince(now, now.Add(-5*time.Hour), time.Time{}, now.Add(-2*time.Hour)))
+	require.Equal(t, time.Duration(0), scm.TimeSince(now))
+	require.Equal(t, time.Duration(0), scm.TimeSince(now, time.Time{}))
+	require.Equal(t, time.Duration(0), scm.TimeSince(now, now.Add(time.Hour)))
+}
+
+func TestTimeOpen(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	createdAt := now.AddDate(0, 0, -10)
+
+	events := []scm.StateEvent{
+		{State: "closed", CreatedAt: now.AddDate(0, 0, -5)},
+		{State: "reopened", CreatedAt: now.AddDate(0, 0, -3)},
+	}
+
+	// Open since it was last reopened
+	require.Equal(t, 72*time.Hour, scm.TimeOpen("opened", createdAt, events, now))
+
+	// Open since it was created
+	require.Equal(t, 240*time.Hour, scm.TimeOpen("opened", createdAt, nil, now))
+
+	// Not open
+	require.Equal(t, time.Duration(0), scm.TimeOpen("merged", createdAt, events, now))
+}
+
+func TestTimeLabeled(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	events := []scm.LabelEvent{
+		{Label: "in-review", Action: "add", CreatedAt: now.Add(-96 * time.Hour)},
+		{Label: "in-review", Action: "remove", CreatedAt: now.Add(-72 * time.Hour)},
+		{Label: "in-review", Action: "add", CreatedAt: now.Add(-50 * time.Hour)},
+		{Label: "bug", Action: "add", CreatedAt: now.Add(-time.Hour)},
+	}
+
+	// In review since the label was last added
+	require.Equal(t, 50*time.Hour, scm.TimeLabeled("in-review", true, events, now))
+
+	// Not in review
+	require.Equal(t, time.Duration(0), scm.TimeLabeled("in-review", false, events, now))
+
+	// No label events for the label
+	require.Equal(t, time.Duration(0), scm.TimeLabeled("ready", true, events, now))
+}
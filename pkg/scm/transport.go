@@ -0,0 +1,53 @@
+package scm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// NewTransport creates the [http.Transport] for API requests, sending them through [proxyURL] (if any),
+// and trusting the PEM encoded certificates in the [caBundle] file (if any) as well as the system certificates,
+// for example to run behind a corporate proxy with its own TLS certificates.
+//
+// Without a [proxyURL], the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used like [http.DefaultTransport]
+func NewTransport(proxyURL, caBundle string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+
+	if len(proxyURL) > 0 {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+
+		if len(proxy.Scheme) == 0 || len(proxy.Host) == 0 {
+			return nil, fmt.Errorf("invalid proxy URL %q, must be an absolute URL (example: 'http://proxy.example.com:3128')", proxyURL)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if len(caBundle) > 0 {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("the CA bundle has no PEM encoded certificates")
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return transport, nil
+}
@@ -0,0 +1,71 @@
+package scm_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport_ProxyURL(t *testing.T) {
+	t.Parallel()
+
+	transport, err := scm.NewTransport("http://proxy.example.com:3128", "")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://gitlab.example.com/api/v4/projects", nil)
+	require.NoError(t, err)
+
+	proxy, err := transport.Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.example.com:3128", proxy.String())
+}
+
+func TestNewTransport_CABundle(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	// The certificate of the test server is only trusted with the CA bundle
+	transport, err := scm.NewTransport("", bundle)
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	transport, err = scm.NewTransport("", "")
+	require.NoError(t, err)
+
+	_, err = (&http.Client{Transport: transport}).Get(server.URL) //nolint:bodyclose
+	require.ErrorContains(t, err, "certificate")
+}
+
+func TestNewTransport_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := scm.NewTransport("proxy.example.com", "")
+	require.ErrorContains(t, err, "must be an absolute URL")
+
+	_, err = scm.NewTransport("", filepath.Join(t.TempDir(), "missing.pem"))
+	require.ErrorContains(t, err, "could not read the CA bundle")
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+
+	_, err = scm.NewTransport("", empty)
+	require.ErrorContains(t, err, "no PEM encoded certificates")
+}
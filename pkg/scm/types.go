@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/jippi/scm-engine/pkg/state"
 	"github.com/jippi/scm-engine/pkg/types"
@@ -106,7 +107,21 @@ type ListOptions struct {
 type ListMergeRequestsOptions struct {
 	ListOptions
 	State string
+
+	// The number of Merge Requests to read per page; all pages are read
 	First int
+
+	// (Optional) Only Merge Requests with all of these labels
+	Labels []string
+
+	// (Optional) Only Merge Requests opened by this user
+	AuthorUsername string
+
+	// (Optional) Only Merge Requests in this milestone
+	MilestoneTitle string
+
+	// (Optional) Only Merge Requests with this text in their title or description
+	Search string
 }
 
 type ListMergeRequest struct {
@@ -213,6 +228,24 @@ type EvaluationResult struct {
 
 	// Wether the evaluation rule matched positive (add label) or negative (remove label)
 	Matched bool
+
+	// ExpiresAfter controls how long the label may stay on the Merge Request after it was added.
+	//
+	// Zero means the label never expires
+	ExpiresAfter time.Duration
+
+	// RemoveAfter controls how long the label stays on the Merge Request after its script started evaluating negatively.
+	//
+	// Zero means the label is removed right away
+	RemoveAfter time.Duration
+}
+
+// LabelEvent is a label being added or removed from a Merge Request
+type LabelEvent struct {
+	Label     string
+	Action    string // "add" or "remove"
+	User      string // The username of the user that added or removed the label
+	CreatedAt time.Time
 }
 
 func (local EvaluationResult) IsEqual(ctx context.Context, remote *Label) bool {
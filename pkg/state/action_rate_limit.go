@@ -0,0 +1,30 @@
+package state
+
+import (
+	"context"
+	"time"
+)
+
+// actionRunLog holds the time of the recent runs of every rate limited action, see [RecordActionRun]
+var actionRunLog rateLimitLog // Zero value is empty and ready for use
+
+// AllowActionRun returns false if the [action] already ran [maxRuns] times for the rate limit [key] within [window]
+// at [now] and must be skipped. It doesn't record a run, see [RecordActionRun].
+//
+// The key is shared between all Merge Requests in the project being evaluated. Like [AllowComment], the history
+// is kept in memory, so it's shared between evaluations in server mode, but starts empty for every CI job,
+// and forgotten once the key had no runs for a whole window
+func AllowActionRun(ctx context.Context, action, key string, maxRuns int, window time.Duration, now time.Time) bool {
+	return !actionRunLog.full(actionRunKey(ctx, action, key), now, maxRuns, window)
+}
+
+// RecordActionRun records a run of the [action] for the rate limit [key] at [now], once its steps were applied
+func RecordActionRun(ctx context.Context, action, key string, window time.Duration, now time.Time) {
+	actionRunLog.record(actionRunKey(ctx, action, key), now, window)
+}
+
+func actionRunKey(ctx context.Context, action, key string) string {
+	providerName, _ := ctx.Value(provider).(string)
+
+	return providerName + "/" + ProjectID(ctx) + "/" + action + "/" + key
+}
@@ -0,0 +1,45 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowActionRun(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	ctx = state.WithProjectID(ctx, "jippi/action-rate-limit")
+	ctx = state.WithMergeRequestID(ctx, "1")
+
+	// Checking the limit doesn't use it up, only recorded runs do
+	require.True(t, state.AllowActionRun(ctx, "notify", "alice", 1, time.Hour, now))
+	require.True(t, state.AllowActionRun(ctx, "notify", "alice", 1, time.Hour, now))
+
+	// The key is hot after the first run within the window, also for other Merge Requests
+	state.RecordActionRun(ctx, "notify", "alice", time.Hour, now)
+	require.False(t, state.AllowActionRun(ctx, "notify", "alice", 1, time.Hour, now.Add(time.Minute)))
+	require.False(t, state.AllowActionRun(state.WithMergeRequestID(ctx, "2"), "notify", "alice", 1, time.Hour, now.Add(2*time.Minute)))
+
+	// Other keys, actions and projects have their own limit
+	require.True(t, state.AllowActionRun(ctx, "notify", "bob", 1, time.Hour, now))
+	require.True(t, state.AllowActionRun(ctx, "other", "alice", 1, time.Hour, now))
+	require.True(t, state.AllowActionRun(state.WithProjectID(ctx, "jippi/other"), "notify", "alice", 1, time.Hour, now))
+
+	// The action runs again once the window is over
+	require.True(t, state.AllowActionRun(ctx, "notify", "alice", 1, time.Hour, now.Add(time.Hour)))
+	state.RecordActionRun(ctx, "notify", "alice", time.Hour, now.Add(time.Hour))
+	require.False(t, state.AllowActionRun(ctx, "notify", "alice", 1, time.Hour, now.Add(time.Hour+time.Second)))
+
+	// More than one run per window
+	state.RecordActionRun(ctx, "twice", "alice", time.Hour, now)
+	require.True(t, state.AllowActionRun(ctx, "twice", "alice", 2, time.Hour, now))
+	state.RecordActionRun(ctx, "twice", "alice", time.Hour, now)
+	require.False(t, state.AllowActionRun(ctx, "twice", "alice", 2, time.Hour, now))
+}
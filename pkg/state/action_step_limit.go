@@ -0,0 +1,54 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// DefaultMaxActionSteps is the default maximum number of action steps applied in a single evaluation, see [WithMaxActionSteps]
+const DefaultMaxActionSteps = 50
+
+// ErrMaxActionStepsExceeded is returned when an evaluation tries to apply more action steps than allowed by [WithMaxActionSteps]
+var ErrMaxActionStepsExceeded = errors.New("maximum number of action steps per evaluation exceeded")
+
+type actionStepCounterKey struct{}
+
+// WithMaxActionSteps allows at most [maxSteps] action steps to be applied in a single evaluation of a Merge Request,
+// as a safety valve against a configuration that, for example, posts hundreds of comments.
+//
+// A [maxSteps] of 0 (or less) disables the limit
+func WithMaxActionSteps(ctx context.Context, maxSteps int) context.Context {
+	return context.WithValue(ctx, maxActionSteps, maxSteps)
+}
+
+// WithActionStepCounter starts counting the action steps of a new evaluation, see [CountActionStep]
+func WithActionStepCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, actionStepCounterKey{}, &atomic.Int64{})
+}
+
+// CountActionStep records an action step about to be applied, or returns [ErrMaxActionStepsExceeded]
+// if the limit (see [WithMaxActionSteps]) has been reached and no further steps must be applied
+func CountActionStep(ctx context.Context) error {
+	limit, _ := ctx.Value(maxActionSteps).(int)
+	if limit <= 0 {
+		return nil
+	}
+
+	counter, ok := ctx.Value(actionStepCounterKey{}).(*atomic.Int64)
+	if !ok {
+		return nil
+	}
+
+	if counter.Add(1) > int64(limit) {
+		slogctx.Error(ctx, "Maximum number of action steps reached, aborting the remaining actions", slog.Int("max_action_steps", limit))
+
+		return fmt.Errorf("%w (%d)", ErrMaxActionStepsExceeded, limit)
+	}
+
+	return nil
+}
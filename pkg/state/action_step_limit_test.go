@@ -0,0 +1,35 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountActionStep(t *testing.T) {
+	t.Parallel()
+
+	ctx := state.WithMaxActionSteps(context.Background(), 3)
+
+	// Every evaluation gets its own budget
+	first := state.WithActionStepCounter(ctx)
+
+	for range 3 {
+		require.NoError(t, state.CountActionStep(first))
+	}
+
+	require.ErrorIs(t, state.CountActionStep(first), state.ErrMaxActionStepsExceeded)
+	require.ErrorIs(t, state.CountActionStep(first), state.ErrMaxActionStepsExceeded)
+
+	second := state.WithActionStepCounter(ctx)
+	require.NoError(t, state.CountActionStep(second))
+
+	// 0 disables the limit
+	unlimited := state.WithActionStepCounter(state.WithMaxActionSteps(context.Background(), 0))
+
+	for range 100 {
+		require.NoError(t, state.CountActionStep(unlimited))
+	}
+}
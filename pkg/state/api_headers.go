@@ -0,0 +1,18 @@
+package state
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithAPIHeaders sets the static headers (including the 'User-Agent') added to every API request
+func WithAPIHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, apiHeaders, headers)
+}
+
+// APIHeaders returns the static headers added to every API request, see [WithAPIHeaders]
+func APIHeaders(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(apiHeaders).(http.Header)
+
+	return headers
+}
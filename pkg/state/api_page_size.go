@@ -0,0 +1,35 @@
+package state
+
+import (
+	"context"
+	"fmt"
+)
+
+// MaxAPIPageSize is the largest page size the GitLab API allows for list requests, and the default, see [WithAPIPageSize]
+const MaxAPIPageSize = 100
+
+// ValidateAPIPageSize checks the [size] is within the page size limits of the GitLab API
+func ValidateAPIPageSize(size int) error {
+	if size < 1 || size > MaxAPIPageSize {
+		return fmt.Errorf("the API page size must be between 1 and %d, got %d", MaxAPIPageSize, size)
+	}
+
+	return nil
+}
+
+// WithAPIPageSize sets the number of items requested per page by paginated API list requests
+func WithAPIPageSize(ctx context.Context, size int) context.Context {
+	return context.WithValue(ctx, apiPageSize, size)
+}
+
+// APIPageSize returns the number of items to request per page, see [WithAPIPageSize].
+//
+// Defaults to [MaxAPIPageSize], and sizes outside the API limits are capped
+func APIPageSize(ctx context.Context) int {
+	size, ok := ctx.Value(apiPageSize).(int)
+	if !ok || size > MaxAPIPageSize {
+		return MaxAPIPageSize
+	}
+
+	return max(size, 1)
+}
@@ -0,0 +1,22 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIPageSize(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, state.MaxAPIPageSize, state.APIPageSize(context.Background()))
+	require.Equal(t, 20, state.APIPageSize(state.WithAPIPageSize(context.Background(), 20)))
+	require.Equal(t, state.MaxAPIPageSize, state.APIPageSize(state.WithAPIPageSize(context.Background(), 500)))
+
+	require.NoError(t, state.ValidateAPIPageSize(1))
+	require.NoError(t, state.ValidateAPIPageSize(100))
+	require.ErrorContains(t, state.ValidateAPIPageSize(0), "the API page size must be between 1 and 100, got 0")
+	require.ErrorContains(t, state.ValidateAPIPageSize(101), "the API page size must be between 1 and 100, got 101")
+}
@@ -0,0 +1,214 @@
+package state
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	slogctx "github.com/veqryn/slog-context"
+)
+
+// rateLimitSweepInterval is how often the rate limit logs evict the histories without events in their window
+const rateLimitSweepInterval = time.Minute
+
+// commentLog holds the time of the recent comments posted on every Merge Request, see [AllowComment]
+var commentLog rateLimitLog // Zero value is empty and ready for use
+
+// rateLimitHistories is the number of histories in the rate limit logs, published on the '/_metrics' endpoint of the server
+var rateLimitHistories = expvar.NewMap("rate_limit_histories")
+
+func init() {
+	rateLimitHistories.Set("comments", expvar.Func(func() any { return commentLog.len() }))
+	rateLimitHistories.Set("actions", expvar.Func(func() any { return actionRunLog.len() }))
+}
+
+type commentRateLimitValue struct {
+	max    int
+	window time.Duration
+}
+
+// rateLimitLog holds the [rateLimitHistory] of every rate limit key.
+//
+// Histories without any events in their window are evicted (at most every [rateLimitSweepInterval]),
+// so a long-running server doesn't keep a history for every Merge Request it ever evaluated
+type rateLimitLog struct {
+	histories sync.Map
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// allow records an event for [key] at [now], or returns false if [limit] events already happened within [window]
+func (log *rateLimitLog) allow(key string, now time.Time, limit int, window time.Duration) bool {
+	log.sweep(now)
+
+	for {
+		value, _ := log.histories.LoadOrStore(key, &rateLimitHistory{})
+
+		// The history was evicted since it was loaded, so record the event in a new one
+		if allowed, ok := value.(*rateLimitHistory).allow(now, limit, window); ok { //nolint:forcetypeassert
+			return allowed
+		}
+	}
+}
+
+// full returns true if [limit] events already happened for [key] within [window] at [now], without recording an event
+func (log *rateLimitLog) full(key string, now time.Time, limit int, window time.Duration) bool {
+	log.sweep(now)
+
+	value, ok := log.histories.Load(key)
+	if !ok {
+		return false
+	}
+
+	return value.(*rateLimitHistory).full(now, limit, window) //nolint:forcetypeassert
+}
+
+// record records an event for [key] at [now], regardless of any limit
+func (log *rateLimitLog) record(key string, now time.Time, window time.Duration) {
+	log.allow(key, now, math.MaxInt, window)
+}
+
+// sweep evicts the histories without any events in their window at [now]
+func (log *rateLimitLog) sweep(now time.Time) {
+	log.mu.Lock()
+
+	if now.Sub(log.lastSweep) < rateLimitSweepInterval {
+		log.mu.Unlock()
+
+		return
+	}
+
+	log.lastSweep = now
+	log.mu.Unlock()
+
+	log.histories.Range(func(key, value any) bool {
+		if value.(*rateLimitHistory).evict(now) { //nolint:forcetypeassert
+			log.histories.CompareAndDelete(key, value)
+		}
+
+		return true
+	})
+}
+
+// len returns the number of histories in the log
+func (log *rateLimitLog) len() int {
+	count := 0
+
+	log.histories.Range(func(any, any) bool {
+		count++
+
+		return true
+	})
+
+	return count
+}
+
+// rateLimitHistory is the time of the comments (or action runs) within the rate limit window
+type rateLimitHistory struct {
+	mu      sync.Mutex
+	times   []time.Time
+	window  time.Duration
+	evicted bool
+}
+
+// allow records an event at [now], or returns false if [limit] events already happened within [window].
+//
+// [ok] is false if the history was evicted from its [rateLimitLog], and the event wasn't recorded
+func (history *rateLimitHistory) allow(now time.Time, limit int, window time.Duration) (allowed, ok bool) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	if history.evicted {
+		return false, false
+	}
+
+	history.window = window
+	history.forget(now)
+
+	if len(history.times) >= limit {
+		return false, true
+	}
+
+	history.times = append(history.times, now)
+
+	return true, true
+}
+
+// full returns true if [limit] events already happened within [window] at [now]
+func (history *rateLimitHistory) full(now time.Time, limit int, window time.Duration) bool {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	// Evicted histories have no events in their window
+	if history.evicted {
+		return false
+	}
+
+	history.window = window
+	history.forget(now)
+
+	return len(history.times) >= limit
+}
+
+// evict marks the history as evicted and returns true if it has no events within its window at [now]
+func (history *rateLimitHistory) evict(now time.Time) bool {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	history.forget(now)
+
+	if len(history.times) > 0 {
+		return false
+	}
+
+	history.evicted = true
+
+	return true
+}
+
+// forget removes the events that are outside the window at [now]
+func (history *rateLimitHistory) forget(now time.Time) {
+	recent := history.times[:0]
+
+	for _, at := range history.times {
+		if now.Sub(at) < history.window {
+			recent = append(recent, at)
+		}
+	}
+
+	history.times = recent
+}
+
+// WithCommentRateLimit allows at most [maxComments] comments per Merge Request within [window].
+//
+// A [maxComments] of 0 (or less) disables the rate limit
+func WithCommentRateLimit(ctx context.Context, maxComments int, window time.Duration) context.Context {
+	return context.WithValue(ctx, commentRateLimit, commentRateLimitValue{max: maxComments, window: window})
+}
+
+// AllowComment records a comment on the Merge Request being evaluated at [now], or returns false if the
+// comment rate limit (see [WithCommentRateLimit]) has been reached and the comment must be skipped.
+//
+// The history is kept in memory, so it's shared between evaluations in server mode, but starts empty for every CI job.
+// Histories are forgotten once the Merge Request had no comments for a whole window
+func AllowComment(ctx context.Context, now time.Time) bool {
+	limit, _ := ctx.Value(commentRateLimit).(commentRateLimitValue)
+	if limit.max <= 0 {
+		return true
+	}
+
+	providerName, _ := ctx.Value(provider).(string)
+	key := providerName + "/" + ProjectID(ctx) + "/" + MergeRequestID(ctx)
+
+	if !commentLog.allow(key, now, limit.max, limit.window) {
+		slogctx.Warn(ctx, "Comment rate limit reached, skipping comment", slog.Int("max_comments", limit.max), slog.Duration("window", limit.window))
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+package state_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowComment(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	ctx = state.WithProjectID(ctx, "jippi/comment-rate-limit")
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommentRateLimit(ctx, 2, time.Hour)
+
+	// The N+1th comment within the window is suppressed
+	require.True(t, state.AllowComment(ctx, now))
+	require.True(t, state.AllowComment(ctx, now.Add(time.Minute)))
+	require.False(t, state.AllowComment(ctx, now.Add(2*time.Minute)))
+
+	// Other Merge Requests have their own limit
+	require.True(t, state.AllowComment(state.WithMergeRequestID(ctx, "2"), now))
+
+	// Comments are allowed again once the first one left the window
+	require.True(t, state.AllowComment(ctx, now.Add(time.Hour)))
+	require.False(t, state.AllowComment(ctx, now.Add(time.Hour+time.Second)))
+
+	// No limit by default
+	unlimited := state.WithMergeRequestID(state.WithProjectID(context.Background(), "jippi/comment-rate-limit"), "3")
+
+	for range 10 {
+		require.True(t, state.AllowComment(unlimited, now))
+	}
+}
+
+//nolint:paralleltest // The rate limit histories are shared by the whole process
+func TestRateLimitHistories_Evicted(t *testing.T) {
+	// Later than the clock of any other test, so all other histories are out of their window too
+	now := time.Date(2100, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	ctx = state.WithProjectID(ctx, "jippi/rate-limit-eviction")
+	ctx = state.WithCommentRateLimit(ctx, 1, time.Hour)
+
+	for _, id := range []string{"1", "2", "3"} {
+		require.True(t, state.AllowComment(state.WithMergeRequestID(ctx, id), now))
+		state.RecordActionRun(state.WithMergeRequestID(ctx, id), "notify", id, time.Minute, now)
+	}
+
+	require.Equal(t, map[string]int{"comments": 3, "actions": 3}, rateLimitHistories(t))
+
+	// The histories are kept while they have events within their window
+	require.False(t, state.AllowComment(state.WithMergeRequestID(ctx, "1"), now.Add(2*time.Minute)))
+	require.Equal(t, map[string]int{"comments": 3, "actions": 3}, rateLimitHistories(t))
+
+	// ... and evicted once they don't. The history of the Merge Request evaluated now is recreated
+	require.True(t, state.AllowComment(state.WithMergeRequestID(ctx, "1"), now.Add(time.Hour)))
+	require.True(t, state.AllowActionRun(state.WithMergeRequestID(ctx, "1"), "notify", "1", 1, time.Minute, now.Add(time.Hour)))
+	state.RecordActionRun(state.WithMergeRequestID(ctx, "1"), "notify", "1", time.Minute, now.Add(time.Hour))
+	require.Equal(t, map[string]int{"comments": 1, "actions": 1}, rateLimitHistories(t))
+
+	// The limit still applies to the recreated history
+	require.False(t, state.AllowComment(state.WithMergeRequestID(ctx, "1"), now.Add(time.Hour+time.Second)))
+}
+
+func rateLimitHistories(t *testing.T) map[string]int {
+	t.Helper()
+
+	histories := map[string]int{}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("rate_limit_histories").String()), &histories))
+
+	return histories
+}
@@ -25,6 +25,38 @@ const (
 	updatePipeline
 	updatePipelineURL
 	evaluationID
+	missingConfigBehavior
+	missingCommitSHABehavior
+	tokenMappings
+	readOnly
+	jobToken
+	triggerEvent
+	profile
+	commentFooter
+	locale
+	onlyTags
+	skipTags
+	allowPartialData
+	triggerOnChanges
+	evaluateArchivedProjects
+	commentRateLimit
+	replayFailedActions
+	maxActionSteps
+	requestID
+	responseCache
+	configChangePolicy
+	apiHeaders
+	apiPageSize
+	httpTransport
+)
+
+// Kinds of events that can trigger an evaluation, see [WithTriggerEvent]
+const (
+	TriggerEventOpen   = "open"
+	TriggerEventReopen = "reopen"
+	TriggerEventUpdate = "update"
+	TriggerEventNote   = "note"
+	TriggerEventMerge  = "merge"
 )
 
 func ProjectID(ctx context.Context) string {
@@ -127,14 +159,226 @@ func WithMergeRequestID(ctx context.Context, id string) context.Context {
 	return ctx
 }
 
+func WithMissingConfigBehavior(ctx context.Context, behavior string) context.Context {
+	ctx = slogctx.With(ctx, slog.String("missing_config_behavior", behavior))
+	ctx = context.WithValue(ctx, missingConfigBehavior, behavior)
+
+	return ctx
+}
+
+// MissingConfigBehavior returns the configured behavior for projects without a configuration file.
+//
+// Returns an empty string if the behavior has not been configured.
+func MissingConfigBehavior(ctx context.Context) string {
+	behavior, _ := ctx.Value(missingConfigBehavior).(string)
+
+	return behavior
+}
+
+func WithMissingCommitSHABehavior(ctx context.Context, behavior string) context.Context {
+	ctx = slogctx.With(ctx, slog.String("missing_commit_sha_behavior", behavior))
+	ctx = context.WithValue(ctx, missingCommitSHABehavior, behavior)
+
+	return ctx
+}
+
+// MissingCommitSHABehavior returns the configured behavior for webhook events without a commit SHA.
+//
+// Returns an empty string if the behavior has not been configured.
+func MissingCommitSHABehavior(ctx context.Context) string {
+	behavior, _ := ctx.Value(missingCommitSHABehavior).(string)
+
+	return behavior
+}
+
+func WithConfigChangePolicy(ctx context.Context, policy string) context.Context {
+	ctx = slogctx.With(ctx, slog.String("config_change_policy", policy))
+	ctx = context.WithValue(ctx, configChangePolicy, policy)
+
+	return ctx
+}
+
+// ConfigChangePolicy returns the configured policy for Merge Requests changing the configuration file.
+//
+// Returns an empty string if the policy has not been configured.
+func ConfigChangePolicy(ctx context.Context) string {
+	policy, _ := ctx.Value(configChangePolicy).(string)
+
+	return policy
+}
+
+func WithTokenMappings(ctx context.Context, mappings []string) context.Context {
+	return context.WithValue(ctx, tokenMappings, mappings)
+}
+
+// TokenMappings returns the configured "project-glob=token" mappings
+//
+// Returns nil if no mappings have been configured.
+func TokenMappings(ctx context.Context) []string {
+	mappings, _ := ctx.Value(tokenMappings).([]string)
+
+	return mappings
+}
+
+// IsDryRun returns true if the evaluation must not change anything; always true in read-only mode
 func IsDryRun(ctx context.Context) bool {
-	return ctx.Value(dryRun).(bool) //nolint:forcetypeassert
+	return IsReadOnly(ctx) || ctx.Value(dryRun).(bool) //nolint:forcetypeassert
+}
+
+// IsDryRunOk is like [IsDryRun], but also returns whether the dry-run mode was set, rather than panicking when it's not
+func IsDryRunOk(ctx context.Context) (bool, bool) {
+	value, ok := ctx.Value(dryRun).(bool)
+
+	return IsReadOnly(ctx) || value, ok
+}
+
+func WithReadOnly(ctx context.Context, value bool) context.Context {
+	ctx = slogctx.With(ctx, slog.Bool("read_only", value))
+	ctx = context.WithValue(ctx, readOnly, value)
+
+	return ctx
+}
+
+// IsReadOnly returns true if scm-engine is running in read-only mode, where nothing
+// may be changed regardless of the 'dry_run' setting in the configuration file
+func IsReadOnly(ctx context.Context) bool {
+	value, _ := ctx.Value(readOnly).(bool)
+
+	return value
+}
+
+// WithJobToken marks the [Token] as a GitLab CI job token (CI_JOB_TOKEN) rather than a personal, group or project access token
+func WithJobToken(ctx context.Context, value bool) context.Context {
+	return context.WithValue(ctx, jobToken, value)
+}
+
+// IsJobToken returns true if the [Token] is a GitLab CI job token
+func IsJobToken(ctx context.Context) bool {
+	value, _ := ctx.Value(jobToken).(bool)
+
+	return value
+}
+
+// WithTriggerEvent sets the kind of webhook event (e.g. [TriggerEventOpen]) that triggered the evaluation
+func WithTriggerEvent(ctx context.Context, value string) context.Context {
+	ctx = slogctx.With(ctx, slog.String("trigger_event", value))
+	ctx = context.WithValue(ctx, triggerEvent, value)
+
+	return ctx
+}
+
+// TriggerEvent returns the kind of event that triggered the evaluation.
+//
+// Evaluations not triggered by a webhook event (e.g. 'evaluate' or periodic evaluations) are [TriggerEventUpdate]
+func TriggerEvent(ctx context.Context) string {
+	value, _ := ctx.Value(triggerEvent).(string)
+	if len(value) == 0 {
+		return TriggerEventUpdate
+	}
+
+	return value
+}
+
+// WithProfile sets the name of the configuration profile to apply on top of the configuration file
+func WithProfile(ctx context.Context, value string) context.Context {
+	ctx = slogctx.With(ctx, slog.String("profile", value))
+	ctx = context.WithValue(ctx, profile, value)
+
+	return ctx
+}
+
+// Profile returns the name of the configuration profile to apply on top of the configuration file.
+//
+// Returns an empty string if no profile has been selected.
+func Profile(ctx context.Context) string {
+	value, _ := ctx.Value(profile).(string)
+
+	return value
+}
+
+// WithLocale sets the locale the 'message_key' of action steps are localized to
+func WithLocale(ctx context.Context, value string) context.Context {
+	ctx = slogctx.With(ctx, slog.String("locale", value))
+	ctx = context.WithValue(ctx, locale, value)
+
+	return ctx
+}
+
+// Locale returns the locale set with [WithLocale], or an empty string
+func Locale(ctx context.Context) string {
+	value, _ := ctx.Value(locale).(string)
+
+	return value
+}
+
+// WithCommentFooter sets the rendered 'comment_footer' appended to every comment scm-engine posts
+func WithCommentFooter(ctx context.Context, value string) context.Context {
+	return context.WithValue(ctx, commentFooter, value)
+}
+
+// CommentFooter returns the rendered 'comment_footer' appended to every comment scm-engine posts.
+//
+// Returns an empty string if no footer has been configured.
+func CommentFooter(ctx context.Context) string {
+	value, _ := ctx.Value(commentFooter).(string)
+
+	return value
+}
+
+// WithOnlyTags limits the evaluation to labels and actions with at least one of the [tags]
+func WithOnlyTags(ctx context.Context, tags []string) context.Context {
+	ctx = slogctx.With(ctx, slog.Any("only_tags", tags))
+	ctx = context.WithValue(ctx, onlyTags, tags)
+
+	return ctx
+}
+
+// OnlyTags returns the tags the evaluation is limited to, or nil if all labels and actions should be evaluated
+func OnlyTags(ctx context.Context) []string {
+	value, _ := ctx.Value(onlyTags).([]string)
+
+	return value
+}
+
+// WithSkipTags excludes labels and actions with any of the [tags] from the evaluation
+func WithSkipTags(ctx context.Context, tags []string) context.Context {
+	ctx = slogctx.With(ctx, slog.Any("skip_tags", tags))
+	ctx = context.WithValue(ctx, skipTags, tags)
+
+	return ctx
+}
+
+// SkipTags returns the tags excluded from the evaluation
+func SkipTags(ctx context.Context) []string {
+	value, _ := ctx.Value(skipTags).([]string)
+
+	return value
+}
+
+// WithAllowPartialData sets if the evaluation should continue when optional Merge Request data can't be loaded
+func WithAllowPartialData(ctx context.Context, value bool) context.Context {
+	ctx = slogctx.With(ctx, slog.Bool("allow_partial_data", value))
+	ctx = context.WithValue(ctx, allowPartialData, value)
+
+	return ctx
+}
+
+// AllowPartialData returns if the evaluation should continue when optional Merge Request data can't be loaded
+func AllowPartialData(ctx context.Context) bool {
+	value, _ := ctx.Value(allowPartialData).(bool)
+
+	return value
 }
 
 func ShouldUpdatePipeline(ctx context.Context) (bool, string) {
 	shouldUpdatePipeline := ctx.Value(updatePipeline).(bool)         //nolint:forcetypeassert
 	shouldUpdatePipelineURL := ctx.Value(updatePipelineURL).(string) //nolint:forcetypeassert
 
+	// Updating the pipeline status is a change too
+	if IsReadOnly(ctx) {
+		shouldUpdatePipeline = false
+	}
+
 	return shouldUpdatePipeline, shouldUpdatePipelineURL
 }
 
@@ -163,3 +407,63 @@ func MergeRequestIDUint(ctx context.Context) uint64 {
 
 	return number
 }
+
+// WithTriggerOnChanges limits "merge_request" update events to those changing at least one of the [fields]
+func WithTriggerOnChanges(ctx context.Context, fields []string) context.Context {
+	ctx = slogctx.With(ctx, slog.Any("trigger_on_changes", fields))
+	ctx = context.WithValue(ctx, triggerOnChanges, fields)
+
+	return ctx
+}
+
+// TriggerOnChanges returns the Merge Request fields that must change for an update event to be evaluated,
+// or nil if all update events should be evaluated
+func TriggerOnChanges(ctx context.Context) []string {
+	value, _ := ctx.Value(triggerOnChanges).([]string)
+
+	return value
+}
+
+// WithEvaluateArchivedProjects sets if webhook events for archived (read-only) projects should still be evaluated
+func WithEvaluateArchivedProjects(ctx context.Context, value bool) context.Context {
+	ctx = slogctx.With(ctx, slog.Bool("evaluate_archived_projects", value))
+	ctx = context.WithValue(ctx, evaluateArchivedProjects, value)
+
+	return ctx
+}
+
+// EvaluateArchivedProjects returns whether webhook events for archived projects should still be evaluated,
+// rather than skipped
+func EvaluateArchivedProjects(ctx context.Context) bool {
+	value, _ := ctx.Value(evaluateArchivedProjects).(bool)
+
+	return value
+}
+
+// WithReplayFailedActions sets if only the actions that failed during the previous evaluation run of the
+// commit should be applied
+func WithReplayFailedActions(ctx context.Context, value bool) context.Context {
+	ctx = slogctx.With(ctx, slog.Bool("replay_failed_actions", value))
+	ctx = context.WithValue(ctx, replayFailedActions, value)
+
+	return ctx
+}
+
+// ReplayFailedActions returns if only the actions that failed during the previous evaluation run should be applied
+func ReplayFailedActions(ctx context.Context) bool {
+	value, _ := ctx.Value(replayFailedActions).(bool)
+
+	return value
+}
+
+// WithRequestID sets the ID of the webhook request being handled
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestID, id)
+}
+
+// RequestID returns the ID of the webhook request being handled, or an empty string outside of a request
+func RequestID(ctx context.Context) string {
+	value, _ := ctx.Value(requestID).(string)
+
+	return value
+}
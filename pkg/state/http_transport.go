@@ -0,0 +1,21 @@
+package state
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithHTTPTransport sets the [http.RoundTripper] sending every API request (example: through a proxy, or a stub in tests).
+//
+// The scm-engine transports (headers, rate limits, read-only mode, ...) wrap it, so requests are also routed through
+// it in tests
+func WithHTTPTransport(ctx context.Context, transport http.RoundTripper) context.Context {
+	return context.WithValue(ctx, httpTransport, transport)
+}
+
+// HTTPTransport returns the transport sending every API request, or nil for [http.DefaultTransport], see [WithHTTPTransport]
+func HTTPTransport(ctx context.Context) http.RoundTripper {
+	transport, _ := ctx.Value(httpTransport).(http.RoundTripper)
+
+	return transport
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMissingContext is returned when required values are missing from the context
+var ErrMissingContext = errors.New("required context is missing")
+
+func ProjectIDOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, projectID)
+}
+
+func EvaluationIDOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, evaluationID)
+}
+
+func CommitSHAOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, commitSha)
+}
+
+func MergeRequestIDOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, mergeRequestID)
+}
+
+func ConfigFilePathOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, configFilePath)
+}
+
+func BaseURLOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, baseURL)
+}
+
+func TokenOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, token)
+}
+
+func ProviderOk(ctx context.Context) (string, bool) {
+	return stringOk(ctx, provider)
+}
+
+// RequireMergeRequestContext asserts that the context has everything needed to evaluate a Merge Request,
+// so we can fail fast instead of passing empty strings into API calls
+func RequireMergeRequestContext(ctx context.Context) error {
+	checks := []struct {
+		name string
+		fn   func(context.Context) (string, bool)
+	}{
+		{name: "provider", fn: ProviderOk},
+		{name: "project_id", fn: ProjectIDOk},
+		{name: "merge_request_id", fn: MergeRequestIDOk},
+		{name: "git_commit_sha", fn: CommitSHAOk},
+		{name: "config_file_path", fn: ConfigFilePathOk},
+	}
+
+	var missing []string
+
+	for _, check := range checks {
+		if _, ok := check.fn(ctx); !ok {
+			missing = append(missing, check.name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingContext, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// stringOk returns the string value for [key] and whether it was set to a non-empty value
+func stringOk(ctx context.Context, key contextKey) (string, bool) {
+	value, ok := ctx.Value(key).(string)
+
+	return value, ok && len(value) > 0
+}
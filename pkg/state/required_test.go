@@ -0,0 +1,41 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitSHAOk(t *testing.T) {
+	t.Parallel()
+
+	_, ok := state.CommitSHAOk(context.Background())
+	require.False(t, ok, "unset value must not be ok")
+
+	_, ok = state.CommitSHAOk(state.WithCommitSHA(context.Background(), ""))
+	require.False(t, ok, "empty value must not be ok")
+
+	sha, ok := state.CommitSHAOk(state.WithCommitSHA(context.Background(), "abc123"))
+	require.True(t, ok)
+	require.Equal(t, "abc123", sha)
+}
+
+func TestRequireMergeRequestContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = state.WithProvider(ctx, "gitlab")
+	ctx = state.WithProjectID(ctx, "jippi/scm-engine")
+	ctx = state.WithConfigFilePath(ctx, ".scm-engine.yml")
+
+	err := state.RequireMergeRequestContext(ctx)
+	require.ErrorIs(t, err, state.ErrMissingContext)
+	require.ErrorContains(t, err, "merge_request_id, git_commit_sha")
+
+	ctx = state.WithMergeRequestID(ctx, "1")
+	ctx = state.WithCommitSHA(ctx, "abc123")
+
+	require.NoError(t, state.RequireMergeRequestContext(ctx))
+}
@@ -0,0 +1,29 @@
+package state
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultResponseCacheTTL is how long cached API responses are used by default, see [WithResponseCache]
+const DefaultResponseCacheTTL = time.Hour
+
+type responseCacheValue struct {
+	dir string
+	ttl time.Duration
+}
+
+// WithResponseCache caches the API responses on disk in [dir] for [ttl], so reruns of a batch evaluation
+// don't fetch the same data again.
+//
+// An empty [dir] disables the cache
+func WithResponseCache(ctx context.Context, dir string, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, responseCache, responseCacheValue{dir: dir, ttl: ttl})
+}
+
+// ResponseCache returns the directory and TTL of the API response cache, or false if the cache is disabled
+func ResponseCache(ctx context.Context) (string, time.Duration, bool) {
+	value, _ := ctx.Value(responseCache).(responseCacheValue)
+
+	return value.dir, value.ttl, len(value.dir) > 0
+}
@@ -0,0 +1,134 @@
+package stdlib
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	// Embed the timezone database, since the container image might not have one
+	_ "time/tzdata"
+
+	"github.com/expr-lang/expr"
+)
+
+// BusinessHours are the weekly time windows considered business hours by
+// the 'is_business_hours()' and 'business_days_since()' script functions
+type BusinessHours struct {
+	// The timezone the windows are in
+	Location *time.Location
+
+	// The windows of business hours; a day with at least one window is a business day
+	Windows []BusinessHoursWindow
+}
+
+// BusinessHoursWindow is a daily time window, on the given days of the week
+type BusinessHoursWindow struct {
+	Days []time.Weekday
+
+	// Start (inclusive) and end (exclusive) of the window, as the wall clock time since midnight
+	Start time.Duration
+	End   time.Duration
+}
+
+// DefaultBusinessHours is 09:00 to 17:00 UTC, Monday to Friday
+var DefaultBusinessHours = BusinessHours{
+	Location: time.UTC,
+	Windows: []BusinessHoursWindow{
+		{
+			Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Start: 9 * time.Hour,
+			End:   17 * time.Hour,
+		},
+	},
+}
+
+type businessHoursKey struct{}
+
+// WithBusinessHours sets the business hours used by the script functions evaluated with [ctx]
+func WithBusinessHours(ctx context.Context, businessHours BusinessHours) context.Context {
+	return context.WithValue(ctx, businessHoursKey{}, businessHours)
+}
+
+// BusinessHoursFromContext returns the business hours set with [WithBusinessHours], or [DefaultBusinessHours]
+func BusinessHoursFromContext(ctx context.Context) BusinessHours {
+	if ctx == nil {
+		return DefaultBusinessHours
+	}
+
+	businessHours, ok := ctx.Value(businessHoursKey{}).(BusinessHours)
+	if !ok {
+		return DefaultBusinessHours
+	}
+
+	return businessHours
+}
+
+// Contains reports whether [t] is within one of the windows
+func (b BusinessHours) Contains(t time.Time) bool {
+	t = t.In(b.Location)
+
+	// Use the wall clock, so windows stay the same across daylight saving time changes
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	for _, window := range b.Windows {
+		if slices.Contains(window.Days, t.Weekday()) && sinceMidnight >= window.Start && sinceMidnight < window.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBusinessDay reports whether [day] has at least one window
+func (b BusinessHours) IsBusinessDay(day time.Weekday) bool {
+	for _, window := range b.Windows {
+		if slices.Contains(window.Days, day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BusinessDaysBetween counts the business days after the date of [from], up to and including the date of [to].
+//
+// For example, from a Friday to the following Monday is 1 business day (with the default business hours)
+func (b BusinessHours) BusinessDaysBetween(from, to time.Time) int {
+	// Step through calendar dates rather than 24 hour periods, so daylight saving time changes don't skew the count
+	from, to = from.In(b.Location), to.In(b.Location)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, b.Location).AddDate(0, 0, 1)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, b.Location)
+
+	count := 0
+
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if b.IsBusinessDay(day.Weekday()) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// IsBusinessHours returns true if it's currently business hours
+var IsBusinessHours = expr.Function(
+	"is_business_hours",
+	func(args ...any) (any, error) {
+		ctx, _ := args[0].(context.Context)
+
+		return BusinessHoursFromContext(ctx).Contains(time.Now()), nil
+	},
+	new(func(context.Context) bool),
+)
+
+// BusinessDaysSince returns the number of business days since the timestamp
+var BusinessDaysSince = expr.Function(
+	"business_days_since",
+	func(args ...any) (any, error) {
+		ctx, _ := args[0].(context.Context)
+		since := args[1].(time.Time) //nolint:forcetypeassert
+
+		return BusinessHoursFromContext(ctx).BusinessDaysBetween(since, time.Now()), nil
+	},
+	new(func(context.Context, time.Time) int),
+)
@@ -0,0 +1,131 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessHours_Contains(t *testing.T) {
+	t.Parallel()
+
+	copenhagen, err := time.LoadLocation("Europe/Copenhagen")
+	require.NoError(t, err)
+
+	businessHours := stdlib.BusinessHours{
+		Location: copenhagen,
+		Windows:  stdlib.DefaultBusinessHours.Windows,
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "weekday morning", at: time.Date(2024, 3, 6, 9, 0, 0, 0, copenhagen), want: true},
+		{name: "weekday before opening", at: time.Date(2024, 3, 6, 8, 59, 59, 0, copenhagen), want: false},
+		{name: "weekday closing is exclusive", at: time.Date(2024, 3, 6, 17, 0, 0, 0, copenhagen), want: false},
+		{name: "UTC time is converted to the configured timezone", at: time.Date(2024, 3, 8, 15, 30, 0, 0, time.UTC), want: true},
+		{name: "saturday", at: time.Date(2024, 3, 9, 12, 0, 0, 0, copenhagen), want: false},
+		{name: "sunday", at: time.Date(2024, 3, 10, 12, 0, 0, 0, copenhagen), want: false},
+		{name: "UTC time inside business hours before DST", at: time.Date(2024, 3, 29, 8, 30, 0, 0, time.UTC), want: true},      // 09:30 CET
+		{name: "same UTC time outside business hours after DST", at: time.Date(2024, 4, 2, 6, 30, 0, 0, time.UTC), want: false}, // 08:30 CEST
+		{name: "opening after DST", at: time.Date(2024, 4, 2, 7, 0, 0, 0, time.UTC), want: true},                                // 09:00 CEST
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, businessHours.Contains(tt.at))
+		})
+	}
+}
+
+func TestBusinessHours_BusinessDaysBetween(t *testing.T) {
+	t.Parallel()
+
+	copenhagen, err := time.LoadLocation("Europe/Copenhagen")
+	require.NoError(t, err)
+
+	businessHours := stdlib.BusinessHours{
+		Location: copenhagen,
+		Windows:  stdlib.DefaultBusinessHours.Windows,
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want int
+	}{
+		{name: "same day", from: time.Date(2024, 3, 6, 9, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 6, 16, 0, 0, 0, copenhagen), want: 0},
+		{name: "next day", from: time.Date(2024, 3, 6, 16, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 7, 9, 0, 0, 0, copenhagen), want: 1},
+		{name: "friday to monday skips the weekend", from: time.Date(2024, 3, 8, 16, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 11, 9, 0, 0, 0, copenhagen), want: 1},
+		{name: "friday to sunday", from: time.Date(2024, 3, 8, 16, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 10, 23, 0, 0, 0, copenhagen), want: 0},
+		{name: "saturday to monday", from: time.Date(2024, 3, 9, 12, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 11, 0, 0, 0, 0, copenhagen), want: 1},
+		{name: "two weeks", from: time.Date(2024, 3, 4, 12, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 18, 12, 0, 0, 0, copenhagen), want: 10},
+		{name: "across the DST change", from: time.Date(2024, 3, 29, 23, 30, 0, 0, copenhagen), to: time.Date(2024, 4, 1, 0, 30, 0, 0, copenhagen), want: 1},
+		{name: "dates use the configured timezone", from: time.Date(2024, 3, 6, 23, 30, 0, 0, time.UTC), to: time.Date(2024, 3, 7, 12, 0, 0, 0, copenhagen), want: 0},
+		{name: "in the future", from: time.Date(2024, 3, 11, 12, 0, 0, 0, copenhagen), to: time.Date(2024, 3, 6, 12, 0, 0, 0, copenhagen), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, businessHours.BusinessDaysBetween(tt.from, tt.to))
+		})
+	}
+}
+
+func TestBusinessHoursFunctions(t *testing.T) {
+	t.Parallel()
+
+	// Every day, all day, so the outcome doesn't depend on when the test runs
+	always := stdlib.BusinessHours{
+		Location: time.UTC,
+		Windows: []stdlib.BusinessHoursWindow{
+			{
+				Days:  []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+				Start: 0,
+				End:   24 * time.Hour,
+			},
+		},
+	}
+
+	env := map[string]any{
+		"ctx":    stdlib.WithBusinessHours(context.Background(), always),
+		"a_week": time.Now().AddDate(0, 0, -7),
+	}
+
+	tests := []struct {
+		script string
+		want   any
+	}{
+		{script: `is_business_hours()`, want: true},
+		{script: `business_days_since(a_week)`, want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []expr.Option{expr.Env(env)}
+			opts = append(opts, stdlib.Functions...)
+			opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+			program, err := expr.Compile(tt.script, opts...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/jippi/scm-engine/pkg/state"
+)
+
+// maxRotationAssignments is the number of Merge Request rotation assignments [Counters] remembers,
+// the oldest assignments are forgotten first
+const maxRotationAssignments = 10_000
+
+// Counters are named counters shared by every evaluation in the process, used by the
+// 'increment_counter()' and 'next_in_rotation()' script functions.
+//
+// Counters are kept per project, so projects using the same counter name don't share the counter
+type Counters struct {
+	mu     sync.Mutex
+	values map[string]int
+
+	// The member of a rotation assigned to a Merge Request, and the order they were assigned in
+	assignments map[string]string
+	assigned    []string
+}
+
+func NewCounters() *Counters {
+	return &Counters{
+		values:      make(map[string]int),
+		assignments: make(map[string]string),
+	}
+}
+
+// defaultCounters are used when the context has no [Counters], so the counters are shared across evaluations by default
+var defaultCounters = NewCounters()
+
+type countersKey struct{}
+
+// WithCounters sets the counters used by the script functions evaluated with [ctx]
+func WithCounters(ctx context.Context, counters *Counters) context.Context {
+	return context.WithValue(ctx, countersKey{}, counters)
+}
+
+// CountersFromContext returns the counters set with [WithCounters], or the counters shared by the process
+func CountersFromContext(ctx context.Context) *Counters {
+	if ctx == nil {
+		return defaultCounters
+	}
+
+	counters, ok := ctx.Value(countersKey{}).(*Counters)
+	if !ok {
+		return defaultCounters
+	}
+
+	return counters
+}
+
+// counterKey returns the key of the counter [name] for the project being evaluated
+func counterKey(ctx context.Context, name string) string {
+	if ctx == nil {
+		return name
+	}
+
+	provider, _ := state.ProviderOk(ctx)
+	project, _ := state.ProjectIDOk(ctx)
+
+	return provider + ":" + project + "/" + name
+}
+
+// isDryRun returns whether the evaluation must not change anything, false if the evaluation has no dry-run mode at all
+func isDryRun(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	dryRun, _ := state.IsDryRunOk(ctx)
+
+	return dryRun
+}
+
+// Increment atomically increments the counter [name] of the project being evaluated, and returns its new value (starting at 1).
+//
+// In dry-run mode the counter is left unchanged, and the value it would have gotten is returned
+func (c *Counters) Increment(ctx context.Context, name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.increment(ctx, counterKey(ctx, name))
+}
+
+func (c *Counters) increment(ctx context.Context, key string) int {
+	if isDryRun(ctx) {
+		return c.values[key] + 1
+	}
+
+	c.values[key]++
+
+	return c.values[key]
+}
+
+// NextInRotation returns the next of the [members], taking turns across evaluations using the counter [name].
+//
+// The member is remembered for the Merge Request being evaluated, so evaluating the same Merge Request again returns the
+// same member (as long as they're still in [members]) without moving the rotation forward
+func (c *Counters) NextInRotation(ctx context.Context, name string, members []string) (string, error) {
+	if len(members) == 0 {
+		return "", errors.New("next_in_rotation: the rotation must have at least one member")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := counterKey(ctx, name)
+
+	var assignmentKey string
+
+	if ctx != nil {
+		if mergeRequestID, ok := state.MergeRequestIDOk(ctx); ok {
+			assignmentKey = key + "!" + mergeRequestID
+		}
+	}
+
+	if member, ok := c.assignments[assignmentKey]; ok && slices.Contains(members, member) {
+		return member, nil
+	}
+
+	member := members[(c.increment(ctx, key)-1)%len(members)]
+
+	if len(assignmentKey) > 0 && !isDryRun(ctx) {
+		c.assign(assignmentKey, member)
+	}
+
+	return member, nil
+}
+
+// assign remembers the rotation [member] for the Merge Request [key], forgetting the oldest assignments once
+// [maxRotationAssignments] is reached
+func (c *Counters) assign(key, member string) {
+	if _, ok := c.assignments[key]; !ok {
+		c.assigned = append(c.assigned, key)
+	}
+
+	c.assignments[key] = member
+
+	for len(c.assigned) > maxRotationAssignments {
+		delete(c.assignments, c.assigned[0])
+		c.assigned = c.assigned[1:]
+	}
+}
+
+// IncrementCounter atomically increments the named counter, and returns its new value
+var IncrementCounter = expr.Function(
+	"increment_counter",
+	func(args ...any) (any, error) {
+		ctx, _ := args[0].(context.Context)
+		name := args[1].(string) //nolint:forcetypeassert
+
+		return CountersFromContext(ctx).Increment(ctx, name), nil
+	},
+	new(func(context.Context, string) int),
+)
+
+// NextInRotation returns the next member of the named rotation (round-robin)
+var NextInRotation = expr.Function(
+	"next_in_rotation",
+	func(args ...any) (any, error) {
+		ctx, _ := args[0].(context.Context)
+		name := args[1].(string) //nolint:forcetypeassert
+
+		members, err := ToStringSlice(args[2])
+		if err != nil {
+			return nil, err
+		}
+
+		return CountersFromContext(ctx).NextInRotation(ctx, name, members)
+	},
+	new(func(context.Context, string, []any) string),
+	new(func(context.Context, string, []string) string),
+)
@@ -0,0 +1,141 @@
+package stdlib_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/state"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextInRotation(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"ctx":  stdlib.WithCounters(context.Background(), stdlib.NewCounters()),
+		"team": []string{"alice", "bob", "carol"},
+	}
+
+	run := func(t *testing.T, script string) any {
+		t.Helper()
+
+		opts := []expr.Option{expr.Env(env)}
+		opts = append(opts, stdlib.Functions...)
+		opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+		program, err := expr.Compile(script, opts...)
+		require.NoError(t, err)
+
+		output, err := expr.Run(program, env)
+		require.NoError(t, err)
+
+		return output
+	}
+
+	// Every evaluation gets the next member, and the rotation wraps around
+	var got []any
+	for range 7 {
+		got = append(got, run(t, `next_in_rotation("backend", team)`))
+	}
+
+	require.Equal(t, []any{"alice", "bob", "carol", "alice", "bob", "carol", "alice"}, got)
+
+	// Rotations with another name take turns on their own
+	require.Equal(t, "frank", run(t, `next_in_rotation("frontend", ["frank", "grace"])`))
+	require.Equal(t, "grace", run(t, `next_in_rotation("frontend", ["frank", "grace"])`))
+	require.Equal(t, "bob", run(t, `next_in_rotation("backend", team)`))
+
+	require.Equal(t, 1, run(t, `increment_counter("deploys")`))
+	require.Equal(t, 2, run(t, `increment_counter("deploys")`))
+}
+
+func TestCounters_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	counters := stdlib.NewCounters()
+	members := []string{"alice", "bob", "carol"}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		got = map[string]int{}
+	)
+
+	// Concurrent evaluations distribute the members evenly
+	for range 300 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			member, err := counters.NextInRotation(context.Background(), "backend", members)
+			require.NoError(t, err)
+
+			mu.Lock()
+			got[member]++
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, map[string]int{"alice": 100, "bob": 100, "carol": 100}, got)
+
+	_, err := counters.NextInRotation(context.Background(), "empty", nil)
+	require.ErrorContains(t, err, "the rotation must have at least one member")
+}
+
+func TestCounters_PerProjectAndMergeRequest(t *testing.T) {
+	t.Parallel()
+
+	counters := stdlib.NewCounters()
+	members := []string{"alice", "bob", "carol"}
+
+	evaluation := func(project, mergeRequestID string, dryRun bool) context.Context {
+		ctx := context.Background()
+		ctx = state.WithProvider(ctx, "gitlab")
+		ctx = state.WithProjectID(ctx, project)
+		ctx = state.WithMergeRequestID(ctx, mergeRequestID)
+		ctx = state.WithDryRun(ctx, dryRun)
+
+		return ctx
+	}
+
+	next := func(ctx context.Context) string {
+		t.Helper()
+
+		member, err := counters.NextInRotation(ctx, "backend", members)
+		require.NoError(t, err)
+
+		return member
+	}
+
+	// Evaluating the same Merge Request again keeps its member, and doesn't move the rotation forward
+	require.Equal(t, "alice", next(evaluation("acme/api", "1", false)))
+	require.Equal(t, "alice", next(evaluation("acme/api", "1", false)))
+	require.Equal(t, "bob", next(evaluation("acme/api", "2", false)))
+	require.Equal(t, "bob", next(evaluation("acme/api", "2", false)))
+
+	// Other projects take turns on their own, even if they use the same rotation name
+	require.Equal(t, "alice", next(evaluation("acme/web", "1", false)))
+
+	// Dry runs show the next member, without moving the rotation forward or remembering the member
+	require.Equal(t, "carol", next(evaluation("acme/api", "3", true)))
+	require.Equal(t, "carol", next(evaluation("acme/api", "4", true)))
+	require.Equal(t, "carol", next(evaluation("acme/api", "4", false)))
+
+	// A member that left the rotation is replaced by the next member
+	member, err := counters.NextInRotation(evaluation("acme/api", "1", false), "backend", []string{"bob", "carol"})
+	require.NoError(t, err)
+	require.Equal(t, "carol", member)
+
+	// Counters are per project too, and unchanged in dry runs
+	require.Equal(t, 1, counters.Increment(evaluation("acme/api", "1", false), "deploys"))
+	require.Equal(t, 2, counters.Increment(evaluation("acme/api", "1", true), "deploys"))
+	require.Equal(t, 1, counters.Increment(evaluation("acme/web", "1", false), "deploys"))
+	require.Equal(t, 2, counters.Increment(evaluation("acme/api", "2", false), "deploys"))
+}
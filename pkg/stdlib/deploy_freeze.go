@@ -0,0 +1,104 @@
+package stdlib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/jippi/scm-engine/pkg/cron"
+)
+
+// DeployFreeze is a period in which deployments (and thus merges) are frozen, used by the 'in_deploy_freeze()'
+// script function. The period starts and ends on cron schedules, like the GitLab deploy freezes
+type DeployFreeze struct {
+	Start *cron.Schedule
+	End   *cron.Schedule
+
+	// The timezone of the cron schedules
+	Location *time.Location
+}
+
+// NewDeployFreeze parses the [start] and [end] cron expressions of a deploy freeze in the IANA [timezone] (UTC if empty)
+func NewDeployFreeze(start, end, timezone string) (DeployFreeze, error) {
+	var (
+		freeze = DeployFreeze{Location: time.UTC}
+		err    error
+	)
+
+	if freeze.Start, err = cron.Parse(start); err != nil {
+		return freeze, fmt.Errorf("invalid freeze start %q: %w", start, err)
+	}
+
+	if freeze.End, err = cron.Parse(end); err != nil {
+		return freeze, fmt.Errorf("invalid freeze end %q: %w", end, err)
+	}
+
+	if len(timezone) > 0 {
+		if freeze.Location, err = time.LoadLocation(timezone); err != nil {
+			return freeze, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+
+	return freeze, nil
+}
+
+// Active returns true if [now] is within the freeze, which is the case when the freeze ends before it starts again
+func (f DeployFreeze) Active(now time.Time) bool {
+	now = now.In(f.Location)
+
+	start, err := f.Start.Next(now)
+	if err != nil {
+		return false
+	}
+
+	end, err := f.End.Next(now)
+	if err != nil {
+		return false
+	}
+
+	return end.Before(start)
+}
+
+// DeployFreezes are the deploy freezes of the project, frozen when any of them is active
+type DeployFreezes []DeployFreeze
+
+// Active returns true if [now] is within any of the freezes
+func (f DeployFreezes) Active(now time.Time) bool {
+	for _, freeze := range f {
+		if freeze.Active(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type deployFreezesKey struct{}
+
+// WithDeployFreezes sets the deploy freezes used by the script functions evaluated with [ctx]
+func WithDeployFreezes(ctx context.Context, freezes DeployFreezes) context.Context {
+	return context.WithValue(ctx, deployFreezesKey{}, freezes)
+}
+
+// DeployFreezesFromContext returns the deploy freezes set with [WithDeployFreezes], or none
+func DeployFreezesFromContext(ctx context.Context) DeployFreezes {
+	if ctx == nil {
+		return nil
+	}
+
+	freezes, _ := ctx.Value(deployFreezesKey{}).(DeployFreezes)
+
+	return freezes
+}
+
+// InDeployFreeze returns true if it's currently within a deploy freeze
+var InDeployFreeze = expr.Function(
+	"in_deploy_freeze",
+	func(args ...any) (any, error) {
+		ctx, _ := args[0].(context.Context)
+
+		return DeployFreezesFromContext(ctx).Active(time.Now()), nil
+	},
+	new(func(context.Context) bool),
+)
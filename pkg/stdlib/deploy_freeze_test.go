@@ -0,0 +1,85 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/patcher"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployFreeze_Active(t *testing.T) {
+	t.Parallel()
+
+	// Friday 23:00 to Monday 07:00
+	copenhagen, err := stdlib.NewDeployFreeze("0 23 * * 5", "0 7 * * 1", "Europe/Copenhagen")
+	require.NoError(t, err)
+
+	utc, err := stdlib.NewDeployFreeze("0 23 * * 5", "0 7 * * 1", "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		freeze stdlib.DeployFreeze
+		now    time.Time
+		want   bool
+	}{
+		{name: "saturday", freeze: copenhagen, now: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "monday morning", freeze: copenhagen, now: time.Date(2024, 6, 17, 4, 0, 0, 0, time.UTC), want: true},
+		{name: "monday after the freeze", freeze: copenhagen, now: time.Date(2024, 6, 17, 5, 0, 0, 0, time.UTC), want: false},
+		{name: "wednesday", freeze: copenhagen, now: time.Date(2024, 6, 12, 12, 0, 0, 0, time.UTC), want: false},
+		// 21:30 UTC is 23:30 in Copenhagen (summer time), so only the Copenhagen freeze has started
+		{name: "friday evening in copenhagen", freeze: copenhagen, now: time.Date(2024, 6, 14, 21, 30, 0, 0, time.UTC), want: true},
+		{name: "friday evening in utc", freeze: utc, now: time.Date(2024, 6, 14, 21, 30, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, tt.freeze.Active(tt.now))
+		})
+	}
+
+	require.True(t, stdlib.DeployFreezes{utc, copenhagen}.Active(time.Date(2024, 6, 14, 21, 30, 0, 0, time.UTC)))
+	require.False(t, stdlib.DeployFreezes{}.Active(time.Now()))
+
+	_, err = stdlib.NewDeployFreeze("0 23 * * 5", "0 7 * * 1", "Mars/Olympus")
+	require.ErrorContains(t, err, `invalid timezone "Mars/Olympus"`)
+}
+
+func TestInDeployFreeze(t *testing.T) {
+	t.Parallel()
+
+	// Starting on new year, and ending every minute, is (nearly) always frozen
+	frozen, err := stdlib.NewDeployFreeze("0 0 1 1 *", "* * * * *", "")
+	require.NoError(t, err)
+
+	for name, tt := range map[string]struct {
+		freezes stdlib.DeployFreezes
+		want    bool
+	}{
+		"in freeze":     {freezes: stdlib.DeployFreezes{frozen}, want: true},
+		"out of freeze": {freezes: nil, want: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			env := map[string]any{"ctx": stdlib.WithDeployFreezes(context.Background(), tt.freezes)}
+
+			opts := []expr.Option{expr.Env(env)}
+			opts = append(opts, stdlib.Functions...)
+			opts = append(opts, expr.Patch(patcher.WithContext{Name: "ctx"}))
+
+			program, err := expr.Compile(`in_deploy_freeze()`, opts...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
@@ -2,10 +2,14 @@ package stdlib
 
 import (
 	"cmp"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/expr-lang/expr"
@@ -87,3 +91,265 @@ var LimitPathDepthTo = expr.Function(
 	},
 	new(func(string, int) string), // (string, int) => string
 )
+
+// listSignatures is the set of type signatures accepted by the list/set helpers
+// that takes two lists as input, since lists can be either []string (e.g. 'uniq' output)
+// or []any (e.g. 'map' output)
+func listSignatures[T any]() []any {
+	return []any{
+		new(func([]any, []any) T),
+		new(func([]string, []string) T),
+		new(func([]any, []string) T),
+		new(func([]string, []any) T),
+	}
+}
+
+// ContainsAny returns true if any of the items in the second list exists in the first list
+var ContainsAny = expr.Function(
+	"contains_any",
+	func(args ...any) (any, error) {
+		list, err := ToStringSlice(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := ToStringSlice(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if slices.Contains(list, item) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	},
+	listSignatures[bool]()...,
+)
+
+// Intersection returns the (unique) list of items that exists in both lists,
+// in the order they appear in the first list
+var Intersection = expr.Function(
+	"intersection",
+	func(args ...any) (any, error) {
+		left, err := ToStringSlice(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := ToStringSlice(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		result := []string{}
+
+		for _, item := range UniqueSlice(left) {
+			if slices.Contains(right, item) {
+				result = append(result, item)
+			}
+		}
+
+		return result, nil
+	},
+	listSignatures[[]string]()...,
+)
+
+// Difference returns the (unique) list of items in the first list that do not
+// exist in the second list, in the order they appear in the first list
+var Difference = expr.Function(
+	"difference",
+	func(args ...any) (any, error) {
+		left, err := ToStringSlice(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := ToStringSlice(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		result := []string{}
+
+		for _, item := range UniqueSlice(left) {
+			if !slices.Contains(right, item) {
+				result = append(result, item)
+			}
+		}
+
+		return result, nil
+	},
+	listSignatures[[]string]()...,
+)
+
+// Unique removes duplicated values from a list of strings or interface{},
+// but unlike [Uniq], retains the original order of the list
+var Unique = expr.Function(
+	"unique",
+	func(args ...any) (any, error) {
+		list, err := ToStringSlice(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return UniqueSlice(list), nil
+	},
+	new(func([]any) []string),    // []any -> []string (when using map() that always return []any)
+	new(func([]string) []string), // []string -> []string
+)
+
+// compiledRegexps caches the patterns compiled by [RegexNamed], since scripts are evaluated for every Merge Request
+var compiledRegexps sync.Map // Zero value is empty and ready for use
+
+// RegexNamed returns the named capture groups of the first match of the pattern in the text, as a map
+// from group name to the captured text (example: 'regex_named("(?P<ticket>[A-Z]+-[0-9]+)", merge_request.title).ticket').
+//
+// Groups that didn't capture anything, or every group if the pattern doesn't match, are nil
+var RegexNamed = expr.Function(
+	"regex_named",
+	func(args ...any) (any, error) {
+		pattern := args[0].(string) //nolint:forcetypeassert
+		text := args[1].(string)    //nolint:forcetypeassert
+
+		cached, ok := compiledRegexps.Load(pattern)
+		if !ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("regex_named() pattern is not a valid regular expression: %w", err)
+			}
+
+			cached, _ = compiledRegexps.LoadOrStore(pattern, re)
+		}
+
+		re := cached.(*regexp.Regexp) //nolint:forcetypeassert
+		groups := map[string]any{}
+
+		for _, name := range re.SubexpNames() {
+			if len(name) > 0 {
+				groups[name] = nil
+			}
+		}
+
+		match := re.FindStringSubmatchIndex(text)
+		if match == nil {
+			return groups, nil
+		}
+
+		for idx, name := range re.SubexpNames() {
+			if len(name) == 0 || match[2*idx] < 0 {
+				continue
+			}
+
+			groups[name] = text[match[2*idx]:match[2*idx+1]]
+		}
+
+		return groups, nil
+	},
+	new(func(string, string) map[string]any), // (pattern, text) => map[string]any
+)
+
+// Coalesce returns the first argument that isn't nil, or nil if all of them are
+var Coalesce = expr.Function(
+	"coalesce",
+	func(args ...any) (any, error) {
+		if len(args) == 0 {
+			return nil, errors.New("coalesce() requires at least one argument")
+		}
+
+		for _, arg := range args {
+			if !isNil(arg) {
+				return arg, nil
+			}
+		}
+
+		return nil, nil
+	},
+)
+
+// Get returns the value of the key in a map (or index in a list), or the default value if it's missing or nil.
+//
+// The value must be of the same kind as a non-nil default value, so scripts can rely on the type they get back
+var Get = expr.Function(
+	"get",
+	func(args ...any) (any, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("get() requires 3 arguments (map, key, default), got %d", len(args))
+		}
+
+		value, err := lookup(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		fallback := args[2]
+
+		if isNil(value) {
+			return fallback, nil
+		}
+
+		if !isNil(fallback) && reflect.TypeOf(value).Kind() != reflect.TypeOf(fallback).Kind() {
+			return nil, fmt.Errorf("get() key %v is a [%T], but the default value is a [%T]", args[1], value, fallback)
+		}
+
+		return value, nil
+	},
+)
+
+// lookup returns the value of [key] in the map (or list) [container], or nil if it doesn't exist
+func lookup(container, key any) (any, error) {
+	if isNil(container) {
+		return nil, nil
+	}
+
+	ref := reflect.ValueOf(container)
+
+	switch ref.Kind() {
+	case reflect.Map:
+		keyRef := reflect.ValueOf(key)
+		if !keyRef.IsValid() || !keyRef.Type().AssignableTo(ref.Type().Key()) {
+			return nil, fmt.Errorf("get() key must be a [%s], got %T", ref.Type().Key(), key)
+		}
+
+		value := ref.MapIndex(keyRef)
+		if !value.IsValid() {
+			return nil, nil
+		}
+
+		return value.Interface(), nil
+
+	case reflect.Slice, reflect.Array:
+		index, ok := key.(int)
+		if !ok {
+			return nil, fmt.Errorf("get() index into a list must be an [int], got %T", key)
+		}
+
+		if index < 0 || index >= ref.Len() {
+			return nil, nil
+		}
+
+		return ref.Index(index).Interface(), nil
+
+	default:
+		return nil, fmt.Errorf("get() requires a map or a list, got %T", container)
+	}
+}
+
+// isNil reports whether the value is nil, including typed nil pointers (e.g. an unset milestone)
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+
+	ref := reflect.ValueOf(value)
+
+	switch ref.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return ref.IsNil()
+
+	default:
+		return false
+	}
+}
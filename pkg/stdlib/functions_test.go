@@ -0,0 +1,271 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/jippi/scm-engine/pkg/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFunctions(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"changed_files":   []string{"docs/index.md", "pkg/auth/token.go", "pkg/auth/token.go", "README.md"},
+		"protected_paths": []any{"pkg/auth/token.go", "pkg/billing/invoice.go"},
+		"labels":          []any{"bug", "security", "bug"},
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   any
+	}{
+		{
+			name:   "contains_any: match",
+			script: `contains_any(labels, ["security", "urgent"])`,
+			want:   true,
+		},
+		{
+			name:   "contains_any: no match",
+			script: `contains_any(labels, ["urgent"])`,
+			want:   false,
+		},
+		{
+			name:   "intersection",
+			script: `intersection(changed_files, protected_paths)`,
+			want:   []string{"pkg/auth/token.go"},
+		},
+		{
+			name:   "intersection with map() output",
+			script: `intersection(map(changed_files, #), ["README.md"])`,
+			want:   []string{"README.md"},
+		},
+		{
+			name:   "difference",
+			script: `difference(changed_files, protected_paths)`,
+			want:   []string{"docs/index.md", "README.md"},
+		},
+		{
+			name:   "unique retains order",
+			script: `unique(labels)`,
+			want:   []string{"bug", "security"},
+		},
+		{
+			name:   "label rule: changed files intersect protected paths",
+			script: `len(intersection(changed_files, protected_paths)) > 0`,
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []expr.Option{expr.Env(env)}
+			opts = append(opts, stdlib.Functions...)
+
+			program, err := expr.Compile(tt.script, opts...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
+
+func TestDefaultValueFunctions(t *testing.T) {
+	t.Parallel()
+
+	type milestone struct {
+		Title string
+	}
+
+	type mergeRequest struct {
+		Milestone *milestone
+	}
+
+	env := map[string]any{
+		"with_milestone":    mergeRequest{Milestone: &milestone{Title: "v1.0"}},
+		"without_milestone": mergeRequest{},
+		"vars": map[string]any{
+			"team":  "platform",
+			"owner": nil,
+			"limits": map[string]any{
+				"files": 10,
+			},
+		},
+		"reviewers": []string{"alice", "bob"},
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		want    any
+		wantErr string
+	}{
+		{
+			name:   "coalesce: first non-nil",
+			script: `coalesce(nil, "a", "b")`,
+			want:   "a",
+		},
+		{
+			name:   "coalesce: all nil",
+			script: `coalesce(nil, nil)`,
+			want:   nil,
+		},
+		{
+			name:   "coalesce: set optional field",
+			script: `coalesce(with_milestone.Milestone?.Title, "none")`,
+			want:   "v1.0",
+		},
+		{
+			name:   "coalesce: unset optional field",
+			script: `coalesce(without_milestone.Milestone?.Title, "none")`,
+			want:   "none",
+		},
+		{
+			name:   "coalesce: typed nil pointer",
+			script: `coalesce(without_milestone.Milestone, with_milestone.Milestone).Title`,
+			want:   "v1.0",
+		},
+		{
+			name:   "get: existing key",
+			script: `get(vars, "team", "unknown")`,
+			want:   "platform",
+		},
+		{
+			name:   "get: missing key",
+			script: `get(vars, "region", "eu")`,
+			want:   "eu",
+		},
+		{
+			name:   "get: nil value",
+			script: `get(vars, "owner", "nobody")`,
+			want:   "nobody",
+		},
+		{
+			name:   "get: nested map",
+			script: `get(get(vars, "limits", {}), "files", 0)`,
+			want:   10,
+		},
+		{
+			name:   "get: missing nested map",
+			script: `get(get(vars, "quotas", {}), "files", 5)`,
+			want:   5,
+		},
+		{
+			name:   "get: list index",
+			script: `get(reviewers, 1, "nobody")`,
+			want:   "bob",
+		},
+		{
+			name:   "get: list index out of range",
+			script: `get(reviewers, 5, "nobody")`,
+			want:   "nobody",
+		},
+		{
+			name:    "get: value and default value types differ",
+			script:  `get(vars, "team", 0)`,
+			wantErr: "get() key team is a [string], but the default value is a [int]",
+		},
+		{
+			name:    "get: not a map",
+			script:  `get("team", "a", 0)`,
+			wantErr: "get() requires a map or a list, got string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []expr.Option{expr.Env(env)}
+			opts = append(opts, stdlib.Functions...)
+
+			program, err := expr.Compile(tt.script, opts...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
+
+func TestRegexNamed(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"title":  "feat(JIRA-123): add login page",
+		"branch": "fix/PROJ-7-broken-build",
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		want    any
+		wantErr string
+	}{
+		{
+			name:   "named groups",
+			script: `regex_named("^(?P<type>[a-z]+)\\((?P<ticket>[A-Z]+-[0-9]+)\\)", title)`,
+			want:   map[string]any{"type": "feat", "ticket": "JIRA-123"},
+		},
+		{
+			name:   "member access",
+			script: `regex_named("(?P<ticket>[A-Z]+-[0-9]+)", branch).ticket`,
+			want:   "PROJ-7",
+		},
+		{
+			name:   "optional group without a match",
+			script: `regex_named("^(?P<type>[a-z]+)(?:\\[(?P<scope>[a-z]+)\\])?", title)`,
+			want:   map[string]any{"type": "feat", "scope": nil},
+		},
+		{
+			name:   "no match",
+			script: `regex_named("(?P<ticket>[A-Z]+-[0-9]+)", "no ticket here")`,
+			want:   map[string]any{"ticket": nil},
+		},
+		{
+			name:   "no match with default value",
+			script: `coalesce(regex_named("(?P<ticket>[A-Z]+-[0-9]+)", "no ticket here").ticket, "none")`,
+			want:   "none",
+		},
+		{
+			name:    "invalid pattern",
+			script:  `regex_named("(?P<ticket>", title)`,
+			wantErr: "regex_named() pattern is not a valid regular expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []expr.Option{expr.Env(env)}
+			opts = append(opts, stdlib.Functions...)
+
+			program, err := expr.Compile(tt.script, opts...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
@@ -24,3 +24,45 @@ func ToDuration(input any) time.Duration {
 		panic(fmt.Errorf("unsupported input type for duration: %T", val))
 	}
 }
+
+// ToStringSlice converts a list of strings or interface{} into a list of strings
+func ToStringSlice(input any) ([]string, error) {
+	switch elements := input.(type) {
+	case []string:
+		return elements, nil
+
+	case []any:
+		result := make([]string, 0, len(elements))
+
+		for _, element := range elements {
+			str, ok := element.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid list element, must be a [string], got %T", element)
+			}
+
+			result = append(result, str)
+		}
+
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("invalid input, must be an array of [string] or [interface], got %T", input)
+	}
+}
+
+// UniqueSlice removes duplicated values from the slice while retaining the original order
+func UniqueSlice[T comparable](in []T) []T {
+	seen := make(map[T]struct{}, len(in))
+	result := make([]T, 0, len(in))
+
+	for _, element := range in {
+		if _, ok := seen[element]; ok {
+			continue
+		}
+
+		seen[element] = struct{}{}
+		result = append(result, element)
+	}
+
+	return result
+}
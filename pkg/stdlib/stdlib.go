@@ -9,6 +9,9 @@ var Functions = []expr.Option{
 	// Replace built-in duration function with one that supports "d" (days) and "w" (weeks)
 	expr.DisableBuiltin("duration"),
 
+	// Replace built-in get function with one that supports a default value
+	expr.DisableBuiltin("get"),
+
 	// Add Expr-lang support for a wider range of "valuers" for custom types, such as
 	//
 	// - "AsString()" interface for custom types wanting to be used as a String (useful for Enum types!)
@@ -18,6 +21,17 @@ var Functions = []expr.Option{
 	Duration,
 	Since,
 
+	// business hours, see [WithBusinessHours]
+	IsBusinessHours,
+	BusinessDaysSince,
+
+	// deploy freezes, see [WithDeployFreezes]
+	InDeployFreeze,
+
+	// shared counters, see [WithCounters]
+	IncrementCounter,
+	NextInRotation,
+
 	// filepath.Dir
 	FilepathDir,
 
@@ -26,4 +40,17 @@ var Functions = []expr.Option{
 
 	// slices.Sort + slices.Compact
 	Uniq,
+
+	// list/set helpers
+	ContainsAny,
+	Difference,
+	Intersection,
+	Unique,
+
+	// regular expression helpers
+	RegexNamed,
+
+	// default value helpers
+	Coalesce,
+	Get,
 }
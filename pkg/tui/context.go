@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/jippi/scm-engine/pkg/redact"
 	"github.com/muesli/termenv"
 	slogmulti "github.com/samber/slog-multi"
 	slogctx "github.com/veqryn/slog-context"
@@ -40,6 +41,7 @@ func NewContext(ctx context.Context, stdout, stderr io.Writer) context.Context {
 						ResolveKey: slogdedup.KeepIfBuiltinKeyConflict,
 					}),
 				).
+				Pipe(redact.NewHandler).
 				Handler(
 					logHandler(stderr),
 				),